@@ -13,6 +13,7 @@ import (
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/api"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/events"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/skills"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/postgres"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/workflows"
@@ -52,6 +53,13 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	signingSecrets, err := signing.ParseSecrets(cfg.EventSigningSecrets)
+	if err != nil {
+		return err
+	}
+	if len(signingSecrets) == 0 {
+		log.Printf("warning: EVENT_SIGNING_SECRETS is not set; unsigned worker events and messages are accepted")
+	}
 	ctx, cancel := notifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
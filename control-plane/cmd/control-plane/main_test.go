@@ -132,6 +132,23 @@ func TestRunConfigLoadFailure(t *testing.T) {
 	}
 }
 
+func TestRunInvalidEventSigningSecrets(t *testing.T) {
+	restore := captureControlPlaneDeps()
+	t.Cleanup(restore)
+
+	loadConfig = func() (config.Config, error) {
+		return config.Config{EventSigningSecrets: "worker"}, nil
+	}
+	newStore = func(_ string) (*postgres.PostgresStore, error) {
+		t.Fatal("store should not be opened with invalid signing secrets")
+		return nil, nil
+	}
+
+	if err := run(); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
 func TestRunStoreInitFailure(t *testing.T) {
 	restore := captureControlPlaneDeps()
 	t.Cleanup(restore)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
@@ -63,6 +64,10 @@ func run() error {
 		}
 		secretsKey = parsed
 	}
+	previousSecretsKeys, err := secrets.ParseKeyList(cfg.LLMSecretsPreviousKeys)
+	if err != nil {
+		return err
+	}
 
 	activities := newActivities(store, llm.Config{
 		Mode:             cfg.LLMMode,
@@ -72,15 +77,19 @@ func run() error {
 		FallbackProvider: cfg.LLMFallbackProvider,
 		FallbackModel:    cfg.LLMFallbackModel,
 		FallbackBaseURL:  cfg.LLMFallbackBaseURL,
+		UtilityProvider:  cfg.LLMUtilityProvider,
+		UtilityModel:     cfg.LLMUtilityModel,
+		UtilityBaseURL:   cfg.LLMUtilityBaseURL,
 		OpenAIAPIKey:     cfg.OpenAIAPIKey,
 		OpenRouterAPIKey: cfg.OpenRouterAPIKey,
 		OpenCodeAPIKey:   cfg.OpenCodeAPIKey,
 		CodexAuthPath:    cfg.CodexAuthPath,
 		CodexHome:        cfg.CodexHome,
-	}, secretsKey, cfg.ControlPlaneURL, cfg.ToolRunnerURL, workflows.WithMemoryConfig(cfg.MemoryMaxResults, cfg.MemoryMaxEntryChars))
+	}, secretsKey, cfg.ControlPlaneURL, cfg.ToolRunnerURL, workflows.WithMemoryConfig(cfg.MemoryMaxResults, cfg.MemoryMaxEntryChars), workflows.WithPreviousSecretsKeys(previousSecretsKeys...), workflows.WithControlPlaneAPIKey(cfg.ControlPlaneAPIKey), workflows.WithEventSigning("worker", cfg.EventSigningSecret), workflows.WithUtilityTimeout(time.Duration(cfg.LLMUtilityTimeoutSeconds)*time.Second))
 
 	w := newWorker(temporalClient, cfg.TemporalTaskQueue, worker.Options{})
 	w.RegisterWorkflow(workflows.RunWorkflow)
+	w.RegisterWorkflow(workflows.AutomationWorkflow)
 	w.RegisterActivity(activities)
 
 	log.Println("Gavryn worker started")
@@ -0,0 +1,441 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/redaction"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+	auditRedacted     = "[redacted]"
+)
+
+type auditAction struct {
+	Action     string
+	TargetType string
+	// TargetParam names the URL parameter holding the target ID when the
+	// handler does not report one.
+	TargetParam string
+}
+
+// auditActions maps every audited "METHOD pattern" to its action. Mutating
+// routes must appear here or in auditExempt.
+var auditActions = map[string]auditAction{
+	"POST /runs":                                            {"run.create", "run", ""},
+	"DELETE /runs/{id}":                                     {"run.delete", "run", "id"},
+	"POST /runs/{id}/resume":                                {"run.resume", "run", "id"},
+	"POST /runs/{id}/cancel":                                {"run.cancel", "run", "id"},
+	"PUT /runs/{id}/workspace/file":                         {"run.file.write", "run", "id"},
+	"DELETE /runs/{id}/workspace/file":                      {"run.file.delete", "run", "id"},
+	"POST /runs/{id}/processes/exec":                        {"process.exec", "run", "id"},
+	"POST /runs/{id}/processes/start":                       {"process.start", "run", "id"},
+	"POST /runs/{id}/processes/{pid}/stop":                  {"process.stop", "process", "pid"},
+	"POST /automation/execute":                              {"automation.execute", "run", ""},
+	"POST /automations":                                     {"automation.create", "automation", ""},
+	"PUT /automations/{id}":                                 {"automation.update", "automation", "id"},
+	"DELETE /automations/{id}":                              {"automation.delete", "automation", "id"},
+	"POST /automations/{id}/inbox/{entryID}/read":           {"automation.inbox.read", "automation", "id"},
+	"POST /automations/{id}/inbox/read-all":                 {"automation.inbox.read_all", "automation", "id"},
+	"POST /automations/{id}/inbox/{entryID}/rerun":          {"automation.inbox.rerun", "automation", "id"},
+	"POST /automations/{id}/inbox/{entryID}/archive":        {"automation.inbox.archive", "automation", "id"},
+	"POST /automations/{id}/inbox/{entryID}/unarchive":      {"automation.inbox.unarchive", "automation", "id"},
+	"POST /automations/{id}/inbox/{entryID}/continue":       {"automation.inbox.continue", "automation", "id"},
+	"POST /automations/process-due":                         {"automation.process_due", "automation", ""},
+	"POST /automations/{id}/run":                            {"automation.run", "automation", "id"},
+	"POST /automations/{id}/dry-run":                        {"automation.dry_run", "automation", "id"},
+	"POST /skills":                                          {"skill.create", "skill", ""},
+	"PUT /skills/{id}":                                      {"skill.update", "skill", "id"},
+	"DELETE /skills/{id}":                                   {"skill.delete", "skill", "id"},
+	"POST /skills/{id}/files":                               {"skill.files.upsert", "skill", "id"},
+	"DELETE /skills/{id}/files":                             {"skill.files.delete", "skill", "id"},
+	"POST /context/folders":                                 {"context.folder.create", "context", ""},
+	"POST /context/files":                                   {"context.file.upload", "context", ""},
+	"DELETE /context/{id}":                                  {"context.delete", "context", "id"},
+	"POST /settings/llm":                                    {"llm_settings.update", "llm_settings", ""},
+	"POST /settings/llm/routes":                             {"llm_route.create", "llm_route", ""},
+	"PUT /settings/llm/routes/{id}":                         {"llm_route.update", "llm_route", "id"},
+	"DELETE /settings/llm/routes/{id}":                      {"llm_route.delete", "llm_route", "id"},
+	"POST /settings/llm/profiles":                           {"llm_profile.create", "llm_profile", ""},
+	"PUT /settings/llm/profiles/{id}":                       {"llm_profile.update", "llm_profile", "id"},
+	"DELETE /settings/llm/profiles/{id}":                    {"llm_profile.delete", "llm_profile", "id"},
+	"POST /settings/secrets/rotate":                         {"secrets.rotate", "secret", ""},
+	"POST /secrets":                                         {"secret.create", "secret", ""},
+	"PUT /secrets/{id}":                                     {"secret.update", "secret", "id"},
+	"DELETE /secrets/{id}":                                  {"secret.delete", "secret", "id"},
+	"PUT /settings/redaction/{profile}":                     {"redaction_policy.upsert", "policy_profile", "profile"},
+	"DELETE /settings/redaction/{profile}":                  {"redaction_policy.delete", "policy_profile", "profile"},
+	"POST /settings/memory":                                 {"memory_settings.update", "memory_settings", ""},
+	"POST /settings/personality":                            {"personality.update", "personality", ""},
+	"POST /settings/api-keys":                               {"api_key.create", "api_key", ""},
+	"DELETE /settings/api-keys/{id}":                        {"api_key.delete", "api_key", "id"},
+	"POST /workspaces":                                      {"workspace.create", "workspace", ""},
+	"DELETE /workspaces/{id}":                               {"workspace.delete", "workspace", "id"},
+	"PUT /workspaces/{id}/members/{userID}":                 {"workspace_member.upsert", "user", "userID"},
+	"DELETE /workspaces/{id}/members/{userID}":              {"workspace_member.delete", "user", "userID"},
+	"POST /users":                                           {"user.create", "user", ""},
+	"DELETE /users/{id}":                                    {"user.delete", "user", "id"},
+	"POST /webhooks":                                        {"webhook.create", "webhook", ""},
+	"PUT /webhooks/{id}":                                    {"webhook.update", "webhook", "id"},
+	"DELETE /webhooks/{id}":                                 {"webhook.delete", "webhook", "id"},
+	"POST /webhooks/{id}/deliveries/{deliveryID}/redeliver": {"webhook.redeliver", "webhook", "id"},
+	"POST /settings/notifications":                          {"notification_channel.create", "notification_channel", ""},
+	"PUT /settings/notifications/{id}":                      {"notification_channel.update", "notification_channel", "id"},
+	"DELETE /settings/notifications/{id}":                   {"notification_channel.delete", "notification_channel", "id"},
+}
+
+// auditExempt lists mutating routes that are deliberately not audited:
+// connection probes and schedule and prompt previews change nothing, worker events and
+// messages are already stored with their actor, automation job callbacks
+// only record the outcome of a job already in the automation inbox, and
+// inbound automation triggers carry no principal and land in that inbox.
+var auditExempt = map[string]bool{
+	"POST /settings/llm/test":                        true,
+	"POST /settings/llm/models":                      true,
+	"POST /settings/notifications/{id}/test":         true,
+	"POST /automations/schedule/preview":             true,
+	"POST /automations/{id}/prompt/preview":          true,
+	"POST /runs/{id}/events":                         true,
+	"POST /runs/{id}/messages":                       true,
+	"POST /automations/{id}/inbox/{entryID}/execute": true,
+	"POST /automations/{id}/inbox/{entryID}/fail":    true,
+	"POST /automations/{id}/trigger":                 true,
+}
+
+// auditChange carries what a handler reports about its target while the
+// audit middleware waits for it to finish.
+type auditChange struct {
+	targetID string
+	before   any
+	after    any
+}
+
+type auditContextKey struct{}
+
+// recordAuditChange attaches the target and its state before and after the
+// request to the audit entry. before is nil for creations and after is nil
+// for deletions. Outside the audit middleware it does nothing.
+func recordAuditChange(r *http.Request, targetID string, before any, after any) {
+	change, _ := r.Context().Value(auditContextKey{}).(*auditChange)
+	if change == nil {
+		return
+	}
+	change.targetID = targetID
+	change.before = before
+	change.after = after
+}
+
+// auditRequests records successful mutating requests that appear in
+// auditActions. It must run after authentication so the actor and
+// workspace are known.
+func (s *Server) auditRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		change := &auditChange{}
+		r = r.WithContext(context.WithValue(r.Context(), auditContextKey{}, change))
+		wrapped := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(wrapped, r)
+
+		status := wrapped.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusBadRequest {
+			return
+		}
+		routeContext := chi.RouteContext(r.Context())
+		if routeContext == nil {
+			return
+		}
+		action, ok := auditActions[r.Method+" "+routeContext.RoutePattern()]
+		if !ok {
+			return
+		}
+		s.appendAuditEntry(context.WithoutCancel(r.Context()), r, action, change, status)
+	})
+}
+
+func (s *Server) appendAuditEntry(ctx context.Context, r *http.Request, action auditAction, change *auditChange, status int) {
+	targetID := change.targetID
+	if targetID == "" && action.TargetParam != "" {
+		targetID = chi.URLParam(r, action.TargetParam)
+	}
+	before := auditSnapshot(change.before)
+	after := auditSnapshot(change.after)
+	if before != nil && after != nil {
+		before, after = auditDiff(before, after)
+	}
+	if before != nil || after != nil {
+		pipeline := s.redactionPipeline(ctx, redaction.DefaultPolicyProfile)
+		before, _ = pipeline.Map("", maskAuditSecrets(before))
+		after, _ = pipeline.Map("", maskAuditSecrets(after))
+	}
+	entry := store.AuditEntry{
+		ID:         uuid.NewString(),
+		Actor:      requestActor(ctx),
+		UserID:     requestUserID(ctx),
+		Action:     action.Action,
+		TargetType: action.TargetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		StatusCode: status,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		RequestID:  r.Header.Get("X-Request-Id"),
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := s.store.AppendAuditEntry(ctx, entry); err != nil {
+		log.Printf("audit append failed action=%s target_id=%s err=%v", entry.Action, entry.TargetID, err)
+	}
+}
+
+// auditSnapshot turns a store or API value into a JSON object with
+// snake_case keys. Store types carry no JSON tags, so their Go field names
+// are converted.
+func auditSnapshot(value any) map[string]any {
+	if value == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var decoded any
+	if err := json.Unmarshal(encoded, &decoded); err != nil || decoded == nil {
+		return nil
+	}
+	if object, ok := snakeCaseKeys(decoded).(map[string]any); ok {
+		return object
+	}
+	return map[string]any{"value": decoded}
+}
+
+func snakeCaseKeys(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for key, item := range typed {
+			result[snakeCase(key)] = snakeCaseKeys(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for idx, item := range typed {
+			result[idx] = snakeCaseKeys(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// snakeCase converts Go field names such as APIKeyEnc to api_key_enc and
+// leaves names that are already snake_case unchanged.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for idx, current := range runes {
+		if unicode.IsUpper(current) {
+			if idx > 0 {
+				previous := runes[idx-1]
+				nextIsLower := idx+1 < len(runes) && unicode.IsLower(runes[idx+1])
+				if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+					builder.WriteRune('_')
+				}
+			}
+			builder.WriteRune(unicode.ToLower(current))
+			continue
+		}
+		builder.WriteRune(current)
+	}
+	return builder.String()
+}
+
+// auditDiff keeps only the fields whose values differ. updated_at always
+// changes and is left out.
+func auditDiff(before map[string]any, after map[string]any) (map[string]any, map[string]any) {
+	changedBefore := map[string]any{}
+	changedAfter := map[string]any{}
+	for key, value := range before {
+		if key == "updated_at" {
+			continue
+		}
+		if other, ok := after[key]; !ok || !reflect.DeepEqual(value, other) {
+			changedBefore[key] = value
+		}
+	}
+	for key, value := range after {
+		if key == "updated_at" {
+			continue
+		}
+		if other, ok := before[key]; !ok || !reflect.DeepEqual(value, other) {
+			changedAfter[key] = value
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+// maskAuditSecrets replaces credential fields, such as encrypted API keys
+// and key hashes, so only the fact that they changed is recorded.
+func maskAuditSecrets(value map[string]any) map[string]any {
+	if value == nil {
+		return nil
+	}
+	result := make(map[string]any, len(value))
+	for key, item := range value {
+		switch {
+		case isAuditSecretField(key):
+			if item != nil && item != "" {
+				result[key] = auditRedacted
+			} else {
+				result[key] = item
+			}
+		case isAuditObject(item):
+			result[key] = maskAuditSecrets(item.(map[string]any))
+		default:
+			result[key] = item
+		}
+	}
+	return result
+}
+
+func isAuditObject(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
+
+func isAuditSecretField(key string) bool {
+	switch key {
+	case "key", "api_key", "password", "secret", "token", "value":
+		return true
+	}
+	for _, suffix := range []string{"_enc", "_hash", "_secret", "_token", "_password"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+type auditEntryRecord struct {
+	ID          string         `json:"id"`
+	WorkspaceID string         `json:"workspace_id"`
+	Actor       string         `json:"actor,omitempty"`
+	UserID      string         `json:"user_id,omitempty"`
+	Action      string         `json:"action"`
+	TargetType  string         `json:"target_type,omitempty"`
+	TargetID    string         `json:"target_id,omitempty"`
+	Before      map[string]any `json:"before,omitempty"`
+	After       map[string]any `json:"after,omitempty"`
+	Method      string         `json:"method"`
+	Path        string         `json:"path"`
+	StatusCode  int            `json:"status_code"`
+	RemoteAddr  string         `json:"remote_addr,omitempty"`
+	UserAgent   string         `json:"user_agent,omitempty"`
+	RequestID   string         `json:"request_id,omitempty"`
+	CreatedAt   string         `json:"created_at"`
+}
+
+type auditEntriesResponse struct {
+	Entries    []auditEntryRecord `json:"entries"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func toAuditEntryRecord(entry store.AuditEntry) auditEntryRecord {
+	return auditEntryRecord{
+		ID:          entry.ID,
+		WorkspaceID: entry.WorkspaceID,
+		Actor:       entry.Actor,
+		UserID:      entry.UserID,
+		Action:      entry.Action,
+		TargetType:  entry.TargetType,
+		TargetID:    entry.TargetID,
+		Before:      entry.Before,
+		After:       entry.After,
+		Method:      entry.Method,
+		Path:        entry.Path,
+		StatusCode:  entry.StatusCode,
+		RemoteAddr:  entry.RemoteAddr,
+		UserAgent:   entry.UserAgent,
+		RequestID:   entry.RequestID,
+		CreatedAt:   entry.CreatedAt,
+	}
+}
+
+// listAuditEntries returns the newest entries first. next_cursor is set
+// when more entries exist and is passed back as ?cursor= for the next page.
+func (s *Server) listAuditEntries(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := store.AuditQuery{
+		Actor:      strings.TrimSpace(params.Get("actor")),
+		UserID:     strings.TrimSpace(params.Get("user_id")),
+		Action:     strings.TrimSpace(params.Get("action")),
+		TargetType: strings.TrimSpace(params.Get("target_type")),
+		TargetID:   strings.TrimSpace(params.Get("target_id")),
+		Limit:      defaultAuditLimit,
+	}
+	for name, target := range map[string]*string{"since": &query.Since, "until": &query.Until} {
+		value := strings.TrimSpace(params.Get(name))
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			http.Error(w, name+" must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*target = parsed.UTC().Format(time.RFC3339Nano)
+	}
+	if raw := strings.TrimSpace(params.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		query.Limit = min(limit, maxAuditLimit)
+	}
+	if raw := strings.TrimSpace(params.Get("cursor")); raw != "" {
+		cursor, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || cursor <= 0 {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		query.BeforeSeq = cursor
+	}
+	pageSize := query.Limit
+	query.Limit = pageSize + 1
+	entries, err := s.store.ListAuditEntries(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response := auditEntriesResponse{Entries: make([]auditEntryRecord, 0, min(len(entries), pageSize))}
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		response.NextCursor = strconv.FormatInt(entries[len(entries)-1].Seq, 10)
+	}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, toAuditEntryRecord(entry))
+	}
+	writeJSONStatus(w, response, http.StatusOK)
+}
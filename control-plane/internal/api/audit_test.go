@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestAuditActionsCoverMutatingRoutes(t *testing.T) {
+	server := NewServer(memory.New(), &MockBroker{}, nil, config.Config{})
+	routes, ok := server.Router().(chi.Routes)
+	require.True(t, ok)
+
+	registered := map[string]bool{}
+	require.NoError(t, chi.Walk(routes, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := method + " " + route
+		registered[key] = true
+		if method != http.MethodGet {
+			_, audited := auditActions[key]
+			require.True(t, audited || auditExempt[key], "mutating route %s is neither audited nor exempt", key)
+		}
+		return nil
+	}))
+	for key := range auditActions {
+		require.True(t, registered[key], "audit action for unregistered route %s", key)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{
+		APIAuthEnabled:  true,
+		APIBootstrapKey: testBootstrapKey,
+		LLMSecretsKey:   testLLMSecretsKey,
+	})
+	defer server.Close()
+
+	keyResp := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", testBootstrapKey, []byte(`{"name":"ops","scopes":["settings:admin"]}`))
+	defer keyResp.Body.Close()
+	require.Equal(t, http.StatusCreated, keyResp.StatusCode)
+	var key apiKeyRecord
+	require.NoError(t, json.NewDecoder(keyResp.Body).Decode(&key))
+
+	secretResp := doWithKey(t, http.MethodPost, server.URL+"/secrets", key.Key, []byte(`{"name":"API_TOKEN","value":"tok-123"}`))
+	secretResp.Body.Close()
+	require.Equal(t, http.StatusCreated, secretResp.StatusCode)
+	for _, content := range []string{"friendly", "use tok-123 when asked"} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/settings/personality", key.Key, []byte(`{"content":"`+content+`"}`))
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	rejected := doWithKey(t, http.MethodPost, server.URL+"/secrets", key.Key, []byte(`{"name":""}`))
+	rejected.Body.Close()
+	require.Equal(t, http.StatusBadRequest, rejected.StatusCode)
+
+	listAudit := func(query string) auditEntriesResponse {
+		t.Helper()
+		resp := doWithKey(t, http.MethodGet, server.URL+"/audit"+query, key.Key, nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page auditEntriesResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		return page
+	}
+
+	all := listAudit("")
+	require.Len(t, all.Entries, 4)
+	require.Empty(t, all.NextCursor)
+	actions := make([]string, 0, len(all.Entries))
+	for _, entry := range all.Entries {
+		actions = append(actions, entry.Action)
+	}
+	require.Equal(t, []string{"personality.update", "personality.update", "secret.create", "api_key.create"}, actions)
+
+	latest := all.Entries[0]
+	require.Equal(t, "api_key:ops", latest.Actor)
+	require.Equal(t, "/settings/personality", latest.Path)
+	require.Equal(t, http.StatusOK, latest.StatusCode)
+	require.Equal(t, map[string]any{"content": "friendly"}, latest.Before)
+	require.Equal(t, map[string]any{"content": "use [secret:API_TOKEN] when asked"}, latest.After)
+
+	secret := all.Entries[2]
+	require.Equal(t, "[redacted]", secret.After["value_enc"])
+	require.Equal(t, "API_TOKEN", secret.After["name"])
+	require.Equal(t, secret.TargetID, secret.After["id"])
+	apiKey := all.Entries[3]
+	require.Equal(t, "api_key:bootstrap", apiKey.Actor)
+	require.Equal(t, "[redacted]", apiKey.After["key_hash"])
+	encoded, err := json.Marshal(all)
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), key.Key)
+	require.NotContains(t, string(encoded), "tok-123")
+
+	first := listAudit("?limit=3")
+	require.Len(t, first.Entries, 3)
+	require.NotEmpty(t, first.NextCursor)
+	second := listAudit("?limit=3&cursor=" + first.NextCursor)
+	require.Len(t, second.Entries, 1)
+	require.Equal(t, "api_key.create", second.Entries[0].Action)
+	require.Empty(t, second.NextCursor)
+
+	filtered := listAudit("?target_type=secret")
+	require.Len(t, filtered.Entries, 1)
+	require.Equal(t, "secret.create", filtered.Entries[0].Action)
+
+	invalid := doWithKey(t, http.MethodGet, server.URL+"/audit?since=yesterday", key.Key, nil)
+	invalid.Body.Close()
+	require.Equal(t, http.StatusBadRequest, invalid.StatusCode)
+}
+
+func TestAuditDiffAndMasking(t *testing.T) {
+	before := auditSnapshot(struct {
+		Name      string
+		APIKeyEnc string
+		Tags      []string
+		UpdatedAt string
+	}{"daily", "enc-1", []string{"a"}, "t1"})
+	after := auditSnapshot(struct {
+		Name      string
+		APIKeyEnc string
+		Tags      []string
+		UpdatedAt string
+	}{"daily", "enc-2", []string{"a", "b"}, "t2"})
+
+	changedBefore, changedAfter := auditDiff(before, after)
+	require.Equal(t, map[string]any{"api_key_enc": "enc-1", "tags": []any{"a"}}, changedBefore)
+	require.Equal(t, map[string]any{"api_key_enc": "[redacted]", "tags": []any{"a", "b"}}, maskAuditSecrets(changedAfter))
+	require.Nil(t, auditSnapshot(nil))
+	require.Equal(t, "llm_profile", snakeCase("LLMProfile"))
+	require.Equal(t, "workspace_id", snakeCase("WorkspaceID"))
+	require.Equal(t, "status_code", snakeCase("status_code"))
+}
@@ -0,0 +1,533 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	scopeRunsRead      = "runs:read"
+	scopeRunsWrite     = "runs:write"
+	scopeSettingsAdmin = "settings:admin"
+	scopeProcessesExec = "processes:exec"
+)
+
+var knownAPIScopes = []string{scopeRunsRead, scopeRunsWrite, scopeSettingsAdmin, scopeProcessesExec}
+
+const (
+	apiKeyPrefix        = "gvn_"
+	apiKeyDisplayLength = 12
+	bootstrapKeyID      = "bootstrap"
+	// last_used_at is only refreshed this often so busy keys do not write on
+	// every request.
+	apiKeyTouchInterval = time.Minute
+	maxSignedBodyBytes  = 32 << 20
+	// workspaceHeader selects the workspace for callers that are not bound
+	// to one: the bootstrap key, or any caller when auth is disabled.
+	workspaceHeader = signing.HeaderWorkspace
+)
+
+// apiPrincipal.WorkspaceID is empty for the bootstrap key, which may act in
+// any workspace.
+type apiPrincipal struct {
+	KeyID       string
+	Name        string
+	Scopes      []string
+	WorkspaceID string
+	UserID      string
+}
+
+func (p *apiPrincipal) hasScope(scope string) bool {
+	for _, candidate := range p.Scopes {
+		if candidate == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalContextKey struct{}
+
+// principalFromContext returns the authenticated caller, or nil when auth is
+// disabled.
+func principalFromContext(ctx context.Context) *apiPrincipal {
+	principal, _ := ctx.Value(principalContextKey{}).(*apiPrincipal)
+	return principal
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// apiKeyFromRequest reads the key from the Authorization bearer token, the
+// X-API-Key header, or an access_token query parameter for EventSource
+// clients that cannot set headers.
+func apiKeyFromRequest(r *http.Request) string {
+	if header := strings.TrimSpace(r.Header.Get("Authorization")); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if header := strings.TrimSpace(r.Header.Get("X-API-Key")); header != "" {
+		return header
+	}
+	if r.Method == http.MethodGet {
+		return strings.TrimSpace(r.URL.Query().Get("access_token"))
+	}
+	return ""
+}
+
+func (s *Server) authenticateRequest(r *http.Request) (*apiPrincipal, error) {
+	key := apiKeyFromRequest(r)
+	if key == "" {
+		return nil, nil
+	}
+	keyHash := hashAPIKey(key)
+	if bootstrap := strings.TrimSpace(s.cfg.APIBootstrapKey); bootstrap != "" {
+		if subtle.ConstantTimeCompare([]byte(keyHash), []byte(hashAPIKey(bootstrap))) == 1 {
+			return &apiPrincipal{KeyID: bootstrapKeyID, Name: bootstrapKeyID, Scopes: knownAPIScopes}, nil
+		}
+	}
+	stored, err := s.store.GetAPIKeyByHash(r.Context(), keyHash)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if lastUsed, err := time.Parse(time.RFC3339Nano, stored.LastUsedAt); err != nil || now.Sub(lastUsed) >= apiKeyTouchInterval {
+		_ = s.store.TouchAPIKey(r.Context(), stored.ID, now.Format(time.RFC3339Nano))
+	}
+	workspaceID := stored.WorkspaceID
+	if workspaceID == "" {
+		workspaceID = store.DefaultWorkspaceID
+	}
+	return &apiPrincipal{
+		KeyID:       stored.ID,
+		Name:        stored.Name,
+		Scopes:      stored.Scopes,
+		WorkspaceID: workspaceID,
+		UserID:      stored.UserID,
+	}, nil
+}
+
+// requireScope authenticates the caller, checks it holds scope and scopes
+// the request to the caller's workspace. Only the workspace scoping applies
+// when API_AUTH_ENABLED is off.
+func (s *Server) requireScope(scope string) func(http.Handler) http.Handler {
+	return s.requireScopeFollowingRun(scope, false)
+}
+
+// requireScopeFollowingRun is requireScope for worker routes: callers that
+// are not bound to a workspace act in the workspace of the run in the path.
+func (s *Server) requireScopeFollowingRun(scope string, followRun bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.cfg.APIAuthEnabled {
+				if scoped, ok := s.scopeWorkspace(w, r, nil, followRun); ok {
+					next.ServeHTTP(w, scoped)
+				}
+				return
+			}
+			principal, err := s.authenticateRequest(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if principal == nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if !principal.hasScope(scope) {
+				http.Error(w, "missing scope: "+scope, http.StatusForbidden)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), principalContextKey{}, principal))
+			if scoped, ok := s.scopeWorkspace(w, r, principal, followRun); ok {
+				next.ServeHTTP(w, scoped)
+			}
+		})
+	}
+}
+
+// unboundCaller reports whether the caller may act across workspaces: the
+// bootstrap key, or anyone when auth is disabled.
+func unboundCaller(ctx context.Context) bool {
+	principal := principalFromContext(ctx)
+	return principal == nil || principal.WorkspaceID == ""
+}
+
+// requireUnbound limits instance-wide routes, such as workspace and user
+// management, to callers that are not bound to a workspace.
+func requireUnbound(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !unboundCaller(r.Context()) {
+			http.Error(w, "api key is bound to a workspace", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// runIDFromPath returns the {id} of /runs/{id}/... paths.
+func runIDFromPath(path string) string {
+	rest, ok := strings.CutPrefix(path, "/runs/")
+	if !ok {
+		return ""
+	}
+	runID, _, _ := strings.Cut(rest, "/")
+	return runID
+}
+
+// scopeWorkspace resolves the workspace a request acts in and, for run
+// routes, hides runs from other workspaces behind a 404. Unknown runs pass
+// through so handlers keep their own not-found behaviour.
+func (s *Server) scopeWorkspace(w http.ResponseWriter, r *http.Request, principal *apiPrincipal, followRun bool) (*http.Request, bool) {
+	ctx := r.Context()
+	requested := strings.TrimSpace(r.Header.Get(workspaceHeader))
+	workspaceID := store.DefaultWorkspaceID
+	pinned := false
+	switch {
+	case principal != nil && principal.WorkspaceID != "":
+		if requested != "" && requested != principal.WorkspaceID {
+			http.Error(w, "api key is not valid for workspace "+requested, http.StatusForbidden)
+			return nil, false
+		}
+		workspaceID = principal.WorkspaceID
+		pinned = true
+	case requested != "":
+		workspace, err := s.store.GetWorkspace(ctx, requested)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil, false
+		}
+		if workspace == nil {
+			http.Error(w, "workspace not found", http.StatusNotFound)
+			return nil, false
+		}
+		workspaceID = requested
+		pinned = true
+	}
+	if runID := runIDFromPath(r.URL.Path); runID != "" {
+		owner, err := s.store.GetRunWorkspace(ctx, runID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil, false
+		}
+		if owner != "" && owner != workspaceID {
+			if !followRun || pinned {
+				http.Error(w, "run not found", http.StatusNotFound)
+				return nil, false
+			}
+			workspaceID = owner
+		}
+	}
+	return r.WithContext(store.WithWorkspace(ctx, workspaceID)), true
+}
+
+type serviceContextKey struct{}
+
+// serviceFromContext returns the worker service that signed the request, if
+// any.
+func serviceFromContext(ctx context.Context) string {
+	service, _ := ctx.Value(serviceContextKey{}).(string)
+	return service
+}
+
+// requestUserID returns the user the caller's API key is bound to, if any.
+func requestUserID(ctx context.Context) string {
+	if principal := principalFromContext(ctx); principal != nil {
+		return principal.UserID
+	}
+	return ""
+}
+
+// requestActor identifies who made a request for the run_events actor column.
+func requestActor(ctx context.Context) string {
+	if service := serviceFromContext(ctx); service != "" {
+		return "service:" + service
+	}
+	if principal := principalFromContext(ctx); principal != nil {
+		return "api_key:" + principal.Name
+	}
+	return ""
+}
+
+// serviceOrScope accepts requests signed by a worker service and otherwise
+// falls back to requireScope. With requireSigned set and signing secrets
+// configured, unsigned requests are rejected outright.
+func (s *Server) serviceOrScope(scope string, requireSigned bool) func(http.Handler) http.Handler {
+	fallback := s.requireScopeFollowingRun(scope, true)
+	return func(next http.Handler) http.Handler {
+		scoped := fallback(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !signing.IsSigned(r) {
+				if requireSigned && len(s.signingSecrets) > 0 {
+					http.Error(w, "signed request required", http.StatusUnauthorized)
+					return
+				}
+				scoped.ServeHTTP(w, r)
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes))
+			if err != nil {
+				http.Error(w, "invalid request", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			service, err := signing.Verify(r, body, s.signingSecrets, s.signingNonces, time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), serviceContextKey{}, service))
+			if scoped, ok := s.scopeWorkspace(w, r, nil, true); ok {
+				next.ServeHTTP(w, scoped)
+			}
+		})
+	}
+}
+
+func parseAllowedOrigins(raw string) map[string]struct{} {
+	origins := map[string]struct{}{}
+	for _, origin := range strings.Split(raw, ",") {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins[origin] = struct{}{}
+		}
+	}
+	return origins
+}
+
+// corsMiddleware only answers cross-origin requests from configured origins;
+// "*" allows any origin.
+func corsMiddleware(allowedOrigins string) func(http.Handler) http.Handler {
+	origins := parseAllowedOrigins(allowedOrigins)
+	_, allowAll := origins["*"]
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			_, allowed := origins[origin]
+			if allowAll {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else if origin != "" && allowed {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			w.Header().Add("Vary", "Origin")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Last-Event-ID, Authorization, X-API-Key, "+workspaceHeader)
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type apiKeyRecord struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Prefix      string   `json:"prefix"`
+	Scopes      []string `json:"scopes"`
+	WorkspaceID string   `json:"workspace_id"`
+	UserID      string   `json:"user_id,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+	LastUsedAt  string   `json:"last_used_at,omitempty"`
+	Key         string   `json:"key,omitempty"`
+}
+
+type apiKeyRequest struct {
+	Name        string   `json:"name"`
+	Scopes      []string `json:"scopes"`
+	WorkspaceID string   `json:"workspace_id"`
+	UserID      string   `json:"user_id"`
+}
+
+type apiKeysResponse struct {
+	Keys   []apiKeyRecord `json:"keys"`
+	Scopes []string       `json:"scopes"`
+}
+
+func toAPIKeyRecord(key store.APIKey) apiKeyRecord {
+	return apiKeyRecord{
+		ID:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Scopes:      nonNilStrings(key.Scopes),
+		WorkspaceID: key.WorkspaceID,
+		UserID:      key.UserID,
+		CreatedAt:   key.CreatedAt,
+		LastUsedAt:  key.LastUsedAt,
+	}
+}
+
+func normalizeAPIScopes(values []string) ([]string, error) {
+	scopes := normalizeRouteCriteria(values, true)
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		known := false
+		for _, candidate := range knownAPIScopes {
+			if scope == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown scope: %s", scope)
+		}
+	}
+	return scopes, nil
+}
+
+func (s *Server) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.store.ListAPIKeys(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]apiKeyRecord, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, toAPIKeyRecord(key))
+	}
+	writeJSONStatus(w, apiKeysResponse{Keys: result, Scopes: knownAPIScopes}, http.StatusOK)
+}
+
+func (s *Server) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	req := apiKeyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	scopes, err := normalizeAPIScopes(req.Scopes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// A key can only hand out scopes it holds itself.
+	if principal := principalFromContext(r.Context()); principal != nil {
+		for _, scope := range scopes {
+			if !principal.hasScope(scope) {
+				http.Error(w, "api key does not hold scope "+scope, http.StatusForbidden)
+				return
+			}
+		}
+	}
+	workspaceID := strings.TrimSpace(req.WorkspaceID)
+	if workspaceID == "" {
+		workspaceID = store.WorkspaceID(r.Context())
+	}
+	if !unboundCaller(r.Context()) && workspaceID != store.WorkspaceID(r.Context()) {
+		http.Error(w, "api key is not valid for workspace "+workspaceID, http.StatusForbidden)
+		return
+	}
+	userID := strings.TrimSpace(req.UserID)
+	if status, err := s.validateAPIKeyOwner(r.Context(), workspaceID, userID); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	plaintext, err := generateAPIKey()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	key := store.APIKey{
+		ID:          uuid.NewString(),
+		Name:        name,
+		Prefix:      plaintext[:apiKeyDisplayLength],
+		KeyHash:     hashAPIKey(plaintext),
+		Scopes:      scopes,
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := s.store.CreateAPIKey(r.Context(), key); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, key.ID, nil, key)
+	record := toAPIKeyRecord(key)
+	// The plaintext key is only ever returned here.
+	record.Key = plaintext
+	writeJSONStatus(w, record, http.StatusCreated)
+}
+
+// validateAPIKeyOwner checks the workspace exists and, when a user is given,
+// that the user is a member of it.
+func (s *Server) validateAPIKeyOwner(ctx context.Context, workspaceID string, userID string) (int, error) {
+	workspace, err := s.store.GetWorkspace(ctx, workspaceID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if workspace == nil {
+		return http.StatusBadRequest, fmt.Errorf("unknown workspace: %s", workspaceID)
+	}
+	if userID == "" {
+		return http.StatusOK, nil
+	}
+	members, err := s.store.ListWorkspaceMembers(ctx, workspaceID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	for _, member := range members {
+		if member.UserID == userID {
+			return http.StatusOK, nil
+		}
+	}
+	return http.StatusBadRequest, fmt.Errorf("user %s is not a member of workspace %s", userID, workspaceID)
+}
+
+func (s *Server) deleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		http.Error(w, "api key id is required", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetAPIKey(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "api key not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteAPIKey(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+const testBootstrapKey = "bootstrap-admin-key"
+
+func doWithKey(t *testing.T, method string, url string, key string, body []byte) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestAPIAuthScopes(t *testing.T) {
+	mem := memory.New()
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{APIAuthEnabled: true, APIBootstrapKey: testBootstrapKey})
+	defer server.Close()
+
+	health := doWithKey(t, http.MethodGet, server.URL+"/health", "", nil)
+	health.Body.Close()
+	require.Equal(t, http.StatusOK, health.StatusCode)
+
+	anonymous := doWithKey(t, http.MethodGet, server.URL+"/runs", "", nil)
+	anonymous.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, anonymous.StatusCode)
+	require.Equal(t, "Bearer", anonymous.Header.Get("WWW-Authenticate"))
+
+	invalid := doWithKey(t, http.MethodGet, server.URL+"/runs", "gvn_wrong", nil)
+	invalid.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, invalid.StatusCode)
+
+	created := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", testBootstrapKey, []byte(`{"name":"dashboard","scopes":["runs:read"]}`))
+	defer created.Body.Close()
+	require.Equal(t, http.StatusCreated, created.StatusCode)
+	var record apiKeyRecord
+	require.NoError(t, json.NewDecoder(created.Body).Decode(&record))
+	require.NotEmpty(t, record.Key)
+	require.Equal(t, record.Key[:apiKeyDisplayLength], record.Prefix)
+
+	stored, err := mem.GetAPIKey(context.Background(), record.ID)
+	require.NoError(t, err)
+	require.Equal(t, hashAPIKey(record.Key), stored.KeyHash)
+	require.NotContains(t, stored.KeyHash, record.Key)
+
+	readResp := doWithKey(t, http.MethodGet, server.URL+"/runs", record.Key, nil)
+	readResp.Body.Close()
+	require.Equal(t, http.StatusOK, readResp.StatusCode)
+
+	queryResp, err := http.Get(server.URL + "/runs?access_token=" + record.Key)
+	require.NoError(t, err)
+	queryResp.Body.Close()
+	require.Equal(t, http.StatusOK, queryResp.StatusCode)
+
+	settingsResp := doWithKey(t, http.MethodGet, server.URL+"/settings/llm", record.Key, nil)
+	settingsResp.Body.Close()
+	require.Equal(t, http.StatusForbidden, settingsResp.StatusCode)
+
+	execResp := doWithKey(t, http.MethodPost, server.URL+"/runs/run-1/processes/exec", record.Key, []byte(`{}`))
+	execResp.Body.Close()
+	require.Equal(t, http.StatusForbidden, execResp.StatusCode)
+
+	fetched, err := mem.GetAPIKey(context.Background(), record.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, fetched.LastUsedAt)
+
+	listResp := doWithKey(t, http.MethodGet, server.URL+"/settings/api-keys", testBootstrapKey, nil)
+	defer listResp.Body.Close()
+	var listed apiKeysResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Len(t, listed.Keys, 1)
+	require.Empty(t, listed.Keys[0].Key)
+
+	deleteResp := doWithKey(t, http.MethodDelete, server.URL+"/settings/api-keys/"+record.ID, testBootstrapKey, nil)
+	deleteResp.Body.Close()
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	revoked := doWithKey(t, http.MethodGet, server.URL+"/runs", record.Key, nil)
+	revoked.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, revoked.StatusCode)
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	cases := []string{
+		`{"name":"","scopes":["runs:read"]}`,
+		`{"name":"ci","scopes":[]}`,
+		`{"name":"ci","scopes":["root"]}`,
+	}
+	for _, body := range cases {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+}
+
+func TestCreateAPIKeyCannotEscalateScopes(t *testing.T) {
+	mem := memory.New()
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{APIAuthEnabled: true, APIBootstrapKey: testBootstrapKey})
+	defer server.Close()
+
+	created := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", testBootstrapKey, []byte(`{"name":"settings","scopes":["settings:admin","runs:read"]}`))
+	defer created.Body.Close()
+	require.Equal(t, http.StatusCreated, created.StatusCode)
+	var admin apiKeyRecord
+	require.NoError(t, json.NewDecoder(created.Body).Decode(&admin))
+
+	escalated := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", admin.Key, []byte(`{"name":"exec","scopes":["runs:read","processes:exec"]}`))
+	escalated.Body.Close()
+	require.Equal(t, http.StatusForbidden, escalated.StatusCode)
+
+	subset := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", admin.Key, []byte(`{"name":"reader","scopes":["runs:read"]}`))
+	subset.Body.Close()
+	require.Equal(t, http.StatusCreated, subset.StatusCode)
+
+	keys, err := mem.ListAPIKeys(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	for _, key := range keys {
+		require.NotContains(t, key.Scopes, scopeProcessesExec)
+	}
+}
+
+func postSigned(t *testing.T, url string, path string, service string, secret string, body []byte) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+path, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	signing.SignRequest(req, service, secret, body, time.Now())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestSignedEventIngestion(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-1", Status: "running"}))
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	server := newTestServer(t, mem, brokerMock, nil, config.Config{
+		APIAuthEnabled:      true,
+		APIBootstrapKey:     testBootstrapKey,
+		EventSigningSecrets: "worker=worker-secret,tool_runner=runner-secret",
+	})
+	defer server.Close()
+
+	body := []byte(`{"type":"tool.completed","source":"tool","payload":{"ok":true}}`)
+
+	// API keys alone cannot forge worker events once signing is configured.
+	unsigned := doWithKey(t, http.MethodPost, server.URL+"/runs/run-1/events", testBootstrapKey, body)
+	unsigned.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, unsigned.StatusCode)
+
+	forged := postSigned(t, server.URL, "/runs/run-1/events", "worker", "wrong-secret", body)
+	forged.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, forged.StatusCode)
+
+	unknown := postSigned(t, server.URL, "/runs/run-1/events", "browser_worker", "worker-secret", body)
+	unknown.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, unknown.StatusCode)
+
+	accepted := postSigned(t, server.URL, "/runs/run-1/events", "tool_runner", "runner-secret", body)
+	accepted.Body.Close()
+	require.Equal(t, http.StatusAccepted, accepted.StatusCode)
+
+	// A captured request is only accepted once, even inside the clock skew.
+	replay, err := http.NewRequest(http.MethodPost, server.URL+"/runs/run-1/events", bytes.NewReader(body))
+	require.NoError(t, err)
+	signing.SignRequest(replay, "tool_runner", "runner-secret", body, time.Now())
+	for _, want := range []int{http.StatusAccepted, http.StatusUnauthorized} {
+		req := replay.Clone(ctx)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, want, resp.StatusCode)
+	}
+
+	// The workspace header is signed, so a relayed request cannot be moved
+	// into another workspace.
+	pinned, err := http.NewRequest(http.MethodPost, server.URL+"/runs/run-1/events", bytes.NewReader(body))
+	require.NoError(t, err)
+	pinned.Header.Set(workspaceHeader, "ws-1")
+	signing.SignRequest(pinned, "tool_runner", "runner-secret", body, time.Now())
+	pinned.Header.Set(workspaceHeader, "ws-2")
+	swapped, err := http.DefaultClient.Do(pinned)
+	require.NoError(t, err)
+	swapped.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, swapped.StatusCode)
+
+	message := postSigned(t, server.URL, "/runs/run-1/messages", "worker", "worker-secret", []byte(`{"role":"assistant","content":"done"}`))
+	message.Body.Close()
+	require.Equal(t, http.StatusAccepted, message.StatusCode)
+
+	keyedMessage := doWithKey(t, http.MethodPost, server.URL+"/runs/run-1/messages", testBootstrapKey, []byte(`{"role":"assistant","content":"thanks"}`))
+	keyedMessage.Body.Close()
+	require.Equal(t, http.StatusAccepted, keyedMessage.StatusCode)
+
+	stored, err := mem.ListEvents(ctx, "run-1", 0)
+	require.NoError(t, err)
+	require.Len(t, stored, 4)
+	require.Equal(t, "service:tool_runner", stored[0].Actor)
+	require.Equal(t, "service:tool_runner", stored[1].Actor)
+	require.Equal(t, "service:worker", stored[2].Actor)
+	require.Equal(t, "api_key:"+bootstrapKeyID, stored[3].Actor)
+}
+
+func TestUnsignedEventsAllowedWithoutSigningSecrets(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-1", Status: "running"}))
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	server := newTestServer(t, mem, brokerMock, nil, config.Config{})
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/runs/run-1/events", "", []byte(`{"type":"tool.completed","source":"tool"}`))
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	stored, err := mem.ListEvents(ctx, "run-1", 0)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	require.Empty(t, stored[0].Actor)
+}
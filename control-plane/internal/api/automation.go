@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
@@ -28,6 +29,8 @@ type automationExecuteRequest struct {
 	BrowserDomainAllowlist []string       `json:"browser_domain_allowlist"`
 	BrowserPreferred       string         `json:"browser_preferred_browser"`
 	BrowserUserAgent       string         `json:"browser_user_agent"`
+	// Skills names the skills the run should use.
+	Skills []string `json:"skills"`
 }
 
 type automationSourceDiagnostic struct {
@@ -49,6 +52,10 @@ type automationDiagnostics struct {
 	TerminalReason   string                       `json:"terminal_reason,omitempty"`
 	TotalEvents      int                          `json:"total_events"`
 	AssistantMessage int                          `json:"assistant_messages"`
+	// DigestEntries lists the results a digest summarized.
+	DigestEntries []automationDigestItem `json:"digest_entries,omitempty"`
+	// PipelineStages lists the result of every stage of a pipeline.
+	PipelineStages []automationStageResult `json:"pipeline_stages,omitempty"`
 }
 
 type automationExecuteResponse struct {
@@ -78,16 +85,79 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-
-	prompt := strings.TrimSpace(req.Prompt)
-	if prompt == "" {
-		prompt = strings.TrimSpace(req.Goal)
-	}
+	prompt := automationExecutePrompt(req)
 	if prompt == "" {
 		http.Error(w, "prompt is required", http.StatusBadRequest)
 		return
 	}
 
+	run, err := s.startAutomationRun(r.Context(), req, prompt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, run.ID, nil, run)
+	if !automationWaitsForCompletion(req) {
+		writeJSONStatus(w, pendingAutomationResponse(run.ID), http.StatusAccepted)
+		return
+	}
+	response, err := s.awaitAutomationRun(r.Context(), run.ID, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSONStatus(w, response, http.StatusOK)
+}
+
+// invokeAutomationExecute runs req for an automation job as
+// POST /automation/execute would.
+func (s *Server) invokeAutomationExecute(ctx context.Context, req automationExecuteRequest) (automationExecuteResponse, error) {
+	settings, err := s.store.GetLLMSettings(ctx)
+	if err != nil {
+		return automationExecuteResponse{}, err
+	}
+	if settings == nil {
+		return automationExecuteResponse{}, errors.New("LLM setup required")
+	}
+	prompt := automationExecutePrompt(req)
+	if prompt == "" {
+		return automationExecuteResponse{}, errors.New("prompt is required")
+	}
+	run, err := s.startAutomationRun(ctx, req, prompt)
+	if err != nil {
+		return automationExecuteResponse{}, err
+	}
+	if !automationWaitsForCompletion(req) {
+		return pendingAutomationResponse(run.ID), nil
+	}
+	return s.awaitAutomationRun(ctx, run.ID, req)
+}
+
+func automationExecutePrompt(req automationExecuteRequest) string {
+	if prompt := strings.TrimSpace(req.Prompt); prompt != "" {
+		return prompt
+	}
+	return strings.TrimSpace(req.Goal)
+}
+
+func automationWaitsForCompletion(req automationExecuteRequest) bool {
+	return req.WaitForCompletion == nil || *req.WaitForCompletion
+}
+
+func pendingAutomationResponse(runID string) automationExecuteResponse {
+	return automationExecuteResponse{
+		RunID:  runID,
+		Status: "running",
+		Phase:  "planning",
+		Diagnostics: automationDiagnostics{
+			Sources:     []automationSourceDiagnostic{},
+			TotalEvents: 2,
+		},
+	}
+}
+
+// startAutomationRun creates a run for req and sends it prompt.
+func (s *Server) startAutomationRun(ctx context.Context, req automationExecuteRequest, prompt string) (store.Run, error) {
 	runID := uuid.New().String()
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	policyProfile := strings.TrimSpace(req.PolicyProfile)
@@ -102,18 +172,19 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 		PolicyProfile: policyProfile,
 		ModelRoute:    strings.TrimSpace(req.ModelRoute),
 		Tags:          req.Tags,
+		UserID:        requestUserID(ctx),
+		CreatedBy:     requestActor(ctx),
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}
-	if err := s.store.CreateRun(r.Context(), run); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if err := s.store.CreateRun(ctx, run); err != nil {
+		return store.Run{}, err
 	}
 	if s.workflows != nil {
-		_ = s.workflows.StartRun(r.Context(), runID)
+		_ = s.workflows.StartRun(ctx, runID)
 	}
 
-	seq, _ := s.store.NextSeq(r.Context(), runID)
+	seq, _ := s.store.NextSeq(ctx, runID)
 	startedEvent := store.RunEvent{
 		RunID:     runID,
 		Seq:       seq,
@@ -129,8 +200,8 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 			"tags":           run.Tags,
 		},
 	}
-	_ = s.store.AppendEvent(r.Context(), startedEvent)
-	s.broker.Publish(toEvent(startedEvent))
+	_ = s.store.AppendEvent(ctx, startedEvent)
+	s.publishEvent(ctx, startedEvent)
 
 	metadata := cloneMetadataMap(req.Metadata)
 	if mode := strings.TrimSpace(req.BrowserMode); mode != "" {
@@ -156,6 +227,9 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 			metadata["browser_domain_allowlist"] = strings.Join(trimmed, ",")
 		}
 	}
+	if skills := normalizeRouteCriteria(req.Skills, false); len(skills) > 0 {
+		metadata["skills"] = strings.Join(skills, ",")
+	}
 
 	msg := store.Message{
 		ID:        uuid.New().String(),
@@ -166,16 +240,15 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: now,
 		Metadata:  metadata,
 	}
-	if err := s.store.AddMessage(r.Context(), msg); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if err := s.store.AddMessage(ctx, msg); err != nil {
+		return store.Run{}, err
 	}
-	s.indexMessageMemory(r.Context(), msg)
+	s.indexMessageMemory(ctx, msg)
 	if s.workflows != nil {
-		_ = s.workflows.SignalMessage(r.Context(), runID, prompt)
+		_ = s.workflows.SignalMessage(ctx, runID, prompt)
 	}
 
-	seq, _ = s.store.NextSeq(r.Context(), runID)
+	seq, _ = s.store.NextSeq(ctx, runID)
 	messageEvent := store.RunEvent{
 		RunID:     runID,
 		Seq:       seq,
@@ -189,29 +262,17 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 			"content":    msg.Content,
 		},
 	}
-	_ = s.store.AppendEvent(r.Context(), messageEvent)
-	s.broker.Publish(toEvent(messageEvent))
-
-	waitForCompletion := true
-	if req.WaitForCompletion != nil {
-		waitForCompletion = *req.WaitForCompletion
-	}
-	if !waitForCompletion {
-		writeJSONStatus(w, automationExecuteResponse{
-			RunID:  runID,
-			Status: "running",
-			Phase:  "planning",
-			Diagnostics: automationDiagnostics{
-				Sources:     []automationSourceDiagnostic{},
-				TotalEvents: 2,
-			},
-		}, http.StatusAccepted)
-		return
-	}
+	_ = s.store.AppendEvent(ctx, messageEvent)
+	s.publishEvent(ctx, messageEvent)
+	return run, nil
+}
 
+// awaitAutomationRun polls runID until it reaches a terminal event or the
+// request's timeout passes, and summarizes the result.
+func (s *Server) awaitAutomationRun(ctx context.Context, runID string, req automationExecuteRequest) (automationExecuteResponse, error) {
 	timeout := normalizeDuration(req.TimeoutMS, defaultAutomationTimeout, 5*time.Second, 30*time.Minute)
 	poll := normalizeDuration(req.PollIntervalMS, defaultAutomationPollInterval, 100*time.Millisecond, 5*time.Second)
-	waitCtx, cancel := contextWithTimeoutOrRequest(r.Context(), timeout)
+	waitCtx, cancel := contextWithTimeoutOrRequest(ctx, timeout)
 	defer cancel()
 
 	var (
@@ -221,8 +282,7 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 	for {
 		currentEvents, err := s.store.ListEvents(waitCtx, runID, 0)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return automationExecuteResponse{}, err
 		}
 		eventsList = currentEvents
 		terminal = latestTerminalEvent(eventsList)
@@ -239,10 +299,9 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	messages, err := s.store.ListMessages(r.Context(), runID)
+	messages, err := s.store.ListMessages(ctx, runID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return automationExecuteResponse{}, err
 	}
 	finalResponse, assistantMessages := latestAssistantMessage(messages)
 	status, phase, completionReason := deriveAutomationStatusFromTerminalEvent(terminal)
@@ -262,7 +321,7 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 		log.Printf("automation.final_response run_id=%s\n%s", runID, finalResponse)
 	}
 
-	writeJSONStatus(w, automationExecuteResponse{
+	return automationExecuteResponse{
 		RunID:            runID,
 		Status:           status,
 		Phase:            phase,
@@ -270,7 +329,7 @@ func (s *Server) executeAutomationRun(w http.ResponseWriter, r *http.Request) {
 		TimedOut:         timedOut,
 		FinalResponse:    finalResponse,
 		Diagnostics:      diagnostics,
-	}, http.StatusOK)
+	}, nil
 }
 
 func cloneMetadataMap(metadata map[string]any) map[string]any {
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	defaultChangeThreshold = 0.9
+
+	// When both results cite sources, the score blends text similarity with
+	// source URL overlap.
+	changeTextWeight   = 0.7
+	changeSourceWeight = 0.3
+
+	changeSummarySourceLimit = 3
+)
+
+// automationChange describes how a result differs from the previous
+// successful one.
+type automationChange struct {
+	Similarity     float64
+	TextSimilarity float64
+	SourceOverlap  float64
+	HasSources     bool
+	AddedSources   []string
+	RemovedSources []string
+}
+
+func normalizeChangeThreshold(value float64) (float64, error) {
+	if math.IsNaN(value) || value < 0 || value > 1 {
+		return 0, errors.New("change_threshold must be between 0 and 1")
+	}
+	return value, nil
+}
+
+func automationResultSucceeded(status string) bool {
+	switch normalizeAutomationStatus(status) {
+	case "completed", "partial":
+		return true
+	default:
+		return false
+	}
+}
+
+// previousSuccessfulInboxEntry returns the most recently completed successful
+// entry other than entryID.
+func previousSuccessfulInboxEntry(entries []store.AutomationInboxEntry, entryID string) *store.AutomationInboxEntry {
+	successful := make([]store.AutomationInboxEntry, 0, len(entries))
+	for _, entry := range entries {
+		if automationResultSucceeded(entry.Status) {
+			successful = append(successful, entry)
+		}
+	}
+	return previousCompletedInboxEntry(successful, entryID)
+}
+
+// applyAutomationChange records on entry how its result compares with
+// previous. Only successful results are compared.
+func applyAutomationChange(entry *store.AutomationInboxEntry, previous *store.AutomationInboxEntry, threshold float64) {
+	entry.ChangeSummary = ""
+	entry.Similarity = nil
+	entry.Unchanged = false
+	if !automationResultSucceeded(entry.Status) {
+		return
+	}
+	if previous == nil {
+		entry.ChangeSummary = "First successful result."
+		return
+	}
+	change := diffAutomationResults(*entry, *previous)
+	similarity := math.Round(change.Similarity*1000) / 1000
+	entry.Similarity = &similarity
+	entry.Unchanged = threshold > 0 && change.Similarity >= threshold
+	entry.ChangeSummary = summarizeAutomationChange(change, entry.Unchanged)
+}
+
+func diffAutomationResults(current store.AutomationInboxEntry, previous store.AutomationInboxEntry) automationChange {
+	change := automationChange{
+		TextSimilarity: textSimilarity(current.FinalResponse, previous.FinalResponse),
+	}
+	currentSources := diagnosticSourceURLs(current.Diagnostics)
+	previousSources := diagnosticSourceURLs(previous.Diagnostics)
+	change.Similarity = change.TextSimilarity
+	if len(currentSources) == 0 && len(previousSources) == 0 {
+		return change
+	}
+	change.HasSources = true
+	shared := 0
+	for key, raw := range currentSources {
+		if _, ok := previousSources[key]; ok {
+			shared++
+			continue
+		}
+		change.AddedSources = append(change.AddedSources, raw)
+	}
+	for key, raw := range previousSources {
+		if _, ok := currentSources[key]; !ok {
+			change.RemovedSources = append(change.RemovedSources, raw)
+		}
+	}
+	sort.Strings(change.AddedSources)
+	sort.Strings(change.RemovedSources)
+	union := len(currentSources) + len(previousSources) - shared
+	change.SourceOverlap = float64(shared) / float64(union)
+	change.Similarity = changeTextWeight*change.TextSimilarity + changeSourceWeight*change.SourceOverlap
+	return change
+}
+
+func summarizeAutomationChange(change automationChange, unchanged bool) string {
+	prefix := "Changed"
+	if unchanged {
+		prefix = "Unchanged"
+	}
+	parts := []string{fmt.Sprintf("%s: %d%% similar to the previous result", prefix, int(math.Round(change.Similarity*100)))}
+	if change.HasSources {
+		if len(change.AddedSources) == 0 && len(change.RemovedSources) == 0 {
+			parts = append(parts, "same sources")
+		}
+		if count := len(change.AddedSources); count > 0 {
+			parts = append(parts, fmt.Sprintf("%d new %s (%s)", count, pluralize(count, "source"), strings.Join(limitStrings(change.AddedSources, changeSummarySourceLimit), ", ")))
+		}
+		if count := len(change.RemovedSources); count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s dropped", count, pluralize(count, "source")))
+		}
+	}
+	return strings.Join(parts, "; ") + "."
+}
+
+// diagnosticSourceURLs maps normalized source URLs to their original form.
+func diagnosticSourceURLs(diagnostics map[string]any) map[string]string {
+	urls := map[string]string{}
+	for _, source := range decodeAutomationDiagnostics(diagnostics).Sources {
+		raw := strings.TrimSpace(source.URL)
+		if key := normalizeSourceURL(raw); key != "" {
+			urls[key] = raw
+		}
+	}
+	return urls
+}
+
+// normalizeSourceURL drops the scheme, "www.", fragment and trailing slash so
+// trivially different links to the same page compare equal.
+func normalizeSourceURL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(strings.TrimRight(strings.TrimSpace(raw), "/"))
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	path := strings.TrimRight(parsed.EscapedPath(), "/")
+	key := host + path
+	if parsed.RawQuery != "" {
+		key += "?" + parsed.RawQuery
+	}
+	return key
+}
+
+// textSimilarity is the cosine similarity of word and word-pair counts, so
+// both vocabulary and phrasing have to match for a high score.
+func textSimilarity(a string, b string) float64 {
+	left := termCounts(a)
+	right := termCounts(b)
+	if len(left) == 0 && len(right) == 0 {
+		return 1
+	}
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+	var dot, leftNorm, rightNorm float64
+	for term, count := range left {
+		leftNorm += count * count
+		dot += count * right[term]
+	}
+	for _, count := range right {
+		rightNorm += count * count
+	}
+	return dot / (math.Sqrt(leftNorm) * math.Sqrt(rightNorm))
+}
+
+func termCounts(text string) map[string]float64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	counts := map[string]float64{}
+	for idx, word := range words {
+		counts[word]++
+		if idx > 0 {
+			counts[words[idx-1]+" "+word]++
+		}
+	}
+	return counts
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return noun
+	}
+	return noun + "s"
+}
+
+func limitStrings(values []string, limit int) []string {
+	if len(values) <= limit {
+		return values
+	}
+	return values[:limit]
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+func diagnosticsWithSources(urls ...string) map[string]any {
+	sources := make([]any, 0, len(urls))
+	for _, url := range urls {
+		sources = append(sources, map[string]any{"url": url})
+	}
+	return map[string]any{"sources": sources}
+}
+
+func TestApplyAutomationChange(t *testing.T) {
+	previous := &store.AutomationInboxEntry{
+		Status:        "completed",
+		FinalResponse: "Markets were flat today. Bitcoin traded near 60k.",
+		Diagnostics:   diagnosticsWithSources("https://news.test/a", "https://news.test/b"),
+	}
+
+	same := store.AutomationInboxEntry{
+		Status:        "completed",
+		FinalResponse: "Markets were flat today. Bitcoin traded near 60k.",
+		Diagnostics:   diagnosticsWithSources("http://www.news.test/a/", "https://news.test/b#top"),
+	}
+	applyAutomationChange(&same, previous, defaultChangeThreshold)
+	require.True(t, same.Unchanged)
+	require.NotNil(t, same.Similarity)
+	require.Equal(t, 1.0, *same.Similarity)
+	require.Equal(t, "Unchanged: 100% similar to the previous result; same sources.", same.ChangeSummary)
+
+	changed := store.AutomationInboxEntry{
+		Status:        "partial",
+		FinalResponse: "An exchange outage halted trading for two hours.",
+		Diagnostics:   diagnosticsWithSources("https://news.test/a", "https://status.test/incident"),
+	}
+	applyAutomationChange(&changed, previous, defaultChangeThreshold)
+	require.False(t, changed.Unchanged)
+	require.Less(t, *changed.Similarity, defaultChangeThreshold)
+	require.Contains(t, changed.ChangeSummary, "Changed: ")
+	require.Contains(t, changed.ChangeSummary, "1 new source (https://status.test/incident)")
+	require.Contains(t, changed.ChangeSummary, "1 source dropped")
+
+	disabled := same
+	applyAutomationChange(&disabled, previous, 0)
+	require.False(t, disabled.Unchanged)
+	require.NotNil(t, disabled.Similarity)
+
+	first := store.AutomationInboxEntry{Status: "completed", FinalResponse: "Hello"}
+	applyAutomationChange(&first, nil, defaultChangeThreshold)
+	require.Equal(t, "First successful result.", first.ChangeSummary)
+	require.Nil(t, first.Similarity)
+
+	failed := store.AutomationInboxEntry{Status: "failed", ChangeSummary: "stale", Unchanged: true}
+	applyAutomationChange(&failed, previous, defaultChangeThreshold)
+	require.Empty(t, failed.ChangeSummary)
+	require.Nil(t, failed.Similarity)
+	require.False(t, failed.Unchanged)
+}
+
+func TestPreviousSuccessfulInboxEntry(t *testing.T) {
+	entries := []store.AutomationInboxEntry{
+		{ID: "new", Status: "running"},
+		{ID: "c", Status: "failed", CompletedAt: "2026-01-03T00:00:00Z"},
+		{ID: "b", Status: "completed", CompletedAt: "2026-01-02T00:00:00Z"},
+		{ID: "a", Status: "completed", CompletedAt: "2026-01-01T00:00:00Z"},
+	}
+	previous := previousSuccessfulInboxEntry(entries, "new")
+	require.NotNil(t, previous)
+	require.Equal(t, "b", previous.ID)
+	require.Nil(t, previousSuccessfulInboxEntry(entries[:2], "new"))
+}
+
+func TestChangeHelpers(t *testing.T) {
+	require.Equal(t, "example.com/page?q=1", normalizeSourceURL("https://www.Example.com/page/?q=1#frag"))
+	require.Equal(t, "not a url", normalizeSourceURL("Not a URL/"))
+	require.Equal(t, 1.0, textSimilarity("", ""))
+	require.Equal(t, 0.0, textSimilarity("something", ""))
+	require.InDelta(t, 1.0, textSimilarity("Same words.", "same words"), 1e-9)
+
+	_, err := normalizeChangeThreshold(1.5)
+	require.Error(t, err)
+	value, err := normalizeChangeThreshold(0)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, value)
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/notify"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	automationKindPrompt = "prompt"
+	automationKindDigest = "digest"
+
+	digestCadenceDaily  = "daily"
+	digestCadenceWeekly = "weekly"
+
+	// digestEmptyReason marks digests that found nothing to summarize; they
+	// are recorded read and not sent.
+	digestEmptyReason = "no_unread_results"
+	// digestFallbackReason marks digests listed without the utility model.
+	digestFallbackReason = "utility_model_unavailable"
+
+	digestGenerateTimeout = 60 * time.Second
+	digestEntryChars      = 1500
+	digestExcerptChars    = 280
+)
+
+// automationDigestItem is one result included in a digest.
+type automationDigestItem struct {
+	AutomationID   string `json:"automation_id"`
+	AutomationName string `json:"automation_name"`
+	EntryID        string `json:"entry_id"`
+	RunID          string `json:"run_id,omitempty"`
+	RunURL         string `json:"run_url,omitempty"`
+	Status         string `json:"status"`
+	CompletedAt    string `json:"completed_at"`
+	Unchanged      bool   `json:"unchanged,omitempty"`
+	// Result is the final response, or the error of a failed run; it is not
+	// stored with the digest.
+	Result string `json:"-"`
+}
+
+func normalizeAutomationKind(value string) (string, error) {
+	switch strings.TrimSpace(strings.ToLower(value)) {
+	case "", automationKindPrompt:
+		return automationKindPrompt, nil
+	case automationKindDigest:
+		return automationKindDigest, nil
+	case automationKindPipeline:
+		return automationKindPipeline, nil
+	default:
+		return "", errors.New("kind must be prompt, digest or pipeline")
+	}
+}
+
+func normalizeDigestCadence(value string) (string, error) {
+	switch strings.TrimSpace(strings.ToLower(value)) {
+	case "", digestCadenceDaily:
+		return digestCadenceDaily, nil
+	case digestCadenceWeekly:
+		return digestCadenceWeekly, nil
+	default:
+		return "", errors.New("digest_cadence must be daily or weekly")
+	}
+}
+
+// digestDays returns the schedule days for a cadence. Weekly digests run on
+// the first requested day, Monday by default.
+func digestDays(cadence string, days []string) []string {
+	if cadence != digestCadenceWeekly {
+		return normalizeDays(days)
+	}
+	if len(days) == 0 {
+		return []string{"mon"}
+	}
+	return normalizeDays(days)[:1]
+}
+
+func digestWindow(cadence string) time.Duration {
+	if cadence == digestCadenceWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// resolveDigestAutomations checks that every member is a prompt automation in
+// the caller's workspace.
+func (s *Server) resolveDigestAutomations(ctx context.Context, ids []string) ([]string, int, error) {
+	normalized := normalizeRouteCriteria(ids, false)
+	for _, id := range normalized {
+		automation, err := s.store.GetAutomation(ctx, id)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if automation == nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("unknown automation: %s", id)
+		}
+		if automation.Kind == automationKindDigest {
+			return nil, http.StatusBadRequest, fmt.Errorf("digest cannot include another digest: %s", id)
+		}
+	}
+	return nonNilStrings(normalized), http.StatusOK, nil
+}
+
+// collectDigestItems returns the unread, finished results of the digest's
+// members completed since its last run, or within one cadence window on the
+// first run, oldest first.
+func (s *Server) collectDigestItems(ctx context.Context, digest store.Automation, now time.Time) ([]automationDigestItem, error) {
+	since := now.Add(-digestWindow(digest.DigestCadence))
+	if last, err := time.Parse(time.RFC3339Nano, digest.LastRunAt); err == nil {
+		since = last
+	}
+	members := map[string]bool{}
+	for _, id := range digest.DigestAutomationIDs {
+		members[id] = true
+	}
+	automations, err := s.store.ListAutomations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]automationDigestItem, 0)
+	for _, automation := range automations {
+		if automation.Kind == automationKindDigest || (len(members) > 0 && !members[automation.ID]) {
+			continue
+		}
+		entries, err := s.store.ListAutomationInbox(ctx, automation.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			completedAt, err := time.Parse(time.RFC3339Nano, entry.CompletedAt)
+			if err != nil || !entry.Unread || completedAt.Before(since) {
+				continue
+			}
+			item := automationDigestItem{
+				AutomationID:   automation.ID,
+				AutomationName: automation.Name,
+				EntryID:        entry.ID,
+				RunID:          entry.RunID,
+				Status:         normalizeAutomationStatus(entry.Status),
+				CompletedAt:    entry.CompletedAt,
+				Unchanged:      entry.Unchanged,
+				Result:         strings.TrimSpace(firstNonEmpty(entry.FinalResponse, entry.Error)),
+			}
+			if item.RunID != "" {
+				item.RunURL = strings.TrimRight(s.cfg.ControlPlaneURL, "/") + "/runs/" + item.RunID
+			}
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CompletedAt < items[j].CompletedAt
+	})
+	return items, nil
+}
+
+// runAutomationDigest summarizes the digest's unread results with the utility
+// model, falling back to a plain list. Unless markRead is false, as for a dry
+// run, the results are then marked read.
+func (s *Server) runAutomationDigest(ctx context.Context, digest store.Automation, markRead bool) (automationExecuteResponse, error) {
+	items, err := s.collectDigestItems(ctx, digest, time.Now().UTC())
+	if err != nil {
+		return automationExecuteResponse{}, err
+	}
+	response := automationExecuteResponse{
+		Status:      "completed",
+		Diagnostics: automationDiagnostics{Sources: []automationSourceDiagnostic{}, DigestEntries: items},
+	}
+	if len(items) == 0 {
+		response.CompletionReason = digestEmptyReason
+		response.FinalResponse = "No unread automation results since the last digest."
+		return response, nil
+	}
+
+	text, err := s.generateDigest(ctx, digest, items)
+	if err != nil {
+		log.Printf("automation digest fell back to list automation_id=%s err=%v", digest.ID, err)
+		response.CompletionReason = digestFallbackReason
+		text = fallbackDigest(items)
+	}
+	response.FinalResponse = appendMissingRunLinks(text, items)
+	if !markRead {
+		return response, nil
+	}
+
+	for _, item := range items {
+		if err := s.store.MarkAutomationInboxEntryRead(ctx, item.AutomationID, item.EntryID); err != nil {
+			log.Printf("automation digest mark read failed automation_id=%s entry_id=%s err=%v", item.AutomationID, item.EntryID, err)
+		}
+	}
+	return response, nil
+}
+
+func (s *Server) generateDigest(ctx context.Context, digest store.Automation, items []automationDigestItem) (string, error) {
+	config, ok, err := s.utilityLLMConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.New("no utility model configured")
+	}
+	provider, err := newLLMProvider(config)
+	if err != nil {
+		return "", err
+	}
+	if !s.providerHealth.Allow(config.Provider, config.Model) {
+		return "", fmt.Errorf("utility model %s/%s is unavailable", config.Provider, config.Model)
+	}
+	generateCtx, cancel := context.WithTimeout(ctx, digestGenerateTimeout)
+	defer cancel()
+	text, err := provider.Generate(generateCtx, buildDigestPrompt(digest, items))
+	if err != nil {
+		s.providerHealth.RecordFailure(config.Provider, config.Model, err.Error(), llm.RetryAfterFromError(err))
+		return "", err
+	}
+	s.providerHealth.RecordSuccess(config.Provider, config.Model)
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("utility model returned an empty digest")
+	}
+	return text, nil
+}
+
+func buildDigestPrompt(digest store.Automation, items []automationDigestItem) []llm.Message {
+	instructions := "You write short digests of scheduled automation results. " +
+		"Group related findings, lead with what changed or needs attention, mention failures plainly, " +
+		"and keep each result's run link next to the points it supports. Use plain text or simple markdown."
+	if extra := strings.TrimSpace(digest.Prompt); extra != "" {
+		instructions += "\n\nAdditional instructions:\n" + extra
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Write a %s digest of these %d automation results.\n", fallbackString(digest.DigestCadence, digestCadenceDaily), len(items))
+	for idx, item := range items {
+		fmt.Fprintf(&builder, "\n## %d. %s (%s, %s)\n", idx+1, fallbackString(item.AutomationName, item.AutomationID), item.Status, item.CompletedAt)
+		if item.RunURL != "" {
+			fmt.Fprintf(&builder, "Run: %s\n", item.RunURL)
+		}
+		if item.Unchanged {
+			builder.WriteString("Marked unchanged from the previous result.\n")
+		}
+		builder.WriteString(notify.Truncate(fallbackString(item.Result, "(no output)"), digestEntryChars))
+		builder.WriteString("\n")
+	}
+	return []llm.Message{
+		{Role: "system", Content: instructions},
+		{Role: "user", Content: builder.String()},
+	}
+}
+
+func fallbackDigest(items []automationDigestItem) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d automation %s:\n", len(items), pluralize(len(items), "result"))
+	for _, item := range items {
+		fmt.Fprintf(&builder, "\n- %s (%s)", fallbackString(item.AutomationName, item.AutomationID), item.Status)
+		if item.Unchanged {
+			builder.WriteString(" [unchanged]")
+		}
+		if excerpt := notify.Truncate(strings.Join(strings.Fields(item.Result), " "), digestExcerptChars); excerpt != "" {
+			builder.WriteString(": " + excerpt)
+		}
+		if item.RunURL != "" {
+			builder.WriteString(" " + item.RunURL)
+		}
+	}
+	return builder.String()
+}
+
+// appendMissingRunLinks lists the run links the digest text left out, so
+// every result stays reachable.
+func appendMissingRunLinks(text string, items []automationDigestItem) string {
+	missing := make([]string, 0)
+	for _, item := range items {
+		if item.RunURL != "" && !strings.Contains(text, item.RunURL) {
+			missing = append(missing, fmt.Sprintf("- %s: %s", fallbackString(item.AutomationName, item.AutomationID), item.RunURL))
+		}
+	}
+	if len(missing) == 0 {
+		return text
+	}
+	return text + "\n\nRuns:\n" + strings.Join(missing, "\n")
+}
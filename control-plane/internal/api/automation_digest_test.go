@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/notify"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestAutomationDigestSettings(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	promptResp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Brief","prompt":"Summarize"}`))
+	defer promptResp.Body.Close()
+	var prompt automationSchedule
+	require.NoError(t, json.NewDecoder(promptResp.Body).Decode(&prompt))
+	require.Equal(t, automationKindPrompt, prompt.Kind)
+
+	for _, body := range []string{
+		`{"name":"Digest","kind":"summary"}`,
+		`{"name":"Digest","kind":"digest","digest_cadence":"hourly"}`,
+		`{"name":"Digest","kind":"digest","digest_automation_ids":["missing"]}`,
+		`{"name":"Plain"}`,
+	} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	createResp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Weekly digest","kind":"digest","digest_cadence":"weekly","days":["fri","sat"],"digest_automation_ids":["`+prompt.ID+`"]}`))
+	defer createResp.Body.Close()
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+	var digest automationSchedule
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&digest))
+	require.Equal(t, automationKindDigest, digest.Kind)
+	require.Equal(t, digestCadenceWeekly, digest.DigestCadence)
+	require.Equal(t, []string{"fri"}, digest.Days)
+	require.Equal(t, []string{prompt.ID}, digest.DigestAutomationIDs)
+	require.Zero(t, digest.ChangeThreshold)
+
+	nested := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Nested","kind":"digest","digest_automation_ids":["`+digest.ID+`"]}`))
+	nested.Body.Close()
+	require.Equal(t, http.StatusBadRequest, nested.StatusCode)
+
+	kindResp := doWithKey(t, http.MethodPut, server.URL+"/automations/"+digest.ID, "", []byte(`{"kind":"prompt"}`))
+	kindResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, kindResp.StatusCode)
+
+	updateResp := doWithKey(t, http.MethodPut, server.URL+"/automations/"+digest.ID, "", []byte(`{"digest_cadence":"daily","digest_automation_ids":[]}`))
+	defer updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+	var updated automationSchedule
+	require.NoError(t, json.NewDecoder(updateResp.Body).Decode(&updated))
+	require.Equal(t, digestCadenceDaily, updated.DigestCadence)
+	require.Equal(t, []string{"fri"}, updated.Days)
+	require.Empty(t, updated.DigestAutomationIDs)
+}
+
+func TestRunAutomationDigest(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	var received []notify.WebhookPayload
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	providerMock := &MockProvider{}
+	original := newLLMProvider
+	newLLMProvider = func(cfg llm.Config) (llm.Provider, error) {
+		require.Equal(t, "openai", cfg.Provider)
+		require.Equal(t, "gpt-mini", cfg.Model)
+		return providerMock, nil
+	}
+	t.Cleanup(func() { newLLMProvider = original })
+
+	api := NewServer(mem, &MockBroker{}, nil, config.Config{
+		LLMSecretsKey:      testLLMSecretsKey,
+		ControlPlaneURL:    "http://gavryn.test/",
+		LLMUtilityProvider: "openai",
+		LLMUtilityModel:    "gpt-mini",
+	})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	channelResp := doWithKey(t, http.MethodPost, server.URL+"/settings/notifications", "", []byte(`{"type":"webhook","config":{"url":"`+receiver.URL+`"}}`))
+	defer channelResp.Body.Close()
+	var channel notificationChannelRecord
+	require.NoError(t, json.NewDecoder(channelResp.Body).Decode(&channel))
+
+	now := time.Now().UTC()
+	stamp := func(offset time.Duration) string { return now.Add(offset).Format(time.RFC3339Nano) }
+	for _, automation := range []store.Automation{
+		{ID: "markets", Name: "Markets", Prompt: "p", Kind: automationKindPrompt, NotifyOn: notifyOnDigest},
+		{ID: "weather", Name: "Weather", Prompt: "p", Kind: automationKindPrompt},
+		{ID: "other", Name: "Other", Prompt: "p", Kind: automationKindPrompt},
+	} {
+		require.NoError(t, mem.CreateAutomation(ctx, automation))
+	}
+	for _, entry := range []store.AutomationInboxEntry{
+		{ID: "m1", AutomationID: "markets", RunID: "run-m1", Status: "completed", FinalResponse: "Bitcoin rallied.", Unread: true, CompletedAt: stamp(-2 * time.Hour)},
+		{ID: "m0", AutomationID: "markets", RunID: "run-m0", Status: "completed", FinalResponse: "Old news.", Unread: true, CompletedAt: stamp(-48 * time.Hour)},
+		{ID: "w1", AutomationID: "weather", RunID: "run-w1", Status: "failed", Error: "timeout", Unread: true, CompletedAt: stamp(-time.Hour)},
+		{ID: "w0", AutomationID: "weather", RunID: "run-w0", Status: "completed", FinalResponse: "Sunny.", Unread: false, CompletedAt: stamp(-time.Hour)},
+		{ID: "o1", AutomationID: "other", RunID: "run-o1", Status: "completed", FinalResponse: "Skipped.", Unread: true, CompletedAt: stamp(-time.Hour)},
+	} {
+		require.NoError(t, mem.CreateAutomationInboxEntry(ctx, entry))
+	}
+
+	digestResp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Morning","kind":"digest","digest_automation_ids":["markets","weather"],"notification_channels":["`+channel.ID+`"]}`))
+	defer digestResp.Body.Close()
+	require.Equal(t, http.StatusCreated, digestResp.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(digestResp.Body).Decode(&created))
+	digest, err := mem.GetAutomation(ctx, created.ID)
+	require.NoError(t, err)
+
+	var prompt []llm.Message
+	providerMock.On("Generate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		prompt = args.Get(1).([]llm.Message)
+	}).Return("Markets rallied (http://gavryn.test/runs/run-m1); the weather check failed.", nil).Once()
+
+	runDigest := func(entryID string) store.AutomationInboxEntry {
+		require.NoError(t, mem.CreateAutomationInboxEntry(ctx, store.AutomationInboxEntry{ID: entryID, AutomationID: digest.ID, Status: "queued", Trigger: "manual", StartedAt: stamp(0)}))
+		current, err := mem.GetAutomation(ctx, digest.ID)
+		require.NoError(t, err)
+		api.executeAutomationJob(ctx, *current, entryID)
+		entries, err := mem.ListAutomationInbox(ctx, digest.ID)
+		require.NoError(t, err)
+		entry := findInboxEntry(entries, entryID)
+		require.NotNil(t, entry)
+		return *entry
+	}
+
+	entry := runDigest("d1")
+	require.Equal(t, "completed", entry.Status)
+	require.True(t, entry.Unread)
+	require.Empty(t, entry.CompletionReason)
+	require.Contains(t, entry.FinalResponse, "Markets rallied")
+	require.Contains(t, entry.FinalResponse, "Runs:\n- Weather: http://gavryn.test/runs/run-w1")
+	require.NotContains(t, entry.FinalResponse, "- Markets: ")
+	items := decodeAutomationDiagnostics(entry.Diagnostics).DigestEntries
+	require.Len(t, items, 2)
+	require.Equal(t, "m1", items[0].EntryID)
+	require.Equal(t, "w1", items[1].EntryID)
+	require.Len(t, prompt, 2)
+	require.Contains(t, prompt[1].Content, "Bitcoin rallied.")
+	require.Contains(t, prompt[1].Content, "timeout")
+	require.NotContains(t, prompt[1].Content, "Skipped.")
+
+	require.Len(t, received, 1)
+	require.Equal(t, "Gavryn digest: Morning", received[0].Title)
+
+	markets, err := mem.ListAutomationInbox(ctx, "markets")
+	require.NoError(t, err)
+	require.False(t, findInboxEntry(markets, "m1").Unread)
+	require.True(t, findInboxEntry(markets, "m0").Unread)
+
+	// Nothing new since the last digest: recorded read and not sent.
+	empty := runDigest("d2")
+	require.Equal(t, digestEmptyReason, empty.CompletionReason)
+	require.False(t, empty.Unread)
+	require.Len(t, received, 1)
+
+	// Without the utility model the digest lists each result.
+	require.NoError(t, mem.CreateAutomationInboxEntry(ctx, store.AutomationInboxEntry{ID: "m2", AutomationID: "markets", RunID: "run-m2", Status: "completed", FinalResponse: "Ether  dipped.", Unread: true, CompletedAt: time.Now().UTC().Add(time.Second).Format(time.RFC3339Nano)}))
+	providerMock.On("Generate", mock.Anything, mock.Anything).Return("", errors.New("rate limited")).Once()
+	fallback := runDigest("d3")
+	require.Equal(t, digestFallbackReason, fallback.CompletionReason)
+	require.Contains(t, fallback.FinalResponse, "- Markets (completed): Ether dipped. http://gavryn.test/runs/run-m2")
+	require.Len(t, received, 2)
+}
+
+func TestShouldNotifyAutomationDigestPolicy(t *testing.T) {
+	require.False(t, shouldNotifyAutomation(notifyOnDigest, store.AutomationInboxEntry{Status: "failed"}, nil))
+	value, err := normalizeNotifyOn("Digest")
+	require.NoError(t, err)
+	require.Equal(t, notifyOnDigest, value)
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+// automationDryRunTag tags the runs of dry runs, so outbound webhooks and run
+// triggers ignore them.
+const automationDryRunTag = "dry_run"
+
+// automationDryRunResponse carries the rendered prompt of a prompt
+// automation; a pipeline's stage prompts are in the entry's diagnostics.
+type automationDryRunResponse struct {
+	Prompt string               `json:"prompt,omitempty"`
+	Entry  automationInboxEntry `json:"entry"`
+}
+
+// isDryRunEntry reports whether entry records a dry run. Dry runs are kept
+// out of unread counts, change detection and {{.LastSummary}}.
+func isDryRunEntry(entry store.AutomationInboxEntry) bool {
+	return entry.Trigger == automationTriggerDryRun
+}
+
+// withoutDryRuns returns entries other than dry runs.
+func withoutDryRuns(entries []store.AutomationInboxEntry) []store.AutomationInboxEntry {
+	return slices.DeleteFunc(slices.Clone(entries), isDryRunEntry)
+}
+
+// dryRunAutomation runs an automation with its full configuration and waits
+// for the result. The request may supply an unsaved prompt and the trigger
+// and payload of an event, as for a prompt preview. Its runs are tagged
+// dry_run, so outbound webhooks and run triggers skip them, and the result
+// is recorded as an inbox entry that is already read. The automation's
+// schedule, retries, change detection and notifications are left alone, and
+// a digest dry run does not mark the results it summarizes read.
+func (s *Server) dryRunAutomation(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	req := promptPreviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	schedule, err := s.store.GetAutomation(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if schedule == nil {
+		http.Error(w, "automation not found", http.StatusNotFound)
+		return
+	}
+	if req.Prompt != nil {
+		if schedule.Kind == automationKindPipeline {
+			http.Error(w, "pipeline prompts are set per stage", http.StatusBadRequest)
+			return
+		}
+		schedule.Prompt = strings.TrimSpace(*req.Prompt)
+	}
+	trigger := fallbackString(strings.TrimSpace(req.Trigger), automationTriggerManual)
+	now := time.Now().UTC()
+	data := s.buildAutomationPromptData(r.Context(), *schedule, "", trigger, req.Payload, now)
+	prompt := ""
+	if schedule.Kind != automationKindDigest && schedule.Kind != automationKindPipeline {
+		if prompt, err = renderAutomationPrompt(schedule.Prompt, data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The run outlives a client that stops waiting, so its entry is finished.
+	ctx := context.WithoutCancel(r.Context())
+	startedAt := now.Format(time.RFC3339Nano)
+	entry := store.AutomationInboxEntry{
+		ID:             uuid.NewString(),
+		AutomationID:   schedule.ID,
+		Status:         "running",
+		Trigger:        automationTriggerDryRun,
+		Attempt:        1,
+		TriggerPayload: req.Payload,
+		StartedAt:      startedAt,
+		CreatedAt:      startedAt,
+		UpdatedAt:      startedAt,
+		Diagnostics:    map[string]any{"sources": []any{}},
+	}
+	if err := s.store.CreateAutomationInboxEntry(ctx, entry); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The lease keeps other replicas from failing the dry run while it runs.
+	s.automationMu.Lock()
+	release, err := s.leaseAutomationJob(ctx, schedule.ID, entry.ID)
+	s.automationMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	// Every run the dry run starts, including pipeline stages and the runs
+	// that judge their conditions, carries the dry_run tag.
+	if !slices.Contains(schedule.Tags, automationDryRunTag) {
+		schedule.Tags = append(slices.Clone(schedule.Tags), automationDryRunTag)
+	}
+	var response automationExecuteResponse
+	switch schedule.Kind {
+	case automationKindDigest:
+		response, err = s.runAutomationDigest(ctx, *schedule, false)
+	case automationKindPipeline:
+		response, err = s.runAutomationPipeline(ctx, *schedule, entry.ID, data, true)
+	default:
+		execReq := automationPromptRequest(*schedule)
+		execReq.Prompt = prompt
+		execReq.Metadata["automation_entry_id"] = entry.ID
+		response, err = s.invokeAutomationExecute(ctx, execReq)
+	}
+	if err != nil {
+		entry.Status = "failed"
+		entry.Error = err.Error()
+		// A pipeline that failed part way keeps its stage results.
+		if len(response.Diagnostics.PipelineStages) > 0 {
+			entry.RunID = response.RunID
+			entry.Diagnostics = encodeAutomationDiagnostics(response.Diagnostics)
+		}
+	} else {
+		entry.RunID = response.RunID
+		entry.Status = response.Status
+		entry.Phase = response.Phase
+		entry.CompletionReason = response.CompletionReason
+		entry.FinalResponse = response.FinalResponse
+		entry.TimedOut = response.TimedOut
+		entry.Diagnostics = encodeAutomationDiagnostics(response.Diagnostics)
+	}
+	entry.CompletedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	entry.UpdatedAt = entry.CompletedAt
+	s.automationMu.Lock()
+	err = s.store.UpdateAutomationInboxEntry(ctx, entry)
+	s.automationMu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, schedule.ID, nil, map[string]any{"entry_id": entry.ID, "run_id": entry.RunID})
+	writeJSONStatus(w, automationDryRunResponse{Prompt: prompt, Entry: toInboxRecord(entry)}, http.StatusOK)
+}
+
+// failOrphanedDryRun marks a dry-run entry that was left running by a
+// control plane that stopped as failed. Unlike completeAutomationJob it
+// leaves the automation, its retries and its notifications alone. Only dry
+// runs whose lease lapsed are failed, so none is still running.
+func (s *Server) failOrphanedDryRun(ctx context.Context, entry store.AutomationInboxEntry) error {
+	completedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	entry.Status = "failed"
+	entry.Error = automationInterruptedError
+	entry.Unread = false
+	entry.CompletedAt = completedAt
+	entry.UpdatedAt = completedAt
+	s.automationMu.Lock()
+	defer s.automationMu.Unlock()
+	return s.store.UpdateAutomationInboxEntry(ctx, entry)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/notify"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestDryRunAutomation(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", Model: "gpt-4.1"}))
+	var (
+		mu       sync.Mutex
+		received []notify.WebhookPayload
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	notified := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received)
+	}
+
+	workflows := &replyingWorkflows{store: mem, reply: func(prompt string) string { return "Re: " + prompt }}
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	api := NewServer(mem, brokerMock, workflows, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	channelResp := doWithKey(t, http.MethodPost, server.URL+"/settings/notifications", "", []byte(`{"type":"webhook","config":{"url":"`+receiver.URL+`"}}`))
+	defer channelResp.Body.Close()
+	var channel notificationChannelRecord
+	require.NoError(t, json.NewDecoder(channelResp.Body).Decode(&channel))
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Brief","prompt":"Since {{.LastSummary}}","tags":["research"],"run_at":"2099-01-01T00:00:00Z","change_threshold":0,"notification_channels":["`+channel.ID+`"]}`))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	queued, reason := api.queueAutomationExecution(ctx, created.ID, automationTriggerManual)
+	require.True(t, queued, reason)
+	waitForInbox(t, mem, created.ID, 1)
+	require.Eventually(t, func() bool { return notified() == 1 }, 5*time.Second, 20*time.Millisecond)
+	before, err := mem.GetAutomation(ctx, created.ID)
+	require.NoError(t, err)
+
+	dryResp := doWithKey(t, http.MethodPost, server.URL+"/automations/"+created.ID+"/dry-run", "", []byte(`{"prompt":"Check {{.Payload.ticker}} since {{.LastSummary}}","trigger":"webhook","payload":{"ticker":"SOL"}}`))
+	defer dryResp.Body.Close()
+	require.Equal(t, http.StatusOK, dryResp.StatusCode)
+	var result automationDryRunResponse
+	require.NoError(t, json.NewDecoder(dryResp.Body).Decode(&result))
+	require.Equal(t, "Check SOL since Re: Since", result.Prompt)
+	require.Equal(t, automationTriggerDryRun, result.Entry.Trigger)
+	require.Equal(t, "completed", result.Entry.Status)
+	require.Equal(t, "Re: Check SOL since Re: Since", result.Entry.FinalResponse)
+	require.False(t, result.Entry.Unread)
+	require.Empty(t, result.Entry.ChangeSummary)
+	require.Equal(t, 1, result.Entry.Diagnostics.AssistantMessage)
+	run, err := mem.GetRun(ctx, result.Entry.RunID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"research", automationDryRunTag}, run.Tags)
+
+	// The automation, its saved prompt and its inbox summary are untouched.
+	after, err := mem.GetAutomation(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+	require.Equal(t, 1, notified())
+	listResp := doWithKey(t, http.MethodGet, server.URL+"/automations", "", nil)
+	defer listResp.Body.Close()
+	var listed automationsListResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Equal(t, 1, listed.UnreadCount)
+	require.Equal(t, "completed", listed.Automations[0].LastStatus)
+
+	// The next real run compares with, and summarizes, the last real run.
+	queued, reason = api.queueAutomationExecution(ctx, created.ID, automationTriggerManual)
+	require.True(t, queued, reason)
+	entries := waitForInbox(t, mem, created.ID, 3)
+	require.Equal(t, []string{"Since", "Check SOL since Re: Since", "Since Re: Since"}, workflows.Prompts())
+	require.NotEqual(t, "First successful result.", entries[0].ChangeSummary)
+	require.NotEmpty(t, entries[0].ChangeSummary)
+	require.Eventually(t, func() bool { return notified() == 2 }, 5*time.Second, 20*time.Millisecond)
+
+	bad := doWithKey(t, http.MethodPost, server.URL+"/automations/"+created.ID+"/dry-run", "", []byte(`{"prompt":"{{.Payload.ticker}}"}`))
+	bad.Body.Close()
+	require.Equal(t, http.StatusBadRequest, bad.StatusCode)
+	missing := doWithKey(t, http.MethodPost, server.URL+"/automations/missing/dry-run", "", nil)
+	missing.Body.Close()
+	require.Equal(t, http.StatusNotFound, missing.StatusCode)
+}
+
+func TestDryRunPipeline(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", Model: "gpt-4.1"}))
+	workflows := &replyingWorkflows{store: mem, reply: func(prompt string) string { return "Re: " + prompt }}
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	api := NewServer(mem, brokerMock, workflows, config.Config{})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{
+		"name":"Watchlist","kind":"pipeline","tags":["research"],"run_at":"2099-01-01T00:00:00Z","stages":[
+			{"name":"research","prompt":"Research {{.Payload.ticker}}"},
+			{"name":"report","prompt":"Report on {{.Previous}}","condition":{"type":"keyword","keywords":["SOL"]}}
+		]}`))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	before, err := mem.GetAutomation(ctx, created.ID)
+	require.NoError(t, err)
+
+	dryResp := doWithKey(t, http.MethodPost, server.URL+"/automations/"+created.ID+"/dry-run", "", []byte(`{"trigger":"webhook","payload":{"ticker":"SOL"}}`))
+	defer dryResp.Body.Close()
+	require.Equal(t, http.StatusOK, dryResp.StatusCode)
+	var result automationDryRunResponse
+	require.NoError(t, json.NewDecoder(dryResp.Body).Decode(&result))
+	require.Empty(t, result.Prompt)
+	require.Equal(t, "completed", result.Entry.Status)
+	require.False(t, result.Entry.Unread)
+	require.Equal(t, "Re: Report on Re: Research SOL", result.Entry.FinalResponse)
+	stages := result.Entry.Diagnostics.PipelineStages
+	require.Len(t, stages, 2)
+	require.Equal(t, "Research SOL", stages[0].Prompt)
+	require.Equal(t, "Report on Re: Research SOL", stages[1].Prompt)
+	for _, stage := range stages {
+		run, err := mem.GetRun(ctx, stage.RunID)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"research", automationDryRunTag}, run.Tags)
+	}
+
+	after, err := mem.GetAutomation(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+
+	override := doWithKey(t, http.MethodPost, server.URL+"/automations/"+created.ID+"/dry-run", "", []byte(`{"prompt":"Research"}`))
+	override.Body.Close()
+	require.Equal(t, http.StatusBadRequest, override.StatusCode)
+}
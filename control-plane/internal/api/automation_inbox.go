@@ -0,0 +1,309 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	defaultInboxLimit      = 50
+	maxInboxLimit          = 200
+	maxInboxRetentionCount = 10000
+	maxInboxRetentionDays  = 3650
+)
+
+type inboxEntriesResponse struct {
+	Entries     []automationInboxEntry `json:"entries"`
+	NextCursor  string                 `json:"next_cursor,omitempty"`
+	UnreadCount int                    `json:"unread_count"`
+}
+
+type continueInboxEntryRequest struct {
+	Message string `json:"message"`
+}
+
+// encodeInboxCursor and decodeInboxCursor turn the position of the last
+// entry on a page into the opaque ?cursor= of the next one.
+func encodeInboxCursor(entry store.AutomationInboxEntry) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(entry.StartedAt + "|" + entry.ID))
+}
+
+func decodeInboxCursor(raw string) (string, string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return "", "", err
+	}
+	startedAt, id, ok := strings.Cut(string(decoded), "|")
+	if !ok || id == "" {
+		return "", "", errors.New("malformed cursor")
+	}
+	if _, err := time.Parse(time.RFC3339Nano, startedAt); err != nil {
+		return "", "", err
+	}
+	return startedAt, id, nil
+}
+
+// splitInboxFilter reads a filter given as repeated or comma-separated
+// query values.
+func splitInboxFilter(values []string) []string {
+	filters := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+				filters = append(filters, part)
+			}
+		}
+	}
+	return filters
+}
+
+// listInbox returns inbox entries across every automation in the workspace,
+// newest first. next_cursor is set when more entries exist and is passed
+// back as ?cursor= for the next page. unread_count covers every unarchived
+// unread entry, regardless of the filters.
+func (s *Server) listInbox(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := store.AutomationInboxQuery{
+		AutomationID: strings.TrimSpace(params.Get("automation_id")),
+		Statuses:     splitInboxFilter(params["status"]),
+		Triggers:     splitInboxFilter(params["trigger"]),
+		Limit:        defaultInboxLimit,
+	}
+	for name, target := range map[string]*bool{"unread": &query.UnreadOnly, "archived": &query.Archived} {
+		raw := strings.TrimSpace(params.Get(name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, name+" must be true or false", http.StatusBadRequest)
+			return
+		}
+		*target = value
+	}
+	for name, target := range map[string]*string{"since": &query.Since, "until": &query.Until} {
+		value := strings.TrimSpace(params.Get(name))
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			http.Error(w, name+" must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*target = parsed.UTC().Format(time.RFC3339Nano)
+	}
+	if raw := strings.TrimSpace(params.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		query.Limit = min(limit, maxInboxLimit)
+	}
+	if raw := strings.TrimSpace(params.Get("cursor")); raw != "" {
+		startedAt, id, err := decodeInboxCursor(raw)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		query.BeforeStartedAt, query.BeforeID = startedAt, id
+	}
+	pageSize := query.Limit
+	query.Limit = pageSize + 1
+	entries, err := s.store.QueryAutomationInbox(r.Context(), query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	unread, err := s.store.QueryAutomationInbox(r.Context(), store.AutomationInboxQuery{UnreadOnly: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	automations, err := s.store.ListAutomations(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	names := make(map[string]string, len(automations))
+	for _, automation := range automations {
+		names[automation.ID] = automation.Name
+	}
+	response := inboxEntriesResponse{Entries: make([]automationInboxEntry, 0, min(len(entries), pageSize)), UnreadCount: len(unread)}
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		response.NextCursor = encodeInboxCursor(entries[len(entries)-1])
+	}
+	for _, entry := range entries {
+		record := toInboxRecord(entry)
+		record.AutomationName = names[entry.AutomationID]
+		response.Entries = append(response.Entries, record)
+	}
+	writeJSONStatus(w, response, http.StatusOK)
+}
+
+// loadInboxEntry returns the inbox entry named by the route, writing a 404
+// when the automation or entry does not exist.
+func (s *Server) loadInboxEntry(w http.ResponseWriter, r *http.Request) (*store.AutomationInboxEntry, bool) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	entryID := strings.TrimSpace(chi.URLParam(r, "entryID"))
+	entries, err := s.store.ListAutomationInbox(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	entry := findInboxEntry(entries, entryID)
+	if entry == nil {
+		http.Error(w, "inbox entry not found", http.StatusNotFound)
+		return nil, false
+	}
+	return entry, true
+}
+
+// rerunInboxEntry queues a new run of the entry's automation with the same
+// trigger payload, so an event-driven prompt renders as it did.
+func (s *Server) rerunInboxEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.loadInboxEntry(w, r)
+	if !ok {
+		return
+	}
+	queued, reason := s.queueAutomationJob(r.Context(), entry.AutomationID, automationJobOptions{
+		Trigger: automationTriggerRerun,
+		Attempt: 1,
+		Payload: entry.TriggerPayload,
+	})
+	if !queued {
+		writeJSONStatus(w, automationQueueResponse{Queued: false, Error: reason}, http.StatusConflict)
+		return
+	}
+	recordAuditChange(r, entry.AutomationID, nil, map[string]any{"entry_id": entry.ID})
+	writeJSONStatus(w, automationQueueResponse{Queued: true}, http.StatusAccepted)
+}
+
+func (s *Server) archiveInboxEntry(w http.ResponseWriter, r *http.Request) {
+	s.setInboxEntryArchived(w, r, true)
+}
+
+func (s *Server) unarchiveInboxEntry(w http.ResponseWriter, r *http.Request) {
+	s.setInboxEntryArchived(w, r, false)
+}
+
+// setInboxEntryArchived moves an entry out of or back into the inbox.
+// Archiving also marks it read. Entries still queued or running cannot be
+// archived.
+func (s *Server) setInboxEntryArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	s.automationMu.Lock()
+	defer s.automationMu.Unlock()
+	entry, ok := s.loadInboxEntry(w, r)
+	if !ok {
+		return
+	}
+	if archived && strings.TrimSpace(entry.CompletedAt) == "" {
+		http.Error(w, "only finished entries can be archived", http.StatusConflict)
+		return
+	}
+	before := map[string]any{"archived_at": entry.ArchivedAt, "unread": entry.Unread}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	entry.ArchivedAt = ""
+	if archived {
+		entry.ArchivedAt = now
+		entry.Unread = false
+	}
+	entry.UpdatedAt = now
+	if err := s.store.UpdateAutomationInboxEntry(r.Context(), *entry); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, entry.AutomationID, before, map[string]any{"entry_id": entry.ID, "archived_at": entry.ArchivedAt, "unread": entry.Unread})
+	writeJSONStatus(w, toInboxRecord(*entry), http.StatusOK)
+}
+
+// continueInboxEntry reopens the run behind an entry with a follow-up
+// message, turning the result into a chat. The entry is marked read.
+func (s *Server) continueInboxEntry(w http.ResponseWriter, r *http.Request) {
+	var req continueInboxEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		http.Error(w, "message is required", http.StatusBadRequest)
+		return
+	}
+	entry, ok := s.loadInboxEntry(w, r)
+	if !ok {
+		return
+	}
+	if entry.RunID == "" {
+		http.Error(w, "inbox entry has no run to continue", http.StatusConflict)
+		return
+	}
+	if !s.ensureLLMConfigured(w, r.Context()) {
+		return
+	}
+	run, err := s.findRun(r.Context(), entry.RunID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if run == nil {
+		http.Error(w, "run not found", http.StatusNotFound)
+		return
+	}
+	metadata := map[string]any{"automation_id": entry.AutomationID, "automation_entry_id": entry.ID}
+	if status, err := s.reopenRun(r.Context(), *run, message, metadata); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if err := s.store.MarkAutomationInboxEntryRead(r.Context(), entry.AutomationID, entry.ID); err != nil {
+		log.Printf("automation inbox mark read failed entry_id=%s err=%v", entry.ID, err)
+	}
+	recordAuditChange(r, entry.AutomationID, nil, map[string]any{"entry_id": entry.ID, "run_id": run.ID})
+	writeJSONStatus(w, map[string]string{"run_id": run.ID, "status": "running"}, http.StatusAccepted)
+}
+
+// applyInboxRetention deletes the finished entries of an automation beyond
+// its InboxRetentionCount newest or finished more than InboxRetentionDays
+// ago. Queued and running entries are always kept.
+func (s *Server) applyInboxRetention(ctx context.Context, automation store.Automation, now time.Time) {
+	if automation.InboxRetentionCount <= 0 && automation.InboxRetentionDays <= 0 {
+		return
+	}
+	entries, err := s.store.ListAutomationInbox(ctx, automation.ID)
+	if err != nil {
+		return
+	}
+	cutoff := now.AddDate(0, 0, -automation.InboxRetentionDays)
+	expired := []string{}
+	finished := 0
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.CompletedAt) == "" {
+			continue
+		}
+		finished++
+		if (automation.InboxRetentionCount > 0 && finished > automation.InboxRetentionCount) ||
+			(automation.InboxRetentionDays > 0 && parseTime(entry.CompletedAt).Before(cutoff)) {
+			expired = append(expired, entry.ID)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+	if err := s.store.DeleteAutomationInboxEntries(ctx, automation.ID, expired); err != nil {
+		log.Printf("automation inbox retention failed automation_id=%s err=%v", automation.ID, err)
+	}
+}
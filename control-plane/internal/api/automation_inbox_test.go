@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func seedInboxEntries(t *testing.T, mem store.Store, entries ...store.AutomationInboxEntry) {
+	t.Helper()
+	for _, entry := range entries {
+		entry.CreatedAt, entry.UpdatedAt = entry.StartedAt, entry.StartedAt
+		require.NoError(t, mem.CreateAutomationInboxEntry(context.Background(), entry))
+	}
+}
+
+func getInbox(t *testing.T, serverURL string, params url.Values) (int, inboxEntriesResponse) {
+	t.Helper()
+	resp := doWithKey(t, http.MethodGet, serverURL+"/automations/inbox?"+params.Encode(), "", nil)
+	defer resp.Body.Close()
+	var body inboxEntriesResponse
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body
+}
+
+func inboxIDs(response inboxEntriesResponse) []string {
+	ids := make([]string, 0, len(response.Entries))
+	for _, entry := range response.Entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestListInbox(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "auto-news", Name: "News"}))
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "auto-prices", Name: "Prices"}))
+	seedInboxEntries(t, mem,
+		store.AutomationInboxEntry{ID: "e1", AutomationID: "auto-news", Status: "completed", Trigger: "schedule", Unread: true, StartedAt: "2026-10-14T09:00:00Z", CompletedAt: "2026-10-14T09:01:00Z"},
+		store.AutomationInboxEntry{ID: "e2", AutomationID: "auto-prices", Status: "failed", Trigger: "webhook", Unread: true, StartedAt: "2026-10-15T09:00:00Z", CompletedAt: "2026-10-15T09:01:00Z"},
+		store.AutomationInboxEntry{ID: "e3", AutomationID: "auto-news", Status: "completed", Trigger: "manual", StartedAt: "2026-10-16T09:00:00Z", CompletedAt: "2026-10-16T09:01:00Z"},
+		store.AutomationInboxEntry{ID: "e4", AutomationID: "auto-prices", Status: "completed", Trigger: "schedule", Unread: true, StartedAt: "2026-10-17T09:00:00Z", CompletedAt: "2026-10-17T09:01:00Z"},
+		store.AutomationInboxEntry{ID: "e5", AutomationID: "auto-news", Status: "completed", Trigger: "schedule", StartedAt: "2026-10-18T09:00:00Z", CompletedAt: "2026-10-18T09:01:00Z", ArchivedAt: "2026-10-18T10:00:00Z"},
+	)
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	status, page := getInbox(t, server.URL, url.Values{})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, []string{"e4", "e3", "e2", "e1"}, inboxIDs(page))
+	require.Equal(t, "Prices", page.Entries[0].AutomationName)
+	require.Equal(t, 3, page.UnreadCount)
+	require.Empty(t, page.NextCursor)
+
+	for _, tc := range []struct {
+		params url.Values
+		want   []string
+	}{
+		{url.Values{"unread": {"true"}}, []string{"e4", "e2", "e1"}},
+		{url.Values{"status": {"failed"}}, []string{"e2"}},
+		{url.Values{"trigger": {"schedule,manual"}}, []string{"e4", "e3", "e1"}},
+		{url.Values{"trigger": {"schedule"}, "unread": {"true"}, "automation_id": {"auto-news"}}, []string{"e1"}},
+		{url.Values{"since": {"2026-10-15T00:00:00Z"}, "until": {"2026-10-17T00:00:00Z"}}, []string{"e3", "e2"}},
+		{url.Values{"archived": {"true"}}, []string{"e5"}},
+	} {
+		status, page := getInbox(t, server.URL, tc.params)
+		require.Equal(t, http.StatusOK, status, tc.params.Encode())
+		require.Equal(t, tc.want, inboxIDs(page), tc.params.Encode())
+	}
+
+	var visited []string
+	params := url.Values{"limit": {"3"}}
+	for {
+		status, page := getInbox(t, server.URL, params)
+		require.Equal(t, http.StatusOK, status)
+		visited = append(visited, inboxIDs(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		params.Set("cursor", page.NextCursor)
+	}
+	require.Equal(t, []string{"e4", "e3", "e2", "e1"}, visited)
+
+	for _, params := range []url.Values{
+		{"unread": {"maybe"}},
+		{"since": {"yesterday"}},
+		{"limit": {"0"}},
+		{"cursor": {"not-a-cursor"}},
+	} {
+		status, _ := getInbox(t, server.URL, params)
+		require.Equal(t, http.StatusBadRequest, status, params.Encode())
+	}
+
+	// The per-automation inbox leaves archived entries out as well.
+	detail := doWithKey(t, http.MethodGet, server.URL+"/automations/auto-news/inbox", "", nil)
+	defer detail.Body.Close()
+	var body automationDetailResponse
+	require.NoError(t, json.NewDecoder(detail.Body).Decode(&body))
+	require.Len(t, body.Inbox, 2)
+}
+
+func TestInboxEntryActions(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", Model: "gpt-4.1"}))
+	workflows := &replyingWorkflows{store: mem, reply: func(string) string { return "done" }}
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	api := NewServer(mem, brokerMock, workflows, config.Config{})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Alerts","prompt":"Check {{.Payload.ticker}}","schedule_type":"manual"}`))
+	defer resp.Body.Close()
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	base := server.URL + "/automations/" + created.ID + "/inbox/"
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-first", Status: "completed", CreatedAt: now, UpdatedAt: now}))
+	seedInboxEntries(t, mem,
+		store.AutomationInboxEntry{ID: "first", AutomationID: created.ID, RunID: "run-first", Status: "completed", Trigger: automationTriggerWebhook, TriggerPayload: map[string]any{"ticker": "SOL"}, Unread: true, StartedAt: "2026-10-17T09:00:00Z", CompletedAt: "2026-10-17T09:01:00Z"},
+		store.AutomationInboxEntry{ID: "pending", AutomationID: created.ID, Status: "queued", Trigger: automationTriggerManual, StartedAt: "2026-10-18T09:00:00Z"},
+	)
+
+	// Archive and restore.
+	archive := doWithKey(t, http.MethodPost, base+"first/archive", "", nil)
+	defer archive.Body.Close()
+	require.Equal(t, http.StatusOK, archive.StatusCode)
+	var archived automationInboxEntry
+	require.NoError(t, json.NewDecoder(archive.Body).Decode(&archived))
+	require.NotEmpty(t, archived.ArchivedAt)
+	require.False(t, archived.Unread)
+	pending := doWithKey(t, http.MethodPost, base+"pending/archive", "", nil)
+	pending.Body.Close()
+	require.Equal(t, http.StatusConflict, pending.StatusCode)
+	missing := doWithKey(t, http.MethodPost, base+"missing/archive", "", nil)
+	missing.Body.Close()
+	require.Equal(t, http.StatusNotFound, missing.StatusCode)
+	restore := doWithKey(t, http.MethodPost, base+"first/unarchive", "", nil)
+	defer restore.Body.Close()
+	var restored automationInboxEntry
+	require.NoError(t, json.NewDecoder(restore.Body).Decode(&restored))
+	require.Empty(t, restored.ArchivedAt)
+
+	// Continue in chat reopens the run with the follow-up message.
+	empty := doWithKey(t, http.MethodPost, base+"first/continue", "", []byte(`{}`))
+	empty.Body.Close()
+	require.Equal(t, http.StatusBadRequest, empty.StatusCode)
+	noRun := doWithKey(t, http.MethodPost, base+"pending/continue", "", []byte(`{"message":"More?"}`))
+	noRun.Body.Close()
+	require.Equal(t, http.StatusConflict, noRun.StatusCode)
+	cont := doWithKey(t, http.MethodPost, base+"first/continue", "", []byte(`{"message":"Compare with ETH"}`))
+	defer cont.Body.Close()
+	require.Equal(t, http.StatusAccepted, cont.StatusCode)
+	messages, err := mem.ListMessages(ctx, "run-first")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, "Compare with ETH", messages[0].Content)
+	require.Equal(t, "first", messages[0].Metadata["automation_entry_id"])
+	events, err := mem.ListEvents(ctx, "run-first", 0)
+	require.NoError(t, err)
+	require.Equal(t, "run.resumed", events[len(events)-1].Type)
+
+	// Re-run queues a new entry with the original payload.
+	require.NoError(t, mem.DeleteAutomationInboxEntries(ctx, created.ID, []string{"pending"}))
+	rerun := doWithKey(t, http.MethodPost, base+"first/rerun", "", nil)
+	rerun.Body.Close()
+	require.Equal(t, http.StatusAccepted, rerun.StatusCode)
+	entries := waitForInbox(t, mem, created.ID, 2)
+	require.Equal(t, automationTriggerRerun, entries[0].Trigger)
+	require.Equal(t, "SOL", entries[0].TriggerPayload["ticker"])
+	require.False(t, entries[1].Unread)
+	require.Equal(t, []string{"Check SOL"}, workflows.Prompts())
+}
+
+func TestAutomationInboxRetention(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Daily","prompt":"News","schedule_type":"manual","inbox_retention_count":10}`))
+	defer resp.Body.Close()
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, 10, created.InboxRetentionCount)
+
+	finished := func(id string, daysAgo int) store.AutomationInboxEntry {
+		at := time.Now().UTC().AddDate(0, 0, -daysAgo).Format(time.RFC3339Nano)
+		return store.AutomationInboxEntry{ID: id, AutomationID: created.ID, Status: "completed", Trigger: automationTriggerManual, StartedAt: at, CompletedAt: at}
+	}
+	seedInboxEntries(t, mem,
+		finished("d40", 40), finished("d20", 20), finished("d3", 3), finished("d2", 2), finished("d1", 1),
+		store.AutomationInboxEntry{ID: "running", AutomationID: created.ID, Status: "running", Trigger: automationTriggerManual, StartedAt: time.Now().UTC().Format(time.RFC3339Nano)},
+	)
+
+	ids := func() []string {
+		entries, err := mem.ListAutomationInbox(ctx, created.ID)
+		require.NoError(t, err)
+		result := []string{}
+		for _, entry := range entries {
+			result = append(result, entry.ID)
+		}
+		return result
+	}
+	update := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"inbox_retention_days":30}`))
+	update.Body.Close()
+	require.Equal(t, http.StatusOK, update.StatusCode)
+	require.Equal(t, []string{"running", "d1", "d2", "d3", "d20"}, ids())
+
+	update = doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"inbox_retention_count":2}`))
+	update.Body.Close()
+	require.Equal(t, []string{"running", "d1", "d2"}, ids())
+
+	for _, body := range []string{`{"inbox_retention_count":-1}`, `{"inbox_retention_days":4000}`} {
+		resp := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	automationStartTimeout = 10 * time.Second
+	// automationJobLease is how long a job's lease lasts without renewal;
+	// the process running it renews it every automationJobLeaseRenewal.
+	automationJobLease        = 2 * time.Minute
+	automationJobLeaseRenewal = 30 * time.Second
+	// automationInterruptedError fails jobs that were queued or running when
+	// the control plane stopped and that no workflow is still driving.
+	automationInterruptedError = "interrupted: the control plane restarted before the job finished"
+)
+
+var errAutomationJobClaimed = errors.New("automation job is already running")
+
+// automationRunner runs automation jobs durably. When the workflow service
+// implements it, jobs run on a Temporal workflow that calls back into
+// /automations/{id}/inbox/{entryID}/execute; otherwise they run on a
+// goroutine in this process.
+type automationRunner interface {
+	StartAutomation(ctx context.Context, workspaceID string, automationID string, entryID string) error
+	AutomationActive(ctx context.Context, entryID string) (bool, error)
+}
+
+type automationJobFailureRequest struct {
+	Error string `json:"error"`
+}
+
+func automationJobPending(status string) bool {
+	return status == "queued" || status == "running"
+}
+
+// startAutomationJob hands a queued inbox entry to the workflow service,
+// falling back to an in-process goroutine when Temporal is unavailable.
+func (s *Server) startAutomationJob(ctx context.Context, schedule store.Automation, entryID string) {
+	if runner, ok := s.workflows.(automationRunner); ok {
+		startCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), automationStartTimeout)
+		err := runner.StartAutomation(startCtx, store.WorkspaceID(ctx), schedule.ID, entryID)
+		cancel()
+		if err == nil {
+			return
+		}
+		log.Printf("automation workflow start failed, running in process automation_id=%s entry_id=%s err=%v", schedule.ID, entryID, err)
+	}
+	go s.runAutomationJobInProcess(context.WithoutCancel(ctx), schedule, entryID)
+}
+
+// runAutomationJobInProcess runs a job on this process, holding its lease
+// while it runs.
+func (s *Server) runAutomationJobInProcess(ctx context.Context, schedule store.Automation, entryID string) {
+	s.automationMu.Lock()
+	release, err := s.leaseAutomationJob(ctx, schedule.ID, entryID)
+	s.automationMu.Unlock()
+	if err != nil {
+		log.Printf("automation job lease failed automation_id=%s entry_id=%s err=%v", schedule.ID, entryID, err)
+		return
+	}
+	defer release()
+	s.executeAutomationJob(ctx, schedule, entryID)
+}
+
+// leaseAutomationJob claims a pending inbox entry for this process in the
+// store, so no other replica runs it or reconciles it away, and renews the
+// lease until the returned release is called. Callers hold automationMu.
+func (s *Server) leaseAutomationJob(ctx context.Context, automationID string, entryID string) (func(), error) {
+	if s.automationJobs[entryID] {
+		return nil, errAutomationJobClaimed
+	}
+	claim := func(ctx context.Context) (bool, error) {
+		now := time.Now().UTC()
+		return s.store.ClaimAutomationInboxEntry(ctx, automationID, entryID, s.instanceID, now.Format(time.RFC3339Nano), now.Add(automationJobLease).Format(time.RFC3339Nano))
+	}
+	claimed, err := claim(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, errAutomationJobClaimed
+	}
+	s.automationJobs[entryID] = true
+
+	renewCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(automationJobLeaseRenewal)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-renewCtx.Done():
+				return
+			case <-ticker.C:
+				if _, err := claim(renewCtx); err != nil && renewCtx.Err() == nil {
+					log.Printf("automation job lease renewal failed automation_id=%s entry_id=%s err=%v", automationID, entryID, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		stop()
+		<-done
+		s.automationMu.Lock()
+		delete(s.automationJobs, entryID)
+		s.automationMu.Unlock()
+		if err := s.store.ReleaseAutomationInboxEntry(context.WithoutCancel(ctx), automationID, entryID, s.instanceID); err != nil {
+			log.Printf("automation job lease release failed automation_id=%s entry_id=%s err=%v", automationID, entryID, err)
+		}
+	}, nil
+}
+
+// claimAutomationJob loads a pending inbox entry and leases it to this
+// process. It writes the response itself unless the job is claimed; the
+// caller then calls the returned release once the job is done.
+func (s *Server) claimAutomationJob(w http.ResponseWriter, r *http.Request) (*store.Automation, func(), bool) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	entryID := strings.TrimSpace(chi.URLParam(r, "entryID"))
+	s.automationMu.Lock()
+	defer s.automationMu.Unlock()
+
+	schedule, err := s.store.GetAutomation(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if schedule == nil {
+		http.Error(w, "automation not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	entries, err := s.store.ListAutomationInbox(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	entry := findInboxEntry(entries, entryID)
+	if entry == nil {
+		http.Error(w, "automation inbox entry not found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	if !automationJobPending(entry.Status) {
+		writeJSONStatus(w, toInboxRecord(*entry), http.StatusOK)
+		return nil, nil, false
+	}
+	release, err := s.leaseAutomationJob(r.Context(), id, entryID)
+	if errors.Is(err, errAutomationJobClaimed) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return nil, nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return schedule, release, true
+}
+
+func (s *Server) writeAutomationJobEntry(w http.ResponseWriter, r *http.Request, automationID string, entryID string) {
+	entries, err := s.store.ListAutomationInbox(r.Context(), automationID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	entry := findInboxEntry(entries, entryID)
+	if entry == nil {
+		http.Error(w, "automation inbox entry not found", http.StatusNotFound)
+		return
+	}
+	writeJSONStatus(w, toInboxRecord(*entry), http.StatusOK)
+}
+
+// executeAutomationInboxEntry runs a pending inbox entry to completion for
+// the automation workflow. Finished entries are returned as they are, so
+// activity retries never run a job twice.
+func (s *Server) executeAutomationInboxEntry(w http.ResponseWriter, r *http.Request) {
+	schedule, release, ok := s.claimAutomationJob(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+	entryID := strings.TrimSpace(chi.URLParam(r, "entryID"))
+	s.executeAutomationJob(context.WithoutCancel(r.Context()), *schedule, entryID)
+	s.writeAutomationJobEntry(w, r, schedule.ID, entryID)
+}
+
+// failAutomationInboxEntry fails a pending inbox entry whose workflow gave up.
+func (s *Server) failAutomationInboxEntry(w http.ResponseWriter, r *http.Request) {
+	var req automationJobFailureRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+	schedule, release, ok := s.claimAutomationJob(w, r)
+	if !ok {
+		return
+	}
+	defer release()
+	entryID := strings.TrimSpace(chi.URLParam(r, "entryID"))
+	detail := fallbackString(strings.TrimSpace(req.Error), "automation job failed")
+	s.completeAutomationJob(context.WithoutCancel(r.Context()), schedule.ID, entryID, automationExecuteResponse{}, errors.New(detail))
+	s.writeAutomationJobEntry(w, r, schedule.ID, entryID)
+}
+
+// runAutomationReconciler reconciles automations every automationJobLease,
+// so jobs abandoned by a replica that stopped are failed once their lease
+// lapses.
+func (s *Server) runAutomationReconciler(ctx context.Context) {
+	ticker := time.NewTicker(automationJobLease)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reconcileAutomations(ctx, time.Now().UTC())
+		}
+	}
+}
+
+// automationLeaseLapsed reports whether no process holds entry's lease at
+// now. An entry that was never leased gets one lease period from its last
+// update to be picked up.
+func automationLeaseLapsed(entry store.AutomationInboxEntry, now time.Time) bool {
+	until, err := time.Parse(time.RFC3339Nano, entry.LeaseUntil)
+	if entry.LeaseOwner == "" {
+		var updated time.Time
+		if updated, err = time.Parse(time.RFC3339Nano, entry.UpdatedAt); err == nil {
+			until = updated.Add(automationJobLease)
+		}
+	}
+	return err != nil || !now.Before(until)
+}
+
+// reconcileAutomations fails jobs left queued or running whose lease has
+// lapsed, unless their workflow is still running and will finish them, and
+// releases automations stuck in progress. Jobs another replica is still
+// running keep their lease and are left alone. Dry runs whose lease lapsed
+// are failed without touching their automation.
+func (s *Server) reconcileAutomations(ctx context.Context, now time.Time) {
+	runner, durable := s.workflows.(automationRunner)
+	workspaces, err := s.store.ListWorkspaces(ctx)
+	if err != nil {
+		log.Printf("automation reconcile list workspaces failed err=%v", err)
+		return
+	}
+	for _, workspace := range workspaces {
+		workspaceCtx := store.WithWorkspace(ctx, workspace.ID)
+		automations, err := s.store.ListAutomations(workspaceCtx)
+		if err != nil {
+			log.Printf("automation reconcile list automations failed workspace_id=%s err=%v", workspace.ID, err)
+			continue
+		}
+		for _, automation := range automations {
+			entries, err := s.store.ListAutomationInbox(workspaceCtx, automation.ID)
+			if err != nil {
+				log.Printf("automation reconcile list inbox failed automation_id=%s err=%v", automation.ID, err)
+				continue
+			}
+			active := false
+			failed := 0
+			for _, entry := range entries {
+				if !automationJobPending(entry.Status) {
+					continue
+				}
+				if !automationLeaseLapsed(entry, now) {
+					if !isDryRunEntry(entry) {
+						active = true
+					}
+					continue
+				}
+				if isDryRunEntry(entry) {
+					log.Printf("automation reconcile failing orphaned dry run automation_id=%s entry_id=%s", automation.ID, entry.ID)
+					if err := s.failOrphanedDryRun(workspaceCtx, entry); err != nil {
+						log.Printf("automation reconcile dry run update failed automation_id=%s entry_id=%s err=%v", automation.ID, entry.ID, err)
+					}
+					continue
+				}
+				if durable {
+					running, err := runner.AutomationActive(workspaceCtx, entry.ID)
+					if err != nil {
+						// Leave the job alone rather than fail one that may still finish.
+						log.Printf("automation reconcile workflow lookup failed automation_id=%s entry_id=%s err=%v", automation.ID, entry.ID, err)
+						active = true
+						continue
+					}
+					if running {
+						active = true
+						continue
+					}
+				}
+				log.Printf("automation reconcile failing orphaned job automation_id=%s entry_id=%s status=%s", automation.ID, entry.ID, entry.Status)
+				s.completeAutomationJob(workspaceCtx, automation.ID, entry.ID, automationExecuteResponse{}, errors.New(automationInterruptedError))
+				failed++
+			}
+			if automation.InProgress && !active && failed == 0 {
+				s.releaseStaleAutomation(workspaceCtx, automation.ID)
+			}
+		}
+	}
+}
+
+// releaseStaleAutomation clears InProgress on an automation that has no
+// pending job.
+func (s *Server) releaseStaleAutomation(ctx context.Context, id string) {
+	s.automationMu.Lock()
+	defer s.automationMu.Unlock()
+	current, err := s.store.GetAutomation(ctx, id)
+	if err != nil || current == nil || !current.InProgress {
+		return
+	}
+	now := time.Now().UTC()
+	current.InProgress = false
+	current.UpdatedAt = now.Format(time.RFC3339Nano)
+	if current.Enabled {
+		current.NextRunAt = nextRunTimestamp(timingFromAutomation(*current), now, time.RFC3339Nano)
+	}
+	if err := s.store.UpdateAutomation(ctx, *current); err != nil {
+		log.Printf("automation reconcile release failed automation_id=%s err=%v", id, err)
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestAutomationJobsOnWorkflow(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	workflows := &MockAutomationWorkflows{}
+	var started []string
+	workflows.On("StartAutomation", mock.Anything, store.DefaultWorkspaceID, "auto-1", mock.Anything).Run(func(args mock.Arguments) {
+		started = append(started, args.String(3))
+	}).Return(nil)
+	api := NewServer(mem, &MockBroker{}, workflows, config.Config{})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	days := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "auto-1", Name: "Auto", Prompt: "p", Kind: automationKindPrompt, Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true}))
+
+	queue := func() string {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations/auto-1/run", "", nil)
+		resp.Body.Close()
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		require.NotEmpty(t, started)
+		return started[len(started)-1]
+	}
+	callback := func(entryID string, action string, body string) (int, automationInboxEntry) {
+		var payload []byte
+		if body != "" {
+			payload = []byte(body)
+		}
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations/auto-1/inbox/"+entryID+"/"+action, "", payload)
+		defer resp.Body.Close()
+		var entry automationInboxEntry
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&entry))
+		}
+		return resp.StatusCode, entry
+	}
+
+	// Queued jobs are handed to the workflow and wait for its callback.
+	first := queue()
+	automation, err := mem.GetAutomation(ctx, "auto-1")
+	require.NoError(t, err)
+	require.True(t, automation.InProgress)
+	entries, err := mem.ListAutomationInbox(ctx, "auto-1")
+	require.NoError(t, err)
+	require.Equal(t, "queued", findInboxEntry(entries, first).Status)
+
+	// Without LLM settings the job fails fast, and retries return the result.
+	status, entry := callback(first, "execute", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "failed", entry.Status)
+	status, again := callback(first, "execute", "")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, entry.CompletedAt, again.CompletedAt)
+	automation, err = mem.GetAutomation(ctx, "auto-1")
+	require.NoError(t, err)
+	require.False(t, automation.InProgress)
+
+	// A job leased by another replica is not run twice.
+	second := queue()
+	leasedAt := time.Now().UTC()
+	claimed, err := mem.ClaimAutomationInboxEntry(ctx, "auto-1", second, "other-replica", leasedAt.Format(time.RFC3339Nano), leasedAt.Add(automationJobLease).Format(time.RFC3339Nano))
+	require.NoError(t, err)
+	require.True(t, claimed)
+	status, _ = callback(second, "execute", "")
+	require.Equal(t, http.StatusConflict, status)
+	status, _ = callback(second, "fail", `{"error":"gave up"}`)
+	require.Equal(t, http.StatusConflict, status)
+	require.NoError(t, mem.ReleaseAutomationInboxEntry(ctx, "auto-1", second, "other-replica"))
+
+	status, failed := callback(second, "fail", `{"error":"gave up"}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "failed", failed.Status)
+	require.Equal(t, "gave up", failed.Error)
+	require.True(t, failed.Unread)
+	automation, err = mem.GetAutomation(ctx, "auto-1")
+	require.NoError(t, err)
+	require.False(t, automation.InProgress)
+	entries, err = mem.ListAutomationInbox(ctx, "auto-1")
+	require.NoError(t, err)
+	require.Empty(t, findInboxEntry(entries, second).LeaseOwner)
+
+	status, _ = callback("missing", "execute", "")
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestAutomationJobFallsBackInProcess(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	workflows := &MockAutomationWorkflows{}
+	workflows.On("StartAutomation", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("temporal unavailable"))
+	api := NewServer(mem, &MockBroker{}, workflows, config.Config{})
+
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "auto-1", Name: "Auto", Prompt: "p", Kind: automationKindPrompt, Days: []string{"mon"}, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true}))
+	queued, reason := api.queueAutomationExecution(ctx, "auto-1", "manual")
+	require.True(t, queued, reason)
+	require.Eventually(t, func() bool {
+		automation, err := mem.GetAutomation(ctx, "auto-1")
+		return err == nil && !automation.InProgress && automation.LastRunAt != ""
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestReconcileAutomations(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	stamp := now.Add(-time.Hour).Format(time.RFC3339Nano)
+	days := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	seed := func(t *testing.T) *memory.MemoryStore {
+		mem := memory.New()
+		require.NoError(t, mem.CreateWorkspace(ctx, store.Workspace{ID: "team", Name: "Team", CreatedAt: stamp, UpdatedAt: stamp}))
+		teamCtx := store.WithWorkspace(ctx, "team")
+		for _, automation := range []store.Automation{
+			{ID: "orphaned", Name: "Orphaned", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true, InProgress: true, NextRunAt: stamp},
+			{ID: "active", Name: "Active", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true, InProgress: true, NextRunAt: stamp},
+		} {
+			require.NoError(t, mem.CreateAutomation(ctx, automation))
+			require.NoError(t, mem.CreateAutomationInboxEntry(ctx, store.AutomationInboxEntry{ID: automation.ID + "-entry", AutomationID: automation.ID, Status: "running", Trigger: "schedule", StartedAt: stamp}))
+		}
+		// Another replica is still running this job and renewing its lease.
+		require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "leased", Name: "Leased", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true, InProgress: true, NextRunAt: stamp}))
+		require.NoError(t, mem.CreateAutomationInboxEntry(ctx, store.AutomationInboxEntry{ID: "leased-entry", AutomationID: "leased", Status: "running", Trigger: "schedule", StartedAt: stamp, LeaseOwner: "other-replica", LeaseUntil: now.Add(time.Minute).Format(time.RFC3339Nano)}))
+		require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "tried", Name: "Tried", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true, NextRunAt: stamp}))
+		require.NoError(t, mem.CreateAutomationInboxEntry(ctx, store.AutomationInboxEntry{ID: "tried-dry-run", AutomationID: "tried", Status: "running", Trigger: automationTriggerDryRun, StartedAt: stamp}))
+		require.NoError(t, mem.CreateAutomation(teamCtx, store.Automation{ID: "stuck", Name: "Stuck", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true, InProgress: true, NextRunAt: stamp}))
+		require.NoError(t, mem.CreateAutomationInboxEntry(teamCtx, store.AutomationInboxEntry{ID: "stuck-done", AutomationID: "stuck", Status: "completed", Trigger: "schedule", StartedAt: stamp, CompletedAt: stamp}))
+		return mem
+	}
+	entryStatus := func(t *testing.T, mem *memory.MemoryStore, automationID string, entryID string) store.AutomationInboxEntry {
+		entries, err := mem.ListAutomationInbox(ctx, automationID)
+		require.NoError(t, err)
+		entry := findInboxEntry(entries, entryID)
+		require.NotNil(t, entry)
+		return *entry
+	}
+	// An orphaned dry run is failed without a notification, a retry or a
+	// change to its automation.
+	requireDryRunFailed := func(t *testing.T, mem *memory.MemoryStore) {
+		entry := entryStatus(t, mem, "tried", "tried-dry-run")
+		require.Equal(t, "failed", entry.Status)
+		require.Equal(t, automationInterruptedError, entry.Error)
+		require.False(t, entry.Unread)
+		entries, err := mem.ListAutomationInbox(ctx, "tried")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		automation, err := mem.GetAutomation(ctx, "tried")
+		require.NoError(t, err)
+		require.Equal(t, stamp, automation.NextRunAt)
+		require.Empty(t, automation.LastRunAt)
+		require.False(t, automation.InProgress)
+	}
+	requireLeasedRunning := func(t *testing.T, mem *memory.MemoryStore) {
+		require.Equal(t, "running", entryStatus(t, mem, "leased", "leased-entry").Status)
+		automation, err := mem.GetAutomation(ctx, "leased")
+		require.NoError(t, err)
+		require.True(t, automation.InProgress)
+	}
+	inProgress := func(t *testing.T, mem *memory.MemoryStore, workspaceID string, id string) bool {
+		automation, err := mem.GetAutomation(store.WithWorkspace(ctx, workspaceID), id)
+		require.NoError(t, err)
+		nextRun, err := time.Parse(time.RFC3339Nano, automation.NextRunAt)
+		require.NoError(t, err)
+		if !automation.InProgress {
+			require.True(t, nextRun.After(now))
+		}
+		return automation.InProgress
+	}
+
+	t.Run("in process", func(t *testing.T) {
+		mem := seed(t)
+		api := NewServer(mem, &MockBroker{}, nil, config.Config{})
+		api.reconcileAutomations(ctx, now)
+
+		for _, id := range []string{"orphaned", "active"} {
+			entry := entryStatus(t, mem, id, id+"-entry")
+			require.Equal(t, "failed", entry.Status)
+			require.Equal(t, automationInterruptedError, entry.Error)
+			require.NotEmpty(t, entry.CompletedAt)
+			require.False(t, inProgress(t, mem, store.DefaultWorkspaceID, id))
+		}
+		require.False(t, inProgress(t, mem, "team", "stuck"))
+		requireDryRunFailed(t, mem)
+		requireLeasedRunning(t, mem)
+
+		// Once its lease lapses, the job is failed.
+		api.reconcileAutomations(ctx, now.Add(2*time.Minute))
+		require.Equal(t, "failed", entryStatus(t, mem, "leased", "leased-entry").Status)
+	})
+
+	t.Run("on workflow", func(t *testing.T) {
+		mem := seed(t)
+		workflows := &MockAutomationWorkflows{}
+		workflows.On("AutomationActive", mock.Anything, "orphaned-entry").Return(false, nil)
+		workflows.On("AutomationActive", mock.Anything, "active-entry").Return(true, nil)
+		api := NewServer(mem, &MockBroker{}, workflows, config.Config{})
+		api.reconcileAutomations(ctx, now)
+
+		require.Equal(t, "failed", entryStatus(t, mem, "orphaned", "orphaned-entry").Status)
+		require.False(t, inProgress(t, mem, store.DefaultWorkspaceID, "orphaned"))
+		require.Equal(t, "running", entryStatus(t, mem, "active", "active-entry").Status)
+		require.True(t, inProgress(t, mem, store.DefaultWorkspaceID, "active"))
+		require.False(t, inProgress(t, mem, "team", "stuck"))
+		requireDryRunFailed(t, mem)
+		requireLeasedRunning(t, mem)
+		workflows.AssertExpectations(t)
+	})
+}
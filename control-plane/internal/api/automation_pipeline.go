@@ -0,0 +1,433 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/notify"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	automationKindPipeline = "pipeline"
+
+	stageConditionKeyword = "keyword"
+	stageConditionModel   = "model"
+
+	stageMatchAny = "any"
+	stageMatchAll = "all"
+
+	stageOnFalseStop = "stop"
+	stageOnFalseSkip = "skip"
+
+	stageStatusSkipped = "skipped"
+	stageStatusNotRun  = "not_run"
+
+	// pipelineStoppedReason marks pipelines that ended early because a
+	// condition did not hold.
+	pipelineStoppedReason = "pipeline_condition_stopped"
+
+	maxPipelineStages = 10
+	// pipelineTimeout keeps a whole pipeline within one automation job
+	// attempt.
+	pipelineTimeout      = 30 * time.Minute
+	stageJudgmentTimeout = 60 * time.Second
+	stageJudgmentChars   = 6000
+)
+
+var stageNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// automationStage is one prompt of a pipeline; see store.AutomationStage.
+type automationStage struct {
+	Name      string                    `json:"name"`
+	Prompt    string                    `json:"prompt"`
+	Condition *automationStageCondition `json:"condition,omitempty"`
+}
+
+type automationStageCondition struct {
+	// Type is "keyword" or "model".
+	Type     string   `json:"type"`
+	Keywords []string `json:"keywords,omitempty"`
+	// Match is "any" (default) or "all" keywords.
+	Match    string `json:"match,omitempty"`
+	Question string `json:"question,omitempty"`
+	// Stage is the stage whose output is checked, the previous one when
+	// empty.
+	Stage string `json:"stage,omitempty"`
+	// OnFalse is "stop" (default) or "skip".
+	OnFalse string `json:"on_false,omitempty"`
+}
+
+// automationStageResult is the outcome of one stage of a pipeline run.
+type automationStageResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	RunID  string `json:"run_id,omitempty"`
+	// ConditionMet is nil for stages without a condition.
+	ConditionMet    *bool  `json:"condition_met,omitempty"`
+	ConditionDetail string `json:"condition_detail,omitempty"`
+	// ConditionRunID is the run that judged a model condition when no
+	// utility model was available.
+	ConditionRunID string `json:"condition_run_id,omitempty"`
+	// Prompt is the stage's rendered prompt, recorded for dry runs.
+	Prompt        string `json:"prompt,omitempty"`
+	FinalResponse string `json:"final_response,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// stageTemplateData is what stage prompt templates see: {{.Previous}} is the
+// previous stage's output and {{stage "name"}} any earlier stage's output,
+// besides everything automation prompts see.
+type stageTemplateData struct {
+	automationPromptData
+	Previous string
+	Stages   map[string]string
+}
+
+func toStageRecords(stages []store.AutomationStage) []automationStage {
+	records := make([]automationStage, 0, len(stages))
+	for _, stage := range stages {
+		record := automationStage{Name: stage.Name, Prompt: stage.Prompt}
+		if stage.ConditionType != "" {
+			record.Condition = &automationStageCondition{
+				Type:     stage.ConditionType,
+				Keywords: append([]string(nil), stage.ConditionKeywords...),
+				Match:    stage.ConditionMatch,
+				Question: stage.ConditionQuestion,
+				Stage:    stage.ConditionStage,
+				OnFalse:  stage.OnFalse,
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func toStoreStages(stages []automationStage) []store.AutomationStage {
+	if len(stages) == 0 {
+		return nil
+	}
+	result := make([]store.AutomationStage, 0, len(stages))
+	for _, stage := range stages {
+		value := store.AutomationStage{Name: stage.Name, Prompt: stage.Prompt}
+		if condition := stage.Condition; condition != nil {
+			value.ConditionType = condition.Type
+			value.ConditionKeywords = append([]string(nil), condition.Keywords...)
+			value.ConditionMatch = condition.Match
+			value.ConditionQuestion = condition.Question
+			value.ConditionStage = condition.Stage
+			value.OnFalse = condition.OnFalse
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+// normalizeAutomationStages validates a pipeline: stage names are unique,
+// prompts parse and only reference earlier stages, and conditions are
+// complete. Unnamed stages are called stage1, stage2 and so on. The first
+// stage cannot have a condition.
+func normalizeAutomationStages(stages []automationStage) ([]automationStage, error) {
+	if len(stages) == 0 {
+		return nil, errors.New("pipeline automations need at least one stage")
+	}
+	if len(stages) > maxPipelineStages {
+		return nil, fmt.Errorf("pipelines have at most %d stages", maxPipelineStages)
+	}
+	normalized := make([]automationStage, 0, len(stages))
+	earlier := map[string]string{}
+	for idx, stage := range stages {
+		stage.Name = strings.TrimSpace(stage.Name)
+		if stage.Name == "" {
+			stage.Name = fmt.Sprintf("stage%d", idx+1)
+		}
+		if !stageNamePattern.MatchString(stage.Name) {
+			return nil, fmt.Errorf("stage name %q may only contain letters, digits, - and _", stage.Name)
+		}
+		if _, exists := earlier[stage.Name]; exists {
+			return nil, fmt.Errorf("duplicate stage name: %s", stage.Name)
+		}
+		stage.Prompt = strings.TrimSpace(stage.Prompt)
+		if stage.Prompt == "" {
+			return nil, fmt.Errorf("stage %s: prompt is required", stage.Name)
+		}
+		if _, err := executeStagePrompt(stage.Prompt, stageTemplateData{Stages: earlier}, false); err != nil {
+			return nil, fmt.Errorf("stage %s: %w", stage.Name, err)
+		}
+		if stage.Condition != nil {
+			if idx == 0 {
+				return nil, errors.New("the first stage cannot have a condition")
+			}
+			condition, err := normalizeStageCondition(*stage.Condition, earlier)
+			if err != nil {
+				return nil, fmt.Errorf("stage %s: %w", stage.Name, err)
+			}
+			stage.Condition = &condition
+		}
+		earlier[stage.Name] = ""
+		normalized = append(normalized, stage)
+	}
+	return normalized, nil
+}
+
+func normalizeStageCondition(condition automationStageCondition, earlier map[string]string) (automationStageCondition, error) {
+	condition.Type = strings.ToLower(strings.TrimSpace(condition.Type))
+	condition.Stage = strings.TrimSpace(condition.Stage)
+	if condition.Stage != "" {
+		if _, ok := earlier[condition.Stage]; !ok {
+			return condition, fmt.Errorf("condition stage %s is not an earlier stage", condition.Stage)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(condition.OnFalse)) {
+	case "", stageOnFalseStop:
+		condition.OnFalse = stageOnFalseStop
+	case stageOnFalseSkip:
+		condition.OnFalse = stageOnFalseSkip
+	default:
+		return condition, errors.New("condition on_false must be stop or skip")
+	}
+	switch condition.Type {
+	case stageConditionKeyword:
+		condition.Keywords = normalizeRouteCriteria(condition.Keywords, false)
+		if len(condition.Keywords) == 0 {
+			return condition, errors.New("keyword conditions need keywords")
+		}
+		switch strings.ToLower(strings.TrimSpace(condition.Match)) {
+		case "", stageMatchAny:
+			condition.Match = stageMatchAny
+		case stageMatchAll:
+			condition.Match = stageMatchAll
+		default:
+			return condition, errors.New("condition match must be any or all")
+		}
+		condition.Question = ""
+	case stageConditionModel:
+		condition.Question = strings.TrimSpace(condition.Question)
+		if condition.Question == "" {
+			return condition, errors.New("model conditions need a question")
+		}
+		condition.Keywords = nil
+		condition.Match = ""
+	default:
+		return condition, errors.New("condition type must be keyword or model")
+	}
+	return condition, nil
+}
+
+// renderStagePrompt executes a stage prompt template. Referencing a stage
+// that has not run before this one is an error.
+func renderStagePrompt(prompt string, data stageTemplateData) (string, error) {
+	return executeStagePrompt(prompt, data, true)
+}
+
+func executeStagePrompt(prompt string, data stageTemplateData, strict bool) (string, error) {
+	funcs := data.funcs()
+	funcs["stage"] = func(name string) (string, error) {
+		output, ok := data.Stages[name]
+		if !ok {
+			return "", fmt.Errorf("unknown or later stage %q", name)
+		}
+		return output, nil
+	}
+	return executePromptTemplate(prompt, funcs, data, strict)
+}
+
+// matchStageKeywords reports whether text contains any (or all) keywords,
+// ignoring case, and which ones matched.
+func matchStageKeywords(text string, keywords []string, match string) (bool, []string) {
+	lower := strings.ToLower(text)
+	matched := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			matched = append(matched, keyword)
+		}
+	}
+	if match == stageMatchAll {
+		return len(matched) == len(keywords), matched
+	}
+	return len(matched) > 0, matched
+}
+
+// parseStageJudgment reads a yes or no answer from the first word of a model
+// reply.
+func parseStageJudgment(reply string) (bool, error) {
+	fields := strings.Fields(strings.ToLower(reply))
+	if len(fields) == 0 {
+		return false, errors.New("model judgment was empty")
+	}
+	switch strings.Trim(fields[0], ".,:;!*\"'`") {
+	case "yes", "true":
+		return true, nil
+	case "no", "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("model judgment was not yes or no: %s", notify.Truncate(strings.TrimSpace(reply), 120))
+	}
+}
+
+func buildStageJudgmentPrompt(question string, text string) []llm.Message {
+	return []llm.Message{
+		{Role: "system", Content: "You check a condition against the output of an automation step. " +
+			"Reply with yes or no as the first word, then one short sentence explaining why."},
+		{Role: "user", Content: fmt.Sprintf("Condition: %s\n\nOutput:\n%s", question, notify.Truncate(fallbackString(text, "(no output)"), stageJudgmentChars))},
+	}
+}
+
+// evaluateStageCondition decides whether a conditional stage runs. Model
+// conditions are judged by the utility model, or by a linked run of the
+// automation's own model when none is configured.
+func (s *Server) evaluateStageCondition(ctx context.Context, schedule store.Automation, stage automationStage, data stageTemplateData, result *automationStageResult) (bool, error) {
+	condition := stage.Condition
+	text := data.Previous
+	if condition.Stage != "" {
+		text = data.Stages[condition.Stage]
+	}
+	if condition.Type == stageConditionKeyword {
+		met, matched := matchStageKeywords(text, condition.Keywords, condition.Match)
+		if len(matched) > 0 {
+			result.ConditionDetail = "matched " + strings.Join(matched, ", ")
+		} else {
+			result.ConditionDetail = "no keyword matched"
+		}
+		return met, nil
+	}
+
+	messages := buildStageJudgmentPrompt(condition.Question, text)
+	var reply string
+	config, ok, err := s.utilityLLMConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		provider, err := newLLMProvider(config)
+		if err != nil {
+			return false, err
+		}
+		judgeCtx, cancel := context.WithTimeout(ctx, stageJudgmentTimeout)
+		reply, err = provider.Generate(judgeCtx, messages)
+		cancel()
+		if err != nil {
+			return false, err
+		}
+	} else {
+		req := automationPromptRequest(schedule)
+		req.Prompt = messages[0].Content + "\n\n" + messages[1].Content
+		req.BrowserMode, req.Skills = "", nil
+		req.Metadata["automation_stage"] = stage.Name + ":condition"
+		response, err := s.invokeAutomationExecute(ctx, req)
+		if err != nil {
+			return false, err
+		}
+		result.ConditionRunID = response.RunID
+		reply = response.FinalResponse
+	}
+	met, err := parseStageJudgment(reply)
+	if err != nil {
+		return false, err
+	}
+	result.ConditionDetail = notify.Truncate(strings.TrimSpace(reply), 280)
+	return met, nil
+}
+
+// runAutomationPipeline runs the stages of a pipeline in order, each as its
+// own run linked to the previous stage's run. A failed stage ends the
+// pipeline with an error; a condition that does not hold skips its stage or
+// stops the pipeline. The response is that of the last stage that ran, with
+// every stage's result in the diagnostics. A dry run also records each
+// stage's rendered prompt.
+func (s *Server) runAutomationPipeline(ctx context.Context, schedule store.Automation, entryID string, promptData automationPromptData, dryRun bool) (automationExecuteResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, pipelineTimeout)
+	defer cancel()
+
+	stages := toStageRecords(schedule.Stages)
+	results := make([]automationStageResult, 0, len(stages))
+	data := stageTemplateData{automationPromptData: promptData, Stages: map[string]string{}}
+	last := automationExecuteResponse{Diagnostics: automationDiagnostics{Sources: []automationSourceDiagnostic{}}}
+	finish := func(err error) (automationExecuteResponse, error) {
+		for idx := len(results); idx < len(stages); idx++ {
+			results = append(results, automationStageResult{Name: stages[idx].Name, Status: stageStatusNotRun})
+		}
+		last.Diagnostics.PipelineStages = results
+		return last, err
+	}
+	if len(stages) == 0 {
+		return finish(errors.New("pipeline has no stages"))
+	}
+
+	previousRunID := ""
+	for _, stage := range stages {
+		result := automationStageResult{Name: stage.Name}
+		if ctx.Err() != nil {
+			result.Status = "failed"
+			result.Error = "pipeline timed out"
+			results = append(results, result)
+			return finish(fmt.Errorf("stage %s: pipeline timed out", stage.Name))
+		}
+		if stage.Condition != nil {
+			met, err := s.evaluateStageCondition(ctx, schedule, stage, data, &result)
+			if err != nil {
+				result.Status = "failed"
+				result.Error = err.Error()
+				results = append(results, result)
+				return finish(fmt.Errorf("stage %s condition: %w", stage.Name, err))
+			}
+			result.ConditionMet = &met
+			if !met {
+				result.Status = stageStatusSkipped
+				results = append(results, result)
+				if stage.Condition.OnFalse == stageOnFalseSkip {
+					continue
+				}
+				last.CompletionReason = pipelineStoppedReason
+				return finish(nil)
+			}
+		}
+
+		prompt, err := renderStagePrompt(stage.Prompt, data)
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			results = append(results, result)
+			return finish(fmt.Errorf("stage %s: %w", stage.Name, err))
+		}
+		if dryRun {
+			result.Prompt = prompt
+		}
+		req := automationPromptRequest(schedule)
+		req.Prompt = prompt
+		req.Metadata["automation_entry_id"] = entryID
+		req.Metadata["automation_stage"] = stage.Name
+		if previousRunID != "" {
+			req.Metadata["automation_previous_run_id"] = previousRunID
+		}
+		response, err := s.invokeAutomationExecute(ctx, req)
+		if err == nil && response.TimedOut {
+			err = errors.New("timed out waiting for the run")
+		}
+		if err == nil && !automationResultSucceeded(response.Status) {
+			err = fmt.Errorf("run %s", normalizeAutomationStatus(response.Status))
+		}
+		result.RunID = response.RunID
+		result.Status = normalizeAutomationStatus(response.Status)
+		result.FinalResponse = response.FinalResponse
+		if response.RunID != "" {
+			last = response
+			previousRunID = response.RunID
+		}
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			results = append(results, result)
+			return finish(fmt.Errorf("stage %s: %w", stage.Name, err))
+		}
+		results = append(results, result)
+		data.Previous = response.FinalResponse
+		data.Stages[stage.Name] = response.FinalResponse
+	}
+	return finish(nil)
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+// replyingWorkflows completes every run it is signalled with the assistant
+// reply returned by reply.
+type replyingWorkflows struct {
+	store store.Store
+	reply func(prompt string) string
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (w *replyingWorkflows) StartRun(context.Context, string) error          { return nil }
+func (w *replyingWorkflows) ResumeRun(context.Context, string, string) error { return nil }
+func (w *replyingWorkflows) CancelRun(context.Context, string) error         { return nil }
+
+func (w *replyingWorkflows) SignalMessage(ctx context.Context, runID string, message string) error {
+	w.mu.Lock()
+	w.prompts = append(w.prompts, message)
+	w.mu.Unlock()
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if err := w.store.AddMessage(ctx, store.Message{ID: runID + "-reply", RunID: runID, Role: "assistant", Content: w.reply(message), Sequence: time.Now().UnixNano(), CreatedAt: now}); err != nil {
+		return err
+	}
+	seq, _ := w.store.NextSeq(ctx, runID)
+	return w.store.AppendEvent(ctx, store.RunEvent{RunID: runID, Seq: seq, Type: "run.completed", Timestamp: now, Payload: map[string]any{"status": "completed", "phase": "completed"}})
+}
+
+func (w *replyingWorkflows) Prompts() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.prompts...)
+}
+
+func TestNormalizeAutomationStages(t *testing.T) {
+	stages, err := normalizeAutomationStages([]automationStage{
+		{Prompt: "Research the news"},
+		{Name: "report", Prompt: "Draft a report from {{stage \"stage1\"}}", Condition: &automationStageCondition{Type: "KEYWORD", Keywords: []string{"ETF", " etf", ""}}},
+		{Name: "pdf", Prompt: "Export {{.Previous}} as a PDF", Condition: &automationStageCondition{Type: "model", Question: "Is the report ready?", Stage: "report", OnFalse: "skip"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "stage1", stages[0].Name)
+	require.Equal(t, []string{"ETF", "etf"}, stages[1].Condition.Keywords)
+	require.Equal(t, stageMatchAny, stages[1].Condition.Match)
+	require.Equal(t, stageOnFalseStop, stages[1].Condition.OnFalse)
+	require.Equal(t, stageOnFalseSkip, stages[2].Condition.OnFalse)
+
+	for name, invalid := range map[string][]automationStage{
+		"no stages":          {},
+		"empty prompt":       {{Prompt: " "}},
+		"duplicate names":    {{Name: "a", Prompt: "p"}, {Name: "a", Prompt: "p"}},
+		"bad name":           {{Name: "a b", Prompt: "p"}},
+		"later stage":        {{Name: "a", Prompt: `{{stage "b"}}`}, {Name: "b", Prompt: "p"}},
+		"bad template":       {{Prompt: "{{.Previous"}},
+		"first condition":    {{Prompt: "p", Condition: &automationStageCondition{Type: "keyword", Keywords: []string{"x"}}}},
+		"no keywords":        {{Prompt: "p"}, {Prompt: "p", Condition: &automationStageCondition{Type: "keyword"}}},
+		"no question":        {{Prompt: "p"}, {Prompt: "p", Condition: &automationStageCondition{Type: "model"}}},
+		"unknown type":       {{Prompt: "p"}, {Prompt: "p", Condition: &automationStageCondition{Type: "regex"}}},
+		"bad on_false":       {{Prompt: "p"}, {Prompt: "p", Condition: &automationStageCondition{Type: "model", Question: "q", OnFalse: "retry"}}},
+		"unknown cond stage": {{Prompt: "p"}, {Prompt: "p", Condition: &automationStageCondition{Type: "model", Question: "q", Stage: "later"}}},
+	} {
+		_, err := normalizeAutomationStages(invalid)
+		require.Error(t, err, name)
+	}
+}
+
+func TestStageConditionHelpers(t *testing.T) {
+	met, matched := matchStageKeywords("Spot ETF approved for Solana", []string{"etf", "solana", "xrp"}, stageMatchAny)
+	require.True(t, met)
+	require.Equal(t, []string{"etf", "solana"}, matched)
+	met, _ = matchStageKeywords("Spot ETF approved for Solana", []string{"etf", "xrp"}, stageMatchAll)
+	require.False(t, met)
+
+	for reply, want := range map[string]bool{"Yes. It mentions the ETF.": true, "**No** - nothing new": false, "true": true} {
+		got, err := parseStageJudgment(reply)
+		require.NoError(t, err, reply)
+		require.Equal(t, want, got, reply)
+	}
+	_, err := parseStageJudgment("Maybe")
+	require.Error(t, err)
+}
+
+func TestAutomationPipeline(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", Model: "gpt-4.1"}))
+	workflows := &replyingWorkflows{store: mem, reply: func(prompt string) string {
+		switch {
+		case strings.HasPrefix(prompt, "Research"):
+			return "Headline: spot ETF approved."
+		case strings.HasPrefix(prompt, "Draft"):
+			return "Report on " + strings.TrimPrefix(prompt, "Draft a report on: ")
+		default:
+			return "done"
+		}
+	}}
+	providerMock := &MockProvider{}
+	providerMock.On("Generate", mock.Anything, mock.MatchedBy(func(messages []llm.Message) bool {
+		return strings.Contains(messages[1].Content, "Is a PDF needed?")
+	})).Return("No, the report is short.", nil).Once()
+	original := newLLMProvider
+	newLLMProvider = func(llm.Config) (llm.Provider, error) { return providerMock, nil }
+	t.Cleanup(func() { newLLMProvider = original })
+
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	api := NewServer(mem, brokerMock, workflows, config.Config{LLMUtilityProvider: "openai", LLMUtilityModel: "gpt-mini"})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{
+		"name":"Watchlist","kind":"pipeline","run_at":"2099-01-01T00:00:00Z","stages":[
+			{"name":"research","prompt":"Research today's crypto news"},
+			{"name":"report","prompt":"Draft a report on: {{.Previous}}","condition":{"type":"keyword","keywords":["ETF"]}},
+			{"name":"pdf","prompt":"Export {{stage \"report\"}} as a PDF","condition":{"type":"model","question":"Is a PDF needed?","on_false":"skip"}},
+			{"name":"notify","prompt":"Summarize {{stage \"research\"}}"}
+		]}`))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, automationKindPipeline, created.Kind)
+	require.Len(t, created.Stages, 4)
+
+	queued, reason := api.queueAutomationExecution(ctx, created.ID, automationTriggerManual)
+	require.True(t, queued, reason)
+	var entries []store.AutomationInboxEntry
+	require.Eventually(t, func() bool {
+		entries, _ = mem.ListAutomationInbox(ctx, created.ID)
+		return len(entries) == 1 && entries[0].CompletedAt != ""
+	}, 15*time.Second, 20*time.Millisecond)
+
+	entry := toInboxRecord(entries[0])
+	require.Equal(t, "completed", entry.Status)
+	require.Equal(t, "done", entry.FinalResponse)
+	stages := entry.Diagnostics.PipelineStages
+	require.Len(t, stages, 4)
+	require.Equal(t, "completed", stages[0].Status)
+	require.Equal(t, "Report on Headline: spot ETF approved.", stages[1].FinalResponse)
+	require.True(t, *stages[1].ConditionMet)
+	require.Equal(t, "matched ETF", stages[1].ConditionDetail)
+	require.Equal(t, stageStatusSkipped, stages[2].Status)
+	require.False(t, *stages[2].ConditionMet)
+	require.Empty(t, stages[2].RunID)
+	require.Equal(t, entry.RunID, stages[3].RunID)
+	require.Equal(t, []string{
+		"Research today's crypto news",
+		"Draft a report on: Headline: spot ETF approved.",
+		"Summarize Headline: spot ETF approved.",
+	}, workflows.Prompts())
+
+	// Stages after a failed condition that stops the pipeline do not run.
+	update := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"stages":[
+		{"name":"research","prompt":"Research today's crypto news"},
+		{"name":"report","prompt":"Draft","condition":{"type":"keyword","keywords":["XRP"]}},
+		{"name":"notify","prompt":"Summarize"}
+	]}`))
+	defer update.Body.Close()
+	require.Equal(t, http.StatusOK, update.StatusCode)
+	queued, reason = api.queueAutomationExecution(ctx, created.ID, automationTriggerManual)
+	require.True(t, queued, reason)
+	require.Eventually(t, func() bool {
+		entries, _ = mem.ListAutomationInbox(ctx, created.ID)
+		return len(entries) == 2 && entries[0].CompletedAt != ""
+	}, 15*time.Second, 20*time.Millisecond)
+	entry = toInboxRecord(entries[0])
+	require.Equal(t, pipelineStoppedReason, entry.CompletionReason)
+	require.Equal(t, "Headline: spot ETF approved.", entry.FinalResponse)
+	require.Equal(t, stageStatusSkipped, entry.Diagnostics.PipelineStages[1].Status)
+	require.Equal(t, stageStatusNotRun, entry.Diagnostics.PipelineStages[2].Status)
+
+	for _, body := range []string{
+		`{"name":"P","kind":"pipeline"}`,
+		`{"name":"P","prompt":"p","stages":[{"prompt":"p"}]}`,
+	} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+	providerMock.AssertExpectations(t)
+}
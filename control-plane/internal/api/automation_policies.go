@@ -0,0 +1,227 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	catchUpSkip = "skip"
+	catchUpOnce = "once"
+	catchUpAll  = "all"
+
+	automationTriggerSchedule = "schedule"
+	automationTriggerManual   = "manual"
+	automationTriggerCatchUp  = "catch_up"
+	automationTriggerRetry    = "retry"
+	automationTriggerWebhook  = "webhook"
+	automationTriggerContext  = "context"
+	automationTriggerRun      = "run"
+	automationTriggerFeed     = "feed"
+	automationTriggerRerun    = "rerun"
+	automationTriggerDryRun   = "dry_run"
+
+	// automationMissGrace is how late a fire time may be queued and still
+	// count as on time rather than as a catch-up.
+	automationMissGrace = 2 * schedulerMaxSleep
+	// automationMaxMissedRuns bounds how many missed fire times are
+	// considered, so a long outage of a frequent schedule stays cheap.
+	automationMaxMissedRuns = 100
+	maxRetryAttempts        = 10
+	maxRetryBackoffSeconds  = 86400
+	defaultRetryBackoff     = time.Minute
+)
+
+// automationJobOptions describes why an inbox entry is queued: its trigger,
+// the fire time it runs for, which attempt it is and, for event triggers,
+// the event's payload.
+type automationJobOptions struct {
+	Trigger      string
+	ScheduledFor string
+	Attempt      int
+	Payload      map[string]any
+}
+
+type automationDueRun struct {
+	AutomationID string
+	Options      automationJobOptions
+}
+
+func normalizeCatchUp(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", catchUpOnce:
+		return catchUpOnce, nil
+	case catchUpSkip:
+		return catchUpSkip, nil
+	case catchUpAll:
+		return catchUpAll, nil
+	default:
+		return "", errors.New("catch_up must be skip, once or all")
+	}
+}
+
+func validateAutomationPolicies(value automationSchedule) error {
+	if value.CatchUpMaxAgeMinutes < 0 {
+		return errors.New("catch_up_max_age_minutes must not be negative")
+	}
+	if value.RetryMaxAttempts < 0 || value.RetryMaxAttempts > maxRetryAttempts {
+		return errors.New("retry_max_attempts must be between 0 and 10")
+	}
+	if value.RetryBackoffSeconds < 0 || value.RetryBackoffSeconds > maxRetryBackoffSeconds {
+		return errors.New("retry_backoff_seconds must be between 0 and 86400")
+	}
+	if value.InboxRetentionCount < 0 || value.InboxRetentionCount > maxInboxRetentionCount {
+		return errors.New("inbox_retention_count must be between 0 and 10000")
+	}
+	if value.InboxRetentionDays < 0 || value.InboxRetentionDays > maxInboxRetentionDays {
+		return errors.New("inbox_retention_days must be between 0 and 3650")
+	}
+	return nil
+}
+
+// applyAutomationPolicies merges the catch-up, retry and inbox retention
+// fields of a request into an automation.
+func applyAutomationPolicies(updated *automationSchedule, req automationUpsertRequest) error {
+	if req.CatchUp != nil {
+		catchUp, err := normalizeCatchUp(*req.CatchUp)
+		if err != nil {
+			return err
+		}
+		updated.CatchUp = catchUp
+	}
+	if req.CatchUpMaxAgeMinutes != nil {
+		updated.CatchUpMaxAgeMinutes = *req.CatchUpMaxAgeMinutes
+	}
+	if req.RetryMaxAttempts != nil {
+		updated.RetryMaxAttempts = *req.RetryMaxAttempts
+	}
+	if req.RetryBackoffSeconds != nil {
+		updated.RetryBackoffSeconds = *req.RetryBackoffSeconds
+	}
+	if req.InboxRetentionCount != nil {
+		updated.InboxRetentionCount = *req.InboxRetentionCount
+	}
+	if req.InboxRetentionDays != nil {
+		updated.InboxRetentionDays = *req.InboxRetentionDays
+	}
+	return validateAutomationPolicies(*updated)
+}
+
+// planDueRun decides what to queue for an automation whose NextRunAt (at)
+// has passed. Fire times within automationMissGrace of now run as scheduled;
+// older ones are missed and handled by the catch-up policy after dropping
+// those past the maximum age. "all" queues the oldest missed run, and the
+// rest follow one by one because NextRunAt advances from the fire time each
+// run was for. When nothing should run it returns false.
+func planDueRun(schedule store.Automation, at time.Time, now time.Time, trigger string) (automationJobOptions, bool) {
+	timing := timingFromAutomation(schedule)
+	due := []time.Time{at}
+	for len(due) < automationMaxMissedRuns {
+		next, err := timing.next(due[len(due)-1])
+		if err != nil || next.After(now) {
+			break
+		}
+		due = append(due, next)
+	}
+	if schedule.CatchUpMaxAgeMinutes > 0 {
+		maxAge := time.Duration(schedule.CatchUpMaxAgeMinutes) * time.Minute
+		kept := due[:0]
+		for _, fireTime := range due {
+			if now.Sub(fireTime) <= maxAge {
+				kept = append(kept, fireTime)
+			}
+		}
+		due = kept
+	}
+	if len(due) == 0 {
+		return automationJobOptions{}, false
+	}
+	latest := due[len(due)-1]
+	onTime := now.Sub(latest) <= automationMissGrace
+	options := func(fireTime time.Time, trigger string) automationJobOptions {
+		return automationJobOptions{Trigger: trigger, ScheduledFor: fireTime.UTC().Format(time.RFC3339Nano), Attempt: 1}
+	}
+
+	switch fallbackString(schedule.CatchUp, catchUpOnce) {
+	case catchUpSkip:
+		if onTime {
+			return options(latest, trigger), true
+		}
+		return automationJobOptions{}, false
+	case catchUpAll:
+		if len(due) == 1 && onTime {
+			return options(latest, trigger), true
+		}
+		return options(due[0], automationTriggerCatchUp), true
+	default:
+		if onTime {
+			return options(latest, trigger), true
+		}
+		return options(latest, automationTriggerCatchUp), true
+	}
+}
+
+// dueRetry returns the pending retry of an automation once it is due.
+func dueRetry(schedule store.Automation, now time.Time) (automationJobOptions, bool) {
+	at, err := time.Parse(time.RFC3339Nano, schedule.RetryAt)
+	if err != nil || at.After(now) {
+		return automationJobOptions{}, false
+	}
+	return automationJobOptions{
+		Trigger:      automationTriggerRetry,
+		ScheduledFor: schedule.RetryScheduledFor,
+		Attempt:      max(schedule.RetryAttempt, 2),
+	}, true
+}
+
+// advanceAutomationSchedule sets NextRunAt after an entry finished.
+// Scheduled and catch-up runs advance from the fire time they ran for, so
+// later missed fire times are still due; manual runs and retries leave a
+// valid NextRunAt alone.
+func advanceAutomationSchedule(current *store.Automation, entry *store.AutomationInboxEntry, completedAt time.Time) {
+	if !current.Enabled {
+		current.NextRunAt = ""
+		return
+	}
+	timing := timingFromAutomation(*current)
+	if entry != nil && (entry.Trigger == automationTriggerSchedule || entry.Trigger == automationTriggerCatchUp) {
+		if fireTime, err := time.Parse(time.RFC3339Nano, entry.ScheduledFor); err == nil {
+			current.NextRunAt = nextRunTimestamp(timing, fireTime, time.RFC3339Nano)
+			return
+		}
+	}
+	if entry != nil && (entry.Trigger == automationTriggerManual || entry.Trigger == automationTriggerRetry) {
+		if _, err := time.Parse(time.RFC3339Nano, current.NextRunAt); err == nil {
+			return
+		}
+	}
+	current.NextRunAt = nextRunTimestamp(timing, completedAt, time.RFC3339Nano)
+}
+
+// scheduleAutomationRetry records a retry of a failed entry while attempts
+// remain, and clears any pending retry otherwise. It reports whether a retry
+// was scheduled.
+func scheduleAutomationRetry(current *store.Automation, entry *store.AutomationInboxEntry, completedAt time.Time) bool {
+	current.RetryAt = ""
+	current.RetryAttempt = 0
+	current.RetryScheduledFor = ""
+	if entry == nil || entry.Status != "failed" {
+		return false
+	}
+	attempt := max(entry.Attempt, 1)
+	if attempt >= current.RetryMaxAttempts {
+		return false
+	}
+	backoff := defaultRetryBackoff
+	if current.RetryBackoffSeconds > 0 {
+		backoff = time.Duration(current.RetryBackoffSeconds) * time.Second
+	}
+	backoff <<= attempt - 1
+	current.RetryAt = completedAt.Add(backoff).Format(time.RFC3339Nano)
+	current.RetryAttempt = attempt + 1
+	current.RetryScheduledFor = entry.ScheduledFor
+	return true
+}
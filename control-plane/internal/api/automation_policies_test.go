@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestPlanDueRun(t *testing.T) {
+	hour := func(h int, m int) time.Time { return time.Date(2026, time.March, 2, h, m, 0, 0, time.UTC) }
+	hourly := store.Automation{ScheduleType: scheduleTypeCron, CronExpression: "0 * * * *", Timezone: "UTC"}
+	cases := []struct {
+		name         string
+		catchUp      string
+		maxAge       int
+		now          time.Time
+		queued       bool
+		trigger      string
+		scheduledFor time.Time
+	}{
+		{name: "once on time", catchUp: catchUpOnce, now: hour(10, 0), queued: true, trigger: automationTriggerSchedule, scheduledFor: hour(10, 0)},
+		{name: "skip on time", catchUp: catchUpSkip, now: hour(10, 1), queued: true, trigger: automationTriggerSchedule, scheduledFor: hour(10, 0)},
+		{name: "all on time starts with the oldest", catchUp: catchUpAll, now: hour(10, 0), queued: true, trigger: automationTriggerCatchUp, scheduledFor: hour(7, 0)},
+		{name: "once late", catchUp: "", now: hour(10, 30), queued: true, trigger: automationTriggerCatchUp, scheduledFor: hour(10, 0)},
+		{name: "skip late", catchUp: catchUpSkip, now: hour(10, 30), queued: false},
+		{name: "all within max age", catchUp: catchUpAll, maxAge: 90, now: hour(10, 30), queued: true, trigger: automationTriggerCatchUp, scheduledFor: hour(9, 0)},
+		{name: "once past max age", catchUp: catchUpOnce, maxAge: 20, now: hour(10, 30), queued: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule := hourly
+			schedule.CatchUp = tc.catchUp
+			schedule.CatchUpMaxAgeMinutes = tc.maxAge
+			options, queued := planDueRun(schedule, hour(7, 0), tc.now, automationTriggerSchedule)
+			require.Equal(t, tc.queued, queued)
+			if !tc.queued {
+				return
+			}
+			require.Equal(t, tc.trigger, options.Trigger)
+			require.Equal(t, tc.scheduledFor.Format(time.RFC3339Nano), options.ScheduledFor)
+			require.Equal(t, 1, options.Attempt)
+		})
+	}
+
+	// The last on-time run of "all" is a plain scheduled run.
+	options, queued := planDueRun(store.Automation{ScheduleType: scheduleTypeCron, CronExpression: "0 * * * *", CatchUp: catchUpAll}, hour(10, 0), hour(10, 0), automationTriggerSchedule)
+	require.True(t, queued)
+	require.Equal(t, automationTriggerSchedule, options.Trigger)
+}
+
+func TestAdvanceAutomationSchedule(t *testing.T) {
+	completedAt := time.Date(2026, time.March, 2, 10, 30, 0, 0, time.UTC)
+	automation := store.Automation{ScheduleType: scheduleTypeCron, CronExpression: "0 * * * *", Enabled: true, NextRunAt: "2026-03-02T07:00:00Z"}
+
+	// Catch-ups advance from their fire time, so later missed runs stay due.
+	current := automation
+	advanceAutomationSchedule(&current, &store.AutomationInboxEntry{Trigger: automationTriggerCatchUp, ScheduledFor: "2026-03-02T07:00:00Z"}, completedAt)
+	require.Equal(t, "2026-03-02T08:00:00Z", current.NextRunAt)
+
+	// Manual runs keep the pending fire time.
+	current = automation
+	advanceAutomationSchedule(&current, &store.AutomationInboxEntry{Trigger: automationTriggerManual}, completedAt)
+	require.Equal(t, "2026-03-02T07:00:00Z", current.NextRunAt)
+
+	current = automation
+	current.NextRunAt = ""
+	advanceAutomationSchedule(&current, &store.AutomationInboxEntry{Trigger: automationTriggerRetry}, completedAt)
+	require.Equal(t, "2026-03-02T11:00:00Z", current.NextRunAt)
+}
+
+func TestAutomationRetries(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	api := NewServer(mem, &MockBroker{}, nil, config.Config{})
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{
+		ID: "auto-1", Name: "Auto", Prompt: "p", Kind: automationKindPrompt, Days: []string{"mon"}, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true,
+		RetryMaxAttempts: 3, RetryBackoffSeconds: 10,
+	}))
+	finished := func(count int) []store.AutomationInboxEntry {
+		var entries []store.AutomationInboxEntry
+		require.Eventually(t, func() bool {
+			automation, err := mem.GetAutomation(ctx, "auto-1")
+			if err != nil || automation.InProgress {
+				return false
+			}
+			entries, err = mem.ListAutomationInbox(ctx, "auto-1")
+			return err == nil && len(entries) == count
+		}, 5*time.Second, 10*time.Millisecond)
+		return entries
+	}
+	expireRetry := func() {
+		automation, err := mem.GetAutomation(ctx, "auto-1")
+		require.NoError(t, err)
+		automation.RetryAt = time.Now().UTC().Add(-time.Second).Format(time.RFC3339Nano)
+		require.NoError(t, mem.UpdateAutomation(ctx, *automation))
+	}
+
+	// Without LLM settings every attempt fails.
+	queued, reason := api.queueAutomationExecution(ctx, "auto-1", automationTriggerManual)
+	require.True(t, queued, reason)
+	entries := finished(1)
+	require.Equal(t, "failed", entries[0].Status)
+	automation, err := mem.GetAutomation(ctx, "auto-1")
+	require.NoError(t, err)
+	require.Equal(t, 2, automation.RetryAttempt)
+	retryAt, err := time.Parse(time.RFC3339Nano, automation.RetryAt)
+	require.NoError(t, err)
+	completedAt, err := time.Parse(time.RFC3339Nano, entries[0].CompletedAt)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Second, retryAt.Sub(completedAt))
+
+	// Not due yet.
+	require.Zero(t, api.queueDueAutomations(ctx, automationTriggerSchedule))
+
+	expireRetry()
+	require.Equal(t, 1, api.queueDueAutomations(ctx, automationTriggerSchedule))
+	entries = finished(2)
+	require.Equal(t, automationTriggerRetry, entries[0].Trigger)
+	require.Equal(t, 2, entries[0].Attempt)
+	automation, err = mem.GetAutomation(ctx, "auto-1")
+	require.NoError(t, err)
+	require.Equal(t, 3, automation.RetryAttempt)
+	retryAt, err = time.Parse(time.RFC3339Nano, automation.RetryAt)
+	require.NoError(t, err)
+	completedAt, err = time.Parse(time.RFC3339Nano, entries[0].CompletedAt)
+	require.NoError(t, err)
+	require.Equal(t, 20*time.Second, retryAt.Sub(completedAt))
+
+	// The third attempt is the last.
+	expireRetry()
+	require.Equal(t, 1, api.queueDueAutomations(ctx, automationTriggerSchedule))
+	entries = finished(3)
+	require.Equal(t, 3, entries[0].Attempt)
+	automation, err = mem.GetAutomation(ctx, "auto-1")
+	require.NoError(t, err)
+	require.Empty(t, automation.RetryAt)
+	require.Zero(t, automation.RetryAttempt)
+}
+
+func TestAutomationPoliciesAPI(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Auto","prompt":"p"}`))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, catchUpOnce, created.CatchUp)
+	require.Zero(t, created.RetryMaxAttempts)
+
+	update := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"catch_up":"all","catch_up_max_age_minutes":720,"retry_max_attempts":3,"retry_backoff_seconds":30}`))
+	defer update.Body.Close()
+	require.Equal(t, http.StatusOK, update.StatusCode)
+	var updated automationSchedule
+	require.NoError(t, json.NewDecoder(update.Body).Decode(&updated))
+	require.Equal(t, catchUpAll, updated.CatchUp)
+	require.Equal(t, 720, updated.CatchUpMaxAgeMinutes)
+	require.Equal(t, 3, updated.RetryMaxAttempts)
+	require.Equal(t, 30, updated.RetryBackoffSeconds)
+
+	for _, body := range []string{
+		`{"name":"Auto","prompt":"p","catch_up":"sometimes"}`,
+		`{"name":"Auto","prompt":"p","catch_up_max_age_minutes":-1}`,
+		`{"name":"Auto","prompt":"p","retry_max_attempts":11}`,
+		`{"name":"Auto","prompt":"p","retry_backoff_seconds":90000}`,
+	} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+}
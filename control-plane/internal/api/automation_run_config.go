@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	browserModeUserTab = "user_tab"
+
+	browserInteractionEnabled  = "enabled"
+	browserInteractionDisabled = "disabled"
+
+	// Automation runs wait between 5 seconds and 30 minutes, the range
+	// executeAutomationRun accepts.
+	minAutomationTimeoutMS = 5000
+	maxAutomationTimeoutMS = 1800000
+)
+
+func normalizeBrowserMode(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return "", nil
+	case browserModeUserTab:
+		return browserModeUserTab, nil
+	default:
+		return "", errors.New("browser_mode must be user_tab or empty")
+	}
+}
+
+// normalizeBrowserInteraction accepts the aliases the run worker understands
+// and stores them as "enabled" or "disabled".
+func normalizeBrowserInteraction(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return "", nil
+	case browserInteractionEnabled, "true", "allow", "allowed", "interactive":
+		return browserInteractionEnabled, nil
+	case browserInteractionDisabled, "false", "deny", "denied", "read_only":
+		return browserInteractionDisabled, nil
+	default:
+		return "", errors.New("browser_interaction must be enabled or disabled")
+	}
+}
+
+// resolveAutomationSkills validates skill names against the workspace's
+// skills.
+func (s *Server) resolveAutomationSkills(ctx context.Context, names []string) ([]string, int, error) {
+	resolved := normalizeRouteCriteria(names, false)
+	if len(resolved) == 0 {
+		return resolved, http.StatusOK, nil
+	}
+	skillsList, err := s.store.ListSkills(ctx)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	known := make(map[string]struct{}, len(skillsList))
+	for _, skill := range skillsList {
+		known[skill.Name] = struct{}{}
+	}
+	for _, name := range resolved {
+		if _, ok := known[name]; !ok {
+			return nil, http.StatusBadRequest, fmt.Errorf("unknown skill: %s", name)
+		}
+	}
+	return resolved, http.StatusOK, nil
+}
+
+// applyAutomationRunConfig merges the run configuration of a request (policy
+// profile, tags, timeout, browser settings and skills) into an automation.
+// Omitted fields keep their value; an empty list clears a list.
+func (s *Server) applyAutomationRunConfig(ctx context.Context, updated *automationSchedule, req automationUpsertRequest) (int, error) {
+	var err error
+	if req.PolicyProfile != nil {
+		updated.PolicyProfile = strings.TrimSpace(*req.PolicyProfile)
+	}
+	if req.Tags != nil {
+		updated.Tags = normalizeRouteCriteria(req.Tags, false)
+	}
+	if req.TimeoutMS != nil {
+		timeout := *req.TimeoutMS
+		if timeout != 0 && (timeout < minAutomationTimeoutMS || timeout > maxAutomationTimeoutMS) {
+			return http.StatusBadRequest, errors.New("timeout_ms must be 0 or between 5000 and 1800000")
+		}
+		updated.TimeoutMS = timeout
+	}
+	if req.BrowserMode != nil {
+		if updated.BrowserMode, err = normalizeBrowserMode(*req.BrowserMode); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if req.BrowserInteraction != nil {
+		if updated.BrowserInteraction, err = normalizeBrowserInteraction(*req.BrowserInteraction); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if req.BrowserDomainAllowlist != nil {
+		updated.BrowserDomainAllowlist = normalizeRouteCriteria(req.BrowserDomainAllowlist, true)
+	}
+	if req.BrowserPreferred != nil {
+		updated.BrowserPreferred = strings.ToLower(strings.TrimSpace(*req.BrowserPreferred))
+	}
+	if req.BrowserUserAgent != nil {
+		updated.BrowserUserAgent = strings.TrimSpace(*req.BrowserUserAgent)
+	}
+	if req.Skills != nil {
+		skills, status, err := s.resolveAutomationSkills(ctx, req.Skills)
+		if err != nil {
+			return status, err
+		}
+		updated.Skills = skills
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestAutomationRunConfigAPI(t *testing.T) {
+	mem := memory.New()
+	require.NoError(t, mem.CreateSkill(context.Background(), store.Skill{ID: "skill-1", Name: "research"}))
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{
+		"name":"Auto","prompt":"p","policy_profile":"strict","tags":["news"," news","daily"],"timeout_ms":600000,
+		"browser_mode":"USER_TAB","browser_interaction":"allow","browser_domain_allowlist":["Example.com"],
+		"browser_preferred_browser":"Brave","browser_user_agent":"Mozilla/5.0","skills":["research"]
+	}`))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, "strict", created.PolicyProfile)
+	require.Equal(t, []string{"news", "daily"}, created.Tags)
+	require.Equal(t, 600000, created.TimeoutMS)
+	require.Equal(t, browserModeUserTab, created.BrowserMode)
+	require.Equal(t, browserInteractionEnabled, created.BrowserInteraction)
+	require.Equal(t, []string{"example.com"}, created.BrowserDomainAllowlist)
+	require.Equal(t, "brave", created.BrowserPreferred)
+	require.Equal(t, []string{"research"}, created.Skills)
+
+	// Omitted fields are kept; an empty list clears.
+	update := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"browser_mode":"","skills":[]}`))
+	defer update.Body.Close()
+	require.Equal(t, http.StatusOK, update.StatusCode)
+	var updated automationSchedule
+	require.NoError(t, json.NewDecoder(update.Body).Decode(&updated))
+	require.Empty(t, updated.BrowserMode)
+	require.Empty(t, updated.Skills)
+	require.Equal(t, "strict", updated.PolicyProfile)
+	require.Equal(t, []string{"news", "daily"}, updated.Tags)
+
+	for _, body := range []string{
+		`{"name":"Auto","prompt":"p","browser_mode":"headless"}`,
+		`{"name":"Auto","prompt":"p","browser_interaction":"sometimes"}`,
+		`{"name":"Auto","prompt":"p","timeout_ms":1000}`,
+		`{"name":"Auto","prompt":"p","skills":["missing"]}`,
+	} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+}
+
+func TestAutomationPromptRequestPassesRunConfig(t *testing.T) {
+	req := automationPromptRequest(store.Automation{
+		ID:                     "auto-1",
+		Name:                   "Auto",
+		Prompt:                 "p",
+		Model:                  "gpt-4.1",
+		PolicyProfile:          "strict",
+		Tags:                   []string{"news"},
+		TimeoutMS:              600000,
+		BrowserMode:            browserModeUserTab,
+		BrowserInteraction:     browserInteractionDisabled,
+		BrowserDomainAllowlist: []string{"example.com"},
+		BrowserPreferred:       "brave",
+		BrowserUserAgent:       "Mozilla/5.0",
+		Skills:                 []string{"research"},
+	})
+	require.Equal(t, "strict", req.PolicyProfile)
+	require.Equal(t, []string{"news"}, req.Tags)
+	require.Equal(t, 600000, req.TimeoutMS)
+	require.Equal(t, browserModeUserTab, req.BrowserMode)
+	require.Equal(t, browserInteractionDisabled, req.BrowserInteraction)
+	require.Equal(t, []string{"example.com"}, req.BrowserDomainAllowlist)
+	require.Equal(t, "brave", req.BrowserPreferred)
+	require.Equal(t, "Mozilla/5.0", req.BrowserUserAgent)
+	require.Equal(t, []string{"research"}, req.Skills)
+	require.Equal(t, "auto-1", req.Metadata["automation_id"])
+}
@@ -0,0 +1,657 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	scheduleTypeWeekly   = "weekly"
+	scheduleTypeCron     = "cron"
+	scheduleTypeInterval = "interval"
+	scheduleTypeOnce     = "once"
+	// scheduleTypeManual never fires; such automations only run manually
+	// or from event triggers.
+	scheduleTypeManual = "manual"
+
+	// schedulePreviewCount is how many fire times previews and automation
+	// details list.
+	schedulePreviewCount = 5
+	// scheduleSearchYears bounds the search for the next cron fire time, so
+	// expressions that never match (such as 30 February) fail instead of
+	// looping.
+	scheduleSearchYears = 5
+	blackoutDateLayout  = "2006-01-02"
+)
+
+var errNoUpcomingRun = errors.New("schedule has no upcoming runs")
+
+// automationTiming is everything that decides when an automation fires.
+// Weekly schedules run on Days at TimeOfDay; Cron, IntervalMinutes and RunAt
+// belong to the other types. Intervals count from Anchor. BlackoutDates are
+// local YYYY-MM-DD dates on which nothing fires.
+type automationTiming struct {
+	Type            string
+	Days            []string
+	TimeOfDay       string
+	Timezone        string
+	Cron            string
+	IntervalMinutes int
+	RunAt           string
+	Anchor          string
+	BlackoutDates   []string
+}
+
+type schedulePreviewRequest struct {
+	ScheduleType    string   `json:"schedule_type"`
+	Days            []string `json:"days"`
+	TimeOfDay       string   `json:"time"`
+	Timezone        string   `json:"timezone"`
+	Cron            string   `json:"cron"`
+	IntervalMinutes int      `json:"interval_minutes"`
+	RunAt           string   `json:"run_at"`
+	BlackoutDates   []string `json:"blackout_dates"`
+}
+
+type schedulePreviewResponse struct {
+	ScheduleType string   `json:"schedule_type"`
+	Timezone     string   `json:"timezone"`
+	NextRuns     []string `json:"next_runs"`
+}
+
+func timingFromAutomation(value store.Automation) automationTiming {
+	return automationTiming{
+		Type:            value.ScheduleType,
+		Days:            value.Days,
+		TimeOfDay:       value.TimeOfDay,
+		Timezone:        value.Timezone,
+		Cron:            value.CronExpression,
+		IntervalMinutes: value.IntervalMinutes,
+		RunAt:           value.RunAt,
+		Anchor:          value.CreatedAt,
+		BlackoutDates:   value.BlackoutDates,
+	}
+}
+
+func timingFromSchedule(value automationSchedule) automationTiming {
+	return automationTiming{
+		Type:            value.ScheduleType,
+		Days:            value.Days,
+		TimeOfDay:       value.TimeOfDay,
+		Timezone:        value.Timezone,
+		Cron:            value.Cron,
+		IntervalMinutes: value.IntervalMinutes,
+		RunAt:           value.RunAt,
+		Anchor:          value.CreatedAt,
+		BlackoutDates:   value.BlackoutDates,
+	}
+}
+
+// nextAutomationRun returns the first fire time of an automation after from.
+func nextAutomationRun(value store.Automation, from time.Time) (time.Time, error) {
+	return timingFromAutomation(value).next(from)
+}
+
+// nextRunTimestamp formats the next fire time after from, or returns "" when
+// the schedule has none left, such as a one-shot that already ran.
+func nextRunTimestamp(timing automationTiming, from time.Time, layout string) string {
+	next, err := timing.next(from)
+	if err != nil {
+		return ""
+	}
+	return next.Format(layout)
+}
+
+// computeNextRun returns the next weekly fire time on days at timeOfDay.
+func computeNextRun(days []string, timeOfDay string, timezone string, from time.Time) (time.Time, error) {
+	return automationTiming{Type: scheduleTypeWeekly, Days: days, TimeOfDay: timeOfDay, Timezone: timezone}.next(from)
+}
+
+func (t automationTiming) next(from time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(normalizeTimezone(t.Timezone))
+	if err != nil {
+		return time.Time{}, err
+	}
+	blackout := map[string]bool{}
+	for _, date := range t.BlackoutDates {
+		blackout[date] = true
+	}
+	blackedOut := func(at time.Time) bool {
+		return blackout[at.In(loc).Format(blackoutDateLayout)]
+	}
+
+	switch fallbackString(t.Type, scheduleTypeWeekly) {
+	case scheduleTypeManual:
+		return time.Time{}, errNoUpcomingRun
+	case scheduleTypeOnce:
+		at, err := time.Parse(time.RFC3339, t.RunAt)
+		if err != nil {
+			return time.Time{}, errors.New("run_at must be an RFC3339 timestamp")
+		}
+		if !at.After(from) || blackedOut(at) {
+			return time.Time{}, errNoUpcomingRun
+		}
+		return at.UTC(), nil
+	case scheduleTypeInterval:
+		if t.IntervalMinutes <= 0 {
+			return time.Time{}, errors.New("interval_minutes must be positive")
+		}
+		interval := time.Duration(t.IntervalMinutes) * time.Minute
+		anchor, err := time.Parse(time.RFC3339Nano, t.Anchor)
+		if err != nil {
+			anchor = from
+		}
+		// Each blacked-out candidate moves the search past that local day,
+		// so this ends after at most one pass per blackout date.
+		for range len(blackout) + 1 {
+			candidate := anchor
+			if !candidate.After(from) {
+				steps := from.Sub(anchor)/interval + 1
+				candidate = anchor.Add(steps * interval)
+			}
+			if !blackedOut(candidate) {
+				return candidate.UTC(), nil
+			}
+			local := candidate.In(loc)
+			from = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+		}
+		return time.Time{}, errNoUpcomingRun
+	case scheduleTypeCron:
+		spec, err := parseCron(t.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return spec.next(from, loc, blackedOut)
+	default:
+		timeOfDay, err := time.Parse("15:04", t.TimeOfDay)
+		if err != nil {
+			return time.Time{}, err
+		}
+		spec := cronSpec{second: 1, minute: 1 << uint(timeOfDay.Minute()), hour: 1 << uint(timeOfDay.Hour()), dom: cronAll(1, 31), month: cronAll(1, 12), domStar: true}
+		for _, day := range normalizeDays(t.Days) {
+			spec.dow |= 1 << uint(weekdayIndex[day])
+		}
+		return spec.next(from, loc, blackedOut)
+	}
+}
+
+// upcoming returns up to n fire times after from.
+func (t automationTiming) upcoming(from time.Time, n int) ([]time.Time, error) {
+	result := make([]time.Time, 0, n)
+	for len(result) < n {
+		next, err := t.next(from)
+		if errors.Is(err, errNoUpcomingRun) && len(result) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, next)
+		from = next
+	}
+	return result, nil
+}
+
+// normalizeAutomationTiming validates the schedule fields of a request. The
+// type is inferred from cron, interval_minutes or run_at when it is not
+// given, and fields of other types are cleared.
+func normalizeAutomationTiming(t *automationTiming, now time.Time) error {
+	scheduleType := strings.ToLower(strings.TrimSpace(t.Type))
+	if scheduleType == "" {
+		switch {
+		case strings.TrimSpace(t.Cron) != "":
+			scheduleType = scheduleTypeCron
+		case t.IntervalMinutes != 0:
+			scheduleType = scheduleTypeInterval
+		case strings.TrimSpace(t.RunAt) != "":
+			scheduleType = scheduleTypeOnce
+		default:
+			scheduleType = scheduleTypeWeekly
+		}
+	}
+	t.Type = scheduleType
+	t.Timezone = normalizeTimezone(t.Timezone)
+	t.Days = normalizeDays(t.Days)
+	timeOfDay, err := normalizeTimeOfDay(t.TimeOfDay)
+	if err != nil {
+		return err
+	}
+	t.TimeOfDay = timeOfDay
+	dates, err := normalizeBlackoutDates(t.BlackoutDates)
+	if err != nil {
+		return err
+	}
+	t.BlackoutDates = dates
+
+	switch scheduleType {
+	case scheduleTypeWeekly:
+		t.Cron, t.IntervalMinutes, t.RunAt = "", 0, ""
+	case scheduleTypeCron:
+		t.Cron = strings.Join(strings.Fields(t.Cron), " ")
+		if _, err := parseCron(t.Cron); err != nil {
+			return err
+		}
+		t.IntervalMinutes, t.RunAt = 0, ""
+	case scheduleTypeInterval:
+		if t.IntervalMinutes <= 0 {
+			return errors.New("interval_minutes must be positive")
+		}
+		t.Cron, t.RunAt = "", ""
+	case scheduleTypeOnce:
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(t.RunAt))
+		if err != nil {
+			return errors.New("run_at must be an RFC3339 timestamp")
+		}
+		if !at.After(now) {
+			return errors.New("run_at must be in the future")
+		}
+		t.RunAt = at.UTC().Format(time.RFC3339)
+		t.Cron, t.IntervalMinutes = "", 0
+	case scheduleTypeManual:
+		t.Cron, t.IntervalMinutes, t.RunAt = "", 0, ""
+		return nil
+	default:
+		return errors.New("schedule_type must be weekly, cron, interval, once or manual")
+	}
+	if _, err := t.next(now); err != nil {
+		return err
+	}
+	return nil
+}
+
+// schedulesChanged reports whether an update touches any schedule field.
+func (req automationUpsertRequest) schedulesChanged() bool {
+	return len(req.Days) > 0 || strings.TrimSpace(req.TimeOfDay) != "" || strings.TrimSpace(req.Timezone) != "" ||
+		req.ScheduleType != nil || req.Cron != nil || req.IntervalMinutes != nil || req.RunAt != nil || req.BlackoutDates != nil
+}
+
+// applyAutomationTiming merges the schedule fields of an update into an
+// automation and validates the result. Giving only cron, interval_minutes
+// or run_at switches to that schedule type.
+func applyAutomationTiming(updated *automationSchedule, req automationUpsertRequest, now time.Time) (int, error) {
+	timing := timingFromSchedule(*updated)
+	switch {
+	case req.ScheduleType != nil:
+		timing.Type = *req.ScheduleType
+	case req.Cron != nil:
+		timing.Type = scheduleTypeCron
+	case req.IntervalMinutes != nil:
+		timing.Type = scheduleTypeInterval
+	case req.RunAt != nil:
+		timing.Type = scheduleTypeOnce
+	}
+	if req.Cron != nil {
+		timing.Cron = *req.Cron
+	}
+	if req.IntervalMinutes != nil {
+		timing.IntervalMinutes = *req.IntervalMinutes
+	}
+	if req.RunAt != nil {
+		timing.RunAt = *req.RunAt
+	}
+	if len(req.Days) > 0 {
+		timing.Days = req.Days
+	}
+	if strings.TrimSpace(req.TimeOfDay) != "" {
+		timing.TimeOfDay = req.TimeOfDay
+	}
+	if strings.TrimSpace(req.Timezone) != "" {
+		timing.Timezone = req.Timezone
+	}
+	if req.BlackoutDates != nil {
+		timing.BlackoutDates = req.BlackoutDates
+	}
+	if err := normalizeAutomationTiming(&timing, now); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if updated.Kind == automationKindDigest && timing.Type != scheduleTypeWeekly {
+		return http.StatusBadRequest, errors.New("digest automations use a weekly schedule")
+	}
+	updated.ScheduleType = timing.Type
+	updated.Days = timing.Days
+	updated.TimeOfDay = timing.TimeOfDay
+	updated.Timezone = timing.Timezone
+	updated.Cron = timing.Cron
+	updated.IntervalMinutes = timing.IntervalMinutes
+	updated.RunAt = timing.RunAt
+	updated.BlackoutDates = timing.BlackoutDates
+	return http.StatusOK, nil
+}
+
+func optionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func normalizeBlackoutDates(values []string) ([]string, error) {
+	seen := map[string]bool{}
+	dates := make([]string, 0, len(values))
+	for _, raw := range values {
+		value := strings.TrimSpace(raw)
+		if _, err := time.Parse(blackoutDateLayout, value); err != nil {
+			return nil, fmt.Errorf("blackout date %q must use YYYY-MM-DD format", raw)
+		}
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		dates = append(dates, value)
+	}
+	sort.Strings(dates)
+	return dates, nil
+}
+
+// formatUpcomingRuns lists the next fire times in the schedule's timezone, so
+// DST shifts are visible in the offsets.
+func formatUpcomingRuns(timing automationTiming, from time.Time) []string {
+	runs, err := timing.upcoming(from, schedulePreviewCount)
+	if err != nil {
+		return []string{}
+	}
+	loc, err := time.LoadLocation(normalizeTimezone(timing.Timezone))
+	if err != nil {
+		loc = time.UTC
+	}
+	formatted := make([]string, 0, len(runs))
+	for _, run := range runs {
+		formatted = append(formatted, run.In(loc).Format(time.RFC3339))
+	}
+	return formatted
+}
+
+func (s *Server) previewAutomationSchedule(w http.ResponseWriter, r *http.Request) {
+	req := schedulePreviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	timing := automationTiming{
+		Type:            req.ScheduleType,
+		Days:            req.Days,
+		TimeOfDay:       req.TimeOfDay,
+		Timezone:        req.Timezone,
+		Cron:            req.Cron,
+		IntervalMinutes: req.IntervalMinutes,
+		RunAt:           req.RunAt,
+		Anchor:          now.Format(time.RFC3339Nano),
+		BlackoutDates:   req.BlackoutDates,
+	}
+	if err := normalizeAutomationTiming(&timing, now); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSONStatus(w, schedulePreviewResponse{
+		ScheduleType: timing.Type,
+		Timezone:     timing.Timezone,
+		NextRuns:     formatUpcomingRuns(timing, now),
+	}, http.StatusOK)
+}
+
+// cronSpec is a parsed cron expression as bitmasks of allowed values. A field
+// written as * or ? sets its Star flag: day-of-month and day-of-week match
+// when either does if both are restricted, as in Vixie cron.
+type cronSpec struct {
+	second   uint64
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	domStar  bool
+	dowStar  bool
+	hourStar bool
+}
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	cronMonthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	cronDayNames   = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+	cronSecondField = cronField{name: "second", min: 0, max: 59}
+	cronMinuteField = cronField{name: "minute", min: 0, max: 59}
+	cronHourField   = cronField{name: "hour", min: 0, max: 23}
+	cronDomField    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonthField  = cronField{name: "month", min: 1, max: 12, names: cronMonthNames}
+	// Day of week accepts 7 for Sunday.
+	cronDowField = cronField{name: "day of week", min: 0, max: 7, names: cronDayNames}
+
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+func cronAll(min int, max int) uint64 {
+	var bits uint64
+	for value := min; value <= max; value++ {
+		bits |= 1 << uint(value)
+	}
+	return bits
+}
+
+// parseCron parses a 5-field (minute hour day-of-month month day-of-week) or
+// 6-field (with a leading second) expression, or one of the @ macros.
+func parseCron(expression string) (cronSpec, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, ok := cronMacros[strings.ToLower(expression)]; ok {
+		expression = macro
+	}
+	fields := strings.Fields(expression)
+	if len(fields) == 5 {
+		fields = append([]string{"0"}, fields...)
+	}
+	if len(fields) != 6 {
+		return cronSpec{}, errors.New("invalid cron expression: expected 5 or 6 fields")
+	}
+	spec := cronSpec{}
+	parsers := []struct {
+		field cronField
+		bits  *uint64
+		star  *bool
+	}{
+		{cronSecondField, &spec.second, nil},
+		{cronMinuteField, &spec.minute, nil},
+		{cronHourField, &spec.hour, &spec.hourStar},
+		{cronDomField, &spec.dom, &spec.domStar},
+		{cronMonthField, &spec.month, nil},
+		{cronDowField, &spec.dow, &spec.dowStar},
+	}
+	for idx, parser := range parsers {
+		bits, star, err := parseCronField(fields[idx], parser.field)
+		if err != nil {
+			return cronSpec{}, fmt.Errorf("invalid cron expression: %w", err)
+		}
+		*parser.bits = bits
+		if parser.star != nil {
+			*parser.star = star
+		}
+	}
+	if spec.dow&(1<<7) != 0 {
+		spec.dow = spec.dow&^(1<<7) | 1
+	}
+	return spec, nil
+}
+
+func parseCronField(value string, field cronField) (uint64, bool, error) {
+	var bits uint64
+	star := false
+	for _, part := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			parsed, err := strconv.Atoi(stepPart)
+			if err != nil || parsed <= 0 {
+				return 0, false, fmt.Errorf("invalid step %q in %s field", stepPart, field.name)
+			}
+			step = parsed
+		}
+		var low, high int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			if rangePart == "?" && field.name != cronDomField.name && field.name != cronDowField.name {
+				return 0, false, fmt.Errorf("? is only allowed in day fields, not %s", field.name)
+			}
+			low, high = field.min, field.max
+			if field.name == cronDowField.name {
+				high = 6
+			}
+			star = star || !hasStep
+		default:
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = field.value(lowPart); err != nil {
+				return 0, false, err
+			}
+			high = low
+			if isRange {
+				if high, err = field.value(highPart); err != nil {
+					return 0, false, err
+				}
+			} else if hasStep {
+				high = field.max
+			}
+			if high < low {
+				return 0, false, fmt.Errorf("range %q in %s field is reversed", rangePart, field.name)
+			}
+		}
+		for current := low; current <= high; current += step {
+			bits |= 1 << uint(current)
+		}
+	}
+	return bits, star, nil
+}
+
+func (f cronField) value(raw string) (int, error) {
+	if value, ok := f.names[strings.ToLower(raw)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("%q is not a valid %s", raw, f.name)
+	}
+	return value, nil
+}
+
+func (c cronSpec) matchesDay(day time.Time) bool {
+	if c.month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	domMatch := c.dom&(1<<uint(day.Day())) != 0
+	dowMatch := c.dow&(1<<uint(day.Weekday())) != 0
+	if !c.domStar && !c.dowStar {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// next returns the first fire time after from. Schedules follow the wall
+// clock in loc: a time skipped by a DST gap fires once, shifted forward by
+// the gap, and a time repeated by a DST overlap fires only on its first
+// occurrence unless the hour field is *, which keeps firing through both.
+func (c cronSpec) next(from time.Time, loc *time.Location, blackedOut func(time.Time) bool) (time.Time, error) {
+	start := from.In(loc)
+	firstDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	lastDay := firstDay.AddDate(scheduleSearchYears, 0, 0)
+	// Candidates are generated in wall-clock order, but DST shifts can move
+	// a later wall time before an earlier one by at most this much.
+	const slack = 3 * time.Hour
+	for day := firstDay.AddDate(0, 0, -1); !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		if !c.matchesDay(day) {
+			continue
+		}
+		var best time.Time
+		var bestWall time.Time
+		for hour := 0; hour < 24; hour++ {
+			if c.hour&(1<<uint(hour)) == 0 {
+				continue
+			}
+			hourEnd := time.Date(day.Year(), day.Month(), day.Day(), hour, 59, 59, 0, time.UTC)
+			if hourEnd.Add(slack).Before(from.Add(-14 * time.Hour)) {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if c.minute&(1<<uint(minute)) == 0 {
+					continue
+				}
+				for second := 0; second < 60; second++ {
+					if c.second&(1<<uint(second)) == 0 {
+						continue
+					}
+					wall := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, time.UTC)
+					if !best.IsZero() && wall.After(bestWall.Add(slack)) {
+						return best, nil
+					}
+					instants := resolveWallClock(wall, loc)
+					if len(instants) > 1 && !c.hourStar {
+						instants = instants[:1]
+					}
+					for _, instant := range instants {
+						if !instant.After(from) || blackedOut(instant) {
+							continue
+						}
+						if best.IsZero() || instant.Before(best) {
+							best, bestWall = instant, wall
+						}
+					}
+				}
+			}
+		}
+		if !best.IsZero() {
+			return best, nil
+		}
+	}
+	return time.Time{}, errNoUpcomingRun
+}
+
+// resolveWallClock returns the instants at which loc shows the wall-clock
+// time in wall (whose own zone is ignored), earliest first. A time inside a
+// DST gap resolves to the instant the same distance past the gap's start.
+func resolveWallClock(wall time.Time, loc *time.Location) []time.Time {
+	offsets := []int{}
+	for _, probe := range []time.Duration{-26 * time.Hour, 0, 26 * time.Hour} {
+		_, offset := wall.Add(probe).In(loc).Zone()
+		duplicate := false
+		for _, seen := range offsets {
+			duplicate = duplicate || seen == offset
+		}
+		if !duplicate {
+			offsets = append(offsets, offset)
+		}
+	}
+	instants := []time.Time{}
+	for _, offset := range offsets {
+		instant := wall.Add(-time.Duration(offset) * time.Second)
+		local := instant.In(loc)
+		if time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC).Equal(wall) {
+			instants = append(instants, instant.UTC())
+		}
+	}
+	if len(instants) == 0 {
+		_, before := wall.Add(-26 * time.Hour).In(loc).Zone()
+		return []time.Time{wall.Add(-time.Duration(before) * time.Second).UTC()}
+	}
+	sort.Slice(instants, func(i, j int) bool { return instants[i].Before(instants[j]) })
+	return instants
+}
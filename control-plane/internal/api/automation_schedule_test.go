@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestParseCron(t *testing.T) {
+	spec, err := parseCron("*/15 9-17 * JAN-mar mon-FRI")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), spec.second)
+	require.Equal(t, uint64(1|1<<15|1<<30|1<<45), spec.minute)
+	require.Equal(t, cronAll(9, 17), spec.hour)
+	require.Equal(t, cronAll(1, 3), spec.month)
+	require.Equal(t, cronAll(1, 5), spec.dow)
+	require.True(t, spec.domStar)
+	require.False(t, spec.dowStar)
+
+	spec, err = parseCron("30 0 12 ? * 7")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1<<30), spec.second)
+	require.Equal(t, uint64(1), spec.dow)
+
+	spec, err = parseCron("@daily")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), spec.hour)
+
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "? * * * *", "* * * foo *"} {
+		_, err := parseCron(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestAutomationTimingNext(t *testing.T) {
+	from := time.Date(2026, time.February, 9, 8, 0, 0, 0, time.UTC) // Monday
+
+	t.Run("cron day fields", func(t *testing.T) {
+		// Day of month and day of week both restricted: either matches.
+		timing := automationTiming{Type: scheduleTypeCron, Cron: "0 9 13 * mon", Timezone: "UTC"}
+		runs, err := timing.upcoming(from, 3)
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{
+			time.Date(2026, time.February, 9, 9, 0, 0, 0, time.UTC),
+			time.Date(2026, time.February, 13, 9, 0, 0, 0, time.UTC),
+			time.Date(2026, time.February, 16, 9, 0, 0, 0, time.UTC),
+		}, runs)
+	})
+
+	t.Run("cron with seconds and a distant match", func(t *testing.T) {
+		timing := automationTiming{Type: scheduleTypeCron, Cron: "15 30 6 29 2 *", Timezone: "UTC"}
+		next, err := timing.next(from)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2028, time.February, 29, 6, 30, 15, 0, time.UTC), next)
+
+		_, err = automationTiming{Type: scheduleTypeCron, Cron: "0 0 30 2 *"}.next(from)
+		require.ErrorIs(t, err, errNoUpcomingRun)
+	})
+
+	t.Run("weekly with blackout dates", func(t *testing.T) {
+		timing := automationTiming{Days: []string{"mon", "fri"}, TimeOfDay: "09:00", Timezone: "UTC", BlackoutDates: []string{"2026-02-09", "2026-02-13"}}
+		next, err := timing.next(from)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2026, time.February, 16, 9, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		timing := automationTiming{Type: scheduleTypeInterval, IntervalMinutes: 90, Timezone: "UTC", Anchor: "2026-02-09T06:00:00Z"}
+		runs, err := timing.upcoming(from, 2)
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{
+			time.Date(2026, time.February, 9, 9, 0, 0, 0, time.UTC),
+			time.Date(2026, time.February, 9, 10, 30, 0, 0, time.UTC),
+		}, runs)
+
+		timing.BlackoutDates = []string{"2026-02-09"}
+		next, err := timing.next(from)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2026, time.February, 10, 0, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("once", func(t *testing.T) {
+		timing := automationTiming{Type: scheduleTypeOnce, RunAt: "2026-02-10T12:00:00+01:00"}
+		runs, err := timing.upcoming(from, schedulePreviewCount)
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{time.Date(2026, time.February, 10, 11, 0, 0, 0, time.UTC)}, runs)
+
+		_, err = timing.next(runs[0])
+		require.ErrorIs(t, err, errNoUpcomingRun)
+	})
+}
+
+func TestAutomationTimingDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	t.Run("gap fires once, shifted forward", func(t *testing.T) {
+		// 2026-03-08 02:00 EST jumps to 03:00 EDT.
+		timing := automationTiming{Type: scheduleTypeCron, Cron: "30 2 * * *", Timezone: "America/New_York"}
+		runs, err := timing.upcoming(time.Date(2026, time.March, 7, 12, 0, 0, 0, newYork), 3)
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{
+			time.Date(2026, time.March, 8, 7, 30, 0, 0, time.UTC), // 03:30 EDT
+			time.Date(2026, time.March, 9, 6, 30, 0, 0, time.UTC), // 02:30 EDT
+			time.Date(2026, time.March, 10, 6, 30, 0, 0, time.UTC),
+		}, runs)
+	})
+
+	t.Run("overlap fires once for fixed hours", func(t *testing.T) {
+		// 2026-11-01 02:00 EDT falls back to 01:00 EST.
+		timing := automationTiming{Days: []string{"sun", "mon"}, TimeOfDay: "01:30", Timezone: "America/New_York"}
+		runs, err := timing.upcoming(time.Date(2026, time.October, 31, 12, 0, 0, 0, time.UTC), 2)
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{
+			time.Date(2026, time.November, 1, 5, 30, 0, 0, time.UTC), // 01:30 EDT
+			time.Date(2026, time.November, 2, 6, 30, 0, 0, time.UTC), // 01:30 EST
+		}, runs)
+	})
+
+	t.Run("overlap keeps firing for every-hour schedules", func(t *testing.T) {
+		timing := automationTiming{Type: scheduleTypeCron, Cron: "0 * * * *", Timezone: "America/New_York"}
+		runs, err := timing.upcoming(time.Date(2026, time.November, 1, 4, 30, 0, 0, time.UTC), 3)
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{
+			time.Date(2026, time.November, 1, 5, 0, 0, 0, time.UTC), // 01:00 EDT
+			time.Date(2026, time.November, 1, 6, 0, 0, 0, time.UTC), // 01:00 EST
+			time.Date(2026, time.November, 1, 7, 0, 0, 0, time.UTC), // 02:00 EST
+		}, runs)
+	})
+}
+
+func TestAutomationSchedulesAPI(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	post := func(path string, body string) *http.Response {
+		return doWithKey(t, http.MethodPost, server.URL+path, "", []byte(body))
+	}
+
+	resp := post("/automations/schedule/preview", `{"cron":"0 9 * * 1-5","timezone":"Europe/Berlin","blackout_dates":["2099-01-01"]}`)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var preview schedulePreviewResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&preview))
+	require.Equal(t, scheduleTypeCron, preview.ScheduleType)
+	require.Len(t, preview.NextRuns, schedulePreviewCount)
+	for _, run := range preview.NextRuns {
+		at, err := time.Parse(time.RFC3339, run)
+		require.NoError(t, err)
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		require.Equal(t, 9, at.In(berlin).Hour())
+	}
+
+	for _, body := range []string{
+		`{"cron":"not a cron"}`,
+		`{"schedule_type":"interval"}`,
+		`{"run_at":"2000-01-01T00:00:00Z"}`,
+		`{"schedule_type":"hourly"}`,
+		`{"blackout_dates":["01/02/2026"]}`,
+	} {
+		resp := post("/automations/schedule/preview", body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	resp = post("/automations", `{"name":"Every two hours","prompt":"p","interval_minutes":120}`)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, scheduleTypeInterval, created.ScheduleType)
+	require.Equal(t, 120, created.IntervalMinutes)
+	require.Len(t, created.NextRuns, schedulePreviewCount)
+	first, err := time.Parse(time.RFC3339, created.NextRuns[0])
+	require.NoError(t, err)
+	second, err := time.Parse(time.RFC3339, created.NextRuns[1])
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Hour, second.Sub(first))
+
+	// Giving only cron switches the type and clears the interval.
+	update := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"cron":"0 6 * * *"}`))
+	defer update.Body.Close()
+	require.Equal(t, http.StatusOK, update.StatusCode)
+	var updated automationSchedule
+	require.NoError(t, json.NewDecoder(update.Body).Decode(&updated))
+	require.Equal(t, scheduleTypeCron, updated.ScheduleType)
+	require.Equal(t, "0 6 * * *", updated.Cron)
+	require.Zero(t, updated.IntervalMinutes)
+	require.NotEmpty(t, updated.NextRunAt)
+
+	runAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second).Format(time.RFC3339)
+	resp = post("/automations", `{"name":"Once","prompt":"p","run_at":"`+runAt+`"}`)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var once automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&once))
+	require.Equal(t, scheduleTypeOnce, once.ScheduleType)
+	require.Equal(t, []string{runAt}, once.NextRuns)
+
+	resp = post("/automations", `{"name":"Digest","kind":"digest","cron":"0 8 * * *"}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
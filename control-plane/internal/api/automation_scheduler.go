@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	// schedulerMaxSleep bounds how long the scheduler sleeps, so schedules
+	// changed by another replica or directly in the store are still picked up.
+	schedulerMaxSleep = time.Minute
+	schedulerMinSleep = time.Second
+	// schedulerStallAfter is how long without a tick before /ready reports
+	// the scheduler as stalled.
+	schedulerStallAfter = 3 * schedulerMaxSleep
+)
+
+// automationSchedulerState is the scheduler's last known state, reported by
+// /ready.
+type automationSchedulerState struct {
+	mu         sync.Mutex
+	running    bool
+	lastTickAt time.Time
+	nextWakeAt time.Time
+	lastQueued int
+	lastError  string
+}
+
+func (s *Server) wakeAutomationScheduler() {
+	select {
+	case s.schedulerWake <- struct{}{}:
+	default:
+	}
+}
+
+// runAutomationScheduler queues due automations until ctx is cancelled. It
+// sleeps until the earliest NextRunAt across workspaces, wakes early when an
+// automation changes, and recomputes everything from the store on each tick,
+// so nothing is lost across restarts.
+func (s *Server) runAutomationScheduler(ctx context.Context) {
+	s.scheduler.mu.Lock()
+	s.scheduler.running = true
+	s.scheduler.mu.Unlock()
+	defer func() {
+		s.scheduler.mu.Lock()
+		s.scheduler.running = false
+		s.scheduler.mu.Unlock()
+	}()
+
+	for {
+		next := s.tickAutomationScheduler(ctx, time.Now().UTC())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-s.schedulerWake:
+			timer.Stop()
+		}
+	}
+}
+
+// tickAutomationScheduler queues the automations due at now in every
+// workspace, starts the feed polls that are due and returns when to wake
+// next.
+func (s *Server) tickAutomationScheduler(ctx context.Context, now time.Time) time.Time {
+	next := now.Add(schedulerMaxSleep)
+	queued := 0
+	lastError := ""
+	workspaces, err := s.store.ListWorkspaces(ctx)
+	if err != nil {
+		lastError = err.Error()
+		log.Printf("automation scheduler list workspaces failed err=%v", err)
+	}
+	for _, workspace := range workspaces {
+		workspaceCtx := store.WithWorkspace(ctx, workspace.ID)
+		queued += s.queueDueAutomations(workspaceCtx, automationTriggerSchedule)
+		automations, err := s.store.ListAutomations(workspaceCtx)
+		if err != nil {
+			lastError = err.Error()
+			log.Printf("automation scheduler list automations failed workspace_id=%s err=%v", workspace.ID, err)
+			continue
+		}
+		for _, automation := range automations {
+			if !automation.Enabled || automation.InProgress {
+				continue
+			}
+			for _, due := range []string{automation.NextRunAt, automation.RetryAt} {
+				if at, err := time.Parse(time.RFC3339Nano, due); err == nil && at.Before(next) {
+					next = at
+				}
+			}
+			if len(automation.FeedURLs) > 0 {
+				due := feedPollDue(automation)
+				if !due.After(now) {
+					s.startFeedPoll(workspaceCtx, automation.ID)
+				} else if due.Before(next) {
+					next = due
+				}
+			}
+		}
+	}
+	if earliest := now.Add(schedulerMinSleep); next.Before(earliest) {
+		next = earliest
+	}
+
+	s.scheduler.mu.Lock()
+	s.scheduler.lastTickAt = now
+	s.scheduler.nextWakeAt = next
+	s.scheduler.lastQueued = queued
+	s.scheduler.lastError = lastError
+	s.scheduler.mu.Unlock()
+	return next
+}
+
+// automationSchedulerStatus reports the scheduler for /ready. A stalled or
+// failing scheduler is degraded but does not make the control plane unready.
+func (s *Server) automationSchedulerStatus(now time.Time) subsystemStatus {
+	s.scheduler.mu.Lock()
+	defer s.scheduler.mu.Unlock()
+	if !s.scheduler.running {
+		return subsystemStatus{Status: "skipped"}
+	}
+	status := subsystemStatus{Status: "ok", Details: map[string]any{"last_queued": s.scheduler.lastQueued}}
+	if !s.scheduler.lastTickAt.IsZero() {
+		status.Details["last_tick_at"] = s.scheduler.lastTickAt.Format(time.RFC3339Nano)
+		status.Details["next_wake_at"] = s.scheduler.nextWakeAt.Format(time.RFC3339Nano)
+	}
+	switch {
+	case s.scheduler.lastError != "":
+		status.Status = "degraded"
+		status.Error = s.scheduler.lastError
+	case !s.scheduler.lastTickAt.IsZero() && now.Sub(s.scheduler.lastTickAt) > schedulerStallAfter:
+		status.Status = "degraded"
+		status.Error = "scheduler has not run since " + s.scheduler.lastTickAt.Format(time.RFC3339)
+	}
+	return status
+}
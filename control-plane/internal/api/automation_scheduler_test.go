@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestTickAutomationScheduler(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	api := NewServer(mem, &MockBroker{}, nil, config.Config{})
+	now := time.Now().UTC()
+	stamp := func(offset time.Duration) string { return now.Add(offset).Format(time.RFC3339Nano) }
+
+	require.NoError(t, mem.CreateWorkspace(ctx, store.Workspace{ID: "team", Name: "Team", CreatedAt: stamp(0), UpdatedAt: stamp(0)}))
+	teamCtx := store.WithWorkspace(ctx, "team")
+	days := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	// Missed while the control plane was down: caught up once.
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "due", Name: "Due", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true, NextRunAt: stamp(-time.Hour)}))
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "off", Name: "Off", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: false, NextRunAt: stamp(-time.Hour)}))
+	require.NoError(t, mem.CreateAutomation(teamCtx, store.Automation{ID: "soon", Name: "Soon", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true, NextRunAt: stamp(10 * time.Second)}))
+	require.NoError(t, mem.CreateAutomation(teamCtx, store.Automation{ID: "unset", Name: "Unset", Prompt: "p", Days: days, TimeOfDay: "09:00", Timezone: "UTC", Enabled: true}))
+
+	next := api.tickAutomationScheduler(ctx, now)
+	require.WithinDuration(t, now.Add(10*time.Second), next, time.Second)
+
+	entries, err := mem.ListAutomationInbox(ctx, "due")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, automationTriggerCatchUp, entries[0].Trigger)
+	entries, err = mem.ListAutomationInbox(ctx, "off")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	unset, err := mem.GetAutomation(teamCtx, "unset")
+	require.NoError(t, err)
+	require.NotEmpty(t, unset.NextRunAt)
+
+	// Without LLM settings the queued run fails quickly and gets rescheduled.
+	require.Eventually(t, func() bool {
+		due, err := mem.GetAutomation(ctx, "due")
+		return err == nil && !due.InProgress && due.LastRunAt != ""
+	}, 5*time.Second, 10*time.Millisecond)
+	due, err := mem.GetAutomation(ctx, "due")
+	require.NoError(t, err)
+	nextRun, err := time.Parse(time.RFC3339Nano, due.NextRunAt)
+	require.NoError(t, err)
+	require.True(t, nextRun.After(now))
+}
+
+func TestAutomationSchedulerReadiness(t *testing.T) {
+	api := NewServer(memory.New(), &MockBroker{}, nil, config.Config{})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	readiness := func() subsystemStatus {
+		resp, err := http.Get(server.URL + "/ready")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var payload readinessResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		return payload.Subsystems["automation_scheduler"]
+	}
+	require.Equal(t, "skipped", readiness().Status)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		api.runAutomationScheduler(ctx)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		status := readiness()
+		return status.Status == "ok" && status.Details["next_wake_at"] != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	api.scheduler.mu.Lock()
+	api.scheduler.lastTickAt = time.Now().Add(-2 * schedulerStallAfter)
+	api.scheduler.mu.Unlock()
+	status := api.automationSchedulerStatus(time.Now())
+	require.Equal(t, "degraded", status.Status)
+	require.Contains(t, status.Error, "has not run since")
+
+	cancel()
+	<-done
+	require.Equal(t, "skipped", readiness().Status)
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/notify"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	// promptSummaryLimit bounds {{.LastSummary}} so a long report does not
+	// crowd out the rest of the prompt.
+	promptSummaryLimit = 2000
+	// maxPromptContextBytes bounds a context file inserted into a prompt.
+	maxPromptContextBytes = 64 << 10
+)
+
+// automationPromptData is what automation prompt templates see: {{.Trigger}}
+// is what queued the run and {{.Payload}} the event behind an event trigger,
+// such as the JSON body of an inbound webhook ({{.Payload.ticker}}). Now and
+// Date are in the automation's timezone, and LastRunAt and LastSummary come
+// from the last run that produced a response, so a prompt can ask for "news
+// since {{.LastRunAt}}". {{context "reports/q3.md"}} inserts a context file.
+type automationPromptData struct {
+	Trigger     string
+	Payload     map[string]any
+	Now         time.Time
+	Date        string
+	Timezone    string
+	LastRunAt   string
+	LastSummary string
+
+	// contextFile reads a context file by path. It is nil when a prompt is
+	// validated on save, where every file reads as empty.
+	contextFile func(path string) (string, error)
+}
+
+// funcs returns the template functions that depend on the data.
+func (d automationPromptData) funcs() template.FuncMap {
+	return template.FuncMap{
+		"context": func(path string) (string, error) {
+			if d.contextFile == nil {
+				return "", nil
+			}
+			return d.contextFile(path)
+		},
+	}
+}
+
+// promptTemplateFuncs are available to automation and stage prompts.
+var promptTemplateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+// executePromptTemplate renders a prompt with the shared functions and
+// extra. Strict rendering fails on keys missing from the data; validation
+// renders leniently because payloads are only known at run time.
+func executePromptTemplate(prompt string, extra template.FuncMap, data any, strict bool) (string, error) {
+	tmpl := template.New("prompt").Funcs(promptTemplateFuncs).Funcs(extra)
+	if strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	tmpl, err := tmpl.Parse(prompt)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("prompt template failed: %w", err)
+	}
+	return builder.String(), nil
+}
+
+// validateAutomationPrompt reports template errors in a prompt when it is
+// saved.
+func validateAutomationPrompt(prompt string) error {
+	data := automationPromptData{}
+	_, err := executePromptTemplate(prompt, data.funcs(), data, false)
+	return err
+}
+
+// renderAutomationPrompt renders a prompt for a run. A prompt that
+// references a payload field the event did not carry, or a context file that
+// does not exist, fails the run.
+func renderAutomationPrompt(prompt string, data automationPromptData) (string, error) {
+	return executePromptTemplate(prompt, data.funcs(), data, true)
+}
+
+// buildAutomationPromptData collects the template data for a run of schedule at
+// now, queued by trigger with payload. The entry being run, if any, is
+// excluded when looking up the last run.
+func (s *Server) buildAutomationPromptData(ctx context.Context, schedule store.Automation, entryID string, trigger string, payload map[string]any, now time.Time) automationPromptData {
+	timezone := normalizeTimezone(schedule.Timezone)
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	data := automationPromptData{
+		Trigger:  trigger,
+		Payload:  payload,
+		Now:      local,
+		Date:     local.Format("2006-01-02"),
+		Timezone: timezone,
+		contextFile: func(path string) (string, error) {
+			return s.readPromptContextFile(ctx, path)
+		},
+	}
+	entries, _ := s.store.ListAutomationInbox(ctx, schedule.ID)
+	if last := lastRespondedInboxEntry(entries, entryID); last != nil {
+		if ranAt := parseTime(fallbackString(last.StartedAt, last.CreatedAt)); !ranAt.IsZero() {
+			data.LastRunAt = ranAt.In(loc).Format(time.RFC3339)
+		}
+		data.LastSummary = notify.Truncate(last.FinalResponse, promptSummaryLimit)
+	}
+	return data
+}
+
+// lastRespondedInboxEntry returns the most recently completed inbox entry,
+// other than entryID, that produced a final response.
+func lastRespondedInboxEntry(entries []store.AutomationInboxEntry, entryID string) *store.AutomationInboxEntry {
+	responded := make([]store.AutomationInboxEntry, 0, len(entries))
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.FinalResponse) != "" {
+			responded = append(responded, entry)
+		}
+	}
+	return previousCompletedInboxEntry(responded, entryID)
+}
+
+// readPromptContextFile returns the text of the context file at path, such
+// as "reports/q3.md". When several uploads share a path the newest wins.
+func (s *Server) readPromptContextFile(ctx context.Context, filePath string) (string, error) {
+	filePath = strings.Trim(strings.TrimPrefix(strings.TrimSpace(filePath), "/context"), "/")
+	nodes, err := s.store.ListContextNodes(ctx)
+	if err != nil {
+		return "", err
+	}
+	var match *store.ContextNode
+	for idx := range nodes {
+		node := nodes[idx]
+		if node.NodeType != "file" || contextNodePath(nodes, node) != filePath {
+			continue
+		}
+		if match == nil || parseTime(node.CreatedAt).After(parseTime(match.CreatedAt)) {
+			match = &node
+		}
+	}
+	if match == nil {
+		return "", fmt.Errorf("context file %q not found", filePath)
+	}
+	file, err := s.store.GetContextFile(ctx, match.ID)
+	if err != nil {
+		return "", err
+	}
+	if file == nil {
+		return "", fmt.Errorf("context file %q not found", filePath)
+	}
+	if len(file.Content) > maxPromptContextBytes {
+		return "", fmt.Errorf("context file %q is larger than %d KB", filePath, maxPromptContextBytes>>10)
+	}
+	if !utf8.Valid(file.Content) {
+		return "", fmt.Errorf("context file %q is not a text file", filePath)
+	}
+	return string(file.Content), nil
+}
+
+type promptPreviewRequest struct {
+	Prompt  *string        `json:"prompt"`
+	Trigger string         `json:"trigger"`
+	Payload map[string]any `json:"payload"`
+}
+
+type promptPreviewResponse struct {
+	Prompt       string `json:"prompt"`
+	Trigger      string `json:"trigger"`
+	ScheduledFor string `json:"scheduled_for"`
+	Date         string `json:"date"`
+	Timezone     string `json:"timezone"`
+	LastRunAt    string `json:"last_run_at,omitempty"`
+	LastSummary  string `json:"last_summary,omitempty"`
+}
+
+// previewAutomationPrompt renders an automation's prompt as its next run
+// would see it. The request may supply an unsaved prompt and the trigger and
+// payload of an event to preview event-driven prompts.
+func (s *Server) previewAutomationPrompt(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	req := promptPreviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	schedule, err := s.store.GetAutomation(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if schedule == nil {
+		http.Error(w, "automation not found", http.StatusNotFound)
+		return
+	}
+	if schedule.Kind == automationKindDigest || schedule.Kind == automationKindPipeline {
+		http.Error(w, "prompt preview is only available for prompt automations", http.StatusBadRequest)
+		return
+	}
+	prompt := schedule.Prompt
+	if req.Prompt != nil {
+		prompt = strings.TrimSpace(*req.Prompt)
+	}
+	now := time.Now().UTC()
+	scheduledFor := now
+	trigger := automationTriggerManual
+	if next := parseTime(schedule.NextRunAt); !next.IsZero() {
+		trigger = automationTriggerSchedule
+		if next.After(now) {
+			scheduledFor = next
+		}
+	}
+	if req.Trigger = strings.TrimSpace(req.Trigger); req.Trigger != "" {
+		trigger = req.Trigger
+	}
+	data := s.buildAutomationPromptData(r.Context(), *schedule, "", trigger, req.Payload, scheduledFor)
+	rendered, err := renderAutomationPrompt(prompt, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSONStatus(w, promptPreviewResponse{
+		Prompt:       rendered,
+		Trigger:      trigger,
+		ScheduledFor: data.Now.Format(time.RFC3339),
+		Date:         data.Date,
+		Timezone:     data.Timezone,
+		LastRunAt:    data.LastRunAt,
+		LastSummary:  data.LastSummary,
+	}, http.StatusOK)
+}
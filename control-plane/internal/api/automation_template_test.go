@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func seedPromptContext(t *testing.T, mem store.Store) {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, mem.CreateContextFolder(ctx, store.ContextNode{ID: "folder-notes", Name: "notes", NodeType: "folder"}))
+	require.NoError(t, mem.CreateContextFile(ctx, store.ContextNode{ID: "brief-old", ParentID: "folder-notes", Name: "brief.md", NodeType: "file", Content: []byte("old brief"), CreatedAt: "2026-10-01T00:00:00Z"}))
+	require.NoError(t, mem.CreateContextFile(ctx, store.ContextNode{ID: "brief-new", ParentID: "folder-notes", Name: "brief.md", NodeType: "file", Content: []byte("watch SOL"), CreatedAt: "2026-10-02T00:00:00Z"}))
+	require.NoError(t, mem.CreateContextFile(ctx, store.ContextNode{ID: "logo", ParentID: "folder-notes", Name: "logo.png", NodeType: "file", Content: []byte{0xff, 0xfe}, CreatedAt: "2026-10-02T00:00:00Z"}))
+}
+
+func TestBuildAutomationPromptData(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	seedPromptContext(t, mem)
+	api := NewServer(mem, &MockBroker{}, nil, config.Config{})
+
+	automation := store.Automation{ID: "auto-1", Name: "News", Timezone: "Asia/Tokyo", CreatedAt: "2026-10-01T00:00:00Z", UpdatedAt: "2026-10-01T00:00:00Z"}
+	require.NoError(t, mem.CreateAutomation(ctx, automation))
+	for _, entry := range []store.AutomationInboxEntry{
+		{ID: "older", Status: "completed", FinalResponse: "Older news", StartedAt: "2026-10-16T23:00:00Z", CompletedAt: "2026-10-16T23:05:00Z"},
+		{ID: "latest", Status: "completed", FinalResponse: "BTC rallied", StartedAt: "2026-10-17T23:00:00Z", CompletedAt: "2026-10-17T23:05:00Z"},
+		{ID: "failed", Status: "failed", Error: "timeout", StartedAt: "2026-10-18T01:00:00Z", CompletedAt: "2026-10-18T01:05:00Z"},
+		{ID: "current", Status: "running", StartedAt: "2026-10-18T23:00:00Z"},
+	} {
+		entry.AutomationID = automation.ID
+		entry.RunID = entry.ID + "-run"
+		entry.Trigger = automationTriggerSchedule
+		entry.CreatedAt, entry.UpdatedAt = entry.StartedAt, entry.StartedAt
+		require.NoError(t, mem.CreateAutomationInboxEntry(ctx, entry))
+	}
+
+	now := time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)
+	data := api.buildAutomationPromptData(ctx, automation, "current", automationTriggerSchedule, nil, now)
+	require.Equal(t, "2026-10-19", data.Date)
+	require.Equal(t, "Asia/Tokyo", data.Timezone)
+	require.Equal(t, "2026-10-18T08:00:00+09:00", data.LastRunAt)
+	require.Equal(t, "BTC rallied", data.LastSummary)
+
+	rendered, err := renderAutomationPrompt(`{{.Now.Weekday}} {{.Date}}: news since {{.LastRunAt}} beyond "{{.LastSummary}}". Focus: {{context "/context/notes/brief.md"}}`, data)
+	require.NoError(t, err)
+	require.Equal(t, `Monday 2026-10-19: news since 2026-10-18T08:00:00+09:00 beyond "BTC rallied". Focus: watch SOL`, rendered)
+
+	_, err = renderAutomationPrompt(`{{context "notes/missing.md"}}`, data)
+	require.ErrorContains(t, err, `context file "notes/missing.md" not found`)
+	_, err = renderAutomationPrompt(`{{context "notes/logo.png"}}`, data)
+	require.ErrorContains(t, err, "is not a text file")
+
+	// Without a previous response there is nothing to compare against.
+	data = api.buildAutomationPromptData(ctx, store.Automation{ID: "other", Timezone: "UTC"}, "", automationTriggerManual, nil, now)
+	require.Empty(t, data.LastRunAt)
+	require.Empty(t, data.LastSummary)
+	require.NoError(t, validateAutomationPrompt(`{{context "notes/missing.md"}} {{.LastSummary}}`))
+}
+
+func TestPreviewAutomationPrompt(t *testing.T) {
+	mem := memory.New()
+	seedPromptContext(t, mem)
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Brief","prompt":"{{.Date}} {{context \"notes/brief.md\"}}","cron":"0 9 * * *","timezone":"Europe/Berlin"}`))
+	defer resp.Body.Close()
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	preview := doWithKey(t, http.MethodPost, server.URL+"/automations/"+created.ID+"/prompt/preview", "", nil)
+	defer preview.Body.Close()
+	require.Equal(t, http.StatusOK, preview.StatusCode)
+	var rendered promptPreviewResponse
+	require.NoError(t, json.NewDecoder(preview.Body).Decode(&rendered))
+	next, err := time.Parse(time.RFC3339, rendered.ScheduledFor)
+	require.NoError(t, err)
+	require.Equal(t, 9, next.Hour())
+	require.Equal(t, next.Format("2006-01-02")+" watch SOL", rendered.Prompt)
+	require.Equal(t, automationTriggerSchedule, rendered.Trigger)
+	require.Equal(t, "Europe/Berlin", rendered.Timezone)
+
+	preview = doWithKey(t, http.MethodPost, server.URL+"/automations/"+created.ID+"/prompt/preview", "", []byte(`{"prompt":"Price of {{.Payload.ticker}} via {{.Trigger}}","trigger":"webhook","payload":{"ticker":"SOL"}}`))
+	defer preview.Body.Close()
+	require.NoError(t, json.NewDecoder(preview.Body).Decode(&rendered))
+	require.Equal(t, "Price of SOL via webhook", rendered.Prompt)
+
+	for _, body := range []string{`{"prompt":"{{.Payload.ticker}}"}`, `{"prompt":"{{context \"notes/missing.md\"}}"}`, `{"prompt":"{{"}`} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations/"+created.ID+"/prompt/preview", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+	missing := doWithKey(t, http.MethodPost, server.URL+"/automations/missing/prompt/preview", "", nil)
+	missing.Body.Close()
+	require.Equal(t, http.StatusNotFound, missing.StatusCode)
+}
+
+func TestAutomationPromptRenderFailureFailsEntry(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", Model: "gpt-4.1"}))
+	workflows := &replyingWorkflows{store: mem, reply: func(string) string { return "done" }}
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	api := NewServer(mem, brokerMock, workflows, config.Config{})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Brief","prompt":"Follow {{context \"notes/brief.md\"}}","run_at":"2099-01-01T00:00:00Z"}`))
+	defer resp.Body.Close()
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	queued, reason := api.queueAutomationExecution(ctx, created.ID, automationTriggerManual)
+	require.True(t, queued, reason)
+	entries := waitForInbox(t, mem, created.ID, 1)
+	require.Equal(t, "failed", entries[0].Status)
+	require.Equal(t, `prompt template failed: template: prompt:1:9: executing "prompt" at <context "notes/brief.md">: error calling context: context file "notes/brief.md" not found`, entries[0].Error)
+	require.Empty(t, workflows.Prompts())
+
+	seedPromptContext(t, mem)
+	queued, reason = api.queueAutomationExecution(ctx, created.ID, automationTriggerManual)
+	require.True(t, queued, reason)
+	entries = waitForInbox(t, mem, created.ID, 2)
+	require.Equal(t, "completed", entries[0].Status)
+	require.Equal(t, []string{"Follow watch SOL"}, workflows.Prompts())
+}
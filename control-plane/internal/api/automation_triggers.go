@@ -0,0 +1,685 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/notify"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	triggerSecretPrefix = "trsec_"
+	// maxTriggerBodyBytes bounds inbound webhook bodies, which are stored
+	// with the inbox entry.
+	maxTriggerBodyBytes = 1 << 20
+	// maxTriggerContentBytes bounds the context file content passed to a
+	// context-triggered run.
+	maxTriggerContentBytes = 64 << 10
+
+	contextChangeUploaded = "uploaded"
+	contextChangeDeleted  = "deleted"
+
+	maxFeedURLs            = 10
+	defaultFeedPollMinutes = 15
+	minFeedPollMinutes     = 5
+	maxFeedPollMinutes     = 1440
+	feedFetchTimeout       = 30 * time.Second
+	maxFeedBytes           = 5 << 20
+	// maxFeedPayloadItems bounds how many new items one feed run receives.
+	maxFeedPayloadItems = 20
+	feedSummaryLimit    = 500
+	feedResolveTimeout  = 5 * time.Second
+)
+
+var errFeedAddressBlocked = errors.New("feed address is not public; set AUTOMATION_FEEDS_ALLOW_PRIVATE=true to allow it")
+
+// sharedAddressSpace is the carrier-grade NAT range, 100.64.0.0/10.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// applyAutomationTriggers merges the event trigger fields of a request into
+// an automation. Enabling the inbound webhook or rotating its secret sets
+// TriggerSecret, which is returned once.
+func (s *Server) applyAutomationTriggers(ctx context.Context, updated *automationSchedule, req automationUpsertRequest) (int, error) {
+	if req.TriggerWebhook != nil && !*req.TriggerWebhook {
+		if req.RotateTriggerSecret {
+			return http.StatusBadRequest, errors.New("rotate_trigger_secret requires trigger_webhook")
+		}
+		updated.triggerSecretEnc = ""
+		updated.TriggerWebhook = false
+	}
+	enable := req.TriggerWebhook != nil && *req.TriggerWebhook && updated.triggerSecretEnc == ""
+	if req.RotateTriggerSecret && updated.triggerSecretEnc == "" && !enable {
+		return http.StatusBadRequest, errors.New("the trigger webhook is not enabled")
+	}
+	if enable || req.RotateTriggerSecret {
+		secret, err := generateSecret(triggerSecretPrefix)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		secretEnc, status, err := s.encryptStoredSecret(secret)
+		if err != nil {
+			return status, err
+		}
+		updated.triggerSecretEnc = secretEnc
+		updated.TriggerWebhook = true
+		updated.TriggerSecret = secret
+	}
+	if req.ContextTriggerPaths != nil {
+		paths, err := normalizeContextTriggerPaths(req.ContextTriggerPaths)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		updated.ContextTriggerPaths = paths
+	}
+	if req.RunTriggerTags != nil {
+		updated.RunTriggerTags = normalizeRouteCriteria(req.RunTriggerTags, true)
+	}
+	if req.FeedURLs != nil {
+		feeds := normalizeRouteCriteria(req.FeedURLs, false)
+		if len(feeds) > maxFeedURLs {
+			return http.StatusBadRequest, fmt.Errorf("automations poll at most %d feeds", maxFeedURLs)
+		}
+		for _, feed := range feeds {
+			if err := s.validateFeedURL(ctx, feed); err != nil {
+				return http.StatusBadRequest, fmt.Errorf("feed %s: %w", feed, err)
+			}
+		}
+		updated.FeedURLs = feeds
+	}
+	if req.FeedPollMinutes != nil {
+		minutes := *req.FeedPollMinutes
+		if minutes != 0 && (minutes < minFeedPollMinutes || minutes > maxFeedPollMinutes) {
+			return http.StatusBadRequest, errors.New("feed_poll_minutes must be 0 or between 5 and 1440")
+		}
+		updated.FeedPollMinutes = minutes
+	}
+	return http.StatusOK, nil
+}
+
+// normalizeContextTriggerPaths validates path patterns of /context files.
+// Paths are relative to the context root and patterns use path.Match
+// syntax, such as "reports/*.md".
+func normalizeContextTriggerPaths(values []string) ([]string, error) {
+	paths := make([]string, 0, len(values))
+	for _, value := range normalizeRouteCriteria(values, false) {
+		value = strings.Trim(strings.TrimPrefix(value, "/context"), "/")
+		if value == "" {
+			return nil, errors.New("context trigger paths must name a file or pattern")
+		}
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("invalid context trigger path %q", value)
+		}
+		paths = append(paths, value)
+	}
+	return paths, nil
+}
+
+// queueTriggeredAutomation queues a first attempt for an event. Events that
+// arrive while the automation is disabled or already running are dropped.
+func (s *Server) queueTriggeredAutomation(ctx context.Context, automationID string, trigger string, payload map[string]any) bool {
+	queued, reason := s.queueAutomationJob(ctx, automationID, automationJobOptions{Trigger: trigger, Attempt: 1, Payload: payload})
+	if !queued {
+		log.Printf("automation trigger skipped automation_id=%s trigger=%s reason=%s", automationID, trigger, reason)
+	}
+	return queued
+}
+
+// findAutomationForTrigger looks an automation up in every workspace,
+// because inbound webhooks carry no API key. The returned context is scoped
+// to the automation's workspace.
+func (s *Server) findAutomationForTrigger(ctx context.Context, id string) (context.Context, *store.Automation, error) {
+	workspaces, err := s.store.ListWorkspaces(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	for _, workspace := range workspaces {
+		workspaceCtx := store.WithWorkspace(ctx, workspace.ID)
+		automation, err := s.store.GetAutomation(workspaceCtx, id)
+		if err != nil {
+			return ctx, nil, err
+		}
+		if automation != nil {
+			return workspaceCtx, automation, nil
+		}
+	}
+	return ctx, nil, nil
+}
+
+// triggerAutomation queues a run from an inbound webhook signed with the
+// automation's trigger secret. The JSON object body becomes the payload
+// prompts reference as {{.Payload}}.
+func (s *Server) triggerAutomation(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxTriggerBodyBytes+1))
+	if err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxTriggerBodyBytes {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	ctx, automation, err := s.findAutomationForTrigger(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if automation == nil || automation.TriggerSecretEnc == "" {
+		http.Error(w, "automation not found", http.StatusNotFound)
+		return
+	}
+	secret, err := s.decryptStoredSecret(automation.TriggerSecretEnc)
+	if err != nil {
+		http.Error(w, "trigger secret unavailable", http.StatusInternalServerError)
+		return
+	}
+	if err := signing.VerifySecret(r, body, secret, s.signingNonces, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	payload := map[string]any{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, "body must be a JSON object", http.StatusBadRequest)
+			return
+		}
+	}
+	queued, reason := s.queueAutomationJob(ctx, automation.ID, automationJobOptions{Trigger: automationTriggerWebhook, Attempt: 1, Payload: payload})
+	if !queued {
+		writeJSONStatus(w, automationQueueResponse{Queued: false, Error: reason}, http.StatusConflict)
+		return
+	}
+	writeJSONStatus(w, automationQueueResponse{Queued: true}, http.StatusAccepted)
+}
+
+// contextNodePath returns the path of a node below the context root.
+func contextNodePath(nodes []store.ContextNode, node store.ContextNode) string {
+	byID := make(map[string]store.ContextNode, len(nodes))
+	for _, item := range nodes {
+		byID[item.ID] = item
+	}
+	parts := []string{node.Name}
+	seen := map[string]bool{node.ID: true}
+	for parentID := node.ParentID; parentID != "" && !seen[parentID]; {
+		parent, ok := byID[parentID]
+		if !ok {
+			break
+		}
+		seen[parentID] = true
+		parts = append([]string{parent.Name}, parts...)
+		parentID = parent.ParentID
+	}
+	return strings.Join(parts, "/")
+}
+
+func matchesContextTriggerPath(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, filePath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatchContextTriggers queues the automations watching a context file
+// that was uploaded or deleted. Text content is passed along so prompts
+// can use it.
+func (s *Server) dispatchContextTriggers(ctx context.Context, node store.ContextNode, change string) {
+	if node.NodeType != "file" {
+		return
+	}
+	automations, err := s.store.ListAutomations(ctx)
+	if err != nil {
+		log.Printf("automation context trigger lookup failed node_id=%s err=%v", node.ID, err)
+		return
+	}
+	var (
+		filePath string
+		payload  map[string]any
+	)
+	for _, automation := range automations {
+		if !automation.Enabled || len(automation.ContextTriggerPaths) == 0 {
+			continue
+		}
+		if payload == nil {
+			nodes, err := s.store.ListContextNodes(ctx)
+			if err != nil {
+				log.Printf("automation context trigger lookup failed node_id=%s err=%v", node.ID, err)
+				return
+			}
+			filePath = contextNodePath(nodes, node)
+			payload = map[string]any{
+				"change":       change,
+				"file_id":      node.ID,
+				"name":         node.Name,
+				"path":         filePath,
+				"content_type": node.ContentType,
+				"size_bytes":   node.SizeBytes,
+			}
+			if change != contextChangeDeleted && len(node.Content) <= maxTriggerContentBytes && utf8.Valid(node.Content) {
+				payload["content"] = string(node.Content)
+			}
+		}
+		if matchesContextTriggerPath(automation.ContextTriggerPaths, filePath) {
+			s.queueTriggeredAutomation(ctx, automation.ID, automationTriggerContext, payload)
+		}
+	}
+}
+
+// dispatchRunTriggers queues the automations watching for completed runs
+// with one of the run's tags. An automation's own runs cannot trigger it,
+// because it is still running when their completion is recorded.
+func (s *Server) dispatchRunTriggers(ctx context.Context, event store.RunEvent) {
+	automations, err := s.store.ListAutomations(ctx)
+	if err != nil {
+		log.Printf("automation run trigger lookup failed run_id=%s err=%v", event.RunID, err)
+		return
+	}
+	var (
+		run     *store.Run
+		payload map[string]any
+	)
+	for _, automation := range automations {
+		if !automation.Enabled || len(automation.RunTriggerTags) == 0 {
+			continue
+		}
+		if run == nil {
+			if run, err = s.store.GetRun(ctx, event.RunID); err != nil || run == nil {
+				return
+			}
+			// Dry runs do not trigger other automations.
+			if slices.Contains(run.Tags, automationDryRunTag) {
+				return
+			}
+			finalResponse := ""
+			if messages, err := s.store.ListMessages(ctx, event.RunID); err == nil {
+				finalResponse, _ = latestAssistantMessage(messages)
+			}
+			status, _, reason := deriveAutomationStatusFromTerminalEvent(&event)
+			payload = map[string]any{
+				"run_id":            run.ID,
+				"tags":              nonNilStrings(run.Tags),
+				"status":            status,
+				"completion_reason": reason,
+				"final_response":    finalResponse,
+			}
+		}
+		if containsAnyLabel(run.Tags, automation.RunTriggerTags) {
+			s.queueTriggeredAutomation(ctx, automation.ID, automationTriggerRun, payload)
+		}
+	}
+}
+
+// feedItem is an RSS or Atom item as passed to feed-triggered runs.
+type feedItem struct {
+	FeedURL   string `json:"feed_url"`
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	Published string `json:"published,omitempty"`
+	Summary   string `json:"summary,omitempty"`
+}
+
+// feedDocument decodes RSS 2.0 (items in channel), RSS 1.0 (items beside
+// the channel) and Atom (entries).
+type feedDocument struct {
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"`
+	Description string `xml:"description"`
+}
+
+type atomEntry struct {
+	Title     string `xml:"title"`
+	ID        string `xml:"id"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+}
+
+// parseFeed returns the items of an RSS or Atom document in feed order.
+// Items are identified by their guid or id, falling back to the link and
+// then the title.
+func parseFeed(feedURL string, body []byte) ([]feedItem, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	// Feeds in other charsets are read as is; item keys stay stable.
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	var doc feedDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+	items := []feedItem{}
+	for _, item := range append(doc.Channel.Items, doc.Items...) {
+		items = append(items, feedItem{
+			FeedURL:   feedURL,
+			ID:        strings.TrimSpace(firstNonEmpty(item.GUID, firstNonEmpty(item.Link, item.Title))),
+			Title:     strings.TrimSpace(item.Title),
+			Link:      strings.TrimSpace(item.Link),
+			Published: strings.TrimSpace(firstNonEmpty(item.PubDate, item.Date)),
+			Summary:   notify.Truncate(item.Description, feedSummaryLimit),
+		})
+	}
+	for _, entry := range doc.Entries {
+		link := ""
+		for _, candidate := range entry.Links {
+			if candidate.Rel == "" || candidate.Rel == "alternate" {
+				link = strings.TrimSpace(candidate.Href)
+				break
+			}
+		}
+		items = append(items, feedItem{
+			FeedURL:   feedURL,
+			ID:        strings.TrimSpace(firstNonEmpty(entry.ID, firstNonEmpty(link, entry.Title))),
+			Title:     strings.TrimSpace(entry.Title),
+			Link:      link,
+			Published: strings.TrimSpace(firstNonEmpty(entry.Published, entry.Updated)),
+			Summary:   notify.Truncate(firstNonEmpty(entry.Summary, entry.Content), feedSummaryLimit),
+		})
+	}
+	kept := items[:0]
+	for _, item := range items {
+		if item.ID != "" {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
+
+// feedKeyPrefix and feedItemKey hash feed URLs and item IDs into the short
+// keys kept in FeedSeenItems.
+func feedKeyPrefix(feedURL string) string {
+	sum := sha256.Sum256([]byte(feedURL))
+	return hex.EncodeToString(sum[:6]) + ":"
+}
+
+func feedItemKey(feedURL string, itemID string) string {
+	sum := sha256.Sum256([]byte(itemID))
+	return feedKeyPrefix(feedURL) + hex.EncodeToString(sum[:8])
+}
+
+func (s *Server) fetchFeed(ctx context.Context, feedURL string) ([]feedItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, feedFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml")
+	resp, err := s.feedClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("feed returned %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedBytes))
+	if err != nil {
+		return nil, err
+	}
+	return parseFeed(feedURL, body)
+}
+
+// publicAddress reports whether the server may connect to ip for a
+// user-supplied URL. Loopback, private, link-local (which includes cloud
+// metadata endpoints), shared, unspecified and multicast addresses are
+// refused.
+func publicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 0 {
+		return false
+	}
+	return !sharedAddressSpace.Contains(ip)
+}
+
+// validateFeedURL checks a feed URL when it is saved. Unless private feeds
+// are allowed, a host that is or resolves to a non-public address is
+// refused.
+func (s *Server) validateFeedURL(ctx context.Context, feedURL string) error {
+	if _, err := normalizeWebhookURL(feedURL); err != nil {
+		return err
+	}
+	if s.cfg.AutomationFeedsAllowPrivate {
+		return nil
+	}
+	return checkPublicURL(ctx, feedURL, errFeedAddressBlocked)
+}
+
+// checkPublicURL returns blocked when rawURL's host is or resolves to a
+// non-public address. A host that does not resolve yet is accepted; clients
+// from newPublicClient check the address again on every connection.
+func checkPublicURL(ctx context.Context, rawURL string, blocked error) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicAddress(ip) {
+			return blocked
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, feedResolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if !publicAddress(addr.IP) {
+			return blocked
+		}
+	}
+	return nil
+}
+
+// newFeedClient returns the client feeds are fetched with.
+func newFeedClient(allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: feedFetchTimeout}
+	}
+	return newPublicClient(feedFetchTimeout, errFeedAddressBlocked)
+}
+
+// newPublicClient returns a client that refuses, with blocked, to connect to
+// non-public addresses after DNS resolution, which also covers redirects and
+// hosts that resolve differently than when they were saved. It ignores proxy
+// settings.
+func newPublicClient(timeout time.Duration, blocked error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicAddress(ip) {
+				return blocked
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// feedPollDue returns when an automation's feeds are next due for polling.
+func feedPollDue(automation store.Automation) time.Time {
+	checkedAt, err := time.Parse(time.RFC3339Nano, automation.FeedCheckedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	minutes := automation.FeedPollMinutes
+	if minutes <= 0 {
+		minutes = defaultFeedPollMinutes
+	}
+	return checkedAt.Add(time.Duration(minutes) * time.Minute)
+}
+
+// startFeedPoll polls an automation's feeds in the background unless a poll
+// of it is already in flight.
+func (s *Server) startFeedPoll(ctx context.Context, automationID string) {
+	s.automationMu.Lock()
+	if s.feedPolls[automationID] {
+		s.automationMu.Unlock()
+		return
+	}
+	s.feedPolls[automationID] = true
+	s.automationMu.Unlock()
+	go func() {
+		defer func() {
+			s.automationMu.Lock()
+			delete(s.feedPolls, automationID)
+			s.automationMu.Unlock()
+		}()
+		if _, err := s.pollAutomationFeeds(context.WithoutCancel(ctx), automationID, time.Now().UTC()); err != nil {
+			log.Printf("automation feed poll failed automation_id=%s err=%v", automationID, err)
+		}
+	}()
+}
+
+// pollAutomationFeeds fetches an automation's feeds and queues one run for
+// the items not seen before, passing them as the payload's "items". The
+// first poll of a feed only records its current items. Items stay unseen
+// when the run cannot be queued, so the next poll offers them again. It
+// reports whether a run was queued.
+func (s *Server) pollAutomationFeeds(ctx context.Context, automationID string, now time.Time) (bool, error) {
+	automation, err := s.store.GetAutomation(ctx, automationID)
+	if err != nil || automation == nil {
+		return false, err
+	}
+	previous := map[string]bool{}
+	for _, key := range automation.FeedSeenItems {
+		previous[key] = true
+	}
+	baselined := func(feedURL string) bool {
+		prefix := feedKeyPrefix(feedURL)
+		for _, key := range automation.FeedSeenItems {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// seen and fallback are the keys to keep depending on whether a run is
+	// queued: the current items of every fetched feed, or only those of
+	// newly baselined feeds.
+	var (
+		seen, fallback []string
+		fresh          []any
+		feedErrors     []string
+	)
+	for _, feedURL := range automation.FeedURLs {
+		prefix := feedKeyPrefix(feedURL)
+		kept := []string{}
+		for _, key := range automation.FeedSeenItems {
+			if strings.HasPrefix(key, prefix) {
+				kept = append(kept, key)
+			}
+		}
+		items, err := s.fetchFeed(ctx, feedURL)
+		if err != nil {
+			feedErrors = append(feedErrors, fmt.Sprintf("%s: %v", feedURL, err))
+			seen = append(seen, kept...)
+			fallback = append(fallback, kept...)
+			continue
+		}
+		current := make([]string, 0, len(items))
+		for _, item := range items {
+			key := feedItemKey(feedURL, item.ID)
+			if baselined(feedURL) && !previous[key] {
+				// New items past the cap stay unseen for the next poll.
+				if len(fresh) >= maxFeedPayloadItems {
+					continue
+				}
+				fresh = append(fresh, map[string]any{
+					"feed_url":  item.FeedURL,
+					"id":        item.ID,
+					"title":     item.Title,
+					"link":      item.Link,
+					"published": item.Published,
+					"summary":   item.Summary,
+				})
+			}
+			current = append(current, key)
+		}
+		seen = append(seen, current...)
+		if baselined(feedURL) {
+			fallback = append(fallback, kept...)
+		} else {
+			fallback = append(fallback, current...)
+		}
+	}
+
+	queued := false
+	if len(fresh) > 0 {
+		queued = s.queueTriggeredAutomation(ctx, automationID, automationTriggerFeed, map[string]any{"items": fresh, "count": len(fresh)})
+	}
+	if !queued {
+		seen = fallback
+	}
+
+	s.automationMu.Lock()
+	current, err := s.store.GetAutomation(ctx, automationID)
+	if err == nil && current != nil {
+		current.FeedSeenItems = seen
+		current.FeedCheckedAt = now.Format(time.RFC3339Nano)
+		err = s.store.UpdateAutomation(ctx, *current)
+	}
+	s.automationMu.Unlock()
+	if err != nil {
+		return queued, err
+	}
+	if len(feedErrors) > 0 {
+		return queued, errors.New(strings.Join(feedErrors, "; "))
+	}
+	return queued, nil
+}
@@ -0,0 +1,363 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func signedTrigger(t *testing.T, url string, secret string, body []byte) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	now := time.Now()
+	nonce := signing.NewNonce()
+	req.Header.Set(signing.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(signing.HeaderNonce, nonce)
+	req.Header.Set(signing.HeaderSignature, signing.Sign(secret, now.Unix(), nonce, req.Method, signing.Target(req.URL), "", body))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func waitForInbox(t *testing.T, mem store.Store, automationID string, count int) []store.AutomationInboxEntry {
+	t.Helper()
+	var entries []store.AutomationInboxEntry
+	require.Eventually(t, func() bool {
+		entries, _ = mem.ListAutomationInbox(context.Background(), automationID)
+		return len(entries) == count && entries[0].CompletedAt != ""
+	}, 15*time.Second, 20*time.Millisecond)
+	return entries
+}
+
+func TestAutomationTriggerConfig(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{
+		"name":"Events","prompt":"Summarize {{.Payload.ticker}}","schedule_type":"manual","trigger_webhook":true,
+		"context_trigger_paths":["/context/reports/*.md"],"run_trigger_tags":["Research"],
+		"feed_urls":["https://example.com/feed.xml"],"feed_poll_minutes":30
+	}`))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Equal(t, scheduleTypeManual, created.ScheduleType)
+	require.Empty(t, created.NextRunAt)
+	require.Empty(t, created.NextRuns)
+	require.True(t, created.TriggerWebhook)
+	require.True(t, strings.HasPrefix(created.TriggerSecret, triggerSecretPrefix))
+	require.Equal(t, []string{"reports/*.md"}, created.ContextTriggerPaths)
+	require.Equal(t, []string{"research"}, created.RunTriggerTags)
+	require.Equal(t, 30, created.FeedPollMinutes)
+
+	// The secret is kept across updates and only returned again on rotation.
+	update := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"name":"Renamed"}`))
+	defer update.Body.Close()
+	var updated automationSchedule
+	require.NoError(t, json.NewDecoder(update.Body).Decode(&updated))
+	require.True(t, updated.TriggerWebhook)
+	require.Empty(t, updated.TriggerSecret)
+
+	rotate := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"rotate_trigger_secret":true}`))
+	defer rotate.Body.Close()
+	var rotated automationSchedule
+	require.NoError(t, json.NewDecoder(rotate.Body).Decode(&rotated))
+	require.NotEmpty(t, rotated.TriggerSecret)
+	require.NotEqual(t, created.TriggerSecret, rotated.TriggerSecret)
+
+	disable := doWithKey(t, http.MethodPut, server.URL+"/automations/"+created.ID, "", []byte(`{"trigger_webhook":false,"feed_urls":[]}`))
+	defer disable.Body.Close()
+	var disabled automationSchedule
+	require.NoError(t, json.NewDecoder(disable.Body).Decode(&disabled))
+	require.False(t, disabled.TriggerWebhook)
+	require.Empty(t, disabled.FeedURLs)
+
+	for _, body := range []string{
+		`{"name":"A","prompt":"p","context_trigger_paths":["reports/["]}`,
+		`{"name":"A","prompt":"p","feed_urls":["ftp://example.com/feed"]}`,
+		`{"name":"A","prompt":"p","feed_poll_minutes":1}`,
+		`{"name":"A","prompt":"p","rotate_trigger_secret":true}`,
+		`{"name":"A","prompt":"Summarize {{.Payload"}`,
+	} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+}
+
+func TestAutomationWebhookTrigger(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", Model: "gpt-4.1"}))
+	workflows := &replyingWorkflows{store: mem, reply: func(string) string { return "done" }}
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	api := NewServer(mem, brokerMock, workflows, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Alerts","prompt":"Summarize {{.Payload.ticker}} ({{.Trigger}})","schedule_type":"manual","trigger_webhook":true}`))
+	defer resp.Body.Close()
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	triggerURL := server.URL + "/automations/" + created.ID + "/trigger"
+
+	unsigned := doWithKey(t, http.MethodPost, triggerURL, "", []byte(`{"ticker":"SOL"}`))
+	unsigned.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, unsigned.StatusCode)
+	forged := signedTrigger(t, triggerURL, "trsec_guess", []byte(`{"ticker":"SOL"}`))
+	forged.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, forged.StatusCode)
+	missing := signedTrigger(t, server.URL+"/automations/missing/trigger", created.TriggerSecret, []byte(`{}`))
+	missing.Body.Close()
+	require.Equal(t, http.StatusNotFound, missing.StatusCode)
+	notObject := signedTrigger(t, triggerURL, created.TriggerSecret, []byte(`["SOL"]`))
+	notObject.Body.Close()
+	require.Equal(t, http.StatusBadRequest, notObject.StatusCode)
+
+	accepted := signedTrigger(t, triggerURL, created.TriggerSecret, []byte(`{"ticker":"SOL"}`))
+	accepted.Body.Close()
+	require.Equal(t, http.StatusAccepted, accepted.StatusCode)
+	entries := waitForInbox(t, mem, created.ID, 1)
+	entry := toInboxRecord(entries[0])
+	require.Equal(t, "completed", entry.Status)
+	require.Equal(t, automationTriggerWebhook, entry.Trigger)
+	require.Equal(t, "SOL", entry.TriggerPayload["ticker"])
+	require.Equal(t, []string{"Summarize SOL (webhook)"}, workflows.Prompts())
+
+	// A payload without the referenced field fails the entry.
+	accepted = signedTrigger(t, triggerURL, created.TriggerSecret, []byte(`{"symbol":"ETH"}`))
+	accepted.Body.Close()
+	require.Equal(t, http.StatusAccepted, accepted.StatusCode)
+	entries = waitForInbox(t, mem, created.ID, 2)
+	require.Equal(t, "failed", entries[0].Status)
+	require.Contains(t, entries[0].Error, "prompt template failed")
+	require.Len(t, workflows.Prompts(), 1)
+}
+
+func TestAutomationContextAndRunTriggers(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", Model: "gpt-4.1"}))
+	workflows := &replyingWorkflows{store: mem, reply: func(string) string { return "done" }}
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	api := NewServer(mem, brokerMock, workflows, config.Config{})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Watcher","prompt":"Review {{.Payload.path}}: {{.Payload.content}}","schedule_type":"manual","context_trigger_paths":["reports/*.md"]}`))
+	defer resp.Body.Close()
+	var watcher automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&watcher))
+
+	folder := store.ContextNode{ID: "folder-1", Name: "reports", NodeType: "folder"}
+	require.NoError(t, mem.CreateContextFolder(ctx, folder))
+	upload := func(name string) {
+		body, _ := json.Marshal(map[string]string{"name": name, "parent_id": folder.ID, "content_base64": base64.StdEncoding.EncodeToString([]byte("Q3 numbers"))})
+		resp := doWithKey(t, http.MethodPost, server.URL+"/context/files", "", body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	upload("notes.txt")
+	upload("q3.md")
+	entries := waitForInbox(t, mem, watcher.ID, 1)
+	require.Equal(t, automationTriggerContext, entries[0].Trigger)
+	require.Equal(t, contextChangeUploaded, entries[0].TriggerPayload["change"])
+	require.Equal(t, []string{"Review reports/q3.md: Q3 numbers"}, workflows.Prompts())
+
+	resp = doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Follow-up","prompt":"Follow up on {{.Payload.final_response}}","schedule_type":"manual","run_trigger_tags":["research"]}`))
+	defer resp.Body.Close()
+	var followUp automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&followUp))
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, run := range []store.Run{{ID: "run-other", Tags: []string{"daily"}}, {ID: "run-research", Tags: []string{"Research"}}} {
+		run.Status, run.CreatedAt, run.UpdatedAt = "running", now, now
+		require.NoError(t, mem.CreateRun(ctx, run))
+		require.NoError(t, mem.AddMessage(ctx, store.Message{ID: run.ID + "-msg", RunID: run.ID, Role: "assistant", Content: "findings from " + run.ID, Sequence: 1, CreatedAt: now}))
+		resp := doWithKey(t, http.MethodPost, server.URL+"/runs/"+run.ID+"/events", "", []byte(`{"type":"run.completed","payload":{"status":"completed"}}`))
+		resp.Body.Close()
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+	entries = waitForInbox(t, mem, followUp.ID, 1)
+	require.Equal(t, automationTriggerRun, entries[0].Trigger)
+	require.Equal(t, "run-research", entries[0].TriggerPayload["run_id"])
+	require.Contains(t, workflows.Prompts(), "Follow up on findings from run-research")
+}
+
+const testRSSFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>News</title>
+%s
+<item><title>First</title><link>https://example.com/1</link><guid>1</guid></item>
+</channel></rss>`
+
+func TestParseFeed(t *testing.T) {
+	items, err := parseFeed("https://example.com/rss", []byte(strings.Replace(testRSSFeed, "%s", `<item><title>Second</title><link>https://example.com/2</link><pubDate>Mon, 19 Oct 2026 08:00:00 GMT</pubDate><description>Summary</description></item>`, 1)))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "https://example.com/2", items[0].ID)
+	require.Equal(t, "Summary", items[0].Summary)
+	require.Equal(t, "1", items[1].ID)
+
+	items, err = parseFeed("https://example.com/atom", []byte(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title>
+<entry><title>Post</title><id>urn:post:1</id><updated>2026-10-18T00:00:00Z</updated>
+<link rel="self" href="https://example.com/self"/><link href="https://example.com/post"/><summary>Hello</summary></entry>
+</feed>`))
+	require.NoError(t, err)
+	require.Equal(t, []feedItem{{FeedURL: "https://example.com/atom", ID: "urn:post:1", Title: "Post", Link: "https://example.com/post", Published: "2026-10-18T00:00:00Z", Summary: "Hello"}}, items)
+
+	_, err = parseFeed("https://example.com/rss", []byte("not xml"))
+	require.Error(t, err)
+}
+
+func TestPollAutomationFeeds(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu    sync.Mutex
+		extra string
+	)
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write([]byte(strings.Replace(testRSSFeed, "%s", extra, 1)))
+	}))
+	defer feed.Close()
+
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", Model: "gpt-4.1"}))
+	workflows := &replyingWorkflows{store: mem, reply: func(string) string { return "done" }}
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	// The test feed is served from loopback.
+	api := NewServer(mem, brokerMock, workflows, config.Config{AutomationFeedsAllowPrivate: true})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Feed","prompt":"New: {{range .Payload.items}}{{.title}} {{end}}","schedule_type":"manual","feed_urls":["`+feed.URL+`"]}`))
+	defer resp.Body.Close()
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	// The first poll only records the items already in the feed.
+	now := time.Now().UTC()
+	queued, err := api.pollAutomationFeeds(ctx, created.ID, now)
+	require.NoError(t, err)
+	require.False(t, queued)
+	automation, err := mem.GetAutomation(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, automation.FeedSeenItems, 1)
+	require.Equal(t, now.Add(defaultFeedPollMinutes*time.Minute), feedPollDue(*automation))
+
+	mu.Lock()
+	extra = `<item><title>Breaking</title><link>https://example.com/2</link></item>`
+	mu.Unlock()
+	queued, err = api.pollAutomationFeeds(ctx, created.ID, time.Now().UTC())
+	require.NoError(t, err)
+	require.True(t, queued)
+	entries := waitForInbox(t, mem, created.ID, 1)
+	require.Equal(t, automationTriggerFeed, entries[0].Trigger)
+	require.Equal(t, []string{"New: Breaking"}, workflows.Prompts())
+
+	// Items already seen do not queue another run.
+	queued, err = api.pollAutomationFeeds(ctx, created.ID, time.Now().UTC())
+	require.NoError(t, err)
+	require.False(t, queued)
+	automation, err = mem.GetAutomation(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, automation.FeedSeenItems, 2)
+
+	// New items past the cap are delivered by the next poll.
+	var burst strings.Builder
+	for idx := range maxFeedPayloadItems + 5 {
+		fmt.Fprintf(&burst, `<item><title>Item %d</title><link>https://example.com/items/%d</link></item>`, idx, idx)
+	}
+	mu.Lock()
+	extra = `<item><title>Breaking</title><link>https://example.com/2</link></item>` + burst.String()
+	mu.Unlock()
+	queued, err = api.pollAutomationFeeds(ctx, created.ID, time.Now().UTC())
+	require.NoError(t, err)
+	require.True(t, queued)
+	entries = waitForInbox(t, mem, created.ID, 2)
+	require.EqualValues(t, maxFeedPayloadItems, entries[0].TriggerPayload["count"])
+	queued, err = api.pollAutomationFeeds(ctx, created.ID, time.Now().UTC())
+	require.NoError(t, err)
+	require.True(t, queued)
+	entries = waitForInbox(t, mem, created.ID, 3)
+	require.EqualValues(t, 5, entries[0].TriggerPayload["count"])
+	require.Contains(t, workflows.Prompts()[2], fmt.Sprintf("Item %d", maxFeedPayloadItems+4))
+	automation, err = mem.GetAutomation(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, automation.FeedSeenItems, maxFeedPayloadItems+7)
+}
+
+func TestFeedURLsMustBePublic(t *testing.T) {
+	for _, tc := range []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::1", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"192.168.0.10", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+	} {
+		require.Equal(t, tc.public, publicAddress(net.ParseIP(tc.ip)), tc.ip)
+	}
+
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+	for _, feed := range []string{"http://127.0.0.1:8081/health", "http://169.254.169.254/latest/meta-data", "http://[::1]/feed", "http://localhost/feed"} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Feed","prompt":"p","schedule_type":"manual","feed_urls":["`+feed+`"]}`))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, feed)
+	}
+
+	// Addresses are checked again when the feed is fetched.
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testRSSFeed))
+	}))
+	defer local.Close()
+	api := NewServer(memory.New(), &MockBroker{}, nil, config.Config{})
+	_, err := api.fetchFeed(context.Background(), local.URL)
+	require.ErrorIs(t, err, errFeedAddressBlocked)
+}
+
+func TestRenderAutomationPrompt(t *testing.T) {
+	rendered, err := renderAutomationPrompt(`{{.Trigger}}: {{json .Payload}}`, automationPromptData{Trigger: automationTriggerWebhook, Payload: map[string]any{"a": 1}})
+	require.NoError(t, err)
+	require.Equal(t, `webhook: {"a":1}`, rendered)
+
+	require.NoError(t, validateAutomationPrompt(`Summarize {{.Payload.ticker}}`))
+	_, err = renderAutomationPrompt(`Summarize {{.Payload.ticker}}`, automationPromptData{Trigger: automationTriggerSchedule})
+	require.ErrorContains(t, err, "prompt template failed")
+	require.ErrorContains(t, validateAutomationPrompt(`{{.Unknown}}`), "prompt template failed")
+}
@@ -1,14 +1,12 @@
 package api
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"net/http/httptest"
 	"sort"
 	"strings"
 	"time"
@@ -30,21 +28,73 @@ var weekdayIndex = map[string]time.Weekday{
 }
 
 type automationSchedule struct {
-	ID         string   `json:"id"`
-	Name       string   `json:"name"`
-	Prompt     string   `json:"prompt"`
-	Model      string   `json:"model"`
-	Days       []string `json:"days"`
-	TimeOfDay  string   `json:"time"`
-	Timezone   string   `json:"timezone"`
-	Enabled    bool     `json:"enabled"`
-	NextRunAt  string   `json:"next_run_at,omitempty"`
-	LastRunAt  string   `json:"last_run_at,omitempty"`
-	InProgress bool     `json:"in_progress"`
-	Unread     int      `json:"unread_count"`
-	LastStatus string   `json:"last_status,omitempty"`
-	CreatedAt  string   `json:"created_at"`
-	UpdatedAt  string   `json:"updated_at"`
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	Prompt               string   `json:"prompt"`
+	Model                string   `json:"model"`
+	LLMProfile           string   `json:"llm_profile,omitempty"`
+	Days                 []string `json:"days"`
+	TimeOfDay            string   `json:"time"`
+	Timezone             string   `json:"timezone"`
+	Enabled              bool     `json:"enabled"`
+	NextRunAt            string   `json:"next_run_at,omitempty"`
+	LastRunAt            string   `json:"last_run_at,omitempty"`
+	InProgress           bool     `json:"in_progress"`
+	NotificationChannels []string `json:"notification_channels"`
+	// NotifyOn is "always", "failure" or "change".
+	NotifyOn        string  `json:"notify_on"`
+	ChangeThreshold float64 `json:"change_threshold"`
+	// Kind is "prompt" or "digest"; see store.Automation.
+	Kind                string   `json:"kind"`
+	DigestAutomationIDs []string `json:"digest_automation_ids,omitempty"`
+	DigestCadence       string   `json:"digest_cadence,omitempty"`
+	// Stages are the prompts of a pipeline, run in order.
+	Stages []automationStage `json:"stages,omitempty"`
+	// ScheduleType is "weekly", "cron", "interval", "once" or "manual"; see
+	// store.Automation.
+	ScheduleType    string   `json:"schedule_type"`
+	Cron            string   `json:"cron,omitempty"`
+	IntervalMinutes int      `json:"interval_minutes,omitempty"`
+	RunAt           string   `json:"run_at,omitempty"`
+	BlackoutDates   []string `json:"blackout_dates"`
+	// NextRuns lists the next fire times in the automation's timezone.
+	NextRuns []string `json:"next_runs,omitempty"`
+	// CatchUp is "skip", "once" or "all"; see store.Automation.
+	CatchUp              string `json:"catch_up"`
+	CatchUpMaxAgeMinutes int    `json:"catch_up_max_age_minutes"`
+	RetryMaxAttempts     int    `json:"retry_max_attempts"`
+	RetryBackoffSeconds  int    `json:"retry_backoff_seconds"`
+	RetryAt              string `json:"retry_at,omitempty"`
+	// The run configuration is passed to every run; see store.Automation.
+	PolicyProfile          string   `json:"policy_profile,omitempty"`
+	Tags                   []string `json:"tags"`
+	TimeoutMS              int      `json:"timeout_ms,omitempty"`
+	BrowserMode            string   `json:"browser_mode,omitempty"`
+	BrowserInteraction     string   `json:"browser_interaction,omitempty"`
+	BrowserDomainAllowlist []string `json:"browser_domain_allowlist"`
+	BrowserPreferred       string   `json:"browser_preferred_browser,omitempty"`
+	BrowserUserAgent       string   `json:"browser_user_agent,omitempty"`
+	Skills                 []string `json:"skills"`
+	// Event triggers; see store.Automation. TriggerSecret is only returned
+	// when it is generated, on enabling the webhook and on rotation.
+	TriggerWebhook      bool     `json:"trigger_webhook"`
+	TriggerSecret       string   `json:"trigger_secret,omitempty"`
+	ContextTriggerPaths []string `json:"context_trigger_paths"`
+	RunTriggerTags      []string `json:"run_trigger_tags"`
+	FeedURLs            []string `json:"feed_urls"`
+	FeedPollMinutes     int      `json:"feed_poll_minutes,omitempty"`
+	// Inbox retention; 0 keeps every entry.
+	InboxRetentionCount int    `json:"inbox_retention_count"`
+	InboxRetentionDays  int    `json:"inbox_retention_days"`
+	Unread              int    `json:"unread_count"`
+	LastStatus          string `json:"last_status,omitempty"`
+	CreatedAt           string `json:"created_at"`
+	UpdatedAt           string `json:"updated_at"`
+
+	// Trigger state that is stored but never returned.
+	triggerSecretEnc string
+	feedSeenItems    []string
+	feedCheckedAt    string
 }
 
 type automationInboxEntry struct {
@@ -62,16 +112,72 @@ type automationInboxEntry struct {
 	StartedAt        string                `json:"started_at"`
 	CompletedAt      string                `json:"completed_at,omitempty"`
 	Diagnostics      automationDiagnostics `json:"diagnostics"`
+	ChangeSummary    string                `json:"change_summary,omitempty"`
+	Similarity       *float64              `json:"similarity,omitempty"`
+	Unchanged        bool                  `json:"unchanged"`
+	ScheduledFor     string                `json:"scheduled_for,omitempty"`
+	Attempt          int                   `json:"attempt"`
+	TriggerPayload   map[string]any        `json:"trigger_payload,omitempty"`
+	ArchivedAt       string                `json:"archived_at,omitempty"`
+	// AutomationName is only set in the cross-automation inbox.
+	AutomationName string `json:"automation_name,omitempty"`
 }
 
 type automationUpsertRequest struct {
-	Name      string   `json:"name"`
-	Prompt    string   `json:"prompt"`
-	Model     string   `json:"model"`
-	Days      []string `json:"days"`
-	TimeOfDay string   `json:"time"`
-	Timezone  string   `json:"timezone"`
-	Enabled   *bool    `json:"enabled"`
+	Name       string   `json:"name"`
+	Prompt     string   `json:"prompt"`
+	Model      string   `json:"model"`
+	LLMProfile *string  `json:"llm_profile"`
+	Days       []string `json:"days"`
+	TimeOfDay  string   `json:"time"`
+	Timezone   string   `json:"timezone"`
+	Enabled    *bool    `json:"enabled"`
+	// NotificationChannels replaces the channel list when present; an empty
+	// list clears it.
+	NotificationChannels []string `json:"notification_channels"`
+	NotifyOn             *string  `json:"notify_on"`
+	ChangeThreshold      *float64 `json:"change_threshold"`
+	Kind                 string   `json:"kind"`
+	// DigestAutomationIDs replaces the digest members when present; an empty
+	// list includes every prompt automation.
+	DigestAutomationIDs []string `json:"digest_automation_ids"`
+	DigestCadence       *string  `json:"digest_cadence"`
+	// Stages replaces a pipeline's stages when present.
+	Stages          []automationStage `json:"stages"`
+	ScheduleType    *string           `json:"schedule_type"`
+	Cron            *string           `json:"cron"`
+	IntervalMinutes *int              `json:"interval_minutes"`
+	RunAt           *string           `json:"run_at"`
+	// BlackoutDates replaces the blackout dates when present; an empty list
+	// clears them.
+	BlackoutDates        []string `json:"blackout_dates"`
+	CatchUp              *string  `json:"catch_up"`
+	CatchUpMaxAgeMinutes *int     `json:"catch_up_max_age_minutes"`
+	RetryMaxAttempts     *int     `json:"retry_max_attempts"`
+	RetryBackoffSeconds  *int     `json:"retry_backoff_seconds"`
+	PolicyProfile        *string  `json:"policy_profile"`
+	// Tags, BrowserDomainAllowlist and Skills replace their list when
+	// present; an empty list clears it.
+	Tags                   []string `json:"tags"`
+	TimeoutMS              *int     `json:"timeout_ms"`
+	BrowserMode            *string  `json:"browser_mode"`
+	BrowserInteraction     *string  `json:"browser_interaction"`
+	BrowserDomainAllowlist []string `json:"browser_domain_allowlist"`
+	BrowserPreferred       *string  `json:"browser_preferred_browser"`
+	BrowserUserAgent       *string  `json:"browser_user_agent"`
+	Skills                 []string `json:"skills"`
+	// TriggerWebhook enables or disables POST /automations/{id}/trigger;
+	// enabling it or RotateTriggerSecret generates a new secret.
+	// ContextTriggerPaths, RunTriggerTags and FeedURLs replace their list
+	// when present; an empty list clears it.
+	TriggerWebhook      *bool    `json:"trigger_webhook"`
+	RotateTriggerSecret bool     `json:"rotate_trigger_secret"`
+	ContextTriggerPaths []string `json:"context_trigger_paths"`
+	RunTriggerTags      []string `json:"run_trigger_tags"`
+	FeedURLs            []string `json:"feed_urls"`
+	FeedPollMinutes     *int     `json:"feed_poll_minutes"`
+	InboxRetentionCount *int     `json:"inbox_retention_count"`
+	InboxRetentionDays  *int     `json:"inbox_retention_days"`
 }
 
 type automationsListResponse struct {
@@ -143,68 +249,117 @@ func normalizeTimeOfDay(value string) (string, error) {
 	return parsed.Format("15:04"), nil
 }
 
-func computeNextRun(days []string, timeOfDay string, timezone string, from time.Time) (time.Time, error) {
-	loc, err := time.LoadLocation(normalizeTimezone(timezone))
-	if err != nil {
-		return time.Time{}, err
-	}
-	parsed, err := time.Parse("15:04", timeOfDay)
-	if err != nil {
-		return time.Time{}, err
-	}
-	hour := parsed.Hour()
-	minute := parsed.Minute()
-	allowed := map[time.Weekday]struct{}{}
-	for _, day := range normalizeDays(days) {
-		allowed[weekdayIndex[day]] = struct{}{}
-	}
-	nowLocal := from.In(loc)
-	for i := 0; i < 14; i++ {
-		candidateDay := nowLocal.AddDate(0, 0, i)
-		candidate := time.Date(candidateDay.Year(), candidateDay.Month(), candidateDay.Day(), hour, minute, 0, 0, loc)
-		if _, ok := allowed[candidate.Weekday()]; !ok {
-			continue
-		}
-		if candidate.After(nowLocal) {
-			return candidate.UTC(), nil
-		}
-	}
-	return time.Time{}, errors.New("failed to compute next run")
-}
-
 func toScheduleRecord(value store.Automation) automationSchedule {
-	return automationSchedule{
-		ID:         value.ID,
-		Name:       value.Name,
-		Prompt:     value.Prompt,
-		Model:      value.Model,
-		Days:       append([]string(nil), value.Days...),
-		TimeOfDay:  value.TimeOfDay,
-		Timezone:   value.Timezone,
-		Enabled:    value.Enabled,
-		NextRunAt:  value.NextRunAt,
-		LastRunAt:  value.LastRunAt,
-		InProgress: value.InProgress,
-		CreatedAt:  value.CreatedAt,
-		UpdatedAt:  value.UpdatedAt,
-	}
+	record := automationSchedule{
+		ID:                     value.ID,
+		Name:                   value.Name,
+		Prompt:                 value.Prompt,
+		Model:                  value.Model,
+		LLMProfile:             value.LLMProfile,
+		Days:                   append([]string(nil), value.Days...),
+		TimeOfDay:              value.TimeOfDay,
+		Timezone:               value.Timezone,
+		Enabled:                value.Enabled,
+		NextRunAt:              value.NextRunAt,
+		LastRunAt:              value.LastRunAt,
+		InProgress:             value.InProgress,
+		NotificationChannels:   nonNilStrings(value.NotificationChannels),
+		NotifyOn:               fallbackString(value.NotifyOn, notifyOnAlways),
+		ChangeThreshold:        value.ChangeThreshold,
+		Kind:                   fallbackString(value.Kind, automationKindPrompt),
+		ScheduleType:           fallbackString(value.ScheduleType, scheduleTypeWeekly),
+		Cron:                   value.CronExpression,
+		IntervalMinutes:        value.IntervalMinutes,
+		RunAt:                  value.RunAt,
+		BlackoutDates:          nonNilStrings(value.BlackoutDates),
+		CatchUp:                fallbackString(value.CatchUp, catchUpOnce),
+		CatchUpMaxAgeMinutes:   value.CatchUpMaxAgeMinutes,
+		RetryMaxAttempts:       value.RetryMaxAttempts,
+		RetryBackoffSeconds:    value.RetryBackoffSeconds,
+		RetryAt:                value.RetryAt,
+		PolicyProfile:          value.PolicyProfile,
+		Tags:                   nonNilStrings(value.Tags),
+		TimeoutMS:              value.TimeoutMS,
+		BrowserMode:            value.BrowserMode,
+		BrowserInteraction:     value.BrowserInteraction,
+		BrowserDomainAllowlist: nonNilStrings(value.BrowserDomainAllowlist),
+		BrowserPreferred:       value.BrowserPreferred,
+		BrowserUserAgent:       value.BrowserUserAgent,
+		Skills:                 nonNilStrings(value.Skills),
+		TriggerWebhook:         value.TriggerSecretEnc != "",
+		ContextTriggerPaths:    nonNilStrings(value.ContextTriggerPaths),
+		RunTriggerTags:         nonNilStrings(value.RunTriggerTags),
+		FeedURLs:               nonNilStrings(value.FeedURLs),
+		FeedPollMinutes:        value.FeedPollMinutes,
+		InboxRetentionCount:    value.InboxRetentionCount,
+		InboxRetentionDays:     value.InboxRetentionDays,
+		CreatedAt:              value.CreatedAt,
+		UpdatedAt:              value.UpdatedAt,
+		triggerSecretEnc:       value.TriggerSecretEnc,
+		feedSeenItems:          append([]string(nil), value.FeedSeenItems...),
+		feedCheckedAt:          value.FeedCheckedAt,
+	}
+	if record.Kind == automationKindDigest {
+		record.DigestAutomationIDs = nonNilStrings(value.DigestAutomationIDs)
+		record.DigestCadence = fallbackString(value.DigestCadence, digestCadenceDaily)
+	}
+	if record.Kind == automationKindPipeline {
+		record.Stages = toStageRecords(value.Stages)
+	}
+	return record
 }
 
 func toStoreAutomation(value automationSchedule) store.Automation {
 	return store.Automation{
-		ID:         value.ID,
-		Name:       value.Name,
-		Prompt:     value.Prompt,
-		Model:      value.Model,
-		Days:       append([]string(nil), value.Days...),
-		TimeOfDay:  value.TimeOfDay,
-		Timezone:   value.Timezone,
-		Enabled:    value.Enabled,
-		NextRunAt:  value.NextRunAt,
-		LastRunAt:  value.LastRunAt,
-		InProgress: value.InProgress,
-		CreatedAt:  value.CreatedAt,
-		UpdatedAt:  value.UpdatedAt,
+		ID:                     value.ID,
+		Name:                   value.Name,
+		Prompt:                 value.Prompt,
+		Model:                  value.Model,
+		LLMProfile:             value.LLMProfile,
+		Days:                   append([]string(nil), value.Days...),
+		TimeOfDay:              value.TimeOfDay,
+		Timezone:               value.Timezone,
+		Enabled:                value.Enabled,
+		NextRunAt:              value.NextRunAt,
+		LastRunAt:              value.LastRunAt,
+		InProgress:             value.InProgress,
+		NotificationChannels:   append([]string(nil), value.NotificationChannels...),
+		NotifyOn:               value.NotifyOn,
+		ChangeThreshold:        value.ChangeThreshold,
+		Kind:                   value.Kind,
+		DigestAutomationIDs:    append([]string(nil), value.DigestAutomationIDs...),
+		DigestCadence:          value.DigestCadence,
+		Stages:                 toStoreStages(value.Stages),
+		ScheduleType:           value.ScheduleType,
+		CronExpression:         value.Cron,
+		IntervalMinutes:        value.IntervalMinutes,
+		RunAt:                  value.RunAt,
+		BlackoutDates:          append([]string(nil), value.BlackoutDates...),
+		CatchUp:                value.CatchUp,
+		CatchUpMaxAgeMinutes:   value.CatchUpMaxAgeMinutes,
+		RetryMaxAttempts:       value.RetryMaxAttempts,
+		RetryBackoffSeconds:    value.RetryBackoffSeconds,
+		RetryAt:                value.RetryAt,
+		PolicyProfile:          value.PolicyProfile,
+		Tags:                   append([]string(nil), value.Tags...),
+		TimeoutMS:              value.TimeoutMS,
+		BrowserMode:            value.BrowserMode,
+		BrowserInteraction:     value.BrowserInteraction,
+		BrowserDomainAllowlist: append([]string(nil), value.BrowserDomainAllowlist...),
+		BrowserPreferred:       value.BrowserPreferred,
+		BrowserUserAgent:       value.BrowserUserAgent,
+		Skills:                 append([]string(nil), value.Skills...),
+		TriggerSecretEnc:       value.triggerSecretEnc,
+		ContextTriggerPaths:    append([]string(nil), value.ContextTriggerPaths...),
+		RunTriggerTags:         append([]string(nil), value.RunTriggerTags...),
+		FeedURLs:               append([]string(nil), value.FeedURLs...),
+		FeedPollMinutes:        value.FeedPollMinutes,
+		FeedSeenItems:          append([]string(nil), value.feedSeenItems...),
+		FeedCheckedAt:          value.feedCheckedAt,
+		InboxRetentionCount:    value.InboxRetentionCount,
+		InboxRetentionDays:     value.InboxRetentionDays,
+		CreatedAt:              value.CreatedAt,
+		UpdatedAt:              value.UpdatedAt,
 	}
 }
 
@@ -224,6 +379,13 @@ func toInboxRecord(value store.AutomationInboxEntry) automationInboxEntry {
 		StartedAt:        value.StartedAt,
 		CompletedAt:      value.CompletedAt,
 		Diagnostics:      decodeAutomationDiagnostics(value.Diagnostics),
+		ChangeSummary:    value.ChangeSummary,
+		Similarity:       value.Similarity,
+		Unchanged:        value.Unchanged,
+		ScheduledFor:     value.ScheduledFor,
+		Attempt:          max(value.Attempt, 1),
+		TriggerPayload:   value.TriggerPayload,
+		ArchivedAt:       value.ArchivedAt,
 	}
 }
 
@@ -243,6 +405,13 @@ func toStoreInboxEntry(value automationInboxEntry) store.AutomationInboxEntry {
 		StartedAt:        value.StartedAt,
 		CompletedAt:      value.CompletedAt,
 		Diagnostics:      encodeAutomationDiagnostics(value.Diagnostics),
+		ChangeSummary:    value.ChangeSummary,
+		Similarity:       value.Similarity,
+		Unchanged:        value.Unchanged,
+		ScheduledFor:     value.ScheduledFor,
+		Attempt:          value.Attempt,
+		TriggerPayload:   value.TriggerPayload,
+		ArchivedAt:       value.ArchivedAt,
 	}
 }
 
@@ -286,14 +455,16 @@ func findInboxEntry(entries []store.AutomationInboxEntry, entryID string) *store
 	return nil
 }
 
+// summarizeInbox returns the unread count and the status of the latest entry
+// that is not a dry run.
 func summarizeInbox(entries []store.AutomationInboxEntry) (int, string) {
 	unread := 0
 	lastStatus := ""
-	for idx, entry := range entries {
+	for _, entry := range withoutDryRuns(entries) {
 		if entry.Unread {
 			unread++
 		}
-		if idx == 0 {
+		if lastStatus == "" {
 			lastStatus = strings.TrimSpace(entry.Status)
 		}
 	}
@@ -321,9 +492,7 @@ func (s *Server) listAutomations(w http.ResponseWriter, r *http.Request) {
 		record.LastStatus = lastStatus
 		totalUnread += unread
 		if record.Enabled {
-			if next, nextErr := computeNextRun(record.Days, record.TimeOfDay, record.Timezone, now); nextErr == nil {
-				record.NextRunAt = next.Format(time.RFC3339)
-			}
+			record.NextRunAt = nextRunTimestamp(timingFromSchedule(record), now, time.RFC3339)
 		} else {
 			record.NextRunAt = ""
 		}
@@ -347,44 +516,175 @@ func (s *Server) createAutomation(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "name is required", http.StatusBadRequest)
 		return
 	}
-	if prompt == "" {
+	kind, err := normalizeAutomationKind(req.Kind)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if prompt == "" && kind == automationKindPrompt {
 		http.Error(w, "prompt is required", http.StatusBadRequest)
 		return
 	}
-	timeOfDay, err := normalizeTimeOfDay(req.TimeOfDay)
-	if err != nil {
+	if err := validateAutomationPrompt(prompt); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var stages []automationStage
+	if kind == automationKindPipeline {
+		if stages, err = normalizeAutomationStages(req.Stages); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if req.Stages != nil {
+		http.Error(w, "only pipeline automations have stages", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC()
+	timing := automationTiming{
+		Type:          optionalString(req.ScheduleType),
+		Days:          req.Days,
+		TimeOfDay:     req.TimeOfDay,
+		Timezone:      req.Timezone,
+		Cron:          optionalString(req.Cron),
+		RunAt:         optionalString(req.RunAt),
+		Anchor:        now.Format(time.RFC3339Nano),
+		BlackoutDates: req.BlackoutDates,
+	}
+	if req.IntervalMinutes != nil {
+		timing.IntervalMinutes = *req.IntervalMinutes
+	}
+	if err := normalizeAutomationTiming(&timing, now); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if kind == automationKindDigest && timing.Type != scheduleTypeWeekly {
+		http.Error(w, "digest automations use a weekly schedule", http.StatusBadRequest)
+		return
+	}
+	llmProfile, status, err := s.resolveAutomationLLMProfile(r.Context(), req.LLMProfile)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	channels, status, err := s.resolveNotificationChannels(r.Context(), req.NotificationChannels)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	notifyOn := notifyOnAlways
+	if req.NotifyOn != nil {
+		if notifyOn, err = normalizeNotifyOn(*req.NotifyOn); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	// Digests are summaries of other results, so they are not diffed unless
+	// asked to be.
+	changeThreshold := defaultChangeThreshold
+	if kind == automationKindDigest {
+		changeThreshold = 0
+	}
+	if req.ChangeThreshold != nil {
+		if changeThreshold, err = normalizeChangeThreshold(*req.ChangeThreshold); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	days := timing.Days
+	var digestMembers []string
+	digestCadence := ""
+	if kind == automationKindDigest {
+		if req.DigestCadence != nil {
+			digestCadence = *req.DigestCadence
+		}
+		if digestCadence, err = normalizeDigestCadence(digestCadence); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		days = digestDays(digestCadence, req.Days)
+		if digestMembers, status, err = s.resolveDigestAutomations(r.Context(), req.DigestAutomationIDs); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
 	enabled := true
 	if req.Enabled != nil {
 		enabled = *req.Enabled
 	}
-	now := time.Now().UTC()
+	policies := automationSchedule{CatchUp: catchUpOnce}
+	if err := applyAutomationPolicies(&policies, req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	schedule := automationSchedule{
-		ID:        uuid.NewString(),
-		Name:      name,
-		Prompt:    prompt,
-		Model:     strings.TrimSpace(req.Model),
-		Days:      normalizeDays(req.Days),
-		TimeOfDay: timeOfDay,
-		Timezone:  normalizeTimezone(req.Timezone),
-		Enabled:   enabled,
-		CreatedAt: now.Format(time.RFC3339Nano),
-		UpdatedAt: now.Format(time.RFC3339Nano),
+		ID:                   uuid.NewString(),
+		Name:                 name,
+		Prompt:               prompt,
+		Model:                strings.TrimSpace(req.Model),
+		LLMProfile:           llmProfile,
+		Days:                 days,
+		TimeOfDay:            timing.TimeOfDay,
+		Timezone:             timing.Timezone,
+		ScheduleType:         timing.Type,
+		Cron:                 timing.Cron,
+		IntervalMinutes:      timing.IntervalMinutes,
+		RunAt:                timing.RunAt,
+		BlackoutDates:        timing.BlackoutDates,
+		CatchUp:              policies.CatchUp,
+		CatchUpMaxAgeMinutes: policies.CatchUpMaxAgeMinutes,
+		RetryMaxAttempts:     policies.RetryMaxAttempts,
+		RetryBackoffSeconds:  policies.RetryBackoffSeconds,
+		InboxRetentionCount:  policies.InboxRetentionCount,
+		InboxRetentionDays:   policies.InboxRetentionDays,
+		Enabled:              enabled,
+		NotificationChannels: channels,
+		NotifyOn:             notifyOn,
+		ChangeThreshold:      changeThreshold,
+		Kind:                 kind,
+		DigestAutomationIDs:  digestMembers,
+		DigestCadence:        digestCadence,
+		Stages:               stages,
+		CreatedAt:            now.Format(time.RFC3339Nano),
+		UpdatedAt:            now.Format(time.RFC3339Nano),
+	}
+	if status, err := s.applyAutomationRunConfig(r.Context(), &schedule, req); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if status, err := s.applyAutomationTriggers(r.Context(), &schedule, req); err != nil {
+		http.Error(w, err.Error(), status)
+		return
 	}
 	if enabled {
-		if next, nextErr := computeNextRun(schedule.Days, schedule.TimeOfDay, schedule.Timezone, now); nextErr == nil {
-			schedule.NextRunAt = next.Format(time.RFC3339Nano)
-		}
+		schedule.NextRunAt = nextRunTimestamp(timingFromSchedule(schedule), now, time.RFC3339Nano)
 	}
 	if err := s.store.CreateAutomation(r.Context(), toStoreAutomation(schedule)); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, schedule.ID, nil, schedule)
+	s.wakeAutomationScheduler()
+	schedule.NextRuns = formatUpcomingRuns(timingFromSchedule(schedule), now)
 	writeJSONStatus(w, schedule, http.StatusCreated)
 }
 
+// resolveAutomationLLMProfile validates an optional provider profile name; an
+// empty name clears the reference.
+func (s *Server) resolveAutomationLLMProfile(ctx context.Context, raw *string) (string, int, error) {
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return "", http.StatusOK, nil
+	}
+	name := strings.TrimSpace(*raw)
+	profile, err := s.findLLMProviderProfile(ctx, name)
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	if profile == nil {
+		return "", http.StatusBadRequest, fmt.Errorf("unknown llm profile: %s", name)
+	}
+	return name, http.StatusOK, nil
+}
+
 func (s *Server) updateAutomation(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimSpace(chi.URLParam(r, "id"))
 	if id == "" {
@@ -406,48 +706,128 @@ func (s *Server) updateAutomation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	updated := toScheduleRecord(*current)
+	if strings.TrimSpace(req.Kind) != "" {
+		if kind, err := normalizeAutomationKind(req.Kind); err != nil || kind != updated.Kind {
+			http.Error(w, "kind cannot be changed", http.StatusBadRequest)
+			return
+		}
+	}
 	if value := strings.TrimSpace(req.Name); value != "" {
 		updated.Name = value
 	}
 	if value := strings.TrimSpace(req.Prompt); value != "" {
+		if err := validateAutomationPrompt(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		updated.Prompt = value
 	}
 	if value := strings.TrimSpace(req.Model); value != "" {
 		updated.Model = value
 	}
-	if len(req.Days) > 0 {
-		updated.Days = normalizeDays(req.Days)
-	}
-	if strings.TrimSpace(req.TimeOfDay) != "" {
-		timeOfDay, err := normalizeTimeOfDay(req.TimeOfDay)
+	if req.LLMProfile != nil {
+		llmProfile, status, err := s.resolveAutomationLLMProfile(r.Context(), req.LLMProfile)
 		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		updated.LLMProfile = llmProfile
+	}
+	if req.Stages != nil {
+		if updated.Kind != automationKindPipeline {
+			http.Error(w, "only pipeline automations have stages", http.StatusBadRequest)
+			return
+		}
+		if updated.Stages, err = normalizeAutomationStages(req.Stages); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		updated.TimeOfDay = timeOfDay
 	}
-	if strings.TrimSpace(req.Timezone) != "" {
-		updated.Timezone = normalizeTimezone(req.Timezone)
+	if req.schedulesChanged() {
+		if status, err := applyAutomationTiming(&updated, req, time.Now().UTC()); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+	if err := applyAutomationPolicies(&updated, req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status, err := s.applyAutomationRunConfig(r.Context(), &updated, req); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if status, err := s.applyAutomationTriggers(r.Context(), &updated, req); err != nil {
+		http.Error(w, err.Error(), status)
+		return
 	}
 	if req.Enabled != nil {
 		updated.Enabled = *req.Enabled
 	}
-	updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
-	if updated.Enabled {
-		if next, err := computeNextRun(updated.Days, updated.TimeOfDay, updated.Timezone, time.Now().UTC()); err == nil {
-			updated.NextRunAt = next.Format(time.RFC3339Nano)
+	if req.NotificationChannels != nil {
+		channels, status, err := s.resolveNotificationChannels(r.Context(), req.NotificationChannels)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
 		}
+		updated.NotificationChannels = channels
+	}
+	if req.NotifyOn != nil {
+		if updated.NotifyOn, err = normalizeNotifyOn(*req.NotifyOn); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.ChangeThreshold != nil {
+		if updated.ChangeThreshold, err = normalizeChangeThreshold(*req.ChangeThreshold); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if updated.Kind == automationKindDigest {
+		if req.DigestCadence != nil {
+			if updated.DigestCadence, err = normalizeDigestCadence(*req.DigestCadence); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if req.DigestCadence != nil || len(req.Days) > 0 {
+			days := req.Days
+			if len(days) == 0 {
+				days = updated.Days
+			}
+			updated.Days = digestDays(updated.DigestCadence, days)
+		}
+		if req.DigestAutomationIDs != nil {
+			members, status, err := s.resolveDigestAutomations(r.Context(), req.DigestAutomationIDs)
+			if err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+			updated.DigestAutomationIDs = members
+		}
+	}
+	now := time.Now().UTC()
+	updated.UpdatedAt = now.Format(time.RFC3339Nano)
+	if updated.Enabled {
+		updated.NextRunAt = nextRunTimestamp(timingFromSchedule(updated), now, time.RFC3339Nano)
 	} else {
 		updated.NextRunAt = ""
 	}
-	if err := s.store.UpdateAutomation(r.Context(), toStoreAutomation(updated)); err != nil {
+	stored := toStoreAutomation(updated)
+	stored.RetryAttempt, stored.RetryScheduledFor = current.RetryAttempt, current.RetryScheduledFor
+	if err := s.store.UpdateAutomation(r.Context(), stored); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, id, toScheduleRecord(*current), updated)
+	s.wakeAutomationScheduler()
+	s.applyInboxRetention(r.Context(), stored, now)
 	entries, err := s.store.ListAutomationInbox(r.Context(), id)
 	if err == nil {
 		updated.Unread, updated.LastStatus = summarizeInbox(entries)
 	}
+	updated.NextRuns = formatUpcomingRuns(timingFromSchedule(updated), now)
 	writeJSONStatus(w, updated, http.StatusOK)
 }
 
@@ -470,6 +850,7 @@ func (s *Server) deleteAutomation(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, id, toScheduleRecord(*current), nil)
 	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
 }
 
@@ -496,12 +877,15 @@ func (s *Server) getAutomationInbox(w http.ResponseWriter, r *http.Request) {
 	record := toScheduleRecord(*automation)
 	record.Unread, record.LastStatus = summarizeInbox(entries)
 	if record.Enabled {
-		if next, nextErr := computeNextRun(record.Days, record.TimeOfDay, record.Timezone, time.Now().UTC()); nextErr == nil {
-			record.NextRunAt = next.Format(time.RFC3339)
-		}
+		now := time.Now().UTC()
+		record.NextRunAt = nextRunTimestamp(timingFromSchedule(record), now, time.RFC3339)
+		record.NextRuns = formatUpcomingRuns(timingFromSchedule(record), now)
 	}
 	mapped := make([]automationInboxEntry, 0, len(entries))
 	for _, entry := range entries {
+		if entry.ArchivedAt != "" {
+			continue
+		}
 		mapped = append(mapped, toInboxRecord(entry))
 	}
 	writeJSONStatus(w, automationDetailResponse{Automation: record, Inbox: mapped, UnreadCount: record.Unread}, http.StatusOK)
@@ -535,7 +919,7 @@ func (s *Server) markAutomationInboxReadAll(w http.ResponseWriter, r *http.Reque
 }
 
 func (s *Server) processDueAutomations(w http.ResponseWriter, r *http.Request) {
-	queued := s.queueDueAutomations("schedule")
+	queued := s.queueDueAutomations(r.Context(), automationTriggerSchedule)
 	writeJSONStatus(w, automationProcessResponse{Queued: queued}, http.StatusOK)
 }
 
@@ -545,7 +929,7 @@ func (s *Server) runAutomationNow(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "automation id is required", http.StatusBadRequest)
 		return
 	}
-	queued, reason := s.queueAutomationExecution(id, "manual")
+	queued, reason := s.queueAutomationExecution(r.Context(), id, automationTriggerManual)
 	if !queued {
 		writeJSONStatus(w, automationQueueResponse{Queued: false, Error: reason}, http.StatusConflict)
 		return
@@ -553,31 +937,52 @@ func (s *Server) runAutomationNow(w http.ResponseWriter, r *http.Request) {
 	writeJSONStatus(w, automationQueueResponse{Queued: true}, http.StatusAccepted)
 }
 
-func (s *Server) queueDueAutomations(trigger string) int {
+// queueDueAutomations queues every enabled automation whose pending retry
+// or stored NextRunAt has passed, applying the catch-up policy to runs
+// missed while nothing was polling, and fills in NextRunAt where it is
+// missing.
+func (s *Server) queueDueAutomations(ctx context.Context, trigger string) int {
 	now := time.Now().UTC()
-	items, err := s.store.ListAutomations(context.Background())
+	s.automationMu.Lock()
+	items, err := s.store.ListAutomations(ctx)
 	if err != nil {
+		s.automationMu.Unlock()
 		return 0
 	}
-	candidates := make([]string, 0)
+	candidates := make([]automationDueRun, 0)
 	for _, schedule := range items {
 		if !schedule.Enabled || schedule.InProgress {
 			continue
 		}
-		next, nextErr := computeNextRun(schedule.Days, schedule.TimeOfDay, schedule.Timezone, now.Add(-1*time.Second))
+		if options, ok := dueRetry(schedule, now); ok {
+			candidates = append(candidates, automationDueRun{AutomationID: schedule.ID, Options: options})
+			continue
+		}
+		if at, err := time.Parse(time.RFC3339Nano, schedule.NextRunAt); err == nil {
+			if at.After(now) {
+				continue
+			}
+			if options, ok := planDueRun(schedule, at, now, trigger); ok {
+				candidates = append(candidates, automationDueRun{AutomationID: schedule.ID, Options: options})
+				continue
+			}
+			log.Printf("automation missed runs dropped automation_id=%s catch_up=%s next_run_at=%s", schedule.ID, fallbackString(schedule.CatchUp, catchUpOnce), schedule.NextRunAt)
+		}
+		next, nextErr := nextAutomationRun(schedule, now)
 		if nextErr != nil {
+			if schedule.NextRunAt != "" {
+				schedule.NextRunAt = ""
+				_ = s.store.UpdateAutomation(ctx, schedule)
+			}
 			continue
 		}
 		schedule.NextRunAt = next.Format(time.RFC3339Nano)
-		schedule.UpdatedAt = now.Format(time.RFC3339Nano)
-		_ = s.store.UpdateAutomation(context.Background(), schedule)
-		if !next.After(now) {
-			candidates = append(candidates, schedule.ID)
-		}
+		_ = s.store.UpdateAutomation(ctx, schedule)
 	}
+	s.automationMu.Unlock()
 	queued := 0
-	for _, id := range candidates {
-		ok, _ := s.queueAutomationExecution(id, trigger)
+	for _, candidate := range candidates {
+		ok, _ := s.queueAutomationJob(ctx, candidate.AutomationID, candidate.Options)
 		if ok {
 			queued++
 		}
@@ -585,11 +990,19 @@ func (s *Server) queueDueAutomations(trigger string) int {
 	return queued
 }
 
-func (s *Server) queueAutomationExecution(id string, trigger string) (bool, string) {
+// queueAutomationExecution queues a first attempt that runs for no
+// particular fire time.
+func (s *Server) queueAutomationExecution(ctx context.Context, id string, trigger string) (bool, string) {
+	return s.queueAutomationJob(ctx, id, automationJobOptions{Trigger: trigger, Attempt: 1})
+}
+
+// queueAutomationJob runs the job detached from ctx's cancellation but keeps
+// its values, so the job stays in the caller's workspace.
+func (s *Server) queueAutomationJob(ctx context.Context, id string, options automationJobOptions) (bool, string) {
 	s.automationMu.Lock()
 	defer s.automationMu.Unlock()
 
-	schedule, err := s.store.GetAutomation(context.Background(), id)
+	schedule, err := s.store.GetAutomation(ctx, id)
 	if err != nil {
 		return false, "failed to load automation"
 	}
@@ -605,76 +1018,94 @@ func (s *Server) queueAutomationExecution(id string, trigger string) (bool, stri
 
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	entry := store.AutomationInboxEntry{
-		ID:           uuid.NewString(),
-		AutomationID: id,
-		Status:       "queued",
-		Trigger:      trigger,
-		Unread:       false,
-		StartedAt:    now,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+		ID:             uuid.NewString(),
+		AutomationID:   id,
+		Status:         "queued",
+		Trigger:        options.Trigger,
+		ScheduledFor:   options.ScheduledFor,
+		Attempt:        max(options.Attempt, 1),
+		TriggerPayload: options.Payload,
+		Unread:         false,
+		StartedAt:      now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 		Diagnostics: map[string]any{
 			"sources": []any{},
 		},
 	}
 	schedule.InProgress = true
 	schedule.UpdatedAt = now
-	if err := s.store.UpdateAutomation(context.Background(), *schedule); err != nil {
+	if options.Trigger == automationTriggerRetry {
+		schedule.RetryAt = ""
+	}
+	if err := s.store.UpdateAutomation(ctx, *schedule); err != nil {
 		return false, "failed to update automation state"
 	}
-	if err := s.store.CreateAutomationInboxEntry(context.Background(), entry); err != nil {
+	if err := s.store.CreateAutomationInboxEntry(ctx, entry); err != nil {
 		schedule.InProgress = false
 		schedule.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
-		_ = s.store.UpdateAutomation(context.Background(), *schedule)
+		_ = s.store.UpdateAutomation(ctx, *schedule)
 		return false, "failed to queue automation"
 	}
-	go s.executeAutomationJob(*schedule, entry.ID)
+	s.startAutomationJob(ctx, *schedule, entry.ID)
 	return true, ""
 }
 
-func (s *Server) executeAutomationJob(schedule store.Automation, entryID string) {
+func (s *Server) executeAutomationJob(ctx context.Context, schedule store.Automation, entryID string) {
 	s.automationMu.Lock()
-	entries, _ := s.store.ListAutomationInbox(context.Background(), schedule.ID)
+	entries, _ := s.store.ListAutomationInbox(ctx, schedule.ID)
 	entry := findInboxEntry(entries, entryID)
 	if entry != nil {
 		entry.Status = "running"
 		entry.StartedAt = time.Now().UTC().Format(time.RFC3339Nano)
 		entry.UpdatedAt = entry.StartedAt
-		_ = s.store.UpdateAutomationInboxEntry(context.Background(), *entry)
+		_ = s.store.UpdateAutomationInboxEntry(ctx, *entry)
 	}
 	s.automationMu.Unlock()
 
-	wait := true
-	req := automationExecuteRequest{
-		Prompt:            schedule.Prompt,
-		ModelRoute:        strings.TrimSpace(schedule.Model),
-		WaitForCompletion: &wait,
-		Metadata: map[string]any{
-			"automation_id":   schedule.ID,
-			"automation_name": schedule.Name,
-		},
-	}
-	if strings.TrimSpace(schedule.Model) != "" {
-		req.Metadata["llm_model"] = strings.TrimSpace(schedule.Model)
+	var (
+		response   automationExecuteResponse
+		err        error
+		promptData automationPromptData
+	)
+	if entry != nil {
+		promptData = s.buildAutomationPromptData(ctx, schedule, entryID, entry.Trigger, entry.TriggerPayload, time.Now())
+	}
+	switch schedule.Kind {
+	case automationKindDigest:
+		response, err = s.runAutomationDigest(ctx, schedule, true)
+	case automationKindPipeline:
+		response, err = s.runAutomationPipeline(ctx, schedule, entryID, promptData, false)
+	default:
+		req := automationPromptRequest(schedule)
+		if req.Prompt, err = renderAutomationPrompt(schedule.Prompt, promptData); err == nil {
+			response, err = s.invokeAutomationExecute(ctx, req)
+		}
 	}
+	s.completeAutomationJob(ctx, schedule.ID, entryID, response, err)
+}
 
-	response, err := s.invokeAutomationExecute(req)
+// completeAutomationJob records the outcome of an inbox entry, releases the
+// automation for its next run and sends its notification.
+func (s *Server) completeAutomationJob(ctx context.Context, automationID string, entryID string, response automationExecuteResponse, err error) {
 	completedAt := time.Now().UTC()
 
 	var (
 		notifySchedule store.Automation
 		notifyEntry    store.AutomationInboxEntry
+		previousEntry  *store.AutomationInboxEntry
 		shouldNotify   bool
 	)
 
 	s.automationMu.Lock()
-	current, loadErr := s.store.GetAutomation(context.Background(), schedule.ID)
+	current, loadErr := s.store.GetAutomation(ctx, automationID)
 	if loadErr != nil || current == nil {
 		s.automationMu.Unlock()
 		return
 	}
-	entries, _ = s.store.ListAutomationInbox(context.Background(), schedule.ID)
+	entries, _ := s.store.ListAutomationInbox(ctx, automationID)
 	inboxEntry := findInboxEntry(entries, entryID)
+	previousEntry = previousCompletedInboxEntry(entries, entryID)
 	if inboxEntry != nil {
 		if err != nil {
 			inboxEntry.Status = "failed"
@@ -682,6 +1113,11 @@ func (s *Server) executeAutomationJob(schedule store.Automation, entryID string)
 			inboxEntry.FinalResponse = ""
 			inboxEntry.TimedOut = false
 			inboxEntry.Diagnostics = map[string]any{"sources": []any{}}
+			// A pipeline that failed part way keeps its stage results.
+			if len(response.Diagnostics.PipelineStages) > 0 {
+				inboxEntry.RunID = response.RunID
+				inboxEntry.Diagnostics = encodeAutomationDiagnostics(response.Diagnostics)
+			}
 		} else {
 			inboxEntry.RunID = response.RunID
 			inboxEntry.Status = response.Status
@@ -691,57 +1127,63 @@ func (s *Server) executeAutomationJob(schedule store.Automation, entryID string)
 			inboxEntry.TimedOut = response.TimedOut
 			inboxEntry.Diagnostics = encodeAutomationDiagnostics(response.Diagnostics)
 		}
+		if current.Kind != automationKindDigest {
+			applyAutomationChange(inboxEntry, previousSuccessfulInboxEntry(entries, entryID), current.ChangeThreshold)
+		}
 		inboxEntry.CompletedAt = completedAt.Format(time.RFC3339Nano)
-		inboxEntry.Unread = true
+		inboxEntry.Unread = inboxEntry.CompletionReason != digestEmptyReason
 		inboxEntry.UpdatedAt = inboxEntry.CompletedAt
-		_ = s.store.UpdateAutomationInboxEntry(context.Background(), *inboxEntry)
+		_ = s.store.UpdateAutomationInboxEntry(ctx, *inboxEntry)
 		notifyEntry = *inboxEntry
-		shouldNotify = true
+		shouldNotify = inboxEntry.Unread
 	}
 
 	current.InProgress = false
 	current.LastRunAt = completedAt.Format(time.RFC3339Nano)
 	current.UpdatedAt = completedAt.Format(time.RFC3339Nano)
-	if current.Enabled {
-		if next, nextErr := computeNextRun(current.Days, current.TimeOfDay, current.Timezone, completedAt); nextErr == nil {
-			current.NextRunAt = next.Format(time.RFC3339Nano)
-		}
-	} else {
-		current.NextRunAt = ""
+	advanceAutomationSchedule(current, inboxEntry, completedAt)
+	// A failure that will be retried is only announced by its last attempt.
+	if scheduleAutomationRetry(current, inboxEntry, completedAt) {
+		shouldNotify = false
 	}
-	_ = s.store.UpdateAutomation(context.Background(), *current)
+	_ = s.store.UpdateAutomation(ctx, *current)
+	s.applyInboxRetention(ctx, *current, completedAt)
 	notifySchedule = *current
 	s.automationMu.Unlock()
+	s.wakeAutomationScheduler()
 
 	if shouldNotify {
-		if notifyErr := s.notifyDiscordAutomationCompletion(notifySchedule, notifyEntry); notifyErr != nil {
+		if notifyErr := s.notifyAutomationCompletion(ctx, notifySchedule, notifyEntry, previousEntry); notifyErr != nil {
 			log.Printf("automation notification failed automation_id=%s entry_id=%s err=%v", notifySchedule.ID, notifyEntry.ID, notifyErr)
 		}
 	}
 }
 
-func (s *Server) invokeAutomationExecute(req automationExecuteRequest) (automationExecuteResponse, error) {
-	payload, err := json.Marshal(req)
-	if err != nil {
-		return automationExecuteResponse{}, err
+func automationPromptRequest(schedule store.Automation) automationExecuteRequest {
+	wait := true
+	req := automationExecuteRequest{
+		Prompt:                 schedule.Prompt,
+		PolicyProfile:          schedule.PolicyProfile,
+		ModelRoute:             strings.TrimSpace(schedule.Model),
+		Tags:                   append([]string(nil), schedule.Tags...),
+		TimeoutMS:              schedule.TimeoutMS,
+		WaitForCompletion:      &wait,
+		BrowserMode:            schedule.BrowserMode,
+		BrowserInteraction:     schedule.BrowserInteraction,
+		BrowserDomainAllowlist: append([]string(nil), schedule.BrowserDomainAllowlist...),
+		BrowserPreferred:       schedule.BrowserPreferred,
+		BrowserUserAgent:       schedule.BrowserUserAgent,
+		Skills:                 append([]string(nil), schedule.Skills...),
+		Metadata: map[string]any{
+			"automation_id":   schedule.ID,
+			"automation_name": schedule.Name,
+		},
 	}
-	httpReq := httptest.NewRequest(http.MethodPost, "/automation/execute", bytes.NewReader(payload))
-	httpReq.Header.Set("Content-Type", "application/json")
-	recorder := httptest.NewRecorder()
-	s.executeAutomationRun(recorder, httpReq)
-
-	result := recorder.Result()
-	defer result.Body.Close()
-	if result.StatusCode < 200 || result.StatusCode >= 300 {
-		raw := strings.TrimSpace(recorder.Body.String())
-		if raw == "" {
-			raw = "automation execution failed"
-		}
-		return automationExecuteResponse{}, fmt.Errorf("automation execute failed (%d): %s", result.StatusCode, raw)
+	if strings.TrimSpace(schedule.Model) != "" {
+		req.Metadata["llm_model"] = strings.TrimSpace(schedule.Model)
 	}
-	output := automationExecuteResponse{}
-	if err := json.NewDecoder(result.Body).Decode(&output); err != nil {
-		return automationExecuteResponse{}, err
+	if strings.TrimSpace(schedule.LLMProfile) != "" {
+		req.Metadata["llm_profile"] = strings.TrimSpace(schedule.LLMProfile)
 	}
-	return output, nil
+	return req
 }
@@ -98,6 +98,7 @@ func (s *Server) createContextFolder(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, node.ID, nil, node)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(contextNodeResponse{
 		ID:        node.ID,
@@ -142,7 +143,9 @@ func (s *Server) uploadContextFile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, node.ID, nil, contextNodeAuditState(node))
 	s.indexContextFileMemory(r.Context(), node)
+	s.dispatchContextTriggers(r.Context(), node, contextChangeUploaded)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(contextNodeResponse{
 		ID:          node.ID,
@@ -187,13 +190,27 @@ func (s *Server) getContextFile(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) deleteContextNode(w http.ResponseWriter, r *http.Request) {
 	nodeID := chi.URLParam(r, "id")
+	node, err := s.store.GetContextFile(r.Context(), nodeID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	if err := s.store.DeleteContextNode(r.Context(), nodeID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if node != nil {
+		s.dispatchContextTriggers(r.Context(), *node, contextChangeDeleted)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// contextNodeAuditState leaves file contents out of the audit log.
+func contextNodeAuditState(node store.ContextNode) store.ContextNode {
+	node.Content = nil
+	return node
+}
+
 func validateContextName(name string) error {
 	if name == "" {
 		return fmt.Errorf("name is required")
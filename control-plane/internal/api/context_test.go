@@ -177,6 +177,7 @@ func TestCreateContextFile(t *testing.T) {
 
 	t.Run("store error", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		storeMock.On("CreateContextFile", mock.Anything, mock.AnythingOfType("store.ContextNode")).Return(errors.New("boom")).Once()
 		server := newTestServer(t, storeMock, &MockBroker{}, nil, config.Config{})
 		defer server.Close()
@@ -190,11 +191,13 @@ func TestCreateContextFile(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		storeMock.On("CreateContextFile", mock.Anything, mock.AnythingOfType("store.ContextNode")).Return(nil).Once()
 		storeMock.On("GetMemorySettings", mock.Anything).Return(&store.MemorySettings{Enabled: true}, nil).Once()
 		storeMock.On("UpsertMemoryEntry", mock.Anything, mock.MatchedBy(func(entry store.MemoryEntry) bool {
 			return entry.Content != "" && entry.Metadata["source"] == "context_file"
 		})).Return(true, nil).Once()
+		storeMock.On("ListAutomations", mock.Anything).Return([]store.Automation{}, nil).Once()
 		cfg := config.Config{MemoryMinContentChars: 1, MemoryChunkChars: 50, MemoryChunkOverlap: 10, MemoryMaxChunks: 2}
 		server := newTestServer(t, storeMock, &MockBroker{}, nil, cfg)
 		defer server.Close()
@@ -210,6 +213,7 @@ func TestCreateContextFile(t *testing.T) {
 func TestDeleteContextNode(t *testing.T) {
 	t.Run("error", func(t *testing.T) {
 		storeMock := &MockStore{}
+		storeMock.On("GetContextFile", mock.Anything, "node-1").Return(nil, nil).Once()
 		storeMock.On("DeleteContextNode", mock.Anything, "node-1").Return(errors.New("boom")).Once()
 		server := newTestServer(t, storeMock, &MockBroker{}, nil, config.Config{})
 		defer server.Close()
@@ -224,7 +228,9 @@ func TestDeleteContextNode(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		storeMock := &MockStore{}
+		storeMock.On("GetContextFile", mock.Anything, "node-1").Return(&store.ContextNode{ID: "node-1", Name: "notes.md", NodeType: "file"}, nil).Once()
 		storeMock.On("DeleteContextNode", mock.Anything, "node-1").Return(nil).Once()
+		storeMock.On("ListAutomations", mock.Anything).Return([]store.Automation{}, nil).Once()
 		server := newTestServer(t, storeMock, &MockBroker{}, nil, config.Config{})
 		defer server.Close()
 
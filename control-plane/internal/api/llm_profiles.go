@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+// Profile names appear in model routes as "@name" or "@name:model", so they
+// cannot contain route separators.
+var llmProfileNameRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+var knownLLMProviders = map[string]struct{}{
+	"codex":           {},
+	"openai":          {},
+	"openrouter":      {},
+	"opencode-zen":    {},
+	"kimi-for-coding": {},
+	"moonshot-ai":     {},
+}
+
+type llmProviderProfile struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	BaseURL    string `json:"base_url"`
+	HasAPIKey  bool   `json:"has_api_key"`
+	APIKeyHint string `json:"api_key_hint,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+type llmProviderProfileRequest struct {
+	Name     string  `json:"name"`
+	Provider string  `json:"provider"`
+	Model    *string `json:"model"`
+	BaseURL  *string `json:"base_url"`
+	APIKey   string  `json:"api_key"`
+}
+
+type llmProviderProfilesResponse struct {
+	Profiles []llmProviderProfile `json:"profiles"`
+}
+
+func (s *Server) toLLMProviderProfileRecord(profile store.LLMProviderProfile) llmProviderProfile {
+	return llmProviderProfile{
+		ID:         profile.ID,
+		Name:       profile.Name,
+		Provider:   profile.Provider,
+		Model:      profile.Model,
+		BaseURL:    profile.BaseURL,
+		HasAPIKey:  profile.APIKeyEnc != "",
+		APIKeyHint: s.apiKeyHint(profile.APIKeyEnc),
+		CreatedAt:  profile.CreatedAt,
+		UpdatedAt:  profile.UpdatedAt,
+	}
+}
+
+func (s *Server) apiKeyHint(ciphertext string) string {
+	if ciphertext == "" || s.cfg.LLMSecretsKey == "" {
+		return ""
+	}
+	apiKey, err := s.decryptStoredSecret(ciphertext)
+	if err != nil || len(apiKey) < 4 {
+		return ""
+	}
+	return apiKey[len(apiKey)-4:]
+}
+
+func normalizeLLMProfileName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+	if !llmProfileNameRE.MatchString(name) {
+		return "", errors.New("name may only contain letters, digits, '.', '_' and '-'")
+	}
+	return name, nil
+}
+
+func normalizeLLMProvider(raw string) (string, error) {
+	provider := strings.ToLower(strings.TrimSpace(raw))
+	if provider == "" {
+		return "", errors.New("provider is required")
+	}
+	if _, ok := knownLLMProviders[provider]; !ok {
+		return "", fmt.Errorf("unknown provider: %s", provider)
+	}
+	return provider, nil
+}
+
+// findLLMProviderProfile looks a profile up by name; it returns nil when no
+// profile matches.
+func (s *Server) findLLMProviderProfile(ctx context.Context, name string) (*store.LLMProviderProfile, error) {
+	profiles, err := s.store.ListLLMProviderProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		if profile.Name == name {
+			found := profile
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) listLLMProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := s.store.ListLLMProviderProfiles(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]llmProviderProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		result = append(result, s.toLLMProviderProfileRecord(profile))
+	}
+	writeJSONStatus(w, llmProviderProfilesResponse{Profiles: result}, http.StatusOK)
+}
+
+func (s *Server) createLLMProfile(w http.ResponseWriter, r *http.Request) {
+	req := llmProviderProfileRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	name, err := normalizeLLMProfileName(req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	provider, err := normalizeLLMProvider(req.Provider)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing, err := s.findLLMProviderProfile(r.Context(), name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		http.Error(w, "profile name already exists", http.StatusConflict)
+		return
+	}
+	apiKeyEnc := ""
+	if strings.TrimSpace(req.APIKey) != "" {
+		ciphertext, status, err := s.encryptStoredSecret(strings.TrimSpace(req.APIKey))
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		apiKeyEnc = ciphertext
+	}
+	if providerNeedsKey(provider) && apiKeyEnc == "" {
+		http.Error(w, "API key required for provider", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	profile := store.LLMProviderProfile{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Provider:  provider,
+		APIKeyEnc: apiKeyEnc,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Model != nil {
+		profile.Model = strings.TrimSpace(*req.Model)
+	}
+	if req.BaseURL != nil {
+		profile.BaseURL = strings.TrimSpace(*req.BaseURL)
+	}
+	if err := s.store.CreateLLMProviderProfile(r.Context(), profile); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, profile.ID, nil, profile)
+	writeJSONStatus(w, s.toLLMProviderProfileRecord(profile), http.StatusCreated)
+}
+
+func (s *Server) updateLLMProfile(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		http.Error(w, "profile id is required", http.StatusBadRequest)
+		return
+	}
+	req := llmProviderProfileRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetLLMProviderProfile(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "profile not found", http.StatusNotFound)
+		return
+	}
+	updated := *current
+	if strings.TrimSpace(req.Name) != "" {
+		name, err := normalizeLLMProfileName(req.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if name != current.Name {
+			existing, err := s.findLLMProviderProfile(r.Context(), name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				http.Error(w, "profile name already exists", http.StatusConflict)
+				return
+			}
+		}
+		updated.Name = name
+	}
+	if strings.TrimSpace(req.Provider) != "" {
+		provider, err := normalizeLLMProvider(req.Provider)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		updated.Provider = provider
+	}
+	if req.Model != nil {
+		updated.Model = strings.TrimSpace(*req.Model)
+	}
+	if req.BaseURL != nil {
+		updated.BaseURL = strings.TrimSpace(*req.BaseURL)
+	}
+	if strings.TrimSpace(req.APIKey) != "" {
+		ciphertext, status, err := s.encryptStoredSecret(strings.TrimSpace(req.APIKey))
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		updated.APIKeyEnc = ciphertext
+	}
+	if providerNeedsKey(updated.Provider) && updated.APIKeyEnc == "" {
+		http.Error(w, "API key required for provider", http.StatusBadRequest)
+		return
+	}
+	updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.store.UpdateLLMProviderProfile(r.Context(), updated); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, current, updated)
+	writeJSONStatus(w, s.toLLMProviderProfileRecord(updated), http.StatusOK)
+}
+
+func (s *Server) deleteLLMProfile(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		http.Error(w, "profile id is required", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetLLMProviderProfile(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "profile not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteLLMProviderProfile(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
+
+// validateRouteProfiles checks that every "@name" entry in a normalized model
+// route refers to an existing provider profile.
+func (s *Server) validateRouteProfiles(ctx context.Context, route string) (int, error) {
+	for _, entry := range strings.Split(route, ",") {
+		name, ok := strings.CutPrefix(entry, "@")
+		if !ok {
+			continue
+		}
+		if idx := strings.Index(name, ":"); idx >= 0 {
+			name = name[:idx]
+		}
+		profile, err := s.findLLMProviderProfile(ctx, name)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if profile == nil {
+			return http.StatusBadRequest, fmt.Errorf("unknown llm profile: %s", name)
+		}
+	}
+	return http.StatusOK, nil
+}
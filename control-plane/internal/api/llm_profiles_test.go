@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+const testLLMSecretsKey = "0123456789abcdef0123456789abcdef"
+
+func postLLMProfile(t *testing.T, url string, payload map[string]any) *http.Response {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+	resp, err := http.Post(url+"/settings/llm/profiles", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	return resp
+}
+
+func TestLLMProfilesCRUD(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	resp := postLLMProfile(t, server.URL, map[string]any{
+		"name":     "work-openrouter",
+		"provider": " OpenRouter ",
+		"model":    "openai/gpt-4o",
+		"api_key":  "sk-or-work-9876",
+	})
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created llmProviderProfile
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NotEmpty(t, created.ID)
+	require.Equal(t, "openrouter", created.Provider)
+	require.True(t, created.HasAPIKey)
+	require.Equal(t, "9876", created.APIKeyHint)
+
+	dup := postLLMProfile(t, server.URL, map[string]any{
+		"name":     "work-openrouter",
+		"provider": "openrouter",
+		"api_key":  "sk-other",
+	})
+	dup.Body.Close()
+	require.Equal(t, http.StatusConflict, dup.StatusCode)
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/settings/llm/profiles/"+created.ID, bytes.NewReader([]byte(`{"model":"","base_url":"https://proxy.example.com"}`)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	updateResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+
+	listResp, err := http.Get(server.URL + "/settings/llm/profiles")
+	require.NoError(t, err)
+	defer listResp.Body.Close()
+	var listed llmProviderProfilesResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Len(t, listed.Profiles, 1)
+	require.Empty(t, listed.Profiles[0].Model)
+	require.Equal(t, "https://proxy.example.com", listed.Profiles[0].BaseURL)
+	require.Equal(t, "9876", listed.Profiles[0].APIKeyHint)
+
+	req, err = http.NewRequest(http.MethodDelete, server.URL+"/settings/llm/profiles/"+created.ID, nil)
+	require.NoError(t, err)
+	deleteResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	deleteResp.Body.Close()
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodDelete, server.URL+"/settings/llm/profiles/"+created.ID, nil)
+	require.NoError(t, err)
+	missingResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	missingResp.Body.Close()
+	require.Equal(t, http.StatusNotFound, missingResp.StatusCode)
+}
+
+func TestCreateLLMProfileValidation(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	cases := []map[string]any{
+		{"name": "", "provider": "openai", "api_key": "sk"},
+		{"name": "bad name", "provider": "openai", "api_key": "sk"},
+		{"name": "bad:name", "provider": "openai", "api_key": "sk"},
+		{"name": "ok", "provider": "nope", "api_key": "sk"},
+		{"name": "ok", "provider": "openrouter"},
+	}
+	for _, payload := range cases {
+		resp := postLLMProfile(t, server.URL, payload)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, payload)
+	}
+
+	codex := postLLMProfile(t, server.URL, map[string]any{"name": "local-codex", "provider": "codex"})
+	codex.Body.Close()
+	require.Equal(t, http.StatusCreated, codex.StatusCode)
+}
+
+func TestLLMProfileReferences(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	resp := postLLMProfile(t, server.URL, map[string]any{"name": "personal", "provider": "openai", "api_key": "sk-personal"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	unknownRoute, err := http.Post(server.URL+"/settings/llm/routes", "application/json", bytes.NewReader([]byte(`{"name":"r","route":"@missing:gpt-4o"}`)))
+	require.NoError(t, err)
+	unknownRoute.Body.Close()
+	require.Equal(t, http.StatusBadRequest, unknownRoute.StatusCode)
+
+	knownRoute, err := http.Post(server.URL+"/settings/llm/routes", "application/json", bytes.NewReader([]byte(`{"name":"r","route":"@personal:gpt-4o, openrouter"}`)))
+	require.NoError(t, err)
+	defer knownRoute.Body.Close()
+	require.Equal(t, http.StatusCreated, knownRoute.StatusCode)
+	var rule llmRouteRule
+	require.NoError(t, json.NewDecoder(knownRoute.Body).Decode(&rule))
+	require.Equal(t, "@personal:gpt-4o,openrouter", rule.Route)
+
+	unknownAutomation, err := http.Post(server.URL+"/automations", "application/json", bytes.NewReader([]byte(`{"name":"a","prompt":"p","days":["mon"],"time":"09:00","timezone":"UTC","llm_profile":"missing"}`)))
+	require.NoError(t, err)
+	unknownAutomation.Body.Close()
+	require.Equal(t, http.StatusBadRequest, unknownAutomation.StatusCode)
+
+	automation, err := http.Post(server.URL+"/automations", "application/json", bytes.NewReader([]byte(`{"name":"a","prompt":"p","days":["mon"],"time":"09:00","timezone":"UTC","llm_profile":"personal"}`)))
+	require.NoError(t, err)
+	defer automation.Body.Close()
+	require.Equal(t, http.StatusCreated, automation.StatusCode)
+	var created automationSchedule
+	require.NoError(t, json.NewDecoder(automation.Body).Decode(&created))
+	require.Equal(t, "personal", created.LLMProfile)
+}
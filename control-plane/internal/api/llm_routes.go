@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const defaultLLMRoutePriority = 100
+
+var knownRouteTaskTypes = map[string]struct{}{
+	"general":  {},
+	"research": {},
+	"code":     {},
+	"title":    {},
+}
+
+type llmRouteRule struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Priority       int      `json:"priority"`
+	TaskTypes      []string `json:"task_types"`
+	Tags           []string `json:"tags"`
+	PolicyProfiles []string `json:"policy_profiles"`
+	AutomationIDs  []string `json:"automation_ids"`
+	Route          string   `json:"route"`
+	Enabled        bool     `json:"enabled"`
+	CreatedAt      string   `json:"created_at"`
+	UpdatedAt      string   `json:"updated_at"`
+}
+
+type llmRouteRuleRequest struct {
+	Name           string   `json:"name"`
+	Priority       *int     `json:"priority"`
+	TaskTypes      []string `json:"task_types"`
+	Tags           []string `json:"tags"`
+	PolicyProfiles []string `json:"policy_profiles"`
+	AutomationIDs  []string `json:"automation_ids"`
+	Route          string   `json:"route"`
+	Enabled        *bool    `json:"enabled"`
+}
+
+type llmRouteRulesResponse struct {
+	Routes []llmRouteRule `json:"routes"`
+}
+
+func toLLMRouteRuleRecord(rule store.LLMRouteRule) llmRouteRule {
+	return llmRouteRule{
+		ID:             rule.ID,
+		Name:           rule.Name,
+		Priority:       rule.Priority,
+		TaskTypes:      nonNilStrings(rule.TaskTypes),
+		Tags:           nonNilStrings(rule.Tags),
+		PolicyProfiles: nonNilStrings(rule.PolicyProfiles),
+		AutomationIDs:  nonNilStrings(rule.AutomationIDs),
+		Route:          rule.Route,
+		Enabled:        rule.Enabled,
+		CreatedAt:      rule.CreatedAt,
+		UpdatedAt:      rule.UpdatedAt,
+	}
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func normalizeRouteCriteria(values []string, lower bool) []string {
+	result := make([]string, 0, len(values))
+	seen := map[string]struct{}{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if lower {
+			value = strings.ToLower(value)
+		}
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	return result
+}
+
+func normalizeRouteTaskTypes(values []string) ([]string, error) {
+	taskTypes := normalizeRouteCriteria(values, true)
+	for _, taskType := range taskTypes {
+		if _, ok := knownRouteTaskTypes[taskType]; !ok {
+			return nil, fmt.Errorf("unknown task type: %s", taskType)
+		}
+	}
+	return taskTypes, nil
+}
+
+// normalizeModelRoute validates a provider:model list in the same format the
+// worker parses for per-run model routes.
+func normalizeModelRoute(raw string) (string, error) {
+	parts := strings.FieldsFunc(strings.TrimSpace(raw), func(r rune) bool {
+		return r == ',' || r == ';' || r == '\n'
+	})
+	entries := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		provider := part
+		if idx := strings.Index(part, ":"); idx >= 0 {
+			provider = strings.TrimSpace(part[:idx])
+			part = provider + ":" + strings.TrimSpace(part[idx+1:])
+		}
+		if provider == "" {
+			return "", fmt.Errorf("route entry %q is missing a provider", part)
+		}
+		entries = append(entries, part)
+	}
+	if len(entries) == 0 {
+		return "", errors.New("route is required")
+	}
+	return strings.Join(entries, ","), nil
+}
+
+func (s *Server) listLLMRoutes(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.store.ListLLMRouteRules(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]llmRouteRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, toLLMRouteRuleRecord(rule))
+	}
+	writeJSONStatus(w, llmRouteRulesResponse{Routes: result}, http.StatusOK)
+}
+
+func (s *Server) createLLMRoute(w http.ResponseWriter, r *http.Request) {
+	req := llmRouteRuleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	route, err := normalizeModelRoute(req.Route)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status, err := s.validateRouteProfiles(r.Context(), route); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	taskTypes, err := normalizeRouteTaskTypes(req.TaskTypes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	priority := defaultLLMRoutePriority
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	rule := store.LLMRouteRule{
+		ID:             uuid.NewString(),
+		Name:           name,
+		Priority:       priority,
+		TaskTypes:      taskTypes,
+		Tags:           normalizeRouteCriteria(req.Tags, false),
+		PolicyProfiles: normalizeRouteCriteria(req.PolicyProfiles, false),
+		AutomationIDs:  normalizeRouteCriteria(req.AutomationIDs, false),
+		Route:          route,
+		Enabled:        enabled,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.store.CreateLLMRouteRule(r.Context(), rule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, rule.ID, nil, rule)
+	writeJSONStatus(w, toLLMRouteRuleRecord(rule), http.StatusCreated)
+}
+
+func (s *Server) updateLLMRoute(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		http.Error(w, "route id is required", http.StatusBadRequest)
+		return
+	}
+	req := llmRouteRuleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetLLMRouteRule(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "route not found", http.StatusNotFound)
+		return
+	}
+	updated := *current
+	if value := strings.TrimSpace(req.Name); value != "" {
+		updated.Name = value
+	}
+	if strings.TrimSpace(req.Route) != "" {
+		route, err := normalizeModelRoute(req.Route)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if status, err := s.validateRouteProfiles(r.Context(), route); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		updated.Route = route
+	}
+	if req.TaskTypes != nil {
+		taskTypes, err := normalizeRouteTaskTypes(req.TaskTypes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		updated.TaskTypes = taskTypes
+	}
+	if req.Tags != nil {
+		updated.Tags = normalizeRouteCriteria(req.Tags, false)
+	}
+	if req.PolicyProfiles != nil {
+		updated.PolicyProfiles = normalizeRouteCriteria(req.PolicyProfiles, false)
+	}
+	if req.AutomationIDs != nil {
+		updated.AutomationIDs = normalizeRouteCriteria(req.AutomationIDs, false)
+	}
+	if req.Priority != nil {
+		updated.Priority = *req.Priority
+	}
+	if req.Enabled != nil {
+		updated.Enabled = *req.Enabled
+	}
+	updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.store.UpdateLLMRouteRule(r.Context(), updated); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, current, updated)
+	writeJSONStatus(w, toLLMRouteRuleRecord(updated), http.StatusOK)
+}
+
+func (s *Server) deleteLLMRoute(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		http.Error(w, "route id is required", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetLLMRouteRule(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "route not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteLLMRouteRule(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
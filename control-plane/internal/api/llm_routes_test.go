@@ -0,0 +1,89 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestLLMRoutesCRUD(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	body, err := json.Marshal(map[string]any{
+		"name":       "Research on fast model",
+		"priority":   10,
+		"task_types": []string{"Research", "research"},
+		"tags":       []string{"news"},
+		"route":      " openrouter : gpt-4o-mini ; openai:gpt-4.1-mini ",
+	})
+	require.NoError(t, err)
+	resp, err := http.Post(server.URL+"/settings/llm/routes", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created llmRouteRule
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NotEmpty(t, created.ID)
+	require.Equal(t, []string{"research"}, created.TaskTypes)
+	require.Equal(t, "openrouter:gpt-4o-mini,openai:gpt-4.1-mini", created.Route)
+	require.True(t, created.Enabled)
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/settings/llm/routes/"+created.ID, bytes.NewReader([]byte(`{"enabled":false,"tags":[]}`)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	updateResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+
+	listResp, err := http.Get(server.URL + "/settings/llm/routes")
+	require.NoError(t, err)
+	defer listResp.Body.Close()
+	var listed llmRouteRulesResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Len(t, listed.Routes, 1)
+	require.False(t, listed.Routes[0].Enabled)
+	require.Empty(t, listed.Routes[0].Tags)
+	require.Equal(t, 10, listed.Routes[0].Priority)
+
+	req, err = http.NewRequest(http.MethodDelete, server.URL+"/settings/llm/routes/"+created.ID, nil)
+	require.NoError(t, err)
+	deleteResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	deleteResp.Body.Close()
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodDelete, server.URL+"/settings/llm/routes/"+created.ID, nil)
+	require.NoError(t, err)
+	missingResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	missingResp.Body.Close()
+	require.Equal(t, http.StatusNotFound, missingResp.StatusCode)
+}
+
+func TestCreateLLMRouteValidation(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	cases := []string{
+		`{"route":"openai:gpt-4o"}`,
+		`{"name":"missing route"}`,
+		`{"name":"bad route","route":":gpt-4o"}`,
+		`{"name":"bad task","route":"openai","task_types":["poetry"]}`,
+		`not-json`,
+	}
+	for _, payload := range cases {
+		resp, err := http.Post(server.URL+"/settings/llm/routes", "application/json", bytes.NewReader([]byte(payload)))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, payload)
+	}
+}
@@ -19,13 +19,16 @@ var newLLMProvider = llm.NewProvider
 var encryptLLMSecret = secrets.Encrypt
 
 type llmSettingsRequest struct {
-	Mode          string `json:"mode"`
-	Provider      string `json:"provider"`
-	Model         string `json:"model"`
-	BaseURL       string `json:"base_url"`
-	APIKey        string `json:"api_key"`
-	CodexAuthPath string `json:"codex_auth_path"`
-	CodexHome     string `json:"codex_home"`
+	Mode            string  `json:"mode"`
+	Provider        string  `json:"provider"`
+	Model           string  `json:"model"`
+	BaseURL         string  `json:"base_url"`
+	APIKey          string  `json:"api_key"`
+	CodexAuthPath   string  `json:"codex_auth_path"`
+	CodexHome       string  `json:"codex_home"`
+	UtilityProvider *string `json:"utility_provider"`
+	UtilityModel    *string `json:"utility_model"`
+	UtilityBaseURL  *string `json:"utility_base_url"`
 }
 
 type llmModelsRequest struct {
@@ -36,15 +39,18 @@ type llmModelsRequest struct {
 }
 
 type llmSettingsResponse struct {
-	Configured    bool   `json:"configured"`
-	Mode          string `json:"mode"`
-	Provider      string `json:"provider"`
-	Model         string `json:"model"`
-	BaseURL       string `json:"base_url"`
-	CodexAuthPath string `json:"codex_auth_path,omitempty"`
-	CodexHome     string `json:"codex_home,omitempty"`
-	HasAPIKey     bool   `json:"has_api_key"`
-	APIKeyHint    string `json:"api_key_hint,omitempty"`
+	Configured      bool   `json:"configured"`
+	Mode            string `json:"mode"`
+	Provider        string `json:"provider"`
+	Model           string `json:"model"`
+	BaseURL         string `json:"base_url"`
+	CodexAuthPath   string `json:"codex_auth_path,omitempty"`
+	CodexHome       string `json:"codex_home,omitempty"`
+	UtilityProvider string `json:"utility_provider"`
+	UtilityModel    string `json:"utility_model"`
+	UtilityBaseURL  string `json:"utility_base_url"`
+	HasAPIKey       bool   `json:"has_api_key"`
+	APIKeyHint      string `json:"api_key_hint,omitempty"`
 }
 
 func (s *Server) getLLMSettings(w http.ResponseWriter, r *http.Request) {
@@ -54,11 +60,14 @@ func (s *Server) getLLMSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	response := llmSettingsResponse{
-		Configured: false,
-		Mode:       s.cfg.LLMMode,
-		Provider:   s.cfg.LLMProvider,
-		Model:      s.cfg.LLMModel,
-		BaseURL:    s.cfg.LLMBaseURL,
+		Configured:      false,
+		Mode:            s.cfg.LLMMode,
+		Provider:        s.cfg.LLMProvider,
+		Model:           s.cfg.LLMModel,
+		BaseURL:         s.cfg.LLMBaseURL,
+		UtilityProvider: s.cfg.LLMUtilityProvider,
+		UtilityModel:    s.cfg.LLMUtilityModel,
+		UtilityBaseURL:  s.cfg.LLMUtilityBaseURL,
 	}
 	if settings != nil {
 		response.Configured = true
@@ -68,16 +77,13 @@ func (s *Server) getLLMSettings(w http.ResponseWriter, r *http.Request) {
 		response.BaseURL = settings.BaseURL
 		response.CodexAuthPath = settings.CodexAuthPath
 		response.CodexHome = settings.CodexHome
-		response.HasAPIKey = settings.APIKeyEnc != ""
-		if settings.APIKeyEnc != "" && s.cfg.LLMSecretsKey != "" {
-			if key, err := secrets.ParseKey(s.cfg.LLMSecretsKey); err == nil {
-				if apiKey, err := secrets.Decrypt(key, settings.APIKeyEnc); err == nil {
-					if len(apiKey) >= 4 {
-						response.APIKeyHint = apiKey[len(apiKey)-4:]
-					}
-				}
-			}
+		if settings.UtilityProvider != "" {
+			response.UtilityProvider = settings.UtilityProvider
+			response.UtilityModel = settings.UtilityModel
+			response.UtilityBaseURL = settings.UtilityBaseURL
 		}
+		response.HasAPIKey = settings.APIKeyEnc != ""
+		response.APIKeyHint = s.apiKeyHint(settings.APIKeyEnc)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -109,6 +115,27 @@ func (s *Server) updateLLMSettings(w http.ResponseWriter, r *http.Request) {
 		codexAuthPath = firstNonEmpty(req.CodexAuthPath, settings.CodexAuthPath)
 		codexHome = firstNonEmpty(req.CodexHome, settings.CodexHome)
 	}
+	utilityProvider := ""
+	utilityModel := ""
+	utilityBaseURL := ""
+	if settings != nil {
+		utilityProvider = settings.UtilityProvider
+		utilityModel = settings.UtilityModel
+		utilityBaseURL = settings.UtilityBaseURL
+	}
+	if req.UtilityProvider != nil {
+		utilityProvider = strings.TrimSpace(*req.UtilityProvider)
+	}
+	if req.UtilityModel != nil {
+		utilityModel = strings.TrimSpace(*req.UtilityModel)
+	}
+	if req.UtilityBaseURL != nil {
+		utilityBaseURL = strings.TrimSpace(*req.UtilityBaseURL)
+	}
+	if utilityProvider == "" {
+		utilityModel = ""
+		utilityBaseURL = ""
+	}
 
 	apiKeyEnc := ""
 	if settings != nil {
@@ -138,20 +165,24 @@ func (s *Server) updateLLMSettings(w http.ResponseWriter, r *http.Request) {
 		createdAt = settings.CreatedAt
 	}
 	newSettings := store.LLMSettings{
-		Mode:          mode,
-		Provider:      provider,
-		Model:         model,
-		BaseURL:       baseURL,
-		APIKeyEnc:     apiKeyEnc,
-		CodexAuthPath: codexAuthPath,
-		CodexHome:     codexHome,
-		CreatedAt:     createdAt,
-		UpdatedAt:     now,
+		Mode:            mode,
+		Provider:        provider,
+		Model:           model,
+		BaseURL:         baseURL,
+		APIKeyEnc:       apiKeyEnc,
+		CodexAuthPath:   codexAuthPath,
+		CodexHome:       codexHome,
+		UtilityProvider: utilityProvider,
+		UtilityModel:    utilityModel,
+		UtilityBaseURL:  utilityBaseURL,
+		CreatedAt:       createdAt,
+		UpdatedAt:       now,
 	}
 	if err := s.store.UpsertLLMSettings(r.Context(), newSettings); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, "", settings, newSettings)
 	s.getLLMSettings(w, r)
 }
 
@@ -197,10 +228,8 @@ func (s *Server) listLLMModels(w http.ResponseWriter, r *http.Request) {
 				req.BaseURL = settings.BaseURL
 			}
 			if req.APIKey == "" && settings.APIKeyEnc != "" && s.cfg.LLMSecretsKey != "" {
-				if key, err := secrets.ParseKey(s.cfg.LLMSecretsKey); err == nil {
-					if apiKey, err := secrets.Decrypt(key, settings.APIKeyEnc); err == nil {
-						req.APIKey = apiKey
-					}
+				if apiKey, err := s.decryptStoredSecret(settings.APIKeyEnc); err == nil {
+					req.APIKey = apiKey
 				}
 			}
 			if req.Model == "" {
@@ -231,11 +260,7 @@ func (s *Server) buildLLMConfig(ctx context.Context, req llmSettingsRequest) (ll
 		apiKey = req.APIKey
 	} else if settings, err := s.store.GetLLMSettings(ctx); err == nil && settings != nil {
 		if providerNeedsKey(provider) && settings.APIKeyEnc != "" {
-			key, err := secrets.ParseKey(s.cfg.LLMSecretsKey)
-			if err != nil {
-				return llm.Config{}, err
-			}
-			decrypted, err := secrets.Decrypt(key, settings.APIKeyEnc)
+			decrypted, err := s.decryptStoredSecret(settings.APIKeyEnc)
 			if err != nil {
 				return llm.Config{}, err
 			}
@@ -266,6 +291,63 @@ func (s *Server) buildLLMConfig(ctx context.Context, req llmSettingsRequest) (ll
 	return config, nil
 }
 
+// utilityLLMConfig resolves the utility model the way runs do: saved settings
+// override LLM_UTILITY_*, the main model and base URL fill gaps, and the saved
+// API key is reused when the utility provider matches the main one. ok is
+// false when no utility provider is configured.
+func (s *Server) utilityLLMConfig(ctx context.Context) (llm.Config, bool, error) {
+	settings, err := s.store.GetLLMSettings(ctx)
+	if err != nil {
+		return llm.Config{}, false, err
+	}
+	config := llm.Config{
+		Mode:             s.cfg.LLMMode,
+		Provider:         s.cfg.LLMUtilityProvider,
+		Model:            s.cfg.LLMUtilityModel,
+		BaseURL:          s.cfg.LLMUtilityBaseURL,
+		OpenAIAPIKey:     s.cfg.OpenAIAPIKey,
+		OpenRouterAPIKey: s.cfg.OpenRouterAPIKey,
+		OpenCodeAPIKey:   s.cfg.OpenCodeAPIKey,
+		CodexAuthPath:    s.cfg.CodexAuthPath,
+		CodexHome:        s.cfg.CodexHome,
+	}
+	mainProvider, mainModel, mainBaseURL := s.cfg.LLMProvider, s.cfg.LLMModel, s.cfg.LLMBaseURL
+	if settings != nil {
+		mainProvider, mainModel, mainBaseURL = settings.Provider, settings.Model, settings.BaseURL
+		config.Mode = firstNonEmpty(settings.Mode, config.Mode)
+		config.CodexAuthPath = firstNonEmpty(settings.CodexAuthPath, config.CodexAuthPath)
+		config.CodexHome = firstNonEmpty(settings.CodexHome, config.CodexHome)
+		if settings.UtilityProvider != "" {
+			config.Provider = settings.UtilityProvider
+			config.Model = settings.UtilityModel
+			config.BaseURL = settings.UtilityBaseURL
+		}
+	}
+	config.Provider = strings.TrimSpace(config.Provider)
+	if config.Provider == "" {
+		return llm.Config{}, false, nil
+	}
+	config.Model = firstNonEmpty(strings.TrimSpace(config.Model), mainModel)
+	if config.BaseURL == "" && config.Provider == mainProvider {
+		config.BaseURL = mainBaseURL
+	}
+	if settings != nil && settings.APIKeyEnc != "" && settings.Provider == config.Provider {
+		apiKey, err := s.decryptStoredSecret(settings.APIKeyEnc)
+		if err != nil {
+			return llm.Config{}, false, err
+		}
+		switch config.Provider {
+		case "openrouter":
+			config.OpenRouterAPIKey = apiKey
+		case "opencode-zen":
+			config.OpenCodeAPIKey = apiKey
+		default:
+			config.OpenAIAPIKey = apiKey
+		}
+	}
+	return config, true, nil
+}
+
 func fetchModels(provider string, req llmModelsRequest) ([]string, error) {
 	if provider == "opencode-zen" {
 		models, err := fetchOpenCodeModels()
@@ -189,6 +189,40 @@ func TestUpdateLLMSettings(t *testing.T) {
 		storeMock.AssertExpectations(t)
 	})
 
+	t.Run("utility model", func(t *testing.T) {
+		storeMock := &MockStore{}
+		existing := &store.LLMSettings{Mode: "remote", Provider: "openai", Model: "gpt-4", APIKeyEnc: "encrypted", UtilityProvider: "openai", UtilityModel: "gpt-4.1-nano", CreatedAt: "before"}
+		storeMock.On("GetLLMSettings", mock.Anything).Return(existing, nil).Once()
+		var saved store.LLMSettings
+		storeMock.On("UpsertLLMSettings", mock.Anything, mock.AnythingOfType("store.LLMSettings")).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(store.LLMSettings)
+		}).Return(nil).Twice()
+		storeMock.On("GetLLMSettings", mock.Anything).Return(&saved, nil).Once()
+		server := newTestServer(t, storeMock, &MockBroker{}, nil, config.Config{})
+		defer server.Close()
+
+		resp, err := http.Post(server.URL+"/settings/llm", "application/json", strings.NewReader(`{"utility_provider":"openrouter","utility_model":"small","utility_base_url":"https://openrouter.example/api/v1"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var response llmSettingsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		require.Equal(t, "openrouter", response.UtilityProvider)
+		require.Equal(t, "small", response.UtilityModel)
+		require.Equal(t, "https://openrouter.example/api/v1", response.UtilityBaseURL)
+		require.Equal(t, "gpt-4", saved.Model)
+
+		storeMock.On("GetLLMSettings", mock.Anything).Return(&saved, nil).Twice()
+		cleared, err := http.Post(server.URL+"/settings/llm", "application/json", strings.NewReader(`{"utility_provider":""}`))
+		require.NoError(t, err)
+		defer cleared.Body.Close()
+		require.Equal(t, http.StatusOK, cleared.StatusCode)
+		require.Equal(t, "", saved.UtilityProvider)
+		require.Equal(t, "", saved.UtilityModel)
+		require.Equal(t, "", saved.UtilityBaseURL)
+		storeMock.AssertExpectations(t)
+	})
+
 	t.Run("existing settings", func(t *testing.T) {
 		storeMock := &MockStore{}
 		existing := &store.LLMSettings{Mode: "remote", Provider: "openai", Model: "gpt-4", APIKeyEnc: "encrypted", CreatedAt: "before"}
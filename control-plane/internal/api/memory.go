@@ -60,6 +60,7 @@ func (s *Server) updateMemorySettings(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, "", current, settings)
 	get := memorySettingsResponse{
 		Enabled:   settings.Enabled,
 		CreatedAt: settings.CreatedAt,
@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/redaction"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
 )
 
@@ -29,6 +30,7 @@ func (s *Server) indexMessageMemory(ctx context.Context, msg store.Message) {
 	if len([]rune(content)) < s.cfg.MemoryMinContentChars {
 		return
 	}
+	content, markers := s.runRedactionPipeline(ctx, msg.RunID).Text("content", content)
 	chunks := chunkContent(content, s.cfg.MemoryChunkChars, s.cfg.MemoryChunkOverlap, s.cfg.MemoryMaxChunks)
 	if len(chunks) == 0 {
 		return
@@ -47,6 +49,9 @@ func (s *Server) indexMessageMemory(ctx context.Context, msg store.Message) {
 			"chunk_total": len(chunks),
 			"fingerprint": fingerprint,
 		}
+		if len(markers) > 0 {
+			metadata["redactions"] = redaction.Summary(markers)
+		}
 		entry := store.MemoryEntry{
 			ID:        uuid.New().String(),
 			Content:   chunk,
@@ -89,6 +94,8 @@ func (s *Server) indexContextFileMemory(ctx context.Context, node store.ContextN
 	if len([]rune(content)) < s.cfg.MemoryMinContentChars {
 		return
 	}
+	// Context files are not tied to a run, so the default profile's policy applies.
+	content, markers := s.redactionPipeline(ctx, redaction.DefaultPolicyProfile).Text("content", content)
 	path := s.contextPathForNode(ctx, node)
 	chunks := chunkContent(content, s.cfg.MemoryChunkChars, s.cfg.MemoryChunkOverlap, s.cfg.MemoryMaxChunks)
 	if len(chunks) == 0 {
@@ -109,6 +116,9 @@ func (s *Server) indexContextFileMemory(ctx context.Context, node store.ContextN
 			"chunk_total":  len(chunks),
 			"fingerprint":  fingerprint,
 		}
+		if len(markers) > 0 {
+			metadata["redactions"] = redaction.Summary(markers)
+		}
 		entry := store.MemoryEntry{
 			ID:        uuid.New().String(),
 			Content:   chunk,
@@ -149,7 +159,7 @@ func (s *Server) recordMemoryIndexError(ctx context.Context, runID string, sourc
 		Payload:   map[string]any{"source": source, "error": err.Error()},
 	}
 	_ = s.store.AppendEvent(ctx, event)
-	s.broker.Publish(toEvent(event))
+	s.publishEvent(ctx, event)
 }
 
 func (s *Server) contextPathForNode(ctx context.Context, node store.ContextNode) string {
@@ -1,47 +1,397 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/notify"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
 )
 
-type discordWebhookPayload struct {
-	Content string                `json:"content,omitempty"`
-	Embeds  []discordWebhookEmbed `json:"embeds,omitempty"`
+const (
+	notifyOnAlways  = "always"
+	notifyOnFailure = "failure"
+	notifyOnChange  = "change"
+	// notifyOnDigest sends nothing per run; results only reach channels
+	// through digest automations.
+	notifyOnDigest = "digest"
+)
+
+type notificationChannelRecord struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config"`
+	// SecretKeys names the credential keys that are set; their values are
+	// never returned.
+	SecretKeys []string `json:"secret_keys"`
+	Enabled    bool     `json:"enabled"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
 }
 
-type discordWebhookEmbed struct {
-	Title       string              `json:"title,omitempty"`
-	Description string              `json:"description,omitempty"`
-	Color       int                 `json:"color,omitempty"`
-	Timestamp   string              `json:"timestamp,omitempty"`
-	Fields      []discordEmbedField `json:"fields,omitempty"`
+type notificationChannelRequest struct {
+	Name    *string           `json:"name"`
+	Type    *string           `json:"type"`
+	Config  map[string]string `json:"config"`
+	Enabled *bool             `json:"enabled"`
 }
 
-type discordEmbedField struct {
-	Name   string `json:"name"`
-	Value  string `json:"value"`
-	Inline bool   `json:"inline,omitempty"`
+type notificationChannelsResponse struct {
+	Channels []notificationChannelRecord `json:"channels"`
+	Types    []string                    `json:"types"`
 }
 
-func (s *Server) notifyDiscordAutomationCompletion(schedule store.Automation, entry store.AutomationInboxEntry) error {
-	webhookURL := strings.TrimSpace(s.cfg.DiscordWebhookURL)
-	if webhookURL == "" {
-		return nil
+func (s *Server) toNotificationChannelRecord(channel store.NotificationChannel) notificationChannelRecord {
+	record := notificationChannelRecord{
+		ID:         channel.ID,
+		Name:       channel.Name,
+		Type:       channel.Type,
+		Config:     map[string]string{},
+		SecretKeys: []string{},
+		Enabled:    channel.Enabled,
+		CreatedAt:  channel.CreatedAt,
+		UpdatedAt:  channel.UpdatedAt,
+	}
+	for key, value := range channel.Config {
+		record.Config[key] = value
+	}
+	if channel.SecretsEnc != "" {
+		if values, err := s.decryptChannelSecrets(channel.SecretsEnc); err == nil {
+			for key := range values {
+				record.SecretKeys = append(record.SecretKeys, key)
+			}
+			sort.Strings(record.SecretKeys)
+		}
+	}
+	return record
+}
+
+func (s *Server) decryptChannelSecrets(secretsEnc string) (map[string]string, error) {
+	values := map[string]string{}
+	if secretsEnc == "" {
+		return values, nil
+	}
+	plaintext, err := s.decryptStoredSecret(secretsEnc)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(plaintext), &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// channelConfig returns the full config of channel, credentials included.
+func (s *Server) channelConfig(channel store.NotificationChannel) (map[string]string, error) {
+	config, err := s.decryptChannelSecrets(channel.SecretsEnc)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt channel secrets: %w", err)
+	}
+	for key, value := range channel.Config {
+		config[key] = value
+	}
+	return config, nil
+}
+
+// applyChannelConfig validates config for channel.Type and splits it into the
+// stored plain config and encrypted credentials. Credential keys omitted from
+// config keep their current values.
+func (s *Server) applyChannelConfig(channel *store.NotificationChannel, current map[string]string, config map[string]string) (int, error) {
+	merged := map[string]string{}
+	secretKeys := map[string]bool{}
+	for _, key := range notify.SecretKeys(channel.Type) {
+		secretKeys[key] = true
+		if value, ok := current[key]; ok {
+			merged[key] = value
+		}
+	}
+	for key, value := range config {
+		merged[strings.TrimSpace(key)] = strings.TrimSpace(value)
 	}
+	for key, value := range merged {
+		if value == "" {
+			delete(merged, key)
+		}
+	}
+	if err := notify.Validate(channel.Type, merged); err != nil {
+		return http.StatusBadRequest, err
+	}
+	plain := map[string]string{}
+	secretValues := map[string]string{}
+	for key, value := range merged {
+		if secretKeys[key] {
+			secretValues[key] = value
+		} else {
+			plain[key] = value
+		}
+	}
+	channel.Config = plain
+	channel.SecretsEnc = ""
+	if len(secretValues) > 0 {
+		raw, err := json.Marshal(secretValues)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		secretsEnc, status, err := s.encryptStoredSecret(string(raw))
+		if err != nil {
+			return status, err
+		}
+		channel.SecretsEnc = secretsEnc
+	}
+	return http.StatusOK, nil
+}
 
+func (s *Server) listNotificationChannels(w http.ResponseWriter, r *http.Request) {
+	channels, err := s.store.ListNotificationChannels(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]notificationChannelRecord, 0, len(channels))
+	for _, channel := range channels {
+		result = append(result, s.toNotificationChannelRecord(channel))
+	}
+	writeJSONStatus(w, notificationChannelsResponse{Channels: result, Types: notify.Types()}, http.StatusOK)
+}
+
+func (s *Server) createNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	req := notificationChannelRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Type == nil || strings.TrimSpace(*req.Type) == "" {
+		http.Error(w, "type is required", http.StatusBadRequest)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	channel := store.NotificationChannel{
+		ID:        uuid.NewString(),
+		Type:      strings.ToLower(strings.TrimSpace(*req.Type)),
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Name != nil {
+		channel.Name = strings.TrimSpace(*req.Name)
+	}
+	if channel.Name == "" {
+		channel.Name = channel.Type
+	}
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+	if status, err := s.applyChannelConfig(&channel, nil, req.Config); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if err := s.store.CreateNotificationChannel(r.Context(), channel); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, channel.ID, nil, channel)
+	writeJSONStatus(w, s.toNotificationChannelRecord(channel), http.StatusCreated)
+}
+
+func (s *Server) updateNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	req := notificationChannelRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetNotificationChannel(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "notification channel not found", http.StatusNotFound)
+		return
+	}
+	updated := *current
+	if req.Name != nil {
+		if name := strings.TrimSpace(*req.Name); name != "" {
+			updated.Name = name
+		}
+	}
+	if req.Enabled != nil {
+		updated.Enabled = *req.Enabled
+	}
+	typeChanged := req.Type != nil && strings.ToLower(strings.TrimSpace(*req.Type)) != current.Type
+	if typeChanged || req.Config != nil {
+		currentSecrets := map[string]string{}
+		config := req.Config
+		if typeChanged {
+			updated.Type = strings.ToLower(strings.TrimSpace(*req.Type))
+		} else {
+			if currentSecrets, err = s.decryptChannelSecrets(current.SecretsEnc); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if status, err := s.applyChannelConfig(&updated, currentSecrets, config); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+	updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.store.UpdateNotificationChannel(r.Context(), updated); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, updated.ID, *current, updated)
+	writeJSONStatus(w, s.toNotificationChannelRecord(updated), http.StatusOK)
+}
+
+func (s *Server) deleteNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	current, err := s.store.GetNotificationChannel(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "notification channel not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteNotificationChannel(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, *current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
+
+func (s *Server) testNotificationChannel(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	channel, err := s.store.GetNotificationChannel(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if channel == nil {
+		http.Error(w, "notification channel not found", http.StatusNotFound)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+	err = s.sendToChannel(ctx, *channel, notify.Message{
+		Title:  "Gavryn test notification",
+		Body:   fmt.Sprintf("Notifications from Gavryn will be delivered to %q.", channel.Name),
+		Status: "completed",
+		Fields: []notify.Field{{Name: "Channel", Value: channel.Type, Inline: true}},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSONStatus(w, map[string]string{"status": "sent"}, http.StatusOK)
+}
+
+func (s *Server) sendToChannel(ctx context.Context, channel store.NotificationChannel, msg notify.Message) error {
+	config, err := s.channelConfig(channel)
+	if err != nil {
+		return err
+	}
+	notifier, err := notify.New(channel.Type, config, s.httpClient)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(ctx, msg)
+}
+
+func normalizeNotifyOn(value string) (string, error) {
+	switch strings.TrimSpace(strings.ToLower(value)) {
+	case "", notifyOnAlways:
+		return notifyOnAlways, nil
+	case notifyOnFailure:
+		return notifyOnFailure, nil
+	case notifyOnChange:
+		return notifyOnChange, nil
+	case notifyOnDigest:
+		return notifyOnDigest, nil
+	default:
+		return "", errors.New("notify_on must be always, failure, change or digest")
+	}
+}
+
+// resolveNotificationChannels checks that every channel ID belongs to the
+// caller's workspace.
+func (s *Server) resolveNotificationChannels(ctx context.Context, ids []string) ([]string, int, error) {
+	resolved := normalizeRouteCriteria(ids, false)
+	for _, id := range resolved {
+		channel, err := s.store.GetNotificationChannel(ctx, id)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if channel == nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("unknown notification channel: %s", id)
+		}
+	}
+	return resolved, http.StatusOK, nil
+}
+
+// shouldNotifyAutomation applies an automation's notify_on policy. previous
+// is the automation's last completed entry before entry, if any. A result
+// marked unchanged only notifies when its status differs from previous.
+// "change" also stays quiet on repeated failures.
+func shouldNotifyAutomation(notifyOn string, entry store.AutomationInboxEntry, previous *store.AutomationInboxEntry) bool {
+	status := normalizeAutomationStatus(entry.Status)
+	statusChanged := previous == nil || normalizeAutomationStatus(previous.Status) != status
+	switch notifyOn {
+	case notifyOnFailure:
+		return status == "failed" || status == "cancelled"
+	case notifyOnChange:
+		if statusChanged {
+			return true
+		}
+		return automationResultSucceeded(status) && !entry.Unchanged
+	case notifyOnDigest:
+		return false
+	default:
+		return statusChanged || !entry.Unchanged
+	}
+}
+
+// previousCompletedInboxEntry returns the most recently completed inbox entry
+// other than entryID, ignoring dry runs.
+func previousCompletedInboxEntry(entries []store.AutomationInboxEntry, entryID string) *store.AutomationInboxEntry {
+	var previous *store.AutomationInboxEntry
+	for idx := range entries {
+		entry := entries[idx]
+		if entry.ID == entryID || isDryRunEntry(entry) || strings.TrimSpace(entry.CompletedAt) == "" {
+			continue
+		}
+		if previous == nil || parseTime(entry.CompletedAt).After(parseTime(previous.CompletedAt)) {
+			copy := entry
+			previous = &copy
+		}
+	}
+	return previous
+}
+
+func automationNotificationMessage(schedule store.Automation, entry store.AutomationInboxEntry) notify.Message {
 	status := normalizeAutomationStatus(entry.Status)
 	title := fmt.Sprintf("Gavryn automation %s", status)
 	if strings.TrimSpace(schedule.Name) != "" {
 		title = fmt.Sprintf("Gavryn automation %s: %s", status, strings.TrimSpace(schedule.Name))
 	}
+	if schedule.Kind == automationKindDigest {
+		title = "Gavryn digest: " + fallbackString(strings.TrimSpace(schedule.Name), "automation results")
+	}
 
 	description := strings.TrimSpace(entry.FinalResponse)
 	if description == "" {
@@ -50,51 +400,71 @@ func (s *Server) notifyDiscordAutomationCompletion(schedule store.Automation, en
 	if description == "" {
 		description = "Automation run completed."
 	}
-	description = truncateForDiscord(description, 900)
 
-	fields := []discordEmbedField{
+	fields := []notify.Field{
 		{Name: "Status", Value: status, Inline: true},
-		{Name: "Trigger", Value: fallbackString(entry.Trigger, "schedule"), Inline: true},
+		{Name: "Trigger", Value: fallbackString(entry.Trigger, automationTriggerSchedule), Inline: true},
+	}
+	if entry.Attempt > 1 {
+		fields = append(fields, notify.Field{Name: "Attempt", Value: strconv.Itoa(entry.Attempt), Inline: true})
 	}
 	if runID := strings.TrimSpace(entry.RunID); runID != "" {
-		fields = append(fields, discordEmbedField{Name: "Run ID", Value: runID, Inline: false})
+		fields = append(fields, notify.Field{Name: "Run ID", Value: runID})
 	}
 	if reason := strings.TrimSpace(entry.CompletionReason); reason != "" {
-		fields = append(fields, discordEmbedField{Name: "Completion Reason", Value: truncateForDiscord(reason, 240), Inline: false})
+		fields = append(fields, notify.Field{Name: "Completion Reason", Value: reason})
 	}
-
-	payload := discordWebhookPayload{
-		Embeds: []discordWebhookEmbed{
-			{
-				Title:       title,
-				Description: description,
-				Color:       discordStatusColor(status),
-				Timestamp:   time.Now().UTC().Format(time.RFC3339),
-				Fields:      fields,
-			},
-		},
+	if summary := strings.TrimSpace(entry.ChangeSummary); summary != "" {
+		fields = append(fields, notify.Field{Name: "Change", Value: summary})
+	}
+	return notify.Message{
+		Title:     title,
+		Body:      description,
+		Status:    status,
+		Fields:    fields,
+		Timestamp: time.Now().UTC(),
 	}
+}
 
-	raw, err := json.Marshal(payload)
-	if err != nil {
-		return err
+// notifyAutomationCompletion sends entry to the automation's enabled channels
+// when its notify_on policy allows. Automations without channels fall back to
+// DISCORD_WEBHOOK_URL when it is set.
+func (s *Server) notifyAutomationCompletion(ctx context.Context, schedule store.Automation, entry store.AutomationInboxEntry, previous *store.AutomationInboxEntry) error {
+	if !shouldNotifyAutomation(schedule.NotifyOn, entry, previous) {
+		return nil
 	}
-	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(raw))
-	if err != nil {
-		return err
+	msg := automationNotificationMessage(schedule, entry)
+	if len(schedule.NotificationChannels) == 0 {
+		webhookURL := strings.TrimSpace(s.cfg.DiscordWebhookURL)
+		if webhookURL == "" {
+			return nil
+		}
+		notifier, err := notify.New(notify.TypeDiscord, map[string]string{"url": webhookURL}, s.httpClient)
+		if err != nil {
+			return err
+		}
+		return notifier.Send(ctx, msg)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-		return fmt.Errorf("discord webhook rejected request: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	var errs []error
+	for _, channelID := range schedule.NotificationChannels {
+		channel, err := s.store.GetNotificationChannel(ctx, channelID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if channel == nil {
+			log.Printf("automation notification channel missing automation_id=%s channel_id=%s", schedule.ID, channelID)
+			continue
+		}
+		if !channel.Enabled {
+			continue
+		}
+		if err := s.sendToChannel(ctx, *channel, msg); err != nil {
+			errs = append(errs, fmt.Errorf("channel %s: %w", channel.ID, err))
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func normalizeAutomationStatus(status string) string {
@@ -106,33 +476,6 @@ func normalizeAutomationStatus(status string) string {
 	}
 }
 
-func discordStatusColor(status string) int {
-	switch normalizeAutomationStatus(status) {
-	case "failed":
-		return 15158332
-	case "cancelled":
-		return 10181046
-	case "partial":
-		return 16776960
-	default:
-		return 5763719
-	}
-}
-
-func truncateForDiscord(value string, limit int) string {
-	text := strings.TrimSpace(value)
-	if text == "" || limit <= 0 {
-		return ""
-	}
-	if len(text) <= limit {
-		return text
-	}
-	if limit <= 3 {
-		return text[:limit]
-	}
-	return strings.TrimSpace(text[:limit-3]) + "..."
-}
-
 func fallbackString(value string, fallback string) string {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -11,11 +12,12 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/notify"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
 )
 
-func TestNotifyDiscordAutomationCompletionPostsEmbed(t *testing.T) {
+func TestNotifyAutomationCompletionFallsBackToDiscordWebhook(t *testing.T) {
 	var (
 		receivedMethod string
 		receivedBody   []byte
@@ -34,7 +36,8 @@ func TestNotifyDiscordAutomationCompletionPostsEmbed(t *testing.T) {
 	})
 
 	hugeSummary := strings.Repeat("A", 1400)
-	err := s.notifyDiscordAutomationCompletion(
+	err := s.notifyAutomationCompletion(
+		context.Background(),
 		store.Automation{ID: "a1", Name: "Daily Brief"},
 		store.AutomationInboxEntry{
 			ID:               "i1",
@@ -44,11 +47,12 @@ func TestNotifyDiscordAutomationCompletionPostsEmbed(t *testing.T) {
 			CompletionReason: "tool_execution_failed",
 			FinalResponse:    hugeSummary,
 		},
+		nil,
 	)
 	require.NoError(t, err)
 	require.Equal(t, http.MethodPost, receivedMethod)
 
-	payload := discordWebhookPayload{}
+	payload := notify.DiscordPayload{}
 	require.NoError(t, json.Unmarshal(receivedBody, &payload))
 	require.Len(t, payload.Embeds, 1)
 	require.Equal(t, "Gavryn automation failed: Daily Brief", payload.Embeds[0].Title)
@@ -58,11 +62,177 @@ func TestNotifyDiscordAutomationCompletionPostsEmbed(t *testing.T) {
 	require.GreaterOrEqual(t, len(fields), 3)
 }
 
-func TestNotifyDiscordAutomationCompletionSkipsWhenNotConfigured(t *testing.T) {
+func TestNotifyAutomationCompletionSkipsWhenNotConfigured(t *testing.T) {
 	s := NewServer(memory.New(), &MockBroker{}, nil, config.Config{})
-	err := s.notifyDiscordAutomationCompletion(
+	err := s.notifyAutomationCompletion(
+		context.Background(),
 		store.Automation{ID: "a1", Name: "No Webhook"},
 		store.AutomationInboxEntry{ID: "i1", Status: "completed"},
+		nil,
 	)
 	require.NoError(t, err)
 }
+
+func TestNotificationChannels(t *testing.T) {
+	mem := memory.New()
+	var received []notify.WebhookPayload
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	for _, body := range []string{
+		`{"name":"ops"}`,
+		`{"type":"pager","config":{}}`,
+		`{"type":"slack","config":{}}`,
+		`{"type":"ntfy","config":{"topic":"alerts","color":"red"}}`,
+	} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/settings/notifications", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	createResp := doWithKey(t, http.MethodPost, server.URL+"/settings/notifications", "", []byte(`{"name":"ops","type":"webhook","config":{"url":"`+receiver.URL+`"}}`))
+	defer createResp.Body.Close()
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+	var created notificationChannelRecord
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&created))
+	require.Equal(t, "webhook", created.Type)
+	require.Empty(t, created.Config)
+	require.Equal(t, []string{"url"}, created.SecretKeys)
+
+	stored, err := mem.GetNotificationChannel(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, stored.SecretsEnc)
+	require.NotContains(t, stored.SecretsEnc, receiver.URL)
+
+	testResp := doWithKey(t, http.MethodPost, server.URL+"/settings/notifications/"+created.ID+"/test", "", nil)
+	testResp.Body.Close()
+	require.Equal(t, http.StatusOK, testResp.StatusCode)
+	require.Len(t, received, 1)
+	require.Equal(t, "Gavryn test notification", received[0].Title)
+
+	// Updating without the secret keeps the stored URL.
+	updateResp := doWithKey(t, http.MethodPut, server.URL+"/settings/notifications/"+created.ID, "", []byte(`{"name":"renamed","config":{},"enabled":false}`))
+	defer updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+	var updated notificationChannelRecord
+	require.NoError(t, json.NewDecoder(updateResp.Body).Decode(&updated))
+	require.Equal(t, "renamed", updated.Name)
+	require.False(t, updated.Enabled)
+	require.Equal(t, []string{"url"}, updated.SecretKeys)
+
+	listResp := doWithKey(t, http.MethodGet, server.URL+"/settings/notifications", "", nil)
+	defer listResp.Body.Close()
+	var listed notificationChannelsResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Len(t, listed.Channels, 1)
+	require.Contains(t, listed.Types, "smtp")
+
+	switchResp := doWithKey(t, http.MethodPut, server.URL+"/settings/notifications/"+created.ID, "", []byte(`{"type":"ntfy"}`))
+	switchResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, switchResp.StatusCode)
+
+	deleteResp := doWithKey(t, http.MethodDelete, server.URL+"/settings/notifications/"+created.ID, "", nil)
+	deleteResp.Body.Close()
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+	missing := doWithKey(t, http.MethodPost, server.URL+"/settings/notifications/"+created.ID+"/test", "", nil)
+	missing.Body.Close()
+	require.Equal(t, http.StatusNotFound, missing.StatusCode)
+}
+
+func TestAutomationNotificationPolicy(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	var received []notify.WebhookPayload
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload notify.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	api := NewServer(mem, &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	createResp := doWithKey(t, http.MethodPost, server.URL+"/settings/notifications", "", []byte(`{"type":"webhook","config":{"url":"`+receiver.URL+`"}}`))
+	defer createResp.Body.Close()
+	var channel notificationChannelRecord
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&channel))
+
+	badResp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Brief","prompt":"Summarize","notification_channels":["missing"]}`))
+	badResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, badResp.StatusCode)
+	badResp = doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Brief","prompt":"Summarize","notify_on":"sometimes"}`))
+	badResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, badResp.StatusCode)
+
+	automationResp := doWithKey(t, http.MethodPost, server.URL+"/automations", "", []byte(`{"name":"Brief","prompt":"Summarize","notification_channels":["`+channel.ID+`"],"notify_on":"change"}`))
+	defer automationResp.Body.Close()
+	require.Equal(t, http.StatusCreated, automationResp.StatusCode)
+	var schedule automationSchedule
+	require.NoError(t, json.NewDecoder(automationResp.Body).Decode(&schedule))
+	require.Equal(t, []string{channel.ID}, schedule.NotificationChannels)
+	require.Equal(t, notifyOnChange, schedule.NotifyOn)
+	require.Equal(t, defaultChangeThreshold, schedule.ChangeThreshold)
+
+	automation, err := mem.GetAutomation(ctx, schedule.ID)
+	require.NoError(t, err)
+	previous := &store.AutomationInboxEntry{ID: "i1", Status: "completed", FinalResponse: "All quiet."}
+	same := store.AutomationInboxEntry{ID: "i2", Status: "completed", FinalResponse: "All quiet."}
+	applyAutomationChange(&same, previous, automation.ChangeThreshold)
+	require.NoError(t, api.notifyAutomationCompletion(ctx, *automation, same, previous))
+	require.Empty(t, received)
+	changed := store.AutomationInboxEntry{ID: "i2", Status: "completed", FinalResponse: "Outage detected."}
+	applyAutomationChange(&changed, previous, automation.ChangeThreshold)
+	require.NoError(t, api.notifyAutomationCompletion(ctx, *automation, changed, previous))
+	require.Len(t, received, 1)
+	require.Equal(t, "Gavryn automation completed: Brief", received[0].Title)
+	require.Equal(t, "Outage detected.", received[0].Body)
+	require.Equal(t, "Change", received[0].Fields[len(received[0].Fields)-1].Name)
+
+	updateResp := doWithKey(t, http.MethodPut, server.URL+"/automations/"+schedule.ID, "", []byte(`{"notify_on":"failure","notification_channels":[]}`))
+	defer updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+	var updated automationSchedule
+	require.NoError(t, json.NewDecoder(updateResp.Body).Decode(&updated))
+	require.Empty(t, updated.NotificationChannels)
+	require.Equal(t, notifyOnFailure, updated.NotifyOn)
+}
+
+func TestShouldNotifyAutomation(t *testing.T) {
+	completed := store.AutomationInboxEntry{Status: "completed", FinalResponse: "same"}
+	unchanged := store.AutomationInboxEntry{Status: "completed", FinalResponse: "same", Unchanged: true}
+	failed := store.AutomationInboxEntry{Status: "failed", Error: "boom"}
+
+	require.True(t, shouldNotifyAutomation("", completed, nil))
+	require.True(t, shouldNotifyAutomation(notifyOnAlways, completed, &completed))
+	require.False(t, shouldNotifyAutomation(notifyOnAlways, unchanged, &completed))
+	require.False(t, shouldNotifyAutomation("", unchanged, &completed))
+	require.True(t, shouldNotifyAutomation(notifyOnAlways, unchanged, &failed))
+	require.True(t, shouldNotifyAutomation(notifyOnAlways, failed, &failed))
+	require.False(t, shouldNotifyAutomation(notifyOnFailure, completed, nil))
+	require.True(t, shouldNotifyAutomation(notifyOnFailure, failed, nil))
+	require.True(t, shouldNotifyAutomation(notifyOnChange, completed, nil))
+	require.True(t, shouldNotifyAutomation(notifyOnChange, completed, &completed))
+	require.False(t, shouldNotifyAutomation(notifyOnChange, unchanged, &completed))
+	require.True(t, shouldNotifyAutomation(notifyOnChange, unchanged, &failed))
+	require.True(t, shouldNotifyAutomation(notifyOnChange, failed, &completed))
+	require.False(t, shouldNotifyAutomation(notifyOnChange, failed, &failed))
+
+	entries := []store.AutomationInboxEntry{
+		{ID: "new", Status: "running"},
+		{ID: "b", CompletedAt: "2026-01-02T00:00:00Z"},
+		{ID: "a", CompletedAt: "2026-01-01T00:00:00Z"},
+	}
+	previous := previousCompletedInboxEntry(entries, "new")
+	require.NotNil(t, previous)
+	require.Equal(t, "b", previous.ID)
+	require.Nil(t, previousCompletedInboxEntry(entries[:1], "new"))
+}
@@ -75,6 +75,7 @@ func (s *Server) updatePersonalitySettings(w http.ResponseWriter, r *http.Reques
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, "", current, settings)
 	response := personalitySettingsResponse{
 		Content:   settings.Content,
 		Source:    "stored",
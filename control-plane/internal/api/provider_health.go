@@ -0,0 +1,52 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+)
+
+// observeProviderHealth mirrors the worker's breaker state from the model
+// request events it reports, so /ready can show provider health.
+func (s *Server) observeProviderHealth(eventType string, payload map[string]any) {
+	if s.providerHealth == nil || payload == nil {
+		return
+	}
+	provider := strings.TrimSpace(toStringValue(payload["provider"]))
+	if provider == "" {
+		return
+	}
+	model := strings.TrimSpace(toStringValue(payload["model"]))
+	switch eventType {
+	case "model.request.completed":
+		s.providerHealth.RecordSuccess(provider, model)
+	case "model.request.failed":
+		if value, ok := payload["retryable"]; ok && !toBoolValue(value) {
+			return
+		}
+		retryAfter := time.Duration(toIntValue(payload["retry_after_ms"])) * time.Millisecond
+		s.providerHealth.RecordFailure(provider, model, toStringValue(payload["error"]), retryAfter)
+	}
+}
+
+func (s *Server) providerHealthStatus() subsystemStatus {
+	snapshot := s.providerHealth.Snapshot()
+	status := subsystemStatus{Status: "ok"}
+	open := 0
+	for _, entry := range snapshot {
+		if entry.State == llm.BreakerOpen {
+			open++
+		}
+	}
+	if open > 0 {
+		status.Status = "degraded"
+		if open == len(snapshot) {
+			status.Error = "all tracked providers have open circuit breakers"
+		}
+	}
+	if len(snapshot) > 0 {
+		status.Details = map[string]any{"providers": snapshot}
+	}
+	return status
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/redaction"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+type redactionRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+type redactionPolicy struct {
+	PolicyProfile string          `json:"policy_profile"`
+	VaultSecrets  bool            `json:"vault_secrets"`
+	KeyFormats    bool            `json:"key_formats"`
+	Emails        bool            `json:"emails"`
+	CustomRules   []redactionRule `json:"custom_rules"`
+	CreatedAt     string          `json:"created_at,omitempty"`
+	UpdatedAt     string          `json:"updated_at,omitempty"`
+}
+
+type redactionPolicyRequest struct {
+	VaultSecrets *bool           `json:"vault_secrets"`
+	KeyFormats   *bool           `json:"key_formats"`
+	Emails       *bool           `json:"emails"`
+	CustomRules  []redactionRule `json:"custom_rules"`
+}
+
+type redactionPoliciesResponse struct {
+	Defaults redactionPolicy   `json:"defaults"`
+	Policies []redactionPolicy `json:"policies"`
+}
+
+func toRedactionPolicyRecord(policy store.RedactionPolicy) redactionPolicy {
+	rules := make([]redactionRule, 0, len(policy.CustomRules))
+	for _, rule := range policy.CustomRules {
+		rules = append(rules, redactionRule{Name: rule.Name, Pattern: rule.Pattern})
+	}
+	return redactionPolicy{
+		PolicyProfile: policy.PolicyProfile,
+		VaultSecrets:  policy.VaultSecrets,
+		KeyFormats:    policy.KeyFormats,
+		Emails:        policy.Emails,
+		CustomRules:   rules,
+		CreatedAt:     policy.CreatedAt,
+		UpdatedAt:     policy.UpdatedAt,
+	}
+}
+
+// redactionCacheTTL bounds how long a compiled pipeline is reused, so policy
+// and vault changes made on another replica take effect.
+const redactionCacheTTL = 30 * time.Second
+
+// redactionCache holds compiled pipelines by workspace and profile, so
+// events don't load the policy and decrypt the vault each time. Its
+// generation moves on every invalidation, so a pipeline compiled from data
+// that changed meanwhile is not kept.
+type redactionCache struct {
+	mu         sync.Mutex
+	pipelines  map[string]cachedRedactionPipeline
+	generation uint64
+}
+
+type cachedRedactionPipeline struct {
+	pipeline  *redaction.Pipeline
+	expiresAt time.Time
+}
+
+func newRedactionCache() *redactionCache {
+	return &redactionCache{pipelines: map[string]cachedRedactionPipeline{}}
+}
+
+// get returns the pipeline cached under key, if any, and the generation to
+// pass to put.
+func (c *redactionCache) get(key string, now time.Time) (*redaction.Pipeline, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.pipelines[key]; ok && now.Before(cached.expiresAt) {
+		return cached.pipeline, c.generation
+	}
+	return nil, c.generation
+}
+
+func (c *redactionCache) put(key string, generation uint64, pipeline *redaction.Pipeline, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation == c.generation {
+		c.pipelines[key] = cachedRedactionPipeline{pipeline: pipeline, expiresAt: now.Add(redactionCacheTTL)}
+	}
+}
+
+// invalidate drops every pipeline after a redaction policy or vault secret
+// changes.
+func (c *redactionCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.pipelines)
+}
+
+// redactionPipeline never returns nil: lookup failures fall back to the
+// built-in defaults so a store hiccup does not let values through.
+func (s *Server) redactionPipeline(ctx context.Context, policyProfile string) *redaction.Pipeline {
+	key := store.WorkspaceID(ctx) + "/" + strings.TrimSpace(policyProfile)
+	now := time.Now()
+	cached, generation := s.redactions.get(key, now)
+	if cached != nil {
+		return cached
+	}
+
+	policy, err := redaction.EffectivePolicy(ctx, s.store, policyProfile)
+	if err != nil {
+		policy = redaction.DefaultPolicy(policyProfile)
+	}
+	var secretValues map[string]string
+	if policy.VaultSecrets {
+		secretValues = s.vaultSecretValues(ctx)
+	}
+	pipeline, compileErr := redaction.FromPolicy(policy, secretValues)
+	if compileErr != nil {
+		// Rules are validated when a policy is saved; one stored some other
+		// way still redacts with the built-in detectors.
+		log.Printf("redaction custom rules ignored policy_profile=%s err=%v", policy.PolicyProfile, compileErr)
+		policy.CustomRules = nil
+		pipeline, _ = redaction.FromPolicy(policy, secretValues)
+	}
+	if err == nil {
+		s.redactions.put(key, generation, pipeline, now)
+	}
+	return pipeline
+}
+
+func (s *Server) runRedactionPipeline(ctx context.Context, runID string) *redaction.Pipeline {
+	policyProfile := redaction.DefaultPolicyProfile
+	if run, err := s.store.GetRun(ctx, runID); err == nil && run != nil && strings.TrimSpace(run.PolicyProfile) != "" {
+		policyProfile = run.PolicyProfile
+	}
+	return s.redactionPipeline(ctx, policyProfile)
+}
+
+// vaultSecretValues decrypts every vault secret; secrets that cannot be
+// decrypted are skipped.
+func (s *Server) vaultSecretValues(ctx context.Context) map[string]string {
+	if s.cfg.LLMSecretsKey == "" {
+		return nil
+	}
+	stored, err := s.store.ListSecrets(ctx)
+	if err != nil || len(stored) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(stored))
+	for _, secret := range stored {
+		value, err := s.decryptStoredSecret(secret.ValueEnc)
+		if err != nil {
+			continue
+		}
+		values[secret.Name] = value
+	}
+	return values
+}
+
+func (s *Server) listRedactionPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := s.store.ListRedactionPolicies(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]redactionPolicy, 0, len(policies))
+	for _, policy := range policies {
+		result = append(result, toRedactionPolicyRecord(policy))
+	}
+	writeJSONStatus(w, redactionPoliciesResponse{
+		Defaults: toRedactionPolicyRecord(redaction.DefaultPolicy(redaction.DefaultPolicyProfile)),
+		Policies: result,
+	}, http.StatusOK)
+}
+
+func (s *Server) upsertRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	profile := strings.TrimSpace(chi.URLParam(r, "profile"))
+	if profile == "" {
+		http.Error(w, "policy profile is required", http.StatusBadRequest)
+		return
+	}
+	req := redactionPolicyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetRedactionPolicy(r.Context(), profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	policy := redaction.DefaultPolicy(profile)
+	policy.CreatedAt = now
+	if current != nil {
+		policy = *current
+	}
+	if req.VaultSecrets != nil {
+		policy.VaultSecrets = *req.VaultSecrets
+	}
+	if req.KeyFormats != nil {
+		policy.KeyFormats = *req.KeyFormats
+	}
+	if req.Emails != nil {
+		policy.Emails = *req.Emails
+	}
+	if req.CustomRules != nil {
+		rules := make([]store.RedactionRule, 0, len(req.CustomRules))
+		for _, rule := range req.CustomRules {
+			rules = append(rules, store.RedactionRule{
+				Name:    strings.TrimSpace(rule.Name),
+				Pattern: strings.TrimSpace(rule.Pattern),
+			})
+		}
+		if _, err := redaction.CompileRules(rules); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		policy.CustomRules = rules
+	}
+	policy.UpdatedAt = now
+	if err := s.store.UpsertRedactionPolicy(r.Context(), policy); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.redactions.invalidate()
+	recordAuditChange(r, profile, current, policy)
+	writeJSONStatus(w, toRedactionPolicyRecord(policy), http.StatusOK)
+}
+
+func (s *Server) deleteRedactionPolicy(w http.ResponseWriter, r *http.Request) {
+	profile := strings.TrimSpace(chi.URLParam(r, "profile"))
+	current, err := s.store.GetRedactionPolicy(r.Context(), profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "redaction policy not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteRedactionPolicy(r.Context(), profile); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.redactions.invalidate()
+	recordAuditChange(r, profile, current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func putRedactionPolicy(t *testing.T, url string, profile string, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut, url+"/settings/redaction/"+profile, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestIngestEventRedactsPayload(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-1", Status: "running", PolicyProfile: "ops"}))
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	server := newTestServer(t, mem, brokerMock, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	secretResp, err := http.Post(server.URL+"/secrets", "application/json", bytes.NewReader([]byte(`{"name":"DEPLOY_TOKEN","value":"dpl-0987654321"}`)))
+	require.NoError(t, err)
+	secretResp.Body.Close()
+	require.Equal(t, http.StatusCreated, secretResp.StatusCode)
+
+	policyResp := putRedactionPolicy(t, server.URL, "ops", `{"emails":true,"custom_rules":[{"name":"ticket","pattern":"TCK-[0-9]+"}]}`)
+	policyResp.Body.Close()
+	require.Equal(t, http.StatusOK, policyResp.StatusCode)
+
+	githubToken := "ghp_" + strings.Repeat("b", 36)
+	body, err := json.Marshal(map[string]any{
+		"type":   "tool.completed",
+		"source": "tool",
+		"payload": map[string]any{
+			"tool_name": "process.exec",
+			"output": map[string]any{
+				"stdout": "deploy with dpl-0987654321 and " + githubToken,
+				"stderr": "notify ops@example.com about TCK-123",
+			},
+		},
+	})
+	require.NoError(t, err)
+	resp, err := http.Post(server.URL+"/runs/run-1/events", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	stored, err := mem.ListEvents(ctx, "run-1", 0)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	output := stored[0].Payload["output"].(map[string]any)
+	require.Equal(t, "deploy with [secret:DEPLOY_TOKEN] and [redacted:github_token]", output["stdout"])
+	require.Equal(t, "notify [redacted:email] about [redacted:ticket]", output["stderr"])
+	require.ElementsMatch(t, []store.RedactionMarker{
+		{Detector: "vault_secret", Label: "DEPLOY_TOKEN", Path: "output.stdout", Count: 1},
+		{Detector: "key_format", Label: "github_token", Path: "output.stdout", Count: 1},
+		{Detector: "email", Label: "email", Path: "output.stderr", Count: 1},
+		{Detector: "custom", Label: "ticket", Path: "output.stderr", Count: 1},
+	}, stored[0].RedactionMarkers)
+}
+
+func TestAddMessageRedactsContent(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-1", Status: "running"}))
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	server := newTestServer(t, mem, brokerMock, nil, config.Config{})
+	defer server.Close()
+
+	token := "sk-proj-" + strings.Repeat("c", 24)
+	resp, err := http.Post(server.URL+"/runs/run-1/messages", "application/json", strings.NewReader(`{"role":"assistant","content":"use `+token+` for ops@example.com"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	messages, err := mem.ListMessages(ctx, "run-1")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	// Emails are only redacted when a policy enables them.
+	require.Equal(t, "use [redacted:openai_key] for ops@example.com", messages[0].Content)
+	require.Equal(t, map[string]int{"key_format:openai_key": 1}, messages[0].Metadata["redactions"])
+
+	stored, err := mem.ListEvents(ctx, "run-1", 0)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	require.Equal(t, messages[0].Content, stored[0].Payload["content"])
+	require.Equal(t, []store.RedactionMarker{{Detector: "key_format", Label: "openai_key", Path: "content", Count: 1}}, stored[0].RedactionMarkers)
+}
+
+func TestRedactionPolicySettings(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	invalid := putRedactionPolicy(t, server.URL, "ops", `{"custom_rules":[{"name":"bad","pattern":"("}]}`)
+	invalid.Body.Close()
+	require.Equal(t, http.StatusBadRequest, invalid.StatusCode)
+
+	created := putRedactionPolicy(t, server.URL, "ops", `{"key_formats":false}`)
+	defer created.Body.Close()
+	require.Equal(t, http.StatusOK, created.StatusCode)
+	var policy redactionPolicy
+	require.NoError(t, json.NewDecoder(created.Body).Decode(&policy))
+	require.True(t, policy.VaultSecrets)
+	require.False(t, policy.KeyFormats)
+	require.Empty(t, policy.CustomRules)
+
+	listResp, err := http.Get(server.URL + "/settings/redaction")
+	require.NoError(t, err)
+	defer listResp.Body.Close()
+	var listed redactionPoliciesResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.True(t, listed.Defaults.KeyFormats)
+	require.Len(t, listed.Policies, 1)
+	require.Equal(t, "ops", listed.Policies[0].PolicyProfile)
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL+"/settings/redaction/ops", nil)
+	require.NoError(t, err)
+	deleteResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	deleteResp.Body.Close()
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodDelete, server.URL+"/settings/redaction/ops", nil)
+	require.NoError(t, err)
+	missingResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	missingResp.Body.Close()
+	require.Equal(t, http.StatusNotFound, missingResp.StatusCode)
+}
+
+// countingSecretsStore counts vault reads, which every uncached redaction
+// pipeline makes.
+type countingSecretsStore struct {
+	store.Store
+	listSecrets atomic.Int32
+}
+
+func (s *countingSecretsStore) ListSecrets(ctx context.Context) ([]store.Secret, error) {
+	s.listSecrets.Add(1)
+	return s.Store.ListSecrets(ctx)
+}
+
+func TestRedactionPipelineCache(t *testing.T) {
+	ctx := context.Background()
+	counting := &countingSecretsStore{Store: memory.New()}
+	api := NewServer(counting, &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	secretResp, err := http.Post(server.URL+"/secrets", "application/json", bytes.NewReader([]byte(`{"name":"DEPLOY_TOKEN","value":"dpl-0987654321"}`)))
+	require.NoError(t, err)
+	defer secretResp.Body.Close()
+	var secret vaultSecret
+	require.NoError(t, json.NewDecoder(secretResp.Body).Decode(&secret))
+	redact := func(text string) string {
+		redacted, _ := api.redactionPipeline(ctx, "ops").Text("", text)
+		return redacted
+	}
+
+	before := counting.listSecrets.Load()
+	require.Equal(t, "[secret:DEPLOY_TOKEN] ops@example.com", redact("dpl-0987654321 ops@example.com"))
+	require.Equal(t, "[secret:DEPLOY_TOKEN]", redact("dpl-0987654321"))
+	require.Equal(t, before+1, counting.listSecrets.Load())
+
+	// Vault and policy writes take effect on the next event.
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/secrets/"+secret.ID, strings.NewReader(`{"value":"dpl-1111111111"}`))
+	require.NoError(t, err)
+	updateResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+	require.Equal(t, "[secret:DEPLOY_TOKEN]", redact("dpl-1111111111"))
+
+	policyResp := putRedactionPolicy(t, server.URL, "ops", `{"emails":true}`)
+	policyResp.Body.Close()
+	require.Equal(t, http.StatusOK, policyResp.StatusCode)
+	require.Equal(t, "[redacted:email]", redact("ops@example.com"))
+
+	// Another workspace has its own vault and policies.
+	teamRedacted, _ := api.redactionPipeline(store.WithWorkspace(ctx, "team"), "ops").Text("", "dpl-1111111111 ops@example.com")
+	require.Equal(t, "dpl-1111111111 ops@example.com", teamRedacted)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -24,6 +25,8 @@ type runSummaryResponse struct {
 	ModelRoute       string   `json:"model_route,omitempty"`
 	Tags             []string `json:"tags,omitempty"`
 	Title            string   `json:"title"`
+	UserID           string   `json:"user_id,omitempty"`
+	CreatedBy        string   `json:"created_by,omitempty"`
 	CreatedAt        string   `json:"created_at"`
 	UpdatedAt        string   `json:"updated_at"`
 	MessageCount     int64    `json:"message_count"`
@@ -51,6 +54,13 @@ type listRunStepsResponse struct {
 }
 
 func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
+	// mine=true narrows the list to runs started by the caller's user.
+	mineOnly := r.URL.Query().Get("mine") == "true"
+	userID := requestUserID(r.Context())
+	if mineOnly && userID == "" {
+		http.Error(w, "mine requires an api key bound to a user", http.StatusBadRequest)
+		return
+	}
 	runs, err := s.store.ListRuns(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -58,6 +68,9 @@ func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
 	}
 	response := listRunsResponse{Runs: make([]runSummaryResponse, 0, len(runs))}
 	for _, run := range runs {
+		if mineOnly && run.UserID != userID {
+			continue
+		}
 		response.Runs = append(response.Runs, runSummaryResponse{
 			ID:               run.ID,
 			Status:           run.Status,
@@ -69,6 +82,8 @@ func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
 			ModelRoute:       run.ModelRoute,
 			Tags:             run.Tags,
 			Title:            run.Title,
+			UserID:           run.UserID,
+			CreatedBy:        run.CreatedBy,
 			CreatedAt:        run.CreatedAt,
 			UpdatedAt:        run.UpdatedAt,
 			MessageCount:     run.MessageCount,
@@ -105,6 +120,8 @@ func (s *Server) getRun(w http.ResponseWriter, r *http.Request) {
 			ModelRoute:       run.ModelRoute,
 			Tags:             run.Tags,
 			Title:            run.Title,
+			UserID:           run.UserID,
+			CreatedBy:        run.CreatedBy,
 			CreatedAt:        run.CreatedAt,
 			UpdatedAt:        run.UpdatedAt,
 			MessageCount:     run.MessageCount,
@@ -176,42 +193,15 @@ func (s *Server) resumeRun(w http.ResponseWriter, r *http.Request) {
 	if !s.ensureLLMConfigured(w, r.Context()) {
 		return
 	}
-
-	runs, err := s.store.ListRuns(r.Context())
+	run, err := s.findRun(r.Context(), runID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	var summary *runSummaryResponse
-	for _, run := range runs {
-		if run.ID != runID {
-			continue
-		}
-		summary = &runSummaryResponse{
-			ID:               run.ID,
-			Status:           run.Status,
-			Phase:            run.Phase,
-			CompletionReason: run.CompletionReason,
-			ResumedFrom:      run.ResumedFrom,
-			CheckpointSeq:    run.CheckpointSeq,
-			PolicyProfile:    run.PolicyProfile,
-			ModelRoute:       run.ModelRoute,
-			Tags:             run.Tags,
-			Title:            run.Title,
-			CreatedAt:        run.CreatedAt,
-			UpdatedAt:        run.UpdatedAt,
-			MessageCount:     run.MessageCount,
-		}
-		break
-	}
-	if summary == nil {
+	if run == nil {
 		http.Error(w, "run not found", http.StatusNotFound)
 		return
 	}
-	if strings.EqualFold(summary.Status, "running") {
-		http.Error(w, "run is already running", http.StatusConflict)
-		return
-	}
 
 	req := resumeRunRequest{}
 	if r.Body != nil {
@@ -221,34 +211,63 @@ func (s *Server) resumeRun(w http.ResponseWriter, r *http.Request) {
 	if messageContent == "" {
 		messageContent = "Continue from the latest checkpoint and complete the task."
 	}
+	if status, err := s.reopenRun(r.Context(), *run, messageContent, map[string]any{"resume": true}); err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"run_id": runID,
+		"status": "running",
+	})
+}
+
+// findRun returns the run with runID, or nil when it does not exist.
+func (s *Server) findRun(ctx context.Context, runID string) (*store.RunSummary, error) {
+	runs, err := s.store.ListRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.ID == runID {
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
+
+// reopenRun adds a user message to a run that is not running and resumes
+// its workflow from the latest checkpoint. On failure it returns the HTTP
+// status to report.
+func (s *Server) reopenRun(ctx context.Context, run store.RunSummary, content string, metadata map[string]any) (int, error) {
+	if strings.EqualFold(run.Status, "running") {
+		return http.StatusConflict, errors.New("run is already running")
+	}
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 	message := store.Message{
 		ID:        uuid.New().String(),
-		RunID:     runID,
+		RunID:     run.ID,
 		Role:      "user",
-		Content:   messageContent,
+		Content:   content,
 		Sequence:  time.Now().UnixNano(),
 		CreatedAt: now,
-		Metadata: map[string]any{
-			"resume": true,
-		},
+		Metadata:  metadata,
 	}
-	if err := s.store.AddMessage(r.Context(), message); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if err := s.store.AddMessage(ctx, message); err != nil {
+		return http.StatusInternalServerError, err
 	}
-	s.indexMessageMemory(r.Context(), message)
+	s.indexMessageMemory(ctx, message)
 
 	if s.workflows != nil {
-		if err := s.workflows.ResumeRun(r.Context(), runID, messageContent); err != nil {
-			http.Error(w, err.Error(), http.StatusBadGateway)
-			return
+		if err := s.workflows.ResumeRun(ctx, run.ID, content); err != nil {
+			return http.StatusBadGateway, err
 		}
 	}
 
-	seq, _ := s.store.NextSeq(r.Context(), runID)
+	seq, _ := s.store.NextSeq(ctx, run.ID)
 	event := store.RunEvent{
-		RunID:     runID,
+		RunID:     run.ID,
 		Seq:       seq,
 		Type:      "run.resumed",
 		Timestamp: now,
@@ -260,13 +279,8 @@ func (s *Server) resumeRun(w http.ResponseWriter, r *http.Request) {
 			"phase":      "planning",
 		},
 	}
-	_ = s.store.AppendEvent(r.Context(), event)
-	_ = s.upsertArtifactsFromEvent(r.Context(), event)
-	s.broker.Publish(toEvent(event))
-
-	w.WriteHeader(http.StatusAccepted)
-	_ = json.NewEncoder(w).Encode(map[string]string{
-		"run_id": runID,
-		"status": "running",
-	})
+	_ = s.store.AppendEvent(ctx, event)
+	_ = s.upsertArtifactsFromEvent(ctx, event)
+	s.publishEvent(ctx, event)
+	return http.StatusAccepted, nil
 }
@@ -210,6 +210,7 @@ func (s *Server) upsertArtifactsFromEvent(ctx context.Context, event store.RunEv
 		if err := s.store.UpsertArtifact(ctx, artifact); err != nil {
 			return err
 		}
+		s.enqueueWebhookDeliveries(ctx, event, "artifact.created", artifactWebhookData(artifact))
 	}
 	return nil
 }
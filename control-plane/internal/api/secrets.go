@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/secrets"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+// Secret names are referenced from tool inputs as {{secret:NAME}}.
+var secretNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+type vaultSecret struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	PolicyProfiles []string `json:"policy_profiles"`
+	CreatedAt      string   `json:"created_at"`
+	UpdatedAt      string   `json:"updated_at"`
+}
+
+type vaultSecretRequest struct {
+	Name           string   `json:"name"`
+	Description    *string  `json:"description"`
+	Value          string   `json:"value"`
+	PolicyProfiles []string `json:"policy_profiles"`
+}
+
+type vaultSecretsResponse struct {
+	Secrets []vaultSecret `json:"secrets"`
+}
+
+type secretRotationFailure struct {
+	Table string `json:"table"`
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+type secretRotationResponse struct {
+	PrimaryKeyID string                  `json:"primary_key_id"`
+	Rotated      int                     `json:"rotated"`
+	Unchanged    int                     `json:"unchanged"`
+	Failed       []secretRotationFailure `json:"failed"`
+}
+
+func (s *Server) secretsKeyring() (*secrets.Keyring, error) {
+	return secrets.ParseKeyring(s.cfg.LLMSecretsKey, s.cfg.LLMSecretsPreviousKeys)
+}
+
+// encryptStoredSecret encrypts with the primary key. The returned status is the
+// HTTP status to report when err is non-nil.
+func (s *Server) encryptStoredSecret(plaintext string) (string, int, error) {
+	key, err := secrets.ParseKey(s.cfg.LLMSecretsKey)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	ciphertext, err := encryptLLMSecret(key, plaintext)
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	return ciphertext, http.StatusOK, nil
+}
+
+// decryptStoredSecret decrypts a stored ciphertext with whichever configured
+// key (primary or previous) produced it.
+func (s *Server) decryptStoredSecret(ciphertext string) (string, error) {
+	keyring, err := s.secretsKeyring()
+	if err != nil {
+		return "", err
+	}
+	return keyring.Decrypt(ciphertext)
+}
+
+func (s *Server) rotateSecrets(w http.ResponseWriter, r *http.Request) {
+	keyring, err := s.secretsKeyring()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// A workspace-bound key rotates its own workspace; unbound callers rotate
+	// every workspace, since the keyring is shared by the whole instance.
+	workspaceIDs := []string{store.WorkspaceID(r.Context())}
+	if unboundCaller(r.Context()) {
+		workspaces, err := s.store.ListWorkspaces(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		workspaceIDs = workspaceIDs[:0]
+		for _, workspace := range workspaces {
+			workspaceIDs = append(workspaceIDs, workspace.ID)
+		}
+	}
+	response := secretRotationResponse{PrimaryKeyID: keyring.PrimaryID(), Failed: []secretRotationFailure{}}
+	for _, workspaceID := range workspaceIDs {
+		report, err := s.store.RotateSecrets(store.WithWorkspace(r.Context(), workspaceID), keyring.Rotate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Rotated += report.Rotated
+		response.Unchanged += report.Unchanged
+		for _, failure := range report.Failed {
+			response.Failed = append(response.Failed, secretRotationFailure{Table: failure.Table, ID: failure.ID, Error: failure.Error})
+		}
+	}
+	writeJSONStatus(w, response, http.StatusOK)
+}
+
+func toVaultSecretRecord(secret store.Secret) vaultSecret {
+	return vaultSecret{
+		ID:             secret.ID,
+		Name:           secret.Name,
+		Description:    secret.Description,
+		PolicyProfiles: nonNilStrings(secret.PolicyProfiles),
+		CreatedAt:      secret.CreatedAt,
+		UpdatedAt:      secret.UpdatedAt,
+	}
+}
+
+func normalizeSecretName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+	if !secretNameRE.MatchString(name) {
+		return "", errors.New("name must start with a letter or '_' and may only contain letters, digits, '.', '_' and '-'")
+	}
+	return name, nil
+}
+
+func (s *Server) findSecretByName(ctx context.Context, name string) (*store.Secret, error) {
+	all, err := s.store.ListSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range all {
+		if secret.Name == name {
+			found := secret
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) listSecrets(w http.ResponseWriter, r *http.Request) {
+	all, err := s.store.ListSecrets(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]vaultSecret, 0, len(all))
+	for _, secret := range all {
+		result = append(result, toVaultSecretRecord(secret))
+	}
+	writeJSONStatus(w, vaultSecretsResponse{Secrets: result}, http.StatusOK)
+}
+
+func (s *Server) createSecret(w http.ResponseWriter, r *http.Request) {
+	req := vaultSecretRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	name, err := normalizeSecretName(req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Value == "" {
+		http.Error(w, "value is required", http.StatusBadRequest)
+		return
+	}
+	existing, err := s.findSecretByName(r.Context(), name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		http.Error(w, "secret name already exists", http.StatusConflict)
+		return
+	}
+	valueEnc, status, err := s.encryptStoredSecret(req.Value)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	secret := store.Secret{
+		ID:             uuid.NewString(),
+		Name:           name,
+		ValueEnc:       valueEnc,
+		PolicyProfiles: normalizeRouteCriteria(req.PolicyProfiles, false),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if req.Description != nil {
+		secret.Description = strings.TrimSpace(*req.Description)
+	}
+	if err := s.store.CreateSecret(r.Context(), secret); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.redactions.invalidate()
+	recordAuditChange(r, secret.ID, nil, secret)
+	writeJSONStatus(w, toVaultSecretRecord(secret), http.StatusCreated)
+}
+
+func (s *Server) updateSecret(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		http.Error(w, "secret id is required", http.StatusBadRequest)
+		return
+	}
+	req := vaultSecretRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetSecret(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "secret not found", http.StatusNotFound)
+		return
+	}
+	updated := *current
+	if strings.TrimSpace(req.Name) != "" {
+		name, err := normalizeSecretName(req.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if name != current.Name {
+			existing, err := s.findSecretByName(r.Context(), name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				http.Error(w, "secret name already exists", http.StatusConflict)
+				return
+			}
+		}
+		updated.Name = name
+	}
+	if req.Description != nil {
+		updated.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Value != "" {
+		valueEnc, status, err := s.encryptStoredSecret(req.Value)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		updated.ValueEnc = valueEnc
+	}
+	if req.PolicyProfiles != nil {
+		updated.PolicyProfiles = normalizeRouteCriteria(req.PolicyProfiles, false)
+	}
+	updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.store.UpdateSecret(r.Context(), updated); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.redactions.invalidate()
+	recordAuditChange(r, id, current, updated)
+	writeJSONStatus(w, toVaultSecretRecord(updated), http.StatusOK)
+}
+
+func (s *Server) deleteSecret(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		http.Error(w, "secret id is required", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetSecret(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "secret not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteSecret(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.redactions.invalidate()
+	recordAuditChange(r, id, current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/secrets"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestRotateSecrets(t *testing.T) {
+	oldKey := strings.Repeat("o", 32)
+	newKey := strings.Repeat("n", 32)
+	oldCipher, err := secrets.Encrypt([]byte(oldKey), "sk-settings-1234")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	st := memory.New()
+	require.NoError(t, st.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", APIKeyEnc: oldCipher}))
+	require.NoError(t, st.CreateLLMProviderProfile(ctx, store.LLMProviderProfile{ID: "broken", Name: "broken", Provider: "openai", APIKeyEnc: "deadbeef:AAAA"}))
+
+	server := newTestServer(t, st, &MockBroker{}, nil, config.Config{LLMSecretsKey: newKey, LLMSecretsPreviousKeys: oldKey})
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/settings/secrets/rotate", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report secretRotationResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Equal(t, secrets.KeyID([]byte(newKey)), report.PrimaryKeyID)
+	require.Equal(t, 1, report.Rotated)
+	require.Len(t, report.Failed, 1)
+	require.Equal(t, "llm_provider_profiles", report.Failed[0].Table)
+	require.Equal(t, "broken", report.Failed[0].ID)
+
+	settings, err := st.GetLLMSettings(ctx)
+	require.NoError(t, err)
+	plain, err := secrets.Decrypt([]byte(newKey), settings.APIKeyEnc)
+	require.NoError(t, err)
+	require.Equal(t, "sk-settings-1234", plain)
+
+	again, err := http.Post(server.URL+"/settings/secrets/rotate", "application/json", nil)
+	require.NoError(t, err)
+	defer again.Body.Close()
+	report = secretRotationResponse{}
+	require.NoError(t, json.NewDecoder(again.Body).Decode(&report))
+	require.Equal(t, 0, report.Rotated)
+	require.Equal(t, 1, report.Unchanged)
+}
+
+func TestRotateSecretsRequiresKey(t *testing.T) {
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/settings/secrets/rotate", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestLLMSettingsDecryptsWithPreviousKey(t *testing.T) {
+	oldKey := strings.Repeat("o", 32)
+	oldCipher, err := secrets.Encrypt([]byte(oldKey), "sk-previous-5678")
+	require.NoError(t, err)
+	st := memory.New()
+	require.NoError(t, st.UpsertLLMSettings(context.Background(), store.LLMSettings{Provider: "openai", APIKeyEnc: oldCipher}))
+
+	server := newTestServer(t, st, &MockBroker{}, nil, config.Config{LLMSecretsKey: strings.Repeat("n", 32), LLMSecretsPreviousKeys: oldKey})
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/settings/llm")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var payload llmSettingsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+	require.Equal(t, "5678", payload.APIKeyHint)
+}
+
+func TestVaultSecretsCRUD(t *testing.T) {
+	st := memory.New()
+	server := newTestServer(t, st, &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	body := `{"name":"API_TOKEN","description":"internal API","value":"tok-123","policy_profiles":["ops"," ops ","admin"]}`
+	resp, err := http.Post(server.URL+"/secrets", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var raw map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&raw))
+	require.NotContains(t, raw, "value")
+	require.Equal(t, []any{"ops", "admin"}, raw["policy_profiles"])
+	id := raw["id"].(string)
+
+	stored, err := st.GetSecret(context.Background(), id)
+	require.NoError(t, err)
+	require.NotContains(t, stored.ValueEnc, "tok-123")
+	plain, err := secrets.Decrypt([]byte(testLLMSecretsKey), stored.ValueEnc)
+	require.NoError(t, err)
+	require.Equal(t, "tok-123", plain)
+
+	for _, invalid := range []string{
+		`{"name":"API_TOKEN","value":"x"}`,
+		`{"name":"bad name","value":"x"}`,
+		`{"name":"9LIVES","value":"x"}`,
+		`{"name":"EMPTY"}`,
+	} {
+		resp, err := http.Post(server.URL+"/secrets", "application/json", strings.NewReader(invalid))
+		require.NoError(t, err)
+		resp.Body.Close()
+		if strings.Contains(invalid, "API_TOKEN") {
+			require.Equal(t, http.StatusConflict, resp.StatusCode)
+		} else {
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, invalid)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/secrets/"+id, strings.NewReader(`{"policy_profiles":["*"]}`))
+	require.NoError(t, err)
+	updateResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+	updated, err := st.GetSecret(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"*"}, updated.PolicyProfiles)
+	require.Equal(t, stored.ValueEnc, updated.ValueEnc)
+
+	listResp, err := http.Get(server.URL + "/secrets")
+	require.NoError(t, err)
+	defer listResp.Body.Close()
+	var listed vaultSecretsResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Len(t, listed.Secrets, 1)
+	require.Equal(t, "internal API", listed.Secrets[0].Description)
+
+	req, err = http.NewRequest(http.MethodDelete, server.URL+"/secrets/"+id, nil)
+	require.NoError(t, err)
+	deleteResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	deleteResp.Body.Close()
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodDelete, server.URL+"/secrets/"+id, nil)
+	require.NoError(t, err)
+	missingResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	missingResp.Body.Close()
+	require.Equal(t, http.StatusNotFound, missingResp.StatusCode)
+}
@@ -18,16 +18,32 @@ import (
 
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/events"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/redaction"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
 )
 
 type Server struct {
-	store        store.Store
-	broker       Broker
-	workflows    WorkflowService
-	cfg          config.Config
-	httpClient   *http.Client
-	automationMu sync.Mutex
+	store          store.Store
+	broker         Broker
+	workflows      WorkflowService
+	cfg            config.Config
+	httpClient     *http.Client
+	feedClient     *http.Client
+	webhookClient  *http.Client
+	automationMu   sync.Mutex
+	automationJobs map[string]bool
+	// instanceID owns the automation job leases this process holds.
+	instanceID     string
+	feedPolls      map[string]bool
+	providerHealth *llm.HealthTracker
+	redactions     *redactionCache
+	signingSecrets map[string]string
+	signingNonces  *signing.NonceCache
+	webhookWake    chan struct{}
+	schedulerWake  chan struct{}
+	scheduler      automationSchedulerState
 }
 
 type Broker interface {
@@ -43,12 +59,25 @@ type WorkflowService interface {
 }
 
 func NewServer(store store.Store, broker Broker, workflows WorkflowService, cfg config.Config) *Server {
+	// EVENT_SIGNING_SECRETS is validated at startup; see cmd/control-plane.
+	signingSecrets, _ := signing.ParseSecrets(cfg.EventSigningSecrets)
 	return &Server{
-		store:      store,
-		broker:     broker,
-		workflows:  workflows,
-		cfg:        cfg,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		store:          store,
+		broker:         broker,
+		workflows:      workflows,
+		cfg:            cfg,
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+		feedClient:     newFeedClient(cfg.AutomationFeedsAllowPrivate),
+		webhookClient:  newWebhookClient(cfg.WebhooksAllowPrivate),
+		automationJobs: map[string]bool{},
+		instanceID:     uuid.New().String(),
+		feedPolls:      map[string]bool{},
+		providerHealth: llm.NewHealthTracker(llm.HealthConfig{}),
+		redactions:     newRedactionCache(),
+		signingSecrets: signingSecrets,
+		signingNonces:  signing.NewNonceCache(),
+		webhookWake:    make(chan struct{}, 1),
+		schedulerWake:  make(chan struct{}, 1),
 	}
 }
 
@@ -56,61 +85,137 @@ func (s *Server) Router() http.Handler {
 	r := chi.NewRouter()
 	r.Use(quietRequestLogger)
 	r.Use(middleware.Recoverer)
-	r.Use(corsMiddleware)
-
-	r.Post("/runs", s.createRun)
-	r.Get("/runs", s.listRuns)
-	r.Get("/runs/{id}", s.getRun)
-	r.Delete("/runs/{id}", s.deleteRun)
-	r.Post("/runs/{id}/messages", s.addMessage)
-	r.Post("/runs/{id}/resume", s.resumeRun)
-	r.Post("/runs/{id}/cancel", s.cancelRun)
-	r.Post("/runs/{id}/events", s.ingestEvent)
-	r.Get("/runs/{id}/events", s.streamEvents)
-	r.Post("/automation/execute", s.executeAutomationRun)
-	r.Get("/automations", s.listAutomations)
-	r.Post("/automations", s.createAutomation)
-	r.Put("/automations/{id}", s.updateAutomation)
-	r.Delete("/automations/{id}", s.deleteAutomation)
-	r.Get("/automations/{id}/inbox", s.getAutomationInbox)
-	r.Post("/automations/{id}/inbox/{entryID}/read", s.markAutomationInboxRead)
-	r.Post("/automations/{id}/inbox/read-all", s.markAutomationInboxReadAll)
-	r.Post("/automations/process-due", s.processDueAutomations)
-	r.Post("/automations/{id}/run", s.runAutomationNow)
-	r.Get("/runs/{id}/steps", s.listRunSteps)
-	r.Get("/runs/{id}/workspace", s.listWorkspace)
-	r.Get("/runs/{id}/workspace/tree", s.listWorkspaceTree)
-	r.Get("/runs/{id}/workspace/file", s.readWorkspaceFile)
-	r.Put("/runs/{id}/workspace/file", s.writeWorkspaceFile)
-	r.Delete("/runs/{id}/workspace/file", s.deleteWorkspaceFile)
-	r.Get("/runs/{id}/workspace/stat", s.statWorkspaceFile)
-	r.Post("/runs/{id}/processes/exec", s.execWorkspaceProcess)
-	r.Get("/runs/{id}/processes", s.listWorkspaceProcesses)
-	r.Post("/runs/{id}/processes/start", s.startWorkspaceProcess)
-	r.Get("/runs/{id}/processes/{pid}", s.getWorkspaceProcess)
-	r.Get("/runs/{id}/processes/{pid}/logs", s.getWorkspaceProcessLogs)
-	r.Post("/runs/{id}/processes/{pid}/stop", s.stopWorkspaceProcess)
-	r.Get("/runs/{id}/artifacts", s.listArtifacts)
-	r.Get("/settings/llm", s.getLLMSettings)
-	r.Post("/settings/llm", s.updateLLMSettings)
-	r.Post("/settings/llm/test", s.testLLMSettings)
-	r.Post("/settings/llm/models", s.listLLMModels)
-	r.Get("/settings/memory", s.getMemorySettings)
-	r.Post("/settings/memory", s.updateMemorySettings)
-	r.Get("/settings/personality", s.getPersonalitySettings)
-	r.Post("/settings/personality", s.updatePersonalitySettings)
-	r.Get("/skills", s.listSkills)
-	r.Post("/skills", s.createSkill)
-	r.Put("/skills/{id}", s.updateSkill)
-	r.Delete("/skills/{id}", s.deleteSkill)
-	r.Get("/skills/{id}/files", s.listSkillFiles)
-	r.Post("/skills/{id}/files", s.upsertSkillFiles)
-	r.Delete("/skills/{id}/files", s.deleteSkillFiles)
-	r.Get("/context", s.listContextNodes)
-	r.Post("/context/folders", s.createContextFolder)
-	r.Post("/context/files", s.uploadContextFile)
-	r.Get("/context/files/{id}", s.getContextFile)
-	r.Delete("/context/{id}", s.deleteContextNode)
+	r.Use(corsMiddleware(s.cfg.CORSAllowedOrigins))
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireScope(scopeRunsRead))
+		r.Get("/runs", s.listRuns)
+		r.Get("/runs/{id}", s.getRun)
+		r.Get("/runs/{id}/events", s.streamEvents)
+		r.Get("/runs/{id}/steps", s.listRunSteps)
+		r.Get("/runs/{id}/workspace", s.listWorkspace)
+		r.Get("/runs/{id}/workspace/tree", s.listWorkspaceTree)
+		r.Get("/runs/{id}/workspace/file", s.readWorkspaceFile)
+		r.Get("/runs/{id}/workspace/stat", s.statWorkspaceFile)
+		r.Get("/runs/{id}/processes", s.listWorkspaceProcesses)
+		r.Get("/runs/{id}/processes/{pid}", s.getWorkspaceProcess)
+		r.Get("/runs/{id}/processes/{pid}/logs", s.getWorkspaceProcessLogs)
+		r.Get("/runs/{id}/artifacts", s.listArtifacts)
+		r.Get("/automations", s.listAutomations)
+		r.Get("/automations/inbox", s.listInbox)
+		r.Get("/automations/{id}/inbox", s.getAutomationInbox)
+		r.Post("/automations/schedule/preview", s.previewAutomationSchedule)
+		r.Post("/automations/{id}/prompt/preview", s.previewAutomationPrompt)
+		r.Get("/skills", s.listSkills)
+		r.Get("/skills/{id}/files", s.listSkillFiles)
+		r.Get("/context", s.listContextNodes)
+		r.Get("/context/files/{id}", s.getContextFile)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireScope(scopeRunsWrite))
+		r.Use(s.auditRequests)
+		r.Post("/runs", s.createRun)
+		r.Delete("/runs/{id}", s.deleteRun)
+		r.Post("/runs/{id}/resume", s.resumeRun)
+		r.Post("/runs/{id}/cancel", s.cancelRun)
+		r.Put("/runs/{id}/workspace/file", s.writeWorkspaceFile)
+		r.Delete("/runs/{id}/workspace/file", s.deleteWorkspaceFile)
+		r.Post("/automation/execute", s.executeAutomationRun)
+		r.Post("/automations", s.createAutomation)
+		r.Put("/automations/{id}", s.updateAutomation)
+		r.Delete("/automations/{id}", s.deleteAutomation)
+		r.Post("/automations/{id}/inbox/{entryID}/read", s.markAutomationInboxRead)
+		r.Post("/automations/{id}/inbox/read-all", s.markAutomationInboxReadAll)
+		r.Post("/automations/{id}/inbox/{entryID}/rerun", s.rerunInboxEntry)
+		r.Post("/automations/{id}/inbox/{entryID}/archive", s.archiveInboxEntry)
+		r.Post("/automations/{id}/inbox/{entryID}/unarchive", s.unarchiveInboxEntry)
+		r.Post("/automations/{id}/inbox/{entryID}/continue", s.continueInboxEntry)
+		r.Post("/automations/process-due", s.processDueAutomations)
+		r.Post("/automations/{id}/run", s.runAutomationNow)
+		r.Post("/automations/{id}/dry-run", s.dryRunAutomation)
+		r.Post("/skills", s.createSkill)
+		r.Put("/skills/{id}", s.updateSkill)
+		r.Delete("/skills/{id}", s.deleteSkill)
+		r.Post("/skills/{id}/files", s.upsertSkillFiles)
+		r.Delete("/skills/{id}/files", s.deleteSkillFiles)
+		r.Post("/context/folders", s.createContextFolder)
+		r.Post("/context/files", s.uploadContextFile)
+		r.Delete("/context/{id}", s.deleteContextNode)
+	})
+
+	r.With(s.serviceOrScope(scopeRunsWrite, true)).Post("/runs/{id}/events", s.ingestEvent)
+	r.With(s.serviceOrScope(scopeRunsWrite, false)).Post("/runs/{id}/messages", s.addMessage)
+	r.With(s.serviceOrScope(scopeRunsWrite, true)).Post("/automations/{id}/inbox/{entryID}/execute", s.executeAutomationInboxEntry)
+	r.With(s.serviceOrScope(scopeRunsWrite, true)).Post("/automations/{id}/inbox/{entryID}/fail", s.failAutomationInboxEntry)
+	// Inbound automation webhooks are authenticated by the automation's own
+	// trigger secret instead of an API key.
+	r.Post("/automations/{id}/trigger", s.triggerAutomation)
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireScope(scopeProcessesExec))
+		r.Use(s.auditRequests)
+		r.Post("/runs/{id}/processes/exec", s.execWorkspaceProcess)
+		r.Post("/runs/{id}/processes/start", s.startWorkspaceProcess)
+		r.Post("/runs/{id}/processes/{pid}/stop", s.stopWorkspaceProcess)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(s.requireScope(scopeSettingsAdmin))
+		r.Use(s.auditRequests)
+		r.Get("/settings/llm", s.getLLMSettings)
+		r.Post("/settings/llm", s.updateLLMSettings)
+		r.Post("/settings/llm/test", s.testLLMSettings)
+		r.Post("/settings/llm/models", s.listLLMModels)
+		r.Get("/settings/llm/routes", s.listLLMRoutes)
+		r.Post("/settings/llm/routes", s.createLLMRoute)
+		r.Put("/settings/llm/routes/{id}", s.updateLLMRoute)
+		r.Delete("/settings/llm/routes/{id}", s.deleteLLMRoute)
+		r.Get("/settings/llm/profiles", s.listLLMProfiles)
+		r.Post("/settings/llm/profiles", s.createLLMProfile)
+		r.Put("/settings/llm/profiles/{id}", s.updateLLMProfile)
+		r.Delete("/settings/llm/profiles/{id}", s.deleteLLMProfile)
+		r.Post("/settings/secrets/rotate", s.rotateSecrets)
+		r.Get("/secrets", s.listSecrets)
+		r.Post("/secrets", s.createSecret)
+		r.Put("/secrets/{id}", s.updateSecret)
+		r.Delete("/secrets/{id}", s.deleteSecret)
+		r.Get("/settings/redaction", s.listRedactionPolicies)
+		r.Put("/settings/redaction/{profile}", s.upsertRedactionPolicy)
+		r.Delete("/settings/redaction/{profile}", s.deleteRedactionPolicy)
+		r.Get("/settings/memory", s.getMemorySettings)
+		r.Post("/settings/memory", s.updateMemorySettings)
+		r.Get("/settings/personality", s.getPersonalitySettings)
+		r.Post("/settings/personality", s.updatePersonalitySettings)
+		r.Get("/settings/api-keys", s.listAPIKeys)
+		r.Post("/settings/api-keys", s.createAPIKey)
+		r.Delete("/settings/api-keys/{id}", s.deleteAPIKey)
+		r.Group(func(r chi.Router) {
+			r.Use(requireUnbound)
+			r.Get("/workspaces", s.listWorkspaces)
+			r.Post("/workspaces", s.createWorkspace)
+			r.Delete("/workspaces/{id}", s.deleteWorkspace)
+			r.Get("/workspaces/{id}/members", s.listWorkspaceMembers)
+			r.Put("/workspaces/{id}/members/{userID}", s.upsertWorkspaceMember)
+			r.Delete("/workspaces/{id}/members/{userID}", s.deleteWorkspaceMember)
+			r.Get("/users", s.listUsers)
+			r.Post("/users", s.createUser)
+			r.Delete("/users/{id}", s.deleteUser)
+		})
+		r.Get("/audit", s.listAuditEntries)
+		r.Get("/webhooks", s.listWebhooks)
+		r.Post("/webhooks", s.createWebhook)
+		r.Put("/webhooks/{id}", s.updateWebhook)
+		r.Delete("/webhooks/{id}", s.deleteWebhook)
+		r.Get("/webhooks/{id}/deliveries", s.listWebhookDeliveries)
+		r.Post("/webhooks/{id}/deliveries/{deliveryID}/redeliver", s.redeliverWebhook)
+		r.Get("/settings/notifications", s.listNotificationChannels)
+		r.Post("/settings/notifications", s.createNotificationChannel)
+		r.Put("/settings/notifications/{id}", s.updateNotificationChannel)
+		r.Delete("/settings/notifications/{id}", s.deleteNotificationChannel)
+		r.Post("/settings/notifications/{id}/test", s.testNotificationChannel)
+	})
+
 	r.Get("/health", s.health)
 	r.Get("/ready", s.ready)
 
@@ -151,8 +256,9 @@ func (s *Server) health(w http.ResponseWriter, r *http.Request) {
 }
 
 type subsystemStatus struct {
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
 }
 
 type readinessResponse struct {
@@ -194,6 +300,10 @@ func (s *Server) ready(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Open breakers degrade LLM routing but do not make the control plane unready.
+	subsystems["llm_providers"] = s.providerHealthStatus()
+	subsystems["automation_scheduler"] = s.automationSchedulerStatus(time.Now().UTC())
+
 	status := "ok"
 	if overall != http.StatusOK {
 		status = "degraded"
@@ -252,6 +362,8 @@ func (s *Server) createRun(w http.ResponseWriter, r *http.Request) {
 		PolicyProfile: policyProfile,
 		ModelRoute:    strings.TrimSpace(req.ModelRoute),
 		Tags:          req.Tags,
+		UserID:        requestUserID(r.Context()),
+		CreatedBy:     requestActor(r.Context()),
 		CreatedAt:     now,
 		UpdatedAt:     now,
 	}
@@ -259,6 +371,7 @@ func (s *Server) createRun(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, id, nil, run)
 
 	if s.workflows != nil {
 		_ = s.workflows.StartRun(r.Context(), id)
@@ -283,7 +396,7 @@ func (s *Server) createRun(w http.ResponseWriter, r *http.Request) {
 	_ = s.store.AppendEvent(r.Context(), event)
 	_ = s.upsertArtifactsFromEvent(r.Context(), event)
 	_ = s.upsertProcessesFromEvent(r.Context(), event)
-	s.broker.Publish(toEvent(event))
+	s.publishEvent(r.Context(), event)
 
 	goal := strings.TrimSpace(req.Goal)
 	if goal != "" {
@@ -316,7 +429,7 @@ func (s *Server) createRun(w http.ResponseWriter, r *http.Request) {
 				},
 			}
 			_ = s.store.AppendEvent(r.Context(), messageEvent)
-			s.broker.Publish(toEvent(messageEvent))
+			s.publishEvent(r.Context(), messageEvent)
 		}
 	}
 
@@ -351,14 +464,23 @@ func (s *Server) addMessage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	content, markers := s.runRedactionPipeline(r.Context(), runID).Text("content", req.Content)
+	metadata := req.Metadata
+	if len(markers) > 0 {
+		metadata = make(map[string]any, len(req.Metadata)+1)
+		for key, value := range req.Metadata {
+			metadata[key] = value
+		}
+		metadata["redactions"] = redaction.Summary(markers)
+	}
 	msg := store.Message{
 		ID:        uuid.New().String(),
 		RunID:     runID,
 		Role:      req.Role,
-		Content:   req.Content,
+		Content:   content,
 		Sequence:  time.Now().UnixNano(),
 		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
-		Metadata:  req.Metadata,
+		Metadata:  metadata,
 	}
 	if err := s.store.AddMessage(r.Context(), msg); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -367,23 +489,25 @@ func (s *Server) addMessage(w http.ResponseWriter, r *http.Request) {
 	s.indexMessageMemory(r.Context(), msg)
 
 	if s.workflows != nil && req.Role == "user" {
-		_ = s.workflows.SignalMessage(r.Context(), runID, req.Content)
+		_ = s.workflows.SignalMessage(r.Context(), runID, msg.Content)
 	}
 
 	seq, _ := s.store.NextSeq(r.Context(), runID)
 	event := store.RunEvent{
-		RunID:     runID,
-		Seq:       seq,
-		Type:      "message.added",
-		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
-		Source:    "control_plane",
-		TraceID:   uuid.New().String(),
-		Payload:   map[string]any{"message_id": msg.ID, "role": msg.Role, "content": msg.Content},
+		RunID:            runID,
+		Seq:              seq,
+		Type:             "message.added",
+		Timestamp:        time.Now().UTC().Format(time.RFC3339Nano),
+		Source:           "control_plane",
+		TraceID:          uuid.New().String(),
+		Payload:          map[string]any{"message_id": msg.ID, "role": msg.Role, "content": msg.Content},
+		RedactionMarkers: markers,
+		Actor:            requestActor(r.Context()),
 	}
 	_ = s.store.AppendEvent(r.Context(), event)
 	_ = s.upsertArtifactsFromEvent(r.Context(), event)
 	_ = s.upsertProcessesFromEvent(r.Context(), event)
-	s.broker.Publish(toEvent(event))
+	s.publishEvent(r.Context(), event)
 
 	w.WriteHeader(http.StatusAccepted)
 }
@@ -396,6 +520,7 @@ func (s *Server) cancelRun(w http.ResponseWriter, r *http.Request) {
 	if s.workflows != nil {
 		_ = s.workflows.CancelRun(r.Context(), runID)
 	}
+	recordAuditChange(r, runID, nil, map[string]any{"stopped_processes": stoppedCount})
 	if stoppedCount > 0 || cleanupErr != nil {
 		seq, _ := s.store.NextSeq(r.Context(), runID)
 		cleanupPayload := map[string]any{
@@ -417,7 +542,7 @@ func (s *Server) cancelRun(w http.ResponseWriter, r *http.Request) {
 		_ = s.store.AppendEvent(r.Context(), cleanupEvent)
 		_ = s.upsertArtifactsFromEvent(r.Context(), cleanupEvent)
 		_ = s.upsertProcessesFromEvent(r.Context(), cleanupEvent)
-		s.broker.Publish(toEvent(cleanupEvent))
+		s.publishEvent(r.Context(), cleanupEvent)
 	}
 	seq, _ := s.store.NextSeq(r.Context(), runID)
 	event := store.RunEvent{
@@ -432,7 +557,7 @@ func (s *Server) cancelRun(w http.ResponseWriter, r *http.Request) {
 	_ = s.store.AppendEvent(r.Context(), event)
 	_ = s.upsertArtifactsFromEvent(r.Context(), event)
 	_ = s.upsertProcessesFromEvent(r.Context(), event)
-	s.broker.Publish(toEvent(event))
+	s.publishEvent(r.Context(), event)
 	w.WriteHeader(http.StatusAccepted)
 }
 
@@ -465,19 +590,23 @@ func (s *Server) ingestEvent(w http.ResponseWriter, r *http.Request) {
 		timestamp = time.Now().UTC().Format(time.RFC3339Nano)
 	}
 
+	payload, markers := s.runRedactionPipeline(r.Context(), runID).Map("", req.Payload)
 	seq, _ := s.store.NextSeq(r.Context(), runID)
 	event := store.RunEvent{
-		RunID:     runID,
-		Seq:       seq,
-		Type:      events.NormalizeType(req.Type),
-		Timestamp: timestamp,
-		Source:    req.Source,
-		TraceID:   strings.TrimSpace(req.TraceID),
-		Payload:   req.Payload,
+		RunID:            runID,
+		Seq:              seq,
+		Type:             events.NormalizeType(req.Type),
+		Timestamp:        timestamp,
+		Source:           req.Source,
+		TraceID:          strings.TrimSpace(req.TraceID),
+		Payload:          payload,
+		RedactionMarkers: markers,
+		Actor:            requestActor(r.Context()),
 	}
 	if event.TraceID == "" {
 		event.TraceID = uuid.New().String()
 	}
+	s.observeProviderHealth(event.Type, event.Payload)
 	if isTransientPayload(req.Payload) {
 		s.broker.Publish(toEvent(event))
 		w.WriteHeader(http.StatusAccepted)
@@ -486,7 +615,7 @@ func (s *Server) ingestEvent(w http.ResponseWriter, r *http.Request) {
 	_ = s.store.AppendEvent(r.Context(), event)
 	_ = s.upsertArtifactsFromEvent(r.Context(), event)
 	_ = s.upsertProcessesFromEvent(r.Context(), event)
-	s.broker.Publish(toEvent(event))
+	s.publishEvent(r.Context(), event)
 
 	w.WriteHeader(http.StatusAccepted)
 }
@@ -605,19 +734,6 @@ func (s *Server) ensureLLMConfigured(w http.ResponseWriter, ctx context.Context)
 	return true
 }
 
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Last-Event-ID")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
-}
-
 func (s *Server) Start(ctx context.Context, addr string) error {
 	server := &http.Server{
 		Addr:    addr,
@@ -627,5 +743,13 @@ func (s *Server) Start(ctx context.Context, addr string) error {
 		<-ctx.Done()
 		_ = server.Shutdown(context.Background())
 	}()
+	go s.runWebhookDispatcher(ctx)
+	// Every replica releases automations and fails jobs whose lease lapsed,
+	// whether or not it runs the scheduler.
+	s.reconcileAutomations(ctx, time.Now().UTC())
+	go s.runAutomationReconciler(ctx)
+	if s.cfg.AutomationSchedulerEnabled {
+		go s.runAutomationScheduler(ctx)
+	}
 	return server.ListenAndServe()
 }
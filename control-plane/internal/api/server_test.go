@@ -122,6 +122,42 @@ func TestReady(t *testing.T) {
 	})
 }
 
+func TestReadyReportsProviderBreakers(t *testing.T) {
+	storeMock := &MockStore{}
+	allowDefaultRedaction(storeMock)
+	storeMock.On("NextSeq", mock.Anything, "run-1").Return(int64(1), nil)
+	storeMock.On("AppendEvent", mock.Anything, mock.Anything).Return(nil)
+	storeMock.On("UpsertArtifact", mock.Anything, mock.Anything).Return(nil).Maybe()
+	storeMock.On("ListRuns", mock.Anything).Return([]store.RunSummary{}, nil)
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+
+	server := newTestServer(t, storeMock, brokerMock, nil, config.Config{})
+	defer server.Close()
+
+	body := `{"type":"model.request.failed","source":"llm","payload":{"provider":"openrouter","model":"gpt-4o","retryable":true,"status_code":429,"retry_after_ms":60000,"error":"LLM request failed: 429 Too Many Requests"}}`
+	resp, err := http.Post(server.URL+"/runs/run-1/events", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/ready")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var payload readinessResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+	providers := payload.Subsystems["llm_providers"]
+	require.Equal(t, "degraded", providers.Status)
+	entries, ok := providers.Details["providers"].([]any)
+	require.True(t, ok)
+	require.Len(t, entries, 1)
+	entry := entries[0].(map[string]any)
+	require.Equal(t, "openrouter", entry["provider"])
+	require.Equal(t, "open", entry["state"])
+}
+
 func TestCreateRun(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		storeMock := &MockStore{}
@@ -306,6 +342,7 @@ func TestListRunSteps(t *testing.T) {
 func TestAddMessage(t *testing.T) {
 	t.Run("success user", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		brokerMock := &MockBroker{}
 		workflows := &MockWorkflowService{}
 		storeMock.On("GetLLMSettings", mock.Anything).Return(&store.LLMSettings{Provider: "openai"}, nil).Once()
@@ -336,6 +373,7 @@ func TestAddMessage(t *testing.T) {
 
 	t.Run("indexes memory when enabled", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		brokerMock := &MockBroker{}
 		workflows := &MockWorkflowService{}
 		storeMock.On("GetLLMSettings", mock.Anything).Return(&store.LLMSettings{Provider: "openai"}, nil).Once()
@@ -381,6 +419,7 @@ func TestAddMessage(t *testing.T) {
 
 	t.Run("llm required", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		storeMock.On("GetLLMSettings", mock.Anything).Return(nil, nil).Once()
 
 		server := newTestServer(t, storeMock, &MockBroker{}, nil, config.Config{})
@@ -397,6 +436,7 @@ func TestAddMessage(t *testing.T) {
 
 	t.Run("store error", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		storeMock.On("GetLLMSettings", mock.Anything).Return(&store.LLMSettings{Provider: "openai"}, nil).Once()
 		storeMock.On("AddMessage", mock.Anything, mock.Anything).Return(errors.New("boom")).Once()
 
@@ -688,6 +728,7 @@ func TestIngestEvent(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		brokerMock := &MockBroker{}
 		storeMock.On("NextSeq", mock.Anything, "run-1").Return(int64(3), nil).Once()
 		storeMock.On("AppendEvent", mock.Anything, mock.MatchedBy(func(event store.RunEvent) bool {
@@ -710,6 +751,7 @@ func TestIngestEvent(t *testing.T) {
 
 	t.Run("default timestamp", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		brokerMock := &MockBroker{}
 		storeMock.On("NextSeq", mock.Anything, "run-1").Return(int64(4), nil).Once()
 		storeMock.On("AppendEvent", mock.Anything, mock.MatchedBy(func(event store.RunEvent) bool {
@@ -732,6 +774,7 @@ func TestIngestEvent(t *testing.T) {
 
 	t.Run("stores artifacts from tool output", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		brokerMock := &MockBroker{}
 		storeMock.On("NextSeq", mock.Anything, "run-1").Return(int64(5), nil).Once()
 		storeMock.On("AppendEvent", mock.Anything, mock.MatchedBy(func(event store.RunEvent) bool {
@@ -757,6 +800,7 @@ func TestIngestEvent(t *testing.T) {
 
 	t.Run("stores process state from process tool output", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		brokerMock := &MockBroker{}
 		storeMock.On("NextSeq", mock.Anything, "run-1").Return(int64(7), nil).Once()
 		storeMock.On("AppendEvent", mock.Anything, mock.MatchedBy(func(event store.RunEvent) bool {
@@ -784,6 +828,7 @@ func TestIngestEvent(t *testing.T) {
 
 	t.Run("transient events skip storage", func(t *testing.T) {
 		storeMock := &MockStore{}
+		allowDefaultRedaction(storeMock)
 		brokerMock := &MockBroker{}
 		storeMock.On("NextSeq", mock.Anything, "run-1").Return(int64(6), nil).Once()
 		brokerMock.On("Publish", mock.Anything).Once()
@@ -1197,18 +1242,27 @@ func TestStreamEvents(t *testing.T) {
 }
 
 func TestCORSMiddleware(t *testing.T) {
-	server := newTestServer(t, &MockStore{}, &MockBroker{}, nil, config.Config{})
+	server := newTestServer(t, &MockStore{}, &MockBroker{}, nil, config.Config{CORSAllowedOrigins: "http://localhost:5173, https://app.example.com/"})
 	defer server.Close()
 
-	req, err := http.NewRequest(http.MethodOptions, server.URL+"/health", nil)
-	require.NoError(t, err)
-	resp, err := http.DefaultClient.Do(req)
-	require.NoError(t, err)
-	defer resp.Body.Close()
+	preflight := func(origin string) *http.Response {
+		req, err := http.NewRequest(http.MethodOptions, server.URL+"/health", nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
 
+	resp := preflight("https://app.example.com")
 	require.Equal(t, http.StatusNoContent, resp.StatusCode)
-	require.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
 	require.Contains(t, resp.Header.Get("Access-Control-Allow-Methods"), "OPTIONS")
+	require.Contains(t, resp.Header.Get("Access-Control-Allow-Headers"), "Authorization")
+
+	resp = preflight("https://evil.example.com")
+	require.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
 }
 
 func TestEnsureLLMConfigured(t *testing.T) {
@@ -1272,6 +1326,9 @@ func TestParseAfterSeq(t *testing.T) {
 
 func TestStart(t *testing.T) {
 	storeMock := &MockStore{}
+	storeMock.On("ClaimWebhookDeliveries", mock.Anything, mock.Anything, mock.Anything, webhookClaimBatch).Return(nil, nil).Maybe()
+	// Start reconciles automations even with the scheduler disabled.
+	storeMock.On("ListWorkspaces", mock.Anything).Return([]store.Workspace{}, nil).Once()
 	brokerMock := &MockBroker{}
 	server := NewServer(storeMock, brokerMock, nil, config.Config{})
 
@@ -1293,6 +1350,7 @@ func TestStart(t *testing.T) {
 
 	err = <-result
 	require.Error(t, err)
+	storeMock.AssertExpectations(t)
 }
 
 func TestSendSSE(t *testing.T) {
@@ -113,6 +113,7 @@ func (s *Server) createSkill(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, skill.ID, nil, skillAuditState(skill, fileEntries))
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(skillResponse{
@@ -166,6 +167,7 @@ func (s *Server) updateSkill(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, updated.ID, existing, updated)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(skillResponse{
@@ -192,6 +194,7 @@ func (s *Server) deleteSkill(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, skillID, existing, nil)
 	if root, err := skills.RootDir(); err == nil {
 		_ = os.RemoveAll(filepath.Join(root, existing.Name))
 	}
@@ -258,6 +261,7 @@ func (s *Server) upsertSkillFiles(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, skill.ID, nil, map[string]any{"paths": skillFilePaths(fileEntries), "deleted_paths": req.DeletePaths})
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -288,10 +292,30 @@ func (s *Server) deleteSkillFiles(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordAuditChange(r, skill.ID, nil, map[string]any{"deleted_paths": req.DeletePaths})
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// skillAuditState describes a new skill for the audit log by its file
+// paths; file contents are not recorded.
+func skillAuditState(skill store.Skill, files []store.SkillFile) map[string]any {
+	return map[string]any{
+		"id":          skill.ID,
+		"name":        skill.Name,
+		"description": skill.Description,
+		"paths":       skillFilePaths(files),
+	}
+}
+
+func skillFilePaths(files []store.SkillFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
 func validateSkillName(name string) error {
 	if name == "" {
 		return fmt.Errorf("name is required")
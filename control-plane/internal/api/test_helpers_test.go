@@ -10,6 +10,7 @@ import (
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/events"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/redaction"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
 )
 
@@ -36,6 +37,14 @@ func (m *MockStore) CreateRun(ctx context.Context, run store.Run) error {
 	return args.Error(0)
 }
 
+func (m *MockStore) GetRun(ctx context.Context, runID string) (*store.Run, error) {
+	args := m.Called(ctx, runID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.Run), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockStore) AddMessage(ctx context.Context, msg store.Message) error {
 	args := m.Called(ctx, msg)
 	return args.Error(0)
@@ -259,6 +268,366 @@ func (m *MockStore) ListArtifacts(ctx context.Context, runID string) ([]store.Ar
 	return result, args.Error(1)
 }
 
+func (m *MockStore) ListLLMRouteRules(ctx context.Context) ([]store.LLMRouteRule, error) {
+	args := m.Called(ctx)
+	var result []store.LLMRouteRule
+	if value := args.Get(0); value != nil {
+		result = value.([]store.LLMRouteRule)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) GetLLMRouteRule(ctx context.Context, ruleID string) (*store.LLMRouteRule, error) {
+	args := m.Called(ctx, ruleID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.LLMRouteRule), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) CreateLLMRouteRule(ctx context.Context, rule store.LLMRouteRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockStore) UpdateLLMRouteRule(ctx context.Context, rule store.LLMRouteRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteLLMRouteRule(ctx context.Context, ruleID string) error {
+	args := m.Called(ctx, ruleID)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListLLMProviderProfiles(ctx context.Context) ([]store.LLMProviderProfile, error) {
+	args := m.Called(ctx)
+	var result []store.LLMProviderProfile
+	if value := args.Get(0); value != nil {
+		result = value.([]store.LLMProviderProfile)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) GetLLMProviderProfile(ctx context.Context, profileID string) (*store.LLMProviderProfile, error) {
+	args := m.Called(ctx, profileID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.LLMProviderProfile), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) CreateLLMProviderProfile(ctx context.Context, profile store.LLMProviderProfile) error {
+	args := m.Called(ctx, profile)
+	return args.Error(0)
+}
+
+func (m *MockStore) UpdateLLMProviderProfile(ctx context.Context, profile store.LLMProviderProfile) error {
+	args := m.Called(ctx, profile)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteLLMProviderProfile(ctx context.Context, profileID string) error {
+	args := m.Called(ctx, profileID)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListSecrets(ctx context.Context) ([]store.Secret, error) {
+	args := m.Called(ctx)
+	if value := args.Get(0); value != nil {
+		return value.([]store.Secret), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) GetSecret(ctx context.Context, secretID string) (*store.Secret, error) {
+	args := m.Called(ctx, secretID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.Secret), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) CreateSecret(ctx context.Context, secret store.Secret) error {
+	args := m.Called(ctx, secret)
+	return args.Error(0)
+}
+
+func (m *MockStore) UpdateSecret(ctx context.Context, secret store.Secret) error {
+	args := m.Called(ctx, secret)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteSecret(ctx context.Context, secretID string) error {
+	args := m.Called(ctx, secretID)
+	return args.Error(0)
+}
+
+func (m *MockStore) RotateSecrets(ctx context.Context, rotate store.SecretRotateFunc) (store.SecretRotationReport, error) {
+	args := m.Called(ctx, rotate)
+	return args.Get(0).(store.SecretRotationReport), args.Error(1)
+}
+
+func (m *MockStore) ListRedactionPolicies(ctx context.Context) ([]store.RedactionPolicy, error) {
+	args := m.Called(ctx)
+	var result []store.RedactionPolicy
+	if value := args.Get(0); value != nil {
+		result = value.([]store.RedactionPolicy)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) GetRedactionPolicy(ctx context.Context, policyProfile string) (*store.RedactionPolicy, error) {
+	args := m.Called(ctx, policyProfile)
+	if value := args.Get(0); value != nil {
+		return value.(*store.RedactionPolicy), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) UpsertRedactionPolicy(ctx context.Context, policy store.RedactionPolicy) error {
+	args := m.Called(ctx, policy)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteRedactionPolicy(ctx context.Context, policyProfile string) error {
+	args := m.Called(ctx, policyProfile)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListAPIKeys(ctx context.Context) ([]store.APIKey, error) {
+	args := m.Called(ctx)
+	var result []store.APIKey
+	if value := args.Get(0); value != nil {
+		result = value.([]store.APIKey)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) GetAPIKey(ctx context.Context, keyID string) (*store.APIKey, error) {
+	args := m.Called(ctx, keyID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*store.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if value := args.Get(0); value != nil {
+		return value.(*store.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) CreateAPIKey(ctx context.Context, key store.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockStore) TouchAPIKey(ctx context.Context, keyID string, usedAt string) error {
+	args := m.Called(ctx, keyID, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteAPIKey(ctx context.Context, keyID string) error {
+	args := m.Called(ctx, keyID)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListWorkspaces(ctx context.Context) ([]store.Workspace, error) {
+	args := m.Called(ctx)
+	var result []store.Workspace
+	if value := args.Get(0); value != nil {
+		result = value.([]store.Workspace)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) GetWorkspace(ctx context.Context, workspaceID string) (*store.Workspace, error) {
+	args := m.Called(ctx, workspaceID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.Workspace), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) CreateWorkspace(ctx context.Context, workspace store.Workspace) error {
+	args := m.Called(ctx, workspace)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteWorkspace(ctx context.Context, workspaceID string) error {
+	args := m.Called(ctx, workspaceID)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListUsers(ctx context.Context) ([]store.User, error) {
+	args := m.Called(ctx)
+	var result []store.User
+	if value := args.Get(0); value != nil {
+		result = value.([]store.User)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) GetUser(ctx context.Context, userID string) (*store.User, error) {
+	args := m.Called(ctx, userID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) CreateUser(ctx context.Context, user store.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListWorkspaceMembers(ctx context.Context, workspaceID string) ([]store.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID)
+	var result []store.WorkspaceMember
+	if value := args.Get(0); value != nil {
+		result = value.([]store.WorkspaceMember)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) UpsertWorkspaceMember(ctx context.Context, member store.WorkspaceMember) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	args := m.Called(ctx, workspaceID, userID)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetRunWorkspace(ctx context.Context, runID string) (string, error) {
+	args := m.Called(ctx, runID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStore) AppendAuditEntry(ctx context.Context, entry store.AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListAuditEntries(ctx context.Context, query store.AuditQuery) ([]store.AuditEntry, error) {
+	args := m.Called(ctx, query)
+	var result []store.AuditEntry
+	if value := args.Get(0); value != nil {
+		result = value.([]store.AuditEntry)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) ListWebhookSubscriptions(ctx context.Context) ([]store.WebhookSubscription, error) {
+	args := m.Called(ctx)
+	var result []store.WebhookSubscription
+	if value := args.Get(0); value != nil {
+		result = value.([]store.WebhookSubscription)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) GetWebhookSubscription(ctx context.Context, subscriptionID string) (*store.WebhookSubscription, error) {
+	args := m.Called(ctx, subscriptionID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.WebhookSubscription), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) CreateWebhookSubscription(ctx context.Context, subscription store.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockStore) UpdateWebhookSubscription(ctx context.Context, subscription store.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	args := m.Called(ctx, subscriptionID)
+	return args.Error(0)
+}
+
+func (m *MockStore) CreateWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockStore) GetWebhookDelivery(ctx context.Context, deliveryID string) (*store.WebhookDelivery, error) {
+	args := m.Called(ctx, deliveryID)
+	if value := args.Get(0); value != nil {
+		return value.(*store.WebhookDelivery), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockStore) UpdateWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockStore) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]store.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, limit)
+	var result []store.WebhookDelivery
+	if value := args.Get(0); value != nil {
+		result = value.([]store.WebhookDelivery)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) ClaimWebhookDeliveries(ctx context.Context, now string, leaseUntil string, limit int) ([]store.WebhookDelivery, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	var result []store.WebhookDelivery
+	if value := args.Get(0); value != nil {
+		result = value.([]store.WebhookDelivery)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) ListNotificationChannels(ctx context.Context) ([]store.NotificationChannel, error) {
+	args := m.Called(ctx)
+	var result []store.NotificationChannel
+	if value := args.Get(0); value != nil {
+		result = value.([]store.NotificationChannel)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) GetNotificationChannel(ctx context.Context, channelID string) (*store.NotificationChannel, error) {
+	args := m.Called(ctx, channelID)
+	var result *store.NotificationChannel
+	if value := args.Get(0); value != nil {
+		result = value.(*store.NotificationChannel)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) CreateNotificationChannel(ctx context.Context, channel store.NotificationChannel) error {
+	args := m.Called(ctx, channel)
+	return args.Error(0)
+}
+
+func (m *MockStore) UpdateNotificationChannel(ctx context.Context, channel store.NotificationChannel) error {
+	args := m.Called(ctx, channel)
+	return args.Error(0)
+}
+
+func (m *MockStore) DeleteNotificationChannel(ctx context.Context, channelID string) error {
+	args := m.Called(ctx, channelID)
+	return args.Error(0)
+}
+
 func (m *MockStore) ListAutomations(ctx context.Context) ([]store.Automation, error) {
 	args := m.Called(ctx)
 	var result []store.Automation
@@ -310,6 +679,16 @@ func (m *MockStore) UpdateAutomationInboxEntry(ctx context.Context, entry store.
 	return args.Error(0)
 }
 
+func (m *MockStore) ClaimAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string, now string, leaseUntil string) (bool, error) {
+	args := m.Called(ctx, automationID, entryID, owner, now, leaseUntil)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStore) ReleaseAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string) error {
+	args := m.Called(ctx, automationID, entryID, owner)
+	return args.Error(0)
+}
+
 func (m *MockStore) MarkAutomationInboxEntryRead(ctx context.Context, automationID string, entryID string) error {
 	args := m.Called(ctx, automationID, entryID)
 	return args.Error(0)
@@ -320,6 +699,20 @@ func (m *MockStore) MarkAutomationInboxReadAll(ctx context.Context, automationID
 	return args.Error(0)
 }
 
+func (m *MockStore) QueryAutomationInbox(ctx context.Context, query store.AutomationInboxQuery) ([]store.AutomationInboxEntry, error) {
+	args := m.Called(ctx, query)
+	var result []store.AutomationInboxEntry
+	if value := args.Get(0); value != nil {
+		result = value.([]store.AutomationInboxEntry)
+	}
+	return result, args.Error(1)
+}
+
+func (m *MockStore) DeleteAutomationInboxEntries(ctx context.Context, automationID string, entryIDs []string) error {
+	args := m.Called(ctx, automationID, entryIDs)
+	return args.Error(0)
+}
+
 type MockBroker struct {
 	mock.Mock
 }
@@ -365,6 +758,22 @@ func (m *MockWorkflowService) CancelRun(ctx context.Context, runID string) error
 	return args.Error(0)
 }
 
+// MockAutomationWorkflows is a workflow service that also runs automation
+// jobs on Temporal.
+type MockAutomationWorkflows struct {
+	MockWorkflowService
+}
+
+func (m *MockAutomationWorkflows) StartAutomation(ctx context.Context, workspaceID string, automationID string, entryID string) error {
+	args := m.Called(ctx, workspaceID, automationID, entryID)
+	return args.Error(0)
+}
+
+func (m *MockAutomationWorkflows) AutomationActive(ctx context.Context, entryID string) (bool, error) {
+	args := m.Called(ctx, entryID)
+	return args.Bool(0), args.Error(1)
+}
+
 type MockProvider struct {
 	mock.Mock
 }
@@ -376,6 +785,24 @@ func (m *MockProvider) Generate(ctx context.Context, messages []llm.Message) (st
 
 func newTestServer(t *testing.T, store store.Store, broker Broker, workflows WorkflowService, cfg config.Config) *httptest.Server {
 	t.Helper()
+	// Run routes look up the run's workspace before reaching the handler,
+	// published events are matched against webhook subscriptions, and
+	// successful mutations are redacted and written to the audit log
+	// afterwards.
+	if storeMock, ok := store.(*MockStore); ok {
+		storeMock.On("GetRunWorkspace", mock.Anything, mock.Anything).Return("", nil).Maybe()
+		storeMock.On("AppendAuditEntry", mock.Anything, mock.Anything).Return(nil).Maybe()
+		storeMock.On("ListWebhookSubscriptions", mock.Anything).Return(nil, nil).Maybe()
+		storeMock.On("GetRedactionPolicy", mock.Anything, redaction.DefaultPolicyProfile).Return(nil, nil).Maybe()
+		storeMock.On("ListSecrets", mock.Anything).Return(nil, nil).Maybe()
+	}
 	server := NewServer(store, broker, workflows, cfg)
 	return httptest.NewServer(server.Router())
 }
+
+// allowDefaultRedaction lets handlers resolve redaction policies against a
+// MockStore that has none stored, so the built-in defaults apply.
+func allowDefaultRedaction(storeMock *MockStore) {
+	storeMock.On("GetRun", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	storeMock.On("GetRedactionPolicy", mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+}
@@ -0,0 +1,648 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/events"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	webhookStatusPending   = "pending"
+	webhookStatusDelivered = "delivered"
+	webhookStatusFailed    = "failed"
+
+	// webhookSigningService is sent as X-Gavryn-Service so receivers can
+	// verify deliveries with the same scheme workers use for events.
+	webhookSigningService = "webhook"
+	webhookSecretPrefix   = "whsec_"
+	webhookHeaderEvent    = "X-Gavryn-Event"
+	webhookHeaderDelivery = "X-Gavryn-Delivery"
+
+	webhookMaxAttempts   = 8
+	webhookBaseBackoff   = 10 * time.Second
+	webhookMaxBackoff    = time.Hour
+	webhookPollInterval  = 5 * time.Second
+	webhookClaimBatch    = 20
+	webhookLease         = 2 * time.Minute
+	webhookErrorBodySize = 512
+	webhookSendTimeout   = 30 * time.Second
+
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 200
+)
+
+// Event type filters are exact types, prefixes ending in ".*", or "*".
+var webhookEventTypeRE = regexp.MustCompile(`^(\*|[a-z0-9_]+(\.[a-z0-9_]+)*(\.\*)?)$`)
+
+// errWebhookUnavailable fails a delivery without retrying because its
+// subscription was disabled.
+var errWebhookUnavailable = errors.New("webhook is disabled")
+
+var errWebhookAddressBlocked = errors.New("webhook address is not public; set WEBHOOKS_ALLOW_PRIVATE=true to allow it")
+
+type webhookRecord struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Tags       []string `json:"tags"`
+	Enabled    bool     `json:"enabled"`
+	// Secret is only returned when it is generated, on create and rotation.
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type webhookRequest struct {
+	Name         *string  `json:"name"`
+	URL          *string  `json:"url"`
+	EventTypes   []string `json:"event_types"`
+	Tags         []string `json:"tags"`
+	Enabled      *bool    `json:"enabled"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+type webhooksResponse struct {
+	Webhooks []webhookRecord `json:"webhooks"`
+}
+
+type webhookDeliveryRecord struct {
+	ID             string         `json:"id"`
+	SubscriptionID string         `json:"subscription_id"`
+	EventType      string         `json:"event_type"`
+	RunID          string         `json:"run_id,omitempty"`
+	EventSeq       int64          `json:"event_seq,omitempty"`
+	Status         string         `json:"status"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  string         `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  string         `json:"last_attempt_at,omitempty"`
+	ResponseStatus int            `json:"response_status,omitempty"`
+	LastError      string         `json:"last_error,omitempty"`
+	Payload        map[string]any `json:"payload"`
+	CreatedAt      string         `json:"created_at"`
+	UpdatedAt      string         `json:"updated_at"`
+}
+
+type webhookDeliveriesResponse struct {
+	Deliveries []webhookDeliveryRecord `json:"deliveries"`
+}
+
+func toWebhookRecord(subscription store.WebhookSubscription) webhookRecord {
+	return webhookRecord{
+		ID:         subscription.ID,
+		Name:       subscription.Name,
+		URL:        subscription.URL,
+		EventTypes: nonNilStrings(subscription.EventTypes),
+		Tags:       nonNilStrings(subscription.Tags),
+		Enabled:    subscription.Enabled,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryRecord(delivery store.WebhookDelivery) webhookDeliveryRecord {
+	return webhookDeliveryRecord{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventType:      delivery.EventType,
+		RunID:          delivery.RunID,
+		EventSeq:       delivery.EventSeq,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		Payload:        delivery.Payload,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+}
+
+func generateWebhookSecret() (string, error) {
+	return generateSecret(webhookSecretPrefix)
+}
+
+// generateSecret returns 32 random bytes, base64url-encoded after prefix.
+func generateSecret(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func normalizeWebhookURL(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return "", errors.New("url is required")
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("url must be an absolute http or https URL")
+	}
+	return value, nil
+}
+
+// validateWebhookURL normalizes a subscription URL and, unless private
+// webhooks are allowed, refuses hosts that are or resolve to non-public
+// addresses. Deliveries check the address again when they connect.
+func (s *Server) validateWebhookURL(ctx context.Context, raw string) (string, error) {
+	webhookURL, err := normalizeWebhookURL(raw)
+	if err != nil {
+		return "", err
+	}
+	if s.cfg.WebhooksAllowPrivate {
+		return webhookURL, nil
+	}
+	if err := checkPublicURL(ctx, webhookURL, errWebhookAddressBlocked); err != nil {
+		return "", err
+	}
+	return webhookURL, nil
+}
+
+// newWebhookClient returns the client deliveries are sent with.
+func newWebhookClient(allowPrivate bool) *http.Client {
+	if allowPrivate {
+		return &http.Client{Timeout: webhookSendTimeout}
+	}
+	return newPublicClient(webhookSendTimeout, errWebhookAddressBlocked)
+}
+
+func normalizeWebhookEventTypes(values []string) ([]string, error) {
+	eventTypes := normalizeRouteCriteria(values, true)
+	if len(eventTypes) == 0 {
+		return nil, errors.New("event_types is required")
+	}
+	for _, eventType := range eventTypes {
+		if !webhookEventTypeRE.MatchString(eventType) {
+			return nil, fmt.Errorf("invalid event type: %s", eventType)
+		}
+	}
+	return eventTypes, nil
+}
+
+func webhookMatchesEventType(filters []string, eventType string) bool {
+	for _, filter := range filters {
+		if filter == "*" || filter == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(filter, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookBackoff returns the delay before retrying after the given number of
+// failed attempts: 10s, 20s, 40s, ... capped at an hour.
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookBaseBackoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return delay
+}
+
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := s.store.ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]webhookRecord, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		result = append(result, toWebhookRecord(subscription))
+	}
+	writeJSONStatus(w, webhooksResponse{Webhooks: result}, http.StatusOK)
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	req := webhookRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	rawURL := ""
+	if req.URL != nil {
+		rawURL = *req.URL
+	}
+	webhookURL, err := s.validateWebhookURL(r.Context(), rawURL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	eventTypes, err := normalizeWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	secretEnc, status, err := s.encryptStoredSecret(secret)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	subscription := store.WebhookSubscription{
+		ID:         uuid.NewString(),
+		URL:        webhookURL,
+		EventTypes: eventTypes,
+		Tags:       normalizeRouteCriteria(req.Tags, true),
+		SecretEnc:  secretEnc,
+		Enabled:    true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if req.Name != nil {
+		subscription.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	if err := s.store.CreateWebhookSubscription(r.Context(), subscription); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, subscription.ID, nil, subscription)
+	record := toWebhookRecord(subscription)
+	record.Secret = secret
+	writeJSONStatus(w, record, http.StatusCreated)
+}
+
+func (s *Server) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	req := webhookRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetWebhookSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	updated := *current
+	if req.Name != nil {
+		updated.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.URL != nil {
+		if updated.URL, err = s.validateWebhookURL(r.Context(), *req.URL); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.EventTypes != nil {
+		if updated.EventTypes, err = normalizeWebhookEventTypes(req.EventTypes); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Tags != nil {
+		updated.Tags = normalizeRouteCriteria(req.Tags, true)
+	}
+	if req.Enabled != nil {
+		updated.Enabled = *req.Enabled
+	}
+	secret := ""
+	if req.RotateSecret {
+		if secret, err = generateWebhookSecret(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		secretEnc, status, err := s.encryptStoredSecret(secret)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		updated.SecretEnc = secretEnc
+	}
+	updated.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.store.UpdateWebhookSubscription(r.Context(), updated); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, updated.ID, *current, updated)
+	record := toWebhookRecord(updated)
+	record.Secret = secret
+	writeJSONStatus(w, record, http.StatusOK)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	current, err := s.store.GetWebhookSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteWebhookSubscription(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, *current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
+
+func (s *Server) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	subscription, err := s.store.GetWebhookSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if subscription == nil {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	limit := parsePositiveInt(r.URL.Query().Get("limit"), defaultWebhookDeliveryLimit)
+	if limit > maxWebhookDeliveryLimit {
+		limit = maxWebhookDeliveryLimit
+	}
+	deliveries, err := s.store.ListWebhookDeliveries(r.Context(), id, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]webhookDeliveryRecord, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		result = append(result, toWebhookDeliveryRecord(delivery))
+	}
+	writeJSONStatus(w, webhookDeliveriesResponse{Deliveries: result}, http.StatusOK)
+}
+
+// redeliverWebhook queues a fresh copy of a past delivery; the original stays
+// in the log unchanged.
+func (s *Server) redeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	deliveryID := strings.TrimSpace(chi.URLParam(r, "deliveryID"))
+	subscription, err := s.store.GetWebhookSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if subscription == nil {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	if !subscription.Enabled {
+		http.Error(w, "webhook is disabled", http.StatusConflict)
+		return
+	}
+	original, err := s.store.GetWebhookDelivery(r.Context(), deliveryID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if original == nil || original.SubscriptionID != id {
+		http.Error(w, "webhook delivery not found", http.StatusNotFound)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	delivery := store.WebhookDelivery{
+		ID:             uuid.NewString(),
+		SubscriptionID: original.SubscriptionID,
+		EventType:      original.EventType,
+		RunID:          original.RunID,
+		EventSeq:       original.EventSeq,
+		Payload:        original.Payload,
+		Status:         webhookStatusPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.store.CreateWebhookDelivery(r.Context(), delivery); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, nil, map[string]any{"delivery_id": delivery.ID, "redelivery_of": original.ID})
+	s.wakeWebhookDispatcher()
+	writeJSONStatus(w, toWebhookDeliveryRecord(delivery), http.StatusAccepted)
+}
+
+// publishEvent streams a stored event to run subscribers and queues it for
+// matching webhook subscriptions.
+func (s *Server) publishEvent(ctx context.Context, event store.RunEvent) {
+	s.broker.Publish(toEvent(event))
+	eventType := events.NormalizeType(event.Type)
+	s.enqueueWebhookDeliveries(ctx, event, eventType, event.Payload)
+	if eventType == "run.completed" {
+		s.dispatchRunTriggers(ctx, event)
+	}
+}
+
+func artifactWebhookData(artifact store.Artifact) map[string]any {
+	return map[string]any{
+		"id":           artifact.ID,
+		"type":         artifact.Type,
+		"category":     artifact.Category,
+		"uri":          artifact.URI,
+		"content_type": artifact.ContentType,
+		"size_bytes":   artifact.SizeBytes,
+		"labels":       nonNilStrings(artifact.Labels),
+	}
+}
+
+// enqueueWebhookDeliveries queues eventType, raised by event, for every
+// enabled subscription whose event and tag filters match. Events of dry runs
+// are never delivered.
+func (s *Server) enqueueWebhookDeliveries(ctx context.Context, event store.RunEvent, eventType string, data map[string]any) {
+	subscriptions, err := s.store.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		log.Printf("webhook subscriptions lookup failed run_id=%s err=%v", event.RunID, err)
+		return
+	}
+	var runTags []string
+	tagsLoaded := false
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	queued := 0
+	for _, subscription := range subscriptions {
+		if !subscription.Enabled || !webhookMatchesEventType(subscription.EventTypes, eventType) {
+			continue
+		}
+		if !tagsLoaded {
+			if run, err := s.store.GetRun(ctx, event.RunID); err == nil && run != nil {
+				runTags = run.Tags
+			}
+			tagsLoaded = true
+		}
+		// Nothing leaves the control plane for a dry run.
+		if slices.Contains(runTags, automationDryRunTag) {
+			return
+		}
+		if len(subscription.Tags) > 0 && !containsAnyLabel(runTags, subscription.Tags) {
+			continue
+		}
+		delivery := store.WebhookDelivery{
+			ID:             uuid.NewString(),
+			SubscriptionID: subscription.ID,
+			EventType:      eventType,
+			RunID:          event.RunID,
+			EventSeq:       event.Seq,
+			Payload: map[string]any{
+				"type":         eventType,
+				"workspace_id": store.WorkspaceID(ctx),
+				"run_id":       event.RunID,
+				"seq":          event.Seq,
+				"source":       event.Source,
+				"timestamp":    event.Timestamp,
+				"data":         data,
+			},
+			Status:        webhookStatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := s.store.CreateWebhookDelivery(ctx, delivery); err != nil {
+			log.Printf("webhook delivery enqueue failed webhook_id=%s run_id=%s err=%v", subscription.ID, event.RunID, err)
+			continue
+		}
+		queued++
+	}
+	if queued > 0 {
+		s.wakeWebhookDispatcher()
+	}
+}
+
+func (s *Server) wakeWebhookDispatcher() {
+	select {
+	case s.webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// runWebhookDispatcher delivers queued webhooks until ctx is cancelled. It
+// polls for due retries and wakes early when new deliveries are queued.
+func (s *Server) runWebhookDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+	for {
+		s.deliverDueWebhooks(ctx, time.Now().UTC())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.webhookWake:
+		}
+	}
+}
+
+// deliverDueWebhooks claims deliveries due at now and attempts each once.
+func (s *Server) deliverDueWebhooks(ctx context.Context, now time.Time) {
+	for {
+		claimed, err := s.store.ClaimWebhookDeliveries(
+			ctx,
+			now.Format(time.RFC3339Nano),
+			now.Add(webhookLease).Format(time.RFC3339Nano),
+			webhookClaimBatch,
+		)
+		if err != nil {
+			log.Printf("webhook delivery claim failed err=%v", err)
+			return
+		}
+		for _, delivery := range claimed {
+			s.attemptWebhookDelivery(store.WithWorkspace(ctx, delivery.WorkspaceID), delivery, now)
+		}
+		if len(claimed) < webhookClaimBatch || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) attemptWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery, now time.Time) {
+	delivery.Attempts++
+	delivery.LastAttemptAt = now.Format(time.RFC3339Nano)
+	delivery.ResponseStatus = 0
+	responseStatus, err := s.sendWebhook(ctx, delivery, now)
+	delivery.ResponseStatus = responseStatus
+	switch {
+	case err == nil:
+		delivery.Status = webhookStatusDelivered
+		delivery.NextAttemptAt = ""
+		delivery.LastError = ""
+	case delivery.Attempts >= webhookMaxAttempts || errors.Is(err, errWebhookUnavailable):
+		delivery.Status = webhookStatusFailed
+		delivery.NextAttemptAt = ""
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = webhookStatusPending
+		delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts)).Format(time.RFC3339Nano)
+		delivery.LastError = err.Error()
+	}
+	delivery.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := s.store.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		log.Printf("webhook delivery update failed delivery_id=%s err=%v", delivery.ID, err)
+	}
+}
+
+// sendWebhook posts the delivery payload signed with the subscription's
+// secret and returns the response status.
+func (s *Server) sendWebhook(ctx context.Context, delivery store.WebhookDelivery, now time.Time) (int, error) {
+	subscription, err := s.store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	if subscription == nil || !subscription.Enabled {
+		return 0, errWebhookUnavailable
+	}
+	secret, err := s.decryptStoredSecret(subscription.SecretEnc)
+	if err != nil {
+		return 0, fmt.Errorf("decrypt webhook secret: %w", err)
+	}
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookHeaderEvent, delivery.EventType)
+	req.Header.Set(webhookHeaderDelivery, delivery.ID)
+	signing.SignRequest(req, webhookSigningService, secret, body, now)
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorBodySize))
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
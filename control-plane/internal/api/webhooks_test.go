@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func TestWebhookSubscriptions(t *testing.T) {
+	mem := memory.New()
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	for _, body := range []string{
+		`{"url":"ftp://example.com","event_types":["run.completed"]}`,
+		`{"url":"https://example.com/hook","event_types":[]}`,
+		`{"url":"https://example.com/hook","event_types":["Run Completed"]}`,
+	} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/webhooks", "", []byte(body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+	}
+
+	createResp := doWithKey(t, http.MethodPost, server.URL+"/webhooks", "", []byte(`{"name":"ops","url":"https://example.com/hook","event_types":["run.completed","Run.Failed","artifact.*"],"tags":["Nightly"]}`))
+	defer createResp.Body.Close()
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+	var created webhookRecord
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&created))
+	require.Equal(t, []string{"run.completed", "run.failed", "artifact.*"}, created.EventTypes)
+	require.Equal(t, []string{"nightly"}, created.Tags)
+	require.True(t, created.Enabled)
+	require.Contains(t, created.Secret, webhookSecretPrefix)
+
+	stored, err := mem.GetWebhookSubscription(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.NotContains(t, stored.SecretEnc, created.Secret)
+
+	listResp := doWithKey(t, http.MethodGet, server.URL+"/webhooks", "", nil)
+	defer listResp.Body.Close()
+	var listed webhooksResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Len(t, listed.Webhooks, 1)
+	require.Empty(t, listed.Webhooks[0].Secret)
+
+	updateResp := doWithKey(t, http.MethodPut, server.URL+"/webhooks/"+created.ID, "", []byte(`{"enabled":false,"rotate_secret":true}`))
+	defer updateResp.Body.Close()
+	require.Equal(t, http.StatusOK, updateResp.StatusCode)
+	var updated webhookRecord
+	require.NoError(t, json.NewDecoder(updateResp.Body).Decode(&updated))
+	require.False(t, updated.Enabled)
+	require.NotEmpty(t, updated.Secret)
+	require.NotEqual(t, created.Secret, updated.Secret)
+	require.Equal(t, created.EventTypes, updated.EventTypes)
+
+	deleteResp := doWithKey(t, http.MethodDelete, server.URL+"/webhooks/"+created.ID, "", nil)
+	deleteResp.Body.Close()
+	require.Equal(t, http.StatusOK, deleteResp.StatusCode)
+	missing := doWithKey(t, http.MethodDelete, server.URL+"/webhooks/"+created.ID, "", nil)
+	missing.Body.Close()
+	require.Equal(t, http.StatusNotFound, missing.StatusCode)
+}
+
+func TestWebhookPrivateAddressesRefused(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	for _, hook := range []string{"http://127.0.0.1:8081/health", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook", "http://10.0.0.5/hook", "http://localhost/hook"} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/webhooks", "", []byte(`{"url":"`+hook+`","event_types":["run.completed"]}`))
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, hook)
+		require.Contains(t, string(body), "WEBHOOKS_ALLOW_PRIVATE", hook)
+	}
+
+	createResp := doWithKey(t, http.MethodPost, server.URL+"/webhooks", "", []byte(`{"url":"https://example.com/hook","event_types":["run.completed"]}`))
+	defer createResp.Body.Close()
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+	var created webhookRecord
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&created))
+	updateResp := doWithKey(t, http.MethodPut, server.URL+"/webhooks/"+created.ID, "", []byte(`{"url":"http://169.254.169.254/latest/meta-data"}`))
+	updateResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, updateResp.StatusCode)
+
+	// A subscription that already points at a private address is refused
+	// when the delivery connects, so nothing of the response is recorded.
+	hits := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.Error(w, "internal secret", http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+	api := NewServer(mem, &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey})
+	secretEnc, _, err := api.encryptStoredSecret("whsec_test")
+	require.NoError(t, err)
+	require.NoError(t, mem.CreateWebhookSubscription(ctx, store.WebhookSubscription{ID: "hook-private", URL: receiver.URL, EventTypes: []string{"*"}, SecretEnc: secretEnc, Enabled: true}))
+	now := time.Now().UTC()
+	require.NoError(t, mem.CreateWebhookDelivery(ctx, store.WebhookDelivery{ID: "private", SubscriptionID: "hook-private", Status: webhookStatusPending, NextAttemptAt: now.Format(time.RFC3339Nano)}))
+	api.deliverDueWebhooks(ctx, now)
+
+	delivery, err := mem.GetWebhookDelivery(ctx, "private")
+	require.NoError(t, err)
+	require.Equal(t, webhookStatusPending, delivery.Status)
+	require.Contains(t, delivery.LastError, "not public")
+	require.NotContains(t, delivery.LastError, "internal secret")
+	require.Zero(t, hits)
+}
+
+func TestWebhookDeliveryRetriesAndRedelivery(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-nightly", Status: "running", Tags: []string{"nightly"}}))
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-adhoc", Status: "running"}))
+
+	var (
+		mu       sync.Mutex
+		secret   string
+		received []map[string]any
+		failNext = true
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		service, err := signing.Verify(r, body, map[string]string{webhookSigningService: secret}, nil, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, webhookSigningService, service)
+		if failNext {
+			failNext = false
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(body, &payload))
+		payload["delivery_header"] = r.Header.Get(webhookHeaderDelivery)
+		received = append(received, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	api := NewServer(mem, brokerMock, nil, config.Config{LLMSecretsKey: testLLMSecretsKey, WebhooksAllowPrivate: true})
+	server := httptest.NewServer(api.Router())
+	defer server.Close()
+
+	createResp := doWithKey(t, http.MethodPost, server.URL+"/webhooks", "", []byte(`{"url":"`+receiver.URL+`/hook","event_types":["run.completed","artifact.*"],"tags":["nightly"]}`))
+	defer createResp.Body.Close()
+	require.Equal(t, http.StatusCreated, createResp.StatusCode)
+	var subscription webhookRecord
+	require.NoError(t, json.NewDecoder(createResp.Body).Decode(&subscription))
+	mu.Lock()
+	secret = subscription.Secret
+	mu.Unlock()
+
+	for _, event := range []struct{ runID, body string }{
+		{"run-nightly", `{"type":"run.completed","source":"worker","payload":{"summary":"done"}}`},
+		{"run-nightly", `{"type":"step.completed","source":"worker","payload":{}}`},
+		{"run-adhoc", `{"type":"run.completed","source":"worker","payload":{}}`},
+	} {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/runs/"+event.runID+"/events", "", []byte(event.body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+
+	deliveries, err := mem.ListWebhookDeliveries(ctx, subscription.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, "run.completed", deliveries[0].EventType)
+	require.Equal(t, "run-nightly", deliveries[0].RunID)
+
+	now := time.Now().UTC()
+	api.deliverDueWebhooks(ctx, now)
+	failed, err := mem.GetWebhookDelivery(ctx, deliveries[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, webhookStatusPending, failed.Status)
+	require.Equal(t, 1, failed.Attempts)
+	require.Equal(t, http.StatusServiceUnavailable, failed.ResponseStatus)
+	require.Contains(t, failed.LastError, "try later")
+	require.Equal(t, now.Add(webhookBaseBackoff).Format(time.RFC3339Nano), failed.NextAttemptAt)
+
+	api.deliverDueWebhooks(ctx, now.Add(webhookBaseBackoff/2))
+	require.Empty(t, received)
+	api.deliverDueWebhooks(ctx, now.Add(webhookBaseBackoff))
+	delivered, err := mem.GetWebhookDelivery(ctx, deliveries[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, webhookStatusDelivered, delivered.Status)
+	require.Equal(t, 2, delivered.Attempts)
+	require.Empty(t, delivered.LastError)
+	require.Len(t, received, 1)
+	require.Equal(t, "run.completed", received[0]["type"])
+	require.Equal(t, map[string]any{"summary": "done"}, received[0]["data"])
+
+	redeliverResp := doWithKey(t, http.MethodPost, server.URL+"/webhooks/"+subscription.ID+"/deliveries/"+delivered.ID+"/redeliver", "", nil)
+	defer redeliverResp.Body.Close()
+	require.Equal(t, http.StatusAccepted, redeliverResp.StatusCode)
+	var redelivery webhookDeliveryRecord
+	require.NoError(t, json.NewDecoder(redeliverResp.Body).Decode(&redelivery))
+	require.NotEqual(t, delivered.ID, redelivery.ID)
+	require.Equal(t, webhookStatusPending, redelivery.Status)
+
+	api.deliverDueWebhooks(ctx, time.Now().UTC())
+	require.Len(t, received, 2)
+	require.Equal(t, redelivery.ID, received[1]["delivery_header"])
+
+	logResp := doWithKey(t, http.MethodGet, server.URL+"/webhooks/"+subscription.ID+"/deliveries", "", nil)
+	defer logResp.Body.Close()
+	var history webhookDeliveriesResponse
+	require.NoError(t, json.NewDecoder(logResp.Body).Decode(&history))
+	require.Len(t, history.Deliveries, 2)
+	for _, delivery := range history.Deliveries {
+		require.Equal(t, webhookStatusDelivered, delivery.Status)
+	}
+
+	unknown := doWithKey(t, http.MethodPost, server.URL+"/webhooks/"+subscription.ID+"/deliveries/missing/redeliver", "", nil)
+	unknown.Body.Close()
+	require.Equal(t, http.StatusNotFound, unknown.StatusCode)
+}
+
+func TestWebhookDeliveryGivesUp(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+	api := NewServer(mem, &MockBroker{}, nil, config.Config{LLMSecretsKey: testLLMSecretsKey, WebhooksAllowPrivate: true})
+	secretEnc, _, err := api.encryptStoredSecret("whsec_test")
+	require.NoError(t, err)
+	require.NoError(t, mem.CreateWebhookSubscription(ctx, store.WebhookSubscription{ID: "hook-1", URL: receiver.URL, EventTypes: []string{"*"}, SecretEnc: secretEnc, Enabled: true}))
+	require.NoError(t, mem.CreateWebhookSubscription(ctx, store.WebhookSubscription{ID: "hook-off", URL: receiver.URL, EventTypes: []string{"*"}, SecretEnc: secretEnc}))
+
+	now := time.Now().UTC()
+	require.NoError(t, mem.CreateWebhookDelivery(ctx, store.WebhookDelivery{ID: "last-try", SubscriptionID: "hook-1", Status: webhookStatusPending, Attempts: webhookMaxAttempts - 1, NextAttemptAt: now.Format(time.RFC3339Nano)}))
+	require.NoError(t, mem.CreateWebhookDelivery(ctx, store.WebhookDelivery{ID: "disabled", SubscriptionID: "hook-off", Status: webhookStatusPending, NextAttemptAt: now.Format(time.RFC3339Nano)}))
+	api.deliverDueWebhooks(ctx, now)
+
+	for _, id := range []string{"last-try", "disabled"} {
+		delivery, err := mem.GetWebhookDelivery(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, webhookStatusFailed, delivery.Status, id)
+		require.Empty(t, delivery.NextAttemptAt)
+	}
+}
+
+func TestArtifactWebhooks(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-1", Status: "running"}))
+	require.NoError(t, mem.CreateWebhookSubscription(ctx, store.WebhookSubscription{ID: "hook-1", URL: "https://example.com", EventTypes: []string{"artifact.*"}, Enabled: true}))
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	server := newTestServer(t, mem, brokerMock, nil, config.Config{})
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/runs/run-1/events", "", []byte(`{"type":"browser.snapshot","source":"worker","payload":{"uri":"file:///shot.png","artifact_id":"art-1","content_type":"image/png"}}`))
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	deliveries, err := mem.ListWebhookDeliveries(ctx, "hook-1", 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, "artifact.created", deliveries[0].EventType)
+	data, ok := deliveries[0].Payload["data"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "art-1", data["id"])
+	require.Equal(t, "image/png", data["content_type"])
+
+	// Events of dry runs are not delivered.
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-dry", Status: "running", Tags: []string{automationDryRunTag}}))
+	resp = doWithKey(t, http.MethodPost, server.URL+"/runs/run-dry/events", "", []byte(`{"type":"browser.snapshot","source":"worker","payload":{"uri":"file:///shot.png","artifact_id":"art-2","content_type":"image/png"}}`))
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	deliveries, err = mem.ListWebhookDeliveries(ctx, "hook-1", 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+}
+
+func TestWebhookMatchingAndBackoff(t *testing.T) {
+	require.True(t, webhookMatchesEventType([]string{"run.completed"}, "run.completed"))
+	require.True(t, webhookMatchesEventType([]string{"run.*"}, "run.failed"))
+	require.True(t, webhookMatchesEventType([]string{"*"}, "approval.requested"))
+	require.False(t, webhookMatchesEventType([]string{"run.*"}, "runner.started"))
+	require.False(t, webhookMatchesEventType([]string{"run.completed"}, "run.failed"))
+
+	require.Equal(t, 10*time.Second, webhookBackoff(1))
+	require.Equal(t, 20*time.Second, webhookBackoff(2))
+	require.Equal(t, 80*time.Second, webhookBackoff(4))
+	require.Equal(t, time.Hour, webhookBackoff(20))
+}
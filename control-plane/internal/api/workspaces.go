@@ -0,0 +1,368 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/skills"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+var (
+	workspaceIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+	workspaceRoles     = []string{"admin", "member"}
+)
+
+type workspaceRecord struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type workspaceRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type workspacesResponse struct {
+	Workspaces []workspaceRecord `json:"workspaces"`
+}
+
+type userRecord struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type userRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type usersResponse struct {
+	Users []userRecord `json:"users"`
+}
+
+type workspaceMemberRecord struct {
+	WorkspaceID string `json:"workspace_id"`
+	UserID      string `json:"user_id"`
+	Role        string `json:"role"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type workspaceMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type workspaceMembersResponse struct {
+	Members []workspaceMemberRecord `json:"members"`
+}
+
+func toWorkspaceRecord(workspace store.Workspace) workspaceRecord {
+	return workspaceRecord{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		CreatedAt: workspace.CreatedAt,
+		UpdatedAt: workspace.UpdatedAt,
+	}
+}
+
+func toUserRecord(user store.User) userRecord {
+	return userRecord{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+func toWorkspaceMemberRecord(member store.WorkspaceMember) workspaceMemberRecord {
+	return workspaceMemberRecord{
+		WorkspaceID: member.WorkspaceID,
+		UserID:      member.UserID,
+		Role:        member.Role,
+		CreatedAt:   member.CreatedAt,
+	}
+}
+
+func (s *Server) listWorkspaces(w http.ResponseWriter, r *http.Request) {
+	workspaces, err := s.store.ListWorkspaces(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]workspaceRecord, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		result = append(result, toWorkspaceRecord(workspace))
+	}
+	writeJSONStatus(w, workspacesResponse{Workspaces: result}, http.StatusOK)
+}
+
+func (s *Server) createWorkspace(w http.ResponseWriter, r *http.Request) {
+	req := workspaceRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	id := strings.ToLower(strings.TrimSpace(req.ID))
+	if id == "" {
+		id = uuid.NewString()
+	}
+	if !workspaceIDPattern.MatchString(id) {
+		http.Error(w, "id must be lowercase letters, digits and dashes", http.StatusBadRequest)
+		return
+	}
+	existing, err := s.store.GetWorkspace(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		http.Error(w, "workspace already exists", http.StatusConflict)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	workspace := store.Workspace{ID: id, Name: name, CreatedAt: now, UpdatedAt: now}
+	if err := s.store.CreateWorkspace(r.Context(), workspace); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, workspace.ID, nil, workspace)
+	if err := skills.EnsureBuiltins(store.WithWorkspace(r.Context(), id), s.store); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSONStatus(w, toWorkspaceRecord(workspace), http.StatusCreated)
+}
+
+// deleteWorkspace removes the workspace together with its runs, skills,
+// context, memory, automations, settings overrides and API keys.
+func (s *Server) deleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == store.DefaultWorkspaceID {
+		http.Error(w, "the default workspace cannot be deleted", http.StatusBadRequest)
+		return
+	}
+	current, err := s.store.GetWorkspace(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "workspace not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteWorkspace(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.store.ListUsers(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]userRecord, 0, len(users))
+	for _, user := range users {
+		result = append(result, toUserRecord(user))
+	}
+	writeJSONStatus(w, usersResponse{Users: result}, http.StatusOK)
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	req := userRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email != "" {
+		users, err := s.store.ListUsers(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, user := range users {
+			if strings.EqualFold(user.Email, email) {
+				http.Error(w, "a user with that email already exists", http.StatusConflict)
+				return
+			}
+		}
+	}
+	user := store.User{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Email:     email,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := s.store.CreateUser(r.Context(), user); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, user.ID, nil, user)
+	writeJSONStatus(w, toUserRecord(user), http.StatusCreated)
+}
+
+// deleteUser also revokes the user's API keys; their runs are kept.
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	current, err := s.store.GetUser(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteUser(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, id, current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
+
+func (s *Server) listWorkspaceMembers(w http.ResponseWriter, r *http.Request) {
+	workspaceID := strings.TrimSpace(chi.URLParam(r, "id"))
+	workspace, err := s.store.GetWorkspace(r.Context(), workspaceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if workspace == nil {
+		http.Error(w, "workspace not found", http.StatusNotFound)
+		return
+	}
+	members, err := s.store.ListWorkspaceMembers(r.Context(), workspaceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := make([]workspaceMemberRecord, 0, len(members))
+	for _, member := range members {
+		result = append(result, toWorkspaceMemberRecord(member))
+	}
+	writeJSONStatus(w, workspaceMembersResponse{Members: result}, http.StatusOK)
+}
+
+func (s *Server) upsertWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID := strings.TrimSpace(chi.URLParam(r, "id"))
+	userID := strings.TrimSpace(chi.URLParam(r, "userID"))
+	req := workspaceMemberRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+	role := strings.ToLower(strings.TrimSpace(req.Role))
+	if role == "" {
+		role = "member"
+	}
+	validRole := false
+	for _, candidate := range workspaceRoles {
+		if role == candidate {
+			validRole = true
+			break
+		}
+	}
+	if !validRole {
+		http.Error(w, "role must be one of: "+strings.Join(workspaceRoles, ", "), http.StatusBadRequest)
+		return
+	}
+	workspace, err := s.store.GetWorkspace(r.Context(), workspaceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if workspace == nil {
+		http.Error(w, "workspace not found", http.StatusNotFound)
+		return
+	}
+	user, err := s.store.GetUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(w, "user not found", http.StatusNotFound)
+		return
+	}
+	current, err := s.findWorkspaceMember(r.Context(), workspaceID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	member := store.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        role,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if current != nil {
+		member.CreatedAt = current.CreatedAt
+	}
+	if err := s.store.UpsertWorkspaceMember(r.Context(), member); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, userID, current, member)
+	writeJSONStatus(w, toWorkspaceMemberRecord(member), http.StatusOK)
+}
+
+func (s *Server) deleteWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID := strings.TrimSpace(chi.URLParam(r, "id"))
+	userID := strings.TrimSpace(chi.URLParam(r, "userID"))
+	current, err := s.findWorkspaceMember(r.Context(), workspaceID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if current == nil {
+		http.Error(w, "workspace member not found", http.StatusNotFound)
+		return
+	}
+	if err := s.store.DeleteWorkspaceMember(r.Context(), workspaceID, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recordAuditChange(r, userID, current, nil)
+	writeJSONStatus(w, map[string]any{"deleted": true}, http.StatusOK)
+}
+
+func (s *Server) findWorkspaceMember(ctx context.Context, workspaceID string, userID string) (*store.WorkspaceMember, error) {
+	members, err := s.store.ListWorkspaceMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if member.UserID == userID {
+			return &member, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/config"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
+)
+
+func doInWorkspace(t *testing.T, method string, url string, key string, workspaceID string, body []byte) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	if workspaceID != "" {
+		req.Header.Set(workspaceHeader, workspaceID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestWorkspaceIsolation(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Mode: "remote", Provider: "openai", Model: "gpt-4.1"}))
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	server := newTestServer(t, mem, brokerMock, nil, config.Config{APIAuthEnabled: true, APIBootstrapKey: testBootstrapKey})
+	defer server.Close()
+
+	workspaceResp := doWithKey(t, http.MethodPost, server.URL+"/workspaces", testBootstrapKey, []byte(`{"id":"team","name":"Team"}`))
+	workspaceResp.Body.Close()
+	require.Equal(t, http.StatusCreated, workspaceResp.StatusCode)
+	teamSkills, err := mem.ListSkills(store.WithWorkspace(ctx, "team"))
+	require.NoError(t, err)
+	require.NotEmpty(t, teamSkills)
+
+	userResp := doWithKey(t, http.MethodPost, server.URL+"/users", testBootstrapKey, []byte(`{"name":"Ana","email":"ana@example.com"}`))
+	defer userResp.Body.Close()
+	require.Equal(t, http.StatusCreated, userResp.StatusCode)
+	var user userRecord
+	require.NoError(t, json.NewDecoder(userResp.Body).Decode(&user))
+
+	unbound := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", testBootstrapKey, []byte(`{"name":"ana","scopes":["runs:read","runs:write"],"workspace_id":"team","user_id":"`+user.ID+`"}`))
+	unbound.Body.Close()
+	require.Equal(t, http.StatusBadRequest, unbound.StatusCode)
+
+	memberResp := doWithKey(t, http.MethodPut, server.URL+"/workspaces/team/members/"+user.ID, testBootstrapKey, []byte(`{"role":"admin"}`))
+	memberResp.Body.Close()
+	require.Equal(t, http.StatusOK, memberResp.StatusCode)
+
+	keyResp := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", testBootstrapKey, []byte(`{"name":"ana","scopes":["runs:read","runs:write"],"workspace_id":"team","user_id":"`+user.ID+`"}`))
+	defer keyResp.Body.Close()
+	require.Equal(t, http.StatusCreated, keyResp.StatusCode)
+	var key apiKeyRecord
+	require.NoError(t, json.NewDecoder(keyResp.Body).Decode(&key))
+	require.Equal(t, "team", key.WorkspaceID)
+
+	runResp := doWithKey(t, http.MethodPost, server.URL+"/runs", key.Key, []byte(`{}`))
+	defer runResp.Body.Close()
+	require.Equal(t, http.StatusOK, runResp.StatusCode)
+	var created struct {
+		ID string `json:"run_id"`
+	}
+	require.NoError(t, json.NewDecoder(runResp.Body).Decode(&created))
+
+	run, err := mem.GetRun(store.WithWorkspace(ctx, "team"), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, run.UserID)
+	require.Equal(t, "api_key:ana", run.CreatedBy)
+
+	hidden := doWithKey(t, http.MethodGet, server.URL+"/runs/"+created.ID+"/steps", testBootstrapKey, nil)
+	hidden.Body.Close()
+	require.Equal(t, http.StatusNotFound, hidden.StatusCode)
+
+	defaultList := doWithKey(t, http.MethodGet, server.URL+"/runs", testBootstrapKey, nil)
+	defer defaultList.Body.Close()
+	var defaultRuns listRunsResponse
+	require.NoError(t, json.NewDecoder(defaultList.Body).Decode(&defaultRuns))
+	require.Empty(t, defaultRuns.Runs)
+
+	teamList := doInWorkspace(t, http.MethodGet, server.URL+"/runs?mine=true", key.Key, "", nil)
+	defer teamList.Body.Close()
+	var teamRuns listRunsResponse
+	require.NoError(t, json.NewDecoder(teamList.Body).Decode(&teamRuns))
+	require.Len(t, teamRuns.Runs, 1)
+	require.Equal(t, user.ID, teamRuns.Runs[0].UserID)
+
+	adminView := doInWorkspace(t, http.MethodGet, server.URL+"/runs/"+created.ID, testBootstrapKey, "team", nil)
+	adminView.Body.Close()
+	require.Equal(t, http.StatusOK, adminView.StatusCode)
+
+	crossed := doInWorkspace(t, http.MethodGet, server.URL+"/runs", key.Key, store.DefaultWorkspaceID, nil)
+	crossed.Body.Close()
+	require.Equal(t, http.StatusForbidden, crossed.StatusCode)
+
+	missing := doInWorkspace(t, http.MethodGet, server.URL+"/runs", testBootstrapKey, "nope", nil)
+	missing.Body.Close()
+	require.Equal(t, http.StatusNotFound, missing.StatusCode)
+}
+
+func TestWorkspaceSettingsOverrides(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.New()
+	require.NoError(t, mem.CreateWorkspace(ctx, store.Workspace{ID: "team", Name: "Team"}))
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	base := doInWorkspace(t, http.MethodPost, server.URL+"/settings/personality", "", "", []byte(`{"content":"friendly"}`))
+	base.Body.Close()
+	require.Equal(t, http.StatusOK, base.StatusCode)
+
+	inherited := doInWorkspace(t, http.MethodGet, server.URL+"/settings/personality", "", "team", nil)
+	defer inherited.Body.Close()
+	var inheritedSettings personalitySettingsResponse
+	require.NoError(t, json.NewDecoder(inherited.Body).Decode(&inheritedSettings))
+	require.Equal(t, "friendly", inheritedSettings.Content)
+
+	override := doInWorkspace(t, http.MethodPost, server.URL+"/settings/personality", "", "team", []byte(`{"content":"terse"}`))
+	override.Body.Close()
+	require.Equal(t, http.StatusOK, override.StatusCode)
+
+	teamSettings, err := mem.GetPersonalitySettings(store.WithWorkspace(ctx, "team"))
+	require.NoError(t, err)
+	require.Equal(t, "terse", teamSettings.Content)
+	defaultSettings, err := mem.GetPersonalitySettings(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "friendly", defaultSettings.Content)
+}
+
+func TestWorkspaceAdministration(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	server := newTestServer(t, memory.New(), &MockBroker{}, nil, config.Config{})
+	defer server.Close()
+
+	invalid := doWithKey(t, http.MethodPost, server.URL+"/workspaces", "", []byte(`{"id":"Bad Id","name":"Bad"}`))
+	invalid.Body.Close()
+	require.Equal(t, http.StatusBadRequest, invalid.StatusCode)
+
+	created := doWithKey(t, http.MethodPost, server.URL+"/workspaces", "", []byte(`{"id":"ops","name":"Ops"}`))
+	created.Body.Close()
+	require.Equal(t, http.StatusCreated, created.StatusCode)
+
+	duplicate := doWithKey(t, http.MethodPost, server.URL+"/workspaces", "", []byte(`{"id":"ops","name":"Ops"}`))
+	duplicate.Body.Close()
+	require.Equal(t, http.StatusConflict, duplicate.StatusCode)
+
+	listResp := doWithKey(t, http.MethodGet, server.URL+"/workspaces", "", nil)
+	defer listResp.Body.Close()
+	var listed workspacesResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Len(t, listed.Workspaces, 2)
+
+	protected := doWithKey(t, http.MethodDelete, server.URL+"/workspaces/default", "", nil)
+	protected.Body.Close()
+	require.Equal(t, http.StatusBadRequest, protected.StatusCode)
+
+	deleted := doWithKey(t, http.MethodDelete, server.URL+"/workspaces/ops", "", nil)
+	deleted.Body.Close()
+	require.Equal(t, http.StatusOK, deleted.StatusCode)
+
+	missingMember := doWithKey(t, http.MethodDelete, server.URL+"/workspaces/default/members/nobody", "", nil)
+	missingMember.Body.Close()
+	require.Equal(t, http.StatusNotFound, missingMember.StatusCode)
+}
+
+func TestWorkspaceBoundAdminKey(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	mem := memory.New()
+	server := newTestServer(t, mem, &MockBroker{}, nil, config.Config{APIAuthEnabled: true, APIBootstrapKey: testBootstrapKey, LLMSecretsKey: testLLMSecretsKey})
+	defer server.Close()
+
+	workspaceResp := doWithKey(t, http.MethodPost, server.URL+"/workspaces", testBootstrapKey, []byte(`{"id":"team","name":"Team"}`))
+	workspaceResp.Body.Close()
+	require.Equal(t, http.StatusCreated, workspaceResp.StatusCode)
+	keyResp := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", testBootstrapKey, []byte(`{"name":"team-admin","scopes":["settings:admin","runs:read"],"workspace_id":"team"}`))
+	defer keyResp.Body.Close()
+	require.Equal(t, http.StatusCreated, keyResp.StatusCode)
+	var teamAdmin apiKeyRecord
+	require.NoError(t, json.NewDecoder(keyResp.Body).Decode(&teamAdmin))
+	defaultResp := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", testBootstrapKey, []byte(`{"name":"ci","scopes":["runs:read"]}`))
+	defer defaultResp.Body.Close()
+	var defaultKey apiKeyRecord
+	require.NoError(t, json.NewDecoder(defaultResp.Body).Decode(&defaultKey))
+	require.Equal(t, store.DefaultWorkspaceID, defaultKey.WorkspaceID)
+
+	foreign := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", teamAdmin.Key, []byte(`{"name":"escape","scopes":["settings:admin"],"workspace_id":"default"}`))
+	foreign.Body.Close()
+	require.Equal(t, http.StatusForbidden, foreign.StatusCode)
+	own := doWithKey(t, http.MethodPost, server.URL+"/settings/api-keys", teamAdmin.Key, []byte(`{"name":"team-ci","scopes":["runs:read"]}`))
+	defer own.Body.Close()
+	require.Equal(t, http.StatusCreated, own.StatusCode)
+	var ownKey apiKeyRecord
+	require.NoError(t, json.NewDecoder(own.Body).Decode(&ownKey))
+	require.Equal(t, "team", ownKey.WorkspaceID)
+
+	listResp := doWithKey(t, http.MethodGet, server.URL+"/settings/api-keys", teamAdmin.Key, nil)
+	defer listResp.Body.Close()
+	var listed apiKeysResponse
+	require.NoError(t, json.NewDecoder(listResp.Body).Decode(&listed))
+	require.Len(t, listed.Keys, 2)
+	for _, key := range listed.Keys {
+		require.Equal(t, "team", key.WorkspaceID)
+	}
+	deleteForeign := doWithKey(t, http.MethodDelete, server.URL+"/settings/api-keys/"+defaultKey.ID, teamAdmin.Key, nil)
+	deleteForeign.Body.Close()
+	require.Equal(t, http.StatusNotFound, deleteForeign.StatusCode)
+
+	for _, route := range []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/workspaces", ""},
+		{http.MethodPost, "/workspaces", `{"id":"other","name":"Other"}`},
+		{http.MethodDelete, "/workspaces/team", ""},
+		{http.MethodGet, "/workspaces/team/members", ""},
+		{http.MethodGet, "/users", ""},
+		{http.MethodPost, "/users", `{"name":"Eve"}`},
+	} {
+		resp := doWithKey(t, route.method, server.URL+route.path, teamAdmin.Key, []byte(route.body))
+		resp.Body.Close()
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, route.method+" "+route.path)
+	}
+
+	teamSecret := doWithKey(t, http.MethodPost, server.URL+"/secrets", teamAdmin.Key, []byte(`{"name":"API_TOKEN","value":"team-token"}`))
+	teamSecret.Body.Close()
+	require.Equal(t, http.StatusCreated, teamSecret.StatusCode)
+	defaultSecret := doWithKey(t, http.MethodPost, server.URL+"/secrets", testBootstrapKey, []byte(`{"name":"API_TOKEN","value":"default-token"}`))
+	defaultSecret.Body.Close()
+	require.Equal(t, http.StatusCreated, defaultSecret.StatusCode)
+	defaultSecrets, err := mem.ListSecrets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, defaultSecrets, 1)
+
+	rotated := func(key string) secretRotationResponse {
+		resp := doWithKey(t, http.MethodPost, server.URL+"/settings/secrets/rotate", key, nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var report secretRotationResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		return report
+	}
+	require.Equal(t, 1, rotated(teamAdmin.Key).Unchanged)
+	require.Equal(t, 2, rotated(testBootstrapKey).Unchanged)
+}
+
+func TestWorkerEventsFollowRunWorkspace(t *testing.T) {
+	mem := memory.New()
+	teamCtx := store.WithWorkspace(context.Background(), "team")
+	require.NoError(t, mem.CreateRun(teamCtx, store.Run{ID: "run-team", Status: "running"}))
+	brokerMock := &MockBroker{}
+	brokerMock.On("Publish", mock.Anything).Return()
+	server := newTestServer(t, mem, brokerMock, nil, config.Config{})
+	defer server.Close()
+
+	resp := doWithKey(t, http.MethodPost, server.URL+"/runs/run-team/events", "", []byte(`{"type":"tool.started","source":"tool","payload":{"tool_name":"noop"}}`))
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	events, err := mem.ListEvents(teamCtx, "run-team", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	readResp := doWithKey(t, http.MethodGet, server.URL+"/runs/run-team/steps", "", nil)
+	readResp.Body.Close()
+	require.Equal(t, http.StatusNotFound, readResp.StatusCode)
+}
@@ -7,26 +7,48 @@ import (
 )
 
 type Config struct {
-	ControlPlanePort      string
-	ControlPlaneURL       string
-	ToolRunnerURL         string
-	PostgresURL           string
-	TemporalAddress       string
-	TemporalTaskQueue     string
-	LLMMode               string
-	LLMProvider           string
-	LLMModel              string
-	LLMBaseURL            string
-	LLMFallbackProvider   string
-	LLMFallbackModel      string
-	LLMFallbackBaseURL    string
-	OpenAIAPIKey          string
-	OpenRouterAPIKey      string
-	OpenCodeAPIKey        string
-	DiscordWebhookURL     string
-	CodexAuthPath         string
-	CodexHome             string
-	LLMSecretsKey         string
+	ControlPlanePort    string
+	ControlPlaneURL     string
+	ToolRunnerURL       string
+	PostgresURL         string
+	TemporalAddress     string
+	TemporalTaskQueue   string
+	LLMMode             string
+	LLMProvider         string
+	LLMModel            string
+	LLMBaseURL          string
+	LLMFallbackProvider string
+	LLMFallbackModel    string
+	LLMFallbackBaseURL  string
+	LLMUtilityProvider  string
+	LLMUtilityModel     string
+	LLMUtilityBaseURL   string
+	// LLMUtilityTimeoutSeconds bounds each utility model request, including
+	// run titles routed away from the primary model.
+	LLMUtilityTimeoutSeconds int
+	OpenAIAPIKey             string
+	OpenRouterAPIKey         string
+	OpenCodeAPIKey           string
+	DiscordWebhookURL        string
+	CodexAuthPath            string
+	CodexHome                string
+	LLMSecretsKey            string
+	LLMSecretsPreviousKeys   string
+	APIAuthEnabled           bool
+	APIBootstrapKey          string
+	ControlPlaneAPIKey       string
+	CORSAllowedOrigins       string
+	EventSigningSecrets      string
+	EventSigningSecret       string
+	// AutomationSchedulerEnabled runs the in-process automation scheduler.
+	// Disable it on all but one control plane replica.
+	AutomationSchedulerEnabled bool
+	// AutomationFeedsAllowPrivate lets automation feeds point at loopback,
+	// private and link-local addresses.
+	AutomationFeedsAllowPrivate bool
+	// WebhooksAllowPrivate lets webhook subscriptions deliver to loopback,
+	// private and link-local addresses.
+	WebhooksAllowPrivate  bool
 	MemoryMaxResults      int
 	MemoryMaxEntryChars   int
 	MemoryChunkChars      int
@@ -43,33 +65,47 @@ func Load() Config {
 		postgresURL = buildPostgresURL()
 	}
 	return Config{
-		ControlPlanePort:      controlPlanePort,
-		ControlPlaneURL:       getEnv("CONTROL_PLANE_URL", "http://localhost:"+controlPlanePort),
-		ToolRunnerURL:         getEnv("TOOL_RUNNER_URL", "http://localhost:8081"),
-		PostgresURL:           postgresURL,
-		TemporalAddress:       getEnv("TEMPORAL_ADDRESS", "localhost:7233"),
-		TemporalTaskQueue:     getEnv("TEMPORAL_TASK_QUEUE", "gavryn-runs"),
-		LLMMode:               getEnv("LLM_MODE", "remote"),
-		LLMProvider:           getEnv("LLM_PROVIDER", "codex"),
-		LLMModel:              getEnv("LLM_MODEL", "gpt-5.2-codex"),
-		LLMBaseURL:            getEnv("LLM_BASE_URL", ""),
-		LLMFallbackProvider:   getEnv("LLM_FALLBACK_PROVIDER", ""),
-		LLMFallbackModel:      getEnv("LLM_FALLBACK_MODEL", ""),
-		LLMFallbackBaseURL:    getEnv("LLM_FALLBACK_BASE_URL", ""),
-		OpenAIAPIKey:          getEnv("OPENAI_API_KEY", ""),
-		OpenRouterAPIKey:      getEnv("OPENROUTER_API_KEY", ""),
-		OpenCodeAPIKey:        getEnv("OPENCODE_API_KEY", ""),
-		DiscordWebhookURL:     getEnv("DISCORD_WEBHOOK_URL", ""),
-		CodexAuthPath:         getEnv("CODEX_AUTH_PATH", ""),
-		CodexHome:             getEnv("CODEX_HOME", ""),
-		LLMSecretsKey:         getEnv("LLM_SECRETS_KEY", ""),
-		MemoryMaxResults:      getEnvInt("MEMORY_MAX_RESULTS", 5),
-		MemoryMaxEntryChars:   getEnvInt("MEMORY_MAX_ENTRY_CHARS", 400),
-		MemoryChunkChars:      getEnvInt("MEMORY_CHUNK_CHARS", 1200),
-		MemoryChunkOverlap:    getEnvInt("MEMORY_CHUNK_OVERLAP", 200),
-		MemoryMaxChunks:       getEnvInt("MEMORY_MAX_CHUNKS", 6),
-		MemoryMinContentChars: getEnvInt("MEMORY_MIN_CONTENT_CHARS", 12),
-		MemoryMaxContentBytes: getEnvInt("MEMORY_MAX_CONTENT_BYTES", 20000),
+		ControlPlanePort:            controlPlanePort,
+		ControlPlaneURL:             getEnv("CONTROL_PLANE_URL", "http://localhost:"+controlPlanePort),
+		ToolRunnerURL:               getEnv("TOOL_RUNNER_URL", "http://localhost:8081"),
+		PostgresURL:                 postgresURL,
+		TemporalAddress:             getEnv("TEMPORAL_ADDRESS", "localhost:7233"),
+		TemporalTaskQueue:           getEnv("TEMPORAL_TASK_QUEUE", "gavryn-runs"),
+		LLMMode:                     getEnv("LLM_MODE", "remote"),
+		LLMProvider:                 getEnv("LLM_PROVIDER", "codex"),
+		LLMModel:                    getEnv("LLM_MODEL", "gpt-5.2-codex"),
+		LLMBaseURL:                  getEnv("LLM_BASE_URL", ""),
+		LLMFallbackProvider:         getEnv("LLM_FALLBACK_PROVIDER", ""),
+		LLMFallbackModel:            getEnv("LLM_FALLBACK_MODEL", ""),
+		LLMFallbackBaseURL:          getEnv("LLM_FALLBACK_BASE_URL", ""),
+		LLMUtilityProvider:          getEnv("LLM_UTILITY_PROVIDER", ""),
+		LLMUtilityModel:             getEnv("LLM_UTILITY_MODEL", ""),
+		LLMUtilityBaseURL:           getEnv("LLM_UTILITY_BASE_URL", ""),
+		LLMUtilityTimeoutSeconds:    getEnvInt("LLM_UTILITY_TIMEOUT_SECONDS", 10),
+		OpenAIAPIKey:                getEnv("OPENAI_API_KEY", ""),
+		OpenRouterAPIKey:            getEnv("OPENROUTER_API_KEY", ""),
+		OpenCodeAPIKey:              getEnv("OPENCODE_API_KEY", ""),
+		DiscordWebhookURL:           getEnv("DISCORD_WEBHOOK_URL", ""),
+		CodexAuthPath:               getEnv("CODEX_AUTH_PATH", ""),
+		CodexHome:                   getEnv("CODEX_HOME", ""),
+		LLMSecretsKey:               getEnv("LLM_SECRETS_KEY", ""),
+		LLMSecretsPreviousKeys:      getEnv("LLM_SECRETS_PREVIOUS_KEYS", ""),
+		APIAuthEnabled:              getEnvBool("API_AUTH_ENABLED", false),
+		APIBootstrapKey:             getEnv("API_BOOTSTRAP_KEY", ""),
+		ControlPlaneAPIKey:          getEnv("CONTROL_PLANE_API_KEY", ""),
+		CORSAllowedOrigins:          getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:5173,http://127.0.0.1:5173"),
+		EventSigningSecrets:         getEnv("EVENT_SIGNING_SECRETS", ""),
+		EventSigningSecret:          getEnv("EVENT_SIGNING_SECRET", ""),
+		AutomationSchedulerEnabled:  getEnvBool("AUTOMATION_SCHEDULER_ENABLED", true),
+		AutomationFeedsAllowPrivate: getEnvBool("AUTOMATION_FEEDS_ALLOW_PRIVATE", false),
+		WebhooksAllowPrivate:        getEnvBool("WEBHOOKS_ALLOW_PRIVATE", false),
+		MemoryMaxResults:            getEnvInt("MEMORY_MAX_RESULTS", 5),
+		MemoryMaxEntryChars:         getEnvInt("MEMORY_MAX_ENTRY_CHARS", 400),
+		MemoryChunkChars:            getEnvInt("MEMORY_CHUNK_CHARS", 1200),
+		MemoryChunkOverlap:          getEnvInt("MEMORY_CHUNK_OVERLAP", 200),
+		MemoryMaxChunks:             getEnvInt("MEMORY_MAX_CHUNKS", 6),
+		MemoryMinContentChars:       getEnvInt("MEMORY_MIN_CONTENT_CHARS", 12),
+		MemoryMaxContentBytes:       getEnvInt("MEMORY_MAX_CONTENT_BYTES", 20000),
 	}
 }
 
@@ -90,6 +126,16 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
 func buildPostgresURL() string {
 	user := getEnv("POSTGRES_USER", "gavryn")
 	password := getEnv("POSTGRES_PASSWORD", "gavryn")
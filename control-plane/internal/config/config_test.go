@@ -24,12 +24,25 @@ var allEnvKeys = []string{
 	"LLM_FALLBACK_PROVIDER",
 	"LLM_FALLBACK_MODEL",
 	"LLM_FALLBACK_BASE_URL",
+	"LLM_UTILITY_PROVIDER",
+	"LLM_UTILITY_MODEL",
+	"LLM_UTILITY_BASE_URL",
+	"LLM_UTILITY_TIMEOUT_SECONDS",
 	"OPENAI_API_KEY",
 	"OPENROUTER_API_KEY",
 	"DISCORD_WEBHOOK_URL",
 	"CODEX_AUTH_PATH",
 	"CODEX_HOME",
 	"LLM_SECRETS_KEY",
+	"API_AUTH_ENABLED",
+	"API_BOOTSTRAP_KEY",
+	"CONTROL_PLANE_API_KEY",
+	"CORS_ALLOWED_ORIGINS",
+	"EVENT_SIGNING_SECRETS",
+	"EVENT_SIGNING_SECRET",
+	"AUTOMATION_SCHEDULER_ENABLED",
+	"AUTOMATION_FEEDS_ALLOW_PRIVATE",
+	"WEBHOOKS_ALLOW_PRIVATE",
 	"MEMORY_MAX_RESULTS",
 	"MEMORY_MAX_ENTRY_CHARS",
 	"MEMORY_CHUNK_CHARS",
@@ -107,6 +120,9 @@ func TestLoad_AllDefaults(t *testing.T) {
 	if cfg.LLMSecretsKey != "" {
 		t.Fatalf("LLMSecretsKey = %q, want %q", cfg.LLMSecretsKey, "")
 	}
+	if cfg.LLMUtilityTimeoutSeconds != 10 {
+		t.Fatalf("LLMUtilityTimeoutSeconds = %d, want %d", cfg.LLMUtilityTimeoutSeconds, 10)
+	}
 	if cfg.MemoryMaxResults != 5 {
 		t.Fatalf("MemoryMaxResults = %d, want %d", cfg.MemoryMaxResults, 5)
 	}
@@ -128,6 +144,21 @@ func TestLoad_AllDefaults(t *testing.T) {
 	if cfg.MemoryMaxContentBytes != 20000 {
 		t.Fatalf("MemoryMaxContentBytes = %d, want %d", cfg.MemoryMaxContentBytes, 20000)
 	}
+	if cfg.APIAuthEnabled {
+		t.Fatalf("APIAuthEnabled = true, want false")
+	}
+	if !cfg.AutomationSchedulerEnabled {
+		t.Fatalf("AutomationSchedulerEnabled = false, want true")
+	}
+	if cfg.AutomationFeedsAllowPrivate {
+		t.Fatalf("AutomationFeedsAllowPrivate = true, want false")
+	}
+	if cfg.WebhooksAllowPrivate {
+		t.Fatalf("WebhooksAllowPrivate = true, want false")
+	}
+	if cfg.CORSAllowedOrigins != "http://localhost:5173,http://127.0.0.1:5173" {
+		t.Fatalf("CORSAllowedOrigins = %q, want %q", cfg.CORSAllowedOrigins, "http://localhost:5173,http://127.0.0.1:5173")
+	}
 }
 
 func TestLoad_AllEnvVars(t *testing.T) {
@@ -144,12 +175,26 @@ func TestLoad_AllEnvVars(t *testing.T) {
 	t.Setenv("LLM_FALLBACK_PROVIDER", "openrouter")
 	t.Setenv("LLM_FALLBACK_MODEL", "anthropic/claude-3.5-sonnet")
 	t.Setenv("LLM_FALLBACK_BASE_URL", "https://openrouter.example.test/v1")
+	t.Setenv("LLM_UTILITY_PROVIDER", "openai")
+	t.Setenv("LLM_UTILITY_MODEL", "gpt-4.1-nano")
+	t.Setenv("LLM_UTILITY_BASE_URL", "https://utility.example.test/v1")
+	t.Setenv("LLM_UTILITY_TIMEOUT_SECONDS", "25")
 	t.Setenv("OPENAI_API_KEY", "openai-key")
 	t.Setenv("OPENROUTER_API_KEY", "openrouter-key")
 	t.Setenv("DISCORD_WEBHOOK_URL", "https://discord.example.test/webhook")
 	t.Setenv("CODEX_AUTH_PATH", "/tmp/codex/auth.json")
 	t.Setenv("CODEX_HOME", "/tmp/codex/home")
 	t.Setenv("LLM_SECRETS_KEY", "secrets-key")
+	t.Setenv("LLM_SECRETS_PREVIOUS_KEYS", "old-key-1,old-key-2")
+	t.Setenv("API_AUTH_ENABLED", "true")
+	t.Setenv("API_BOOTSTRAP_KEY", "bootstrap-key")
+	t.Setenv("CONTROL_PLANE_API_KEY", "worker-key")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.test")
+	t.Setenv("EVENT_SIGNING_SECRETS", "worker=one,tool_runner=two")
+	t.Setenv("EVENT_SIGNING_SECRET", "one")
+	t.Setenv("AUTOMATION_SCHEDULER_ENABLED", "false")
+	t.Setenv("AUTOMATION_FEEDS_ALLOW_PRIVATE", "true")
+	t.Setenv("WEBHOOKS_ALLOW_PRIVATE", "true")
 	t.Setenv("MEMORY_MAX_RESULTS", "9")
 	t.Setenv("MEMORY_MAX_ENTRY_CHARS", "512")
 	t.Setenv("MEMORY_CHUNK_CHARS", "1500")
@@ -199,6 +244,18 @@ func TestLoad_AllEnvVars(t *testing.T) {
 	if cfg.LLMFallbackBaseURL != "https://openrouter.example.test/v1" {
 		t.Fatalf("LLMFallbackBaseURL = %q, want %q", cfg.LLMFallbackBaseURL, "https://openrouter.example.test/v1")
 	}
+	if cfg.LLMUtilityProvider != "openai" {
+		t.Fatalf("LLMUtilityProvider = %q, want %q", cfg.LLMUtilityProvider, "openai")
+	}
+	if cfg.LLMUtilityModel != "gpt-4.1-nano" {
+		t.Fatalf("LLMUtilityModel = %q, want %q", cfg.LLMUtilityModel, "gpt-4.1-nano")
+	}
+	if cfg.LLMUtilityBaseURL != "https://utility.example.test/v1" {
+		t.Fatalf("LLMUtilityBaseURL = %q, want %q", cfg.LLMUtilityBaseURL, "https://utility.example.test/v1")
+	}
+	if cfg.LLMUtilityTimeoutSeconds != 25 {
+		t.Fatalf("LLMUtilityTimeoutSeconds = %d, want %d", cfg.LLMUtilityTimeoutSeconds, 25)
+	}
 	if cfg.OpenAIAPIKey != "openai-key" {
 		t.Fatalf("OpenAIAPIKey = %q, want %q", cfg.OpenAIAPIKey, "openai-key")
 	}
@@ -217,6 +274,36 @@ func TestLoad_AllEnvVars(t *testing.T) {
 	if cfg.LLMSecretsKey != "secrets-key" {
 		t.Fatalf("LLMSecretsKey = %q, want %q", cfg.LLMSecretsKey, "secrets-key")
 	}
+	if cfg.LLMSecretsPreviousKeys != "old-key-1,old-key-2" {
+		t.Fatalf("LLMSecretsPreviousKeys = %q, want %q", cfg.LLMSecretsPreviousKeys, "old-key-1,old-key-2")
+	}
+	if !cfg.APIAuthEnabled {
+		t.Fatalf("APIAuthEnabled = false, want true")
+	}
+	if cfg.APIBootstrapKey != "bootstrap-key" {
+		t.Fatalf("APIBootstrapKey = %q, want %q", cfg.APIBootstrapKey, "bootstrap-key")
+	}
+	if cfg.ControlPlaneAPIKey != "worker-key" {
+		t.Fatalf("ControlPlaneAPIKey = %q, want %q", cfg.ControlPlaneAPIKey, "worker-key")
+	}
+	if cfg.CORSAllowedOrigins != "https://app.example.test" {
+		t.Fatalf("CORSAllowedOrigins = %q, want %q", cfg.CORSAllowedOrigins, "https://app.example.test")
+	}
+	if cfg.EventSigningSecrets != "worker=one,tool_runner=two" {
+		t.Fatalf("EventSigningSecrets = %q, want %q", cfg.EventSigningSecrets, "worker=one,tool_runner=two")
+	}
+	if cfg.EventSigningSecret != "one" {
+		t.Fatalf("EventSigningSecret = %q, want %q", cfg.EventSigningSecret, "one")
+	}
+	if cfg.AutomationSchedulerEnabled {
+		t.Fatalf("AutomationSchedulerEnabled = true, want false")
+	}
+	if !cfg.AutomationFeedsAllowPrivate {
+		t.Fatalf("AutomationFeedsAllowPrivate = false, want true")
+	}
+	if !cfg.WebhooksAllowPrivate {
+		t.Fatalf("WebhooksAllowPrivate = false, want true")
+	}
 	if cfg.MemoryMaxResults != 9 {
 		t.Fatalf("MemoryMaxResults = %d, want %d", cfg.MemoryMaxResults, 9)
 	}
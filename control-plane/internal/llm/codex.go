@@ -165,7 +165,7 @@ func (p *codexProvider) request(ctx context.Context, auth *codexAuth, messages [
 	}
 	if resp.StatusCode >= 400 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("codex request failed: %s %s", resp.Status, string(bodyBytes))
+		return "", newRequestFailed(resp, fmt.Sprintf("codex request failed: %s %s", resp.Status, string(bodyBytes)))
 	}
 
 	var payloadResp map[string]any
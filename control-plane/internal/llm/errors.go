@@ -1,6 +1,13 @@
 package llm
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type ErrUnsupportedProvider struct {
 	Provider string
@@ -9,3 +16,59 @@ type ErrUnsupportedProvider struct {
 func (e ErrUnsupportedProvider) Error() string {
 	return fmt.Sprintf("unsupported LLM provider: %s", e.Provider)
 }
+
+type ErrRequestFailed struct {
+	Message    string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e ErrRequestFailed) Error() string {
+	return e.Message
+}
+
+func newRequestFailed(resp *http.Response, message string) ErrRequestFailed {
+	err := ErrRequestFailed{Message: message, StatusCode: resp.StatusCode}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		err.RetryAfter = ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return err
+}
+
+// ParseRetryAfter accepts both the delay-seconds and HTTP-date forms of the header.
+func ParseRetryAfter(raw string, now time.Time) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	at, err := http.ParseTime(raw)
+	if err != nil {
+		return 0
+	}
+	if delay := at.Sub(now); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+func RetryAfterFromError(err error) time.Duration {
+	var failed ErrRequestFailed
+	if errors.As(err, &failed) {
+		return failed.RetryAfter
+	}
+	return 0
+}
+
+func StatusCodeFromError(err error) int {
+	var failed ErrRequestFailed
+	if errors.As(err, &failed) {
+		return failed.StatusCode
+	}
+	return 0
+}
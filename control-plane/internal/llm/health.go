@@ -0,0 +1,259 @@
+package llm
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// minProbeLease is the shortest time a half-open breaker waits for its probe
+// to report back before letting another request probe instead. It covers a
+// probe whose caller never records the outcome.
+const minProbeLease = time.Minute
+
+type HealthConfig struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+	MaxCooldown      time.Duration
+}
+
+type ProviderHealth struct {
+	Provider            string       `json:"provider"`
+	Model               string       `json:"model,omitempty"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenUntil           string       `json:"open_until,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+	LastFailureAt       string       `json:"last_failure_at,omitempty"`
+}
+
+type providerBreaker struct {
+	provider            string
+	model               string
+	state               BreakerState
+	consecutiveFailures int
+	cooldown            time.Duration
+	openUntil           time.Time
+	lastError           string
+	lastFailureAt       time.Time
+	// probeStartedAt is when the in-flight half-open probe was admitted.
+	probeStartedAt time.Time
+}
+
+// HealthTracker keeps one circuit breaker per provider/model pair. It is safe
+// for concurrent use and is meant to be shared across every run in a process.
+type HealthTracker struct {
+	mu       sync.Mutex
+	cfg      HealthConfig
+	now      func() time.Time
+	breakers map[string]*providerBreaker
+}
+
+func NewHealthTracker(cfg HealthConfig) *HealthTracker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 3
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	if cfg.MaxCooldown < cfg.Cooldown {
+		cfg.MaxCooldown = 5 * time.Minute
+		if cfg.MaxCooldown < cfg.Cooldown {
+			cfg.MaxCooldown = cfg.Cooldown
+		}
+	}
+	return &HealthTracker{
+		cfg:      cfg,
+		now:      time.Now,
+		breakers: map[string]*providerBreaker{},
+	}
+}
+
+func healthKey(provider string, model string) string {
+	return strings.ToLower(strings.TrimSpace(provider)) + "|" + strings.TrimSpace(model)
+}
+
+func (t *HealthTracker) breaker(provider string, model string) *providerBreaker {
+	key := healthKey(provider, model)
+	entry, ok := t.breakers[key]
+	if !ok {
+		entry = &providerBreaker{
+			provider: strings.TrimSpace(provider),
+			model:    strings.TrimSpace(model),
+			state:    BreakerClosed,
+			cooldown: t.cfg.Cooldown,
+		}
+		t.breakers[key] = entry
+	}
+	return entry
+}
+
+// Available reports whether Allow would admit a request now, without claiming
+// the half-open probe. Use it to choose candidates and call Allow just before
+// a request is actually sent.
+func (t *HealthTracker) Available(provider string, model string) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.breakers[healthKey(provider, model)]
+	if !ok {
+		return true
+	}
+	now := t.now()
+	switch entry.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		return !now.Before(entry.openUntil)
+	default:
+		return !now.Before(entry.probeStartedAt.Add(max(entry.cooldown, minProbeLease)))
+	}
+}
+
+// Allow reports whether a request may be sent. An open breaker whose cooldown
+// has elapsed moves to half-open and admits a single probe; other requests
+// are rejected until the probe reports back or its lease runs out.
+func (t *HealthTracker) Allow(provider string, model string) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.breakers[healthKey(provider, model)]
+	if !ok {
+		return true
+	}
+	now := t.now()
+	switch entry.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if now.Before(entry.openUntil) {
+			return false
+		}
+		entry.state = BreakerHalfOpen
+	default:
+		if now.Before(entry.probeStartedAt.Add(max(entry.cooldown, minProbeLease))) {
+			return false
+		}
+	}
+	entry.probeStartedAt = now
+	return true
+}
+
+func (t *HealthTracker) RecordSuccess(provider string, model string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.breakers[healthKey(provider, model)]
+	if !ok {
+		return
+	}
+	entry.state = BreakerClosed
+	entry.consecutiveFailures = 0
+	entry.cooldown = t.cfg.Cooldown
+	entry.openUntil = time.Time{}
+	entry.probeStartedAt = time.Time{}
+}
+
+// Release ends an admitted request that says nothing about the provider's
+// health, such as one the provider rejected as invalid or one the caller
+// cancelled, so a half-open breaker admits the next probe straight away.
+func (t *HealthTracker) Release(provider string, model string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.breakers[healthKey(provider, model)]
+	if !ok || entry.state != BreakerHalfOpen {
+		return
+	}
+	entry.probeStartedAt = time.Time{}
+}
+
+// RecordFailure counts a failed request. A positive retryAfter (from a 429 or
+// 503 Retry-After header) opens the breaker immediately for at least that long.
+func (t *HealthTracker) RecordFailure(provider string, model string, message string, retryAfter time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	entry := t.breaker(provider, model)
+	entry.consecutiveFailures++
+	entry.lastError = strings.TrimSpace(message)
+	entry.lastFailureAt = now
+
+	switch {
+	case entry.state == BreakerHalfOpen:
+		entry.cooldown *= 2
+		if entry.cooldown > t.cfg.MaxCooldown {
+			entry.cooldown = t.cfg.MaxCooldown
+		}
+		entry.state = BreakerOpen
+		entry.openUntil = now.Add(entry.cooldown)
+		entry.probeStartedAt = time.Time{}
+	case entry.consecutiveFailures >= t.cfg.FailureThreshold:
+		entry.state = BreakerOpen
+		if until := now.Add(entry.cooldown); until.After(entry.openUntil) {
+			entry.openUntil = until
+		}
+	}
+	if retryAfter > 0 {
+		entry.state = BreakerOpen
+		if until := now.Add(retryAfter); until.After(entry.openUntil) {
+			entry.openUntil = until
+		}
+	}
+}
+
+func (t *HealthTracker) Snapshot() []ProviderHealth {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	results := make([]ProviderHealth, 0, len(t.breakers))
+	for _, entry := range t.breakers {
+		item := ProviderHealth{
+			Provider:            entry.provider,
+			Model:               entry.model,
+			State:               entry.state,
+			ConsecutiveFailures: entry.consecutiveFailures,
+			LastError:           entry.lastError,
+		}
+		if entry.state == BreakerOpen && !now.Before(entry.openUntil) {
+			item.State = BreakerHalfOpen
+		}
+		if item.State == BreakerOpen {
+			item.OpenUntil = entry.openUntil.UTC().Format(time.RFC3339Nano)
+		}
+		if !entry.lastFailureAt.IsZero() {
+			item.LastFailureAt = entry.lastFailureAt.UTC().Format(time.RFC3339Nano)
+		}
+		results = append(results, item)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Provider != results[j].Provider {
+			return results[i].Provider < results[j].Provider
+		}
+		return results[i].Model < results[j].Model
+	})
+	return results
+}
@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHealthTracker_OpensAfterThreshold(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewHealthTracker(HealthConfig{FailureThreshold: 2, Cooldown: 10 * time.Second})
+	tracker.now = func() time.Time { return now }
+
+	tracker.RecordFailure("openai", "gpt-4o", "LLM request failed: 502 Bad Gateway", 0)
+	if !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected breaker to stay closed below threshold")
+	}
+	tracker.RecordFailure("openai", "gpt-4o", "LLM request failed: 502 Bad Gateway", 0)
+	if tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected breaker to open at threshold")
+	}
+	if !tracker.Allow("openai", "gpt-4o-mini") {
+		t.Fatal("expected other models to be unaffected")
+	}
+
+	now = now.Add(11 * time.Second)
+	if !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected half-open probe after cooldown")
+	}
+	tracker.RecordFailure("openai", "gpt-4o", "still down", 0)
+	snapshot := tracker.Snapshot()
+	if len(snapshot) != 1 || snapshot[0].State != BreakerOpen {
+		t.Fatalf("expected reopened breaker, got %+v", snapshot)
+	}
+	if snapshot[0].OpenUntil != now.Add(20*time.Second).Format(time.RFC3339Nano) {
+		t.Fatalf("expected doubled cooldown, got %s", snapshot[0].OpenUntil)
+	}
+
+	now = now.Add(21 * time.Second)
+	if !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected probe after second cooldown")
+	}
+	tracker.RecordSuccess("openai", "gpt-4o")
+	snapshot = tracker.Snapshot()
+	if snapshot[0].State != BreakerClosed || snapshot[0].ConsecutiveFailures != 0 {
+		t.Fatalf("expected closed breaker after success, got %+v", snapshot[0])
+	}
+}
+
+func TestHealthTracker_RetryAfterOpensImmediately(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewHealthTracker(HealthConfig{})
+	tracker.now = func() time.Time { return now }
+
+	tracker.RecordFailure("openrouter", "", "429 Too Many Requests", 90*time.Second)
+	if tracker.Allow("openrouter", "") {
+		t.Fatal("expected retry-after to open breaker")
+	}
+	now = now.Add(60 * time.Second)
+	if tracker.Allow("openrouter", "") {
+		t.Fatal("expected breaker to honor retry-after beyond default cooldown")
+	}
+	now = now.Add(31 * time.Second)
+	if !tracker.Allow("openrouter", "") {
+		t.Fatal("expected breaker to allow after retry-after elapsed")
+	}
+}
+
+func TestHealthTracker_NilIsPermissive(t *testing.T) {
+	var tracker *HealthTracker
+	if !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected nil tracker to allow")
+	}
+	tracker.RecordFailure("openai", "gpt-4o", "boom", 0)
+	tracker.RecordSuccess("openai", "gpt-4o")
+	if tracker.Snapshot() != nil {
+		t.Fatal("expected nil snapshot")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got := ParseRetryAfter("30", now); got != 30*time.Second {
+		t.Fatalf("expected 30s, got %s", got)
+	}
+	if got := ParseRetryAfter(now.Add(45*time.Second).Format(http.TimeFormat), now); got != 45*time.Second {
+		t.Fatalf("expected 45s, got %s", got)
+	}
+	if got := ParseRetryAfter("soon", now); got != 0 {
+		t.Fatalf("expected 0 for invalid value, got %s", got)
+	}
+	if got := ParseRetryAfter("-5", now); got != 0 {
+		t.Fatalf("expected 0 for negative value, got %s", got)
+	}
+}
+
+func TestHealthTracker_HalfOpenAdmitsSingleProbe(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewHealthTracker(HealthConfig{FailureThreshold: 1, Cooldown: 10 * time.Second})
+	tracker.now = func() time.Time { return now }
+
+	tracker.RecordFailure("openai", "gpt-4o", "502 Bad Gateway", 0)
+	now = now.Add(11 * time.Second)
+	if !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected half-open probe after cooldown")
+	}
+	if tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected requests behind an in-flight probe to be rejected")
+	}
+
+	now = now.Add(minProbeLease)
+	if !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected a new probe once the lease ran out")
+	}
+	tracker.RecordSuccess("openai", "gpt-4o")
+	if !tracker.Allow("openai", "gpt-4o") || !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected a closed breaker to admit every request")
+	}
+}
+
+func TestHealthTracker_AvailableDoesNotClaimProbe(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewHealthTracker(HealthConfig{FailureThreshold: 1, Cooldown: 10 * time.Second})
+	tracker.now = func() time.Time { return now }
+
+	tracker.RecordFailure("openai", "gpt-4o", "502 Bad Gateway", 0)
+	if tracker.Available("openai", "gpt-4o") {
+		t.Fatal("expected an open breaker to be unavailable")
+	}
+	now = now.Add(11 * time.Second)
+	if !tracker.Available("openai", "gpt-4o") || !tracker.Available("openai", "gpt-4o") {
+		t.Fatal("expected checking availability to leave the probe unclaimed")
+	}
+	if !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected half-open probe after cooldown")
+	}
+	if tracker.Available("openai", "gpt-4o") {
+		t.Fatal("expected a provider with an in-flight probe to be unavailable")
+	}
+
+	tracker.Release("openai", "gpt-4o")
+	if !tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected a released probe to admit the next request")
+	}
+	if tracker.Allow("openai", "gpt-4o") {
+		t.Fatal("expected requests behind the new probe to be rejected")
+	}
+}
@@ -21,6 +21,9 @@ type Config struct {
 	FallbackProvider string
 	FallbackModel    string
 	FallbackBaseURL  string
+	UtilityProvider  string
+	UtilityModel     string
+	UtilityBaseURL   string
 	OpenAIAPIKey     string
 	OpenRouterAPIKey string
 	OpenCodeAPIKey   string
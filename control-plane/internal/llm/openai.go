@@ -65,7 +65,7 @@ func (p *OpenAIProvider) Generate(ctx context.Context, messages []Message) (stri
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("LLM request failed: %s", resp.Status)
+		return "", newRequestFailed(resp, fmt.Sprintf("LLM request failed: %s", resp.Status))
 	}
 
 	var parsed struct {
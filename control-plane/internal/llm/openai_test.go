@@ -333,3 +333,27 @@ func TestOpenAIProvider_Generate_NetworkError(t *testing.T) {
 		t.Fatal("expected error for network failure, got nil")
 	}
 }
+
+func TestOpenAIProvider_Generate_RateLimitedRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "12")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(OpenAIConfig{APIKey: "key", Model: "gpt-4", BaseURL: server.URL})
+	_, err := provider.Generate(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if err.Error() != "LLM request failed: 429 Too Many Requests" {
+		t.Fatalf("unexpected error message: %s", err.Error())
+	}
+	var failed ErrRequestFailed
+	if !errors.As(err, &failed) {
+		t.Fatalf("expected ErrRequestFailed, got %T", err)
+	}
+	if failed.StatusCode != http.StatusTooManyRequests || RetryAfterFromError(err).Seconds() != 12 {
+		t.Fatalf("unexpected failure details: %+v", failed)
+	}
+}
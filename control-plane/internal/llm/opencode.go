@@ -93,7 +93,7 @@ func (p *OpenCodeProvider) Generate(ctx context.Context, messages []Message) (st
 	}
 
 	if resp.StatusCode >= 400 {
-		return "", fmt.Errorf("opencode request failed: %s %s", resp.Status, strings.TrimSpace(string(bodyBytes)))
+		return "", newRequestFailed(resp, fmt.Sprintf("opencode request failed: %s %s", resp.Status, strings.TrimSpace(string(bodyBytes))))
 	}
 
 	content, err := parseOpenCodeChatResponse(bodyBytes)
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Discord posts an embed to a Discord webhook.
+type Discord struct {
+	URL    string
+	Client *http.Client
+}
+
+type DiscordPayload struct {
+	Content string         `json:"content,omitempty"`
+	Embeds  []DiscordEmbed `json:"embeds,omitempty"`
+}
+
+type DiscordEmbed struct {
+	Title       string              `json:"title,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+	Fields      []DiscordEmbedField `json:"fields,omitempty"`
+}
+
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+func (d *Discord) Send(ctx context.Context, msg Message) error {
+	fields := make([]DiscordEmbedField, 0, len(msg.Fields))
+	for _, field := range msg.Fields {
+		fields = append(fields, DiscordEmbedField{Name: field.Name, Value: Truncate(field.Value, 240), Inline: field.Inline})
+	}
+	payload := DiscordPayload{
+		Embeds: []DiscordEmbed{
+			{
+				Title:       Truncate(msg.Title, 256),
+				Description: Truncate(msg.Body, 900),
+				Color:       discordColor(msg.Status),
+				Timestamp:   timestampOf(msg).Format(time.RFC3339),
+				Fields:      fields,
+			},
+		},
+	}
+	return postJSON(ctx, d.Client, d.URL, payload, nil)
+}
+
+func discordColor(status string) int {
+	switch status {
+	case "failed":
+		return 15158332
+	case "cancelled":
+		return 10181046
+	case "partial":
+		return 16776960
+	default:
+		return 5763719
+	}
+}
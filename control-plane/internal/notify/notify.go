@@ -0,0 +1,218 @@
+// Package notify delivers short status notifications, such as automation
+// results, to chat services, push services, webhooks and email.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	TypeDiscord = "discord"
+	TypeSlack   = "slack"
+	TypeTeams   = "teams"
+	TypeNtfy    = "ntfy"
+	TypeWebhook = "webhook"
+	TypeSMTP    = "smtp"
+)
+
+// Field is a labelled detail shown alongside the message body.
+type Field struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+// Message is a channel-neutral notification. Status is one of completed,
+// partial, failed or cancelled and drives colours and priorities.
+type Message struct {
+	Title     string
+	Body      string
+	Status    string
+	Fields    []Field
+	Timestamp time.Time
+}
+
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type channelSpec struct {
+	required []string
+	optional []string
+	// secret keys are stored encrypted and never returned by the API.
+	secret []string
+	build  func(config map[string]string, client *http.Client) (Notifier, error)
+}
+
+var channelSpecs = map[string]channelSpec{
+	TypeDiscord: {
+		required: []string{"url"},
+		secret:   []string{"url"},
+		build: func(config map[string]string, client *http.Client) (Notifier, error) {
+			return &Discord{URL: config["url"], Client: client}, nil
+		},
+	},
+	TypeSlack: {
+		required: []string{"url"},
+		secret:   []string{"url"},
+		build: func(config map[string]string, client *http.Client) (Notifier, error) {
+			return &Slack{URL: config["url"], Client: client}, nil
+		},
+	},
+	TypeTeams: {
+		required: []string{"url"},
+		secret:   []string{"url"},
+		build: func(config map[string]string, client *http.Client) (Notifier, error) {
+			return &Teams{URL: config["url"], Client: client}, nil
+		},
+	},
+	TypeNtfy: {
+		required: []string{"topic"},
+		optional: []string{"server", "token"},
+		secret:   []string{"token"},
+		build: func(config map[string]string, client *http.Client) (Notifier, error) {
+			return &Ntfy{Server: config["server"], Topic: config["topic"], Token: config["token"], Client: client}, nil
+		},
+	},
+	TypeWebhook: {
+		required: []string{"url"},
+		secret:   []string{"url"},
+		build: func(config map[string]string, client *http.Client) (Notifier, error) {
+			return &Webhook{URL: config["url"], Client: client}, nil
+		},
+	},
+	TypeSMTP: {
+		required: []string{"host", "from", "to"},
+		optional: []string{"port", "username", "password", "tls"},
+		secret:   []string{"password"},
+		build: func(config map[string]string, _ *http.Client) (Notifier, error) {
+			notifier, err := newSMTP(config)
+			if err != nil {
+				return nil, err
+			}
+			return notifier, nil
+		},
+	},
+}
+
+// Types lists the supported channel types.
+func Types() []string {
+	types := make([]string, 0, len(channelSpecs))
+	for channelType := range channelSpecs {
+		types = append(types, channelType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// SecretKeys lists the config keys of channelType that hold credentials.
+func SecretKeys(channelType string) []string {
+	return append([]string(nil), channelSpecs[channelType].secret...)
+}
+
+// Validate checks that config has every required key of channelType and no
+// unknown keys.
+func Validate(channelType string, config map[string]string) error {
+	spec, ok := channelSpecs[channelType]
+	if !ok {
+		return fmt.Errorf("unknown channel type: %s", channelType)
+	}
+	allowed := map[string]bool{}
+	for _, key := range append(append([]string{}, spec.required...), spec.optional...) {
+		allowed[key] = true
+	}
+	for key := range config {
+		if !allowed[key] {
+			return fmt.Errorf("unknown %s config key: %s", channelType, key)
+		}
+	}
+	for _, key := range spec.required {
+		if strings.TrimSpace(config[key]) == "" {
+			return fmt.Errorf("%s config requires %s", channelType, key)
+		}
+	}
+	for _, key := range []string{"url", "server"} {
+		if raw := strings.TrimSpace(config[key]); raw != "" {
+			parsed, err := url.Parse(raw)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("%s must be an absolute http or https URL", key)
+			}
+		}
+	}
+	return nil
+}
+
+// New builds the notifier for a channel. HTTP channels send with client.
+func New(channelType string, config map[string]string, client *http.Client) (Notifier, error) {
+	if err := Validate(channelType, config); err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return channelSpecs[channelType].build(config, client)
+}
+
+func postJSON(ctx context.Context, client *http.Client, target string, payload any, headers map[string]string) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return post(ctx, client, target, "application/json", raw, headers)
+}
+
+func post(ctx context.Context, client *http.Client, target string, contentType string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification rejected: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Truncate shortens value to at most limit bytes, ending in "..." when cut.
+func Truncate(value string, limit int) string {
+	text := strings.TrimSpace(value)
+	if text == "" || limit <= 0 {
+		return ""
+	}
+	if len(text) <= limit {
+		return text
+	}
+	if limit <= 3 {
+		return text[:limit]
+	}
+	return strings.TrimSpace(text[:limit-3]) + "..."
+}
+
+func timestampOf(msg Message) time.Time {
+	if msg.Timestamp.IsZero() {
+		return time.Now().UTC()
+	}
+	return msg.Timestamp.UTC()
+}
+
+func isFailure(status string) bool {
+	return status == "failed" || status == "cancelled"
+}
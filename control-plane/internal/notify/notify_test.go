@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturedRequest struct {
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+func newReceiver(t *testing.T, status int) (*httptest.Server, <-chan capturedRequest) {
+	t.Helper()
+	captured := make(chan capturedRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		captured <- capturedRequest{path: r.URL.Path, headers: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, captured
+}
+
+func testMessage() Message {
+	return Message{
+		Title:     "Gavryn automation failed: Daily Brief",
+		Body:      strings.Repeat("x", 1200),
+		Status:    "failed",
+		Fields:    []Field{{Name: "Status", Value: "failed", Inline: true}, {Name: "Run ID", Value: "run-1"}},
+		Timestamp: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(TypeDiscord, map[string]string{"url": "https://discord.test/hook"}))
+	assert.NoError(t, Validate(TypeNtfy, map[string]string{"topic": "alerts"}))
+	assert.ErrorContains(t, Validate("pager", map[string]string{}), "unknown channel type")
+	assert.ErrorContains(t, Validate(TypeSlack, map[string]string{}), "requires url")
+	assert.ErrorContains(t, Validate(TypeSlack, map[string]string{"url": "ftp://x"}), "http or https")
+	assert.ErrorContains(t, Validate(TypeWebhook, map[string]string{"url": "https://x.test", "extra": "1"}), "unknown webhook config key")
+	assert.ErrorContains(t, Validate(TypeSMTP, map[string]string{"host": "mail.test", "from": "a@test"}), "requires to")
+
+	_, err := New(TypeSMTP, map[string]string{"host": "mail.test", "from": "a@test", "to": "b@test", "port": "0"}, nil)
+	assert.ErrorContains(t, err, "port")
+	_, err = New(TypeSMTP, map[string]string{"host": "mail.test", "from": "a@test", "to": " , "}, nil)
+	assert.ErrorContains(t, err, "requires to")
+
+	assert.Equal(t, []string{"password"}, SecretKeys(TypeSMTP))
+	assert.Equal(t, []string{"discord", "ntfy", "slack", "smtp", "teams", "webhook"}, Types())
+}
+
+func TestDiscordSendsEmbed(t *testing.T) {
+	server, captured := newReceiver(t, http.StatusNoContent)
+	notifier, err := New(TypeDiscord, map[string]string{"url": server.URL}, server.Client())
+	require.NoError(t, err)
+	require.NoError(t, notifier.Send(context.Background(), testMessage()))
+
+	var payload DiscordPayload
+	require.NoError(t, json.Unmarshal((<-captured).body, &payload))
+	require.Len(t, payload.Embeds, 1)
+	assert.Equal(t, "Gavryn automation failed: Daily Brief", payload.Embeds[0].Title)
+	assert.LessOrEqual(t, len(payload.Embeds[0].Description), 900)
+	assert.Equal(t, 15158332, payload.Embeds[0].Color)
+	assert.Equal(t, "2026-01-02T03:04:05Z", payload.Embeds[0].Timestamp)
+	assert.Len(t, payload.Embeds[0].Fields, 2)
+}
+
+func TestSlackSendsBlocks(t *testing.T) {
+	server, captured := newReceiver(t, http.StatusOK)
+	notifier, err := New(TypeSlack, map[string]string{"url": server.URL}, server.Client())
+	require.NoError(t, err)
+	require.NoError(t, notifier.Send(context.Background(), testMessage()))
+
+	var payload struct {
+		Text   string           `json:"text"`
+		Blocks []map[string]any `json:"blocks"`
+	}
+	require.NoError(t, json.Unmarshal((<-captured).body, &payload))
+	assert.Equal(t, ":x: Gavryn automation failed: Daily Brief", payload.Text)
+	require.Len(t, payload.Blocks, 3)
+	assert.Equal(t, "header", payload.Blocks[0]["type"])
+	assert.Len(t, payload.Blocks[2]["fields"], 2)
+}
+
+func TestTeamsSendsAdaptiveCard(t *testing.T) {
+	server, captured := newReceiver(t, http.StatusAccepted)
+	notifier, err := New(TypeTeams, map[string]string{"url": server.URL}, server.Client())
+	require.NoError(t, err)
+	require.NoError(t, notifier.Send(context.Background(), testMessage()))
+
+	body := string((<-captured).body)
+	assert.Contains(t, body, `"contentType":"application/vnd.microsoft.card.adaptive"`)
+	assert.Contains(t, body, `"color":"Attention"`)
+	assert.Contains(t, body, `"FactSet"`)
+}
+
+func TestNtfySendsHeaders(t *testing.T) {
+	server, captured := newReceiver(t, http.StatusOK)
+	notifier, err := New(TypeNtfy, map[string]string{"server": server.URL + "/", "topic": "alerts", "token": "tk_1"}, server.Client())
+	require.NoError(t, err)
+	require.NoError(t, notifier.Send(context.Background(), testMessage()))
+
+	request := <-captured
+	assert.Equal(t, "/alerts", request.path)
+	assert.Equal(t, "Gavryn automation failed: Daily Brief", request.headers.Get("Title"))
+	assert.Equal(t, "high", request.headers.Get("Priority"))
+	assert.Equal(t, "x", request.headers.Get("Tags"))
+	assert.Equal(t, "Bearer tk_1", request.headers.Get("Authorization"))
+	assert.Contains(t, string(request.body), "Run ID: run-1")
+}
+
+func TestWebhookSendsGenericJSON(t *testing.T) {
+	server, captured := newReceiver(t, http.StatusOK)
+	notifier, err := New(TypeWebhook, map[string]string{"url": server.URL}, server.Client())
+	require.NoError(t, err)
+	require.NoError(t, notifier.Send(context.Background(), testMessage()))
+
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal((<-captured).body, &payload))
+	assert.Equal(t, "failed", payload.Status)
+	assert.Len(t, payload.Body, 1200)
+	assert.Equal(t, []WebhookField{{Name: "Status", Value: "failed"}, {Name: "Run ID", Value: "run-1"}}, payload.Fields)
+}
+
+func TestSendReportsRejection(t *testing.T) {
+	server, _ := newReceiver(t, http.StatusBadRequest)
+	notifier, err := New(TypeWebhook, map[string]string{"url": server.URL}, server.Client())
+	require.NoError(t, err)
+	assert.ErrorContains(t, notifier.Send(context.Background(), testMessage()), "status=400")
+}
+
+func TestSMTPMessage(t *testing.T) {
+	notifier, err := newSMTP(map[string]string{"host": "mail.test", "from": "bot@test", "to": "a@test, b@test"})
+	require.NoError(t, err)
+	assert.Equal(t, 587, notifier.Port)
+
+	msg := testMessage()
+	msg.Title = "Subject\r\nBcc: evil@test"
+	msg.Body = "line one\nline two"
+	raw := string(notifier.message(msg))
+	assert.Contains(t, raw, "To: a@test, b@test\r\n")
+	assert.Contains(t, raw, "Subject: Subject  Bcc: evil@test\r\n")
+	assert.Contains(t, raw, "line one\r\nline two\r\n")
+	assert.Contains(t, raw, "Run ID: run-1\r\n")
+}
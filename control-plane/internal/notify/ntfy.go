@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const defaultNtfyServer = "https://ntfy.sh"
+
+// Ntfy publishes a plain-text message to an ntfy topic.
+type Ntfy struct {
+	Server string
+	Topic  string
+	Token  string
+	Client *http.Client
+}
+
+func (n *Ntfy) Send(ctx context.Context, msg Message) error {
+	server := strings.TrimRight(strings.TrimSpace(n.Server), "/")
+	if server == "" {
+		server = defaultNtfyServer
+	}
+	lines := []string{}
+	if body := Truncate(msg.Body, 3000); body != "" {
+		lines = append(lines, body)
+	}
+	for _, field := range msg.Fields {
+		lines = append(lines, fmt.Sprintf("%s: %s", field.Name, Truncate(field.Value, 240)))
+	}
+	// ntfy reads the title, priority and tags from headers; header values
+	// must stay on one line.
+	headers := map[string]string{
+		"Title":    strings.ReplaceAll(Truncate(msg.Title, 200), "\n", " "),
+		"Priority": "default",
+		"Tags":     "white_check_mark",
+	}
+	switch {
+	case isFailure(msg.Status):
+		headers["Priority"] = "high"
+		headers["Tags"] = "x"
+	case msg.Status == "partial":
+		headers["Tags"] = "warning"
+	}
+	if token := strings.TrimSpace(n.Token); token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return post(ctx, n.Client, server+"/"+strings.Trim(n.Topic, "/"), "text/plain; charset=utf-8", []byte(strings.Join(lines, "\n")), headers)
+}
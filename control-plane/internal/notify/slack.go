@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Slack posts Block Kit blocks to a Slack incoming webhook.
+type Slack struct {
+	URL    string
+	Client *http.Client
+}
+
+var slackStatusEmoji = map[string]string{
+	"completed": ":white_check_mark:",
+	"partial":   ":warning:",
+	"failed":    ":x:",
+	"cancelled": ":no_entry_sign:",
+}
+
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	title := msg.Title
+	if emoji := slackStatusEmoji[msg.Status]; emoji != "" {
+		title = emoji + " " + title
+	}
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": Truncate(msg.Title, 150)}},
+	}
+	if body := Truncate(msg.Body, 2900); body != "" {
+		blocks = append(blocks, map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": body}})
+	}
+	if len(msg.Fields) > 0 {
+		fields := make([]map[string]any, 0, len(msg.Fields))
+		for _, field := range msg.Fields {
+			fields = append(fields, map[string]any{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", field.Name, Truncate(field.Value, 240))})
+		}
+		// Slack allows at most ten fields per section.
+		for start := 0; start < len(fields); start += 10 {
+			end := min(start+10, len(fields))
+			blocks = append(blocks, map[string]any{"type": "section", "fields": fields[start:end]})
+		}
+	}
+	payload := map[string]any{
+		// text is the fallback shown in notifications and by clients without
+		// block support.
+		"text":   strings.TrimSpace(title),
+		"blocks": blocks,
+	}
+	return postJSON(ctx, s.Client, s.URL, payload, nil)
+}
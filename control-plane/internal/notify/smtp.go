@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP sends a plain-text email. Port 465 uses implicit TLS; other ports
+// upgrade with STARTTLS when the server offers it, unless tls is "none".
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	TLS      string
+}
+
+func newSMTP(config map[string]string) (*SMTP, error) {
+	port := 587
+	if raw := strings.TrimSpace(config["port"]); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > 65535 {
+			return nil, fmt.Errorf("smtp port must be between 1 and 65535")
+		}
+		port = parsed
+	}
+	mode := strings.ToLower(strings.TrimSpace(config["tls"]))
+	switch mode {
+	case "", "auto", "implicit", "starttls", "none":
+	default:
+		return nil, fmt.Errorf("smtp tls must be one of auto, implicit, starttls or none")
+	}
+	recipients := []string{}
+	for _, value := range strings.Split(config["to"], ",") {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			recipients = append(recipients, trimmed)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("smtp config requires to")
+	}
+	return &SMTP{
+		Host:     strings.TrimSpace(config["host"]),
+		Port:     port,
+		Username: config["username"],
+		Password: config["password"],
+		From:     strings.TrimSpace(config["from"]),
+		To:       recipients,
+		TLS:      mode,
+	}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	tlsConfig := &tls.Config{ServerName: s.Host, MinVersion: tls.VersionTLS12}
+
+	implicit := s.TLS == "implicit" || ((s.TLS == "" || s.TLS == "auto") && s.Port == 465)
+	var conn net.Conn
+	var err error
+	if implicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !implicit && s.TLS != "none" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if s.TLS == "starttls" {
+			return fmt.Errorf("smtp server does not support STARTTLS")
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.From); err != nil {
+		return err
+	}
+	for _, recipient := range s.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(s.message(msg)); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *SMTP) message(msg Message) []byte {
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(Truncate(msg.Title, 200))
+	lines := []string{}
+	if body := strings.TrimSpace(msg.Body); body != "" {
+		lines = append(lines, body, "")
+	}
+	for _, field := range msg.Fields {
+		lines = append(lines, fmt.Sprintf("%s: %s", field.Name, field.Value))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", timestampOf(msg).Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	for _, line := range lines {
+		// Dot-stuffing is handled by the smtp data writer.
+		b.WriteString(strings.ReplaceAll(line, "\n", "\r\n"))
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
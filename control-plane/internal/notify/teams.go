@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// Teams posts an Adaptive Card to a Microsoft Teams incoming webhook or
+// workflow URL.
+type Teams struct {
+	URL    string
+	Client *http.Client
+}
+
+func (t *Teams) Send(ctx context.Context, msg Message) error {
+	color := "Good"
+	switch {
+	case isFailure(msg.Status):
+		color = "Attention"
+	case msg.Status == "partial":
+		color = "Warning"
+	}
+	body := []map[string]any{
+		{"type": "TextBlock", "text": msg.Title, "weight": "Bolder", "size": "Medium", "color": color, "wrap": true},
+	}
+	if text := Truncate(msg.Body, 2000); text != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": text, "wrap": true})
+	}
+	if len(msg.Fields) > 0 {
+		facts := make([]map[string]string, 0, len(msg.Fields))
+		for _, field := range msg.Fields {
+			facts = append(facts, map[string]string{"title": field.Name, "value": Truncate(field.Value, 240)})
+		}
+		body = append(body, map[string]any{"type": "FactSet", "facts": facts})
+	}
+	payload := map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+				},
+			},
+		},
+	}
+	return postJSON(ctx, t.Client, t.URL, payload, nil)
+}
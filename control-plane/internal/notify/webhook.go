@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Webhook posts the message as generic JSON for custom integrations.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+type WebhookPayload struct {
+	Title     string         `json:"title"`
+	Body      string         `json:"body"`
+	Status    string         `json:"status"`
+	Fields    []WebhookField `json:"fields"`
+	Timestamp string         `json:"timestamp"`
+}
+
+type WebhookField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	fields := make([]WebhookField, 0, len(msg.Fields))
+	for _, field := range msg.Fields {
+		fields = append(fields, WebhookField{Name: field.Name, Value: field.Value})
+	}
+	payload := WebhookPayload{
+		Title:     msg.Title,
+		Body:      msg.Body,
+		Status:    msg.Status,
+		Fields:    fields,
+		Timestamp: timestampOf(msg).Format(time.RFC3339),
+	}
+	return postJSON(ctx, w.Client, w.URL, payload, nil)
+}
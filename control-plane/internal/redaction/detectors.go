@@ -0,0 +1,148 @@
+package redaction
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+// Very short vault values would match ordinary words, so they are not
+// searched for.
+const minSecretValueLength = 4
+
+type secretValuesDetector struct {
+	names  []string
+	values map[string]string
+}
+
+// SecretValues replaces vault secret values with [secret:NAME], matching the
+// placeholder tool inputs use.
+func SecretValues(values map[string]string) Detector {
+	names := make([]string, 0, len(values))
+	for name, value := range values {
+		if len(value) >= minSecretValueLength {
+			names = append(names, name)
+		}
+	}
+	// Longer values first so a secret that contains another is replaced whole.
+	sort.Slice(names, func(i, j int) bool {
+		if len(values[names[i]]) != len(values[names[j]]) {
+			return len(values[names[i]]) > len(values[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	return &secretValuesDetector{names: names, values: values}
+}
+
+func (d *secretValuesDetector) Name() string {
+	return DetectorVaultSecret
+}
+
+func (d *secretValuesDetector) Redact(text string) (string, []Match) {
+	var matches []Match
+	for _, name := range d.names {
+		value := d.values[name]
+		count := strings.Count(text, value)
+		if count == 0 {
+			continue
+		}
+		text = strings.ReplaceAll(text, value, "[secret:"+name+"]")
+		matches = append(matches, Match{Label: name, Count: count})
+	}
+	return text, matches
+}
+
+type patternRule struct {
+	label string
+	re    *regexp.Regexp
+	// keepPrefix keeps capture group 1 (e.g. "Bearer ") in front of the marker.
+	keepPrefix bool
+}
+
+type patternDetector struct {
+	name  string
+	rules []patternRule
+}
+
+func (d *patternDetector) Name() string {
+	return d.name
+}
+
+func (d *patternDetector) Redact(text string) (string, []Match) {
+	var matches []Match
+	for _, rule := range d.rules {
+		count := 0
+		text = rule.re.ReplaceAllStringFunc(text, func(found string) string {
+			count++
+			if rule.keepPrefix {
+				if groups := rule.re.FindStringSubmatch(found); len(groups) > 1 {
+					return groups[1] + replacementToken(rule.label)
+				}
+			}
+			return replacementToken(rule.label)
+		})
+		if count > 0 {
+			matches = append(matches, Match{Label: rule.label, Count: count})
+		}
+	}
+	return text, matches
+}
+
+var keyFormatRules = []patternRule{
+	{label: "private_key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{label: "anthropic_key", re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{label: "openai_key", re: regexp.MustCompile(`\bsk-(?:proj-|or-v1-)?[A-Za-z0-9_-]{20,}`)},
+	{label: "stripe_key", re: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[0-9A-Za-z]{16,}`)},
+	{label: "github_token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{label: "aws_access_key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{label: "slack_token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{label: "google_api_key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{label: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{label: "bearer_token", re: regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/-]{20,}=*`), keepPrefix: true},
+}
+
+// KeyFormats detects well-known API key and token formats.
+func KeyFormats() Detector {
+	return &patternDetector{name: DetectorKeyFormat, rules: keyFormatRules}
+}
+
+var emailRule = patternRule{
+	label: "email",
+	re:    regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`),
+}
+
+func Emails() Detector {
+	return &patternDetector{name: DetectorEmail, rules: []patternRule{emailRule}}
+}
+
+// Pattern builds a custom detector; matches are replaced with [redacted:NAME].
+func Pattern(name string, re *regexp.Regexp) Detector {
+	return &patternDetector{name: DetectorCustom, rules: []patternRule{{label: name, re: re}}}
+}
+
+// CompileRules validates and compiles per-profile custom rules.
+func CompileRules(rules []store.RedactionRule) ([]Detector, error) {
+	detectors := make([]Detector, 0, len(rules))
+	for _, rule := range rules {
+		name := strings.TrimSpace(rule.Name)
+		if name == "" {
+			return nil, fmt.Errorf("redaction rule name is required")
+		}
+		pattern := strings.TrimSpace(rule.Pattern)
+		if pattern == "" {
+			return nil, fmt.Errorf("redaction rule %s: pattern is required", name)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rule %s: %w", name, err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("redaction rule %s: pattern must not match empty text", name)
+		}
+		detectors = append(detectors, Pattern(name, re))
+	}
+	return detectors, nil
+}
@@ -0,0 +1,206 @@
+package redaction
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	DetectorVaultSecret = "vault_secret"
+	DetectorKeyFormat   = "key_format"
+	DetectorEmail       = "email"
+	DetectorCustom      = "custom"
+
+	DefaultPolicyProfile = "default"
+)
+
+// Match reports how many times a detector replaced values of one kind.
+type Match struct {
+	Label string
+	Count int
+}
+
+// Detector finds sensitive values in text and replaces them with a marker
+// that names what was removed, never the value itself.
+type Detector interface {
+	Name() string
+	Redact(text string) (string, []Match)
+}
+
+// Pipeline runs detectors in order over strings and JSON-like values.
+type Pipeline struct {
+	detectors []Detector
+}
+
+func New(detectors ...Detector) *Pipeline {
+	filtered := make([]Detector, 0, len(detectors))
+	for _, detector := range detectors {
+		if detector != nil {
+			filtered = append(filtered, detector)
+		}
+	}
+	return &Pipeline{detectors: filtered}
+}
+
+// DefaultPolicy is used for profiles without a stored policy when no
+// "default" policy has been stored either.
+func DefaultPolicy(policyProfile string) store.RedactionPolicy {
+	return store.RedactionPolicy{
+		PolicyProfile: policyProfile,
+		VaultSecrets:  true,
+		KeyFormats:    true,
+	}
+}
+
+// PolicyStore is the part of store.Store that EffectivePolicy reads.
+type PolicyStore interface {
+	GetRedactionPolicy(ctx context.Context, policyProfile string) (*store.RedactionPolicy, error)
+}
+
+// EffectivePolicy resolves a profile's policy, falling back to the stored
+// "default" policy and then to the built-in defaults.
+func EffectivePolicy(ctx context.Context, policies PolicyStore, policyProfile string) (store.RedactionPolicy, error) {
+	policyProfile = strings.TrimSpace(policyProfile)
+	if policyProfile == "" {
+		policyProfile = DefaultPolicyProfile
+	}
+	policy, err := policies.GetRedactionPolicy(ctx, policyProfile)
+	if err != nil {
+		return store.RedactionPolicy{}, err
+	}
+	if policy == nil && policyProfile != DefaultPolicyProfile {
+		policy, err = policies.GetRedactionPolicy(ctx, DefaultPolicyProfile)
+		if err != nil {
+			return store.RedactionPolicy{}, err
+		}
+	}
+	if policy == nil {
+		return DefaultPolicy(policyProfile), nil
+	}
+	return *policy, nil
+}
+
+// FromPolicy builds the pipeline for a policy. secretValues maps vault secret
+// names to their plaintext values.
+func FromPolicy(policy store.RedactionPolicy, secretValues map[string]string) (*Pipeline, error) {
+	detectors := []Detector{}
+	if policy.VaultSecrets && len(secretValues) > 0 {
+		detectors = append(detectors, SecretValues(secretValues))
+	}
+	if policy.KeyFormats {
+		detectors = append(detectors, KeyFormats())
+	}
+	if policy.Emails {
+		detectors = append(detectors, Emails())
+	}
+	custom, err := CompileRules(policy.CustomRules)
+	if err != nil {
+		return nil, err
+	}
+	detectors = append(detectors, custom...)
+	return New(detectors...), nil
+}
+
+func (p *Pipeline) Text(path string, text string) (string, []store.RedactionMarker) {
+	if p == nil || text == "" {
+		return text, nil
+	}
+	markers := []store.RedactionMarker{}
+	for _, detector := range p.detectors {
+		redacted, matches := detector.Redact(text)
+		text = redacted
+		for _, match := range matches {
+			if match.Count <= 0 {
+				continue
+			}
+			markers = append(markers, store.RedactionMarker{
+				Detector: detector.Name(),
+				Label:    match.Label,
+				Path:     path,
+				Count:    match.Count,
+			})
+		}
+	}
+	if len(markers) == 0 {
+		return text, nil
+	}
+	return text, markers
+}
+
+// Value redacts every string inside a JSON-like value. Maps and slices are
+// copied, never modified in place.
+func (p *Pipeline) Value(path string, value any) (any, []store.RedactionMarker) {
+	if p == nil {
+		return value, nil
+	}
+	switch typed := value.(type) {
+	case string:
+		return p.Text(path, typed)
+	case map[string]any:
+		return p.Map(path, typed)
+	case []any:
+		result := make([]any, len(typed))
+		var markers []store.RedactionMarker
+		for i, item := range typed {
+			redacted, itemMarkers := p.Value(path+"["+strconv.Itoa(i)+"]", item)
+			result[i] = redacted
+			markers = append(markers, itemMarkers...)
+		}
+		return result, markers
+	case []string:
+		result := make([]string, len(typed))
+		var markers []store.RedactionMarker
+		for i, item := range typed {
+			redacted, itemMarkers := p.Text(path+"["+strconv.Itoa(i)+"]", item)
+			result[i] = redacted
+			markers = append(markers, itemMarkers...)
+		}
+		return result, markers
+	default:
+		return value, nil
+	}
+}
+
+func (p *Pipeline) Map(path string, value map[string]any) (map[string]any, []store.RedactionMarker) {
+	if p == nil || value == nil {
+		return value, nil
+	}
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make(map[string]any, len(value))
+	var markers []store.RedactionMarker
+	for _, key := range keys {
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+		redacted, childMarkers := p.Value(childPath, value[key])
+		result[key] = redacted
+		markers = append(markers, childMarkers...)
+	}
+	return result, markers
+}
+
+// Summary renders markers as "detector:label" counts for logs and metadata.
+func Summary(markers []store.RedactionMarker) map[string]int {
+	if len(markers) == 0 {
+		return nil
+	}
+	summary := map[string]int{}
+	for _, marker := range markers {
+		summary[fmt.Sprintf("%s:%s", marker.Detector, marker.Label)] += marker.Count
+	}
+	return summary
+}
+
+func replacementToken(label string) string {
+	return "[redacted:" + strings.TrimSpace(label) + "]"
+}
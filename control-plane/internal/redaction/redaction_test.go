@@ -0,0 +1,113 @@
+package redaction
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+func TestPipelineText_KeyFormats(t *testing.T) {
+	pipeline := New(KeyFormats())
+
+	text := "token ghp_" + strings.Repeat("a", 36) + " and Authorization: Bearer " + strings.Repeat("x", 32)
+	redacted, markers := pipeline.Text("stdout", text)
+
+	require.Equal(t, "token [redacted:github_token] and Authorization: Bearer [redacted:bearer_token]", redacted)
+	require.Equal(t, []store.RedactionMarker{
+		{Detector: DetectorKeyFormat, Label: "github_token", Path: "stdout", Count: 1},
+		{Detector: DetectorKeyFormat, Label: "bearer_token", Path: "stdout", Count: 1},
+	}, markers)
+}
+
+func TestPipelineText_NoMatches(t *testing.T) {
+	redacted, markers := New(KeyFormats(), Emails()).Text("content", "nothing to see here")
+	require.Equal(t, "nothing to see here", redacted)
+	require.Nil(t, markers)
+}
+
+func TestSecretValues_LongestFirst(t *testing.T) {
+	pipeline := New(SecretValues(map[string]string{
+		"SHORT": "abcd",
+		"LONG":  "abcdefgh",
+		"TINY":  "ab",
+	}))
+
+	redacted, markers := pipeline.Text("content", "abcdefgh abcd ab")
+	require.Equal(t, "[secret:LONG] [secret:SHORT] ab", redacted)
+	require.Len(t, markers, 2)
+	require.Equal(t, "LONG", markers[0].Label)
+}
+
+func TestPipelineMap_CopiesAndRecordsPaths(t *testing.T) {
+	pipeline := New(Emails())
+	input := map[string]any{
+		"output": map[string]any{
+			"lines": []any{"ok", "mail ops@example.com"},
+			"count": 2,
+		},
+	}
+
+	redacted, markers := pipeline.Map("", input)
+	lines := redacted["output"].(map[string]any)["lines"].([]any)
+	require.Equal(t, "mail [redacted:email]", lines[1])
+	require.Equal(t, 2, redacted["output"].(map[string]any)["count"])
+	require.Equal(t, "mail ops@example.com", input["output"].(map[string]any)["lines"].([]any)[1])
+	require.Equal(t, []store.RedactionMarker{
+		{Detector: DetectorEmail, Label: "email", Path: "output.lines[1]", Count: 1},
+	}, markers)
+}
+
+func TestFromPolicy(t *testing.T) {
+	policy := DefaultPolicy("ops")
+	policy.CustomRules = []store.RedactionRule{{Name: "ticket", Pattern: `TCK-[0-9]+`}}
+
+	pipeline, err := FromPolicy(policy, map[string]string{"API_TOKEN": "s3cr3t-value"})
+	require.NoError(t, err)
+
+	redacted, markers := pipeline.Text("content", "s3cr3t-value TCK-42 dev@example.com")
+	require.Equal(t, "[secret:API_TOKEN] [redacted:ticket] dev@example.com", redacted)
+	require.Equal(t, map[string]int{"vault_secret:API_TOKEN": 1, "custom:ticket": 1}, Summary(markers))
+}
+
+type policyMap map[string]*store.RedactionPolicy
+
+func (m policyMap) GetRedactionPolicy(ctx context.Context, policyProfile string) (*store.RedactionPolicy, error) {
+	return m[policyProfile], nil
+}
+
+func TestEffectivePolicy_FallsBackToDefaultProfile(t *testing.T) {
+	ctx := context.Background()
+
+	policy, err := EffectivePolicy(ctx, policyMap{}, "ops")
+	require.NoError(t, err)
+	require.Equal(t, DefaultPolicy("ops"), policy)
+
+	stored := policyMap{DefaultPolicyProfile: {PolicyProfile: DefaultPolicyProfile, Emails: true}}
+	policy, err = EffectivePolicy(ctx, stored, "ops")
+	require.NoError(t, err)
+	require.True(t, policy.Emails)
+	require.False(t, policy.KeyFormats)
+
+	stored["ops"] = &store.RedactionPolicy{PolicyProfile: "ops", KeyFormats: true}
+	policy, err = EffectivePolicy(ctx, stored, " ops ")
+	require.NoError(t, err)
+	require.Equal(t, "ops", policy.PolicyProfile)
+	require.True(t, policy.KeyFormats)
+}
+
+func TestCompileRules_Validation(t *testing.T) {
+	cases := []store.RedactionRule{
+		{Name: "", Pattern: "x"},
+		{Name: "empty", Pattern: ""},
+		{Name: "invalid", Pattern: "("},
+		{Name: "matches-empty", Pattern: "a*"},
+	}
+	for _, rule := range cases {
+		_, err := CompileRules([]store.RedactionRule{rule})
+		require.Error(t, err, rule.Name)
+	}
+}
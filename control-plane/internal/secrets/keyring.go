@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Keyring holds the primary key used for new ciphertexts plus any number of
+// decrypt-only keys kept around until stored secrets have been rotated.
+type Keyring struct {
+	primary []byte
+	keys    map[string][]byte
+	order   []string
+}
+
+func NewKeyring(primary []byte, previous ...[]byte) *Keyring {
+	ring := &Keyring{primary: primary, keys: map[string][]byte{}}
+	for _, key := range append([][]byte{primary}, previous...) {
+		id := KeyID(key)
+		if _, ok := ring.keys[id]; ok {
+			continue
+		}
+		ring.keys[id] = key
+		ring.order = append(ring.order, id)
+	}
+	return ring
+}
+
+// ParseKeyring parses LLM_SECRETS_KEY and the comma-separated
+// LLM_SECRETS_PREVIOUS_KEYS.
+func ParseKeyring(primary string, previous string) (*Keyring, error) {
+	primaryKey, err := ParseKey(primary)
+	if err != nil {
+		return nil, err
+	}
+	previousKeys, err := ParseKeyList(previous)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(primaryKey, previousKeys...), nil
+}
+
+func ParseKeyList(raw string) ([][]byte, error) {
+	keys := [][]byte{}
+	for i, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, err := ParseKey(part)
+		if err != nil {
+			return nil, fmt.Errorf("LLM_SECRETS_PREVIOUS_KEYS entry %d: %w", i+1, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (k *Keyring) PrimaryKey() []byte {
+	return k.primary
+}
+
+func (k *Keyring) PrimaryID() string {
+	return KeyID(k.primary)
+}
+
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	return Encrypt(k.primary, plaintext)
+}
+
+// Decrypt picks the key named by the ciphertext's key ID. Legacy untagged
+// values are tried against every key, primary first.
+func (k *Keyring) Decrypt(encoded string) (string, error) {
+	keyID, payload := splitCiphertext(encoded)
+	if keyID != "" {
+		key, ok := k.keys[keyID]
+		if !ok {
+			return "", fmt.Errorf("secret was encrypted with unknown key %s", keyID)
+		}
+		return decryptPayload(key, payload)
+	}
+	var lastErr error
+	for _, id := range k.order {
+		plain, err := decryptPayload(k.keys[id], payload)
+		if err == nil {
+			return plain, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no secrets key configured")
+	}
+	return "", lastErr
+}
+
+// Rotate re-encrypts a ciphertext with the primary key. It reports false when
+// the value is already tagged with the primary key and was left untouched.
+func (k *Keyring) Rotate(encoded string) (string, bool, error) {
+	if CiphertextKeyID(encoded) == k.PrimaryID() {
+		return encoded, false, nil
+	}
+	plain, err := k.Decrypt(encoded)
+	if err != nil {
+		return encoded, false, err
+	}
+	rotated, err := k.Encrypt(plain)
+	if err != nil {
+		return encoded, false, err
+	}
+	return rotated, true, nil
+}
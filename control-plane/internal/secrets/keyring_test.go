@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestKeyring_DecryptsWithPreviousKeys(t *testing.T) {
+	oldKey := []byte(strings.Repeat("o", 32))
+	newKey := fixedKey()
+	oldCipher, err := Encrypt(oldKey, "old-secret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, legacy := splitCiphertext(oldCipher)
+
+	ring := NewKeyring(newKey, oldKey)
+	for _, encoded := range []string{oldCipher, legacy} {
+		result, err := ring.Decrypt(encoded)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result != "old-secret" {
+			t.Fatalf("expected %q, got %q", "old-secret", result)
+		}
+	}
+
+	encoded, err := ring.Encrypt("new-secret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if CiphertextKeyID(encoded) != ring.PrimaryID() {
+		t.Fatalf("expected primary key id, got %q", CiphertextKeyID(encoded))
+	}
+
+	if _, err := NewKeyring(newKey).Decrypt(oldCipher); err == nil {
+		t.Fatal("expected unknown key error")
+	}
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	oldKey := []byte(strings.Repeat("o", 32))
+	ring := NewKeyring(fixedKey(), oldKey)
+	oldCipher, err := Encrypt(oldKey, "value")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	rotated, changed, err := ring.Rotate(oldCipher)
+	if err != nil || !changed {
+		t.Fatalf("expected rotation, got changed=%v err=%v", changed, err)
+	}
+	if CiphertextKeyID(rotated) != ring.PrimaryID() {
+		t.Fatalf("expected primary key id, got %q", CiphertextKeyID(rotated))
+	}
+
+	again, changed, err := ring.Rotate(rotated)
+	if err != nil || changed || again != rotated {
+		t.Fatalf("expected no-op for primary ciphertext, got changed=%v err=%v", changed, err)
+	}
+
+	if _, _, err := ring.Rotate("garbage"); err == nil {
+		t.Fatal("expected error for undecryptable value")
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	previous := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32)))
+	ring, err := ParseKeyring(strings.Repeat("a", 32), " "+previous+", ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(ring.keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(ring.keys))
+	}
+	if _, err := ParseKeyring(strings.Repeat("a", 32), "bad"); err == nil {
+		t.Fatal("expected error for invalid previous key")
+	}
+	if _, err := ParseKeyring("", ""); err == nil {
+		t.Fatal("expected error for missing primary key")
+	}
+}
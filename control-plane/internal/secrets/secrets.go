@@ -4,13 +4,43 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
 )
 
 var newGCM = cipher.NewGCM
 
+// Ciphertexts are "<key id>:<base64 nonce+ciphertext>". The key ID is derived
+// from the key itself, so the same key always yields the same ID. Values
+// written before key IDs were introduced are bare base64 and are still
+// accepted by Decrypt.
+const keyIDSeparator = ":"
+
+// KeyID returns the short fingerprint used to tag ciphertexts produced with key.
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// CiphertextKeyID returns the key ID a ciphertext was tagged with, or "" for
+// untagged legacy values.
+func CiphertextKeyID(encoded string) string {
+	keyID, _ := splitCiphertext(encoded)
+	return keyID
+}
+
+func splitCiphertext(encoded string) (string, string) {
+	if idx := strings.Index(encoded, keyIDSeparator); idx >= 0 {
+		return encoded[:idx], encoded[idx+len(keyIDSeparator):]
+	}
+	return "", encoded
+}
+
 func ParseKey(raw string) ([]byte, error) {
 	if raw == "" {
 		return nil, errors.New("LLM_SECRETS_KEY is required")
@@ -43,10 +73,18 @@ func Encrypt(key []byte, plaintext string) (string, error) {
 	}
 	ciphertext := gcm.Seal(nil, nonce, []byte(plaintext), nil)
 	combined := append(nonce, ciphertext...)
-	return base64.StdEncoding.EncodeToString(combined), nil
+	return KeyID(key) + keyIDSeparator + base64.StdEncoding.EncodeToString(combined), nil
 }
 
 func Decrypt(key []byte, encoded string) (string, error) {
+	keyID, payload := splitCiphertext(encoded)
+	if keyID != "" && keyID != KeyID(key) {
+		return "", fmt.Errorf("secret was encrypted with key %s", keyID)
+	}
+	return decryptPayload(key, payload)
+}
+
+func decryptPayload(key []byte, encoded string) (string, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
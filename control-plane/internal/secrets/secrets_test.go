@@ -133,12 +133,13 @@ func TestDecrypt_Tampered(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
-	data, err := base64.StdEncoding.DecodeString(encoded)
+	keyID, payload := splitCiphertext(encoded)
+	data, err := base64.StdEncoding.DecodeString(payload)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 	data[len(data)-1] ^= 0xff
-	tampered := base64.StdEncoding.EncodeToString(data)
+	tampered := keyID + keyIDSeparator + base64.StdEncoding.EncodeToString(data)
 	_, err = Decrypt(key, tampered)
 	if err == nil {
 		t.Fatal("expected error")
@@ -208,3 +209,41 @@ func TestDecrypt_NewGCMError(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestEncrypt_TagsKeyID(t *testing.T) {
+	key := fixedKey()
+	encoded, err := Encrypt(key, "secret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := CiphertextKeyID(encoded); got != KeyID(key) {
+		t.Fatalf("expected key id %q, got %q", KeyID(key), got)
+	}
+}
+
+func TestDecrypt_LegacyUntagged(t *testing.T) {
+	key := fixedKey()
+	encoded, err := Encrypt(key, "secret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, legacy := splitCiphertext(encoded)
+	result, err := Decrypt(key, legacy)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result != "secret" {
+		t.Fatalf("expected %q, got %q", "secret", result)
+	}
+}
+
+func TestDecrypt_KeyIDMismatch(t *testing.T) {
+	encoded, err := Encrypt(fixedKey(), "secret")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	_, err = Decrypt([]byte(strings.Repeat("b", 32)), encoded)
+	if err == nil || !strings.Contains(err.Error(), "encrypted with key") {
+		t.Fatalf("expected key mismatch error, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Package signing implements the HMAC request signatures services use when
+// posting events and messages to the control plane.
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	HeaderService   = "X-Gavryn-Service"
+	HeaderTimestamp = "X-Gavryn-Timestamp"
+	HeaderSignature = "X-Gavryn-Signature"
+	HeaderNonce     = "X-Gavryn-Nonce"
+	// HeaderWorkspace selects the workspace a signed request acts in, so it
+	// is part of the signed string whenever it is set.
+	HeaderWorkspace = "X-Gavryn-Workspace"
+
+	signatureVersion = "v1"
+	// MaxClockSkew bounds how old (or how far in the future) a signed request
+	// may be, which also limits how long a captured request can be replayed.
+	MaxClockSkew = 5 * time.Minute
+)
+
+var (
+	ErrMissingSignature = errors.New("request is not signed")
+	ErrUnknownService   = errors.New("unknown signing service")
+	ErrExpired          = errors.New("signature timestamp outside allowed window")
+	ErrInvalidSignature = errors.New("invalid request signature")
+	ErrReplayed         = errors.New("signed request was already used")
+)
+
+// Sign returns the signature header value for a request. The signed string
+// covers the timestamp, nonce, method, target (the path and any query
+// string) and a SHA-256 of the body, followed by the workspace when one is
+// set. Requests without a workspace sign the same string as before it was
+// added.
+func Sign(secret string, timestamp int64, nonce string, method string, target string, workspace string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d\n%s\n%s\n%s\n%s", timestamp, nonce, strings.ToUpper(method), target, hex.EncodeToString(bodyHash[:]))
+	if workspace != "" {
+		fmt.Fprintf(mac, "\n%s", workspace)
+	}
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Target returns the part of u a signature covers: the path, followed by
+// "?" and the raw query when there is one.
+func Target(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	return u.Path + "?" + u.RawQuery
+}
+
+// NewNonce returns a random nonce for a signed request.
+func NewNonce() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// SignRequest sets the signing headers on req for body, which must be the
+// exact bytes sent. The workspace header must be set before signing.
+func SignRequest(req *http.Request, service string, secret string, body []byte, now time.Time) {
+	timestamp := now.Unix()
+	nonce := NewNonce()
+	req.Header.Set(HeaderService, service)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, nonce, req.Method, Target(req.URL), workspaceOf(req), body))
+}
+
+func workspaceOf(req *http.Request) string {
+	return strings.TrimSpace(req.Header.Get(HeaderWorkspace))
+}
+
+// IsSigned reports whether req carries signing headers.
+func IsSigned(req *http.Request) bool {
+	return strings.TrimSpace(req.Header.Get(HeaderSignature)) != ""
+}
+
+// Verify checks req against the per-service secrets and returns the
+// authenticated service name. With nonces set, a request is only accepted
+// once.
+func Verify(req *http.Request, body []byte, secrets map[string]string, nonces *NonceCache, now time.Time) (string, error) {
+	service := strings.TrimSpace(req.Header.Get(HeaderService))
+	signature := strings.TrimSpace(req.Header.Get(HeaderSignature))
+	if service == "" || signature == "" {
+		return "", ErrMissingSignature
+	}
+	secret, ok := secrets[service]
+	if !ok || secret == "" {
+		return "", ErrUnknownService
+	}
+	if err := VerifySecret(req, body, secret, nonces, now); err != nil {
+		return "", err
+	}
+	return service, nil
+}
+
+// VerifySecret checks req against a single secret. The service header is
+// not required, so senders that only know one secret can sign requests.
+func VerifySecret(req *http.Request, body []byte, secret string, nonces *NonceCache, now time.Time) error {
+	signature := strings.TrimSpace(req.Header.Get(HeaderSignature))
+	nonce := strings.TrimSpace(req.Header.Get(HeaderNonce))
+	if signature == "" || nonce == "" {
+		return ErrMissingSignature
+	}
+	timestamp, err := strconv.ParseInt(strings.TrimSpace(req.Header.Get(HeaderTimestamp)), 10, 64)
+	if err != nil {
+		return ErrExpired
+	}
+	skew := now.Sub(time.Unix(timestamp, 0))
+	if skew > MaxClockSkew || skew < -MaxClockSkew {
+		return ErrExpired
+	}
+	expected := Sign(secret, timestamp, nonce, req.Method, Target(req.URL), workspaceOf(req), body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	if nonces != nil && !nonces.use(nonce, now) {
+		return ErrReplayed
+	}
+	return nil
+}
+
+// NonceCache remembers the nonces of accepted requests for as long as their
+// timestamps are inside the allowed window, so a captured request cannot be
+// replayed. It is per process: replicas behind a load balancer each keep
+// their own. It is safe for concurrent use.
+type NonceCache struct {
+	mu        sync.Mutex
+	expiresAt map[string]time.Time
+	nextPrune time.Time
+}
+
+func NewNonceCache() *NonceCache {
+	return &NonceCache{expiresAt: map[string]time.Time{}}
+}
+
+// use records nonce and reports whether it was unused.
+func (c *NonceCache) use(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !now.Before(c.nextPrune) {
+		for key, expiresAt := range c.expiresAt {
+			if !now.Before(expiresAt) {
+				delete(c.expiresAt, key)
+			}
+		}
+		c.nextPrune = now.Add(MaxClockSkew)
+	}
+	if expiresAt, ok := c.expiresAt[nonce]; ok && now.Before(expiresAt) {
+		return false
+	}
+	// A timestamp may run MaxClockSkew ahead of the clock and is accepted
+	// until MaxClockSkew after it.
+	c.expiresAt[nonce] = now.Add(2 * MaxClockSkew)
+	return true
+}
+
+// ParseSecrets parses "service=secret" pairs separated by commas.
+func ParseSecrets(raw string) (map[string]string, error) {
+	secrets := map[string]string{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		service, secret, ok := strings.Cut(part, "=")
+		service = strings.TrimSpace(service)
+		secret = strings.TrimSpace(secret)
+		if !ok || service == "" || secret == "" {
+			return nil, fmt.Errorf("invalid signing secret entry %q: want service=secret", part)
+		}
+		secrets[service] = secret
+	}
+	return secrets, nil
+}
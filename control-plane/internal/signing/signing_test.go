@@ -0,0 +1,136 @@
+package signing
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newSignedRequest(t *testing.T, service string, secret string, body []byte, at time.Time) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "http://control-plane/runs/run-1/events", nil)
+	require.NoError(t, err)
+	SignRequest(req, service, secret, body, at)
+	return req
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	secrets := map[string]string{"worker": "worker-secret"}
+	body := []byte(`{"type":"run.completed"}`)
+
+	req := newSignedRequest(t, "worker", "worker-secret", body, now)
+	nonces := NewNonceCache()
+	service, err := Verify(req, body, secrets, nonces, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, "worker", service)
+	_, err = Verify(req, body, secrets, nonces, now.Add(2*time.Minute))
+	require.ErrorIs(t, err, ErrReplayed)
+
+	_, err = Verify(req, []byte(`{"type":"run.failed"}`), secrets, nil, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = Verify(req, body, secrets, nil, now.Add(MaxClockSkew+time.Second))
+	require.ErrorIs(t, err, ErrExpired)
+
+	forged := newSignedRequest(t, "worker", "guess", body, now)
+	_, err = Verify(forged, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	unknown := newSignedRequest(t, "tool_runner", "worker-secret", body, now)
+	_, err = Verify(unknown, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrUnknownService)
+
+	otherPath := newSignedRequest(t, "worker", "worker-secret", body, now)
+	otherPath.URL.Path = "/runs/run-2/events"
+	_, err = Verify(otherPath, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	otherQuery := newSignedRequest(t, "worker", "worker-secret", body, now)
+	otherQuery.URL.RawQuery = "after_seq=10"
+	_, err = Verify(otherQuery, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	noNonce := newSignedRequest(t, "worker", "worker-secret", body, now)
+	noNonce.Header.Del(HeaderNonce)
+	_, err = Verify(noNonce, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrMissingSignature)
+
+	unsigned, err := http.NewRequest(http.MethodPost, "http://control-plane/runs/run-1/events", nil)
+	require.NoError(t, err)
+	require.False(t, IsSigned(unsigned))
+	_, err = Verify(unsigned, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrMissingSignature)
+
+	workspaceReq := newSignedRequest(t, "worker", "worker-secret", body, now)
+	workspaceReq.Header.Set(HeaderWorkspace, "ws-1")
+	_, err = Verify(workspaceReq, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	pinned, err := http.NewRequest(http.MethodPost, "http://control-plane/automations/auto-1/inbox/entry-1/execute", nil)
+	require.NoError(t, err)
+	pinned.Header.Set(HeaderWorkspace, "ws-1")
+	SignRequest(pinned, "worker", "worker-secret", body, now)
+	_, err = Verify(pinned, body, secrets, nil, now)
+	require.NoError(t, err)
+	pinned.Header.Set(HeaderWorkspace, "ws-2")
+	_, err = Verify(pinned, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	pinned.Header.Del(HeaderWorkspace)
+	_, err = Verify(pinned, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	badTimestamp := newSignedRequest(t, "worker", "worker-secret", body, now)
+	badTimestamp.Header.Set(HeaderTimestamp, "soon")
+	_, err = Verify(badTimestamp, body, secrets, nil, now)
+	require.ErrorIs(t, err, ErrExpired)
+}
+
+func TestVerifySecret(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	body := []byte(`{"ticker":"SOL"}`)
+	req, err := http.NewRequest(http.MethodPost, "http://control-plane/automations/auto-1/trigger", nil)
+	require.NoError(t, err)
+	req.Header.Set(HeaderTimestamp, "1800000000")
+	req.Header.Set(HeaderNonce, "nonce-1")
+	req.Header.Set(HeaderSignature, Sign("trigger-secret", now.Unix(), "nonce-1", req.Method, req.URL.Path, "", body))
+
+	require.NoError(t, VerifySecret(req, body, "trigger-secret", nil, now))
+	require.ErrorIs(t, VerifySecret(req, body, "other-secret", nil, now), ErrInvalidSignature)
+	require.ErrorIs(t, VerifySecret(req, body, "trigger-secret", nil, now.Add(MaxClockSkew+time.Second)), ErrExpired)
+	req.Header.Del(HeaderSignature)
+	require.ErrorIs(t, VerifySecret(req, body, "trigger-secret", nil, now), ErrMissingSignature)
+}
+
+func TestSignMatchesKnownVector(t *testing.T) {
+	// Workers written in other languages must produce the same value.
+	signature := Sign("secret", 1_800_000_000, "nonce-1", "post", "/runs/run-1/events?after_seq=1", "", []byte("{}"))
+	require.Equal(t, "v1=1c7c16e73d3531f1364504cf473528e8a9f379e3e1735805ef23f9d79c254026", signature)
+	require.NotEqual(t, signature, Sign("secret", 1_800_000_000, "nonce-1", "post", "/runs/run-1/events?after_seq=1", "ws-1", []byte("{}")))
+}
+
+func TestNonceCache(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	nonces := NewNonceCache()
+	require.True(t, nonces.use("a", now))
+	require.False(t, nonces.use("a", now.Add(2*MaxClockSkew-time.Second)))
+	require.True(t, nonces.use("b", now))
+
+	// Expired nonces are pruned; their requests fail the timestamp check.
+	later := now.Add(3 * MaxClockSkew)
+	require.True(t, nonces.use("c", later))
+	require.Len(t, nonces.expiresAt, 1)
+}
+
+func TestParseSecrets(t *testing.T) {
+	secrets, err := ParseSecrets(" worker=one , tool_runner=two,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"worker": "one", "tool_runner": "two"}, secrets)
+
+	_, err = ParseSecrets("worker")
+	require.Error(t, err)
+	_, err = ParseSecrets("=secret")
+	require.Error(t, err)
+}
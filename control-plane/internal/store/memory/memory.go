@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -19,17 +20,34 @@ type MemoryStore struct {
 	processes   map[string]map[string]store.RunProcess
 	messages    map[string][]store.Message
 	seq         map[string]int64
-	settings    *store.LLMSettings
+	settings    map[string]store.LLMSettings
+	routeRules  map[string]store.LLMRouteRule
 	skills      map[string]store.Skill
 	files       map[string]map[string]store.SkillFile
 	context     map[string]store.ContextNode
-	memory      *store.MemorySettings
-	personality *store.PersonalitySettings
+	memory      map[string]store.MemorySettings
+	personality map[string]store.PersonalitySettings
 	entries     []store.MemoryEntry
 	entryIndex  map[string]store.MemoryEntry
 	artifacts   map[string]map[string]store.Artifact
 	automations map[string]store.Automation
 	inbox       map[string][]store.AutomationInboxEntry
+	profiles    map[string]store.LLMProviderProfile
+	secrets     map[string]store.Secret
+	redaction   map[string]map[string]store.RedactionPolicy
+	apiKeys     map[string]store.APIKey
+	workspaces  map[string]store.Workspace
+	users       map[string]store.User
+	members     map[string]map[string]store.WorkspaceMember
+	audit       []store.AuditEntry
+	webhooks    map[string]store.WebhookSubscription
+	deliveries  map[string]store.WebhookDelivery
+	channels    map[string]store.NotificationChannel
+	// owners maps "kind:id" to the workspace of skills, context nodes,
+	// memory entries, automations, webhooks, notification channels, route
+	// rules, provider profiles and secrets; missing keys belong to the
+	// default workspace.
+	owners map[string]string
 }
 
 func New() *MemoryStore {
@@ -40,6 +58,7 @@ func New() *MemoryStore {
 		processes:   map[string]map[string]store.RunProcess{},
 		messages:    map[string][]store.Message{},
 		seq:         map[string]int64{},
+		routeRules:  map[string]store.LLMRouteRule{},
 		skills:      map[string]store.Skill{},
 		files:       map[string]map[string]store.SkillFile{},
 		context:     map[string]store.ContextNode{},
@@ -47,9 +66,55 @@ func New() *MemoryStore {
 		artifacts:   map[string]map[string]store.Artifact{},
 		automations: map[string]store.Automation{},
 		inbox:       map[string][]store.AutomationInboxEntry{},
+		profiles:    map[string]store.LLMProviderProfile{},
+		secrets:     map[string]store.Secret{},
+		redaction:   map[string]map[string]store.RedactionPolicy{},
+		apiKeys:     map[string]store.APIKey{},
+		settings:    map[string]store.LLMSettings{},
+		memory:      map[string]store.MemorySettings{},
+		personality: map[string]store.PersonalitySettings{},
+		workspaces:  map[string]store.Workspace{store.DefaultWorkspaceID: defaultWorkspace()},
+		users:       map[string]store.User{},
+		members:     map[string]map[string]store.WorkspaceMember{},
+		webhooks:    map[string]store.WebhookSubscription{},
+		deliveries:  map[string]store.WebhookDelivery{},
+		channels:    map[string]store.NotificationChannel{},
+		owners:      map[string]string{},
 	}
 }
 
+func defaultWorkspace() store.Workspace {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	return store.Workspace{ID: store.DefaultWorkspaceID, Name: "Default", CreatedAt: now, UpdatedAt: now}
+}
+
+func (m *MemoryStore) setOwnerLocked(ctx context.Context, kind string, id string) {
+	m.owners[kind+":"+id] = store.WorkspaceID(ctx)
+}
+
+func (m *MemoryStore) ownedLocked(ctx context.Context, kind string, id string) bool {
+	owner := m.owners[kind+":"+id]
+	if owner == "" {
+		owner = store.DefaultWorkspaceID
+	}
+	return owner == store.WorkspaceID(ctx)
+}
+
+func runWorkspace(run store.Run) string {
+	if run.WorkspaceID == "" {
+		return store.DefaultWorkspaceID
+	}
+	return run.WorkspaceID
+}
+
+// foreignRunLocked reports whether runID exists in another workspace. Child
+// rows of unknown runs are accepted so callers can record events before the
+// run row lands.
+func (m *MemoryStore) foreignRunLocked(ctx context.Context, runID string) bool {
+	run, ok := m.runs[runID]
+	return ok && runWorkspace(run) != store.WorkspaceID(ctx)
+}
+
 func (m *MemoryStore) CreateRun(ctx context.Context, run store.Run) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -59,13 +124,39 @@ func (m *MemoryStore) CreateRun(ctx context.Context, run store.Run) error {
 	if strings.TrimSpace(run.PolicyProfile) == "" {
 		run.PolicyProfile = "default"
 	}
+	run.WorkspaceID = store.WorkspaceID(ctx)
 	m.runs[run.ID] = run
 	return nil
 }
 
+func (m *MemoryStore) GetRun(ctx context.Context, runID string) (*store.Run, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	run, ok := m.runs[runID]
+	if !ok || runWorkspace(run) != store.WorkspaceID(ctx) {
+		return nil, nil
+	}
+	run.WorkspaceID = runWorkspace(run)
+	run.Tags = append([]string(nil), run.Tags...)
+	return &run, nil
+}
+
+func (m *MemoryStore) GetRunWorkspace(ctx context.Context, runID string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	run, ok := m.runs[runID]
+	if !ok {
+		return "", nil
+	}
+	return runWorkspace(run), nil
+}
+
 func (m *MemoryStore) DeleteRun(ctx context.Context, runID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.foreignRunLocked(ctx, runID) {
+		return nil
+	}
 	delete(m.runs, runID)
 	delete(m.events, runID)
 	delete(m.runSteps, runID)
@@ -76,14 +167,689 @@ func (m *MemoryStore) DeleteRun(ctx context.Context, runID string) error {
 	return nil
 }
 
+func (m *MemoryStore) ListLLMRouteRules(ctx context.Context) ([]store.LLMRouteRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]store.LLMRouteRule, 0, len(m.routeRules))
+	for _, rule := range m.routeRules {
+		if !m.ownedLocked(ctx, "route_rule", rule.ID) {
+			continue
+		}
+		results = append(results, cloneRouteRule(rule))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Priority != results[j].Priority {
+			return results[i].Priority < results[j].Priority
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetLLMRouteRule(ctx context.Context, ruleID string) (*store.LLMRouteRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rule, ok := m.routeRules[ruleID]
+	if !ok || !m.ownedLocked(ctx, "route_rule", ruleID) {
+		return nil, nil
+	}
+	cloned := cloneRouteRule(rule)
+	return &cloned, nil
+}
+
+func (m *MemoryStore) CreateLLMRouteRule(ctx context.Context, rule store.LLMRouteRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routeRules[rule.ID] = cloneRouteRule(rule)
+	m.setOwnerLocked(ctx, "route_rule", rule.ID)
+	return nil
+}
+
+func (m *MemoryStore) UpdateLLMRouteRule(ctx context.Context, rule store.LLMRouteRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.routeRules[rule.ID]; !ok || !m.ownedLocked(ctx, "route_rule", rule.ID) {
+		return nil
+	}
+	m.routeRules[rule.ID] = cloneRouteRule(rule)
+	return nil
+}
+
+func (m *MemoryStore) DeleteLLMRouteRule(ctx context.Context, ruleID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "route_rule", ruleID) {
+		return nil
+	}
+	delete(m.routeRules, ruleID)
+	delete(m.owners, "route_rule:"+ruleID)
+	return nil
+}
+
+func cloneRouteRule(rule store.LLMRouteRule) store.LLMRouteRule {
+	cloned := rule
+	cloned.TaskTypes = append([]string{}, rule.TaskTypes...)
+	cloned.Tags = append([]string{}, rule.Tags...)
+	cloned.PolicyProfiles = append([]string{}, rule.PolicyProfiles...)
+	cloned.AutomationIDs = append([]string{}, rule.AutomationIDs...)
+	return cloned
+}
+
+func (m *MemoryStore) ListLLMProviderProfiles(ctx context.Context) ([]store.LLMProviderProfile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]store.LLMProviderProfile, 0, len(m.profiles))
+	for _, profile := range m.profiles {
+		if !m.ownedLocked(ctx, "llm_profile", profile.ID) {
+			continue
+		}
+		results = append(results, profile)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetLLMProviderProfile(ctx context.Context, profileID string) (*store.LLMProviderProfile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	profile, ok := m.profiles[profileID]
+	if !ok || !m.ownedLocked(ctx, "llm_profile", profileID) {
+		return nil, nil
+	}
+	return &profile, nil
+}
+
+func (m *MemoryStore) CreateLLMProviderProfile(ctx context.Context, profile store.LLMProviderProfile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.profiles[profile.ID] = profile
+	m.setOwnerLocked(ctx, "llm_profile", profile.ID)
+	return nil
+}
+
+func (m *MemoryStore) UpdateLLMProviderProfile(ctx context.Context, profile store.LLMProviderProfile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.profiles[profile.ID]; !ok || !m.ownedLocked(ctx, "llm_profile", profile.ID) {
+		return nil
+	}
+	m.profiles[profile.ID] = profile
+	return nil
+}
+
+func (m *MemoryStore) DeleteLLMProviderProfile(ctx context.Context, profileID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "llm_profile", profileID) {
+		return nil
+	}
+	delete(m.profiles, profileID)
+	delete(m.owners, "llm_profile:"+profileID)
+	return nil
+}
+
+func (m *MemoryStore) ListSecrets(ctx context.Context) ([]store.Secret, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]store.Secret, 0, len(m.secrets))
+	for _, secret := range m.secrets {
+		if !m.ownedLocked(ctx, "secret", secret.ID) {
+			continue
+		}
+		results = append(results, cloneSecret(secret))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetSecret(ctx context.Context, secretID string) (*store.Secret, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	secret, ok := m.secrets[secretID]
+	if !ok || !m.ownedLocked(ctx, "secret", secretID) {
+		return nil, nil
+	}
+	cloned := cloneSecret(secret)
+	return &cloned, nil
+}
+
+func (m *MemoryStore) CreateSecret(ctx context.Context, secret store.Secret) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[secret.ID] = cloneSecret(secret)
+	m.setOwnerLocked(ctx, "secret", secret.ID)
+	return nil
+}
+
+func (m *MemoryStore) UpdateSecret(ctx context.Context, secret store.Secret) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.secrets[secret.ID]; !ok || !m.ownedLocked(ctx, "secret", secret.ID) {
+		return nil
+	}
+	m.secrets[secret.ID] = cloneSecret(secret)
+	return nil
+}
+
+func (m *MemoryStore) DeleteSecret(ctx context.Context, secretID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "secret", secretID) {
+		return nil
+	}
+	delete(m.secrets, secretID)
+	delete(m.owners, "secret:"+secretID)
+	return nil
+}
+
+func cloneSecret(secret store.Secret) store.Secret {
+	cloned := secret
+	cloned.PolicyProfiles = append([]string{}, secret.PolicyProfiles...)
+	return cloned
+}
+
+func (m *MemoryStore) ListRedactionPolicies(ctx context.Context) ([]store.RedactionPolicy, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	policies := m.redaction[store.WorkspaceID(ctx)]
+	results := make([]store.RedactionPolicy, 0, len(policies))
+	for _, policy := range policies {
+		results = append(results, cloneRedactionPolicy(policy))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].PolicyProfile < results[j].PolicyProfile
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetRedactionPolicy(ctx context.Context, policyProfile string) (*store.RedactionPolicy, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	policy, ok := m.redaction[store.WorkspaceID(ctx)][policyProfile]
+	if !ok {
+		return nil, nil
+	}
+	cloned := cloneRedactionPolicy(policy)
+	return &cloned, nil
+}
+
+func (m *MemoryStore) UpsertRedactionPolicy(ctx context.Context, policy store.RedactionPolicy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	workspaceID := store.WorkspaceID(ctx)
+	if m.redaction[workspaceID] == nil {
+		m.redaction[workspaceID] = map[string]store.RedactionPolicy{}
+	}
+	if existing, ok := m.redaction[workspaceID][policy.PolicyProfile]; ok {
+		policy.CreatedAt = existing.CreatedAt
+	}
+	m.redaction[workspaceID][policy.PolicyProfile] = cloneRedactionPolicy(policy)
+	return nil
+}
+
+func (m *MemoryStore) DeleteRedactionPolicy(ctx context.Context, policyProfile string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.redaction[store.WorkspaceID(ctx)], policyProfile)
+	return nil
+}
+
+func cloneRedactionPolicy(policy store.RedactionPolicy) store.RedactionPolicy {
+	cloned := policy
+	cloned.CustomRules = append([]store.RedactionRule(nil), policy.CustomRules...)
+	return cloned
+}
+
+func (m *MemoryStore) ListAPIKeys(ctx context.Context) ([]store.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]store.APIKey, 0, len(m.apiKeys))
+	for _, key := range m.apiKeys {
+		if key.WorkspaceID != store.WorkspaceID(ctx) {
+			continue
+		}
+		results = append(results, cloneAPIKey(key))
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].CreatedAt != results[j].CreatedAt {
+			return results[i].CreatedAt < results[j].CreatedAt
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetAPIKey(ctx context.Context, keyID string) (*store.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.apiKeys[keyID]
+	if !ok || key.WorkspaceID != store.WorkspaceID(ctx) {
+		return nil, nil
+	}
+	cloned := cloneAPIKey(key)
+	return &cloned, nil
+}
+
+func (m *MemoryStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*store.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.apiKeys {
+		if key.KeyHash == keyHash {
+			cloned := cloneAPIKey(key)
+			return &cloned, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryStore) CreateAPIKey(ctx context.Context, key store.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if strings.TrimSpace(key.WorkspaceID) == "" {
+		key.WorkspaceID = store.DefaultWorkspaceID
+	}
+	m.apiKeys[key.ID] = cloneAPIKey(key)
+	return nil
+}
+
+func (m *MemoryStore) TouchAPIKey(ctx context.Context, keyID string, usedAt string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.apiKeys[keyID]
+	if !ok {
+		return nil
+	}
+	key.LastUsedAt = usedAt
+	m.apiKeys[keyID] = key
+	return nil
+}
+
+func (m *MemoryStore) DeleteAPIKey(ctx context.Context, keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key, ok := m.apiKeys[keyID]; !ok || key.WorkspaceID != store.WorkspaceID(ctx) {
+		return nil
+	}
+	delete(m.apiKeys, keyID)
+	return nil
+}
+
+func (m *MemoryStore) ListWorkspaces(ctx context.Context) ([]store.Workspace, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]store.Workspace, 0, len(m.workspaces))
+	for _, workspace := range m.workspaces {
+		results = append(results, workspace)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		left := parseTime(results[i].CreatedAt)
+		right := parseTime(results[j].CreatedAt)
+		if left.Equal(right) {
+			return results[i].ID < results[j].ID
+		}
+		return left.Before(right)
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetWorkspace(ctx context.Context, workspaceID string) (*store.Workspace, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	workspace, ok := m.workspaces[workspaceID]
+	if !ok {
+		return nil, nil
+	}
+	return &workspace, nil
+}
+
+func (m *MemoryStore) CreateWorkspace(ctx context.Context, workspace store.Workspace) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.workspaces[workspace.ID]; ok {
+		return fmt.Errorf("workspace %q already exists", workspace.ID)
+	}
+	m.workspaces[workspace.ID] = workspace
+	return nil
+}
+
+// DeleteWorkspace removes the workspace and everything it owns, matching the
+// cascading foreign keys in Postgres.
+func (m *MemoryStore) DeleteWorkspace(ctx context.Context, workspaceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.workspaces[workspaceID]; !ok {
+		return nil
+	}
+	delete(m.workspaces, workspaceID)
+	delete(m.members, workspaceID)
+	delete(m.settings, workspaceID)
+	delete(m.memory, workspaceID)
+	delete(m.personality, workspaceID)
+	delete(m.redaction, workspaceID)
+	for id, run := range m.runs {
+		if runWorkspace(run) != workspaceID {
+			continue
+		}
+		delete(m.runs, id)
+		delete(m.events, id)
+		delete(m.runSteps, id)
+		delete(m.processes, id)
+		delete(m.messages, id)
+		delete(m.seq, id)
+		delete(m.artifacts, id)
+	}
+	for key, owner := range m.owners {
+		if owner != workspaceID {
+			continue
+		}
+		kind, id, _ := strings.Cut(key, ":")
+		switch kind {
+		case "skill":
+			delete(m.skills, id)
+			delete(m.files, id)
+		case "context":
+			delete(m.context, id)
+		case "automation":
+			delete(m.automations, id)
+			delete(m.inbox, id)
+		case "webhook":
+			delete(m.webhooks, id)
+		case "channel":
+			delete(m.channels, id)
+		case "route_rule":
+			delete(m.routeRules, id)
+		case "llm_profile":
+			delete(m.profiles, id)
+		case "secret":
+			delete(m.secrets, id)
+		case "memory":
+			kept := m.entries[:0]
+			for _, entry := range m.entries {
+				if entry.ID != id {
+					kept = append(kept, entry)
+				}
+			}
+			m.entries = kept
+		}
+		delete(m.owners, key)
+	}
+	for key := range m.entryIndex {
+		if strings.HasPrefix(key, workspaceID+":") {
+			delete(m.entryIndex, key)
+		}
+	}
+	for id, key := range m.apiKeys {
+		if key.WorkspaceID == workspaceID {
+			delete(m.apiKeys, id)
+		}
+	}
+	for id, delivery := range m.deliveries {
+		if delivery.WorkspaceID == workspaceID {
+			delete(m.deliveries, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) ListUsers(ctx context.Context) ([]store.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]store.User, 0, len(m.users))
+	for _, user := range m.users {
+		results = append(results, user)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		left := parseTime(results[i].CreatedAt)
+		right := parseTime(results[j].CreatedAt)
+		if left.Equal(right) {
+			return results[i].Name < results[j].Name
+		}
+		return left.Before(right)
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetUser(ctx context.Context, userID string) (*store.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	user, ok := m.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (m *MemoryStore) CreateUser(ctx context.Context, user store.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if user.Email != "" {
+		for _, existing := range m.users {
+			if strings.EqualFold(existing.Email, user.Email) {
+				return fmt.Errorf("user with email %q already exists", user.Email)
+			}
+		}
+	}
+	m.users[user.ID] = user
+	return nil
+}
+
+func (m *MemoryStore) DeleteUser(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.users, userID)
+	for _, members := range m.members {
+		delete(members, userID)
+	}
+	for id, key := range m.apiKeys {
+		if key.UserID == userID {
+			delete(m.apiKeys, id)
+		}
+	}
+	for id, run := range m.runs {
+		if run.UserID == userID {
+			run.UserID = ""
+			m.runs[id] = run
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) ListWorkspaceMembers(ctx context.Context, workspaceID string) ([]store.WorkspaceMember, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	members := m.members[workspaceID]
+	results := make([]store.WorkspaceMember, 0, len(members))
+	for _, member := range members {
+		results = append(results, member)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		left := parseTime(results[i].CreatedAt)
+		right := parseTime(results[j].CreatedAt)
+		if left.Equal(right) {
+			return results[i].UserID < results[j].UserID
+		}
+		return left.Before(right)
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) UpsertWorkspaceMember(ctx context.Context, member store.WorkspaceMember) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.members[member.WorkspaceID] == nil {
+		m.members[member.WorkspaceID] = map[string]store.WorkspaceMember{}
+	}
+	if existing, ok := m.members[member.WorkspaceID][member.UserID]; ok {
+		member.CreatedAt = existing.CreatedAt
+	}
+	m.members[member.WorkspaceID][member.UserID] = member
+	return nil
+}
+
+func (m *MemoryStore) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.members[workspaceID], userID)
+	return nil
+}
+
+func cloneAPIKey(key store.APIKey) store.APIKey {
+	cloned := key
+	cloned.Scopes = append([]string{}, key.Scopes...)
+	return cloned
+}
+
+// RotateSecrets re-encrypts the stored API keys of the workspace in ctx.
+func (m *MemoryStore) RotateSecrets(ctx context.Context, rotate store.SecretRotateFunc) (store.SecretRotationReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := store.SecretRotationReport{}
+	apply := func(table string, id string, ciphertext string) (string, bool) {
+		if ciphertext == "" {
+			return ciphertext, false
+		}
+		rotated, changed, err := rotate(ciphertext)
+		if err != nil {
+			report.Failed = append(report.Failed, store.SecretRotationFailure{Table: table, ID: id, Error: err.Error()})
+			return ciphertext, false
+		}
+		if !changed {
+			report.Unchanged++
+			return ciphertext, false
+		}
+		report.Rotated++
+		return rotated, true
+	}
+	settingsIDs := make([]string, 0, len(m.settings))
+	for id := range m.settings {
+		if id != store.WorkspaceID(ctx) {
+			continue
+		}
+		settingsIDs = append(settingsIDs, id)
+	}
+	sort.Strings(settingsIDs)
+	settingsKeys := map[string]string{}
+	for _, id := range settingsIDs {
+		if rotated, changed := apply("llm_settings", id, m.settings[id].APIKeyEnc); changed {
+			settingsKeys[id] = rotated
+		}
+	}
+	profileIDs := make([]string, 0, len(m.profiles))
+	for id := range m.profiles {
+		if !m.ownedLocked(ctx, "llm_profile", id) {
+			continue
+		}
+		profileIDs = append(profileIDs, id)
+	}
+	sort.Strings(profileIDs)
+	profileKeys := map[string]string{}
+	for _, id := range profileIDs {
+		if rotated, changed := apply("llm_provider_profiles", id, m.profiles[id].APIKeyEnc); changed {
+			profileKeys[id] = rotated
+		}
+	}
+	secretIDs := make([]string, 0, len(m.secrets))
+	for id := range m.secrets {
+		if !m.ownedLocked(ctx, "secret", id) {
+			continue
+		}
+		secretIDs = append(secretIDs, id)
+	}
+	sort.Strings(secretIDs)
+	secretValues := map[string]string{}
+	for _, id := range secretIDs {
+		if rotated, changed := apply("secrets", id, m.secrets[id].ValueEnc); changed {
+			secretValues[id] = rotated
+		}
+	}
+	webhookIDs := make([]string, 0, len(m.webhooks))
+	for id := range m.webhooks {
+		if !m.ownedLocked(ctx, "webhook", id) {
+			continue
+		}
+		webhookIDs = append(webhookIDs, id)
+	}
+	sort.Strings(webhookIDs)
+	webhookSecrets := map[string]string{}
+	for _, id := range webhookIDs {
+		if rotated, changed := apply("webhook_subscriptions", id, m.webhooks[id].SecretEnc); changed {
+			webhookSecrets[id] = rotated
+		}
+	}
+	channelIDs := make([]string, 0, len(m.channels))
+	for id := range m.channels {
+		if !m.ownedLocked(ctx, "channel", id) {
+			continue
+		}
+		channelIDs = append(channelIDs, id)
+	}
+	sort.Strings(channelIDs)
+	channelSecrets := map[string]string{}
+	for _, id := range channelIDs {
+		if rotated, changed := apply("notification_channels", id, m.channels[id].SecretsEnc); changed {
+			channelSecrets[id] = rotated
+		}
+	}
+	automationIDs := make([]string, 0, len(m.automations))
+	for id := range m.automations {
+		if !m.ownedLocked(ctx, "automation", id) {
+			continue
+		}
+		automationIDs = append(automationIDs, id)
+	}
+	sort.Strings(automationIDs)
+	triggerSecrets := map[string]string{}
+	for _, id := range automationIDs {
+		if rotated, changed := apply("automations", id, m.automations[id].TriggerSecretEnc); changed {
+			triggerSecrets[id] = rotated
+		}
+	}
+	for id, rotated := range triggerSecrets {
+		automation := m.automations[id]
+		automation.TriggerSecretEnc = rotated
+		m.automations[id] = automation
+	}
+	for id, rotated := range channelSecrets {
+		channel := m.channels[id]
+		channel.SecretsEnc = rotated
+		m.channels[id] = channel
+	}
+	for id, rotated := range webhookSecrets {
+		webhook := m.webhooks[id]
+		webhook.SecretEnc = rotated
+		m.webhooks[id] = webhook
+	}
+	for id, rotated := range settingsKeys {
+		settings := m.settings[id]
+		settings.APIKeyEnc = rotated
+		m.settings[id] = settings
+	}
+	for id, rotated := range secretValues {
+		secret := m.secrets[id]
+		secret.ValueEnc = rotated
+		m.secrets[id] = secret
+	}
+	for id, rotated := range profileKeys {
+		profile := m.profiles[id]
+		profile.APIKeyEnc = rotated
+		m.profiles[id] = profile
+	}
+	return report, nil
+}
+
 func (m *MemoryStore) ListAutomations(ctx context.Context) ([]store.Automation, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	results := make([]store.Automation, 0, len(m.automations))
 	for _, automation := range m.automations {
-		cloned := automation
-		cloned.Days = append([]string{}, automation.Days...)
-		results = append(results, cloned)
+		if !m.ownedLocked(ctx, "automation", automation.ID) {
+			continue
+		}
+		results = append(results, cloneAutomation(automation))
 	}
 	sort.Slice(results, func(i, j int) bool {
 		return parseTime(results[i].UpdatedAt).After(parseTime(results[j].UpdatedAt))
@@ -95,20 +861,18 @@ func (m *MemoryStore) GetAutomation(ctx context.Context, automationID string) (*
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	automation, ok := m.automations[automationID]
-	if !ok {
+	if !ok || !m.ownedLocked(ctx, "automation", automationID) {
 		return nil, nil
-	}
-	cloned := automation
-	cloned.Days = append([]string{}, automation.Days...)
+	}
+	cloned := cloneAutomation(automation)
 	return &cloned, nil
 }
 
 func (m *MemoryStore) CreateAutomation(ctx context.Context, automation store.Automation) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	cloned := automation
-	cloned.Days = append([]string{}, automation.Days...)
-	m.automations[automation.ID] = cloned
+	m.automations[automation.ID] = cloneAutomation(automation)
+	m.setOwnerLocked(ctx, "automation", automation.ID)
 	if _, ok := m.inbox[automation.ID]; !ok {
 		m.inbox[automation.ID] = []store.AutomationInboxEntry{}
 	}
@@ -118,20 +882,43 @@ func (m *MemoryStore) CreateAutomation(ctx context.Context, automation store.Aut
 func (m *MemoryStore) UpdateAutomation(ctx context.Context, automation store.Automation) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if _, ok := m.automations[automation.ID]; !ok {
+	if _, ok := m.automations[automation.ID]; !ok || !m.ownedLocked(ctx, "automation", automation.ID) {
 		return nil
 	}
+	m.automations[automation.ID] = cloneAutomation(automation)
+	return nil
+}
+
+func cloneAutomation(automation store.Automation) store.Automation {
 	cloned := automation
 	cloned.Days = append([]string{}, automation.Days...)
-	m.automations[automation.ID] = cloned
-	return nil
+	cloned.NotificationChannels = append([]string{}, automation.NotificationChannels...)
+	cloned.DigestAutomationIDs = append([]string{}, automation.DigestAutomationIDs...)
+	cloned.BlackoutDates = append([]string{}, automation.BlackoutDates...)
+	cloned.Tags = append([]string{}, automation.Tags...)
+	cloned.BrowserDomainAllowlist = append([]string{}, automation.BrowserDomainAllowlist...)
+	cloned.Skills = append([]string{}, automation.Skills...)
+	cloned.ContextTriggerPaths = append([]string{}, automation.ContextTriggerPaths...)
+	cloned.RunTriggerTags = append([]string{}, automation.RunTriggerTags...)
+	cloned.FeedURLs = append([]string{}, automation.FeedURLs...)
+	cloned.FeedSeenItems = append([]string{}, automation.FeedSeenItems...)
+	cloned.Stages = nil
+	for _, stage := range automation.Stages {
+		stage.ConditionKeywords = append([]string{}, stage.ConditionKeywords...)
+		cloned.Stages = append(cloned.Stages, stage)
+	}
+	return cloned
 }
 
 func (m *MemoryStore) DeleteAutomation(ctx context.Context, automationID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "automation", automationID) {
+		return nil
+	}
 	delete(m.automations, automationID)
 	delete(m.inbox, automationID)
+	delete(m.owners, "automation:"+automationID)
 	return nil
 }
 
@@ -139,14 +926,12 @@ func (m *MemoryStore) ListAutomationInbox(ctx context.Context, automationID stri
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	entries := m.inbox[automationID]
-	if entries == nil {
+	if entries == nil || !m.ownedLocked(ctx, "automation", automationID) {
 		return []store.AutomationInboxEntry{}, nil
 	}
 	cloned := make([]store.AutomationInboxEntry, 0, len(entries))
 	for _, entry := range entries {
-		copy := entry
-		copy.Diagnostics = cloneMap(entry.Diagnostics)
-		cloned = append(cloned, copy)
+		cloned = append(cloned, cloneInboxEntry(entry))
 	}
 	sort.Slice(cloned, func(i, j int) bool {
 		return parseTime(cloned[i].StartedAt).After(parseTime(cloned[j].StartedAt))
@@ -157,32 +942,91 @@ func (m *MemoryStore) ListAutomationInbox(ctx context.Context, automationID stri
 func (m *MemoryStore) CreateAutomationInboxEntry(ctx context.Context, entry store.AutomationInboxEntry) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	copy := entry
-	copy.Diagnostics = cloneMap(entry.Diagnostics)
-	m.inbox[entry.AutomationID] = append([]store.AutomationInboxEntry{copy}, m.inbox[entry.AutomationID]...)
+	if !m.ownedLocked(ctx, "automation", entry.AutomationID) {
+		return store.ErrNotInWorkspace
+	}
+	m.inbox[entry.AutomationID] = append([]store.AutomationInboxEntry{cloneInboxEntry(entry)}, m.inbox[entry.AutomationID]...)
 	return nil
 }
 
 func (m *MemoryStore) UpdateAutomationInboxEntry(ctx context.Context, entry store.AutomationInboxEntry) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "automation", entry.AutomationID) {
+		return nil
+	}
 	entries := m.inbox[entry.AutomationID]
 	for idx := range entries {
 		if entries[idx].ID != entry.ID {
 			continue
 		}
-		copy := entry
-		copy.Diagnostics = cloneMap(entry.Diagnostics)
-		entries[idx] = copy
+		entry.LeaseOwner = entries[idx].LeaseOwner
+		entry.LeaseUntil = entries[idx].LeaseUntil
+		entries[idx] = cloneInboxEntry(entry)
 		m.inbox[entry.AutomationID] = entries
 		return nil
 	}
 	return nil
 }
 
+func (m *MemoryStore) ClaimAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string, now string, leaseUntil string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "automation", automationID) {
+		return false, nil
+	}
+	entries := m.inbox[automationID]
+	for idx := range entries {
+		entry := &entries[idx]
+		if entry.ID != entryID {
+			continue
+		}
+		if entry.Status != "queued" && entry.Status != "running" {
+			return false, nil
+		}
+		if entry.LeaseOwner != "" && entry.LeaseOwner != owner && parseTime(entry.LeaseUntil).After(parseTime(now)) {
+			return false, nil
+		}
+		entry.LeaseOwner = owner
+		entry.LeaseUntil = leaseUntil
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *MemoryStore) ReleaseAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "automation", automationID) {
+		return nil
+	}
+	entries := m.inbox[automationID]
+	for idx := range entries {
+		if entries[idx].ID == entryID && entries[idx].LeaseOwner == owner {
+			entries[idx].LeaseOwner = ""
+			entries[idx].LeaseUntil = ""
+		}
+	}
+	return nil
+}
+
+func cloneInboxEntry(entry store.AutomationInboxEntry) store.AutomationInboxEntry {
+	cloned := entry
+	cloned.Diagnostics = cloneMap(entry.Diagnostics)
+	cloned.TriggerPayload = cloneMap(entry.TriggerPayload)
+	if entry.Similarity != nil {
+		similarity := *entry.Similarity
+		cloned.Similarity = &similarity
+	}
+	return cloned
+}
+
 func (m *MemoryStore) MarkAutomationInboxEntryRead(ctx context.Context, automationID string, entryID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "automation", automationID) {
+		return nil
+	}
 	entries := m.inbox[automationID]
 	for idx := range entries {
 		if entries[idx].ID == entryID {
@@ -193,9 +1037,76 @@ func (m *MemoryStore) MarkAutomationInboxEntryRead(ctx context.Context, automati
 	return nil
 }
 
+func (m *MemoryStore) QueryAutomationInbox(ctx context.Context, query store.AutomationInboxQuery) ([]store.AutomationInboxEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	bounds := make([]time.Time, 3)
+	for idx, value := range []string{query.Since, query.Until, query.BeforeStartedAt} {
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time bound %q: %w", value, err)
+		}
+		bounds[idx] = parsed
+	}
+	since, until, before := bounds[0], bounds[1], bounds[2]
+	results := []store.AutomationInboxEntry{}
+	for automationID, entries := range m.inbox {
+		if (query.AutomationID != "" && automationID != query.AutomationID) || !m.ownedLocked(ctx, "automation", automationID) {
+			continue
+		}
+		for _, entry := range entries {
+			startedAt := parseTime(entry.StartedAt)
+			switch {
+			case query.UnreadOnly && !entry.Unread,
+				query.Archived != (entry.ArchivedAt != ""),
+				len(query.Statuses) > 0 && !slices.Contains(query.Statuses, entry.Status),
+				len(query.Triggers) > 0 && !slices.Contains(query.Triggers, entry.Trigger),
+				!since.IsZero() && startedAt.Before(since),
+				!until.IsZero() && !startedAt.Before(until),
+				!before.IsZero() && (startedAt.After(before) || (startedAt.Equal(before) && entry.ID >= query.BeforeID)):
+				continue
+			}
+			results = append(results, cloneInboxEntry(entry))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		left, right := parseTime(results[i].StartedAt), parseTime(results[j].StartedAt)
+		if !left.Equal(right) {
+			return left.After(right)
+		}
+		return results[i].ID > results[j].ID
+	})
+	if query.Limit > 0 && len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+func (m *MemoryStore) DeleteAutomationInboxEntries(ctx context.Context, automationID string, entryIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "automation", automationID) {
+		return nil
+	}
+	kept := make([]store.AutomationInboxEntry, 0, len(m.inbox[automationID]))
+	for _, entry := range m.inbox[automationID] {
+		if !slices.Contains(entryIDs, entry.ID) {
+			kept = append(kept, entry)
+		}
+	}
+	m.inbox[automationID] = kept
+	return nil
+}
+
 func (m *MemoryStore) MarkAutomationInboxReadAll(ctx context.Context, automationID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "automation", automationID) {
+		return nil
+	}
 	entries := m.inbox[automationID]
 	for idx := range entries {
 		entries[idx].Unread = false
@@ -204,11 +1115,298 @@ func (m *MemoryStore) MarkAutomationInboxReadAll(ctx context.Context, automation
 	return nil
 }
 
+func (m *MemoryStore) AppendAuditEntry(ctx context.Context, entry store.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.Seq = int64(len(m.audit)) + 1
+	entry.WorkspaceID = store.WorkspaceID(ctx)
+	m.audit = append(m.audit, cloneAuditEntry(entry))
+	return nil
+}
+
+func (m *MemoryStore) ListAuditEntries(ctx context.Context, query store.AuditQuery) ([]store.AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	since, until, err := auditBounds(query)
+	if err != nil {
+		return nil, err
+	}
+	workspaceID := store.WorkspaceID(ctx)
+	results := []store.AuditEntry{}
+	for idx := len(m.audit) - 1; idx >= 0; idx-- {
+		entry := m.audit[idx]
+		if query.Limit > 0 && len(results) >= query.Limit {
+			break
+		}
+		if entry.WorkspaceID != workspaceID || (query.BeforeSeq > 0 && entry.Seq >= query.BeforeSeq) {
+			continue
+		}
+		if !matchesAuditQuery(entry, query) {
+			continue
+		}
+		if !since.IsZero() || !until.IsZero() {
+			createdAt, err := time.Parse(time.RFC3339Nano, entry.CreatedAt)
+			if err != nil || (!since.IsZero() && createdAt.Before(since)) || (!until.IsZero() && !createdAt.Before(until)) {
+				continue
+			}
+		}
+		results = append(results, cloneAuditEntry(entry))
+	}
+	return results, nil
+}
+
+func auditBounds(query store.AuditQuery) (time.Time, time.Time, error) {
+	var since, until time.Time
+	var err error
+	if query.Since != "" {
+		if since, err = time.Parse(time.RFC3339Nano, query.Since); err != nil {
+			return since, until, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if query.Until != "" {
+		if until, err = time.Parse(time.RFC3339Nano, query.Until); err != nil {
+			return since, until, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+	return since, until, nil
+}
+
+func matchesAuditQuery(entry store.AuditEntry, query store.AuditQuery) bool {
+	return (query.Actor == "" || entry.Actor == query.Actor) &&
+		(query.UserID == "" || entry.UserID == query.UserID) &&
+		(query.Action == "" || entry.Action == query.Action) &&
+		(query.TargetType == "" || entry.TargetType == query.TargetType) &&
+		(query.TargetID == "" || entry.TargetID == query.TargetID)
+}
+
+func cloneAuditEntry(entry store.AuditEntry) store.AuditEntry {
+	cloned := entry
+	cloned.Before = cloneMap(entry.Before)
+	cloned.After = cloneMap(entry.After)
+	return cloned
+}
+
+func (m *MemoryStore) ListWebhookSubscriptions(ctx context.Context) ([]store.WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]store.WebhookSubscription, 0, len(m.webhooks))
+	for _, subscription := range m.webhooks {
+		if m.ownedLocked(ctx, "webhook", subscription.ID) {
+			results = append(results, cloneWebhookSubscription(subscription))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return parseTime(results[i].CreatedAt).Before(parseTime(results[j].CreatedAt))
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetWebhookSubscription(ctx context.Context, subscriptionID string) (*store.WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	subscription, ok := m.webhooks[subscriptionID]
+	if !ok || !m.ownedLocked(ctx, "webhook", subscriptionID) {
+		return nil, nil
+	}
+	cloned := cloneWebhookSubscription(subscription)
+	return &cloned, nil
+}
+
+func (m *MemoryStore) CreateWebhookSubscription(ctx context.Context, subscription store.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.webhooks[subscription.ID] = cloneWebhookSubscription(subscription)
+	m.setOwnerLocked(ctx, "webhook", subscription.ID)
+	return nil
+}
+
+func (m *MemoryStore) UpdateWebhookSubscription(ctx context.Context, subscription store.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.webhooks[subscription.ID]; !ok || !m.ownedLocked(ctx, "webhook", subscription.ID) {
+		return nil
+	}
+	m.webhooks[subscription.ID] = cloneWebhookSubscription(subscription)
+	return nil
+}
+
+func (m *MemoryStore) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "webhook", subscriptionID) {
+		return nil
+	}
+	delete(m.webhooks, subscriptionID)
+	delete(m.owners, "webhook:"+subscriptionID)
+	for id, delivery := range m.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			delete(m.deliveries, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) CreateWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.webhooks[delivery.SubscriptionID]; !ok || !m.ownedLocked(ctx, "webhook", delivery.SubscriptionID) {
+		return store.ErrNotInWorkspace
+	}
+	delivery.WorkspaceID = store.WorkspaceID(ctx)
+	m.deliveries[delivery.ID] = cloneWebhookDelivery(delivery)
+	return nil
+}
+
+func (m *MemoryStore) GetWebhookDelivery(ctx context.Context, deliveryID string) (*store.WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	delivery, ok := m.deliveries[deliveryID]
+	if !ok || delivery.WorkspaceID != store.WorkspaceID(ctx) {
+		return nil, nil
+	}
+	cloned := cloneWebhookDelivery(delivery)
+	return &cloned, nil
+}
+
+func (m *MemoryStore) UpdateWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.deliveries[delivery.ID]
+	if !ok || current.WorkspaceID != store.WorkspaceID(ctx) {
+		return nil
+	}
+	delivery.WorkspaceID = current.WorkspaceID
+	m.deliveries[delivery.ID] = cloneWebhookDelivery(delivery)
+	return nil
+}
+
+func (m *MemoryStore) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]store.WebhookDelivery, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := []store.WebhookDelivery{}
+	for _, delivery := range m.deliveries {
+		if delivery.SubscriptionID == subscriptionID && delivery.WorkspaceID == store.WorkspaceID(ctx) {
+			results = append(results, cloneWebhookDelivery(delivery))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return parseTime(results[i].CreatedAt).After(parseTime(results[j].CreatedAt))
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (m *MemoryStore) ClaimWebhookDeliveries(ctx context.Context, now string, leaseUntil string, limit int) ([]store.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	due := parseTime(now)
+	results := []store.WebhookDelivery{}
+	for _, delivery := range m.deliveries {
+		if delivery.Status == "pending" && !parseTime(delivery.NextAttemptAt).After(due) {
+			results = append(results, delivery)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return parseTime(results[i].NextAttemptAt).Before(parseTime(results[j].NextAttemptAt))
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	for idx := range results {
+		results[idx].NextAttemptAt = leaseUntil
+		m.deliveries[results[idx].ID] = results[idx]
+		results[idx] = cloneWebhookDelivery(results[idx])
+	}
+	return results, nil
+}
+
+func cloneWebhookSubscription(subscription store.WebhookSubscription) store.WebhookSubscription {
+	cloned := subscription
+	cloned.EventTypes = append([]string{}, subscription.EventTypes...)
+	cloned.Tags = append([]string{}, subscription.Tags...)
+	return cloned
+}
+
+func cloneWebhookDelivery(delivery store.WebhookDelivery) store.WebhookDelivery {
+	cloned := delivery
+	cloned.Payload = cloneMap(delivery.Payload)
+	return cloned
+}
+
+func (m *MemoryStore) ListNotificationChannels(ctx context.Context) ([]store.NotificationChannel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]store.NotificationChannel, 0, len(m.channels))
+	for _, channel := range m.channels {
+		if m.ownedLocked(ctx, "channel", channel.ID) {
+			results = append(results, cloneNotificationChannel(channel))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return parseTime(results[i].CreatedAt).Before(parseTime(results[j].CreatedAt))
+	})
+	return results, nil
+}
+
+func (m *MemoryStore) GetNotificationChannel(ctx context.Context, channelID string) (*store.NotificationChannel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	channel, ok := m.channels[channelID]
+	if !ok || !m.ownedLocked(ctx, "channel", channelID) {
+		return nil, nil
+	}
+	cloned := cloneNotificationChannel(channel)
+	return &cloned, nil
+}
+
+func (m *MemoryStore) CreateNotificationChannel(ctx context.Context, channel store.NotificationChannel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.channels[channel.ID] = cloneNotificationChannel(channel)
+	m.setOwnerLocked(ctx, "channel", channel.ID)
+	return nil
+}
+
+func (m *MemoryStore) UpdateNotificationChannel(ctx context.Context, channel store.NotificationChannel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.channels[channel.ID]; !ok || !m.ownedLocked(ctx, "channel", channel.ID) {
+		return nil
+	}
+	m.channels[channel.ID] = cloneNotificationChannel(channel)
+	return nil
+}
+
+func (m *MemoryStore) DeleteNotificationChannel(ctx context.Context, channelID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "channel", channelID) {
+		return nil
+	}
+	delete(m.channels, channelID)
+	delete(m.owners, "channel:"+channelID)
+	return nil
+}
+
+func cloneNotificationChannel(channel store.NotificationChannel) store.NotificationChannel {
+	cloned := channel
+	cloned.Config = map[string]string{}
+	for key, value := range channel.Config {
+		cloned.Config[key] = value
+	}
+	return cloned
+}
+
 func (m *MemoryStore) ListRuns(ctx context.Context) ([]store.RunSummary, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	results := make([]store.RunSummary, 0, len(m.runs))
 	for _, run := range m.runs {
+		if runWorkspace(run) != store.WorkspaceID(ctx) {
+			continue
+		}
 		summary := store.RunSummary{
 			ID:               run.ID,
 			Status:           run.Status,
@@ -219,6 +1417,8 @@ func (m *MemoryStore) ListRuns(ctx context.Context) ([]store.RunSummary, error)
 			PolicyProfile:    run.PolicyProfile,
 			ModelRoute:       run.ModelRoute,
 			Tags:             append([]string{}, run.Tags...),
+			UserID:           run.UserID,
+			CreatedBy:        run.CreatedBy,
 			CreatedAt:        run.CreatedAt,
 			UpdatedAt:        run.UpdatedAt,
 		}
@@ -266,6 +1466,9 @@ func (m *MemoryStore) ListRuns(ctx context.Context) ([]store.RunSummary, error)
 func (m *MemoryStore) AddMessage(ctx context.Context, msg store.Message) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.foreignRunLocked(ctx, msg.RunID) {
+		return store.ErrNotInWorkspace
+	}
 	m.messages[msg.RunID] = append(m.messages[msg.RunID], msg)
 	return nil
 }
@@ -273,6 +1476,9 @@ func (m *MemoryStore) AddMessage(ctx context.Context, msg store.Message) error {
 func (m *MemoryStore) ListMessages(ctx context.Context, runID string) ([]store.Message, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	if m.foreignRunLocked(ctx, runID) {
+		return []store.Message{}, nil
+	}
 	messages := m.messages[runID]
 	return append([]store.Message{}, messages...), nil
 }
@@ -280,18 +1486,20 @@ func (m *MemoryStore) ListMessages(ctx context.Context, runID string) ([]store.M
 func (m *MemoryStore) GetLLMSettings(ctx context.Context) (*store.LLMSettings, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	if m.settings == nil {
+	settings, ok := m.settings[store.WorkspaceID(ctx)]
+	if !ok {
+		settings, ok = m.settings[store.DefaultWorkspaceID]
+	}
+	if !ok {
 		return nil, nil
 	}
-	copy := *m.settings
-	return &copy, nil
+	return &settings, nil
 }
 
 func (m *MemoryStore) UpsertLLMSettings(ctx context.Context, settings store.LLMSettings) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	copy := settings
-	m.settings = &copy
+	m.settings[store.WorkspaceID(ctx)] = settings
 	return nil
 }
 
@@ -300,7 +1508,9 @@ func (m *MemoryStore) ListSkills(ctx context.Context) ([]store.Skill, error) {
 	defer m.mu.RUnlock()
 	results := make([]store.Skill, 0, len(m.skills))
 	for _, skill := range m.skills {
-		results = append(results, skill)
+		if m.ownedLocked(ctx, "skill", skill.ID) {
+			results = append(results, skill)
+		}
 	}
 	return results, nil
 }
@@ -309,7 +1519,7 @@ func (m *MemoryStore) GetSkill(ctx context.Context, skillID string) (*store.Skil
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	skill, ok := m.skills[skillID]
-	if !ok {
+	if !ok || !m.ownedLocked(ctx, "skill", skillID) {
 		return nil, nil
 	}
 	copy := skill
@@ -320,12 +1530,16 @@ func (m *MemoryStore) CreateSkill(ctx context.Context, skill store.Skill) error
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.skills[skill.ID] = skill
+	m.setOwnerLocked(ctx, "skill", skill.ID)
 	return nil
 }
 
 func (m *MemoryStore) UpdateSkill(ctx context.Context, skill store.Skill) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if _, ok := m.skills[skill.ID]; !ok || !m.ownedLocked(ctx, "skill", skill.ID) {
+		return nil
+	}
 	m.skills[skill.ID] = skill
 	return nil
 }
@@ -333,8 +1547,12 @@ func (m *MemoryStore) UpdateSkill(ctx context.Context, skill store.Skill) error
 func (m *MemoryStore) DeleteSkill(ctx context.Context, skillID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "skill", skillID) {
+		return nil
+	}
 	delete(m.skills, skillID)
 	delete(m.files, skillID)
+	delete(m.owners, "skill:"+skillID)
 	return nil
 }
 
@@ -342,7 +1560,7 @@ func (m *MemoryStore) ListSkillFiles(ctx context.Context, skillID string) ([]sto
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	files := m.files[skillID]
-	if files == nil {
+	if files == nil || !m.ownedLocked(ctx, "skill", skillID) {
 		return []store.SkillFile{}, nil
 	}
 	results := make([]store.SkillFile, 0, len(files))
@@ -355,6 +1573,9 @@ func (m *MemoryStore) ListSkillFiles(ctx context.Context, skillID string) ([]sto
 func (m *MemoryStore) UpsertSkillFile(ctx context.Context, file store.SkillFile) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "skill", file.SkillID) {
+		return store.ErrNotInWorkspace
+	}
 	if m.files[file.SkillID] == nil {
 		m.files[file.SkillID] = map[string]store.SkillFile{}
 	}
@@ -366,7 +1587,7 @@ func (m *MemoryStore) DeleteSkillFile(ctx context.Context, skillID string, path
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	files := m.files[skillID]
-	if files == nil {
+	if files == nil || !m.ownedLocked(ctx, "skill", skillID) {
 		return nil
 	}
 	delete(files, path)
@@ -378,7 +1599,9 @@ func (m *MemoryStore) ListContextNodes(ctx context.Context) ([]store.ContextNode
 	defer m.mu.RUnlock()
 	results := make([]store.ContextNode, 0, len(m.context))
 	for _, node := range m.context {
-		results = append(results, node)
+		if m.ownedLocked(ctx, "context", node.ID) {
+			results = append(results, node)
+		}
 	}
 	return results, nil
 }
@@ -387,7 +1610,7 @@ func (m *MemoryStore) GetContextFile(ctx context.Context, nodeID string) (*store
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	node, ok := m.context[nodeID]
-	if !ok {
+	if !ok || !m.ownedLocked(ctx, "context", nodeID) {
 		return nil, nil
 	}
 	copy := node
@@ -397,7 +1620,11 @@ func (m *MemoryStore) GetContextFile(ctx context.Context, nodeID string) (*store
 func (m *MemoryStore) CreateContextFolder(ctx context.Context, node store.ContextNode) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if node.ParentID != "" && !m.ownedLocked(ctx, "context", node.ParentID) {
+		return store.ErrNotInWorkspace
+	}
 	m.context[node.ID] = node
+	m.setOwnerLocked(ctx, "context", node.ID)
 	return nil
 }
 
@@ -408,6 +1635,9 @@ func (m *MemoryStore) CreateContextFile(ctx context.Context, node store.ContextN
 func (m *MemoryStore) DeleteContextNode(ctx context.Context, nodeID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if !m.ownedLocked(ctx, "context", nodeID) {
+		return nil
+	}
 	m.deleteContextSubtree(nodeID)
 	return nil
 }
@@ -415,18 +1645,20 @@ func (m *MemoryStore) DeleteContextNode(ctx context.Context, nodeID string) erro
 func (m *MemoryStore) GetMemorySettings(ctx context.Context) (*store.MemorySettings, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	if m.memory == nil {
+	settings, ok := m.memory[store.WorkspaceID(ctx)]
+	if !ok {
+		settings, ok = m.memory[store.DefaultWorkspaceID]
+	}
+	if !ok {
 		return nil, nil
 	}
-	copy := *m.memory
-	return &copy, nil
+	return &settings, nil
 }
 
 func (m *MemoryStore) UpsertMemorySettings(ctx context.Context, settings store.MemorySettings) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	copy := settings
-	m.memory = &copy
+	m.memory[store.WorkspaceID(ctx)] = settings
 	return nil
 }
 
@@ -442,30 +1674,34 @@ func (m *MemoryStore) UpsertMemoryEntry(ctx context.Context, entry store.MemoryE
 		}
 	}
 	if fingerprint != "" {
-		if _, exists := m.entryIndex[fingerprint]; exists {
+		key := store.WorkspaceID(ctx) + ":" + fingerprint
+		if _, exists := m.entryIndex[key]; exists {
 			return false, nil
 		}
-		m.entryIndex[fingerprint] = entry
+		m.entryIndex[key] = entry
 	}
 	m.entries = append(m.entries, entry)
+	m.setOwnerLocked(ctx, "memory", entry.ID)
 	return true, nil
 }
 
 func (m *MemoryStore) GetPersonalitySettings(ctx context.Context) (*store.PersonalitySettings, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	if m.personality == nil {
+	settings, ok := m.personality[store.WorkspaceID(ctx)]
+	if !ok {
+		settings, ok = m.personality[store.DefaultWorkspaceID]
+	}
+	if !ok {
 		return nil, nil
 	}
-	copy := *m.personality
-	return &copy, nil
+	return &settings, nil
 }
 
 func (m *MemoryStore) UpsertPersonalitySettings(ctx context.Context, settings store.PersonalitySettings) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	copy := settings
-	m.personality = &copy
+	m.personality[store.WorkspaceID(ctx)] = settings
 	return nil
 }
 
@@ -480,6 +1716,9 @@ func (m *MemoryStore) SearchMemory(ctx context.Context, query string, limit int)
 		if len(results) >= limit {
 			break
 		}
+		if !m.ownedLocked(ctx, "memory", entry.ID) {
+			continue
+		}
 		if strings.Contains(strings.ToLower(entry.Content), strings.ToLower(query)) {
 			results = append(results, entry)
 		}
@@ -498,11 +1737,15 @@ func (m *MemoryStore) deleteContextSubtree(nodeID string) {
 		}
 	}
 	delete(m.context, nodeID)
+	delete(m.owners, "context:"+nodeID)
 }
 
 func (m *MemoryStore) AppendEvent(ctx context.Context, event store.RunEvent) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.foreignRunLocked(ctx, event.RunID) {
+		return store.ErrNotInWorkspace
+	}
 	event.Type = normalizeEventType(event.Type)
 	m.events[event.RunID] = append(m.events[event.RunID], event)
 	m.applyRunStepLocked(event)
@@ -521,6 +1764,9 @@ func normalizeEventType(eventType string) string {
 func (m *MemoryStore) ListEvents(ctx context.Context, runID string, afterSeq int64) ([]store.RunEvent, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	if m.foreignRunLocked(ctx, runID) {
+		return []store.RunEvent{}, nil
+	}
 	events := m.events[runID]
 	if afterSeq <= 0 {
 		return append([]store.RunEvent{}, events...), nil
@@ -537,6 +1783,9 @@ func (m *MemoryStore) ListEvents(ctx context.Context, runID string, afterSeq int
 func (m *MemoryStore) NextSeq(ctx context.Context, runID string) (int64, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.foreignRunLocked(ctx, runID) {
+		return 0, store.ErrNotInWorkspace
+	}
 	m.seq[runID] += 1
 	return m.seq[runID], nil
 }
@@ -546,7 +1795,7 @@ func (m *MemoryStore) ListRunSteps(ctx context.Context, runID string) ([]store.R
 	defer m.mu.RUnlock()
 
 	stepsByID := m.runSteps[runID]
-	if len(stepsByID) == 0 {
+	if len(stepsByID) == 0 || m.foreignRunLocked(ctx, runID) {
 		return []store.RunStep{}, nil
 	}
 
@@ -576,6 +1825,9 @@ func (m *MemoryStore) UpsertRunProcess(ctx context.Context, process store.RunPro
 	if process.ProcessID == "" {
 		return fmt.Errorf("process id required")
 	}
+	if m.foreignRunLocked(ctx, process.RunID) {
+		return store.ErrNotInWorkspace
+	}
 	if m.processes[process.RunID] == nil {
 		m.processes[process.RunID] = map[string]store.RunProcess{}
 	}
@@ -632,7 +1884,7 @@ func (m *MemoryStore) GetRunProcess(ctx context.Context, runID string, processID
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	byRun := m.processes[runID]
-	if byRun == nil {
+	if byRun == nil || m.foreignRunLocked(ctx, runID) {
 		return nil, nil
 	}
 	process, ok := byRun[processID]
@@ -647,7 +1899,7 @@ func (m *MemoryStore) ListRunProcesses(ctx context.Context, runID string) ([]sto
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	byRun := m.processes[runID]
-	if byRun == nil {
+	if byRun == nil || m.foreignRunLocked(ctx, runID) {
 		return []store.RunProcess{}, nil
 	}
 	processes := make([]store.RunProcess, 0, len(byRun))
@@ -671,6 +1923,9 @@ func (m *MemoryStore) UpsertArtifact(ctx context.Context, artifact store.Artifac
 	if artifact.ID == "" {
 		return fmt.Errorf("artifact id required")
 	}
+	if m.foreignRunLocked(ctx, artifact.RunID) {
+		return store.ErrNotInWorkspace
+	}
 	if m.artifacts[artifact.RunID] == nil {
 		m.artifacts[artifact.RunID] = map[string]store.Artifact{}
 	}
@@ -682,7 +1937,7 @@ func (m *MemoryStore) ListArtifacts(ctx context.Context, runID string) ([]store.
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	artifacts := m.artifacts[runID]
-	if artifacts == nil {
+	if artifacts == nil || m.foreignRunLocked(ctx, runID) {
 		return []store.Artifact{}, nil
 	}
 	results := make([]store.Artifact, 0, len(artifacts))
@@ -796,6 +2051,8 @@ func (m *MemoryStore) applyRunStateLocked(event store.RunEvent) {
 		if resumedFrom := readString(event.Payload, "resumed_from"); resumedFrom != "" {
 			run.ResumedFrom = resumedFrom
 		}
+	case store.RunTagsUpdatedEvent:
+		run.Tags = store.MergeRunTags(run.Tags, store.RunTagsFromEvent(event))
 	}
 	if event.Seq > run.CheckpointSeq {
 		run.CheckpointSeq = event.Seq
@@ -2,7 +2,9 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 
@@ -90,6 +92,20 @@ func TestListRuns_UsesGeneratedTitleEvent(t *testing.T) {
 	require.Equal(t, "AI Generated Title", runs[0].Title)
 }
 
+func TestAppendEvent_MergesRunTags(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+	runID := "run-1"
+
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: runID, Status: "running", Tags: []string{"news"}, CreatedAt: "2026-01-01T00:00:00Z", UpdatedAt: "2026-01-01T00:00:00Z"}))
+	require.NoError(t, mem.AppendEvent(ctx, store.RunEvent{RunID: runID, Seq: 1, Type: store.RunTagsUpdatedEvent, Payload: map[string]any{"tags": []any{"News", "ai-policy"}}}))
+
+	runs, err := mem.ListRuns(ctx)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, []string{"news", "ai-policy"}, runs[0].Tags)
+}
+
 func TestListMessages(t *testing.T) {
 	ctx := context.Background()
 	mem := New()
@@ -693,3 +709,650 @@ func TestConcurrentNextSeq(t *testing.T) {
 		}
 	}
 }
+
+func TestLLMRouteRules(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+
+	require.NoError(t, mem.CreateLLMRouteRule(ctx, store.LLMRouteRule{ID: "b", Name: "beta", Priority: 20, TaskTypes: []string{"code"}, Route: "codex", Enabled: true}))
+	require.NoError(t, mem.CreateLLMRouteRule(ctx, store.LLMRouteRule{ID: "a", Name: "alpha", Priority: 10, Tags: []string{"news"}, Route: "openrouter:fast", Enabled: true}))
+
+	rules, err := mem.ListLLMRouteRules(ctx)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Equal(t, "alpha", rules[0].Name)
+	rules[0].Tags[0] = "mutated"
+
+	fetched, err := mem.GetLLMRouteRule(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"news"}, fetched.Tags)
+
+	fetched.Enabled = false
+	require.NoError(t, mem.UpdateLLMRouteRule(ctx, *fetched))
+	fetched, err = mem.GetLLMRouteRule(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, fetched.Enabled)
+
+	require.NoError(t, mem.DeleteLLMRouteRule(ctx, "a"))
+	fetched, err = mem.GetLLMRouteRule(ctx, "a")
+	require.NoError(t, err)
+	require.Nil(t, fetched)
+}
+
+func TestLLMProviderProfiles(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+
+	require.NoError(t, mem.CreateLLMProviderProfile(ctx, store.LLMProviderProfile{ID: "b", Name: "personal-openai", Provider: "openai"}))
+	require.NoError(t, mem.CreateLLMProviderProfile(ctx, store.LLMProviderProfile{ID: "a", Name: "work-openrouter", Provider: "openrouter", APIKeyEnc: "enc"}))
+
+	profiles, err := mem.ListLLMProviderProfiles(ctx)
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	require.Equal(t, "personal-openai", profiles[0].Name)
+
+	fetched, err := mem.GetLLMProviderProfile(ctx, "a")
+	require.NoError(t, err)
+	fetched.Model = "openai/gpt-4o"
+	require.NoError(t, mem.UpdateLLMProviderProfile(ctx, *fetched))
+	fetched, err = mem.GetLLMProviderProfile(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "openai/gpt-4o", fetched.Model)
+	require.Equal(t, "enc", fetched.APIKeyEnc)
+
+	require.NoError(t, mem.DeleteLLMProviderProfile(ctx, "a"))
+	fetched, err = mem.GetLLMProviderProfile(ctx, "a")
+	require.NoError(t, err)
+	require.Nil(t, fetched)
+}
+
+func TestRotateSecrets(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+	require.NoError(t, mem.UpsertLLMSettings(ctx, store.LLMSettings{Provider: "openai", APIKeyEnc: "old:settings"}))
+	require.NoError(t, mem.CreateLLMProviderProfile(ctx, store.LLMProviderProfile{ID: "a", Name: "a", APIKeyEnc: "new:current"}))
+	require.NoError(t, mem.CreateLLMProviderProfile(ctx, store.LLMProviderProfile{ID: "b", Name: "b", APIKeyEnc: "old:bad"}))
+	require.NoError(t, mem.CreateLLMProviderProfile(ctx, store.LLMProviderProfile{ID: "c", Name: "c"}))
+	require.NoError(t, mem.CreateSecret(ctx, store.Secret{ID: "s", Name: "TOKEN", ValueEnc: "old:token"}))
+
+	report, err := mem.RotateSecrets(ctx, func(ciphertext string) (string, bool, error) {
+		switch {
+		case ciphertext == "old:bad":
+			return "", false, errors.New("unknown key")
+		case strings.HasPrefix(ciphertext, "new:"):
+			return ciphertext, false, nil
+		}
+		return strings.Replace(ciphertext, "old:", "new:", 1), true, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Rotated)
+	require.Equal(t, 1, report.Unchanged)
+	require.Equal(t, []store.SecretRotationFailure{{Table: "llm_provider_profiles", ID: "b", Error: "unknown key"}}, report.Failed)
+
+	settings, err := mem.GetLLMSettings(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "new:settings", settings.APIKeyEnc)
+	bad, err := mem.GetLLMProviderProfile(ctx, "b")
+	require.NoError(t, err)
+	require.Equal(t, "old:bad", bad.APIKeyEnc)
+	secret, err := mem.GetSecret(ctx, "s")
+	require.NoError(t, err)
+	require.Equal(t, "new:token", secret.ValueEnc)
+}
+
+func TestSecrets(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+
+	require.NoError(t, mem.CreateSecret(ctx, store.Secret{ID: "b", Name: "ZULU", ValueEnc: "enc-z"}))
+	require.NoError(t, mem.CreateSecret(ctx, store.Secret{ID: "a", Name: "ALPHA", ValueEnc: "enc-a", PolicyProfiles: []string{"ops"}}))
+
+	secrets, err := mem.ListSecrets(ctx)
+	require.NoError(t, err)
+	require.Len(t, secrets, 2)
+	require.Equal(t, "ALPHA", secrets[0].Name)
+
+	secrets[0].PolicyProfiles[0] = "mutated"
+	fetched, err := mem.GetSecret(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"ops"}, fetched.PolicyProfiles)
+
+	fetched.PolicyProfiles = []string{"*"}
+	require.NoError(t, mem.UpdateSecret(ctx, *fetched))
+	fetched, err = mem.GetSecret(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"*"}, fetched.PolicyProfiles)
+
+	require.NoError(t, mem.DeleteSecret(ctx, "a"))
+	missing, err := mem.GetSecret(ctx, "a")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestRedactionPolicies(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+
+	require.NoError(t, mem.UpsertRedactionPolicy(ctx, store.RedactionPolicy{PolicyProfile: "ops", Emails: true, CreatedAt: "t1", UpdatedAt: "t1"}))
+	require.NoError(t, mem.UpsertRedactionPolicy(ctx, store.RedactionPolicy{
+		PolicyProfile: "ops",
+		KeyFormats:    true,
+		CustomRules:   []store.RedactionRule{{Name: "ticket", Pattern: "TCK-[0-9]+"}},
+		CreatedAt:     "t2",
+		UpdatedAt:     "t2",
+	}))
+	require.NoError(t, mem.UpsertRedactionPolicy(ctx, store.RedactionPolicy{PolicyProfile: "default"}))
+
+	policies, err := mem.ListRedactionPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	require.Equal(t, "default", policies[0].PolicyProfile)
+
+	policies[1].CustomRules[0].Name = "mutated"
+	fetched, err := mem.GetRedactionPolicy(ctx, "ops")
+	require.NoError(t, err)
+	require.Equal(t, "t1", fetched.CreatedAt)
+	require.Equal(t, "t2", fetched.UpdatedAt)
+	require.False(t, fetched.Emails)
+	require.Equal(t, "ticket", fetched.CustomRules[0].Name)
+
+	require.NoError(t, mem.DeleteRedactionPolicy(ctx, "ops"))
+	missing, err := mem.GetRedactionPolicy(ctx, "ops")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestGetRun(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+
+	require.NoError(t, mem.CreateRun(ctx, store.Run{ID: "run-1", Status: "queued", Tags: []string{"a"}}))
+	run, err := mem.GetRun(ctx, "run-1")
+	require.NoError(t, err)
+	require.Equal(t, "default", run.PolicyProfile)
+	run.Tags[0] = "mutated"
+	again, err := mem.GetRun(ctx, "run-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, again.Tags)
+
+	missing, err := mem.GetRun(ctx, "run-2")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+
+	require.NoError(t, mem.CreateAPIKey(ctx, store.APIKey{ID: "b", Name: "second", KeyHash: "hash-b", Scopes: []string{"runs:read"}, CreatedAt: "2026-01-02T00:00:00Z"}))
+	require.NoError(t, mem.CreateAPIKey(ctx, store.APIKey{ID: "a", Name: "first", KeyHash: "hash-a", CreatedAt: "2026-01-01T00:00:00Z"}))
+
+	keys, err := mem.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, "first", keys[0].Name)
+
+	found, err := mem.GetAPIKeyByHash(ctx, "hash-b")
+	require.NoError(t, err)
+	require.Equal(t, "b", found.ID)
+	found.Scopes[0] = "mutated"
+
+	require.NoError(t, mem.TouchAPIKey(ctx, "b", "2026-01-03T00:00:00Z"))
+	fetched, err := mem.GetAPIKey(ctx, "b")
+	require.NoError(t, err)
+	require.Equal(t, []string{"runs:read"}, fetched.Scopes)
+	require.Equal(t, "2026-01-03T00:00:00Z", fetched.LastUsedAt)
+
+	require.NoError(t, mem.DeleteAPIKey(ctx, "b"))
+	missing, err := mem.GetAPIKeyByHash(ctx, "hash-b")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestWorkspaceScoping(t *testing.T) {
+	mem := New()
+	defaultCtx := context.Background()
+	teamCtx := store.WithWorkspace(defaultCtx, "team")
+	require.NoError(t, mem.CreateWorkspace(defaultCtx, store.Workspace{ID: "team", Name: "Team"}))
+
+	require.NoError(t, mem.CreateRun(teamCtx, store.Run{ID: "run-team", Status: "running"}))
+	require.NoError(t, mem.CreateRun(defaultCtx, store.Run{ID: "run-default", Status: "running"}))
+
+	runs, err := mem.ListRuns(teamCtx)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, "run-team", runs[0].ID)
+
+	hidden, err := mem.GetRun(defaultCtx, "run-team")
+	require.NoError(t, err)
+	require.Nil(t, hidden)
+	workspaceID, err := mem.GetRunWorkspace(defaultCtx, "run-team")
+	require.NoError(t, err)
+	require.Equal(t, "team", workspaceID)
+
+	err = mem.AddMessage(defaultCtx, store.Message{ID: "m", RunID: "run-team", Role: "user"})
+	require.ErrorIs(t, err, store.ErrNotInWorkspace)
+	require.NoError(t, mem.AddMessage(teamCtx, store.Message{ID: "m", RunID: "run-team", Role: "user"}))
+	messages, err := mem.ListMessages(defaultCtx, "run-team")
+	require.NoError(t, err)
+	require.Empty(t, messages)
+
+	require.NoError(t, mem.CreateSkill(teamCtx, store.Skill{ID: "skill-team", Name: "notes"}))
+	require.NoError(t, mem.CreateSkill(defaultCtx, store.Skill{ID: "skill-default", Name: "notes"}))
+	skills, err := mem.ListSkills(teamCtx)
+	require.NoError(t, err)
+	require.Len(t, skills, 1)
+	require.Equal(t, "skill-team", skills[0].ID)
+	require.ErrorIs(t, mem.UpsertSkillFile(defaultCtx, store.SkillFile{SkillID: "skill-team", Path: "SKILL.md"}), store.ErrNotInWorkspace)
+
+	require.NoError(t, mem.CreateAutomation(teamCtx, store.Automation{ID: "auto-team"}))
+	automation, err := mem.GetAutomation(defaultCtx, "auto-team")
+	require.NoError(t, err)
+	require.Nil(t, automation)
+	require.ErrorIs(t, mem.CreateAutomationInboxEntry(defaultCtx, store.AutomationInboxEntry{ID: "e", AutomationID: "auto-team"}), store.ErrNotInWorkspace)
+
+	require.NoError(t, mem.CreateSecret(teamCtx, store.Secret{ID: "secret-team", Name: "TOKEN", ValueEnc: "team"}))
+	require.NoError(t, mem.CreateSecret(defaultCtx, store.Secret{ID: "secret-default", Name: "TOKEN", ValueEnc: "default"}))
+	secrets, err := mem.ListSecrets(teamCtx)
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	require.Equal(t, "secret-team", secrets[0].ID)
+	foreignSecret, err := mem.GetSecret(defaultCtx, "secret-team")
+	require.NoError(t, err)
+	require.Nil(t, foreignSecret)
+	require.NoError(t, mem.UpdateSecret(defaultCtx, store.Secret{ID: "secret-team", Name: "TOKEN", ValueEnc: "overwritten"}))
+	require.NoError(t, mem.DeleteSecret(defaultCtx, "secret-team"))
+	teamSecret, err := mem.GetSecret(teamCtx, "secret-team")
+	require.NoError(t, err)
+	require.Equal(t, "team", teamSecret.ValueEnc)
+
+	require.NoError(t, mem.CreateLLMRouteRule(teamCtx, store.LLMRouteRule{ID: "rule-team", Name: "team"}))
+	rules, err := mem.ListLLMRouteRules(defaultCtx)
+	require.NoError(t, err)
+	require.Empty(t, rules)
+	require.NoError(t, mem.CreateLLMProviderProfile(teamCtx, store.LLMProviderProfile{ID: "profile-team", Name: "team", APIKeyEnc: "team"}))
+	profile, err := mem.GetLLMProviderProfile(defaultCtx, "profile-team")
+	require.NoError(t, err)
+	require.Nil(t, profile)
+
+	require.NoError(t, mem.UpsertRedactionPolicy(teamCtx, store.RedactionPolicy{PolicyProfile: "default", Emails: true}))
+	policy, err := mem.GetRedactionPolicy(defaultCtx, "default")
+	require.NoError(t, err)
+	require.Nil(t, policy)
+	require.NoError(t, mem.DeleteRedactionPolicy(defaultCtx, "default"))
+	policy, err = mem.GetRedactionPolicy(teamCtx, "default")
+	require.NoError(t, err)
+	require.True(t, policy.Emails)
+
+	require.NoError(t, mem.CreateAPIKey(defaultCtx, store.APIKey{ID: "key-team", KeyHash: "hash-team", WorkspaceID: "team"}))
+	keys, err := mem.ListAPIKeys(defaultCtx)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+	require.NoError(t, mem.DeleteAPIKey(defaultCtx, "key-team"))
+	key, err := mem.GetAPIKey(teamCtx, "key-team")
+	require.NoError(t, err)
+	require.NotNil(t, key)
+
+	report, err := mem.RotateSecrets(teamCtx, func(ciphertext string) (string, bool, error) {
+		return "rotated:" + ciphertext, true, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Rotated)
+	defaultSecret, err := mem.GetSecret(defaultCtx, "secret-default")
+	require.NoError(t, err)
+	require.Equal(t, "default", defaultSecret.ValueEnc)
+
+	require.NoError(t, mem.DeleteWorkspace(defaultCtx, "team"))
+	workspaceID, err = mem.GetRunWorkspace(defaultCtx, "run-team")
+	require.NoError(t, err)
+	require.Empty(t, workspaceID)
+	skills, err = mem.ListSkills(defaultCtx)
+	require.NoError(t, err)
+	require.Len(t, skills, 1)
+	secrets, err = mem.ListSecrets(teamCtx)
+	require.NoError(t, err)
+	require.Empty(t, secrets)
+	policy, err = mem.GetRedactionPolicy(teamCtx, "default")
+	require.NoError(t, err)
+	require.Nil(t, policy)
+}
+
+func TestWorkspaceSettingsFallBackToDefault(t *testing.T) {
+	mem := New()
+	defaultCtx := context.Background()
+	teamCtx := store.WithWorkspace(defaultCtx, "team")
+
+	require.NoError(t, mem.UpsertLLMSettings(defaultCtx, store.LLMSettings{Provider: "openai", Model: "base"}))
+	inherited, err := mem.GetLLMSettings(teamCtx)
+	require.NoError(t, err)
+	require.Equal(t, "base", inherited.Model)
+
+	require.NoError(t, mem.UpsertLLMSettings(teamCtx, store.LLMSettings{Provider: "anthropic", Model: "team"}))
+	override, err := mem.GetLLMSettings(teamCtx)
+	require.NoError(t, err)
+	require.Equal(t, "team", override.Model)
+	base, err := mem.GetLLMSettings(defaultCtx)
+	require.NoError(t, err)
+	require.Equal(t, "base", base.Model)
+
+	require.NoError(t, mem.UpsertPersonalitySettings(teamCtx, store.PersonalitySettings{Content: "terse"}))
+	personality, err := mem.GetPersonalitySettings(defaultCtx)
+	require.NoError(t, err)
+	require.Nil(t, personality)
+
+	fingerprint := map[string]any{"fingerprint": "same"}
+	inserted, err := mem.UpsertMemoryEntry(defaultCtx, store.MemoryEntry{ID: "a", Content: "shared fact", Metadata: fingerprint})
+	require.NoError(t, err)
+	require.True(t, inserted)
+	inserted, err = mem.UpsertMemoryEntry(teamCtx, store.MemoryEntry{ID: "b", Content: "shared fact", Metadata: fingerprint})
+	require.NoError(t, err)
+	require.True(t, inserted)
+	results, err := mem.SearchMemory(teamCtx, "shared", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "b", results[0].ID)
+}
+
+func TestUsersAndMembers(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+
+	workspaces, err := mem.ListWorkspaces(ctx)
+	require.NoError(t, err)
+	require.Len(t, workspaces, 1)
+	require.Equal(t, store.DefaultWorkspaceID, workspaces[0].ID)
+
+	require.NoError(t, mem.CreateUser(ctx, store.User{ID: "u1", Name: "Ana", Email: "ana@example.com"}))
+	require.Error(t, mem.CreateUser(ctx, store.User{ID: "u2", Name: "Other", Email: "ANA@example.com"}))
+	require.NoError(t, mem.UpsertWorkspaceMember(ctx, store.WorkspaceMember{WorkspaceID: "default", UserID: "u1", Role: "member", CreatedAt: "t1"}))
+	require.NoError(t, mem.UpsertWorkspaceMember(ctx, store.WorkspaceMember{WorkspaceID: "default", UserID: "u1", Role: "admin", CreatedAt: "t2"}))
+	members, err := mem.ListWorkspaceMembers(ctx, "default")
+	require.NoError(t, err)
+	require.Equal(t, []store.WorkspaceMember{{WorkspaceID: "default", UserID: "u1", Role: "admin", CreatedAt: "t1"}}, members)
+
+	require.NoError(t, mem.CreateAPIKey(ctx, store.APIKey{ID: "k", Name: "ana", KeyHash: "h", UserID: "u1"}))
+	require.NoError(t, mem.DeleteUser(ctx, "u1"))
+	members, err = mem.ListWorkspaceMembers(ctx, "default")
+	require.NoError(t, err)
+	require.Empty(t, members)
+	key, err := mem.GetAPIKey(ctx, "k")
+	require.NoError(t, err)
+	require.Nil(t, key)
+}
+
+func TestAuditEntries(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+
+	for _, entry := range []struct{ action, createdAt string }{
+		{"skill.create", "2026-01-01T00:00:00Z"},
+		{"automation.update", "2026-01-01T00:00:01Z"},
+		{"skill.delete", "2026-01-01T00:00:02Z"},
+	} {
+		require.NoError(t, mem.AppendAuditEntry(ctx, store.AuditEntry{
+			ID:         "audit-" + entry.action,
+			Action:     entry.action,
+			TargetType: strings.Split(entry.action, ".")[0],
+			After:      map[string]any{"name": "daily"},
+			CreatedAt:  entry.createdAt,
+		}))
+	}
+	require.NoError(t, mem.AppendAuditEntry(store.WithWorkspace(ctx, "team"), store.AuditEntry{ID: "audit-team", Action: "skill.create", CreatedAt: "2026-01-01T00:00:00Z"}))
+
+	entries, err := mem.ListAuditEntries(ctx, store.AuditQuery{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "skill.delete", entries[0].Action)
+	require.Equal(t, store.DefaultWorkspaceID, entries[0].WorkspaceID)
+
+	page, err := mem.ListAuditEntries(ctx, store.AuditQuery{BeforeSeq: entries[0].Seq, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, "automation.update", page[0].Action)
+
+	skills, err := mem.ListAuditEntries(ctx, store.AuditQuery{TargetType: "skill", Since: "2026-01-01T00:00:01Z"})
+	require.NoError(t, err)
+	require.Len(t, skills, 1)
+	require.Equal(t, "skill.delete", skills[0].Action)
+
+	skills[0].After["name"] = "changed"
+	again, err := mem.ListAuditEntries(ctx, store.AuditQuery{Action: "skill.delete"})
+	require.NoError(t, err)
+	require.Equal(t, "daily", again[0].After["name"])
+
+	_, err = mem.ListAuditEntries(ctx, store.AuditQuery{Until: "yesterday"})
+	require.Error(t, err)
+}
+
+func TestWebhookSubscriptionsAndDeliveries(t *testing.T) {
+	ctx := context.Background()
+	teamCtx := store.WithWorkspace(ctx, "team")
+	mem := New()
+
+	require.NoError(t, mem.CreateWebhookSubscription(ctx, store.WebhookSubscription{
+		ID:         "hook-1",
+		Name:       "ops",
+		URL:        "https://example.com/hook",
+		EventTypes: []string{"run.completed"},
+		SecretEnc:  "enc",
+		Enabled:    true,
+		CreatedAt:  "2026-01-01T00:00:00Z",
+	}))
+	require.NoError(t, mem.CreateWebhookSubscription(teamCtx, store.WebhookSubscription{ID: "hook-team", EventTypes: []string{"*"}, Enabled: true}))
+
+	subscriptions, err := mem.ListWebhookSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	hidden, err := mem.GetWebhookSubscription(ctx, "hook-team")
+	require.NoError(t, err)
+	require.Nil(t, hidden)
+	require.ErrorIs(t, mem.CreateWebhookDelivery(ctx, store.WebhookDelivery{ID: "bad", SubscriptionID: "hook-team"}), store.ErrNotInWorkspace)
+
+	for _, delivery := range []store.WebhookDelivery{
+		{ID: "del-1", SubscriptionID: "hook-1", Status: "pending", NextAttemptAt: "2026-01-01T00:00:00Z", CreatedAt: "2026-01-01T00:00:00Z"},
+		{ID: "del-2", SubscriptionID: "hook-1", Status: "pending", NextAttemptAt: "2026-01-01T00:10:00Z", CreatedAt: "2026-01-01T00:00:01Z"},
+		{ID: "del-3", SubscriptionID: "hook-1", Status: "delivered", NextAttemptAt: "2026-01-01T00:00:00Z", CreatedAt: "2026-01-01T00:00:02Z"},
+	} {
+		delivery.Payload = map[string]any{"type": "run.completed"}
+		require.NoError(t, mem.CreateWebhookDelivery(ctx, delivery))
+	}
+
+	claimed, err := mem.ClaimWebhookDeliveries(ctx, "2026-01-01T00:05:00Z", "2026-01-01T00:06:00Z", 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, "del-1", claimed[0].ID)
+	require.Equal(t, store.DefaultWorkspaceID, claimed[0].WorkspaceID)
+	again, err := mem.ClaimWebhookDeliveries(ctx, "2026-01-01T00:05:30Z", "2026-01-01T00:06:30Z", 10)
+	require.NoError(t, err)
+	require.Empty(t, again)
+
+	claimed[0].Status = "delivered"
+	claimed[0].Attempts = 1
+	require.NoError(t, mem.UpdateWebhookDelivery(ctx, claimed[0]))
+	delivery, err := mem.GetWebhookDelivery(ctx, "del-1")
+	require.NoError(t, err)
+	require.Equal(t, "delivered", delivery.Status)
+	otherWorkspace, err := mem.GetWebhookDelivery(teamCtx, "del-1")
+	require.NoError(t, err)
+	require.Nil(t, otherWorkspace)
+
+	deliveries, err := mem.ListWebhookDeliveries(ctx, "hook-1", 2)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	require.Equal(t, "del-3", deliveries[0].ID)
+
+	require.NoError(t, mem.DeleteWebhookSubscription(ctx, "hook-1"))
+	deliveries, err = mem.ListWebhookDeliveries(ctx, "hook-1", 0)
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
+
+func TestNotificationChannels(t *testing.T) {
+	ctx := context.Background()
+	teamCtx := store.WithWorkspace(ctx, "team")
+	mem := New()
+	require.NoError(t, mem.CreateWorkspace(ctx, store.Workspace{ID: "team", Name: "Team"}))
+
+	require.NoError(t, mem.CreateNotificationChannel(ctx, store.NotificationChannel{
+		ID:         "chan-1",
+		Name:       "ops",
+		Type:       "ntfy",
+		Config:     map[string]string{"topic": "alerts"},
+		SecretsEnc: "old:token",
+		Enabled:    true,
+		CreatedAt:  "2026-01-01T00:00:00Z",
+	}))
+	require.NoError(t, mem.CreateNotificationChannel(teamCtx, store.NotificationChannel{ID: "chan-team", Type: "slack", Enabled: true}))
+
+	channels, err := mem.ListNotificationChannels(ctx)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	channels[0].Config["topic"] = "mutated"
+	channel, err := mem.GetNotificationChannel(ctx, "chan-1")
+	require.NoError(t, err)
+	require.Equal(t, "alerts", channel.Config["topic"])
+	hidden, err := mem.GetNotificationChannel(ctx, "chan-team")
+	require.NoError(t, err)
+	require.Nil(t, hidden)
+
+	channel.Enabled = false
+	require.NoError(t, mem.UpdateNotificationChannel(ctx, *channel))
+	require.NoError(t, mem.UpdateNotificationChannel(teamCtx, store.NotificationChannel{ID: "chan-1", Name: "hijack"}))
+	channel, err = mem.GetNotificationChannel(ctx, "chan-1")
+	require.NoError(t, err)
+	require.False(t, channel.Enabled)
+	require.Equal(t, "ops", channel.Name)
+
+	report, err := mem.RotateSecrets(ctx, func(ciphertext string) (string, bool, error) {
+		return strings.Replace(ciphertext, "old:", "new:", 1), true, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Rotated)
+	channel, err = mem.GetNotificationChannel(ctx, "chan-1")
+	require.NoError(t, err)
+	require.Equal(t, "new:token", channel.SecretsEnc)
+
+	require.NoError(t, mem.DeleteNotificationChannel(teamCtx, "chan-1"))
+	require.NoError(t, mem.DeleteNotificationChannel(ctx, "chan-1"))
+	channels, err = mem.ListNotificationChannels(ctx)
+	require.NoError(t, err)
+	require.Empty(t, channels)
+	require.NoError(t, mem.DeleteWorkspace(ctx, "team"))
+	channel, err = mem.GetNotificationChannel(teamCtx, "chan-team")
+	require.NoError(t, err)
+	require.Nil(t, channel)
+}
+
+func TestAutomationInboxChangeFields(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "auto-1", Name: "Brief", ChangeThreshold: 0.8}))
+	automation, err := mem.GetAutomation(ctx, "auto-1")
+	require.NoError(t, err)
+	require.Equal(t, 0.8, automation.ChangeThreshold)
+
+	similarity := 0.95
+	entry := store.AutomationInboxEntry{ID: "inbox-1", AutomationID: "auto-1", Status: "completed", ChangeSummary: "Unchanged.", Similarity: &similarity, Unchanged: true}
+	require.NoError(t, mem.CreateAutomationInboxEntry(ctx, entry))
+	similarity = 0.1
+
+	entries, err := mem.ListAutomationInbox(ctx, "auto-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, 0.95, *entries[0].Similarity)
+	require.True(t, entries[0].Unchanged)
+	*entries[0].Similarity = 0.2
+
+	entries, err = mem.ListAutomationInbox(ctx, "auto-1")
+	require.NoError(t, err)
+	require.Equal(t, 0.95, *entries[0].Similarity)
+}
+
+func TestClaimAutomationInboxEntry(t *testing.T) {
+	ctx := context.Background()
+	mem := New()
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "auto-1", Name: "Brief"}))
+	require.NoError(t, mem.CreateAutomationInboxEntry(ctx, store.AutomationInboxEntry{ID: "inbox-1", AutomationID: "auto-1", Status: "queued"}))
+	require.NoError(t, mem.CreateAutomationInboxEntry(ctx, store.AutomationInboxEntry{ID: "inbox-done", AutomationID: "auto-1", Status: "completed"}))
+
+	claimed, err := mem.ClaimAutomationInboxEntry(ctx, "auto-1", "inbox-1", "replica-a", "2026-01-01T00:00:00Z", "2026-01-01T00:02:00Z")
+	require.NoError(t, err)
+	require.True(t, claimed)
+	claimed, err = mem.ClaimAutomationInboxEntry(ctx, "auto-1", "inbox-1", "replica-b", "2026-01-01T00:01:00Z", "2026-01-01T00:03:00Z")
+	require.NoError(t, err)
+	require.False(t, claimed)
+	claimed, err = mem.ClaimAutomationInboxEntry(ctx, "auto-1", "inbox-1", "replica-a", "2026-01-01T00:01:00Z", "2026-01-01T00:03:00Z")
+	require.NoError(t, err)
+	require.True(t, claimed)
+	claimed, err = mem.ClaimAutomationInboxEntry(ctx, "auto-1", "inbox-done", "replica-a", "2026-01-01T00:01:00Z", "2026-01-01T00:03:00Z")
+	require.NoError(t, err)
+	require.False(t, claimed)
+
+	// Updates keep the lease.
+	require.NoError(t, mem.UpdateAutomationInboxEntry(ctx, store.AutomationInboxEntry{ID: "inbox-1", AutomationID: "auto-1", Status: "running"}))
+	entries, err := mem.ListAutomationInbox(ctx, "auto-1")
+	require.NoError(t, err)
+	entry := entries[0]
+	if entry.ID != "inbox-1" {
+		entry = entries[1]
+	}
+	require.Equal(t, "replica-a", entry.LeaseOwner)
+	require.Equal(t, "2026-01-01T00:03:00Z", entry.LeaseUntil)
+
+	// A lapsed lease can be taken over.
+	claimed, err = mem.ClaimAutomationInboxEntry(ctx, "auto-1", "inbox-1", "replica-b", "2026-01-01T00:03:00Z", "2026-01-01T00:05:00Z")
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, mem.ReleaseAutomationInboxEntry(ctx, "auto-1", "inbox-1", "replica-a"))
+	claimed, err = mem.ClaimAutomationInboxEntry(ctx, "auto-1", "inbox-1", "replica-a", "2026-01-01T00:04:00Z", "2026-01-01T00:06:00Z")
+	require.NoError(t, err)
+	require.False(t, claimed)
+	require.NoError(t, mem.ReleaseAutomationInboxEntry(ctx, "auto-1", "inbox-1", "replica-b"))
+	claimed, err = mem.ClaimAutomationInboxEntry(ctx, "auto-1", "inbox-1", "replica-a", "2026-01-01T00:04:00Z", "2026-01-01T00:06:00Z")
+	require.NoError(t, err)
+	require.True(t, claimed)
+}
+
+func TestQueryAutomationInbox(t *testing.T) {
+	ctx := context.Background()
+	teamCtx := store.WithWorkspace(ctx, "team")
+	mem := New()
+	require.NoError(t, mem.CreateWorkspace(ctx, store.Workspace{ID: "team", Name: "Team"}))
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "auto-1", Name: "News"}))
+	require.NoError(t, mem.CreateAutomation(ctx, store.Automation{ID: "auto-2", Name: "Prices"}))
+	require.NoError(t, mem.CreateAutomation(teamCtx, store.Automation{ID: "auto-team", Name: "Team"}))
+	for _, entry := range []store.AutomationInboxEntry{
+		{ID: "a", AutomationID: "auto-1", Status: "completed", Trigger: "schedule", Unread: true, StartedAt: "2026-10-15T09:00:00Z"},
+		{ID: "b", AutomationID: "auto-2", Status: "failed", Trigger: "webhook", Unread: true, StartedAt: "2026-10-16T09:00:00Z"},
+		{ID: "c", AutomationID: "auto-1", Status: "completed", Trigger: "manual", StartedAt: "2026-10-16T09:00:00Z"},
+		{ID: "d", AutomationID: "auto-2", Status: "completed", Trigger: "schedule", StartedAt: "2026-10-17T09:00:00Z", ArchivedAt: "2026-10-18T09:00:00Z"},
+	} {
+		require.NoError(t, mem.CreateAutomationInboxEntry(ctx, entry))
+	}
+	require.NoError(t, mem.CreateAutomationInboxEntry(teamCtx, store.AutomationInboxEntry{ID: "e", AutomationID: "auto-team", Status: "completed", StartedAt: "2026-10-18T09:00:00Z"}))
+
+	ids := func(query store.AutomationInboxQuery) []string {
+		entries, err := mem.QueryAutomationInbox(ctx, query)
+		require.NoError(t, err)
+		result := []string{}
+		for _, entry := range entries {
+			result = append(result, entry.ID)
+		}
+		return result
+	}
+	require.Equal(t, []string{"c", "b", "a"}, ids(store.AutomationInboxQuery{}))
+	require.Equal(t, []string{"d"}, ids(store.AutomationInboxQuery{Archived: true}))
+	require.Equal(t, []string{"b", "a"}, ids(store.AutomationInboxQuery{UnreadOnly: true}))
+	require.Equal(t, []string{"b"}, ids(store.AutomationInboxQuery{Statuses: []string{"failed"}}))
+	require.Equal(t, []string{"c", "a"}, ids(store.AutomationInboxQuery{Triggers: []string{"schedule", "manual"}}))
+	require.Equal(t, []string{"c", "a"}, ids(store.AutomationInboxQuery{AutomationID: "auto-1"}))
+	require.Equal(t, []string{"c", "b"}, ids(store.AutomationInboxQuery{Since: "2026-10-16T00:00:00Z", Until: "2026-10-17T00:00:00Z"}))
+	require.Equal(t, []string{"c"}, ids(store.AutomationInboxQuery{Limit: 1}))
+	require.Equal(t, []string{"b", "a"}, ids(store.AutomationInboxQuery{BeforeStartedAt: "2026-10-16T09:00:00Z", BeforeID: "c"}))
+	_, err := mem.QueryAutomationInbox(ctx, store.AutomationInboxQuery{Since: "yesterday"})
+	require.Error(t, err)
+
+	require.NoError(t, mem.DeleteAutomationInboxEntries(teamCtx, "auto-1", []string{"a"}))
+	require.NoError(t, mem.DeleteAutomationInboxEntries(ctx, "auto-1", []string{"a", "missing"}))
+	require.Equal(t, []string{"c"}, ids(store.AutomationInboxQuery{AutomationID: "auto-1"}))
+}
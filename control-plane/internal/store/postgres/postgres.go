@@ -56,6 +56,18 @@ func verifySchema(ctx context.Context, db *sql.DB) error {
 		"artifacts",
 		"automations",
 		"automation_inbox",
+		"llm_route_rules",
+		"llm_provider_profiles",
+		"secrets",
+		"redaction_policies",
+		"api_keys",
+		"workspaces",
+		"users",
+		"workspace_members",
+		"audit_log",
+		"webhook_subscriptions",
+		"webhook_deliveries",
+		"notification_channels",
 	}
 	for _, table := range required {
 		var regclass sql.NullString
@@ -93,10 +105,13 @@ func (p *PostgresStore) CreateRun(ctx context.Context, run store.Run) error {
 			policy_profile,
 			model_route,
 			tags,
+			workspace_id,
+			user_id,
+			created_by,
 			created_at,
 			updated_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 	_, err = p.db.ExecContext(
 		ctx,
@@ -110,17 +125,93 @@ func (p *PostgresStore) CreateRun(ctx context.Context, run store.Run) error {
 		policyProfile,
 		nullString(run.ModelRoute),
 		tagsBytes,
+		store.WorkspaceID(ctx),
+		nullString(run.UserID),
+		nullString(run.CreatedBy),
 		run.CreatedAt,
 		run.UpdatedAt,
 	)
 	return err
 }
 
+func (p *PostgresStore) GetRun(ctx context.Context, runID string) (*store.Run, error) {
+	const query = `
+		SELECT id, status, phase, completion_reason, resumed_from, checkpoint_seq, policy_profile, model_route, tags, workspace_id, COALESCE(user_id::text, ''), COALESCE(created_by, ''), created_at, updated_at
+		FROM runs
+		WHERE id = $1 AND workspace_id = $2
+	`
+	var (
+		run              store.Run
+		completionReason sql.NullString
+		resumedFrom      sql.NullString
+		modelRoute       sql.NullString
+		tagsBytes        []byte
+		createdAt        time.Time
+		updatedAt        time.Time
+	)
+	if err := p.db.QueryRowContext(ctx, query, runID, store.WorkspaceID(ctx)).Scan(
+		&run.ID,
+		&run.Status,
+		&run.Phase,
+		&completionReason,
+		&resumedFrom,
+		&run.CheckpointSeq,
+		&run.PolicyProfile,
+		&modelRoute,
+		&tagsBytes,
+		&run.WorkspaceID,
+		&run.UserID,
+		&run.CreatedBy,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	run.CompletionReason = completionReason.String
+	run.ResumedFrom = resumedFrom.String
+	run.ModelRoute = modelRoute.String
+	run.Tags = decodeStringSlice(tagsBytes)
+	run.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	run.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return &run, nil
+}
+
 func (p *PostgresStore) DeleteRun(ctx context.Context, runID string) error {
-	_, err := p.db.ExecContext(ctx, "DELETE FROM runs WHERE id = $1", runID)
+	_, err := p.db.ExecContext(ctx, "DELETE FROM runs WHERE id = $1 AND workspace_id = $2", runID, store.WorkspaceID(ctx))
 	return err
 }
 
+func (p *PostgresStore) GetRunWorkspace(ctx context.Context, runID string) (string, error) {
+	var workspaceID string
+	if err := p.db.QueryRowContext(ctx, "SELECT workspace_id FROM runs WHERE id = $1", runID).Scan(&workspaceID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return workspaceID, nil
+}
+
+// requireInWorkspace guards writes to child tables, which carry no workspace
+// of their own: the parent row in table must belong to the context's
+// workspace.
+func requireInWorkspace(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, table string, id string) error {
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM " + table + " WHERE id = $1 AND workspace_id = $2)"
+	if err := q.QueryRowContext(ctx, query, id, store.WorkspaceID(ctx)).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return store.ErrNotInWorkspace
+	}
+	return nil
+}
+
 func (p *PostgresStore) ListRuns(ctx context.Context) ([]store.RunSummary, error) {
 	const query = `
 		SELECT
@@ -143,6 +234,8 @@ func (p *PostgresStore) ListRuns(ctx context.Context) ([]store.RunSummary, error
 			r.policy_profile,
 			r.model_route,
 			r.tags,
+			COALESCE(r.user_id::text, ''),
+			COALESCE(r.created_by, ''),
 			r.created_at,
 			COALESCE(latest.timestamp, r.updated_at) AS updated_at,
 			COALESCE(NULLIF(r.title, ''), title_event.title, first_message.content, '') AS title,
@@ -172,10 +265,11 @@ func (p *PostgresStore) ListRuns(ctx context.Context) ([]store.RunSummary, error
 			LIMIT 1
 		) first_message ON true
 		LEFT JOIN messages m ON m.run_id = r.id
-		GROUP BY r.id, r.status, r.phase, r.completion_reason, r.resumed_from, r.checkpoint_seq, r.policy_profile, r.model_route, r.tags, r.created_at, r.updated_at, r.title, latest.type, latest.timestamp, title_event.title, first_message.content
+		WHERE r.workspace_id = $1
+		GROUP BY r.id, r.status, r.phase, r.completion_reason, r.resumed_from, r.checkpoint_seq, r.policy_profile, r.model_route, r.tags, r.user_id, r.created_by, r.created_at, r.updated_at, r.title, latest.type, latest.timestamp, title_event.title, first_message.content
 		ORDER BY COALESCE(latest.timestamp, r.updated_at) DESC
 	`
-	rows, err := p.db.QueryContext(ctx, query)
+	rows, err := p.db.QueryContext(ctx, query, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +294,8 @@ func (p *PostgresStore) ListRuns(ctx context.Context) ([]store.RunSummary, error
 			&summary.PolicyProfile,
 			&modelRoute,
 			&tagsBytes,
+			&summary.UserID,
+			&summary.CreatedBy,
 			&createdAt,
 			&updatedAt,
 			&summary.Title,
@@ -239,6 +335,9 @@ func (p *PostgresStore) AddMessage(ctx context.Context, msg store.Message) error
 	if err != nil {
 		return err
 	}
+	if err := requireInWorkspace(ctx, p.db, "runs", msg.RunID); err != nil {
+		return err
+	}
 	const query = `
 		INSERT INTO messages (id, run_id, role, content, sequence, created_at, metadata)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -251,10 +350,10 @@ func (p *PostgresStore) ListMessages(ctx context.Context, runID string) ([]store
 	const query = `
 		SELECT id, run_id, role, content, sequence, created_at, metadata
 		FROM messages
-		WHERE run_id = $1
+		WHERE run_id = $1 AND run_id IN (SELECT id FROM runs WHERE workspace_id = $2)
 		ORDER BY sequence ASC
 	`
-	rows, err := p.db.QueryContext(ctx, query, runID)
+	rows, err := p.db.QueryContext(ctx, query, runID, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -288,14 +387,16 @@ func (p *PostgresStore) ListMessages(ctx context.Context, runID string) ([]store
 
 func (p *PostgresStore) GetLLMSettings(ctx context.Context) (*store.LLMSettings, error) {
 	const query = `
-		SELECT mode, provider, model, base_url, api_key_enc, codex_auth_path, codex_home, created_at, updated_at
+		SELECT mode, provider, model, base_url, api_key_enc, codex_auth_path, codex_home, utility_provider, utility_model, utility_base_url, created_at, updated_at
 		FROM llm_settings
-		WHERE id = 1
+		WHERE workspace_id IN ($1, $2)
+		ORDER BY workspace_id = $1 DESC
+		LIMIT 1
 	`
 	var createdAt time.Time
 	var updatedAt time.Time
 	settings := store.LLMSettings{}
-	if err := p.db.QueryRowContext(ctx, query).Scan(
+	if err := p.db.QueryRowContext(ctx, query, store.WorkspaceID(ctx), store.DefaultWorkspaceID).Scan(
 		&settings.Mode,
 		&settings.Provider,
 		&settings.Model,
@@ -303,6 +404,9 @@ func (p *PostgresStore) GetLLMSettings(ctx context.Context) (*store.LLMSettings,
 		&settings.APIKeyEnc,
 		&settings.CodexAuthPath,
 		&settings.CodexHome,
+		&settings.UtilityProvider,
+		&settings.UtilityModel,
+		&settings.UtilityBaseURL,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
@@ -319,10 +423,10 @@ func (p *PostgresStore) GetLLMSettings(ctx context.Context) (*store.LLMSettings,
 func (p *PostgresStore) UpsertLLMSettings(ctx context.Context, settings store.LLMSettings) error {
 	const query = `
 		INSERT INTO llm_settings
-			(id, mode, provider, model, base_url, api_key_enc, codex_auth_path, codex_home, created_at, updated_at)
+			(workspace_id, mode, provider, model, base_url, api_key_enc, codex_auth_path, codex_home, utility_provider, utility_model, utility_base_url, created_at, updated_at)
 		VALUES
-			(1, $1, $2, $3, $4, $5, $6, $7, $8, $9)
-		ON CONFLICT (id)
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (workspace_id)
 		DO UPDATE SET
 			mode = EXCLUDED.mode,
 			provider = EXCLUDED.provider,
@@ -331,11 +435,15 @@ func (p *PostgresStore) UpsertLLMSettings(ctx context.Context, settings store.LL
 			api_key_enc = EXCLUDED.api_key_enc,
 			codex_auth_path = EXCLUDED.codex_auth_path,
 			codex_home = EXCLUDED.codex_home,
+			utility_provider = EXCLUDED.utility_provider,
+			utility_model = EXCLUDED.utility_model,
+			utility_base_url = EXCLUDED.utility_base_url,
 			updated_at = EXCLUDED.updated_at
 	`
 	_, err := p.db.ExecContext(
 		ctx,
 		query,
+		store.WorkspaceID(ctx),
 		settings.Mode,
 		settings.Provider,
 		settings.Model,
@@ -343,35 +451,65 @@ func (p *PostgresStore) UpsertLLMSettings(ctx context.Context, settings store.LL
 		settings.APIKeyEnc,
 		settings.CodexAuthPath,
 		settings.CodexHome,
+		settings.UtilityProvider,
+		settings.UtilityModel,
+		settings.UtilityBaseURL,
 		settings.CreatedAt,
 		settings.UpdatedAt,
 	)
 	return err
 }
 
-func (p *PostgresStore) ListSkills(ctx context.Context) ([]store.Skill, error) {
-	const query = `
-		SELECT id, name, description, created_at, updated_at
-		FROM skills
-		ORDER BY name ASC
-	`
-	rows, err := p.db.QueryContext(ctx, query)
+const llmRouteRuleColumns = `id, name, priority, task_types, tags, policy_profiles, automation_ids, route, enabled, created_at, updated_at`
+
+func scanLLMRouteRule(scanner interface{ Scan(dest ...any) error }) (store.LLMRouteRule, error) {
+	var (
+		rule           store.LLMRouteRule
+		taskTypes      []byte
+		tags           []byte
+		policyProfiles []byte
+		automationIDs  []byte
+		createdAt      time.Time
+		updatedAt      time.Time
+	)
+	if err := scanner.Scan(
+		&rule.ID,
+		&rule.Name,
+		&rule.Priority,
+		&taskTypes,
+		&tags,
+		&policyProfiles,
+		&automationIDs,
+		&rule.Route,
+		&rule.Enabled,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return store.LLMRouteRule{}, err
+	}
+	rule.TaskTypes = decodeStringSlice(taskTypes)
+	rule.Tags = decodeStringSlice(tags)
+	rule.PolicyProfiles = decodeStringSlice(policyProfiles)
+	rule.AutomationIDs = decodeStringSlice(automationIDs)
+	rule.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	rule.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return rule, nil
+}
+
+func (p *PostgresStore) ListLLMRouteRules(ctx context.Context) ([]store.LLMRouteRule, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+llmRouteRuleColumns+` FROM llm_route_rules WHERE workspace_id = $1 ORDER BY priority ASC, name ASC`, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	results := []store.Skill{}
+	results := make([]store.LLMRouteRule, 0)
 	for rows.Next() {
-		var createdAt time.Time
-		var updatedAt time.Time
-		var skill store.Skill
-		if err := rows.Scan(&skill.ID, &skill.Name, &skill.Description, &createdAt, &updatedAt); err != nil {
+		rule, err := scanLLMRouteRule(rows)
+		if err != nil {
 			return nil, err
 		}
-		skill.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-		skill.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-		results = append(results, skill)
+		results = append(results, rule)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -379,93 +517,133 @@ func (p *PostgresStore) ListSkills(ctx context.Context) ([]store.Skill, error) {
 	return results, nil
 }
 
-func (p *PostgresStore) GetSkill(ctx context.Context, skillID string) (*store.Skill, error) {
-	const query = `
-		SELECT id, name, description, created_at, updated_at
-		FROM skills
-		WHERE id = $1
-	`
-	var createdAt time.Time
-	var updatedAt time.Time
-	skill := store.Skill{}
-	if err := p.db.QueryRowContext(ctx, query, skillID).Scan(
-		&skill.ID,
-		&skill.Name,
-		&skill.Description,
-		&createdAt,
-		&updatedAt,
-	); err != nil {
+func (p *PostgresStore) GetLLMRouteRule(ctx context.Context, ruleID string) (*store.LLMRouteRule, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+llmRouteRuleColumns+` FROM llm_route_rules WHERE id = $1 AND workspace_id = $2`, ruleID, store.WorkspaceID(ctx))
+	rule, err := scanLLMRouteRule(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	skill.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-	skill.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-	return &skill, nil
+	return &rule, nil
 }
 
-func (p *PostgresStore) CreateSkill(ctx context.Context, skill store.Skill) error {
+func encodeStringSlice(values []string) []byte {
+	if values == nil {
+		values = []string{}
+	}
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return []byte("[]")
+	}
+	return raw
+}
+
+func (p *PostgresStore) CreateLLMRouteRule(ctx context.Context, rule store.LLMRouteRule) error {
 	const query = `
-		INSERT INTO skills (id, name, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO llm_route_rules (
+			id, name, priority, task_types, tags, policy_profiles, automation_ids, route, enabled, created_at, updated_at, workspace_id
+		) VALUES (
+			$1, $2, $3, $4::jsonb, $5::jsonb, $6::jsonb, $7::jsonb, $8, $9, $10, $11, $12
+		)
 	`
-	_, err := p.db.ExecContext(ctx, query, skill.ID, skill.Name, skill.Description, skill.CreatedAt, skill.UpdatedAt)
+	_, err := p.db.ExecContext(
+		ctx,
+		query,
+		rule.ID,
+		rule.Name,
+		rule.Priority,
+		encodeStringSlice(rule.TaskTypes),
+		encodeStringSlice(rule.Tags),
+		encodeStringSlice(rule.PolicyProfiles),
+		encodeStringSlice(rule.AutomationIDs),
+		rule.Route,
+		rule.Enabled,
+		parseTimestampValue(rule.CreatedAt),
+		parseTimestampValue(rule.UpdatedAt),
+		store.WorkspaceID(ctx),
+	)
 	return err
 }
 
-func (p *PostgresStore) UpdateSkill(ctx context.Context, skill store.Skill) error {
+func (p *PostgresStore) UpdateLLMRouteRule(ctx context.Context, rule store.LLMRouteRule) error {
 	const query = `
-		UPDATE skills
-		SET name = $1, description = $2, updated_at = $3
-		WHERE id = $4
+		UPDATE llm_route_rules
+		SET
+			name = $2,
+			priority = $3,
+			task_types = $4::jsonb,
+			tags = $5::jsonb,
+			policy_profiles = $6::jsonb,
+			automation_ids = $7::jsonb,
+			route = $8,
+			enabled = $9,
+			updated_at = $10
+		WHERE id = $1 AND workspace_id = $11
 	`
-	_, err := p.db.ExecContext(ctx, query, skill.Name, skill.Description, skill.UpdatedAt, skill.ID)
+	_, err := p.db.ExecContext(
+		ctx,
+		query,
+		rule.ID,
+		rule.Name,
+		rule.Priority,
+		encodeStringSlice(rule.TaskTypes),
+		encodeStringSlice(rule.Tags),
+		encodeStringSlice(rule.PolicyProfiles),
+		encodeStringSlice(rule.AutomationIDs),
+		rule.Route,
+		rule.Enabled,
+		parseTimestampValue(rule.UpdatedAt),
+		store.WorkspaceID(ctx),
+	)
 	return err
 }
 
-func (p *PostgresStore) DeleteSkill(ctx context.Context, skillID string) error {
-	const query = `
-		DELETE FROM skills
-		WHERE id = $1
-	`
-	_, err := p.db.ExecContext(ctx, query, skillID)
+func (p *PostgresStore) DeleteLLMRouteRule(ctx context.Context, ruleID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM llm_route_rules WHERE id = $1 AND workspace_id = $2", ruleID, store.WorkspaceID(ctx))
 	return err
 }
 
-func (p *PostgresStore) ListSkillFiles(ctx context.Context, skillID string) ([]store.SkillFile, error) {
-	const query = `
-		SELECT id, skill_id, path, content, content_type, size_bytes, created_at, updated_at
-		FROM skill_files
-		WHERE skill_id = $1
-		ORDER BY path ASC
-	`
-	rows, err := p.db.QueryContext(ctx, query, skillID)
+const llmProviderProfileColumns = `id, name, provider, model, base_url, api_key_enc, created_at, updated_at`
+
+func scanLLMProviderProfile(scanner interface{ Scan(dest ...any) error }) (store.LLMProviderProfile, error) {
+	var (
+		profile   store.LLMProviderProfile
+		createdAt time.Time
+		updatedAt time.Time
+	)
+	if err := scanner.Scan(
+		&profile.ID,
+		&profile.Name,
+		&profile.Provider,
+		&profile.Model,
+		&profile.BaseURL,
+		&profile.APIKeyEnc,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return store.LLMProviderProfile{}, err
+	}
+	profile.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	profile.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return profile, nil
+}
+
+func (p *PostgresStore) ListLLMProviderProfiles(ctx context.Context) ([]store.LLMProviderProfile, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+llmProviderProfileColumns+` FROM llm_provider_profiles WHERE workspace_id = $1 ORDER BY name ASC`, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	results := []store.SkillFile{}
+	results := make([]store.LLMProviderProfile, 0)
 	for rows.Next() {
-		var createdAt time.Time
-		var updatedAt time.Time
-		var file store.SkillFile
-		if err := rows.Scan(
-			&file.ID,
-			&file.SkillID,
-			&file.Path,
-			&file.Content,
-			&file.ContentType,
-			&file.SizeBytes,
-			&createdAt,
-			&updatedAt,
-		); err != nil {
+		profile, err := scanLLMProviderProfile(rows)
+		if err != nil {
 			return nil, err
 		}
-		file.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-		file.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-		results = append(results, file)
+		results = append(results, profile)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -473,79 +651,114 @@ func (p *PostgresStore) ListSkillFiles(ctx context.Context, skillID string) ([]s
 	return results, nil
 }
 
-func (p *PostgresStore) UpsertSkillFile(ctx context.Context, file store.SkillFile) error {
+func (p *PostgresStore) GetLLMProviderProfile(ctx context.Context, profileID string) (*store.LLMProviderProfile, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+llmProviderProfileColumns+` FROM llm_provider_profiles WHERE id = $1 AND workspace_id = $2`, profileID, store.WorkspaceID(ctx))
+	profile, err := scanLLMProviderProfile(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (p *PostgresStore) CreateLLMProviderProfile(ctx context.Context, profile store.LLMProviderProfile) error {
 	const query = `
-		INSERT INTO skill_files
-			(id, skill_id, path, content, content_type, size_bytes, created_at, updated_at)
-		VALUES
-			($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (skill_id, path)
-		DO UPDATE SET
-			content = EXCLUDED.content,
-			content_type = EXCLUDED.content_type,
-			size_bytes = EXCLUDED.size_bytes,
-			updated_at = EXCLUDED.updated_at
+		INSERT INTO llm_provider_profiles (
+			id, name, provider, model, base_url, api_key_enc, created_at, updated_at, workspace_id
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)
 	`
 	_, err := p.db.ExecContext(
 		ctx,
 		query,
-		file.ID,
-		file.SkillID,
-		file.Path,
-		file.Content,
-		file.ContentType,
-		file.SizeBytes,
-		file.CreatedAt,
-		file.UpdatedAt,
+		profile.ID,
+		profile.Name,
+		profile.Provider,
+		profile.Model,
+		profile.BaseURL,
+		profile.APIKeyEnc,
+		parseTimestampValue(profile.CreatedAt),
+		parseTimestampValue(profile.UpdatedAt),
+		store.WorkspaceID(ctx),
 	)
 	return err
 }
 
-func (p *PostgresStore) DeleteSkillFile(ctx context.Context, skillID string, path string) error {
+func (p *PostgresStore) UpdateLLMProviderProfile(ctx context.Context, profile store.LLMProviderProfile) error {
 	const query = `
-		DELETE FROM skill_files
-		WHERE skill_id = $1 AND path = $2
+		UPDATE llm_provider_profiles
+		SET
+			name = $2,
+			provider = $3,
+			model = $4,
+			base_url = $5,
+			api_key_enc = $6,
+			updated_at = $7
+		WHERE id = $1 AND workspace_id = $8
 	`
-	_, err := p.db.ExecContext(ctx, query, skillID, path)
+	_, err := p.db.ExecContext(
+		ctx,
+		query,
+		profile.ID,
+		profile.Name,
+		profile.Provider,
+		profile.Model,
+		profile.BaseURL,
+		profile.APIKeyEnc,
+		parseTimestampValue(profile.UpdatedAt),
+		store.WorkspaceID(ctx),
+	)
 	return err
 }
 
-func (p *PostgresStore) ListContextNodes(ctx context.Context) ([]store.ContextNode, error) {
-	const query = `
-		SELECT id, parent_id, name, node_type, content_type, size_bytes, created_at, updated_at
-		FROM context_nodes
-		ORDER BY name ASC
-	`
-	rows, err := p.db.QueryContext(ctx, query)
+func (p *PostgresStore) DeleteLLMProviderProfile(ctx context.Context, profileID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM llm_provider_profiles WHERE id = $1 AND workspace_id = $2", profileID, store.WorkspaceID(ctx))
+	return err
+}
+
+const secretColumns = `id, name, description, value_enc, policy_profiles, created_at, updated_at`
+
+func scanSecret(scanner interface{ Scan(dest ...any) error }) (store.Secret, error) {
+	var (
+		secret         store.Secret
+		policyProfiles []byte
+		createdAt      time.Time
+		updatedAt      time.Time
+	)
+	if err := scanner.Scan(
+		&secret.ID,
+		&secret.Name,
+		&secret.Description,
+		&secret.ValueEnc,
+		&policyProfiles,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return store.Secret{}, err
+	}
+	secret.PolicyProfiles = decodeStringSlice(policyProfiles)
+	secret.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	secret.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return secret, nil
+}
+
+func (p *PostgresStore) ListSecrets(ctx context.Context) ([]store.Secret, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+secretColumns+` FROM secrets WHERE workspace_id = $1 ORDER BY name ASC`, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	results := []store.ContextNode{}
+	results := make([]store.Secret, 0)
 	for rows.Next() {
-		var parentID sql.NullString
-		var createdAt time.Time
-		var updatedAt time.Time
-		var node store.ContextNode
-		if err := rows.Scan(
-			&node.ID,
-			&parentID,
-			&node.Name,
-			&node.NodeType,
-			&node.ContentType,
-			&node.SizeBytes,
-			&createdAt,
-			&updatedAt,
-		); err != nil {
+		secret, err := scanSecret(rows)
+		if err != nil {
 			return nil, err
 		}
-		if parentID.Valid {
-			node.ParentID = parentID.String
-		}
-		node.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-		node.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-		results = append(results, node)
+		results = append(results, secret)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -553,196 +766,281 @@ func (p *PostgresStore) ListContextNodes(ctx context.Context) ([]store.ContextNo
 	return results, nil
 }
 
-func (p *PostgresStore) GetContextFile(ctx context.Context, nodeID string) (*store.ContextNode, error) {
-	const query = `
-		SELECT id, parent_id, name, node_type, content, content_type, size_bytes, created_at, updated_at
-		FROM context_nodes
-		WHERE id = $1
-	`
-	var parentID sql.NullString
-	var createdAt time.Time
-	var updatedAt time.Time
-	node := store.ContextNode{}
-	if err := p.db.QueryRowContext(ctx, query, nodeID).Scan(
-		&node.ID,
-		&parentID,
-		&node.Name,
-		&node.NodeType,
-		&node.Content,
-		&node.ContentType,
-		&node.SizeBytes,
-		&createdAt,
-		&updatedAt,
-	); err != nil {
+func (p *PostgresStore) GetSecret(ctx context.Context, secretID string) (*store.Secret, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+secretColumns+` FROM secrets WHERE id = $1 AND workspace_id = $2`, secretID, store.WorkspaceID(ctx))
+	secret, err := scanSecret(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	if parentID.Valid {
-		node.ParentID = parentID.String
-	}
-	node.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-	node.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-	return &node, nil
+	return &secret, nil
 }
 
-func (p *PostgresStore) CreateContextFolder(ctx context.Context, node store.ContextNode) error {
+func (p *PostgresStore) CreateSecret(ctx context.Context, secret store.Secret) error {
 	const query = `
-		INSERT INTO context_nodes (id, parent_id, name, node_type, content, content_type, size_bytes, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO secrets (
+			id, name, description, value_enc, policy_profiles, created_at, updated_at, workspace_id
+		) VALUES (
+			$1, $2, $3, $4, $5::jsonb, $6, $7, $8
+		)
 	`
-	var parentID any
-	if node.ParentID == "" {
-		parentID = nil
-	} else {
-		parentID = node.ParentID
-	}
 	_, err := p.db.ExecContext(
 		ctx,
 		query,
-		node.ID,
-		parentID,
-		node.Name,
-		node.NodeType,
-		node.Content,
-		node.ContentType,
-		node.SizeBytes,
-		node.CreatedAt,
-		node.UpdatedAt,
+		secret.ID,
+		secret.Name,
+		secret.Description,
+		secret.ValueEnc,
+		encodeStringSlice(secret.PolicyProfiles),
+		parseTimestampValue(secret.CreatedAt),
+		parseTimestampValue(secret.UpdatedAt),
+		store.WorkspaceID(ctx),
 	)
 	return err
 }
 
-func (p *PostgresStore) CreateContextFile(ctx context.Context, node store.ContextNode) error {
-	return p.CreateContextFolder(ctx, node)
-}
-
-func (p *PostgresStore) DeleteContextNode(ctx context.Context, nodeID string) error {
+func (p *PostgresStore) UpdateSecret(ctx context.Context, secret store.Secret) error {
 	const query = `
-		DELETE FROM context_nodes
-		WHERE id = $1
+		UPDATE secrets
+		SET
+			name = $2,
+			description = $3,
+			value_enc = $4,
+			policy_profiles = $5::jsonb,
+			updated_at = $6
+		WHERE id = $1 AND workspace_id = $7
 	`
-	_, err := p.db.ExecContext(ctx, query, nodeID)
+	_, err := p.db.ExecContext(
+		ctx,
+		query,
+		secret.ID,
+		secret.Name,
+		secret.Description,
+		secret.ValueEnc,
+		encodeStringSlice(secret.PolicyProfiles),
+		parseTimestampValue(secret.UpdatedAt),
+		store.WorkspaceID(ctx),
+	)
 	return err
 }
 
-func (p *PostgresStore) GetMemorySettings(ctx context.Context) (*store.MemorySettings, error) {
-	const query = `
-		SELECT enabled, created_at, updated_at
-		FROM memory_settings
-		WHERE id = 1
-	`
-	var createdAt time.Time
-	var updatedAt time.Time
-	settings := store.MemorySettings{}
-	if err := p.db.QueryRowContext(ctx, query).Scan(&settings.Enabled, &createdAt, &updatedAt); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+func (p *PostgresStore) DeleteSecret(ctx context.Context, secretID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM secrets WHERE id = $1 AND workspace_id = $2", secretID, store.WorkspaceID(ctx))
+	return err
+}
+
+type encryptedSecretRow struct {
+	table      string
+	id         string
+	ciphertext string
+}
+
+// RotateSecrets re-encrypts every stored API key of the workspace in ctx inside
+// one transaction. Rows the rotate function cannot decrypt are reported and
+// left unchanged.
+func (p *PostgresStore) RotateSecrets(ctx context.Context, rotate store.SecretRotateFunc) (report store.SecretRotationReport, err error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return report, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
 		}
-		return nil, err
+	}()
+
+	var secretRows []encryptedSecretRow
+	sources := []struct {
+		table string
+		query string
+	}{
+		{"llm_settings", "SELECT workspace_id, api_key_enc FROM llm_settings WHERE workspace_id = $1 AND api_key_enc <> '' ORDER BY workspace_id FOR UPDATE"},
+		{"llm_provider_profiles", "SELECT id::text, api_key_enc FROM llm_provider_profiles WHERE workspace_id = $1 AND api_key_enc <> '' ORDER BY id FOR UPDATE"},
+		{"secrets", "SELECT id::text, value_enc FROM secrets WHERE workspace_id = $1 AND value_enc <> '' ORDER BY id FOR UPDATE"},
+		{"webhook_subscriptions", "SELECT id::text, secret_enc FROM webhook_subscriptions WHERE workspace_id = $1 AND secret_enc <> '' ORDER BY id FOR UPDATE"},
+		{"notification_channels", "SELECT id::text, secrets_enc FROM notification_channels WHERE workspace_id = $1 AND secrets_enc <> '' ORDER BY id FOR UPDATE"},
+		{"automations", "SELECT id::text, trigger_secret_enc FROM automations WHERE workspace_id = $1 AND trigger_secret_enc <> '' ORDER BY id FOR UPDATE"},
+	}
+	for _, source := range sources {
+		rows, queryErr := tx.QueryContext(ctx, source.query, store.WorkspaceID(ctx))
+		if queryErr != nil {
+			err = queryErr
+			return report, err
+		}
+		for rows.Next() {
+			row := encryptedSecretRow{table: source.table}
+			if err = rows.Scan(&row.id, &row.ciphertext); err != nil {
+				rows.Close()
+				return report, err
+			}
+			secretRows = append(secretRows, row)
+		}
+		if err = rows.Err(); err != nil {
+			rows.Close()
+			return report, err
+		}
+		rows.Close()
 	}
-	settings.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-	settings.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-	return &settings, nil
-}
 
-func (p *PostgresStore) UpsertMemorySettings(ctx context.Context, settings store.MemorySettings) error {
-	const query = `
-		INSERT INTO memory_settings (id, enabled, created_at, updated_at)
-		VALUES (1, $1, $2, $3)
-		ON CONFLICT (id)
-		DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at
-	`
-	_, err := p.db.ExecContext(ctx, query, settings.Enabled, settings.CreatedAt, settings.UpdatedAt)
-	return err
+	for _, row := range secretRows {
+		rotated, changed, rotateErr := rotate(row.ciphertext)
+		if rotateErr != nil {
+			report.Failed = append(report.Failed, store.SecretRotationFailure{Table: row.table, ID: row.id, Error: rotateErr.Error()})
+			continue
+		}
+		if !changed {
+			report.Unchanged++
+			continue
+		}
+		column := "api_key_enc"
+		switch row.table {
+		case "secrets":
+			column = "value_enc"
+		case "webhook_subscriptions":
+			column = "secret_enc"
+		case "notification_channels":
+			column = "secrets_enc"
+		case "automations":
+			column = "trigger_secret_enc"
+		}
+		keyColumn := "id::text"
+		if row.table == "llm_settings" {
+			keyColumn = "workspace_id"
+		}
+		query := "UPDATE " + row.table + " SET " + column + " = $1 WHERE " + keyColumn + " = $2"
+		if _, err = tx.ExecContext(ctx, query, rotated, row.id); err != nil {
+			return report, err
+		}
+		report.Rotated++
+	}
+	err = tx.Commit()
+	return report, err
 }
 
-func (p *PostgresStore) UpsertMemoryEntry(ctx context.Context, entry store.MemoryEntry) (bool, error) {
-	metadata := entry.Metadata
-	if metadata == nil {
-		metadata = map[string]any{}
+const apiKeyColumns = `id, name, key_prefix, key_hash, scopes, workspace_id, COALESCE(user_id::text, ''), created_at, last_used_at`
+
+func scanAPIKey(scanner interface{ Scan(dest ...any) error }) (store.APIKey, error) {
+	var (
+		key        store.APIKey
+		scopes     []byte
+		createdAt  time.Time
+		lastUsedAt sql.NullTime
+	)
+	if err := scanner.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&scopes,
+		&key.WorkspaceID,
+		&key.UserID,
+		&createdAt,
+		&lastUsedAt,
+	); err != nil {
+		return store.APIKey{}, err
 	}
-	encoded, err := json.Marshal(metadata)
+	key.Scopes = decodeStringSlice(scopes)
+	key.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = lastUsedAt.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return key, nil
+}
+
+func (p *PostgresStore) ListAPIKeys(ctx context.Context) ([]store.APIKey, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE workspace_id = $1 ORDER BY created_at ASC, name ASC`, store.WorkspaceID(ctx))
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	fingerprint := readMetadataString(metadata, "fingerprint")
-	if fingerprint == "" {
-		return p.insertMemoryEntry(ctx, entry, encoded)
+	defer rows.Close()
+
+	results := make([]store.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, key)
 	}
-	return p.insertMemoryEntryWithFingerprint(ctx, entry, encoded, fingerprint)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
-func (p *PostgresStore) GetPersonalitySettings(ctx context.Context) (*store.PersonalitySettings, error) {
-	const query = `
-		SELECT content, created_at, updated_at
-		FROM personality_settings
-		WHERE id = 1
-	`
-	settings := store.PersonalitySettings{}
-	var createdAt time.Time
-	var updatedAt time.Time
-	if err := p.db.QueryRowContext(ctx, query).Scan(&settings.Content, &createdAt, &updatedAt); err != nil {
+func (p *PostgresStore) GetAPIKey(ctx context.Context, keyID string) (*store.APIKey, error) {
+	return p.getAPIKey(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 AND workspace_id = $2`, keyID, store.WorkspaceID(ctx))
+}
+
+func (p *PostgresStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*store.APIKey, error) {
+	return p.getAPIKey(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash)
+}
+
+func (p *PostgresStore) getAPIKey(ctx context.Context, query string, args ...any) (*store.APIKey, error) {
+	key, err := scanAPIKey(p.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	settings.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-	settings.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-	return &settings, nil
+	return &key, nil
 }
 
-func (p *PostgresStore) UpsertPersonalitySettings(ctx context.Context, settings store.PersonalitySettings) error {
+func (p *PostgresStore) CreateAPIKey(ctx context.Context, key store.APIKey) error {
+	workspaceID := strings.TrimSpace(key.WorkspaceID)
+	if workspaceID == "" {
+		workspaceID = store.DefaultWorkspaceID
+	}
 	const query = `
-		INSERT INTO personality_settings (id, content, created_at, updated_at)
-		VALUES (1, $1, $2, $3)
-		ON CONFLICT (id)
-		DO UPDATE SET content = EXCLUDED.content, updated_at = EXCLUDED.updated_at
+		INSERT INTO api_keys (
+			id, name, key_prefix, key_hash, scopes, workspace_id, user_id, created_at, last_used_at
+		) VALUES (
+			$1, $2, $3, $4, $5::jsonb, $6, $7, $8, $9
+		)
 	`
-	_, err := p.db.ExecContext(ctx, query, settings.Content, settings.CreatedAt, settings.UpdatedAt)
+	_, err := p.db.ExecContext(
+		ctx,
+		query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		encodeStringSlice(key.Scopes),
+		workspaceID,
+		nullString(key.UserID),
+		parseTimestampValue(key.CreatedAt),
+		parseTimestampNull(key.LastUsedAt),
+	)
 	return err
 }
 
-func (p *PostgresStore) SearchMemory(ctx context.Context, query string, limit int) ([]store.MemoryEntry, error) {
-	if strings.TrimSpace(query) == "" || limit <= 0 {
-		return []store.MemoryEntry{}, nil
-	}
-	const sqlQuery = `
-		SELECT id, content, metadata, created_at, updated_at
-		FROM memory_entries
-		WHERE tsv @@ plainto_tsquery('english', $1)
-		ORDER BY ts_rank_cd(tsv, plainto_tsquery('english', $1)) DESC, updated_at DESC
-		LIMIT $2
-	`
-	rows, err := p.db.QueryContext(ctx, sqlQuery, query, limit)
+func (p *PostgresStore) TouchAPIKey(ctx context.Context, keyID string, usedAt string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", keyID, parseTimestampValue(usedAt))
+	return err
+}
+
+func (p *PostgresStore) DeleteAPIKey(ctx context.Context, keyID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1 AND workspace_id = $2", keyID, store.WorkspaceID(ctx))
+	return err
+}
+
+func (p *PostgresStore) ListWorkspaces(ctx context.Context) ([]store.Workspace, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, name, created_at, updated_at FROM workspaces ORDER BY created_at ASC, id ASC`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	results := []store.MemoryEntry{}
+	results := make([]store.Workspace, 0)
 	for rows.Next() {
-		var createdAt time.Time
-		var updatedAt time.Time
-		var metadataBytes []byte
-		var entry store.MemoryEntry
-		if err := rows.Scan(&entry.ID, &entry.Content, &metadataBytes, &createdAt, &updatedAt); err != nil {
+		workspace, err := scanWorkspace(rows)
+		if err != nil {
 			return nil, err
 		}
-		entry.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-		entry.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-		if len(metadataBytes) > 0 {
-			metadata := map[string]any{}
-			if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
-				return nil, err
-			}
-			entry.Metadata = metadata
-		} else {
-			entry.Metadata = map[string]any{}
-		}
-		results = append(results, entry)
+		results = append(results, workspace)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -750,592 +1048,2312 @@ func (p *PostgresStore) SearchMemory(ctx context.Context, query string, limit in
 	return results, nil
 }
 
-func (p *PostgresStore) SearchMemoryWithEmbedding(ctx context.Context, query string, embedding []float32, limit int) ([]store.MemoryEntry, error) {
-	if limit <= 0 {
-		return []store.MemoryEntry{}, nil
+func scanWorkspace(scanner interface{ Scan(dest ...any) error }) (store.Workspace, error) {
+	var (
+		workspace store.Workspace
+		createdAt time.Time
+		updatedAt time.Time
+	)
+	if err := scanner.Scan(&workspace.ID, &workspace.Name, &createdAt, &updatedAt); err != nil {
+		return store.Workspace{}, err
 	}
-	if len(embedding) == 0 || len(embedding) != memoryEmbeddingDimensions {
-		return p.SearchMemory(ctx, query, limit)
+	workspace.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	workspace.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return workspace, nil
+}
+
+func (p *PostgresStore) GetWorkspace(ctx context.Context, workspaceID string) (*store.Workspace, error) {
+	workspace, err := scanWorkspace(p.db.QueryRowContext(ctx, `SELECT id, name, created_at, updated_at FROM workspaces WHERE id = $1`, workspaceID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
 	}
-	vector := formatVector(embedding)
-	const sqlQuery = `
-		SELECT id, content, metadata, created_at, updated_at
-		FROM memory_entries
-		WHERE embedding IS NOT NULL
-		ORDER BY embedding <=> $1::vector
-		LIMIT $2
-	`
-	rows, err := p.db.QueryContext(ctx, sqlQuery, vector, limit)
+	return &workspace, nil
+}
+
+func (p *PostgresStore) CreateWorkspace(ctx context.Context, workspace store.Workspace) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO workspaces (id, name, created_at, updated_at) VALUES ($1, $2, $3, $4)`,
+		workspace.ID,
+		workspace.Name,
+		parseTimestampValue(workspace.CreatedAt),
+		parseTimestampValue(workspace.UpdatedAt),
+	)
+	return err
+}
+
+func (p *PostgresStore) DeleteWorkspace(ctx context.Context, workspaceID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM workspaces WHERE id = $1", workspaceID)
+	return err
+}
+
+func scanUser(scanner interface{ Scan(dest ...any) error }) (store.User, error) {
+	var (
+		user      store.User
+		email     sql.NullString
+		createdAt time.Time
+	)
+	if err := scanner.Scan(&user.ID, &user.Name, &email, &createdAt); err != nil {
+		return store.User{}, err
+	}
+	user.Email = email.String
+	user.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	return user, nil
+}
+
+func (p *PostgresStore) ListUsers(ctx context.Context) ([]store.User, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, name, email, created_at FROM users ORDER BY created_at ASC, name ASC`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	results := []store.MemoryEntry{}
+	results := make([]store.User, 0)
 	for rows.Next() {
-		var createdAt time.Time
-		var updatedAt time.Time
-		var metadataBytes []byte
-		var entry store.MemoryEntry
-		if err := rows.Scan(&entry.ID, &entry.Content, &metadataBytes, &createdAt, &updatedAt); err != nil {
+		user, err := scanUser(rows)
+		if err != nil {
 			return nil, err
 		}
-		entry.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-		entry.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-		if len(metadataBytes) > 0 {
-			metadata := map[string]any{}
-			if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
-				return nil, err
-			}
-			entry.Metadata = metadata
-		} else {
-			entry.Metadata = map[string]any{}
-		}
-		results = append(results, entry)
+		results = append(results, user)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	if len(results) == 0 {
-		return p.SearchMemory(ctx, query, limit)
-	}
 	return results, nil
 }
 
-func (p *PostgresStore) AppendEvent(ctx context.Context, event store.RunEvent) error {
-	event.Type = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(event.Type)), "_", ".")
-	payload := event.Payload
-	if payload == nil {
-		payload = map[string]any{}
+func (p *PostgresStore) GetUser(ctx context.Context, userID string) (*store.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, nil
 	}
-	encoded, err := json.Marshal(payload)
+	user, err := scanUser(p.db.QueryRowContext(ctx, `SELECT id, name, email, created_at FROM users WHERE id = $1`, userID))
 	if err != nil {
-		return err
-	}
-	timestamp := event.Timestamp
-	if timestamp == "" {
-		timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
 	}
-	timestampValue := parseTimestampValue(timestamp)
-	traceID := strings.TrimSpace(event.TraceID)
-	var traceIDValue any
-	if traceID == "" {
-		traceIDValue = nil
-	} else if _, err := uuid.Parse(traceID); err != nil {
-		traceIDValue = nil
-	} else {
-		traceIDValue = traceID
+	return &user, nil
+}
+
+func (p *PostgresStore) CreateUser(ctx context.Context, user store.User) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO users (id, name, email, created_at) VALUES ($1, $2, $3, $4)`,
+		user.ID,
+		user.Name,
+		nullString(user.Email),
+		parseTimestampValue(user.CreatedAt),
+	)
+	return err
+}
+
+func (p *PostgresStore) DeleteUser(ctx context.Context, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil
 	}
-	const query = `
-		INSERT INTO run_events (run_id, seq, type, timestamp, source, trace_id, payload)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
-	tx, err := p.db.BeginTx(ctx, nil)
+	_, err := p.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", userID)
+	return err
+}
+
+func (p *PostgresStore) ListWorkspaceMembers(ctx context.Context, workspaceID string) ([]store.WorkspaceMember, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT workspace_id, user_id::text, role, created_at
+		FROM workspace_members
+		WHERE workspace_id = $1
+		ORDER BY created_at ASC
+	`, workspaceID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-		}
-	}()
+	defer rows.Close()
 
-	if _, err = tx.ExecContext(ctx, query, event.RunID, event.Seq, event.Type, timestampValue, event.Source, traceIDValue, encoded); err != nil {
-		return err
-	}
-	if step, ok := store.BuildRunStepFromEvent(event); ok {
-		if err = upsertRunStepTx(ctx, tx, step); err != nil {
-			return err
+	results := make([]store.WorkspaceMember, 0)
+	for rows.Next() {
+		var (
+			member    store.WorkspaceMember
+			createdAt time.Time
+		)
+		if err := rows.Scan(&member.WorkspaceID, &member.UserID, &member.Role, &createdAt); err != nil {
+			return nil, err
 		}
+		member.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		results = append(results, member)
 	}
-	if err = applyRunStateUpdateTx(ctx, tx, event); err != nil {
-		return err
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	err = tx.Commit()
-	return err
+	return results, nil
 }
 
-func (p *PostgresStore) ListEvents(ctx context.Context, runID string, afterSeq int64) ([]store.RunEvent, error) {
+func (p *PostgresStore) UpsertWorkspaceMember(ctx context.Context, member store.WorkspaceMember) error {
 	const query = `
-		SELECT run_id, seq, type, timestamp, source, trace_id, payload
-		FROM run_events
-		WHERE run_id = $1 AND seq > $2
-		ORDER BY seq ASC
+		INSERT INTO workspace_members (workspace_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
 	`
-	rows, err := p.db.QueryContext(ctx, query, runID, afterSeq)
-	if err != nil {
+	_, err := p.db.ExecContext(ctx, query, member.WorkspaceID, member.UserID, member.Role, parseTimestampValue(member.CreatedAt))
+	return err
+}
+
+func (p *PostgresStore) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil
+	}
+	_, err := p.db.ExecContext(ctx, "DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2", workspaceID, userID)
+	return err
+}
+
+type redactionMarkerJSON struct {
+	Detector string `json:"detector"`
+	Label    string `json:"label"`
+	Path     string `json:"path"`
+	Count    int    `json:"count"`
+}
+
+func encodeRedactionMarkers(markers []store.RedactionMarker) ([]byte, error) {
+	encoded := make([]redactionMarkerJSON, 0, len(markers))
+	for _, marker := range markers {
+		encoded = append(encoded, redactionMarkerJSON(marker))
+	}
+	return json.Marshal(encoded)
+}
+
+func decodeRedactionMarkers(raw []byte) ([]store.RedactionMarker, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	decoded := []redactionMarkerJSON{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	if len(decoded) == 0 {
+		return nil, nil
+	}
+	markers := make([]store.RedactionMarker, 0, len(decoded))
+	for _, marker := range decoded {
+		markers = append(markers, store.RedactionMarker(marker))
+	}
+	return markers, nil
+}
 
-	results := []store.RunEvent{}
-	for rows.Next() {
-		var payloadBytes []byte
-		var timestamp time.Time
-		var traceID sql.NullString
-		var event store.RunEvent
-		if err := rows.Scan(&event.RunID, &event.Seq, &event.Type, &timestamp, &event.Source, &traceID, &payloadBytes); err != nil {
-			return nil, err
-		}
-		event.Timestamp = timestamp.UTC().Format(time.RFC3339Nano)
-		if traceID.Valid {
-			event.TraceID = traceID.String
-		}
-		if len(payloadBytes) > 0 {
-			payload := map[string]any{}
-			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
-				return nil, err
-			}
-			event.Payload = payload
-		} else {
-			event.Payload = map[string]any{}
+type redactionRuleJSON struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+const redactionPolicyColumns = `policy_profile, vault_secrets, key_formats, emails, custom_rules, created_at, updated_at`
+
+func scanRedactionPolicy(scanner interface{ Scan(dest ...any) error }) (store.RedactionPolicy, error) {
+	var (
+		policy    store.RedactionPolicy
+		rulesRaw  []byte
+		createdAt time.Time
+		updatedAt time.Time
+	)
+	if err := scanner.Scan(
+		&policy.PolicyProfile,
+		&policy.VaultSecrets,
+		&policy.KeyFormats,
+		&policy.Emails,
+		&rulesRaw,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return store.RedactionPolicy{}, err
+	}
+	rules := []redactionRuleJSON{}
+	if len(rulesRaw) > 0 {
+		if err := json.Unmarshal(rulesRaw, &rules); err != nil {
+			return store.RedactionPolicy{}, err
 		}
-		results = append(results, event)
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	for _, rule := range rules {
+		policy.CustomRules = append(policy.CustomRules, store.RedactionRule(rule))
 	}
-	return results, nil
+	policy.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	policy.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return policy, nil
 }
 
-func (p *PostgresStore) ListRunSteps(ctx context.Context, runID string) ([]store.RunStep, error) {
-	const query = `
-		SELECT run_id,
-			step_id,
-			parent_step_id,
-			name,
-			status,
-			plan_id,
-			attempt,
-			policy_decision,
-			dependencies,
-			expected_artifacts,
-			diagnostics,
-			started_at,
-			completed_at
-		FROM run_steps
-		WHERE run_id = $1
-		ORDER BY COALESCE((diagnostics->>'seq')::bigint, 9223372036854775807), created_at ASC
-	`
-	rows, err := p.db.QueryContext(ctx, query, runID)
+func (p *PostgresStore) ListRedactionPolicies(ctx context.Context) ([]store.RedactionPolicy, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+redactionPolicyColumns+` FROM redaction_policies WHERE workspace_id = $1 ORDER BY policy_profile ASC`, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	steps := []store.RunStep{}
+	results := make([]store.RedactionPolicy, 0)
 	for rows.Next() {
-		var (
-			step              store.RunStep
-			parentStepID      sql.NullString
-			name              sql.NullString
-			planID            sql.NullString
-			policyDecision    sql.NullString
-			dependenciesBytes []byte
-			expectedBytes     []byte
-			diagnosticsBytes  []byte
-			startedAt         sql.NullTime
-			completedAt       sql.NullTime
-		)
-		if err := rows.Scan(
-			&step.RunID,
-			&step.ID,
-			&parentStepID,
-			&name,
-			&step.Status,
-			&planID,
-			&step.Attempt,
-			&policyDecision,
-			&dependenciesBytes,
-			&expectedBytes,
-			&diagnosticsBytes,
-			&startedAt,
-			&completedAt,
-		); err != nil {
+		policy, err := scanRedactionPolicy(rows)
+		if err != nil {
 			return nil, err
 		}
-		if parentStepID.Valid {
-			step.ParentStepID = parentStepID.String
-		}
-		if name.Valid {
-			step.Name = name.String
-		}
-		if planID.Valid {
-			step.PlanID = planID.String
-		}
-		if policyDecision.Valid {
-			step.PolicyDecision = policyDecision.String
-		}
-		if startedAt.Valid {
-			step.StartedAt = startedAt.Time.UTC().Format(time.RFC3339Nano)
-		}
-		if completedAt.Valid {
-			step.CompletedAt = completedAt.Time.UTC().Format(time.RFC3339Nano)
-		}
-		step.Dependencies = decodeStringSlice(dependenciesBytes)
-		step.ExpectedArtifacts = decodeStringSlice(expectedBytes)
-		step.Diagnostics = decodeJSONMap(diagnosticsBytes)
-		step.Kind = readDiagString(step.Diagnostics, "kind")
-		if step.Kind == "" {
-			step.Kind = "step"
-		}
-		step.Source = readDiagString(step.Diagnostics, "source")
-		step.Seq = readDiagInt64(step.Diagnostics, "seq")
-		step.Error = readDiagString(step.Diagnostics, "error")
-		if step.Name == "" {
-			step.Name = step.ID
-		}
-		steps = append(steps, step)
+		results = append(results, policy)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return steps, nil
+	return results, nil
 }
 
-func (p *PostgresStore) UpsertRunProcess(ctx context.Context, process store.RunProcess) error {
-	if strings.TrimSpace(process.RunID) == "" {
-		return fmt.Errorf("run id required")
-	}
-	if strings.TrimSpace(process.ProcessID) == "" {
-		return fmt.Errorf("process id required")
-	}
-	if strings.TrimSpace(process.Command) == "" {
-		process.Command = "process"
-	}
-	if strings.TrimSpace(process.Status) == "" {
-		process.Status = "running"
-	}
-	argsBytes, err := json.Marshal(process.Args)
-	if err != nil {
-		return err
-	}
-	previewBytes, err := json.Marshal(process.PreviewURLs)
+func (p *PostgresStore) GetRedactionPolicy(ctx context.Context, policyProfile string) (*store.RedactionPolicy, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+redactionPolicyColumns+` FROM redaction_policies WHERE policy_profile = $1 AND workspace_id = $2`, policyProfile, store.WorkspaceID(ctx))
+	policy, err := scanRedactionPolicy(row)
 	if err != nil {
-		return err
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
 	}
-	metadata := process.Metadata
-	if metadata == nil {
-		metadata = map[string]any{}
+	return &policy, nil
+}
+
+func (p *PostgresStore) UpsertRedactionPolicy(ctx context.Context, policy store.RedactionPolicy) error {
+	rules := make([]redactionRuleJSON, 0, len(policy.CustomRules))
+	for _, rule := range policy.CustomRules {
+		rules = append(rules, redactionRuleJSON(rule))
 	}
-	metadataBytes, err := json.Marshal(metadata)
+	encodedRules, err := json.Marshal(rules)
 	if err != nil {
 		return err
 	}
-	query := `
-		INSERT INTO run_processes (
-			run_id,
-			process_id,
-			command,
-			args,
-			cwd,
-			status,
-			pid,
-			started_at,
-			ended_at,
-			exit_code,
-			signal,
-			preview_urls,
-			metadata
-		) VALUES ($1, $2, $3, $4::jsonb, $5, $6, $7, $8, $9, $10, $11, $12::jsonb, $13::jsonb)
-		ON CONFLICT (run_id, process_id)
+	const query = `
+		INSERT INTO redaction_policies (
+			policy_profile, vault_secrets, key_formats, emails, custom_rules, created_at, updated_at, workspace_id
+		) VALUES (
+			$1, $2, $3, $4, $5::jsonb, $6, $7, $8
+		)
+		ON CONFLICT (workspace_id, policy_profile)
 		DO UPDATE SET
-			command = COALESCE(NULLIF(EXCLUDED.command, ''), run_processes.command),
-			args = CASE
-				WHEN jsonb_typeof(EXCLUDED.args) = 'array' AND jsonb_array_length(EXCLUDED.args) > 0 THEN EXCLUDED.args
-				ELSE run_processes.args
-			END,
-			cwd = COALESCE(NULLIF(EXCLUDED.cwd, ''), run_processes.cwd),
-			status = COALESCE(NULLIF(EXCLUDED.status, ''), run_processes.status),
-			pid = CASE WHEN EXCLUDED.pid > 0 THEN EXCLUDED.pid ELSE run_processes.pid END,
-			started_at = COALESCE(run_processes.started_at, EXCLUDED.started_at),
-			ended_at = COALESCE(EXCLUDED.ended_at, run_processes.ended_at),
-			exit_code = COALESCE(EXCLUDED.exit_code, run_processes.exit_code),
-			signal = COALESCE(NULLIF(EXCLUDED.signal, ''), run_processes.signal),
-			preview_urls = CASE
-				WHEN jsonb_typeof(EXCLUDED.preview_urls) = 'array' AND jsonb_array_length(EXCLUDED.preview_urls) > 0
-					THEN EXCLUDED.preview_urls
-				ELSE run_processes.preview_urls
-			END,
-			metadata = run_processes.metadata || EXCLUDED.metadata
+			vault_secrets = EXCLUDED.vault_secrets,
+			key_formats = EXCLUDED.key_formats,
+			emails = EXCLUDED.emails,
+			custom_rules = EXCLUDED.custom_rules,
+			updated_at = EXCLUDED.updated_at
 	`
 	_, err = p.db.ExecContext(
 		ctx,
 		query,
-		process.RunID,
-		process.ProcessID,
-		process.Command,
-		argsBytes,
-		nullString(process.Cwd),
-		process.Status,
-		process.PID,
-		parseTimestampNull(process.StartedAt),
-		parseTimestampNull(process.EndedAt),
-		intPtrValue(process.ExitCode),
-		nullString(process.Signal),
-		previewBytes,
-		metadataBytes,
+		policy.PolicyProfile,
+		policy.VaultSecrets,
+		policy.KeyFormats,
+		policy.Emails,
+		encodedRules,
+		parseTimestampValue(policy.CreatedAt),
+		parseTimestampValue(policy.UpdatedAt),
+		store.WorkspaceID(ctx),
 	)
 	return err
 }
 
-func (p *PostgresStore) GetRunProcess(ctx context.Context, runID string, processID string) (*store.RunProcess, error) {
+func (p *PostgresStore) DeleteRedactionPolicy(ctx context.Context, policyProfile string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM redaction_policies WHERE policy_profile = $1 AND workspace_id = $2", policyProfile, store.WorkspaceID(ctx))
+	return err
+}
+
+func (p *PostgresStore) ListSkills(ctx context.Context) ([]store.Skill, error) {
 	const query = `
-		SELECT run_id,
-			process_id,
-			command,
-			args,
-			cwd,
-			status,
-			pid,
-			started_at,
-			ended_at,
-			exit_code,
-			signal,
-			preview_urls,
-			metadata
-		FROM run_processes
-		WHERE run_id = $1 AND process_id = $2
+		SELECT id, name, description, created_at, updated_at
+		FROM skills
+		WHERE workspace_id = $1
+		ORDER BY name ASC
 	`
-	processes, err := p.queryRunProcesses(ctx, query, runID, processID)
+	rows, err := p.db.QueryContext(ctx, query, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
-	if len(processes) == 0 {
-		return nil, nil
+	defer rows.Close()
+
+	results := []store.Skill{}
+	for rows.Next() {
+		var createdAt time.Time
+		var updatedAt time.Time
+		var skill store.Skill
+		if err := rows.Scan(&skill.ID, &skill.Name, &skill.Description, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		skill.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		skill.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+		results = append(results, skill)
 	}
-	return &processes[0], nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
 }
 
-func (p *PostgresStore) ListRunProcesses(ctx context.Context, runID string) ([]store.RunProcess, error) {
+func (p *PostgresStore) GetSkill(ctx context.Context, skillID string) (*store.Skill, error) {
 	const query = `
-		SELECT run_id,
-			process_id,
-			command,
-			args,
-			cwd,
-			status,
-			pid,
-			started_at,
-			ended_at,
-			exit_code,
-			signal,
-			preview_urls,
-			metadata
-		FROM run_processes
-		WHERE run_id = $1
-		ORDER BY started_at DESC, process_id ASC
+		SELECT id, name, description, created_at, updated_at
+		FROM skills
+		WHERE id = $1 AND workspace_id = $2
+	`
+	var createdAt time.Time
+	var updatedAt time.Time
+	skill := store.Skill{}
+	if err := p.db.QueryRowContext(ctx, query, skillID, store.WorkspaceID(ctx)).Scan(
+		&skill.ID,
+		&skill.Name,
+		&skill.Description,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	skill.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	skill.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return &skill, nil
+}
+
+func (p *PostgresStore) CreateSkill(ctx context.Context, skill store.Skill) error {
+	const query = `
+		INSERT INTO skills (id, workspace_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
-	return p.queryRunProcesses(ctx, query, runID)
+	_, err := p.db.ExecContext(ctx, query, skill.ID, store.WorkspaceID(ctx), skill.Name, skill.Description, skill.CreatedAt, skill.UpdatedAt)
+	return err
 }
 
-func (p *PostgresStore) NextSeq(ctx context.Context, runID string) (int64, error) {
+func (p *PostgresStore) UpdateSkill(ctx context.Context, skill store.Skill) error {
 	const query = `
-		INSERT INTO run_event_sequences (run_id, last_seq)
-		VALUES ($1, 1)
-		ON CONFLICT (run_id)
-		DO UPDATE SET last_seq = run_event_sequences.last_seq + 1
-		RETURNING last_seq
+		UPDATE skills
+		SET name = $1, description = $2, updated_at = $3
+		WHERE id = $4 AND workspace_id = $5
 	`
-	var seq int64
-	if err := p.db.QueryRowContext(ctx, query, runID).Scan(&seq); err != nil {
-		return 0, err
-	}
-	return seq, nil
+	_, err := p.db.ExecContext(ctx, query, skill.Name, skill.Description, skill.UpdatedAt, skill.ID, store.WorkspaceID(ctx))
+	return err
 }
 
-func (p *PostgresStore) UpsertArtifact(ctx context.Context, artifact store.Artifact) error {
-	metadata := artifact.Metadata
-	if metadata == nil {
-		metadata = map[string]any{}
-	}
-	encoded, err := json.Marshal(metadata)
+func (p *PostgresStore) DeleteSkill(ctx context.Context, skillID string) error {
+	const query = `
+		DELETE FROM skills
+		WHERE id = $1 AND workspace_id = $2
+	`
+	_, err := p.db.ExecContext(ctx, query, skillID, store.WorkspaceID(ctx))
+	return err
+}
+
+func (p *PostgresStore) ListSkillFiles(ctx context.Context, skillID string) ([]store.SkillFile, error) {
+	const query = `
+		SELECT id, skill_id, path, content, content_type, size_bytes, created_at, updated_at
+		FROM skill_files
+		WHERE skill_id = $1 AND skill_id IN (SELECT id FROM skills WHERE workspace_id = $2)
+		ORDER BY path ASC
+	`
+	rows, err := p.db.QueryContext(ctx, query, skillID, store.WorkspaceID(ctx))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	labelsBytes, err := json.Marshal(artifact.Labels)
-	if err != nil {
-		return err
+	defer rows.Close()
+
+	results := []store.SkillFile{}
+	for rows.Next() {
+		var createdAt time.Time
+		var updatedAt time.Time
+		var file store.SkillFile
+		if err := rows.Scan(
+			&file.ID,
+			&file.SkillID,
+			&file.Path,
+			&file.Content,
+			&file.ContentType,
+			&file.SizeBytes,
+			&createdAt,
+			&updatedAt,
+		); err != nil {
+			return nil, err
+		}
+		file.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		file.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+		results = append(results, file)
 	}
-	category := strings.TrimSpace(artifact.Category)
-	if category == "" {
-		category = "generic"
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	retentionClass := strings.TrimSpace(artifact.RetentionClass)
-	if retentionClass == "" {
-		retentionClass = "default"
+	return results, nil
+}
+
+func (p *PostgresStore) UpsertSkillFile(ctx context.Context, file store.SkillFile) error {
+	if err := requireInWorkspace(ctx, p.db, "skills", file.SkillID); err != nil {
+		return err
 	}
 	const query = `
-		INSERT INTO artifacts (
-			id,
-			run_id,
-			type,
-			category,
-			uri,
-			content_type,
-			size_bytes,
-			checksum,
-			labels,
-			searchable_text,
-			retention_class,
-			created_at,
-			metadata
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::jsonb, $10, $11, $12, $13)
-		ON CONFLICT (id)
+		INSERT INTO skill_files
+			(id, skill_id, path, content, content_type, size_bytes, created_at, updated_at)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (skill_id, path)
 		DO UPDATE SET
-			type = EXCLUDED.type,
-			category = EXCLUDED.category,
-			uri = EXCLUDED.uri,
+			content = EXCLUDED.content,
 			content_type = EXCLUDED.content_type,
 			size_bytes = EXCLUDED.size_bytes,
-			checksum = EXCLUDED.checksum,
-			labels = EXCLUDED.labels,
-			searchable_text = EXCLUDED.searchable_text,
-			retention_class = EXCLUDED.retention_class,
-			metadata = EXCLUDED.metadata,
-			created_at = EXCLUDED.created_at
+			updated_at = EXCLUDED.updated_at
 	`
-	_, err = p.db.ExecContext(
+	_, err := p.db.ExecContext(
 		ctx,
 		query,
-		artifact.ID,
-		artifact.RunID,
-		artifact.Type,
-		category,
-		artifact.URI,
-		artifact.ContentType,
-		artifact.SizeBytes,
-		nullString(artifact.Checksum),
-		labelsBytes,
-		nullString(artifact.SearchableText),
-		retentionClass,
-		artifact.CreatedAt,
-		encoded,
+		file.ID,
+		file.SkillID,
+		file.Path,
+		file.Content,
+		file.ContentType,
+		file.SizeBytes,
+		file.CreatedAt,
+		file.UpdatedAt,
 	)
 	return err
 }
 
-func (p *PostgresStore) ListArtifacts(ctx context.Context, runID string) ([]store.Artifact, error) {
+func (p *PostgresStore) DeleteSkillFile(ctx context.Context, skillID string, path string) error {
 	const query = `
-		SELECT id, run_id, type, category, uri, content_type, size_bytes, checksum, labels, searchable_text, retention_class, created_at, metadata
-		FROM artifacts
-		WHERE run_id = $1
-		ORDER BY created_at ASC
+		DELETE FROM skill_files
+		WHERE skill_id = $1 AND path = $2 AND skill_id IN (SELECT id FROM skills WHERE workspace_id = $3)
+	`
+	_, err := p.db.ExecContext(ctx, query, skillID, path, store.WorkspaceID(ctx))
+	return err
+}
+
+func (p *PostgresStore) ListContextNodes(ctx context.Context) ([]store.ContextNode, error) {
+	const query = `
+		SELECT id, parent_id, name, node_type, content_type, size_bytes, created_at, updated_at
+		FROM context_nodes
+		WHERE workspace_id = $1
+		ORDER BY name ASC
 	`
-	rows, err := p.db.QueryContext(ctx, query, runID)
+	rows, err := p.db.QueryContext(ctx, query, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	results := []store.Artifact{}
+	results := []store.ContextNode{}
 	for rows.Next() {
+		var parentID sql.NullString
 		var createdAt time.Time
-		var category sql.NullString
-		var checksum sql.NullString
-		var searchableText sql.NullString
-		var retentionClass sql.NullString
-		var labelsBytes []byte
-		var metadataBytes []byte
-		var artifact store.Artifact
+		var updatedAt time.Time
+		var node store.ContextNode
 		if err := rows.Scan(
-			&artifact.ID,
-			&artifact.RunID,
-			&artifact.Type,
-			&category,
-			&artifact.URI,
-			&artifact.ContentType,
-			&artifact.SizeBytes,
-			&checksum,
-			&labelsBytes,
-			&searchableText,
-			&retentionClass,
+			&node.ID,
+			&parentID,
+			&node.Name,
+			&node.NodeType,
+			&node.ContentType,
+			&node.SizeBytes,
 			&createdAt,
-			&metadataBytes,
+			&updatedAt,
 		); err != nil {
 			return nil, err
 		}
-		if category.Valid {
-			artifact.Category = category.String
-		}
-		if checksum.Valid {
-			artifact.Checksum = checksum.String
+		if parentID.Valid {
+			node.ParentID = parentID.String
 		}
-		artifact.Labels = decodeStringSlice(labelsBytes)
-		if searchableText.Valid {
-			artifact.SearchableText = searchableText.String
+		node.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		node.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+		results = append(results, node)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *PostgresStore) GetContextFile(ctx context.Context, nodeID string) (*store.ContextNode, error) {
+	const query = `
+		SELECT id, parent_id, name, node_type, content, content_type, size_bytes, created_at, updated_at
+		FROM context_nodes
+		WHERE id = $1 AND workspace_id = $2
+	`
+	var parentID sql.NullString
+	var createdAt time.Time
+	var updatedAt time.Time
+	node := store.ContextNode{}
+	if err := p.db.QueryRowContext(ctx, query, nodeID, store.WorkspaceID(ctx)).Scan(
+		&node.ID,
+		&parentID,
+		&node.Name,
+		&node.NodeType,
+		&node.Content,
+		&node.ContentType,
+		&node.SizeBytes,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
 		}
-		if retentionClass.Valid {
-			artifact.RetentionClass = retentionClass.String
+		return nil, err
+	}
+	if parentID.Valid {
+		node.ParentID = parentID.String
+	}
+	node.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	node.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return &node, nil
+}
+
+func (p *PostgresStore) CreateContextFolder(ctx context.Context, node store.ContextNode) error {
+	const query = `
+		INSERT INTO context_nodes (id, workspace_id, parent_id, name, node_type, content, content_type, size_bytes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	var parentID any
+	if node.ParentID == "" {
+		parentID = nil
+	} else {
+		if err := requireInWorkspace(ctx, p.db, "context_nodes", node.ParentID); err != nil {
+			return err
 		}
-		artifact.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		parentID = node.ParentID
+	}
+	_, err := p.db.ExecContext(
+		ctx,
+		query,
+		node.ID,
+		store.WorkspaceID(ctx),
+		parentID,
+		node.Name,
+		node.NodeType,
+		node.Content,
+		node.ContentType,
+		node.SizeBytes,
+		node.CreatedAt,
+		node.UpdatedAt,
+	)
+	return err
+}
+
+func (p *PostgresStore) CreateContextFile(ctx context.Context, node store.ContextNode) error {
+	return p.CreateContextFolder(ctx, node)
+}
+
+func (p *PostgresStore) DeleteContextNode(ctx context.Context, nodeID string) error {
+	const query = `
+		DELETE FROM context_nodes
+		WHERE id = $1 AND workspace_id = $2
+	`
+	_, err := p.db.ExecContext(ctx, query, nodeID, store.WorkspaceID(ctx))
+	return err
+}
+
+func (p *PostgresStore) GetMemorySettings(ctx context.Context) (*store.MemorySettings, error) {
+	const query = `
+		SELECT enabled, created_at, updated_at
+		FROM memory_settings
+		WHERE workspace_id IN ($1, $2)
+		ORDER BY workspace_id = $1 DESC
+		LIMIT 1
+	`
+	var createdAt time.Time
+	var updatedAt time.Time
+	settings := store.MemorySettings{}
+	if err := p.db.QueryRowContext(ctx, query, store.WorkspaceID(ctx), store.DefaultWorkspaceID).Scan(&settings.Enabled, &createdAt, &updatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	settings.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	settings.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return &settings, nil
+}
+
+func (p *PostgresStore) UpsertMemorySettings(ctx context.Context, settings store.MemorySettings) error {
+	const query = `
+		INSERT INTO memory_settings (workspace_id, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id)
+		DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at
+	`
+	_, err := p.db.ExecContext(ctx, query, store.WorkspaceID(ctx), settings.Enabled, settings.CreatedAt, settings.UpdatedAt)
+	return err
+}
+
+func (p *PostgresStore) UpsertMemoryEntry(ctx context.Context, entry store.MemoryEntry) (bool, error) {
+	metadata := entry.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return false, err
+	}
+	fingerprint := readMetadataString(metadata, "fingerprint")
+	if fingerprint == "" {
+		return p.insertMemoryEntry(ctx, entry, encoded)
+	}
+	return p.insertMemoryEntryWithFingerprint(ctx, entry, encoded, fingerprint)
+}
+
+func (p *PostgresStore) GetPersonalitySettings(ctx context.Context) (*store.PersonalitySettings, error) {
+	const query = `
+		SELECT content, created_at, updated_at
+		FROM personality_settings
+		WHERE workspace_id IN ($1, $2)
+		ORDER BY workspace_id = $1 DESC
+		LIMIT 1
+	`
+	settings := store.PersonalitySettings{}
+	var createdAt time.Time
+	var updatedAt time.Time
+	if err := p.db.QueryRowContext(ctx, query, store.WorkspaceID(ctx), store.DefaultWorkspaceID).Scan(&settings.Content, &createdAt, &updatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	settings.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	settings.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return &settings, nil
+}
+
+func (p *PostgresStore) UpsertPersonalitySettings(ctx context.Context, settings store.PersonalitySettings) error {
+	const query = `
+		INSERT INTO personality_settings (workspace_id, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace_id)
+		DO UPDATE SET content = EXCLUDED.content, updated_at = EXCLUDED.updated_at
+	`
+	_, err := p.db.ExecContext(ctx, query, store.WorkspaceID(ctx), settings.Content, settings.CreatedAt, settings.UpdatedAt)
+	return err
+}
+
+func (p *PostgresStore) SearchMemory(ctx context.Context, query string, limit int) ([]store.MemoryEntry, error) {
+	if strings.TrimSpace(query) == "" || limit <= 0 {
+		return []store.MemoryEntry{}, nil
+	}
+	const sqlQuery = `
+		SELECT id, content, metadata, created_at, updated_at
+		FROM memory_entries
+		WHERE workspace_id = $3 AND tsv @@ plainto_tsquery('english', $1)
+		ORDER BY ts_rank_cd(tsv, plainto_tsquery('english', $1)) DESC, updated_at DESC
+		LIMIT $2
+	`
+	rows, err := p.db.QueryContext(ctx, sqlQuery, query, limit, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []store.MemoryEntry{}
+	for rows.Next() {
+		var createdAt time.Time
+		var updatedAt time.Time
+		var metadataBytes []byte
+		var entry store.MemoryEntry
+		if err := rows.Scan(&entry.ID, &entry.Content, &metadataBytes, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		entry.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
 		if len(metadataBytes) > 0 {
 			metadata := map[string]any{}
 			if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
 				return nil, err
 			}
-			artifact.Metadata = metadata
+			entry.Metadata = metadata
 		} else {
-			artifact.Metadata = map[string]any{}
+			entry.Metadata = map[string]any{}
 		}
-		results = append(results, artifact)
+		results = append(results, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *PostgresStore) SearchMemoryWithEmbedding(ctx context.Context, query string, embedding []float32, limit int) ([]store.MemoryEntry, error) {
+	if limit <= 0 {
+		return []store.MemoryEntry{}, nil
+	}
+	if len(embedding) == 0 || len(embedding) != memoryEmbeddingDimensions {
+		return p.SearchMemory(ctx, query, limit)
+	}
+	vector := formatVector(embedding)
+	const sqlQuery = `
+		SELECT id, content, metadata, created_at, updated_at
+		FROM memory_entries
+		WHERE workspace_id = $3 AND embedding IS NOT NULL
+		ORDER BY embedding <=> $1::vector
+		LIMIT $2
+	`
+	rows, err := p.db.QueryContext(ctx, sqlQuery, vector, limit, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []store.MemoryEntry{}
+	for rows.Next() {
+		var createdAt time.Time
+		var updatedAt time.Time
+		var metadataBytes []byte
+		var entry store.MemoryEntry
+		if err := rows.Scan(&entry.ID, &entry.Content, &metadataBytes, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		entry.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+		if len(metadataBytes) > 0 {
+			metadata := map[string]any{}
+			if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
+				return nil, err
+			}
+			entry.Metadata = metadata
+		} else {
+			entry.Metadata = map[string]any{}
+		}
+		results = append(results, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return p.SearchMemory(ctx, query, limit)
+	}
+	return results, nil
+}
+
+func (p *PostgresStore) AppendEvent(ctx context.Context, event store.RunEvent) error {
+	event.Type = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(event.Type)), "_", ".")
+	payload := event.Payload
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	timestamp := event.Timestamp
+	if timestamp == "" {
+		timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	timestampValue := parseTimestampValue(timestamp)
+	traceID := strings.TrimSpace(event.TraceID)
+	var traceIDValue any
+	if traceID == "" {
+		traceIDValue = nil
+	} else if _, err := uuid.Parse(traceID); err != nil {
+		traceIDValue = nil
+	} else {
+		traceIDValue = traceID
+	}
+	const query = `
+		INSERT INTO run_events (run_id, seq, type, timestamp, source, trace_id, payload, redaction_markers, actor)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9)
+	`
+	markers, err := encodeRedactionMarkers(event.RedactionMarkers)
+	if err != nil {
+		return err
+	}
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = requireInWorkspace(ctx, tx, "runs", event.RunID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, query, event.RunID, event.Seq, event.Type, timestampValue, event.Source, traceIDValue, encoded, markers, nullString(event.Actor)); err != nil {
+		return err
+	}
+	if step, ok := store.BuildRunStepFromEvent(event); ok {
+		if err = upsertRunStepTx(ctx, tx, step); err != nil {
+			return err
+		}
+	}
+	if err = applyRunStateUpdateTx(ctx, tx, event); err != nil {
+		return err
+	}
+	err = tx.Commit()
+	return err
+}
+
+func (p *PostgresStore) ListEvents(ctx context.Context, runID string, afterSeq int64) ([]store.RunEvent, error) {
+	const query = `
+		SELECT run_id, seq, type, timestamp, source, trace_id, payload, COALESCE(redaction_markers, '[]'::jsonb), COALESCE(actor, '')
+		FROM run_events
+		WHERE run_id = $1 AND seq > $2 AND run_id IN (SELECT id FROM runs WHERE workspace_id = $3)
+		ORDER BY seq ASC
+	`
+	rows, err := p.db.QueryContext(ctx, query, runID, afterSeq, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []store.RunEvent{}
+	for rows.Next() {
+		var payloadBytes []byte
+		var markerBytes []byte
+		var timestamp time.Time
+		var traceID sql.NullString
+		var event store.RunEvent
+		if err := rows.Scan(&event.RunID, &event.Seq, &event.Type, &timestamp, &event.Source, &traceID, &payloadBytes, &markerBytes, &event.Actor); err != nil {
+			return nil, err
+		}
+		markers, err := decodeRedactionMarkers(markerBytes)
+		if err != nil {
+			return nil, err
+		}
+		event.RedactionMarkers = markers
+		event.Timestamp = timestamp.UTC().Format(time.RFC3339Nano)
+		if traceID.Valid {
+			event.TraceID = traceID.String
+		}
+		if len(payloadBytes) > 0 {
+			payload := map[string]any{}
+			if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+				return nil, err
+			}
+			event.Payload = payload
+		} else {
+			event.Payload = map[string]any{}
+		}
+		results = append(results, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *PostgresStore) ListRunSteps(ctx context.Context, runID string) ([]store.RunStep, error) {
+	const query = `
+		SELECT run_id,
+			step_id,
+			parent_step_id,
+			name,
+			status,
+			plan_id,
+			attempt,
+			policy_decision,
+			dependencies,
+			expected_artifacts,
+			diagnostics,
+			started_at,
+			completed_at
+		FROM run_steps
+		WHERE run_id = $1 AND run_id IN (SELECT id FROM runs WHERE workspace_id = $2)
+		ORDER BY COALESCE((diagnostics->>'seq')::bigint, 9223372036854775807), created_at ASC
+	`
+	rows, err := p.db.QueryContext(ctx, query, runID, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	steps := []store.RunStep{}
+	for rows.Next() {
+		var (
+			step              store.RunStep
+			parentStepID      sql.NullString
+			name              sql.NullString
+			planID            sql.NullString
+			policyDecision    sql.NullString
+			dependenciesBytes []byte
+			expectedBytes     []byte
+			diagnosticsBytes  []byte
+			startedAt         sql.NullTime
+			completedAt       sql.NullTime
+		)
+		if err := rows.Scan(
+			&step.RunID,
+			&step.ID,
+			&parentStepID,
+			&name,
+			&step.Status,
+			&planID,
+			&step.Attempt,
+			&policyDecision,
+			&dependenciesBytes,
+			&expectedBytes,
+			&diagnosticsBytes,
+			&startedAt,
+			&completedAt,
+		); err != nil {
+			return nil, err
+		}
+		if parentStepID.Valid {
+			step.ParentStepID = parentStepID.String
+		}
+		if name.Valid {
+			step.Name = name.String
+		}
+		if planID.Valid {
+			step.PlanID = planID.String
+		}
+		if policyDecision.Valid {
+			step.PolicyDecision = policyDecision.String
+		}
+		if startedAt.Valid {
+			step.StartedAt = startedAt.Time.UTC().Format(time.RFC3339Nano)
+		}
+		if completedAt.Valid {
+			step.CompletedAt = completedAt.Time.UTC().Format(time.RFC3339Nano)
+		}
+		step.Dependencies = decodeStringSlice(dependenciesBytes)
+		step.ExpectedArtifacts = decodeStringSlice(expectedBytes)
+		step.Diagnostics = decodeJSONMap(diagnosticsBytes)
+		step.Kind = readDiagString(step.Diagnostics, "kind")
+		if step.Kind == "" {
+			step.Kind = "step"
+		}
+		step.Source = readDiagString(step.Diagnostics, "source")
+		step.Seq = readDiagInt64(step.Diagnostics, "seq")
+		step.Error = readDiagString(step.Diagnostics, "error")
+		if step.Name == "" {
+			step.Name = step.ID
+		}
+		steps = append(steps, step)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+func (p *PostgresStore) UpsertRunProcess(ctx context.Context, process store.RunProcess) error {
+	if strings.TrimSpace(process.RunID) == "" {
+		return fmt.Errorf("run id required")
+	}
+	if strings.TrimSpace(process.ProcessID) == "" {
+		return fmt.Errorf("process id required")
+	}
+	if strings.TrimSpace(process.Command) == "" {
+		process.Command = "process"
+	}
+	if strings.TrimSpace(process.Status) == "" {
+		process.Status = "running"
+	}
+	if err := requireInWorkspace(ctx, p.db, "runs", process.RunID); err != nil {
+		return err
+	}
+	argsBytes, err := json.Marshal(process.Args)
+	if err != nil {
+		return err
+	}
+	previewBytes, err := json.Marshal(process.PreviewURLs)
+	if err != nil {
+		return err
+	}
+	metadata := process.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO run_processes (
+			run_id,
+			process_id,
+			command,
+			args,
+			cwd,
+			status,
+			pid,
+			started_at,
+			ended_at,
+			exit_code,
+			signal,
+			preview_urls,
+			metadata
+		) VALUES ($1, $2, $3, $4::jsonb, $5, $6, $7, $8, $9, $10, $11, $12::jsonb, $13::jsonb)
+		ON CONFLICT (run_id, process_id)
+		DO UPDATE SET
+			command = COALESCE(NULLIF(EXCLUDED.command, ''), run_processes.command),
+			args = CASE
+				WHEN jsonb_typeof(EXCLUDED.args) = 'array' AND jsonb_array_length(EXCLUDED.args) > 0 THEN EXCLUDED.args
+				ELSE run_processes.args
+			END,
+			cwd = COALESCE(NULLIF(EXCLUDED.cwd, ''), run_processes.cwd),
+			status = COALESCE(NULLIF(EXCLUDED.status, ''), run_processes.status),
+			pid = CASE WHEN EXCLUDED.pid > 0 THEN EXCLUDED.pid ELSE run_processes.pid END,
+			started_at = COALESCE(run_processes.started_at, EXCLUDED.started_at),
+			ended_at = COALESCE(EXCLUDED.ended_at, run_processes.ended_at),
+			exit_code = COALESCE(EXCLUDED.exit_code, run_processes.exit_code),
+			signal = COALESCE(NULLIF(EXCLUDED.signal, ''), run_processes.signal),
+			preview_urls = CASE
+				WHEN jsonb_typeof(EXCLUDED.preview_urls) = 'array' AND jsonb_array_length(EXCLUDED.preview_urls) > 0
+					THEN EXCLUDED.preview_urls
+				ELSE run_processes.preview_urls
+			END,
+			metadata = run_processes.metadata || EXCLUDED.metadata
+	`
+	_, err = p.db.ExecContext(
+		ctx,
+		query,
+		process.RunID,
+		process.ProcessID,
+		process.Command,
+		argsBytes,
+		nullString(process.Cwd),
+		process.Status,
+		process.PID,
+		parseTimestampNull(process.StartedAt),
+		parseTimestampNull(process.EndedAt),
+		intPtrValue(process.ExitCode),
+		nullString(process.Signal),
+		previewBytes,
+		metadataBytes,
+	)
+	return err
+}
+
+func (p *PostgresStore) GetRunProcess(ctx context.Context, runID string, processID string) (*store.RunProcess, error) {
+	const query = `
+		SELECT run_id,
+			process_id,
+			command,
+			args,
+			cwd,
+			status,
+			pid,
+			started_at,
+			ended_at,
+			exit_code,
+			signal,
+			preview_urls,
+			metadata
+		FROM run_processes
+		WHERE run_id = $1 AND process_id = $2 AND run_id IN (SELECT id FROM runs WHERE workspace_id = $3)
+	`
+	processes, err := p.queryRunProcesses(ctx, query, runID, processID, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(processes) == 0 {
+		return nil, nil
+	}
+	return &processes[0], nil
+}
+
+func (p *PostgresStore) ListRunProcesses(ctx context.Context, runID string) ([]store.RunProcess, error) {
+	const query = `
+		SELECT run_id,
+			process_id,
+			command,
+			args,
+			cwd,
+			status,
+			pid,
+			started_at,
+			ended_at,
+			exit_code,
+			signal,
+			preview_urls,
+			metadata
+		FROM run_processes
+		WHERE run_id = $1 AND run_id IN (SELECT id FROM runs WHERE workspace_id = $2)
+		ORDER BY started_at DESC, process_id ASC
+	`
+	return p.queryRunProcesses(ctx, query, runID, store.WorkspaceID(ctx))
+}
+
+func (p *PostgresStore) NextSeq(ctx context.Context, runID string) (int64, error) {
+	if err := requireInWorkspace(ctx, p.db, "runs", runID); err != nil {
+		return 0, err
+	}
+	const query = `
+		INSERT INTO run_event_sequences (run_id, last_seq)
+		VALUES ($1, 1)
+		ON CONFLICT (run_id)
+		DO UPDATE SET last_seq = run_event_sequences.last_seq + 1
+		RETURNING last_seq
+	`
+	var seq int64
+	if err := p.db.QueryRowContext(ctx, query, runID).Scan(&seq); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+func (p *PostgresStore) UpsertArtifact(ctx context.Context, artifact store.Artifact) error {
+	metadata := artifact.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	labelsBytes, err := json.Marshal(artifact.Labels)
+	if err != nil {
+		return err
+	}
+	category := strings.TrimSpace(artifact.Category)
+	if category == "" {
+		category = "generic"
+	}
+	retentionClass := strings.TrimSpace(artifact.RetentionClass)
+	if retentionClass == "" {
+		retentionClass = "default"
+	}
+	if err := requireInWorkspace(ctx, p.db, "runs", artifact.RunID); err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO artifacts (
+			id,
+			run_id,
+			type,
+			category,
+			uri,
+			content_type,
+			size_bytes,
+			checksum,
+			labels,
+			searchable_text,
+			retention_class,
+			created_at,
+			metadata
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::jsonb, $10, $11, $12, $13)
+		ON CONFLICT (id)
+		DO UPDATE SET
+			type = EXCLUDED.type,
+			category = EXCLUDED.category,
+			uri = EXCLUDED.uri,
+			content_type = EXCLUDED.content_type,
+			size_bytes = EXCLUDED.size_bytes,
+			checksum = EXCLUDED.checksum,
+			labels = EXCLUDED.labels,
+			searchable_text = EXCLUDED.searchable_text,
+			retention_class = EXCLUDED.retention_class,
+			metadata = EXCLUDED.metadata,
+			created_at = EXCLUDED.created_at
+	`
+	_, err = p.db.ExecContext(
+		ctx,
+		query,
+		artifact.ID,
+		artifact.RunID,
+		artifact.Type,
+		category,
+		artifact.URI,
+		artifact.ContentType,
+		artifact.SizeBytes,
+		nullString(artifact.Checksum),
+		labelsBytes,
+		nullString(artifact.SearchableText),
+		retentionClass,
+		artifact.CreatedAt,
+		encoded,
+	)
+	return err
+}
+
+func (p *PostgresStore) ListArtifacts(ctx context.Context, runID string) ([]store.Artifact, error) {
+	const query = `
+		SELECT id, run_id, type, category, uri, content_type, size_bytes, checksum, labels, searchable_text, retention_class, created_at, metadata
+		FROM artifacts
+		WHERE run_id = $1 AND run_id IN (SELECT id FROM runs WHERE workspace_id = $2)
+		ORDER BY created_at ASC
+	`
+	rows, err := p.db.QueryContext(ctx, query, runID, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []store.Artifact{}
+	for rows.Next() {
+		var createdAt time.Time
+		var category sql.NullString
+		var checksum sql.NullString
+		var searchableText sql.NullString
+		var retentionClass sql.NullString
+		var labelsBytes []byte
+		var metadataBytes []byte
+		var artifact store.Artifact
+		if err := rows.Scan(
+			&artifact.ID,
+			&artifact.RunID,
+			&artifact.Type,
+			&category,
+			&artifact.URI,
+			&artifact.ContentType,
+			&artifact.SizeBytes,
+			&checksum,
+			&labelsBytes,
+			&searchableText,
+			&retentionClass,
+			&createdAt,
+			&metadataBytes,
+		); err != nil {
+			return nil, err
+		}
+		if category.Valid {
+			artifact.Category = category.String
+		}
+		if checksum.Valid {
+			artifact.Checksum = checksum.String
+		}
+		artifact.Labels = decodeStringSlice(labelsBytes)
+		if searchableText.Valid {
+			artifact.SearchableText = searchableText.String
+		}
+		if retentionClass.Valid {
+			artifact.RetentionClass = retentionClass.String
+		}
+		artifact.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+		if len(metadataBytes) > 0 {
+			metadata := map[string]any{}
+			if err := json.Unmarshal(metadataBytes, &metadata); err != nil {
+				return nil, err
+			}
+			artifact.Metadata = metadata
+		} else {
+			artifact.Metadata = map[string]any{}
+		}
+		results = append(results, artifact)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+const automationColumns = `id, name, prompt, model, llm_profile, days, time_of_day, timezone, enabled, next_run_at, last_run_at, in_progress, notification_channels, notify_on, change_threshold, kind, digest_automation_ids, digest_cadence, schedule_type, cron_expression, interval_minutes, run_at, blackout_dates, catch_up, catch_up_max_age_minutes, retry_max_attempts, retry_backoff_seconds, retry_at, retry_attempt, retry_scheduled_for, policy_profile, tags, timeout_ms, browser_mode, browser_interaction, browser_domain_allowlist, browser_preferred_browser, browser_user_agent, skills, stages, trigger_secret_enc, context_trigger_paths, run_trigger_tags, feed_urls, feed_poll_minutes, feed_seen_items, feed_checked_at, inbox_retention_count, inbox_retention_days, created_at, updated_at`
+
+func scanAutomation(scanner interface{ Scan(dest ...any) error }) (store.Automation, error) {
+	var (
+		item          store.Automation
+		daysBytes     []byte
+		nextRunAt     sql.NullTime
+		lastRunAt     sql.NullTime
+		channelsBytes []byte
+		digestBytes   []byte
+		runAt         sql.NullTime
+		blackoutBytes []byte
+		retryAt       sql.NullTime
+		retryFor      sql.NullTime
+		tagsBytes     []byte
+		allowBytes    []byte
+		skillsBytes   []byte
+		stagesBytes   []byte
+		contextBytes  []byte
+		runTagsBytes  []byte
+		feedsBytes    []byte
+		seenBytes     []byte
+		feedChecked   sql.NullTime
+		createdAt     time.Time
+		updatedAt     time.Time
+	)
+	if err := scanner.Scan(
+		&item.ID,
+		&item.Name,
+		&item.Prompt,
+		&item.Model,
+		&item.LLMProfile,
+		&daysBytes,
+		&item.TimeOfDay,
+		&item.Timezone,
+		&item.Enabled,
+		&nextRunAt,
+		&lastRunAt,
+		&item.InProgress,
+		&channelsBytes,
+		&item.NotifyOn,
+		&item.ChangeThreshold,
+		&item.Kind,
+		&digestBytes,
+		&item.DigestCadence,
+		&item.ScheduleType,
+		&item.CronExpression,
+		&item.IntervalMinutes,
+		&runAt,
+		&blackoutBytes,
+		&item.CatchUp,
+		&item.CatchUpMaxAgeMinutes,
+		&item.RetryMaxAttempts,
+		&item.RetryBackoffSeconds,
+		&retryAt,
+		&item.RetryAttempt,
+		&retryFor,
+		&item.PolicyProfile,
+		&tagsBytes,
+		&item.TimeoutMS,
+		&item.BrowserMode,
+		&item.BrowserInteraction,
+		&allowBytes,
+		&item.BrowserPreferred,
+		&item.BrowserUserAgent,
+		&skillsBytes,
+		&stagesBytes,
+		&item.TriggerSecretEnc,
+		&contextBytes,
+		&runTagsBytes,
+		&feedsBytes,
+		&item.FeedPollMinutes,
+		&seenBytes,
+		&feedChecked,
+		&item.InboxRetentionCount,
+		&item.InboxRetentionDays,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return store.Automation{}, err
+	}
+	item.Days = decodeStringSlice(daysBytes)
+	item.NotificationChannels = decodeStringSlice(channelsBytes)
+	item.DigestAutomationIDs = decodeStringSlice(digestBytes)
+	item.BlackoutDates = decodeStringSlice(blackoutBytes)
+	item.Tags = decodeStringSlice(tagsBytes)
+	item.BrowserDomainAllowlist = decodeStringSlice(allowBytes)
+	item.Skills = decodeStringSlice(skillsBytes)
+	item.Stages = decodeAutomationStages(stagesBytes)
+	item.ContextTriggerPaths = decodeStringSlice(contextBytes)
+	item.RunTriggerTags = decodeStringSlice(runTagsBytes)
+	item.FeedURLs = decodeStringSlice(feedsBytes)
+	item.FeedSeenItems = decodeStringSlice(seenBytes)
+	if feedChecked.Valid {
+		item.FeedCheckedAt = feedChecked.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if runAt.Valid {
+		item.RunAt = runAt.Time.UTC().Format(time.RFC3339)
+	}
+	if retryAt.Valid {
+		item.RetryAt = retryAt.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if retryFor.Valid {
+		item.RetryScheduledFor = retryFor.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if nextRunAt.Valid {
+		item.NextRunAt = nextRunAt.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if lastRunAt.Valid {
+		item.LastRunAt = lastRunAt.Time.UTC().Format(time.RFC3339Nano)
+	}
+	item.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	item.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return item, nil
+}
+
+func (p *PostgresStore) ListAutomations(ctx context.Context) ([]store.Automation, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+automationColumns+` FROM automations WHERE workspace_id = $1 ORDER BY updated_at DESC`, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]store.Automation, 0)
+	for rows.Next() {
+		item, err := scanAutomation(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *PostgresStore) GetAutomation(ctx context.Context, automationID string) (*store.Automation, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+automationColumns+` FROM automations WHERE id = $1 AND workspace_id = $2`, automationID, store.WorkspaceID(ctx))
+	item, err := scanAutomation(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (p *PostgresStore) CreateAutomation(ctx context.Context, automation store.Automation) error {
+	daysBytes, err := json.Marshal(automation.Days)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO automations (
+			id, name, prompt, model, llm_profile, days, time_of_day, timezone, enabled, next_run_at, last_run_at, in_progress,
+			notification_channels, notify_on, change_threshold, kind, digest_automation_ids, digest_cadence,
+			schedule_type, cron_expression, interval_minutes, run_at, blackout_dates, catch_up, catch_up_max_age_minutes,
+			retry_max_attempts, retry_backoff_seconds, retry_at, retry_attempt, retry_scheduled_for, policy_profile, tags,
+			timeout_ms, browser_mode, browser_interaction, browser_domain_allowlist, browser_preferred_browser,
+			browser_user_agent, skills, stages, trigger_secret_enc, context_trigger_paths, run_trigger_tags, feed_urls,
+			feed_poll_minutes, feed_seen_items, feed_checked_at, inbox_retention_count, inbox_retention_days, created_at,
+			updated_at, workspace_id
+		) VALUES (
+			$1, $2, $3, $4, $5, $6::jsonb, $7, $8, $9, $10, $11, $12, $13::jsonb, $14, $15, $16, $17::jsonb, $18,
+			$19, $20, $21, $22, $23::jsonb, $24, $25, $26, $27, $28, $29, $30, $31, $32::jsonb,
+			$33, $34, $35, $36::jsonb, $37, $38, $39::jsonb, $40::jsonb, $41, $42::jsonb, $43::jsonb, $44::jsonb,
+			$45, $46::jsonb, $47, $48, $49, $50, $51, $52
+		)
+	`
+	_, err = p.db.ExecContext(
+		ctx,
+		query,
+		automation.ID,
+		automation.Name,
+		automation.Prompt,
+		automation.Model,
+		automation.LLMProfile,
+		daysBytes,
+		automation.TimeOfDay,
+		automation.Timezone,
+		automation.Enabled,
+		parseTimestampNull(automation.NextRunAt),
+		parseTimestampNull(automation.LastRunAt),
+		automation.InProgress,
+		encodeStringSlice(automation.NotificationChannels),
+		automation.NotifyOn,
+		automation.ChangeThreshold,
+		automation.Kind,
+		encodeStringSlice(automation.DigestAutomationIDs),
+		automation.DigestCadence,
+		automation.ScheduleType,
+		automation.CronExpression,
+		automation.IntervalMinutes,
+		parseTimestampNull(automation.RunAt),
+		encodeStringSlice(automation.BlackoutDates),
+		automation.CatchUp,
+		automation.CatchUpMaxAgeMinutes,
+		automation.RetryMaxAttempts,
+		automation.RetryBackoffSeconds,
+		parseTimestampNull(automation.RetryAt),
+		automation.RetryAttempt,
+		parseTimestampNull(automation.RetryScheduledFor),
+		automation.PolicyProfile,
+		encodeStringSlice(automation.Tags),
+		automation.TimeoutMS,
+		automation.BrowserMode,
+		automation.BrowserInteraction,
+		encodeStringSlice(automation.BrowserDomainAllowlist),
+		automation.BrowserPreferred,
+		automation.BrowserUserAgent,
+		encodeStringSlice(automation.Skills),
+		encodeAutomationStages(automation.Stages),
+		automation.TriggerSecretEnc,
+		encodeStringSlice(automation.ContextTriggerPaths),
+		encodeStringSlice(automation.RunTriggerTags),
+		encodeStringSlice(automation.FeedURLs),
+		automation.FeedPollMinutes,
+		encodeStringSlice(automation.FeedSeenItems),
+		parseTimestampNull(automation.FeedCheckedAt),
+		automation.InboxRetentionCount,
+		automation.InboxRetentionDays,
+		parseTimestampValue(automation.CreatedAt),
+		parseTimestampValue(automation.UpdatedAt),
+		store.WorkspaceID(ctx),
+	)
+	return err
+}
+
+func (p *PostgresStore) UpdateAutomation(ctx context.Context, automation store.Automation) error {
+	daysBytes, err := json.Marshal(automation.Days)
+	if err != nil {
+		return err
+	}
+	const query = `
+		UPDATE automations
+		SET
+			name = $2,
+			prompt = $3,
+			model = $4,
+			llm_profile = $5,
+			days = $6::jsonb,
+			time_of_day = $7,
+			timezone = $8,
+			enabled = $9,
+			next_run_at = $10,
+			last_run_at = $11,
+			in_progress = $12,
+			notification_channels = $13::jsonb,
+			notify_on = $14,
+			change_threshold = $15,
+			kind = $16,
+			digest_automation_ids = $17::jsonb,
+			digest_cadence = $18,
+			schedule_type = $19,
+			cron_expression = $20,
+			interval_minutes = $21,
+			run_at = $22,
+			blackout_dates = $23::jsonb,
+			catch_up = $24,
+			catch_up_max_age_minutes = $25,
+			retry_max_attempts = $26,
+			retry_backoff_seconds = $27,
+			retry_at = $28,
+			retry_attempt = $29,
+			retry_scheduled_for = $30,
+			policy_profile = $31,
+			tags = $32::jsonb,
+			timeout_ms = $33,
+			browser_mode = $34,
+			browser_interaction = $35,
+			browser_domain_allowlist = $36::jsonb,
+			browser_preferred_browser = $37,
+			browser_user_agent = $38,
+			skills = $39::jsonb,
+			stages = $40::jsonb,
+			trigger_secret_enc = $41,
+			context_trigger_paths = $42::jsonb,
+			run_trigger_tags = $43::jsonb,
+			feed_urls = $44::jsonb,
+			feed_poll_minutes = $45,
+			feed_seen_items = $46::jsonb,
+			feed_checked_at = $47,
+			inbox_retention_count = $48,
+			inbox_retention_days = $49,
+			updated_at = $50
+		WHERE id = $1 AND workspace_id = $51
+	`
+	_, err = p.db.ExecContext(
+		ctx,
+		query,
+		automation.ID,
+		automation.Name,
+		automation.Prompt,
+		automation.Model,
+		automation.LLMProfile,
+		daysBytes,
+		automation.TimeOfDay,
+		automation.Timezone,
+		automation.Enabled,
+		parseTimestampNull(automation.NextRunAt),
+		parseTimestampNull(automation.LastRunAt),
+		automation.InProgress,
+		encodeStringSlice(automation.NotificationChannels),
+		automation.NotifyOn,
+		automation.ChangeThreshold,
+		automation.Kind,
+		encodeStringSlice(automation.DigestAutomationIDs),
+		automation.DigestCadence,
+		automation.ScheduleType,
+		automation.CronExpression,
+		automation.IntervalMinutes,
+		parseTimestampNull(automation.RunAt),
+		encodeStringSlice(automation.BlackoutDates),
+		automation.CatchUp,
+		automation.CatchUpMaxAgeMinutes,
+		automation.RetryMaxAttempts,
+		automation.RetryBackoffSeconds,
+		parseTimestampNull(automation.RetryAt),
+		automation.RetryAttempt,
+		parseTimestampNull(automation.RetryScheduledFor),
+		automation.PolicyProfile,
+		encodeStringSlice(automation.Tags),
+		automation.TimeoutMS,
+		automation.BrowserMode,
+		automation.BrowserInteraction,
+		encodeStringSlice(automation.BrowserDomainAllowlist),
+		automation.BrowserPreferred,
+		automation.BrowserUserAgent,
+		encodeStringSlice(automation.Skills),
+		encodeAutomationStages(automation.Stages),
+		automation.TriggerSecretEnc,
+		encodeStringSlice(automation.ContextTriggerPaths),
+		encodeStringSlice(automation.RunTriggerTags),
+		encodeStringSlice(automation.FeedURLs),
+		automation.FeedPollMinutes,
+		encodeStringSlice(automation.FeedSeenItems),
+		parseTimestampNull(automation.FeedCheckedAt),
+		automation.InboxRetentionCount,
+		automation.InboxRetentionDays,
+		parseTimestampValue(automation.UpdatedAt),
+		store.WorkspaceID(ctx),
+	)
+	return err
+}
+
+func (p *PostgresStore) DeleteAutomation(ctx context.Context, automationID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM automations WHERE id = $1 AND workspace_id = $2", automationID, store.WorkspaceID(ctx))
+	return err
+}
+
+const automationInboxColumns = `id, automation_id, run_id, status, phase, completion_reason, final_response, timed_out, error, unread, trigger, started_at, completed_at, diagnostics,
+	change_summary, similarity, unchanged, scheduled_for, attempt, trigger_payload, archived_at, lease_owner, lease_until, created_at, updated_at`
+
+func scanAutomationInboxEntry(scanner interface{ Scan(dest ...any) error }) (store.AutomationInboxEntry, error) {
+	var (
+		entry            store.AutomationInboxEntry
+		runID            sql.NullString
+		phase            sql.NullString
+		completionReason sql.NullString
+		finalResponse    sql.NullString
+		errorValue       sql.NullString
+		completedAt      sql.NullTime
+		diagnosticsBytes []byte
+		similarity       sql.NullFloat64
+		scheduledFor     sql.NullTime
+		payloadBytes     []byte
+		archivedAt       sql.NullTime
+		leaseUntil       sql.NullTime
+		createdAt        time.Time
+		updatedAt        time.Time
+		startedAt        time.Time
+	)
+	if err := scanner.Scan(
+		&entry.ID,
+		&entry.AutomationID,
+		&runID,
+		&entry.Status,
+		&phase,
+		&completionReason,
+		&finalResponse,
+		&entry.TimedOut,
+		&errorValue,
+		&entry.Unread,
+		&entry.Trigger,
+		&startedAt,
+		&completedAt,
+		&diagnosticsBytes,
+		&entry.ChangeSummary,
+		&similarity,
+		&entry.Unchanged,
+		&scheduledFor,
+		&entry.Attempt,
+		&payloadBytes,
+		&archivedAt,
+		&entry.LeaseOwner,
+		&leaseUntil,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return entry, err
+	}
+	if similarity.Valid {
+		value := similarity.Float64
+		entry.Similarity = &value
+	}
+	if scheduledFor.Valid {
+		entry.ScheduledFor = scheduledFor.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if runID.Valid {
+		entry.RunID = runID.String
+	}
+	if phase.Valid {
+		entry.Phase = phase.String
+	}
+	if completionReason.Valid {
+		entry.CompletionReason = completionReason.String
+	}
+	if finalResponse.Valid {
+		entry.FinalResponse = finalResponse.String
+	}
+	if errorValue.Valid {
+		entry.Error = errorValue.String
+	}
+	entry.StartedAt = startedAt.UTC().Format(time.RFC3339Nano)
+	if completedAt.Valid {
+		entry.CompletedAt = completedAt.Time.UTC().Format(time.RFC3339Nano)
+	}
+	entry.Diagnostics = decodeJSONMap(diagnosticsBytes)
+	entry.TriggerPayload = decodeJSONMap(payloadBytes)
+	if archivedAt.Valid {
+		entry.ArchivedAt = archivedAt.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if leaseUntil.Valid {
+		entry.LeaseUntil = leaseUntil.Time.UTC().Format(time.RFC3339Nano)
+	}
+	entry.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	entry.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return entry, nil
+}
+
+func scanAutomationInboxEntries(rows *sql.Rows) ([]store.AutomationInboxEntry, error) {
+	defer rows.Close()
+	results := make([]store.AutomationInboxEntry, 0)
+	for rows.Next() {
+		entry, err := scanAutomationInboxEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *PostgresStore) ListAutomationInbox(ctx context.Context, automationID string) ([]store.AutomationInboxEntry, error) {
+	query := `
+		SELECT ` + automationInboxColumns + `
+		FROM automation_inbox
+		WHERE automation_id = $1 AND automation_id IN (SELECT id FROM automations WHERE workspace_id = $2)
+		ORDER BY started_at DESC, created_at DESC
+	`
+	rows, err := p.db.QueryContext(ctx, query, automationID, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return scanAutomationInboxEntries(rows)
+}
+
+func (p *PostgresStore) QueryAutomationInbox(ctx context.Context, query store.AutomationInboxQuery) ([]store.AutomationInboxEntry, error) {
+	conditions := []string{"automation_id IN (SELECT id FROM automations WHERE workspace_id = $1)"}
+	args := []any{store.WorkspaceID(ctx)}
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+	if query.AutomationID != "" {
+		addCondition("automation_id::text = $%d", query.AutomationID)
+	}
+	if query.UnreadOnly {
+		conditions = append(conditions, "unread")
+	}
+	if query.Archived {
+		conditions = append(conditions, "archived_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+	if len(query.Statuses) > 0 {
+		addCondition("status = ANY($%d::text[])", query.Statuses)
+	}
+	if len(query.Triggers) > 0 {
+		addCondition("trigger = ANY($%d::text[])", query.Triggers)
+	}
+	for _, bound := range []struct {
+		clause string
+		value  string
+	}{
+		{"started_at >= $%d", query.Since},
+		{"started_at < $%d", query.Until},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, bound.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time bound %q: %w", bound.value, err)
+		}
+		addCondition(bound.clause, parsed.UTC())
+	}
+	if query.BeforeStartedAt != "" {
+		before, err := time.Parse(time.RFC3339Nano, query.BeforeStartedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor %q: %w", query.BeforeStartedAt, err)
+		}
+		args = append(args, before.UTC(), query.BeforeID)
+		conditions = append(conditions, fmt.Sprintf("(started_at, id::text) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	statement := `SELECT ` + automationInboxColumns + ` FROM automation_inbox WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY started_at DESC, id::text DESC`
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		statement += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	rows, err := p.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAutomationInboxEntries(rows)
+}
+
+func (p *PostgresStore) CreateAutomationInboxEntry(ctx context.Context, entry store.AutomationInboxEntry) error {
+	diagnostics := entry.Diagnostics
+	if diagnostics == nil {
+		diagnostics = map[string]any{}
+	}
+	encoded, err := json.Marshal(diagnostics)
+	if err != nil {
+		return err
+	}
+	payload := entry.TriggerPayload
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if err := requireInWorkspace(ctx, p.db, "automations", entry.AutomationID); err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO automation_inbox (
+			id, automation_id, run_id, status, phase, completion_reason, final_response, timed_out, error, unread, trigger, started_at, completed_at, diagnostics,
+			change_summary, similarity, unchanged, scheduled_for, attempt, trigger_payload, archived_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14::jsonb, $15, $16, $17, $18, $19, $20::jsonb, $21, $22, $23
+		)
+	`
+	_, err = p.db.ExecContext(
+		ctx,
+		query,
+		entry.ID,
+		entry.AutomationID,
+		nullString(entry.RunID),
+		entry.Status,
+		nullString(entry.Phase),
+		nullString(entry.CompletionReason),
+		nullString(entry.FinalResponse),
+		entry.TimedOut,
+		nullString(entry.Error),
+		entry.Unread,
+		entry.Trigger,
+		parseTimestampValue(entry.StartedAt),
+		parseTimestampNull(entry.CompletedAt),
+		encoded,
+		entry.ChangeSummary,
+		entry.Similarity,
+		entry.Unchanged,
+		parseTimestampNull(entry.ScheduledFor),
+		entry.Attempt,
+		encodedPayload,
+		parseTimestampNull(entry.ArchivedAt),
+		parseTimestampValue(entry.CreatedAt),
+		parseTimestampValue(entry.UpdatedAt),
+	)
+	return err
+}
+
+func (p *PostgresStore) UpdateAutomationInboxEntry(ctx context.Context, entry store.AutomationInboxEntry) error {
+	diagnostics := entry.Diagnostics
+	if diagnostics == nil {
+		diagnostics = map[string]any{}
+	}
+	encoded, err := json.Marshal(diagnostics)
+	if err != nil {
+		return err
+	}
+	const query = `
+		UPDATE automation_inbox
+		SET
+			run_id = $3,
+			status = $4,
+			phase = $5,
+			completion_reason = $6,
+			final_response = $7,
+			timed_out = $8,
+			error = $9,
+			unread = $10,
+			trigger = $11,
+			started_at = $12,
+			completed_at = $13,
+			diagnostics = $14::jsonb,
+			change_summary = $15,
+			similarity = $16,
+			unchanged = $17,
+			scheduled_for = $18,
+			attempt = $19,
+			archived_at = $20,
+			updated_at = $21
+		WHERE automation_id = $1 AND id = $2 AND automation_id IN (SELECT id FROM automations WHERE workspace_id = $22)
+	`
+	_, err = p.db.ExecContext(
+		ctx,
+		query,
+		entry.AutomationID,
+		entry.ID,
+		nullString(entry.RunID),
+		entry.Status,
+		nullString(entry.Phase),
+		nullString(entry.CompletionReason),
+		nullString(entry.FinalResponse),
+		entry.TimedOut,
+		nullString(entry.Error),
+		entry.Unread,
+		entry.Trigger,
+		parseTimestampValue(entry.StartedAt),
+		parseTimestampNull(entry.CompletedAt),
+		encoded,
+		entry.ChangeSummary,
+		entry.Similarity,
+		entry.Unchanged,
+		parseTimestampNull(entry.ScheduledFor),
+		entry.Attempt,
+		parseTimestampNull(entry.ArchivedAt),
+		parseTimestampValue(entry.UpdatedAt),
+		store.WorkspaceID(ctx),
+	)
+	return err
+}
+
+func (p *PostgresStore) ClaimAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string, now string, leaseUntil string) (bool, error) {
+	const query = `
+		UPDATE automation_inbox
+		SET lease_owner = $3, lease_until = $5
+		WHERE automation_id = $1 AND id = $2
+			AND status IN ('queued', 'running')
+			AND (lease_owner = '' OR lease_owner = $3 OR lease_until IS NULL OR lease_until <= $4)
+			AND automation_id IN (SELECT id FROM automations WHERE workspace_id = $6)
+	`
+	result, err := p.db.ExecContext(ctx, query, automationID, entryID, owner, parseTimestampValue(now), parseTimestampValue(leaseUntil), store.WorkspaceID(ctx))
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (p *PostgresStore) ReleaseAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`UPDATE automation_inbox SET lease_owner = '', lease_until = NULL WHERE automation_id = $1 AND id = $2 AND lease_owner = $3 AND automation_id IN (SELECT id FROM automations WHERE workspace_id = $4)`,
+		automationID,
+		entryID,
+		owner,
+		store.WorkspaceID(ctx),
+	)
+	return err
+}
+
+func (p *PostgresStore) MarkAutomationInboxEntryRead(ctx context.Context, automationID string, entryID string) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`UPDATE automation_inbox SET unread = FALSE, updated_at = NOW() WHERE automation_id = $1 AND id = $2 AND automation_id IN (SELECT id FROM automations WHERE workspace_id = $3)`,
+		automationID,
+		entryID,
+		store.WorkspaceID(ctx),
+	)
+	return err
+}
+
+func (p *PostgresStore) DeleteAutomationInboxEntries(ctx context.Context, automationID string, entryIDs []string) error {
+	if len(entryIDs) == 0 {
+		return nil
+	}
+	_, err := p.db.ExecContext(
+		ctx,
+		`DELETE FROM automation_inbox WHERE automation_id = $1 AND id::text = ANY($2::text[]) AND automation_id IN (SELECT id FROM automations WHERE workspace_id = $3)`,
+		automationID,
+		entryIDs,
+		store.WorkspaceID(ctx),
+	)
+	return err
+}
+
+func (p *PostgresStore) MarkAutomationInboxReadAll(ctx context.Context, automationID string) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		`UPDATE automation_inbox SET unread = FALSE, updated_at = NOW() WHERE automation_id = $1 AND automation_id IN (SELECT id FROM automations WHERE workspace_id = $2)`,
+		automationID,
+		store.WorkspaceID(ctx),
+	)
+	return err
+}
+
+const auditEntryColumns = `seq, id, workspace_id, actor, user_id, action, target_type, target_id, before, after, method, path, status_code, remote_addr, user_agent, request_id, created_at`
+
+func scanAuditEntry(scanner interface{ Scan(dest ...any) error }) (store.AuditEntry, error) {
+	var (
+		entry     store.AuditEntry
+		before    []byte
+		after     []byte
+		createdAt time.Time
+	)
+	if err := scanner.Scan(
+		&entry.Seq,
+		&entry.ID,
+		&entry.WorkspaceID,
+		&entry.Actor,
+		&entry.UserID,
+		&entry.Action,
+		&entry.TargetType,
+		&entry.TargetID,
+		&before,
+		&after,
+		&entry.Method,
+		&entry.Path,
+		&entry.StatusCode,
+		&entry.RemoteAddr,
+		&entry.UserAgent,
+		&entry.RequestID,
+		&createdAt,
+	); err != nil {
+		return store.AuditEntry{}, err
+	}
+	entry.Before = decodeJSONMap(before)
+	entry.After = decodeJSONMap(after)
+	entry.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	return entry, nil
+}
+
+func (p *PostgresStore) AppendAuditEntry(ctx context.Context, entry store.AuditEntry) error {
+	before, err := json.Marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(entry.After)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO audit_log (
+			id, workspace_id, actor, user_id, action, target_type, target_id, before, after,
+			method, path, status_code, remote_addr, user_agent, request_id, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9::jsonb, $10, $11, $12, $13, $14, $15, $16
+		)
+	`
+	_, err = p.db.ExecContext(
+		ctx,
+		query,
+		entry.ID,
+		store.WorkspaceID(ctx),
+		entry.Actor,
+		entry.UserID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		before,
+		after,
+		entry.Method,
+		entry.Path,
+		entry.StatusCode,
+		entry.RemoteAddr,
+		entry.UserAgent,
+		entry.RequestID,
+		parseTimestampValue(entry.CreatedAt),
+	)
+	return err
+}
+
+func (p *PostgresStore) ListAuditEntries(ctx context.Context, query store.AuditQuery) ([]store.AuditEntry, error) {
+	conditions := []string{"workspace_id = $1"}
+	args := []any{store.WorkspaceID(ctx)}
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+	for _, filter := range []struct {
+		column string
+		value  string
+	}{
+		{"actor", query.Actor},
+		{"user_id", query.UserID},
+		{"action", query.Action},
+		{"target_type", query.TargetType},
+		{"target_id", query.TargetID},
+	} {
+		if filter.value != "" {
+			addCondition(filter.column+" = $%d", filter.value)
+		}
+	}
+	for _, bound := range []struct {
+		clause string
+		value  string
+	}{
+		{"created_at >= $%d", query.Since},
+		{"created_at < $%d", query.Until},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, bound.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time bound %q: %w", bound.value, err)
+		}
+		addCondition(bound.clause, parsed.UTC())
+	}
+	if query.BeforeSeq > 0 {
+		addCondition("seq < $%d", query.BeforeSeq)
+	}
+	statement := `SELECT ` + auditEntryColumns + ` FROM audit_log WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY seq DESC`
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		statement += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	rows, err := p.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]store.AuditEntry, 0)
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+const webhookSubscriptionColumns = `id, name, url, event_types, tags, secret_enc, enabled, created_at, updated_at`
+
+func scanWebhookSubscription(scanner interface{ Scan(dest ...any) error }) (store.WebhookSubscription, error) {
+	var (
+		subscription store.WebhookSubscription
+		eventTypes   []byte
+		tags         []byte
+		createdAt    time.Time
+		updatedAt    time.Time
+	)
+	if err := scanner.Scan(
+		&subscription.ID,
+		&subscription.Name,
+		&subscription.URL,
+		&eventTypes,
+		&tags,
+		&subscription.SecretEnc,
+		&subscription.Enabled,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return store.WebhookSubscription{}, err
+	}
+	subscription.EventTypes = decodeStringSlice(eventTypes)
+	subscription.Tags = decodeStringSlice(tags)
+	subscription.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	subscription.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return subscription, nil
+}
+
+func (p *PostgresStore) ListWebhookSubscriptions(ctx context.Context) ([]store.WebhookSubscription, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE workspace_id = $1 ORDER BY created_at ASC`, store.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]store.WebhookSubscription, 0)
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, subscription)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *PostgresStore) GetWebhookSubscription(ctx context.Context, subscriptionID string) (*store.WebhookSubscription, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id::text = $1 AND workspace_id = $2`, subscriptionID, store.WorkspaceID(ctx))
+	subscription, err := scanWebhookSubscription(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (p *PostgresStore) CreateWebhookSubscription(ctx context.Context, subscription store.WebhookSubscription) error {
+	const query = `
+		INSERT INTO webhook_subscriptions (
+			id, workspace_id, name, url, event_types, tags, secret_enc, enabled, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5::jsonb, $6::jsonb, $7, $8, $9, $10
+		)
+	`
+	_, err := p.db.ExecContext(
+		ctx,
+		query,
+		subscription.ID,
+		store.WorkspaceID(ctx),
+		subscription.Name,
+		subscription.URL,
+		encodeStringSlice(subscription.EventTypes),
+		encodeStringSlice(subscription.Tags),
+		subscription.SecretEnc,
+		subscription.Enabled,
+		parseTimestampValue(subscription.CreatedAt),
+		parseTimestampValue(subscription.UpdatedAt),
+	)
+	return err
+}
+
+func (p *PostgresStore) UpdateWebhookSubscription(ctx context.Context, subscription store.WebhookSubscription) error {
+	const query = `
+		UPDATE webhook_subscriptions
+		SET
+			name = $2,
+			url = $3,
+			event_types = $4::jsonb,
+			tags = $5::jsonb,
+			secret_enc = $6,
+			enabled = $7,
+			updated_at = $8
+		WHERE id::text = $1 AND workspace_id = $9
+	`
+	_, err := p.db.ExecContext(
+		ctx,
+		query,
+		subscription.ID,
+		subscription.Name,
+		subscription.URL,
+		encodeStringSlice(subscription.EventTypes),
+		encodeStringSlice(subscription.Tags),
+		subscription.SecretEnc,
+		subscription.Enabled,
+		parseTimestampValue(subscription.UpdatedAt),
+		store.WorkspaceID(ctx),
+	)
+	return err
+}
+
+func (p *PostgresStore) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id::text = $1 AND workspace_id = $2", subscriptionID, store.WorkspaceID(ctx))
+	return err
+}
+
+const notificationChannelColumns = `id, name, type, config, secrets_enc, enabled, created_at, updated_at`
+
+func scanNotificationChannel(scanner interface{ Scan(dest ...any) error }) (store.NotificationChannel, error) {
+	var (
+		channel   store.NotificationChannel
+		config    []byte
+		createdAt time.Time
+		updatedAt time.Time
+	)
+	if err := scanner.Scan(
+		&channel.ID,
+		&channel.Name,
+		&channel.Type,
+		&config,
+		&channel.SecretsEnc,
+		&channel.Enabled,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return store.NotificationChannel{}, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	channel.Config = map[string]string{}
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &channel.Config); err != nil {
+			return store.NotificationChannel{}, err
+		}
 	}
-	return results, nil
+	channel.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	channel.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return channel, nil
 }
 
-func (p *PostgresStore) ListAutomations(ctx context.Context) ([]store.Automation, error) {
-	const query = `
-		SELECT id, name, prompt, model, days, time_of_day, timezone, enabled, next_run_at, last_run_at, in_progress, created_at, updated_at
-		FROM automations
-		ORDER BY updated_at DESC
-	`
-	rows, err := p.db.QueryContext(ctx, query)
+func (p *PostgresStore) ListNotificationChannels(ctx context.Context) ([]store.NotificationChannel, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+notificationChannelColumns+` FROM notification_channels WHERE workspace_id = $1 ORDER BY created_at ASC`, store.WorkspaceID(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-
-	results := make([]store.Automation, 0)
+	results := make([]store.NotificationChannel, 0)
 	for rows.Next() {
-		var (
-			item      store.Automation
-			daysBytes []byte
-			nextRunAt sql.NullTime
-			lastRunAt sql.NullTime
-			createdAt time.Time
-			updatedAt time.Time
-		)
-		if err := rows.Scan(
-			&item.ID,
-			&item.Name,
-			&item.Prompt,
-			&item.Model,
-			&daysBytes,
-			&item.TimeOfDay,
-			&item.Timezone,
-			&item.Enabled,
-			&nextRunAt,
-			&lastRunAt,
-			&item.InProgress,
-			&createdAt,
-			&updatedAt,
-		); err != nil {
+		channel, err := scanNotificationChannel(rows)
+		if err != nil {
 			return nil, err
 		}
-		item.Days = decodeStringSlice(daysBytes)
-		if nextRunAt.Valid {
-			item.NextRunAt = nextRunAt.Time.UTC().Format(time.RFC3339Nano)
-		}
-		if lastRunAt.Valid {
-			item.LastRunAt = lastRunAt.Time.UTC().Format(time.RFC3339Nano)
-		}
-		item.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-		item.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-		results = append(results, item)
+		results = append(results, channel)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -1343,199 +3361,140 @@ func (p *PostgresStore) ListAutomations(ctx context.Context) ([]store.Automation
 	return results, nil
 }
 
-func (p *PostgresStore) GetAutomation(ctx context.Context, automationID string) (*store.Automation, error) {
-	const query = `
-		SELECT id, name, prompt, model, days, time_of_day, timezone, enabled, next_run_at, last_run_at, in_progress, created_at, updated_at
-		FROM automations
-		WHERE id = $1
-	`
-	var (
-		item      store.Automation
-		daysBytes []byte
-		nextRunAt sql.NullTime
-		lastRunAt sql.NullTime
-		createdAt time.Time
-		updatedAt time.Time
-	)
-	if err := p.db.QueryRowContext(ctx, query, automationID).Scan(
-		&item.ID,
-		&item.Name,
-		&item.Prompt,
-		&item.Model,
-		&daysBytes,
-		&item.TimeOfDay,
-		&item.Timezone,
-		&item.Enabled,
-		&nextRunAt,
-		&lastRunAt,
-		&item.InProgress,
-		&createdAt,
-		&updatedAt,
-	); err != nil {
+func (p *PostgresStore) GetNotificationChannel(ctx context.Context, channelID string) (*store.NotificationChannel, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+notificationChannelColumns+` FROM notification_channels WHERE id::text = $1 AND workspace_id = $2`, channelID, store.WorkspaceID(ctx))
+	channel, err := scanNotificationChannel(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	item.Days = decodeStringSlice(daysBytes)
-	if nextRunAt.Valid {
-		item.NextRunAt = nextRunAt.Time.UTC().Format(time.RFC3339Nano)
-	}
-	if lastRunAt.Valid {
-		item.LastRunAt = lastRunAt.Time.UTC().Format(time.RFC3339Nano)
-	}
-	item.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-	item.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-	return &item, nil
+	return &channel, nil
 }
 
-func (p *PostgresStore) CreateAutomation(ctx context.Context, automation store.Automation) error {
-	daysBytes, err := json.Marshal(automation.Days)
+func (p *PostgresStore) CreateNotificationChannel(ctx context.Context, channel store.NotificationChannel) error {
+	config, err := json.Marshal(nonNilConfig(channel.Config))
 	if err != nil {
 		return err
 	}
 	const query = `
-		INSERT INTO automations (
-			id, name, prompt, model, days, time_of_day, timezone, enabled, next_run_at, last_run_at, in_progress, created_at, updated_at
+		INSERT INTO notification_channels (
+			id, workspace_id, name, type, config, secrets_enc, enabled, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5::jsonb, $6, $7, $8, $9, $10, $11, $12, $13
+			$1, $2, $3, $4, $5::jsonb, $6, $7, $8, $9
 		)
 	`
 	_, err = p.db.ExecContext(
 		ctx,
 		query,
-		automation.ID,
-		automation.Name,
-		automation.Prompt,
-		automation.Model,
-		daysBytes,
-		automation.TimeOfDay,
-		automation.Timezone,
-		automation.Enabled,
-		parseTimestampNull(automation.NextRunAt),
-		parseTimestampNull(automation.LastRunAt),
-		automation.InProgress,
-		parseTimestampValue(automation.CreatedAt),
-		parseTimestampValue(automation.UpdatedAt),
+		channel.ID,
+		store.WorkspaceID(ctx),
+		channel.Name,
+		channel.Type,
+		config,
+		channel.SecretsEnc,
+		channel.Enabled,
+		parseTimestampValue(channel.CreatedAt),
+		parseTimestampValue(channel.UpdatedAt),
 	)
 	return err
 }
 
-func (p *PostgresStore) UpdateAutomation(ctx context.Context, automation store.Automation) error {
-	daysBytes, err := json.Marshal(automation.Days)
+func (p *PostgresStore) UpdateNotificationChannel(ctx context.Context, channel store.NotificationChannel) error {
+	config, err := json.Marshal(nonNilConfig(channel.Config))
 	if err != nil {
 		return err
 	}
 	const query = `
-		UPDATE automations
+		UPDATE notification_channels
 		SET
 			name = $2,
-			prompt = $3,
-			model = $4,
-			days = $5::jsonb,
-			time_of_day = $6,
-			timezone = $7,
-			enabled = $8,
-			next_run_at = $9,
-			last_run_at = $10,
-			in_progress = $11,
-			updated_at = $12
-		WHERE id = $1
+			type = $3,
+			config = $4::jsonb,
+			secrets_enc = $5,
+			enabled = $6,
+			updated_at = $7
+		WHERE id::text = $1 AND workspace_id = $8
 	`
 	_, err = p.db.ExecContext(
 		ctx,
 		query,
-		automation.ID,
-		automation.Name,
-		automation.Prompt,
-		automation.Model,
-		daysBytes,
-		automation.TimeOfDay,
-		automation.Timezone,
-		automation.Enabled,
-		parseTimestampNull(automation.NextRunAt),
-		parseTimestampNull(automation.LastRunAt),
-		automation.InProgress,
-		parseTimestampValue(automation.UpdatedAt),
+		channel.ID,
+		channel.Name,
+		channel.Type,
+		config,
+		channel.SecretsEnc,
+		channel.Enabled,
+		parseTimestampValue(channel.UpdatedAt),
+		store.WorkspaceID(ctx),
 	)
 	return err
 }
 
-func (p *PostgresStore) DeleteAutomation(ctx context.Context, automationID string) error {
-	_, err := p.db.ExecContext(ctx, "DELETE FROM automations WHERE id = $1", automationID)
+func (p *PostgresStore) DeleteNotificationChannel(ctx context.Context, channelID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM notification_channels WHERE id::text = $1 AND workspace_id = $2", channelID, store.WorkspaceID(ctx))
 	return err
 }
 
-func (p *PostgresStore) ListAutomationInbox(ctx context.Context, automationID string) ([]store.AutomationInboxEntry, error) {
-	const query = `
-		SELECT id, automation_id, run_id, status, phase, completion_reason, final_response, timed_out, error, unread, trigger, started_at, completed_at, diagnostics, created_at, updated_at
-		FROM automation_inbox
-		WHERE automation_id = $1
-		ORDER BY started_at DESC, created_at DESC
-	`
-	rows, err := p.db.QueryContext(ctx, query, automationID)
-	if err != nil {
-		return nil, err
+func nonNilConfig(config map[string]string) map[string]string {
+	if config == nil {
+		return map[string]string{}
+	}
+	return config
+}
+
+const webhookDeliveryColumns = `id, workspace_id, subscription_id, event_type, run_id, event_seq, payload, status, attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at, updated_at`
+
+func scanWebhookDelivery(scanner interface{ Scan(dest ...any) error }) (store.WebhookDelivery, error) {
+	var (
+		delivery      store.WebhookDelivery
+		payload       []byte
+		nextAttemptAt sql.NullTime
+		lastAttemptAt sql.NullTime
+		createdAt     time.Time
+		updatedAt     time.Time
+	)
+	if err := scanner.Scan(
+		&delivery.ID,
+		&delivery.WorkspaceID,
+		&delivery.SubscriptionID,
+		&delivery.EventType,
+		&delivery.RunID,
+		&delivery.EventSeq,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&nextAttemptAt,
+		&lastAttemptAt,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return store.WebhookDelivery{}, err
+	}
+	delivery.Payload = decodeJSONMap(payload)
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = nextAttemptAt.Time.UTC().Format(time.RFC3339Nano)
 	}
+	if lastAttemptAt.Valid {
+		delivery.LastAttemptAt = lastAttemptAt.Time.UTC().Format(time.RFC3339Nano)
+	}
+	delivery.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+	delivery.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
+	return delivery, nil
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]store.WebhookDelivery, error) {
 	defer rows.Close()
-	results := make([]store.AutomationInboxEntry, 0)
+	results := make([]store.WebhookDelivery, 0)
 	for rows.Next() {
-		var (
-			entry            store.AutomationInboxEntry
-			runID            sql.NullString
-			phase            sql.NullString
-			completionReason sql.NullString
-			finalResponse    sql.NullString
-			errorValue       sql.NullString
-			completedAt      sql.NullTime
-			diagnosticsBytes []byte
-			createdAt        time.Time
-			updatedAt        time.Time
-			startedAt        time.Time
-		)
-		if err := rows.Scan(
-			&entry.ID,
-			&entry.AutomationID,
-			&runID,
-			&entry.Status,
-			&phase,
-			&completionReason,
-			&finalResponse,
-			&entry.TimedOut,
-			&errorValue,
-			&entry.Unread,
-			&entry.Trigger,
-			&startedAt,
-			&completedAt,
-			&diagnosticsBytes,
-			&createdAt,
-			&updatedAt,
-		); err != nil {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
 			return nil, err
 		}
-		if runID.Valid {
-			entry.RunID = runID.String
-		}
-		if phase.Valid {
-			entry.Phase = phase.String
-		}
-		if completionReason.Valid {
-			entry.CompletionReason = completionReason.String
-		}
-		if finalResponse.Valid {
-			entry.FinalResponse = finalResponse.String
-		}
-		if errorValue.Valid {
-			entry.Error = errorValue.String
-		}
-		entry.StartedAt = startedAt.UTC().Format(time.RFC3339Nano)
-		if completedAt.Valid {
-			entry.CompletedAt = completedAt.Time.UTC().Format(time.RFC3339Nano)
-		}
-		entry.Diagnostics = decodeJSONMap(diagnosticsBytes)
-		entry.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
-		entry.UpdatedAt = updatedAt.UTC().Format(time.RFC3339Nano)
-		results = append(results, entry)
+		results = append(results, delivery)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -1543,125 +3502,134 @@ func (p *PostgresStore) ListAutomationInbox(ctx context.Context, automationID st
 	return results, nil
 }
 
-func (p *PostgresStore) CreateAutomationInboxEntry(ctx context.Context, entry store.AutomationInboxEntry) error {
-	diagnostics := entry.Diagnostics
-	if diagnostics == nil {
-		diagnostics = map[string]any{}
-	}
-	encoded, err := json.Marshal(diagnostics)
+func (p *PostgresStore) CreateWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery) error {
+	payload, err := json.Marshal(delivery.Payload)
 	if err != nil {
 		return err
 	}
 	const query = `
-		INSERT INTO automation_inbox (
-			id, automation_id, run_id, status, phase, completion_reason, final_response, timed_out, error, unread, trigger, started_at, completed_at, diagnostics, created_at, updated_at
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14::jsonb, $15, $16
+		INSERT INTO webhook_deliveries (
+			id, workspace_id, subscription_id, event_type, run_id, event_seq, payload, status, attempts,
+			next_attempt_at, last_attempt_at, response_status, last_error, created_at, updated_at
 		)
+		SELECT $1, workspace_id, id, $3, $4, $5, $6::jsonb, $7, $8, $9, $10, $11, $12, $13, $14
+		FROM webhook_subscriptions
+		WHERE id::text = $2 AND workspace_id = $15
 	`
-	_, err = p.db.ExecContext(
+	result, err := p.db.ExecContext(
 		ctx,
 		query,
-		entry.ID,
-		entry.AutomationID,
-		nullString(entry.RunID),
-		entry.Status,
-		nullString(entry.Phase),
-		nullString(entry.CompletionReason),
-		nullString(entry.FinalResponse),
-		entry.TimedOut,
-		nullString(entry.Error),
-		entry.Unread,
-		entry.Trigger,
-		parseTimestampValue(entry.StartedAt),
-		parseTimestampNull(entry.CompletedAt),
-		encoded,
-		parseTimestampValue(entry.CreatedAt),
-		parseTimestampValue(entry.UpdatedAt),
+		delivery.ID,
+		delivery.SubscriptionID,
+		delivery.EventType,
+		delivery.RunID,
+		delivery.EventSeq,
+		payload,
+		delivery.Status,
+		delivery.Attempts,
+		parseTimestampNull(delivery.NextAttemptAt),
+		parseTimestampNull(delivery.LastAttemptAt),
+		delivery.ResponseStatus,
+		delivery.LastError,
+		parseTimestampValue(delivery.CreatedAt),
+		parseTimestampValue(delivery.UpdatedAt),
+		store.WorkspaceID(ctx),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if inserted, err := result.RowsAffected(); err == nil && inserted == 0 {
+		return store.ErrNotInWorkspace
+	}
+	return nil
 }
 
-func (p *PostgresStore) UpdateAutomationInboxEntry(ctx context.Context, entry store.AutomationInboxEntry) error {
-	diagnostics := entry.Diagnostics
-	if diagnostics == nil {
-		diagnostics = map[string]any{}
-	}
-	encoded, err := json.Marshal(diagnostics)
+func (p *PostgresStore) GetWebhookDelivery(ctx context.Context, deliveryID string) (*store.WebhookDelivery, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id::text = $1 AND workspace_id = $2`, deliveryID, store.WorkspaceID(ctx))
+	delivery, err := scanWebhookDelivery(row)
 	if err != nil {
-		return err
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return &delivery, nil
+}
+
+func (p *PostgresStore) UpdateWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery) error {
 	const query = `
-		UPDATE automation_inbox
+		UPDATE webhook_deliveries
 		SET
-			run_id = $3,
-			status = $4,
-			phase = $5,
-			completion_reason = $6,
-			final_response = $7,
-			timed_out = $8,
-			error = $9,
-			unread = $10,
-			trigger = $11,
-			started_at = $12,
-			completed_at = $13,
-			diagnostics = $14::jsonb,
-			updated_at = $15
-		WHERE automation_id = $1 AND id = $2
+			status = $2,
+			attempts = $3,
+			next_attempt_at = $4,
+			last_attempt_at = $5,
+			response_status = $6,
+			last_error = $7,
+			updated_at = $8
+		WHERE id::text = $1 AND workspace_id = $9
 	`
-	_, err = p.db.ExecContext(
+	_, err := p.db.ExecContext(
 		ctx,
 		query,
-		entry.AutomationID,
-		entry.ID,
-		nullString(entry.RunID),
-		entry.Status,
-		nullString(entry.Phase),
-		nullString(entry.CompletionReason),
-		nullString(entry.FinalResponse),
-		entry.TimedOut,
-		nullString(entry.Error),
-		entry.Unread,
-		entry.Trigger,
-		parseTimestampValue(entry.StartedAt),
-		parseTimestampNull(entry.CompletedAt),
-		encoded,
-		parseTimestampValue(entry.UpdatedAt),
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		parseTimestampNull(delivery.NextAttemptAt),
+		parseTimestampNull(delivery.LastAttemptAt),
+		delivery.ResponseStatus,
+		delivery.LastError,
+		parseTimestampValue(delivery.UpdatedAt),
+		store.WorkspaceID(ctx),
 	)
 	return err
 }
 
-func (p *PostgresStore) MarkAutomationInboxEntryRead(ctx context.Context, automationID string, entryID string) error {
-	_, err := p.db.ExecContext(
-		ctx,
-		`UPDATE automation_inbox SET unread = FALSE, updated_at = NOW() WHERE automation_id = $1 AND id = $2`,
-		automationID,
-		entryID,
-	)
-	return err
+func (p *PostgresStore) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]store.WebhookDelivery, error) {
+	statement := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE subscription_id::text = $1 AND workspace_id = $2 ORDER BY created_at DESC`
+	args := []any{subscriptionID, store.WorkspaceID(ctx)}
+	if limit > 0 {
+		args = append(args, limit)
+		statement += " LIMIT $3"
+	}
+	rows, err := p.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhookDeliveries(rows)
 }
 
-func (p *PostgresStore) MarkAutomationInboxReadAll(ctx context.Context, automationID string) error {
-	_, err := p.db.ExecContext(
-		ctx,
-		`UPDATE automation_inbox SET unread = FALSE, updated_at = NOW() WHERE automation_id = $1`,
-		automationID,
-	)
-	return err
+func (p *PostgresStore) ClaimWebhookDeliveries(ctx context.Context, now string, leaseUntil string, limit int) ([]store.WebhookDelivery, error) {
+	const query = `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+	rows, err := p.db.QueryContext(ctx, query, parseTimestampValue(now), parseTimestampValue(leaseUntil), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhookDeliveries(rows)
 }
 
 func (p *PostgresStore) insertMemoryEntry(ctx context.Context, entry store.MemoryEntry, metadata []byte) (bool, error) {
 	query := `
-		INSERT INTO memory_entries (id, content, metadata, embedding, created_at, updated_at)
-		VALUES ($1, $2, $3, NULL, $4, $5)
+		INSERT INTO memory_entries (id, content, metadata, embedding, created_at, updated_at, workspace_id)
+		VALUES ($1, $2, $3, NULL, $4, $5, $6)
 	`
-	params := []any{entry.ID, entry.Content, metadata, entry.CreatedAt, entry.UpdatedAt}
+	params := []any{entry.ID, entry.Content, metadata, entry.CreatedAt, entry.UpdatedAt, store.WorkspaceID(ctx)}
 	if len(entry.Embedding) == memoryEmbeddingDimensions {
 		query = `
-			INSERT INTO memory_entries (id, content, metadata, embedding, created_at, updated_at)
-			VALUES ($1, $2, $3, $4::vector, $5, $6)
+			INSERT INTO memory_entries (id, content, metadata, embedding, created_at, updated_at, workspace_id)
+			VALUES ($1, $2, $3, $4::vector, $5, $6, $7)
 		`
-		params = []any{entry.ID, entry.Content, metadata, formatVector(entry.Embedding), entry.CreatedAt, entry.UpdatedAt}
+		params = []any{entry.ID, entry.Content, metadata, formatVector(entry.Embedding), entry.CreatedAt, entry.UpdatedAt, store.WorkspaceID(ctx)}
 	}
 	if _, err := p.db.ExecContext(ctx, query, params...); err != nil {
 		return false, err
@@ -1672,29 +3640,29 @@ func (p *PostgresStore) insertMemoryEntry(ctx context.Context, entry store.Memor
 func (p *PostgresStore) insertMemoryEntryWithFingerprint(ctx context.Context, entry store.MemoryEntry, metadata []byte, fingerprint string) (bool, error) {
 	query := `
 		WITH inserted AS (
-			INSERT INTO memory_entries (id, content, metadata, embedding, created_at, updated_at)
-			SELECT $1, $2, $3, NULL, $4, $5
+			INSERT INTO memory_entries (id, content, metadata, embedding, created_at, updated_at, workspace_id)
+			SELECT $1, $2, $3, NULL, $4, $5, $7
 			WHERE NOT EXISTS (
-				SELECT 1 FROM memory_entries WHERE metadata->>'fingerprint' = $6
+				SELECT 1 FROM memory_entries WHERE metadata->>'fingerprint' = $6 AND workspace_id = $7
 			)
 			RETURNING id
 		)
 		SELECT COUNT(*) FROM inserted
 	`
-	params := []any{entry.ID, entry.Content, metadata, entry.CreatedAt, entry.UpdatedAt, fingerprint}
+	params := []any{entry.ID, entry.Content, metadata, entry.CreatedAt, entry.UpdatedAt, fingerprint, store.WorkspaceID(ctx)}
 	if len(entry.Embedding) == memoryEmbeddingDimensions {
 		query = `
 			WITH inserted AS (
-				INSERT INTO memory_entries (id, content, metadata, embedding, created_at, updated_at)
-				SELECT $1, $2, $3, $4::vector, $5, $6
+				INSERT INTO memory_entries (id, content, metadata, embedding, created_at, updated_at, workspace_id)
+				SELECT $1, $2, $3, $4::vector, $5, $6, $8
 				WHERE NOT EXISTS (
-					SELECT 1 FROM memory_entries WHERE metadata->>'fingerprint' = $7
+					SELECT 1 FROM memory_entries WHERE metadata->>'fingerprint' = $7 AND workspace_id = $8
 				)
 				RETURNING id
 			)
 			SELECT COUNT(*) FROM inserted
 		`
-		params = []any{entry.ID, entry.Content, metadata, formatVector(entry.Embedding), entry.CreatedAt, entry.UpdatedAt, fingerprint}
+		params = []any{entry.ID, entry.Content, metadata, formatVector(entry.Embedding), entry.CreatedAt, entry.UpdatedAt, fingerprint, store.WorkspaceID(ctx)}
 	}
 	var insertedCount int
 	if err := p.db.QueryRowContext(ctx, query, params...).Scan(&insertedCount); err != nil {
@@ -1852,6 +3820,8 @@ func applyRunStateUpdateTx(ctx context.Context, tx *sql.Tx, event store.RunEvent
 		status = "running"
 		phase = "planning"
 		resumedFrom = readDiagString(event.Payload, "resumed_from")
+	case store.RunTagsUpdatedEvent:
+		return applyRunTagsUpdateTx(ctx, tx, event)
 	default:
 		return nil
 	}
@@ -1887,6 +3857,29 @@ func applyRunStateUpdateTx(ctx context.Context, tx *sql.Tx, event store.RunEvent
 	return err
 }
 
+func applyRunTagsUpdateTx(ctx context.Context, tx *sql.Tx, event store.RunEvent) error {
+	incoming := store.RunTagsFromEvent(event)
+	if len(incoming) == 0 {
+		return nil
+	}
+	var raw []byte
+	if err := tx.QueryRowContext(ctx, `SELECT tags FROM runs WHERE id = $1 FOR UPDATE`, event.RunID).Scan(&raw); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+	tags := store.MergeRunTags(decodeStringSlice(raw), incoming)
+	_, err := tx.ExecContext(
+		ctx,
+		`UPDATE runs SET tags = $2::jsonb, updated_at = $3 WHERE id = $1`,
+		event.RunID,
+		encodeStringSlice(tags),
+		parseTimestampValue(event.Timestamp),
+	)
+	return err
+}
+
 func parseTimestampValue(value string) time.Time {
 	parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
 	if err != nil {
@@ -1915,6 +3908,43 @@ func nullString(value string) any {
 	return value
 }
 
+// automationStageRow is the JSON form of a pipeline stage in
+// automations.stages.
+type automationStageRow struct {
+	Name              string   `json:"name"`
+	Prompt            string   `json:"prompt"`
+	ConditionType     string   `json:"condition_type,omitempty"`
+	ConditionKeywords []string `json:"condition_keywords,omitempty"`
+	ConditionMatch    string   `json:"condition_match,omitempty"`
+	ConditionQuestion string   `json:"condition_question,omitempty"`
+	ConditionStage    string   `json:"condition_stage,omitempty"`
+	OnFalse           string   `json:"on_false,omitempty"`
+}
+
+func encodeAutomationStages(stages []store.AutomationStage) []byte {
+	rows := make([]automationStageRow, 0, len(stages))
+	for _, stage := range stages {
+		rows = append(rows, automationStageRow(stage))
+	}
+	raw, err := json.Marshal(rows)
+	if err != nil {
+		return []byte("[]")
+	}
+	return raw
+}
+
+func decodeAutomationStages(raw []byte) []store.AutomationStage {
+	rows := []automationStageRow{}
+	if len(raw) == 0 || json.Unmarshal(raw, &rows) != nil || len(rows) == 0 {
+		return nil
+	}
+	stages := make([]store.AutomationStage, 0, len(rows))
+	for _, row := range rows {
+		stages = append(stages, store.AutomationStage(row))
+	}
+	return stages
+}
+
 func decodeStringSlice(raw []byte) []string {
 	if len(raw) == 0 {
 		return nil
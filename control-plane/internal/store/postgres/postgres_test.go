@@ -139,6 +139,11 @@ func cleanDB(t *testing.T) {
 		artifacts,
 		runs,
 		llm_settings,
+		llm_route_rules,
+		llm_provider_profiles,
+		secrets,
+		redaction_policies,
+		api_keys,
 		skill_files,
 		skills,
 		automation_inbox,
@@ -146,11 +151,21 @@ func cleanDB(t *testing.T) {
 		context_nodes,
 		memory_settings,
 		memory_entries,
-		personality_settings
+		personality_settings,
+		workspace_members,
+		users,
+		audit_log,
+		webhook_deliveries,
+		webhook_subscriptions,
+		notification_channels
 		CASCADE`)
 	if err != nil {
 		t.Fatalf("clean db: %v", err)
 	}
+	// The default workspace is seeded by the migration and must survive.
+	if _, err := testDB.Exec(`DELETE FROM workspaces WHERE id <> 'default'`); err != nil {
+		t.Fatalf("clean workspaces: %v", err)
+	}
 }
 
 func newStore(t *testing.T) *PostgresStore {
@@ -551,6 +566,9 @@ func TestUpsertLLMSettings(t *testing.T) {
 	}
 
 	settings.Provider = "local"
+	settings.UtilityProvider = "openrouter"
+	settings.UtilityModel = "small"
+	settings.UtilityBaseURL = "https://openrouter.example/api/v1"
 	settings.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
 	if err := pgStore.UpsertLLMSettings(ctx, settings); err != nil {
 		t.Fatalf("upsert llm settings: %v", err)
@@ -563,6 +581,408 @@ func TestUpsertLLMSettings(t *testing.T) {
 	if fetched == nil || fetched.Provider != "local" {
 		t.Fatalf("expected updated settings")
 	}
+	if fetched.UtilityProvider != "openrouter" || fetched.UtilityModel != "small" || fetched.UtilityBaseURL != "https://openrouter.example/api/v1" {
+		t.Fatalf("expected utility model to round-trip, got %+v", fetched)
+	}
+}
+
+func TestLLMRouteRules(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	rule := storepkg.LLMRouteRule{
+		ID:        "00000000-0000-0000-0000-000000000101",
+		Name:      "research",
+		Priority:  10,
+		TaskTypes: []string{"research"},
+		Tags:      []string{"news"},
+		Route:     "openrouter:fast",
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := pgStore.CreateLLMRouteRule(ctx, rule); err != nil {
+		t.Fatalf("create route rule: %v", err)
+	}
+	rule.Enabled = false
+	rule.Route = "openai:gpt-4.1-mini"
+	if err := pgStore.UpdateLLMRouteRule(ctx, rule); err != nil {
+		t.Fatalf("update route rule: %v", err)
+	}
+	rules, err := pgStore.ListLLMRouteRules(ctx)
+	if err != nil {
+		t.Fatalf("list route rules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Enabled || rules[0].Route != "openai:gpt-4.1-mini" || len(rules[0].TaskTypes) != 1 {
+		t.Fatalf("unexpected route rules: %+v", rules)
+	}
+	if err := pgStore.DeleteLLMRouteRule(ctx, rule.ID); err != nil {
+		t.Fatalf("delete route rule: %v", err)
+	}
+	fetched, err := pgStore.GetLLMRouteRule(ctx, rule.ID)
+	if err != nil {
+		t.Fatalf("get route rule: %v", err)
+	}
+	if fetched != nil {
+		t.Fatalf("expected route rule to be deleted")
+	}
+}
+
+func TestLLMProviderProfiles(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	profile := storepkg.LLMProviderProfile{
+		ID:        "00000000-0000-0000-0000-000000000201",
+		Name:      "work-openrouter",
+		Provider:  "openrouter",
+		Model:     "openai/gpt-4o",
+		APIKeyEnc: "enc",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := pgStore.CreateLLMProviderProfile(ctx, profile); err != nil {
+		t.Fatalf("create profile: %v", err)
+	}
+	duplicate := profile
+	duplicate.ID = "00000000-0000-0000-0000-000000000202"
+	if err := pgStore.CreateLLMProviderProfile(ctx, duplicate); err == nil {
+		t.Fatalf("expected duplicate profile name to fail")
+	}
+	profile.BaseURL = "https://openrouter.example.test/v1"
+	if err := pgStore.UpdateLLMProviderProfile(ctx, profile); err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+	profiles, err := pgStore.ListLLMProviderProfiles(ctx)
+	if err != nil {
+		t.Fatalf("list profiles: %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].BaseURL != "https://openrouter.example.test/v1" || profiles[0].APIKeyEnc != "enc" {
+		t.Fatalf("unexpected profiles: %+v", profiles)
+	}
+	if err := pgStore.DeleteLLMProviderProfile(ctx, profile.ID); err != nil {
+		t.Fatalf("delete profile: %v", err)
+	}
+	fetched, err := pgStore.GetLLMProviderProfile(ctx, profile.ID)
+	if err != nil {
+		t.Fatalf("get profile: %v", err)
+	}
+	if fetched != nil {
+		t.Fatalf("expected profile to be deleted")
+	}
+}
+
+func TestRotateSecrets(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if err := pgStore.UpsertLLMSettings(ctx, storepkg.LLMSettings{Mode: "remote", Provider: "openai", APIKeyEnc: "old:settings", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("upsert settings: %v", err)
+	}
+	for i, enc := range []string{"old:good", "old:bad", ""} {
+		profile := storepkg.LLMProviderProfile{
+			ID:        fmt.Sprintf("00000000-0000-0000-0000-00000000030%d", i),
+			Name:      fmt.Sprintf("profile-%d", i),
+			Provider:  "openai",
+			APIKeyEnc: enc,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := pgStore.CreateLLMProviderProfile(ctx, profile); err != nil {
+			t.Fatalf("create profile: %v", err)
+		}
+	}
+	if err := pgStore.CreateSecret(ctx, storepkg.Secret{ID: "00000000-0000-0000-0000-000000000310", Name: "TOKEN", ValueEnc: "old:token", CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("create secret: %v", err)
+	}
+	report, err := pgStore.RotateSecrets(ctx, func(ciphertext string) (string, bool, error) {
+		if ciphertext == "old:bad" {
+			return "", false, fmt.Errorf("unknown key")
+		}
+		return strings.Replace(ciphertext, "old:", "new:", 1), true, nil
+	})
+	if err != nil {
+		t.Fatalf("rotate secrets: %v", err)
+	}
+	if report.Rotated != 3 || len(report.Failed) != 1 || report.Failed[0].ID != "00000000-0000-0000-0000-000000000301" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	settings, err := pgStore.GetLLMSettings(ctx)
+	if err != nil {
+		t.Fatalf("get settings: %v", err)
+	}
+	if settings.APIKeyEnc != "new:settings" {
+		t.Fatalf("expected rotated settings key, got %q", settings.APIKeyEnc)
+	}
+	bad, err := pgStore.GetLLMProviderProfile(ctx, "00000000-0000-0000-0000-000000000301")
+	if err != nil {
+		t.Fatalf("get profile: %v", err)
+	}
+	if bad.APIKeyEnc != "old:bad" {
+		t.Fatalf("expected undecryptable key to be untouched, got %q", bad.APIKeyEnc)
+	}
+	secret, err := pgStore.GetSecret(ctx, "00000000-0000-0000-0000-000000000310")
+	if err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if secret.ValueEnc != "new:token" {
+		t.Fatalf("expected rotated secret, got %q", secret.ValueEnc)
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	secret := storepkg.Secret{
+		ID:             "00000000-0000-0000-0000-000000000401",
+		Name:           "API_TOKEN",
+		Description:    "internal API",
+		ValueEnc:       "enc",
+		PolicyProfiles: []string{"ops"},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := pgStore.CreateSecret(ctx, secret); err != nil {
+		t.Fatalf("create secret: %v", err)
+	}
+	duplicate := secret
+	duplicate.ID = "00000000-0000-0000-0000-000000000402"
+	if err := pgStore.CreateSecret(ctx, duplicate); err == nil {
+		t.Fatalf("expected duplicate secret name to fail")
+	}
+	secret.PolicyProfiles = []string{"ops", "admin"}
+	if err := pgStore.UpdateSecret(ctx, secret); err != nil {
+		t.Fatalf("update secret: %v", err)
+	}
+	listed, err := pgStore.ListSecrets(ctx)
+	if err != nil {
+		t.Fatalf("list secrets: %v", err)
+	}
+	if len(listed) != 1 || len(listed[0].PolicyProfiles) != 2 || listed[0].ValueEnc != "enc" {
+		t.Fatalf("unexpected secrets: %+v", listed)
+	}
+	if err := pgStore.DeleteSecret(ctx, secret.ID); err != nil {
+		t.Fatalf("delete secret: %v", err)
+	}
+	fetched, err := pgStore.GetSecret(ctx, secret.ID)
+	if err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if fetched != nil {
+		t.Fatalf("expected secret to be deleted")
+	}
+}
+
+func TestRedactionPolicies(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	policy := storepkg.RedactionPolicy{
+		PolicyProfile: "ops",
+		VaultSecrets:  true,
+		Emails:        true,
+		CustomRules:   []storepkg.RedactionRule{{Name: "ticket", Pattern: `TCK-[0-9]+`}},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	require.NoError(t, pgStore.UpsertRedactionPolicy(ctx, policy))
+	policy.KeyFormats = true
+	require.NoError(t, pgStore.UpsertRedactionPolicy(ctx, policy))
+
+	listed, err := pgStore.ListRedactionPolicies(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.True(t, listed[0].KeyFormats)
+	require.Equal(t, policy.CustomRules, listed[0].CustomRules)
+
+	require.NoError(t, pgStore.DeleteRedactionPolicy(ctx, "ops"))
+	fetched, err := pgStore.GetRedactionPolicy(ctx, "ops")
+	require.NoError(t, err)
+	require.Nil(t, fetched)
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	key := storepkg.APIKey{
+		ID:        "00000000-0000-0000-0000-000000000501",
+		Name:      "ci",
+		Prefix:    "gvn_abcd",
+		KeyHash:   "hash-1",
+		Scopes:    []string{"runs:read"},
+		CreatedAt: now,
+	}
+	require.NoError(t, pgStore.CreateAPIKey(ctx, key))
+	duplicate := key
+	duplicate.ID = "00000000-0000-0000-0000-000000000502"
+	require.Error(t, pgStore.CreateAPIKey(ctx, duplicate))
+
+	fetched, err := pgStore.GetAPIKeyByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	require.NotNil(t, fetched)
+	require.Equal(t, []string{"runs:read"}, fetched.Scopes)
+	require.Empty(t, fetched.LastUsedAt)
+
+	require.NoError(t, pgStore.TouchAPIKey(ctx, key.ID, now))
+	fetched, err = pgStore.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, fetched.LastUsedAt)
+
+	listed, err := pgStore.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+
+	require.NoError(t, pgStore.DeleteAPIKey(ctx, key.ID))
+	missing, err := pgStore.GetAPIKeyByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
+func TestWorkspaceScoping(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	require.NoError(t, pgStore.CreateWorkspace(ctx, storepkg.Workspace{ID: "team", Name: "Team", CreatedAt: now, UpdatedAt: now}))
+	teamCtx := storepkg.WithWorkspace(ctx, "team")
+
+	user := storepkg.User{ID: uuid.NewString(), Name: "Ana", Email: "ana@example.com", CreatedAt: now}
+	require.NoError(t, pgStore.CreateUser(ctx, user))
+	require.NoError(t, pgStore.UpsertWorkspaceMember(ctx, storepkg.WorkspaceMember{WorkspaceID: "team", UserID: user.ID, Role: "admin", CreatedAt: now}))
+	members, err := pgStore.ListWorkspaceMembers(ctx, "team")
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	require.Equal(t, "admin", members[0].Role)
+
+	runID := uuid.NewString()
+	require.NoError(t, pgStore.CreateRun(teamCtx, storepkg.Run{ID: runID, Status: "running", UserID: user.ID, CreatedBy: "api_key:ana", CreatedAt: now, UpdatedAt: now}))
+	run, err := pgStore.GetRun(teamCtx, runID)
+	require.NoError(t, err)
+	require.Equal(t, "team", run.WorkspaceID)
+	require.Equal(t, user.ID, run.UserID)
+	hidden, err := pgStore.GetRun(ctx, runID)
+	require.NoError(t, err)
+	require.Nil(t, hidden)
+	workspaceID, err := pgStore.GetRunWorkspace(ctx, runID)
+	require.NoError(t, err)
+	require.Equal(t, "team", workspaceID)
+
+	err = pgStore.AddMessage(ctx, storepkg.Message{ID: uuid.NewString(), RunID: runID, Role: "user", Content: "hi", Sequence: 1, CreatedAt: now})
+	require.ErrorIs(t, err, storepkg.ErrNotInWorkspace)
+
+	require.NoError(t, pgStore.UpsertLLMSettings(ctx, storepkg.LLMSettings{Mode: "remote", Provider: "openai", Model: "base", CreatedAt: now, UpdatedAt: now}))
+	inherited, err := pgStore.GetLLMSettings(teamCtx)
+	require.NoError(t, err)
+	require.Equal(t, "base", inherited.Model)
+	require.NoError(t, pgStore.UpsertLLMSettings(teamCtx, storepkg.LLMSettings{Mode: "remote", Provider: "openai", Model: "team", CreatedAt: now, UpdatedAt: now}))
+	override, err := pgStore.GetLLMSettings(teamCtx)
+	require.NoError(t, err)
+	require.Equal(t, "team", override.Model)
+
+	teamSecretID := uuid.NewString()
+	require.NoError(t, pgStore.CreateSecret(teamCtx, storepkg.Secret{ID: teamSecretID, Name: "TOKEN", ValueEnc: "old:team", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, pgStore.CreateSecret(ctx, storepkg.Secret{ID: uuid.NewString(), Name: "TOKEN", ValueEnc: "old:default", CreatedAt: now, UpdatedAt: now}))
+	teamSecrets, err := pgStore.ListSecrets(teamCtx)
+	require.NoError(t, err)
+	require.Len(t, teamSecrets, 1)
+	require.Equal(t, teamSecretID, teamSecrets[0].ID)
+	foreignSecret, err := pgStore.GetSecret(ctx, teamSecretID)
+	require.NoError(t, err)
+	require.Nil(t, foreignSecret)
+	require.NoError(t, pgStore.DeleteSecret(ctx, teamSecretID))
+
+	require.NoError(t, pgStore.CreateLLMProviderProfile(teamCtx, storepkg.LLMProviderProfile{ID: uuid.NewString(), Name: "fast", Provider: "openai", Model: "gpt-4.1", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, pgStore.CreateLLMProviderProfile(ctx, storepkg.LLMProviderProfile{ID: uuid.NewString(), Name: "fast", Provider: "openai", Model: "gpt-4.1", CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, pgStore.CreateLLMRouteRule(teamCtx, storepkg.LLMRouteRule{ID: uuid.NewString(), Name: "team", Route: "fast", Enabled: true, CreatedAt: now, UpdatedAt: now}))
+	defaultRules, err := pgStore.ListLLMRouteRules(ctx)
+	require.NoError(t, err)
+	require.Empty(t, defaultRules)
+
+	require.NoError(t, pgStore.UpsertRedactionPolicy(teamCtx, storepkg.RedactionPolicy{PolicyProfile: "default", Emails: true, CreatedAt: now, UpdatedAt: now}))
+	require.NoError(t, pgStore.UpsertRedactionPolicy(ctx, storepkg.RedactionPolicy{PolicyProfile: "default", CreatedAt: now, UpdatedAt: now}))
+	teamPolicy, err := pgStore.GetRedactionPolicy(teamCtx, "default")
+	require.NoError(t, err)
+	require.True(t, teamPolicy.Emails)
+
+	teamKey := storepkg.APIKey{ID: uuid.NewString(), Name: "team", Prefix: "gvn_team", KeyHash: "hash-team", WorkspaceID: "team", CreatedAt: now}
+	require.NoError(t, pgStore.CreateAPIKey(ctx, teamKey))
+	defaultKeys, err := pgStore.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Empty(t, defaultKeys)
+	require.NoError(t, pgStore.DeleteAPIKey(ctx, teamKey.ID))
+	stillThere, err := pgStore.GetAPIKey(teamCtx, teamKey.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stillThere)
+
+	report, err := pgStore.RotateSecrets(teamCtx, func(ciphertext string) (string, bool, error) {
+		return strings.Replace(ciphertext, "old:", "new:", 1), true, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Rotated)
+	defaultSecrets, err := pgStore.ListSecrets(ctx)
+	require.NoError(t, err)
+	require.Len(t, defaultSecrets, 1)
+	require.Equal(t, "old:default", defaultSecrets[0].ValueEnc)
+
+	require.NoError(t, pgStore.DeleteWorkspace(ctx, "team"))
+	workspaceID, err = pgStore.GetRunWorkspace(ctx, runID)
+	require.NoError(t, err)
+	require.Empty(t, workspaceID)
+	teamSecrets, err = pgStore.ListSecrets(teamCtx)
+	require.NoError(t, err)
+	require.Empty(t, teamSecrets)
+}
+
+func TestAuditEntries(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	base := time.Now().UTC()
+	for idx, action := range []string{"skill.create", "automation.update", "skill.delete"} {
+		require.NoError(t, pgStore.AppendAuditEntry(ctx, storepkg.AuditEntry{
+			ID:         uuid.NewString(),
+			Actor:      "api_key:ci",
+			Action:     action,
+			TargetType: strings.Split(action, ".")[0],
+			TargetID:   "target-1",
+			After:      map[string]any{"name": "daily"},
+			Method:     "POST",
+			Path:       "/skills",
+			StatusCode: 200,
+			CreatedAt:  base.Add(time.Duration(idx) * time.Second).Format(time.RFC3339Nano),
+		}))
+	}
+	require.NoError(t, pgStore.AppendAuditEntry(storepkg.WithWorkspace(ctx, "team"), storepkg.AuditEntry{
+		ID: uuid.NewString(), Action: "skill.create", Method: "POST", Path: "/skills", StatusCode: 200, CreatedAt: base.Format(time.RFC3339Nano),
+	}))
+
+	entries, err := pgStore.ListAuditEntries(ctx, storepkg.AuditQuery{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "skill.delete", entries[0].Action)
+	require.Equal(t, "daily", entries[2].After["name"])
+	require.Empty(t, entries[2].Before)
+
+	page, err := pgStore.ListAuditEntries(ctx, storepkg.AuditQuery{BeforeSeq: entries[0].Seq, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, "automation.update", page[0].Action)
+
+	skills, err := pgStore.ListAuditEntries(ctx, storepkg.AuditQuery{TargetType: "skill", Since: base.Add(time.Second).Format(time.RFC3339Nano)})
+	require.NoError(t, err)
+	require.Len(t, skills, 1)
+	require.Equal(t, "skill.delete", skills[0].Action)
+
+	_, err = pgStore.ListAuditEntries(ctx, storepkg.AuditQuery{Until: "yesterday"})
+	require.Error(t, err)
 }
 
 func TestListSkills(t *testing.T) {
@@ -1224,6 +1644,34 @@ func TestListEvents(t *testing.T) {
 	}
 }
 
+func TestListEvents_RedactionMarkersAndActor(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+
+	run := storepkg.Run{ID: uuid.NewString(), Status: "running", PolicyProfile: "ops", CreatedAt: time.Now().UTC().Format(time.RFC3339Nano), UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano)}
+	require.NoError(t, pgStore.CreateRun(ctx, run))
+
+	markers := []storepkg.RedactionMarker{{Detector: "key_format", Label: "github_token", Path: "output.stdout", Count: 2}}
+	require.NoError(t, pgStore.AppendEvent(ctx, storepkg.RunEvent{RunID: run.ID, Seq: 1, Type: "tool.completed", Source: "tool", RedactionMarkers: markers, Actor: "service:tool_runner"}))
+	require.NoError(t, pgStore.AppendEvent(ctx, storepkg.RunEvent{RunID: run.ID, Seq: 2, Type: "tool.completed", Source: "tool"}))
+
+	events, err := pgStore.ListEvents(ctx, run.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, markers, events[0].RedactionMarkers)
+	require.Empty(t, events[1].RedactionMarkers)
+	require.Equal(t, "service:tool_runner", events[0].Actor)
+	require.Empty(t, events[1].Actor)
+
+	fetched, err := pgStore.GetRun(ctx, run.ID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched)
+	require.Equal(t, "ops", fetched.PolicyProfile)
+	missing, err := pgStore.GetRun(ctx, uuid.NewString())
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
 func TestListEvents_InvalidPayload(t *testing.T) {
 	ctx := context.Background()
 	pgStore := newStore(t)
@@ -1326,3 +1774,607 @@ func TestListSkills_ClosedDB(t *testing.T) {
 		t.Fatalf("expected error on closed db")
 	}
 }
+
+func TestWebhookSubscriptionsAndDeliveries(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	base := time.Now().UTC()
+	require.NoError(t, pgStore.CreateWorkspace(ctx, storepkg.Workspace{ID: "team", Name: "Team", CreatedAt: base.Format(time.RFC3339Nano), UpdatedAt: base.Format(time.RFC3339Nano)}))
+	teamCtx := storepkg.WithWorkspace(ctx, "team")
+
+	subscription := storepkg.WebhookSubscription{
+		ID:         uuid.NewString(),
+		Name:       "ops",
+		URL:        "https://example.com/hook",
+		EventTypes: []string{"run.completed", "artifact.*"},
+		Tags:       []string{"nightly"},
+		SecretEnc:  "enc",
+		Enabled:    true,
+		CreatedAt:  base.Format(time.RFC3339Nano),
+		UpdatedAt:  base.Format(time.RFC3339Nano),
+	}
+	require.NoError(t, pgStore.CreateWebhookSubscription(ctx, subscription))
+
+	fetched, err := pgStore.GetWebhookSubscription(ctx, subscription.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"run.completed", "artifact.*"}, fetched.EventTypes)
+	require.Equal(t, []string{"nightly"}, fetched.Tags)
+	hidden, err := pgStore.GetWebhookSubscription(teamCtx, subscription.ID)
+	require.NoError(t, err)
+	require.Nil(t, hidden)
+
+	fetched.Enabled = false
+	fetched.UpdatedAt = base.Add(time.Second).Format(time.RFC3339Nano)
+	require.NoError(t, pgStore.UpdateWebhookSubscription(ctx, *fetched))
+	listed, err := pgStore.ListWebhookSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.False(t, listed[0].Enabled)
+
+	dueID := uuid.NewString()
+	for idx, id := range []string{dueID, uuid.NewString()} {
+		require.NoError(t, pgStore.CreateWebhookDelivery(ctx, storepkg.WebhookDelivery{
+			ID:             id,
+			SubscriptionID: subscription.ID,
+			EventType:      "run.completed",
+			RunID:          "run-1",
+			EventSeq:       int64(idx + 1),
+			Payload:        map[string]any{"type": "run.completed"},
+			Status:         "pending",
+			NextAttemptAt:  base.Add(time.Duration(idx) * time.Hour).Format(time.RFC3339Nano),
+			CreatedAt:      base.Add(time.Duration(idx) * time.Second).Format(time.RFC3339Nano),
+			UpdatedAt:      base.Format(time.RFC3339Nano),
+		}))
+	}
+	require.ErrorIs(t, pgStore.CreateWebhookDelivery(teamCtx, storepkg.WebhookDelivery{ID: uuid.NewString(), SubscriptionID: subscription.ID, EventType: "run.completed", Status: "pending"}), storepkg.ErrNotInWorkspace)
+
+	claimed, err := pgStore.ClaimWebhookDeliveries(ctx, base.Add(time.Minute).Format(time.RFC3339Nano), base.Add(2*time.Minute).Format(time.RFC3339Nano), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, dueID, claimed[0].ID)
+	require.Equal(t, storepkg.DefaultWorkspaceID, claimed[0].WorkspaceID)
+	require.Equal(t, "run.completed", claimed[0].Payload["type"])
+
+	claimed[0].Status = "delivered"
+	claimed[0].Attempts = 1
+	claimed[0].ResponseStatus = 204
+	claimed[0].LastAttemptAt = base.Add(time.Minute).Format(time.RFC3339Nano)
+	claimed[0].UpdatedAt = claimed[0].LastAttemptAt
+	require.NoError(t, pgStore.UpdateWebhookDelivery(ctx, claimed[0]))
+	delivery, err := pgStore.GetWebhookDelivery(ctx, dueID)
+	require.NoError(t, err)
+	require.Equal(t, "delivered", delivery.Status)
+	require.Equal(t, 204, delivery.ResponseStatus)
+
+	deliveries, err := pgStore.ListWebhookDeliveries(ctx, subscription.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.NotEqual(t, dueID, deliveries[0].ID)
+
+	require.NoError(t, pgStore.DeleteWebhookSubscription(ctx, subscription.ID))
+	deliveries, err = pgStore.ListWebhookDeliveries(ctx, subscription.ID, 0)
+	require.NoError(t, err)
+	require.Empty(t, deliveries)
+}
+
+func TestNotificationChannelsAndAutomationPolicy(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	base := time.Now().UTC()
+	require.NoError(t, pgStore.CreateWorkspace(ctx, storepkg.Workspace{ID: "team", Name: "Team", CreatedAt: base.Format(time.RFC3339Nano), UpdatedAt: base.Format(time.RFC3339Nano)}))
+	teamCtx := storepkg.WithWorkspace(ctx, "team")
+
+	channel := storepkg.NotificationChannel{
+		ID:         uuid.NewString(),
+		Name:       "ops",
+		Type:       "ntfy",
+		Config:     map[string]string{"topic": "alerts"},
+		SecretsEnc: "enc",
+		Enabled:    true,
+		CreatedAt:  base.Format(time.RFC3339Nano),
+		UpdatedAt:  base.Format(time.RFC3339Nano),
+	}
+	require.NoError(t, pgStore.CreateNotificationChannel(ctx, channel))
+
+	fetched, err := pgStore.GetNotificationChannel(ctx, channel.ID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"topic": "alerts"}, fetched.Config)
+	require.Equal(t, "enc", fetched.SecretsEnc)
+	hidden, err := pgStore.GetNotificationChannel(teamCtx, channel.ID)
+	require.NoError(t, err)
+	require.Nil(t, hidden)
+
+	fetched.Enabled = false
+	fetched.Config["server"] = "https://ntfy.example.com"
+	fetched.UpdatedAt = base.Add(time.Second).Format(time.RFC3339Nano)
+	require.NoError(t, pgStore.UpdateNotificationChannel(ctx, *fetched))
+	channels, err := pgStore.ListNotificationChannels(ctx)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	require.False(t, channels[0].Enabled)
+	require.Equal(t, "https://ntfy.example.com", channels[0].Config["server"])
+
+	automation := storepkg.Automation{
+		ID:                   uuid.NewString(),
+		Name:                 "Daily Brief",
+		Prompt:               "Summarize",
+		Days:                 []string{"mon"},
+		TimeOfDay:            "09:00",
+		Timezone:             "UTC",
+		NotificationChannels: []string{channel.ID},
+		NotifyOn:             "failure",
+		CreatedAt:            base.Format(time.RFC3339Nano),
+		UpdatedAt:            base.Format(time.RFC3339Nano),
+	}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	stored, err := pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{channel.ID}, stored.NotificationChannels)
+	require.Equal(t, "failure", stored.NotifyOn)
+	stored.NotifyOn = "change"
+	stored.NotificationChannels = nil
+	require.NoError(t, pgStore.UpdateAutomation(ctx, *stored))
+	stored, err = pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Empty(t, stored.NotificationChannels)
+	require.Equal(t, "change", stored.NotifyOn)
+
+	require.NoError(t, pgStore.DeleteNotificationChannel(ctx, channel.ID))
+	channels, err = pgStore.ListNotificationChannels(ctx)
+	require.NoError(t, err)
+	require.Empty(t, channels)
+}
+
+func TestAutomationInboxChangeFields(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	automation := storepkg.Automation{
+		ID:              uuid.NewString(),
+		Name:            "Daily Brief",
+		Prompt:          "Summarize",
+		Days:            []string{"mon"},
+		TimeOfDay:       "09:00",
+		Timezone:        "UTC",
+		ChangeThreshold: 0.8,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	stored, err := pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0.8, stored.ChangeThreshold)
+
+	entry := storepkg.AutomationInboxEntry{
+		ID:           uuid.NewString(),
+		AutomationID: automation.ID,
+		RunID:        uuid.NewString(),
+		Status:       "running",
+		Unread:       true,
+		Trigger:      "manual",
+		StartedAt:    now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	require.NoError(t, pgStore.CreateAutomationInboxEntry(ctx, entry))
+	entries, err := pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Nil(t, entries[0].Similarity)
+	require.False(t, entries[0].Unchanged)
+
+	similarity := 0.95
+	entry.Status = "completed"
+	entry.ChangeSummary = "Unchanged: 95% similar to the previous result."
+	entry.Similarity = &similarity
+	entry.Unchanged = true
+	require.NoError(t, pgStore.UpdateAutomationInboxEntry(ctx, entry))
+	entries, err = pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, entry.ChangeSummary, entries[0].ChangeSummary)
+	require.NotNil(t, entries[0].Similarity)
+	require.Equal(t, 0.95, *entries[0].Similarity)
+	require.True(t, entries[0].Unchanged)
+}
+
+func TestAutomationDigestFields(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	member := uuid.NewString()
+	digest := storepkg.Automation{
+		ID:                  uuid.NewString(),
+		Name:                "Weekly digest",
+		Days:                []string{"mon"},
+		TimeOfDay:           "08:00",
+		Timezone:            "UTC",
+		Kind:                "digest",
+		DigestAutomationIDs: []string{member},
+		DigestCadence:       "weekly",
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	require.NoError(t, pgStore.CreateAutomation(ctx, digest))
+	stored, err := pgStore.GetAutomation(ctx, digest.ID)
+	require.NoError(t, err)
+	require.Equal(t, "digest", stored.Kind)
+	require.Equal(t, []string{member}, stored.DigestAutomationIDs)
+	require.Equal(t, "weekly", stored.DigestCadence)
+
+	stored.DigestAutomationIDs = nil
+	stored.DigestCadence = "daily"
+	require.NoError(t, pgStore.UpdateAutomation(ctx, *stored))
+	stored, err = pgStore.GetAutomation(ctx, digest.ID)
+	require.NoError(t, err)
+	require.Empty(t, stored.DigestAutomationIDs)
+	require.Equal(t, "daily", stored.DigestCadence)
+}
+
+func TestAutomationScheduleFields(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	automation := storepkg.Automation{
+		ID:             uuid.NewString(),
+		Name:           "Cron",
+		Prompt:         "p",
+		Days:           []string{"mon"},
+		TimeOfDay:      "09:00",
+		Timezone:       "Europe/Berlin",
+		ScheduleType:   "cron",
+		CronExpression: "*/30 8-18 * * 1-5",
+		BlackoutDates:  []string{"2026-12-24", "2026-12-25"},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	stored, err := pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, "cron", stored.ScheduleType)
+	require.Equal(t, "*/30 8-18 * * 1-5", stored.CronExpression)
+	require.Equal(t, []string{"2026-12-24", "2026-12-25"}, stored.BlackoutDates)
+	require.Empty(t, stored.RunAt)
+
+	stored.ScheduleType = "once"
+	stored.CronExpression = ""
+	stored.RunAt = "2026-12-31T23:00:00Z"
+	stored.BlackoutDates = nil
+	require.NoError(t, pgStore.UpdateAutomation(ctx, *stored))
+	stored, err = pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, "once", stored.ScheduleType)
+	require.Equal(t, "2026-12-31T23:00:00Z", stored.RunAt)
+	require.Empty(t, stored.BlackoutDates)
+
+	stored.ScheduleType = "interval"
+	stored.RunAt = ""
+	stored.IntervalMinutes = 45
+	require.NoError(t, pgStore.UpdateAutomation(ctx, *stored))
+	stored, err = pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, 45, stored.IntervalMinutes)
+	require.Empty(t, stored.RunAt)
+}
+
+func TestAutomationPolicyFields(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	automation := storepkg.Automation{
+		ID:                   uuid.NewString(),
+		Name:                 "Retried",
+		Prompt:               "p",
+		Days:                 []string{"mon"},
+		TimeOfDay:            "09:00",
+		Timezone:             "UTC",
+		CatchUp:              "all",
+		CatchUpMaxAgeMinutes: 720,
+		RetryMaxAttempts:     3,
+		RetryBackoffSeconds:  30,
+		RetryAt:              "2026-03-02T10:00:30Z",
+		RetryAttempt:         2,
+		RetryScheduledFor:    "2026-03-02T09:00:00Z",
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	stored, err := pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, "all", stored.CatchUp)
+	require.Equal(t, 720, stored.CatchUpMaxAgeMinutes)
+	require.Equal(t, 3, stored.RetryMaxAttempts)
+	require.Equal(t, 30, stored.RetryBackoffSeconds)
+	require.Equal(t, "2026-03-02T10:00:30Z", stored.RetryAt)
+	require.Equal(t, 2, stored.RetryAttempt)
+	require.Equal(t, "2026-03-02T09:00:00Z", stored.RetryScheduledFor)
+
+	stored.RetryAt = ""
+	stored.RetryAttempt = 0
+	stored.RetryScheduledFor = ""
+	require.NoError(t, pgStore.UpdateAutomation(ctx, *stored))
+	stored, err = pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Empty(t, stored.RetryAt)
+	require.Empty(t, stored.RetryScheduledFor)
+
+	entry := storepkg.AutomationInboxEntry{
+		ID:           uuid.NewString(),
+		AutomationID: automation.ID,
+		Status:       "queued",
+		Trigger:      "retry",
+		StartedAt:    now,
+		ScheduledFor: "2026-03-02T09:00:00Z",
+		Attempt:      2,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	require.NoError(t, pgStore.CreateAutomationInboxEntry(ctx, entry))
+	entries, err := pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "2026-03-02T09:00:00Z", entries[0].ScheduledFor)
+	require.Equal(t, 2, entries[0].Attempt)
+
+	entry.Attempt = 3
+	entry.ScheduledFor = ""
+	require.NoError(t, pgStore.UpdateAutomationInboxEntry(ctx, entry))
+	entries, err = pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, 3, entries[0].Attempt)
+	require.Empty(t, entries[0].ScheduledFor)
+}
+
+func TestAutomationRunConfigFields(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	automation := storepkg.Automation{
+		ID:                     uuid.NewString(),
+		Name:                   "Configured",
+		Prompt:                 "p",
+		Days:                   []string{"mon"},
+		TimeOfDay:              "09:00",
+		Timezone:               "UTC",
+		PolicyProfile:          "strict",
+		Tags:                   []string{"news"},
+		TimeoutMS:              600000,
+		BrowserMode:            "user_tab",
+		BrowserInteraction:     "disabled",
+		BrowserDomainAllowlist: []string{"example.com"},
+		BrowserPreferred:       "brave",
+		BrowserUserAgent:       "Mozilla/5.0",
+		Skills:                 []string{"research"},
+		CreatedAt:              now,
+		UpdatedAt:              now,
+	}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	stored, err := pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, "strict", stored.PolicyProfile)
+	require.Equal(t, []string{"news"}, stored.Tags)
+	require.Equal(t, 600000, stored.TimeoutMS)
+	require.Equal(t, "user_tab", stored.BrowserMode)
+	require.Equal(t, "disabled", stored.BrowserInteraction)
+	require.Equal(t, []string{"example.com"}, stored.BrowserDomainAllowlist)
+	require.Equal(t, "brave", stored.BrowserPreferred)
+	require.Equal(t, "Mozilla/5.0", stored.BrowserUserAgent)
+	require.Equal(t, []string{"research"}, stored.Skills)
+
+	stored.BrowserMode = ""
+	stored.Skills = nil
+	require.NoError(t, pgStore.UpdateAutomation(ctx, *stored))
+	stored, err = pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Empty(t, stored.BrowserMode)
+	require.Empty(t, stored.Skills)
+	require.Equal(t, []string{"news"}, stored.Tags)
+}
+
+func TestAutomationStagesField(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	stages := []storepkg.AutomationStage{
+		{Name: "research", Prompt: "Research the news"},
+		{Name: "report", Prompt: "Draft {{.Previous}}", ConditionType: "keyword", ConditionKeywords: []string{"ETF"}, ConditionMatch: "any", OnFalse: "stop"},
+	}
+	automation := storepkg.Automation{
+		ID:        uuid.NewString(),
+		Name:      "Pipeline",
+		Kind:      "pipeline",
+		Days:      []string{"mon"},
+		TimeOfDay: "09:00",
+		Timezone:  "UTC",
+		Stages:    stages,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	stored, err := pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, stages, stored.Stages)
+
+	stored.Stages = stages[:1]
+	require.NoError(t, pgStore.UpdateAutomation(ctx, *stored))
+	stored, err = pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, stages[:1], stored.Stages)
+}
+
+func TestAutomationTriggerFields(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	automation := storepkg.Automation{
+		ID:                  uuid.NewString(),
+		Name:                "Triggers",
+		ScheduleType:        "manual",
+		Timezone:            "UTC",
+		TriggerSecretEnc:    "enc-secret",
+		ContextTriggerPaths: []string{"reports/*.md"},
+		RunTriggerTags:      []string{"research"},
+		FeedURLs:            []string{"https://example.com/feed.xml"},
+		FeedPollMinutes:     30,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	stored, err := pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, "enc-secret", stored.TriggerSecretEnc)
+	require.Equal(t, []string{"reports/*.md"}, stored.ContextTriggerPaths)
+	require.Equal(t, []string{"research"}, stored.RunTriggerTags)
+	require.Equal(t, []string{"https://example.com/feed.xml"}, stored.FeedURLs)
+	require.Equal(t, 30, stored.FeedPollMinutes)
+	require.Empty(t, stored.FeedSeenItems)
+	require.Empty(t, stored.FeedCheckedAt)
+
+	stored.FeedSeenItems = []string{"abc:123"}
+	stored.FeedCheckedAt = now
+	require.NoError(t, pgStore.UpdateAutomation(ctx, *stored))
+	stored, err = pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"abc:123"}, stored.FeedSeenItems)
+	require.NotEmpty(t, stored.FeedCheckedAt)
+
+	entry := storepkg.AutomationInboxEntry{
+		ID:             uuid.NewString(),
+		AutomationID:   automation.ID,
+		RunID:          uuid.NewString(),
+		Status:         "running",
+		Trigger:        "webhook",
+		TriggerPayload: map[string]any{"ticker": "SOL"},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	require.NoError(t, pgStore.CreateAutomationInboxEntry(ctx, entry))
+	entries, err := pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]any{"ticker": "SOL"}, entries[0].TriggerPayload)
+}
+
+func TestClaimAutomationInboxEntry(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	base := time.Now().UTC().Truncate(time.Second)
+	now := base.Format(time.RFC3339Nano)
+	at := func(offset time.Duration) string {
+		return base.Add(offset).Format(time.RFC3339Nano)
+	}
+
+	automation := storepkg.Automation{ID: uuid.NewString(), Name: "Leased", Prompt: "p", Days: []string{"mon"}, TimeOfDay: "09:00", Timezone: "UTC", CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	entry := storepkg.AutomationInboxEntry{ID: uuid.NewString(), AutomationID: automation.ID, Status: "queued", Trigger: "manual", StartedAt: now, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, pgStore.CreateAutomationInboxEntry(ctx, entry))
+
+	claimed, err := pgStore.ClaimAutomationInboxEntry(ctx, automation.ID, entry.ID, "replica-a", now, at(2*time.Minute))
+	require.NoError(t, err)
+	require.True(t, claimed)
+	claimed, err = pgStore.ClaimAutomationInboxEntry(ctx, automation.ID, entry.ID, "replica-b", at(time.Minute), at(3*time.Minute))
+	require.NoError(t, err)
+	require.False(t, claimed)
+	claimed, err = pgStore.ClaimAutomationInboxEntry(ctx, automation.ID, entry.ID, "replica-a", at(time.Minute), at(3*time.Minute))
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	// Updates keep the lease.
+	entry.Status = "running"
+	require.NoError(t, pgStore.UpdateAutomationInboxEntry(ctx, entry))
+	entries, err := pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "replica-a", entries[0].LeaseOwner)
+	require.Equal(t, at(3*time.Minute), entries[0].LeaseUntil)
+
+	// A lapsed lease can be taken over, and only its owner releases it.
+	claimed, err = pgStore.ClaimAutomationInboxEntry(ctx, automation.ID, entry.ID, "replica-b", at(3*time.Minute), at(5*time.Minute))
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.NoError(t, pgStore.ReleaseAutomationInboxEntry(ctx, automation.ID, entry.ID, "replica-a"))
+	entries, err = pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, "replica-b", entries[0].LeaseOwner)
+	require.NoError(t, pgStore.ReleaseAutomationInboxEntry(ctx, automation.ID, entry.ID, "replica-b"))
+	entries, err = pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Empty(t, entries[0].LeaseOwner)
+	require.Empty(t, entries[0].LeaseUntil)
+
+	entry.Status = "completed"
+	require.NoError(t, pgStore.UpdateAutomationInboxEntry(ctx, entry))
+	claimed, err = pgStore.ClaimAutomationInboxEntry(ctx, automation.ID, entry.ID, "replica-a", at(4*time.Minute), at(6*time.Minute))
+	require.NoError(t, err)
+	require.False(t, claimed)
+}
+
+func TestQueryAutomationInbox(t *testing.T) {
+	ctx := context.Background()
+	pgStore := newStore(t)
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	automation := storepkg.Automation{ID: uuid.NewString(), Name: "Inbox", Timezone: "UTC", InboxRetentionCount: 5, InboxRetentionDays: 30, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, pgStore.CreateAutomation(ctx, automation))
+	stored, err := pgStore.GetAutomation(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Equal(t, 5, stored.InboxRetentionCount)
+	require.Equal(t, 30, stored.InboxRetentionDays)
+
+	entries := []storepkg.AutomationInboxEntry{
+		{Status: "completed", Trigger: "schedule", Unread: true, StartedAt: "2026-10-15T09:00:00Z"},
+		{Status: "failed", Trigger: "webhook", Unread: true, StartedAt: "2026-10-16T09:00:00Z"},
+		{Status: "completed", Trigger: "manual", StartedAt: "2026-10-16T09:00:00Z"},
+		{Status: "completed", Trigger: "schedule", StartedAt: "2026-10-17T09:00:00Z", ArchivedAt: "2026-10-18T09:00:00Z"},
+	}
+	for idx := range entries {
+		entries[idx].ID = uuid.NewString()
+		entries[idx].AutomationID = automation.ID
+		entries[idx].RunID = uuid.NewString()
+		entries[idx].CreatedAt, entries[idx].UpdatedAt = now, now
+		require.NoError(t, pgStore.CreateAutomationInboxEntry(ctx, entries[idx]))
+	}
+
+	count := func(query storepkg.AutomationInboxQuery) int {
+		result, err := pgStore.QueryAutomationInbox(ctx, query)
+		require.NoError(t, err)
+		return len(result)
+	}
+	require.Equal(t, 3, count(storepkg.AutomationInboxQuery{AutomationID: automation.ID}))
+	require.Equal(t, 1, count(storepkg.AutomationInboxQuery{AutomationID: automation.ID, Archived: true}))
+	require.Equal(t, 2, count(storepkg.AutomationInboxQuery{AutomationID: automation.ID, UnreadOnly: true}))
+	require.Equal(t, 1, count(storepkg.AutomationInboxQuery{AutomationID: automation.ID, Statuses: []string{"failed"}}))
+	require.Equal(t, 2, count(storepkg.AutomationInboxQuery{AutomationID: automation.ID, Triggers: []string{"schedule", "manual"}}))
+	require.Equal(t, 2, count(storepkg.AutomationInboxQuery{AutomationID: automation.ID, Since: "2026-10-16T00:00:00Z", Until: "2026-10-17T00:00:00Z"}))
+
+	// Paging one entry at a time visits every unarchived entry once.
+	seen := map[string]bool{}
+	query := storepkg.AutomationInboxQuery{AutomationID: automation.ID, Limit: 1}
+	for {
+		page, err := pgStore.QueryAutomationInbox(ctx, query)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		require.False(t, seen[page[0].ID])
+		seen[page[0].ID] = true
+		query.BeforeStartedAt, query.BeforeID = page[0].StartedAt, page[0].ID
+	}
+	require.Len(t, seen, 3)
+
+	archived, err := pgStore.QueryAutomationInbox(ctx, storepkg.AutomationInboxQuery{AutomationID: automation.ID, Archived: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, archived[0].ArchivedAt)
+
+	require.NoError(t, pgStore.DeleteAutomationInboxEntries(ctx, automation.ID, []string{entries[0].ID, entries[1].ID}))
+	remaining, err := pgStore.ListAutomationInbox(ctx, automation.ID)
+	require.NoError(t, err)
+	require.Len(t, remaining, 2)
+}
@@ -0,0 +1,37 @@
+package store
+
+import "strings"
+
+const RunTagsUpdatedEvent = "run.tags.updated"
+
+// RunTagsFromEvent returns the tags carried by a run.tags.updated event. Tags
+// are additive: stores merge them into the run's existing tags.
+func RunTagsFromEvent(event RunEvent) []string {
+	if normalizeEventType(event.Type) != RunTagsUpdatedEvent {
+		return nil
+	}
+	if values, ok := event.Payload["tags"].([]string); ok {
+		return MergeRunTags(nil, values)
+	}
+	return MergeRunTags(nil, readStringSlice(event.Payload, "tags"))
+}
+
+func MergeRunTags(existing []string, incoming []string) []string {
+	seen := map[string]struct{}{}
+	result := make([]string, 0, len(existing)+len(incoming))
+	for _, values := range [][]string{existing, incoming} {
+		for _, value := range values {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			key := strings.ToLower(value)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, value)
+		}
+	}
+	return result
+}
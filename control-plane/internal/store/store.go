@@ -2,6 +2,8 @@ package store
 
 import "context"
 
+// Run.WorkspaceID is taken from the context on create. UserID and CreatedBy
+// record the principal that started the run.
 type Run struct {
 	ID               string
 	Status           string
@@ -12,6 +14,9 @@ type Run struct {
 	PolicyProfile    string
 	ModelRoute       string
 	Tags             []string
+	WorkspaceID      string
+	UserID           string
+	CreatedBy        string
 	CreatedAt        string
 	UpdatedAt        string
 }
@@ -27,6 +32,8 @@ type RunSummary struct {
 	ModelRoute       string
 	Tags             []string
 	Title            string
+	UserID           string
+	CreatedBy        string
 	CreatedAt        string
 	UpdatedAt        string
 	MessageCount     int64
@@ -43,25 +50,236 @@ type Message struct {
 }
 
 type LLMSettings struct {
-	Mode          string
-	Provider      string
-	Model         string
-	BaseURL       string
-	APIKeyEnc     string
-	CodexAuthPath string
-	CodexHome     string
-	CreatedAt     string
-	UpdatedAt     string
+	Mode            string
+	Provider        string
+	Model           string
+	BaseURL         string
+	APIKeyEnc       string
+	CodexAuthPath   string
+	CodexHome       string
+	UtilityProvider string
+	UtilityModel    string
+	UtilityBaseURL  string
+	CreatedAt       string
+	UpdatedAt       string
+}
+
+type LLMRouteRule struct {
+	ID             string
+	Name           string
+	Priority       int
+	TaskTypes      []string
+	Tags           []string
+	PolicyProfiles []string
+	AutomationIDs  []string
+	Route          string
+	Enabled        bool
+	CreatedAt      string
+	UpdatedAt      string
+}
+
+type LLMProviderProfile struct {
+	ID        string
+	Name      string
+	Provider  string
+	Model     string
+	BaseURL   string
+	APIKeyEnc string
+	CreatedAt string
+	UpdatedAt string
+}
+
+// Secret is a named, encrypted value that tool inputs can reference as
+// {{secret:NAME}}. PolicyProfiles lists the run policy profiles allowed to use
+// it; "*" allows every profile.
+type Secret struct {
+	ID             string
+	Name           string
+	Description    string
+	ValueEnc       string
+	PolicyProfiles []string
+	CreatedAt      string
+	UpdatedAt      string
+}
+
+// SecretRotateFunc re-encrypts one stored ciphertext. It returns false when the
+// value is already encrypted with the current key.
+type SecretRotateFunc func(ciphertext string) (string, bool, error)
+
+type SecretRotationFailure struct {
+	Table string
+	ID    string
+	Error string
+}
+
+type SecretRotationReport struct {
+	Rotated   int
+	Unchanged int
+	Failed    []SecretRotationFailure
+}
+
+// APIKey is a control-plane API key. Only the SHA-256 hash of the key is
+// stored; Prefix keeps the first characters so keys can be told apart. A key
+// acts in WorkspaceID, on behalf of UserID when set.
+type APIKey struct {
+	ID          string
+	Name        string
+	Prefix      string
+	KeyHash     string
+	Scopes      []string
+	WorkspaceID string
+	UserID      string
+	CreatedAt   string
+	LastUsedAt  string
+}
+
+type Workspace struct {
+	ID        string
+	Name      string
+	CreatedAt string
+	UpdatedAt string
+}
+
+type User struct {
+	ID        string
+	Name      string
+	Email     string
+	CreatedAt string
+}
+
+type WorkspaceMember struct {
+	WorkspaceID string
+	UserID      string
+	Role        string
+	CreatedAt   string
+}
+
+// AuditEntry records a mutating API request. Before and After hold only the
+// fields the request changed, with secrets already redacted. Seq orders
+// entries within the store and serves as the pagination cursor.
+type AuditEntry struct {
+	Seq         int64
+	ID          string
+	WorkspaceID string
+	Actor       string
+	UserID      string
+	Action      string
+	TargetType  string
+	TargetID    string
+	Before      map[string]any
+	After       map[string]any
+	Method      string
+	Path        string
+	StatusCode  int
+	RemoteAddr  string
+	UserAgent   string
+	RequestID   string
+	CreatedAt   string
+}
+
+// AuditQuery filters ListAuditEntries. Empty fields match everything; Since
+// and Until are RFC 3339 bounds on CreatedAt. Entries are returned newest
+// first, starting below BeforeSeq when it is set.
+type AuditQuery struct {
+	Actor      string
+	UserID     string
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      string
+	Until      string
+	BeforeSeq  int64
+	Limit      int
+}
+
+// WebhookSubscription forwards run events to URL. EventTypes holds exact
+// event types or prefixes ending in ".*"; "*" matches every event. When Tags
+// is set, only events from runs carrying one of those tags are delivered.
+type WebhookSubscription struct {
+	ID         string
+	Name       string
+	URL        string
+	EventTypes []string
+	Tags       []string
+	SecretEnc  string
+	Enabled    bool
+	CreatedAt  string
+	UpdatedAt  string
+}
+
+// WebhookDelivery is one queued or attempted webhook POST. Pending deliveries
+// are retried at NextAttemptAt until they succeed or run out of attempts.
+type WebhookDelivery struct {
+	ID             string
+	WorkspaceID    string
+	SubscriptionID string
+	EventType      string
+	RunID          string
+	EventSeq       int64
+	Payload        map[string]any
+	Status         string
+	Attempts       int
+	NextAttemptAt  string
+	LastAttemptAt  string
+	ResponseStatus int
+	LastError      string
+	CreatedAt      string
+	UpdatedAt      string
+}
+
+// NotificationChannel is a configured destination for automation
+// notifications. Config holds the non-secret settings for Type; credential
+// keys are kept in SecretsEnc as an encrypted JSON object.
+type NotificationChannel struct {
+	ID         string
+	Name       string
+	Type       string
+	Config     map[string]string
+	SecretsEnc string
+	Enabled    bool
+	CreatedAt  string
+	UpdatedAt  string
 }
 
 type RunEvent struct {
-	RunID     string
-	Seq       int64
-	Type      string
-	Timestamp string
-	Source    string
-	TraceID   string
-	Payload   map[string]any
+	RunID            string
+	Seq              int64
+	Type             string
+	Timestamp        string
+	Source           string
+	TraceID          string
+	Payload          map[string]any
+	RedactionMarkers []RedactionMarker
+	// Actor is the authenticated caller that submitted the event, e.g.
+	// "service:worker" or "api_key:ci".
+	Actor string
+}
+
+// RedactionMarker records one kind of value removed from a payload: which
+// detector matched, what it matched (never the value itself), where, and how
+// many times.
+type RedactionMarker struct {
+	Detector string
+	Label    string
+	Path     string
+	Count    int
+}
+
+type RedactionRule struct {
+	Name    string
+	Pattern string
+}
+
+// RedactionPolicy configures the redaction pipeline for one run policy
+// profile. The "default" policy also applies to profiles without their own.
+type RedactionPolicy struct {
+	PolicyProfile string
+	VaultSecrets  bool
+	KeyFormats    bool
+	Emails        bool
+	CustomRules   []RedactionRule
+	CreatedAt     string
+	UpdatedAt     string
 }
 
 type RunStep struct {
@@ -121,6 +339,7 @@ type Automation struct {
 	Name       string
 	Prompt     string
 	Model      string
+	LLMProfile string
 	Days       []string
 	TimeOfDay  string
 	Timezone   string
@@ -128,8 +347,94 @@ type Automation struct {
 	NextRunAt  string
 	LastRunAt  string
 	InProgress bool
-	CreatedAt  string
-	UpdatedAt  string
+	// NotificationChannels lists the channel IDs notified after each run;
+	// NotifyOn is "always", "failure" or "change".
+	NotificationChannels []string
+	NotifyOn             string
+	// ChangeThreshold is the similarity (0-1) at or above which a result is
+	// marked unchanged; 0 disables the check.
+	ChangeThreshold float64
+	// Kind is "prompt" or "digest". A digest runs no prompt; it summarizes
+	// the unread results of DigestAutomationIDs (every prompt automation
+	// when empty) and DigestCadence is "daily" or "weekly".
+	Kind                string
+	DigestAutomationIDs []string
+	DigestCadence       string
+	// Stages are the ordered prompts of a "pipeline" automation.
+	Stages []AutomationStage
+	// ScheduleType is "weekly" (Days at TimeOfDay), "cron" (CronExpression),
+	// "interval" (every IntervalMinutes from CreatedAt), "once" (at RunAt) or
+	// "manual" (never; runs only start manually or from event triggers).
+	// Nothing fires on BlackoutDates, local YYYY-MM-DD dates in Timezone.
+	ScheduleType    string
+	CronExpression  string
+	IntervalMinutes int
+	RunAt           string
+	BlackoutDates   []string
+	// CatchUp decides what happens to fire times missed while nothing was
+	// running: "skip", "once" or "all". Missed runs older than
+	// CatchUpMaxAgeMinutes (0 for no limit) are dropped.
+	CatchUp              string
+	CatchUpMaxAgeMinutes int
+	// Failed runs are retried up to RetryMaxAttempts times, waiting
+	// RetryBackoffSeconds doubled per attempt. RetryAt, RetryAttempt and
+	// RetryScheduledFor describe the pending retry, if any.
+	RetryMaxAttempts    int
+	RetryBackoffSeconds int
+	RetryAt             string
+	RetryAttempt        int
+	RetryScheduledFor   string
+	// PolicyProfile, Tags, TimeoutMS (0 for the default), the browser
+	// settings and Skills configure every run the automation starts.
+	// BrowserMode "user_tab" drives the user's own browser tab, within
+	// BrowserDomainAllowlist when set.
+	PolicyProfile          string
+	Tags                   []string
+	TimeoutMS              int
+	BrowserMode            string
+	BrowserInteraction     string
+	BrowserDomainAllowlist []string
+	BrowserPreferred       string
+	BrowserUserAgent       string
+	Skills                 []string
+	// Event triggers queue runs besides the schedule. TriggerSecretEnc is
+	// the encrypted HMAC secret of POST /automations/{id}/trigger, which is
+	// disabled when empty. ContextTriggerPaths are path patterns of /context
+	// files whose upload or deletion queues a run, and RunTriggerTags queue
+	// a run when a run carrying one of them completes. FeedURLs are RSS or
+	// Atom feeds polled every FeedPollMinutes; FeedSeenItems holds the item
+	// keys the last poll saw and FeedCheckedAt when it ran.
+	TriggerSecretEnc    string
+	ContextTriggerPaths []string
+	RunTriggerTags      []string
+	FeedURLs            []string
+	FeedPollMinutes     int
+	FeedSeenItems       []string
+	FeedCheckedAt       string
+	// InboxRetentionCount keeps only that many of the newest finished inbox
+	// entries and InboxRetentionDays drops entries finished longer ago; 0
+	// keeps everything.
+	InboxRetentionCount int
+	InboxRetentionDays  int
+	CreatedAt           string
+	UpdatedAt           string
+}
+
+// AutomationStage is one prompt of a pipeline. Prompt is a template that can
+// reference earlier stage outputs. A stage with a ConditionType ("keyword"
+// or "model") only runs when the output of ConditionStage (the previous
+// stage when empty) contains any, or with ConditionMatch "all" every, of
+// ConditionKeywords, or when the model answers yes to ConditionQuestion.
+// OnFalse is "stop" to end the pipeline or "skip" to go on without the stage.
+type AutomationStage struct {
+	Name              string
+	Prompt            string
+	ConditionType     string
+	ConditionKeywords []string
+	ConditionMatch    string
+	ConditionQuestion string
+	ConditionStage    string
+	OnFalse           string
 }
 
 type AutomationInboxEntry struct {
@@ -147,8 +452,46 @@ type AutomationInboxEntry struct {
 	StartedAt        string
 	CompletedAt      string
 	Diagnostics      map[string]any
-	CreatedAt        string
-	UpdatedAt        string
+	// ChangeSummary and Similarity compare the result with the automation's
+	// previous successful entry; Similarity is nil when there was nothing to
+	// compare. Unchanged is set when Similarity reached the automation's
+	// ChangeThreshold.
+	ChangeSummary string
+	Similarity    *float64
+	Unchanged     bool
+	// ScheduledFor is the fire time a scheduled, catch-up or retry entry
+	// ran for; Attempt counts retries from 1.
+	ScheduledFor string
+	Attempt      int
+	// TriggerPayload is the event that queued a webhook, context, run or
+	// feed entry, such as the JSON body of an inbound webhook.
+	TriggerPayload map[string]any
+	// ArchivedAt is set when the entry was archived out of the inbox.
+	ArchivedAt string
+	// LeaseOwner names the control plane process running a pending entry,
+	// and LeaseUntil is when that claim lapses unless it is renewed.
+	LeaseOwner string
+	LeaseUntil string
+	CreatedAt  string
+	UpdatedAt  string
+}
+
+// AutomationInboxQuery filters QueryAutomationInbox across the workspace's
+// automations. Empty fields match everything; Since and Until are RFC 3339
+// bounds on StartedAt. Archived entries are returned only, and then
+// exclusively, when Archived is set. Entries are returned newest first,
+// starting after the entry identified by BeforeStartedAt and BeforeID.
+type AutomationInboxQuery struct {
+	AutomationID    string
+	UnreadOnly      bool
+	Statuses        []string
+	Triggers        []string
+	Since           string
+	Until           string
+	Archived        bool
+	BeforeStartedAt string
+	BeforeID        string
+	Limit           int
 }
 
 type Skill struct {
@@ -203,14 +546,62 @@ type MemoryEntry struct {
 	UpdatedAt string
 }
 
+// Store is scoped by the workspace carried in each call's context (see
+// WithWorkspace). Runs, skills, context, memory, automations, webhooks,
+// notification channels and audit entries belong to a workspace; LLM, memory and personality settings fall
+// back to the default workspace's when a workspace has no override.
+// Provider profiles, route rules, secrets, redaction policies, API keys,
+// users and workspaces are instance-wide.
 type Store interface {
 	DeleteRun(ctx context.Context, runID string) error
 	ListRuns(ctx context.Context) ([]RunSummary, error)
 	CreateRun(ctx context.Context, run Run) error
+	GetRun(ctx context.Context, runID string) (*Run, error)
 	AddMessage(ctx context.Context, msg Message) error
 	ListMessages(ctx context.Context, runID string) ([]Message, error)
 	GetLLMSettings(ctx context.Context) (*LLMSettings, error)
 	UpsertLLMSettings(ctx context.Context, settings LLMSettings) error
+	ListLLMRouteRules(ctx context.Context) ([]LLMRouteRule, error)
+	GetLLMRouteRule(ctx context.Context, ruleID string) (*LLMRouteRule, error)
+	CreateLLMRouteRule(ctx context.Context, rule LLMRouteRule) error
+	UpdateLLMRouteRule(ctx context.Context, rule LLMRouteRule) error
+	DeleteLLMRouteRule(ctx context.Context, ruleID string) error
+	ListLLMProviderProfiles(ctx context.Context) ([]LLMProviderProfile, error)
+	GetLLMProviderProfile(ctx context.Context, profileID string) (*LLMProviderProfile, error)
+	CreateLLMProviderProfile(ctx context.Context, profile LLMProviderProfile) error
+	UpdateLLMProviderProfile(ctx context.Context, profile LLMProviderProfile) error
+	DeleteLLMProviderProfile(ctx context.Context, profileID string) error
+	RotateSecrets(ctx context.Context, rotate SecretRotateFunc) (SecretRotationReport, error)
+	ListSecrets(ctx context.Context) ([]Secret, error)
+	GetSecret(ctx context.Context, secretID string) (*Secret, error)
+	CreateSecret(ctx context.Context, secret Secret) error
+	UpdateSecret(ctx context.Context, secret Secret) error
+	DeleteSecret(ctx context.Context, secretID string) error
+	ListRedactionPolicies(ctx context.Context) ([]RedactionPolicy, error)
+	GetRedactionPolicy(ctx context.Context, policyProfile string) (*RedactionPolicy, error)
+	UpsertRedactionPolicy(ctx context.Context, policy RedactionPolicy) error
+	DeleteRedactionPolicy(ctx context.Context, policyProfile string) error
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	GetAPIKey(ctx context.Context, keyID string) (*APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	TouchAPIKey(ctx context.Context, keyID string, usedAt string) error
+	DeleteAPIKey(ctx context.Context, keyID string) error
+	ListWorkspaces(ctx context.Context) ([]Workspace, error)
+	GetWorkspace(ctx context.Context, workspaceID string) (*Workspace, error)
+	CreateWorkspace(ctx context.Context, workspace Workspace) error
+	DeleteWorkspace(ctx context.Context, workspaceID string) error
+	ListUsers(ctx context.Context) ([]User, error)
+	GetUser(ctx context.Context, userID string) (*User, error)
+	CreateUser(ctx context.Context, user User) error
+	DeleteUser(ctx context.Context, userID string) error
+	ListWorkspaceMembers(ctx context.Context, workspaceID string) ([]WorkspaceMember, error)
+	UpsertWorkspaceMember(ctx context.Context, member WorkspaceMember) error
+	DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error
+	// GetRunWorkspace looks a run up across workspaces so callers that only
+	// know a run ID, such as workers, can scope their context. It returns ""
+	// for unknown runs.
+	GetRunWorkspace(ctx context.Context, runID string) (string, error)
 	ListSkills(ctx context.Context) ([]Skill, error)
 	GetSkill(ctx context.Context, skillID string) (*Skill, error)
 	CreateSkill(ctx context.Context, skill Skill) error
@@ -247,7 +638,37 @@ type Store interface {
 	DeleteAutomation(ctx context.Context, automationID string) error
 	ListAutomationInbox(ctx context.Context, automationID string) ([]AutomationInboxEntry, error)
 	CreateAutomationInboxEntry(ctx context.Context, entry AutomationInboxEntry) error
+	// UpdateAutomationInboxEntry leaves the entry's lease alone.
 	UpdateAutomationInboxEntry(ctx context.Context, entry AutomationInboxEntry) error
+	// ClaimAutomationInboxEntry leases a queued or running entry to owner
+	// until leaseUntil and reports whether it did. An entry leased to another
+	// owner is only claimed once that lease has lapsed at now, so concurrent
+	// control planes do not run the same job; owner renews its own lease.
+	ClaimAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string, now string, leaseUntil string) (bool, error)
+	// ReleaseAutomationInboxEntry clears owner's lease on an entry.
+	ReleaseAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string) error
 	MarkAutomationInboxEntryRead(ctx context.Context, automationID string, entryID string) error
 	MarkAutomationInboxReadAll(ctx context.Context, automationID string) error
+	QueryAutomationInbox(ctx context.Context, query AutomationInboxQuery) ([]AutomationInboxEntry, error)
+	DeleteAutomationInboxEntries(ctx context.Context, automationID string, entryIDs []string) error
+	AppendAuditEntry(ctx context.Context, entry AuditEntry) error
+	ListAuditEntries(ctx context.Context, query AuditQuery) ([]AuditEntry, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, subscriptionID string) (*WebhookSubscription, error)
+	CreateWebhookSubscription(ctx context.Context, subscription WebhookSubscription) error
+	UpdateWebhookSubscription(ctx context.Context, subscription WebhookSubscription) error
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error
+	CreateWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, deliveryID string) (*WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]WebhookDelivery, error)
+	// ClaimWebhookDeliveries leases up to limit pending deliveries due at now,
+	// across all workspaces, by moving their NextAttemptAt to leaseUntil so
+	// concurrent dispatchers do not send them twice.
+	ClaimWebhookDeliveries(ctx context.Context, now string, leaseUntil string, limit int) ([]WebhookDelivery, error)
+	ListNotificationChannels(ctx context.Context) ([]NotificationChannel, error)
+	GetNotificationChannel(ctx context.Context, channelID string) (*NotificationChannel, error)
+	CreateNotificationChannel(ctx context.Context, channel NotificationChannel) error
+	UpdateNotificationChannel(ctx context.Context, channel NotificationChannel) error
+	DeleteNotificationChannel(ctx context.Context, channelID string) error
 }
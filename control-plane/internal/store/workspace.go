@@ -0,0 +1,32 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// DefaultWorkspaceID is the workspace that holds data created before
+// workspaces existed, and the one used when a context carries none.
+const DefaultWorkspaceID = "default"
+
+// ErrNotInWorkspace is returned by writes to child rows (messages, events,
+// skill files, inbox entries, ...) whose parent is not in the context's
+// workspace.
+var ErrNotInWorkspace = errors.New("not found in workspace")
+
+type workspaceContextKey struct{}
+
+// WithWorkspace scopes every Store call made with the returned context to
+// workspaceID.
+func WithWorkspace(ctx context.Context, workspaceID string) context.Context {
+	return context.WithValue(ctx, workspaceContextKey{}, strings.TrimSpace(workspaceID))
+}
+
+// WorkspaceID returns the workspace a Store call is scoped to.
+func WorkspaceID(ctx context.Context) string {
+	if workspaceID, _ := ctx.Value(workspaceContextKey{}).(string); workspaceID != "" {
+		return workspaceID
+	}
+	return DefaultWorkspaceID
+}
@@ -0,0 +1,15 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceID(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, DefaultWorkspaceID, WorkspaceID(ctx))
+	require.Equal(t, DefaultWorkspaceID, WorkspaceID(WithWorkspace(ctx, "  ")))
+	require.Equal(t, "team-a", WorkspaceID(WithWorkspace(ctx, " team-a ")))
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/personality"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/secrets"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/skills"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
 )
@@ -73,7 +74,7 @@ type RunFailureInput struct {
 
 var (
 	newProvider   = llm.NewProvider
-	decryptSecret = secrets.Decrypt
+	decryptSecret = (*secrets.Keyring).Decrypt
 	marshalJSON   = json.Marshal
 	buildSystem   = (*RunActivities).buildSystemPrompt
 	buildMemory   = (*RunActivities).buildMemoryPrompt
@@ -203,19 +204,32 @@ type toolRunnerResponse struct {
 type RunActivities struct {
 	store               store.Store
 	defaultConfig       llm.Config
-	secretsKey          []byte
+	previousSecretsKeys [][]byte
+	keyring             *secrets.Keyring
 	controlPlane        string
+	controlPlaneAPIKey  string
+	signingService      string
+	signingSecret       string
 	toolRunner          string
 	httpClient          *http.Client
 	requestTimeout      time.Duration
+	utilityTimeout      time.Duration
 	toolTimeout         time.Duration
 	memoryMaxResults    int
 	memoryMaxEntryChars int
+	providerHealth      *llm.HealthTracker
 }
 
 type llmProviderCandidate struct {
 	Name     string
+	Model    string
+	Rule     string
 	Provider llm.Provider
+	// Unguarded is set when every candidate's breaker was open and the run
+	// tries them anyway; requests then skip the breaker's admission check.
+	Unguarded bool
+	// Timeout, when set, replaces the caller's timeout in generateOnce.
+	Timeout time.Duration
 }
 
 type RunActivitiesOption func(*RunActivities)
@@ -231,31 +245,88 @@ func WithMemoryConfig(maxResults int, maxEntryChars int) RunActivitiesOption {
 	}
 }
 
+func WithProviderHealth(tracker *llm.HealthTracker) RunActivitiesOption {
+	return func(a *RunActivities) {
+		if tracker != nil {
+			a.providerHealth = tracker
+		}
+	}
+}
+
+// WithPreviousSecretsKeys adds decrypt-only keys for secrets that have not yet
+// been rotated to the primary key.
+func WithPreviousSecretsKeys(keys ...[]byte) RunActivitiesOption {
+	return func(a *RunActivities) {
+		a.previousSecretsKeys = append(a.previousSecretsKeys, keys...)
+	}
+}
+
+// WithControlPlaneAPIKey sets the API key sent when posting messages and
+// events back to the control plane.
+func WithControlPlaneAPIKey(key string) RunActivitiesOption {
+	return func(a *RunActivities) {
+		a.controlPlaneAPIKey = strings.TrimSpace(key)
+	}
+}
+
+// WithEventSigning signs messages and events posted to the control plane with
+// the service's EVENT_SIGNING_SECRET.
+func WithEventSigning(service string, secret string) RunActivitiesOption {
+	return func(a *RunActivities) {
+		a.signingService = strings.TrimSpace(service)
+		a.signingSecret = strings.TrimSpace(secret)
+	}
+}
+
+// WithUtilityTimeout bounds each utility model request and each run title
+// request routed away from the primary model.
+func WithUtilityTimeout(timeout time.Duration) RunActivitiesOption {
+	return func(a *RunActivities) {
+		if timeout > 0 {
+			a.utilityTimeout = timeout
+		}
+	}
+}
+
 func NewRunActivities(store store.Store, defaultConfig llm.Config, secretsKey []byte, controlPlaneURL string, toolRunnerURL string, opts ...RunActivitiesOption) *RunActivities {
 	activities := &RunActivities{
 		store:               store,
 		defaultConfig:       defaultConfig,
-		secretsKey:          secretsKey,
 		controlPlane:        strings.TrimRight(controlPlaneURL, "/"),
 		toolRunner:          strings.TrimRight(toolRunnerURL, "/"),
 		httpClient:          &http.Client{Timeout: 60 * time.Second},
 		requestTimeout:      10 * time.Second,
+		utilityTimeout:      10 * time.Second,
 		toolTimeout:         30 * time.Second,
 		memoryMaxResults:    5,
 		memoryMaxEntryChars: 400,
+		providerHealth:      llm.NewHealthTracker(llm.HealthConfig{}),
 	}
 	for _, opt := range opts {
 		if opt != nil {
 			opt(activities)
 		}
 	}
+	if secretsKey != nil {
+		activities.keyring = secrets.NewKeyring(secretsKey, activities.previousSecretsKeys...)
+	}
 	return activities
 }
 
+// runContext scopes ctx to the workspace of runID so store calls see the
+// run's settings, skills and memory.
+func (a *RunActivities) runContext(ctx context.Context, runID string) context.Context {
+	if workspaceID, err := a.store.GetRunWorkspace(ctx, runID); err == nil && workspaceID != "" {
+		return store.WithWorkspace(ctx, workspaceID)
+	}
+	return ctx
+}
+
 func (a *RunActivities) PlanExecution(ctx context.Context, input PlanInput) (PlanOutput, error) {
 	if strings.TrimSpace(input.RunID) == "" {
 		return PlanOutput{}, errors.New("run_id required")
 	}
+	ctx = a.runContext(ctx, input.RunID)
 	trimmedMessage := strings.TrimSpace(input.Message)
 	if trimmedMessage == "" {
 		messages, err := a.store.ListMessages(ctx, input.RunID)
@@ -290,6 +361,7 @@ func (a *RunActivities) PlanExecution(ctx context.Context, input PlanInput) (Pla
 		"name":               "Plan execution",
 		"plan_id":            planID,
 		"planned_step_count": len(steps),
+		"task_type":          taskTypeForPlan(steps),
 	})
 	return PlanOutput{
 		PlanID: planID,
@@ -374,6 +446,7 @@ func (a *RunActivities) ExecutePlan(ctx context.Context, input ExecuteInput) (Ex
 	if strings.TrimSpace(input.RunID) == "" {
 		return ExecuteOutput{}, errors.New("run_id required")
 	}
+	ctx = a.runContext(ctx, input.RunID)
 	_ = a.emitEvent(ctx, input.RunID, "run.phase.changed", map[string]any{
 		"phase":   "executing",
 		"plan_id": strings.TrimSpace(input.PlanID),
@@ -389,6 +462,7 @@ func (a *RunActivities) VerifyExecution(ctx context.Context, input VerifyInput)
 	if strings.TrimSpace(input.RunID) == "" {
 		return VerifyOutput{}, errors.New("run_id required")
 	}
+	ctx = a.runContext(ctx, input.RunID)
 	_ = a.emitEvent(ctx, input.RunID, "run.phase.changed", map[string]any{
 		"phase":   "validating",
 		"plan_id": strings.TrimSpace(input.PlanID),
@@ -461,6 +535,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 	if input.RunID == "" {
 		return errors.New("run_id required")
 	}
+	ctx = a.runContext(ctx, input.RunID)
 	messages, err := a.store.ListMessages(ctx, input.RunID)
 	if err != nil {
 		return err
@@ -470,13 +545,26 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 		_ = a.postEvent(ctx, input.RunID, "run.failed", map[string]any{"error": err.Error()})
 		return err
 	}
+	routeContext := a.resolveRouteMatchContext(ctx, input.RunID, messages)
 	modelRoute := a.resolveModelRoute(ctx, input.RunID, messages)
-	providers, err := a.buildProviderCandidates(cfg, modelRoute)
+	routeRule := ""
+	if modelRoute == "" {
+		if rule := a.matchModelRouteRule(ctx, routeContext); rule != nil {
+			modelRoute = rule.Route
+			routeRule = rule.Name
+		}
+	}
+	providers, err := a.buildProviderCandidates(ctx, cfg, modelRoute, routeRule)
 	if err != nil {
 		_ = a.postEvent(ctx, input.RunID, "run.failed", map[string]any{"error": err.Error()})
 		return err
 	}
-	primaryProvider := providers[0].Provider
+	utility := a.resolveUtilityModel(ctx, cfg)
+	titleFallback := providers[0]
+	if utility != nil {
+		titleFallback = utility.candidate()
+	}
+	titleProvider := a.resolveTaskProvider(ctx, cfg, routeContext, taskTypeTitle, titleFallback)
 	llmMessages := make([]llm.Message, 0, len(messages))
 	for _, msg := range messages {
 		if msg.Content == "" {
@@ -487,6 +575,9 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 	if systemPrompt := buildSystem(a, ctx); systemPrompt != "" {
 		llmMessages = append([]llm.Message{{Role: "system", Content: systemPrompt}}, llmMessages...)
 	}
+	if skillsPrompt := buildEnabledSkillsPrompt(messages); skillsPrompt != "" {
+		llmMessages = append([]llm.Message{{Role: "system", Content: skillsPrompt}}, llmMessages...)
+	}
 	if memoryPrompt := buildMemory(a, ctx, messages); memoryPrompt != "" {
 		llmMessages = append([]llm.Message{{Role: "system", Content: memoryPrompt}}, llmMessages...)
 	}
@@ -497,7 +588,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 		"step_id": "assistant_reply",
 		"name":    "Generate assistant reply",
 	})
-	llmMessages = clampConversationWindow(llmMessages, maxConversationMessages, maxConversationChars)
+	llmMessages = a.compactConversationWindow(ctx, utility, llmMessages)
 	latestUserRequest := latestUserMessage(messages)
 	browserUserTab := resolveBrowserUserTabConfig(messages)
 	mustExecuteTools := a.toolRunner != "" && requestLikelyNeedsTools(latestUserRequest)
@@ -548,6 +639,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 					}
 					final := a.composeBestEffortFinalResponse(ctx, input.RunID, providers, llmMessages, latestUserRequest, successfulToolCalls, researchRequirements, hadToolErrors, err)
 					final = enforceResearchQualityFallback(final, successfulToolCalls, researchRequirements, latestUserRequest)
+					final = a.polishResearchResponse(ctx, utility, final, researchRequirements)
 					if postErr := a.postMessage(ctx, input.RunID, final); postErr != nil {
 						return postErr
 					}
@@ -571,7 +663,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 						completionReason = "partial_tool_errors"
 					}
 					_ = a.postCompletionEvent(ctx, input.RunID, status, completionReason)
-					a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+					a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 					return nil
 				}
 				fallback := buildNoContentFallback(successfulToolCalls)
@@ -584,7 +676,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 					"status":  "partial",
 				})
 				_ = a.postCompletionEvent(ctx, input.RunID, "partial", "llm_no_content")
-				a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, fallback)
+				a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, fallback)
 				return nil
 			}
 			if isRetryableLLMError(err) {
@@ -601,6 +693,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 					}
 					final := a.composeBestEffortFinalResponse(ctx, input.RunID, providers, llmMessages, latestUserRequest, successfulToolCalls, researchRequirements, hadToolErrors, err)
 					final = enforceResearchQualityFallback(final, successfulToolCalls, researchRequirements, latestUserRequest)
+					final = a.polishResearchResponse(ctx, utility, final, researchRequirements)
 					if postErr := a.postMessage(ctx, input.RunID, final); postErr != nil {
 						return postErr
 					}
@@ -624,7 +717,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 						completionReason = "partial_tool_errors"
 					}
 					_ = a.postCompletionEvent(ctx, input.RunID, status, completionReason)
-					a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+					a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 					return nil
 				}
 				fallback := buildTransientLLMFallback(err)
@@ -721,7 +814,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 								completionReason = "partial_tool_errors"
 							}
 							_ = a.postCompletionEvent(ctx, input.RunID, status, completionReason)
-							a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+							a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 							return nil
 						}
 						llmMessages = append(llmMessages,
@@ -787,7 +880,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 									completionReason = "partial_tool_errors"
 								}
 								_ = a.postCompletionEvent(ctx, input.RunID, status, completionReason)
-								a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+								a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 								return nil
 							}
 						}
@@ -834,7 +927,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 									completionReason = "partial_tool_errors"
 								}
 								_ = a.postCompletionEvent(ctx, input.RunID, status, completionReason)
-								a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+								a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 								return nil
 							}
 							if shouldRepromptForWebResearch(researchRequirements, uniqueSources, extractCount, linkCount) {
@@ -857,7 +950,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 						"status":  "partial",
 					})
 					_ = a.postCompletionEvent(ctx, input.RunID, "partial", "insufficient_web_research_evidence")
-					a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+					a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 					return nil
 				}
 			}
@@ -877,6 +970,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 				}
 			}
 			finalResponse = enforceResearchQualityFallback(finalResponse, successfulToolCalls, researchRequirements, latestUserRequest)
+			finalResponse = a.polishResearchResponse(ctx, utility, finalResponse, researchRequirements)
 			if err := a.postMessage(ctx, input.RunID, finalResponse); err != nil {
 				return err
 			}
@@ -890,7 +984,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 				completionReason = "partial_tool_errors"
 			}
 			_ = a.postCompletionEvent(ctx, input.RunID, ternaryStatus(hadToolErrors), completionReason)
-			a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, finalResponse)
+			a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, finalResponse)
 			return nil
 		}
 		if a.toolRunner == "" {
@@ -949,7 +1043,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 				completionReason = "partial_tool_errors"
 			}
 			_ = a.postCompletionEvent(ctx, input.RunID, status, completionReason)
-			a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+			a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 			return nil
 		}
 	}
@@ -976,7 +1070,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 				"status":  "partial",
 			})
 			_ = a.postCompletionEvent(ctx, input.RunID, "partial", "insufficient_web_research_evidence")
-			a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+			a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 			return nil
 		}
 		final := strings.TrimSpace(stripFencedToolBlocks(lastResponse))
@@ -1005,6 +1099,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 			}
 		}
 		final = enforceResearchQualityFallback(final, successfulToolCalls, researchRequirements, latestUserRequest)
+		final = a.polishResearchResponse(ctx, utility, final, researchRequirements)
 		if err := a.postMessage(ctx, input.RunID, final); err != nil {
 			return err
 		}
@@ -1033,7 +1128,7 @@ func (a *RunActivities) GenerateAssistantReply(ctx context.Context, input Genera
 			"status":  status,
 		})
 		_ = a.postCompletionEvent(ctx, input.RunID, status, completionReason)
-		a.maybeGenerateRunTitle(ctx, input.RunID, primaryProvider, messages, final)
+		a.finishRunMetadata(ctx, input.RunID, titleProvider, utility, messages, final)
 		return nil
 	}
 	return nil
@@ -1043,6 +1138,7 @@ func (a *RunActivities) HandleRunFailure(ctx context.Context, input RunFailureIn
 	if strings.TrimSpace(input.RunID) == "" {
 		return errors.New("run_id required")
 	}
+	ctx = a.runContext(ctx, input.RunID)
 	detail := strings.TrimSpace(input.Error)
 	if detail == "" {
 		detail = "unknown workflow activity error"
@@ -1090,27 +1186,32 @@ func (a *RunActivities) emitEvent(ctx context.Context, runID string, eventType s
 	return a.appendLocalEvent(ctx, runID, eventType, "llm", payload)
 }
 
+// appendLocalEvent stores an event the control plane could not be reached
+// for, redacted with the run's pipeline as ingest would.
 func (a *RunActivities) appendLocalEvent(ctx context.Context, runID string, eventType string, source string, payload map[string]any) error {
 	seq, err := a.store.NextSeq(ctx, runID)
 	if err != nil {
 		return err
 	}
+	redacted, markers := a.runRedactionPipeline(ctx, runID).Map("", payload)
 	return a.store.AppendEvent(ctx, store.RunEvent{
-		RunID:     runID,
-		Seq:       seq,
-		Type:      eventType,
-		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
-		Source:    source,
-		TraceID:   uuid.New().String(),
-		Payload:   payload,
+		RunID:            runID,
+		Seq:              seq,
+		Type:             eventType,
+		Timestamp:        time.Now().UTC().Format(time.RFC3339Nano),
+		Source:           source,
+		TraceID:          uuid.New().String(),
+		Payload:          redacted,
+		RedactionMarkers: markers,
 	})
 }
 
-func (a *RunActivities) buildProviderCandidates(cfg llm.Config, modelRoute string) ([]llmProviderCandidate, error) {
+func (a *RunActivities) buildProviderCandidates(ctx context.Context, cfg llm.Config, modelRoute string, routeRule string) ([]llmProviderCandidate, error) {
 	candidates := make([]llmProviderCandidate, 0, 4)
 	seen := map[string]struct{}{}
+	profiles := a.listProviderProfiles(ctx)
 
-	appendCandidate := func(name string, candidateCfg llm.Config, required bool) error {
+	appendCandidate := func(name string, candidateCfg llm.Config, required bool, rule string) error {
 		key := strings.TrimSpace(candidateCfg.Provider) + "|" + strings.TrimSpace(candidateCfg.Model)
 		if _, exists := seen[key]; exists {
 			return nil
@@ -1125,6 +1226,8 @@ func (a *RunActivities) buildProviderCandidates(cfg llm.Config, modelRoute strin
 		seen[key] = struct{}{}
 		candidates = append(candidates, llmProviderCandidate{
 			Name:     strings.TrimSpace(name),
+			Model:    strings.TrimSpace(candidateCfg.Model),
+			Rule:     rule,
 			Provider: provider,
 		})
 		return nil
@@ -1133,18 +1236,17 @@ func (a *RunActivities) buildProviderCandidates(cfg llm.Config, modelRoute strin
 	routeEntries := parseModelRoute(modelRoute)
 	if len(routeEntries) > 0 {
 		for _, entry := range routeEntries {
-			routeCfg := cfg
-			routeCfg.Provider = entry.provider
-			if entry.model != "" {
-				routeCfg.Model = entry.model
+			routeCfg, ok := a.routeEntryConfig(cfg, entry, profiles)
+			if !ok {
+				continue
 			}
-			_ = appendCandidate(entry.provider, routeCfg, false)
+			_ = appendCandidate(routeCfg.Provider, routeCfg, false, strings.TrimSpace(routeRule))
 		}
 		if len(candidates) > 0 {
-			return candidates, nil
+			return a.filterHealthyCandidates(candidates), nil
 		}
 	}
-	if err := appendCandidate(strings.TrimSpace(cfg.Provider), cfg, true); err != nil {
+	if err := appendCandidate(strings.TrimSpace(cfg.Provider), cfg, true, ""); err != nil {
 		return nil, err
 	}
 
@@ -1155,13 +1257,82 @@ func (a *RunActivities) buildProviderCandidates(cfg llm.Config, modelRoute strin
 		if model := strings.TrimSpace(cfg.FallbackModel); model != "" {
 			fallbackCfg.Model = model
 		}
+		fallbackCfg = a.withProfileCredentials(fallbackCfg, cfg.Provider, profiles)
 		if baseURL := strings.TrimSpace(cfg.FallbackBaseURL); baseURL != "" {
 			fallbackCfg.BaseURL = baseURL
 		}
-		_ = appendCandidate(fallbackProvider, fallbackCfg, false)
+		_ = appendCandidate(fallbackProvider, fallbackCfg, false, "")
 	}
 
-	return candidates, nil
+	return a.filterHealthyCandidates(candidates), nil
+}
+
+// filterHealthyCandidates drops candidates whose circuit breaker is open. When
+// every candidate is open the configured order is kept so the run still tries.
+// It does not claim a half-open probe: generateWithRetry asks the breaker
+// again when it actually sends a request.
+func (a *RunActivities) filterHealthyCandidates(candidates []llmProviderCandidate) []llmProviderCandidate {
+	if a.providerHealth == nil || len(candidates) == 0 {
+		return candidates
+	}
+	healthy := make([]llmProviderCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if a.providerHealth.Available(candidate.Name, candidate.Model) {
+			healthy = append(healthy, candidate)
+		}
+	}
+	if len(healthy) == 0 {
+		for i := range candidates {
+			candidates[i].Unguarded = true
+		}
+		return candidates
+	}
+	return healthy
+}
+
+// admitProvider claims the candidate's circuit breaker for one request.
+func (a *RunActivities) admitProvider(candidate llmProviderCandidate) error {
+	if candidate.Unguarded || a.providerHealth.Allow(candidate.Name, candidate.Model) {
+		return nil
+	}
+	return fmt.Errorf("llm provider %s %s is unavailable: circuit breaker open", candidate.Name, candidate.Model)
+}
+
+// recordProviderOutcome reports an admitted request to the provider's circuit
+// breaker. Errors that are not retryable say nothing about the provider's
+// health and only release a half-open probe.
+func (a *RunActivities) recordProviderOutcome(candidate llmProviderCandidate, err error) {
+	switch {
+	case err == nil:
+		a.providerHealth.RecordSuccess(candidate.Name, candidate.Model)
+	case isRetryableLLMError(err):
+		a.providerHealth.RecordFailure(candidate.Name, candidate.Model, truncateRunes(strings.TrimSpace(err.Error()), 200), llm.RetryAfterFromError(err))
+	default:
+		a.providerHealth.Release(candidate.Name, candidate.Model)
+	}
+}
+
+// generateOnce sends a single request through the candidate's circuit
+// breaker and records the outcome.
+func (a *RunActivities) generateOnce(ctx context.Context, candidate llmProviderCandidate, messages []llm.Message, timeout time.Duration) (string, error) {
+	if err := a.admitProvider(candidate); err != nil {
+		return "", err
+	}
+	if candidate.Timeout > 0 {
+		timeout = candidate.Timeout
+	}
+	generateCtx := ctx
+	cancel := func() {}
+	if timeout > 0 {
+		generateCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+	response, err := candidate.Provider.Generate(generateCtx, messages)
+	a.recordProviderOutcome(candidate, err)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response), nil
 }
 
 type modelRouteEntry struct {
@@ -1225,6 +1396,121 @@ func (a *RunActivities) resolveModelRoute(ctx context.Context, runID string, mes
 	return ""
 }
 
+const (
+	taskTypeGeneral  = "general"
+	taskTypeResearch = "research"
+	taskTypeCode     = "code"
+	taskTypeTitle    = "title"
+)
+
+type routeMatchContext struct {
+	TaskType      string
+	Tags          []string
+	PolicyProfile string
+	AutomationID  string
+}
+
+func taskTypeForPlan(steps []PlannedStep) string {
+	if len(steps) == 0 {
+		return taskTypeGeneral
+	}
+	switch steps[0].ID {
+	case "collect_sources":
+		return taskTypeResearch
+	case "inspect_workspace":
+		return taskTypeCode
+	default:
+		return taskTypeGeneral
+	}
+}
+
+func (a *RunActivities) resolveRouteMatchContext(ctx context.Context, runID string, messages []store.Message) routeMatchContext {
+	match := routeMatchContext{
+		TaskType:      taskTypeForPlan(buildExecutionPlan(latestUserMessage(messages))),
+		PolicyProfile: "default",
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		match.AutomationID = readString(messages[i].Metadata, "automation_id")
+		break
+	}
+	if strings.TrimSpace(runID) == "" {
+		return match
+	}
+	eventsList, err := a.store.ListEvents(ctx, runID, 0)
+	if err != nil {
+		return match
+	}
+	for i := len(eventsList) - 1; i >= 0; i-- {
+		if eventsList[i].Type != "run.started" && eventsList[i].Type != "run.resumed" {
+			continue
+		}
+		if profile := readString(eventsList[i].Payload, "policy_profile"); profile != "" {
+			match.PolicyProfile = profile
+		}
+		match.Tags = toStringSlice(eventsList[i].Payload["tags"])
+		break
+	}
+	return match
+}
+
+// matchModelRouteRule returns the first enabled rule, in priority order, whose
+// criteria all match. Empty criteria match anything.
+func (a *RunActivities) matchModelRouteRule(ctx context.Context, match routeMatchContext) *store.LLMRouteRule {
+	rules, err := a.store.ListLLMRouteRules(ctx)
+	if err != nil {
+		return nil
+	}
+	for _, rule := range rules {
+		if !rule.Enabled || len(parseModelRoute(rule.Route)) == 0 {
+			continue
+		}
+		if !routeCriterionMatches(rule.TaskTypes, []string{match.TaskType}) ||
+			!routeCriterionMatches(rule.Tags, match.Tags) ||
+			!routeCriterionMatches(rule.PolicyProfiles, []string{match.PolicyProfile}) ||
+			!routeCriterionMatches(rule.AutomationIDs, []string{match.AutomationID}) {
+			continue
+		}
+		matched := rule
+		return &matched
+	}
+	return nil
+}
+
+func routeCriterionMatches(allowed []string, values []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, candidate := range allowed {
+		for _, value := range values {
+			if value != "" && strings.EqualFold(strings.TrimSpace(candidate), strings.TrimSpace(value)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveTaskProvider picks the provider for an auxiliary task such as title
+// generation, falling back to fallback. Routed providers get the utility
+// timeout rather than the caller's.
+func (a *RunActivities) resolveTaskProvider(ctx context.Context, cfg llm.Config, match routeMatchContext, taskType string, fallback llmProviderCandidate) llmProviderCandidate {
+	match.TaskType = taskType
+	rule := a.matchModelRouteRule(ctx, match)
+	if rule == nil || !containsString(rule.TaskTypes, taskType) {
+		return fallback
+	}
+	candidates, err := a.buildProviderCandidates(ctx, cfg, rule.Route, rule.Name)
+	if err != nil || len(candidates) == 0 || candidates[0].Rule == "" {
+		return fallback
+	}
+	candidate := candidates[0]
+	candidate.Timeout = a.utilityTimeout
+	return candidate
+}
+
 func (a *RunActivities) resolveConfig(ctx context.Context, messages []store.Message) (llm.Config, error) {
 	cfg := a.defaultConfig
 	settings, err := a.store.GetLLMSettings(ctx)
@@ -1238,11 +1524,16 @@ func (a *RunActivities) resolveConfig(ctx context.Context, messages []store.Mess
 		cfg.BaseURL = settings.BaseURL
 		cfg.CodexAuthPath = settings.CodexAuthPath
 		cfg.CodexHome = settings.CodexHome
+		if settings.UtilityProvider != "" {
+			cfg.UtilityProvider = settings.UtilityProvider
+			cfg.UtilityModel = settings.UtilityModel
+			cfg.UtilityBaseURL = settings.UtilityBaseURL
+		}
 		if settings.APIKeyEnc != "" {
-			if a.secretsKey == nil {
+			if a.keyring == nil {
 				return cfg, errors.New("LLM_SECRETS_KEY is required to decrypt API keys")
 			}
-			apiKey, err := decryptSecret(a.secretsKey, settings.APIKeyEnc)
+			apiKey, err := decryptSecret(a.keyring, settings.APIKeyEnc)
 			if err != nil {
 				return cfg, err
 			}
@@ -1256,6 +1547,18 @@ func (a *RunActivities) resolveConfig(ctx context.Context, messages []store.Mess
 			}
 		}
 	}
+	var profiles []store.LLMProviderProfile
+	if profileName := extractProfileOverride(messages); profileName != "" {
+		profiles = a.listProviderProfiles(ctx)
+		profile := findProviderProfileByName(profiles, profileName)
+		if profile == nil {
+			return cfg, fmt.Errorf("unknown llm profile: %s", profileName)
+		}
+		cfg, err = a.applyProviderProfile(cfg, *profile)
+		if err != nil {
+			return cfg, err
+		}
+	}
 	if overrideProvider, overrideModel := extractOverrides(messages); overrideProvider != "" || overrideModel != "" {
 		if overrideProvider != "" {
 			cfg.Provider = overrideProvider
@@ -1264,6 +1567,12 @@ func (a *RunActivities) resolveConfig(ctx context.Context, messages []store.Mess
 			cfg.Model = overrideModel
 		}
 	}
+	if requiresAPIKey(cfg.Provider) && !providerHasAPIKey(cfg, cfg.Provider) {
+		if profiles == nil {
+			profiles = a.listProviderProfiles(ctx)
+		}
+		cfg = a.withProfileCredentials(cfg, "", profiles)
+	}
 	if requiresAPIKey(cfg.Provider) {
 		if cfg.Provider == "openrouter" {
 			if cfg.OpenRouterAPIKey == "" {
@@ -1321,6 +1630,32 @@ func resolveBrowserUserTabConfig(messages []store.Message) browserUserTabConfig
 	return config
 }
 
+// buildEnabledSkillsPrompt points the model at the skills the latest user
+// message enabled through its comma-separated "skills" metadata.
+func buildEnabledSkillsPrompt(messages []store.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role != "user" {
+			continue
+		}
+		names := make([]string, 0)
+		for _, name := range strings.Split(readString(msg.Metadata, "skills"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return ""
+		}
+		skillsRoot := "~/.config/opencode/skills"
+		if root, err := skills.RootDir(); err == nil {
+			skillsRoot = root
+		}
+		return fmt.Sprintf("Skills enabled for this request: %s. Read each skill's SKILL.md under %s before starting and follow it.", strings.Join(names, ", "), skillsRoot)
+	}
+	return ""
+}
+
 func normalizePreferredBrowser(raw string) string {
 	value := strings.ToLower(strings.TrimSpace(raw))
 	switch value {
@@ -1784,7 +2119,7 @@ func (a *RunActivities) detectToolCapabilities(ctx context.Context) toolCapabili
 	return toolCapabilities{runnerStatus: runnerStatus, browserStatus: browserStatus, familiesLine: strings.Join(families, ", ")}
 }
 
-func (a *RunActivities) maybeGenerateRunTitle(ctx context.Context, runID string, provider llm.Provider, messages []store.Message, assistantReply string) {
+func (a *RunActivities) maybeGenerateRunTitle(ctx context.Context, runID string, provider llmProviderCandidate, messages []store.Message, assistantReply string) {
 	if strings.TrimSpace(assistantReply) == "" {
 		return
 	}
@@ -1815,13 +2150,7 @@ func (a *RunActivities) maybeGenerateRunTitle(ctx context.Context, runID string,
 		{Role: "system", Content: "Generate a concise chat title (3-6 words). Return only the title text, no punctuation wrappers."},
 		{Role: "user", Content: fmt.Sprintf("User request: %s\n\nAssistant response: %s", truncateRunes(latestUser, 280), truncateRunes(strings.TrimSpace(assistantReply), 600))},
 	}
-	titleCtx := ctx
-	cancel := func() {}
-	if runTitleGenerateTimeout > 0 {
-		titleCtx, cancel = context.WithTimeout(ctx, runTitleGenerateTimeout)
-	}
-	defer cancel()
-	titleRaw, err := provider.Generate(titleCtx, titlePrompt)
+	titleRaw, err := a.generateOnce(ctx, provider, titlePrompt, runTitleGenerateTimeout)
 	if err != nil {
 		return
 	}
@@ -1829,18 +2158,7 @@ func (a *RunActivities) maybeGenerateRunTitle(ctx context.Context, runID string,
 	if title == "" {
 		return
 	}
-	seq, err := a.store.NextSeq(ctx, runID)
-	if err != nil {
-		return
-	}
-	_ = a.store.AppendEvent(ctx, store.RunEvent{
-		RunID:     runID,
-		Seq:       seq,
-		Type:      "run.title.updated",
-		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
-		Source:    "llm",
-		Payload:   map[string]any{"title": title},
-	})
+	_ = a.emitEvent(ctx, runID, "run.title.updated", map[string]any{"title": title})
 }
 
 func sanitizeRunTitle(raw string) string {
@@ -2233,46 +2551,72 @@ func (a *RunActivities) generateWithRetry(ctx context.Context, runID string, pro
 					timeout = remaining
 				}
 			}
+			if err := a.admitProvider(provider); err != nil {
+				lastErr = err
+				break
+			}
 			if timeout > 0 {
 				generateCtx, cancel = context.WithTimeout(ctx, timeout)
 			}
 			if runID != "" {
-				_ = a.postEvent(ctx, runID, "model.request.started", map[string]any{
+				startedPayload := map[string]any{
 					"provider":  provider.Name,
+					"model":     provider.Model,
 					"attempt":   attempt,
 					"transient": true,
-				})
+				}
+				if provider.Rule != "" {
+					startedPayload["route_rule"] = provider.Rule
+				}
+				_ = a.postEvent(ctx, runID, "model.request.started", startedPayload)
 			}
 			response, err := provider.Provider.Generate(generateCtx, messages)
 			cancel()
+			if err == nil && strings.TrimSpace(response) == "" {
+				err = errors.New("LLM response had no content")
+			}
+			a.recordProviderOutcome(provider, err)
 			if err == nil {
-				if strings.TrimSpace(response) == "" {
-					err = errors.New("LLM response had no content")
-				} else {
-					if runID != "" {
-						_ = a.postEvent(ctx, runID, "model.request.completed", map[string]any{
-							"provider":  provider.Name,
-							"attempt":   attempt,
-							"transient": true,
-						})
-					}
-					return response, nil
+				if runID != "" {
+					_ = a.postEvent(ctx, runID, "model.request.completed", map[string]any{
+						"provider":  provider.Name,
+						"model":     provider.Model,
+						"attempt":   attempt,
+						"transient": true,
+					})
 				}
+				return response, nil
 			}
+			retryable := isRetryableLLMError(err)
+			retryAfter := llm.RetryAfterFromError(err)
 			if runID != "" {
-				_ = a.postEvent(ctx, runID, "model.request.failed", map[string]any{
-					"provider": provider.Name,
-					"attempt":  attempt,
-					"error":    truncateRunes(strings.TrimSpace(err.Error()), 200),
-				})
+				failedPayload := map[string]any{
+					"provider":  provider.Name,
+					"model":     provider.Model,
+					"attempt":   attempt,
+					"retryable": retryable,
+					"error":     truncateRunes(strings.TrimSpace(err.Error()), 200),
+				}
+				if statusCode := llm.StatusCodeFromError(err); statusCode > 0 {
+					failedPayload["status_code"] = statusCode
+				}
+				if retryAfter > 0 {
+					failedPayload["retry_after_ms"] = retryAfter.Milliseconds()
+				}
+				_ = a.postEvent(ctx, runID, "model.request.failed", failedPayload)
 			}
 			lastErr = err
-			if !isRetryableLLMError(err) {
+			if !retryable {
 				return "", err
 			}
 			if shouldFailoverProvider(err) {
 				break
 			}
+			// A Retry-After hint means the provider asked us to back off; move on
+			// to the next candidate instead of retrying into the rate limit.
+			if retryAfter > 0 {
+				break
+			}
 			// Timeouts are the slowest failure mode; cap these to two attempts.
 			if isTimeoutLLMError(err) && attempt >= 2 {
 				break
@@ -5219,12 +5563,27 @@ func inferToolFailureReasonCode(err error) string {
 	}
 }
 
-func (a *RunActivities) executeToolCall(ctx context.Context, runID string, call toolCall, browserUserTab browserUserTabConfig) (map[string]any, error) {
+func (a *RunActivities) executeToolCall(ctx context.Context, runID string, call toolCall, browserUserTab browserUserTabConfig) (output map[string]any, err error) {
 	if a.toolRunner == "" {
 		return nil, &toolExecutionError{Message: "tool runner url not configured"}
 	}
 	invocationID := uuid.New().String()
 	toolInput := cloneAnyMap(call.Input)
+	usedSecrets, err := a.resolveToolSecrets(ctx, runID, toolInput)
+	if err != nil {
+		return nil, &toolExecutionError{InvocationID: invocationID, Message: err.Error()}
+	}
+	if len(usedSecrets) > 0 {
+		defer func() {
+			if output != nil {
+				output, _ = scrubSecretValues(output, usedSecrets).(map[string]any)
+			}
+			var toolErr *toolExecutionError
+			if errors.As(err, &toolErr) {
+				toolErr.Message = scrubSecretString(toolErr.Message, usedSecrets)
+			}
+		}()
+	}
 	if browserUserTab.Enabled && strings.HasPrefix(strings.ToLower(strings.TrimSpace(call.ToolName)), "browser.") {
 		toolInput["_browser_mode"] = "user_tab"
 		toolInput["_browser_guardrails"] = map[string]any{
@@ -5243,6 +5602,9 @@ func (a *RunActivities) executeToolCall(ctx context.Context, runID string, call
 		"tool_name":        call.ToolName,
 		"input":            toolInput,
 	}
+	if len(usedSecrets) > 0 {
+		payload["secrets"] = usedSecrets
+	}
 	if a.toolTimeout > 0 {
 		payload["timeout_ms"] = int(a.toolTimeout / time.Millisecond)
 	}
@@ -5616,6 +5978,7 @@ func (a *RunActivities) postMessage(ctx context.Context, runID string, content s
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	a.setControlPlaneAuth(req, body)
 	resp, err := a.httpClient.Do(req)
 	if err != nil {
 		return err
@@ -5647,6 +6010,7 @@ func (a *RunActivities) postEvent(ctx context.Context, runID string, eventType s
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	a.setControlPlaneAuth(req, body)
 	resp, err := a.httpClient.Do(req)
 	if err != nil {
 		return err
@@ -5657,3 +6021,12 @@ func (a *RunActivities) postEvent(ctx context.Context, runID string, eventType s
 	}
 	return nil
 }
+
+func (a *RunActivities) setControlPlaneAuth(req *http.Request, body []byte) {
+	if a.controlPlaneAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.controlPlaneAPIKey)
+	}
+	if a.signingService != "" && a.signingSecret != "" {
+		signing.SignRequest(req, a.signingService, a.signingSecret, body, time.Now())
+	}
+}
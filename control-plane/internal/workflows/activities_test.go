@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -16,7 +17,9 @@ import (
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/personality"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/secrets"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
 	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store/memory"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,6 +35,9 @@ type stubStore struct {
 	getPersonalitySettingsFunc func(ctx context.Context) (*store.PersonalitySettings, error)
 	searchMemoryFunc           func(ctx context.Context, query string, limit int) ([]store.MemoryEntry, error)
 	searchMemoryEmbeddingFunc  func(ctx context.Context, query string, embedding []float32, limit int) ([]store.MemoryEntry, error)
+	listLLMRouteRulesFunc      func(ctx context.Context) ([]store.LLMRouteRule, error)
+	listLLMProfilesFunc        func(ctx context.Context) ([]store.LLMProviderProfile, error)
+	listSecretsFunc            func(ctx context.Context) ([]store.Secret, error)
 }
 
 func (s *stubStore) ListRuns(ctx context.Context) ([]store.RunSummary, error) {
@@ -40,9 +46,10 @@ func (s *stubStore) ListRuns(ctx context.Context) ([]store.RunSummary, error) {
 	}
 	return nil, nil
 }
-func (s *stubStore) DeleteRun(ctx context.Context, runID string) error       { return nil }
-func (s *stubStore) CreateRun(ctx context.Context, run store.Run) error      { return nil }
-func (s *stubStore) AddMessage(ctx context.Context, msg store.Message) error { return nil }
+func (s *stubStore) DeleteRun(ctx context.Context, runID string) error            { return nil }
+func (s *stubStore) CreateRun(ctx context.Context, run store.Run) error           { return nil }
+func (s *stubStore) GetRun(ctx context.Context, runID string) (*store.Run, error) { return nil, nil }
+func (s *stubStore) AddMessage(ctx context.Context, msg store.Message) error      { return nil }
 func (s *stubStore) ListMessages(ctx context.Context, runID string) ([]store.Message, error) {
 	if s.listMessagesFunc != nil {
 		return s.listMessagesFunc(ctx, runID)
@@ -58,6 +65,150 @@ func (s *stubStore) GetLLMSettings(ctx context.Context) (*store.LLMSettings, err
 func (s *stubStore) UpsertLLMSettings(ctx context.Context, settings store.LLMSettings) error {
 	return nil
 }
+func (s *stubStore) ListLLMRouteRules(ctx context.Context) ([]store.LLMRouteRule, error) {
+	if s.listLLMRouteRulesFunc != nil {
+		return s.listLLMRouteRulesFunc(ctx)
+	}
+	return nil, nil
+}
+func (s *stubStore) GetLLMRouteRule(ctx context.Context, ruleID string) (*store.LLMRouteRule, error) {
+	return nil, nil
+}
+func (s *stubStore) CreateLLMRouteRule(ctx context.Context, rule store.LLMRouteRule) error {
+	return nil
+}
+func (s *stubStore) UpdateLLMRouteRule(ctx context.Context, rule store.LLMRouteRule) error {
+	return nil
+}
+func (s *stubStore) DeleteLLMRouteRule(ctx context.Context, ruleID string) error { return nil }
+func (s *stubStore) ListLLMProviderProfiles(ctx context.Context) ([]store.LLMProviderProfile, error) {
+	if s.listLLMProfilesFunc != nil {
+		return s.listLLMProfilesFunc(ctx)
+	}
+	return nil, nil
+}
+func (s *stubStore) GetLLMProviderProfile(ctx context.Context, profileID string) (*store.LLMProviderProfile, error) {
+	return nil, nil
+}
+func (s *stubStore) CreateLLMProviderProfile(ctx context.Context, profile store.LLMProviderProfile) error {
+	return nil
+}
+func (s *stubStore) UpdateLLMProviderProfile(ctx context.Context, profile store.LLMProviderProfile) error {
+	return nil
+}
+func (s *stubStore) DeleteLLMProviderProfile(ctx context.Context, profileID string) error { return nil }
+func (s *stubStore) ListSecrets(ctx context.Context) ([]store.Secret, error) {
+	if s.listSecretsFunc != nil {
+		return s.listSecretsFunc(ctx)
+	}
+	return nil, nil
+}
+func (s *stubStore) GetSecret(ctx context.Context, secretID string) (*store.Secret, error) {
+	return nil, nil
+}
+func (s *stubStore) CreateSecret(ctx context.Context, secret store.Secret) error { return nil }
+func (s *stubStore) UpdateSecret(ctx context.Context, secret store.Secret) error { return nil }
+func (s *stubStore) DeleteSecret(ctx context.Context, secretID string) error     { return nil }
+func (s *stubStore) RotateSecrets(ctx context.Context, rotate store.SecretRotateFunc) (store.SecretRotationReport, error) {
+	return store.SecretRotationReport{}, nil
+}
+func (s *stubStore) ListRedactionPolicies(ctx context.Context) ([]store.RedactionPolicy, error) {
+	return nil, nil
+}
+func (s *stubStore) GetRedactionPolicy(ctx context.Context, policyProfile string) (*store.RedactionPolicy, error) {
+	return nil, nil
+}
+func (s *stubStore) UpsertRedactionPolicy(ctx context.Context, policy store.RedactionPolicy) error {
+	return nil
+}
+func (s *stubStore) DeleteRedactionPolicy(ctx context.Context, policyProfile string) error {
+	return nil
+}
+func (s *stubStore) ListAPIKeys(ctx context.Context) ([]store.APIKey, error) { return nil, nil }
+func (s *stubStore) GetAPIKey(ctx context.Context, keyID string) (*store.APIKey, error) {
+	return nil, nil
+}
+func (s *stubStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*store.APIKey, error) {
+	return nil, nil
+}
+func (s *stubStore) CreateAPIKey(ctx context.Context, key store.APIKey) error { return nil }
+func (s *stubStore) TouchAPIKey(ctx context.Context, keyID string, usedAt string) error {
+	return nil
+}
+func (s *stubStore) DeleteAPIKey(ctx context.Context, keyID string) error          { return nil }
+func (s *stubStore) ListWorkspaces(ctx context.Context) ([]store.Workspace, error) { return nil, nil }
+func (s *stubStore) GetWorkspace(ctx context.Context, workspaceID string) (*store.Workspace, error) {
+	return nil, nil
+}
+func (s *stubStore) CreateWorkspace(ctx context.Context, workspace store.Workspace) error { return nil }
+func (s *stubStore) DeleteWorkspace(ctx context.Context, workspaceID string) error        { return nil }
+func (s *stubStore) ListUsers(ctx context.Context) ([]store.User, error)                  { return nil, nil }
+func (s *stubStore) GetUser(ctx context.Context, userID string) (*store.User, error)      { return nil, nil }
+func (s *stubStore) CreateUser(ctx context.Context, user store.User) error                { return nil }
+func (s *stubStore) DeleteUser(ctx context.Context, userID string) error                  { return nil }
+func (s *stubStore) ListWorkspaceMembers(ctx context.Context, workspaceID string) ([]store.WorkspaceMember, error) {
+	return nil, nil
+}
+func (s *stubStore) UpsertWorkspaceMember(ctx context.Context, member store.WorkspaceMember) error {
+	return nil
+}
+func (s *stubStore) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	return nil
+}
+func (s *stubStore) GetRunWorkspace(ctx context.Context, runID string) (string, error) {
+	return "", nil
+}
+func (s *stubStore) AppendAuditEntry(ctx context.Context, entry store.AuditEntry) error {
+	return nil
+}
+func (s *stubStore) ListAuditEntries(ctx context.Context, query store.AuditQuery) ([]store.AuditEntry, error) {
+	return nil, nil
+}
+func (s *stubStore) ListWebhookSubscriptions(ctx context.Context) ([]store.WebhookSubscription, error) {
+	return nil, nil
+}
+func (s *stubStore) GetWebhookSubscription(ctx context.Context, subscriptionID string) (*store.WebhookSubscription, error) {
+	return nil, nil
+}
+func (s *stubStore) CreateWebhookSubscription(ctx context.Context, subscription store.WebhookSubscription) error {
+	return nil
+}
+func (s *stubStore) UpdateWebhookSubscription(ctx context.Context, subscription store.WebhookSubscription) error {
+	return nil
+}
+func (s *stubStore) DeleteWebhookSubscription(ctx context.Context, subscriptionID string) error {
+	return nil
+}
+func (s *stubStore) CreateWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery) error {
+	return nil
+}
+func (s *stubStore) GetWebhookDelivery(ctx context.Context, deliveryID string) (*store.WebhookDelivery, error) {
+	return nil, nil
+}
+func (s *stubStore) UpdateWebhookDelivery(ctx context.Context, delivery store.WebhookDelivery) error {
+	return nil
+}
+func (s *stubStore) ListWebhookDeliveries(ctx context.Context, subscriptionID string, limit int) ([]store.WebhookDelivery, error) {
+	return nil, nil
+}
+func (s *stubStore) ClaimWebhookDeliveries(ctx context.Context, now string, leaseUntil string, limit int) ([]store.WebhookDelivery, error) {
+	return nil, nil
+}
+func (s *stubStore) ListNotificationChannels(ctx context.Context) ([]store.NotificationChannel, error) {
+	return nil, nil
+}
+func (s *stubStore) GetNotificationChannel(ctx context.Context, channelID string) (*store.NotificationChannel, error) {
+	return nil, nil
+}
+func (s *stubStore) CreateNotificationChannel(ctx context.Context, channel store.NotificationChannel) error {
+	return nil
+}
+func (s *stubStore) UpdateNotificationChannel(ctx context.Context, channel store.NotificationChannel) error {
+	return nil
+}
+func (s *stubStore) DeleteNotificationChannel(ctx context.Context, channelID string) error {
+	return nil
+}
 func (s *stubStore) ListSkills(ctx context.Context) ([]store.Skill, error) { return nil, nil }
 func (s *stubStore) GetSkill(ctx context.Context, skillID string) (*store.Skill, error) {
 	return nil, nil
@@ -173,12 +324,24 @@ func (s *stubStore) CreateAutomationInboxEntry(ctx context.Context, entry store.
 func (s *stubStore) UpdateAutomationInboxEntry(ctx context.Context, entry store.AutomationInboxEntry) error {
 	return nil
 }
+func (s *stubStore) ClaimAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string, now string, leaseUntil string) (bool, error) {
+	return false, nil
+}
+func (s *stubStore) ReleaseAutomationInboxEntry(ctx context.Context, automationID string, entryID string, owner string) error {
+	return nil
+}
 func (s *stubStore) MarkAutomationInboxEntryRead(ctx context.Context, automationID string, entryID string) error {
 	return nil
 }
 func (s *stubStore) MarkAutomationInboxReadAll(ctx context.Context, automationID string) error {
 	return nil
 }
+func (s *stubStore) QueryAutomationInbox(ctx context.Context, query store.AutomationInboxQuery) ([]store.AutomationInboxEntry, error) {
+	return nil, nil
+}
+func (s *stubStore) DeleteAutomationInboxEntries(ctx context.Context, automationID string, entryIDs []string) error {
+	return nil
+}
 
 type stubProvider struct {
 	generate func(ctx context.Context, messages []llm.Message) (string, error)
@@ -1150,14 +1313,14 @@ func TestBuildProviderCandidates_UsesPrimaryOnlyWithoutExplicitFallback(t *testi
 	}
 
 	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, "http://example.com", "")
-	candidates, err := activities.buildProviderCandidates(llm.Config{
+	candidates, err := activities.buildProviderCandidates(context.Background(), llm.Config{
 		Provider:         "openai",
 		Model:            "gpt-4.1",
 		OpenAIAPIKey:     "openai-key",
 		OpenRouterAPIKey: "openrouter-key",
 		OpenCodeAPIKey:   "opencode-key",
 		CodexAuthPath:    "/tmp/codex/auth.json",
-	}, "")
+	}, "", "")
 	require.NoError(t, err)
 	require.Len(t, candidates, 1)
 	require.Equal(t, "openai", candidates[0].Name)
@@ -1177,13 +1340,13 @@ func TestBuildProviderCandidates_ExplicitFallbackSameProviderUsesFallbackModel(t
 	}
 
 	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, "http://example.com", "")
-	candidates, err := activities.buildProviderCandidates(llm.Config{
+	candidates, err := activities.buildProviderCandidates(context.Background(), llm.Config{
 		Provider:         "openai",
 		Model:            "gpt-4.1",
 		FallbackProvider: "openai",
 		FallbackModel:    "gpt-4.1-mini",
 		OpenAIAPIKey:     "openai-key",
-	}, "")
+	}, "", "")
 	require.NoError(t, err)
 	require.Len(t, candidates, 2)
 	require.Equal(t, "openai", candidates[0].Name)
@@ -1205,14 +1368,14 @@ func TestBuildProviderCandidates_ModelRouteOverridesDefaultChain(t *testing.T) {
 	}
 
 	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, "http://example.com", "")
-	candidates, err := activities.buildProviderCandidates(llm.Config{
+	candidates, err := activities.buildProviderCandidates(context.Background(), llm.Config{
 		Provider:         "openai",
 		Model:            "gpt-4.1",
 		FallbackProvider: "openrouter",
 		FallbackModel:    "meta-llama/3.1-8b",
 		OpenAIAPIKey:     "openai-key",
 		OpenCodeAPIKey:   "opencode-key",
-	}, "opencode-zen:kimi-k2.5,openai:gpt-4o-mini")
+	}, "opencode-zen:kimi-k2.5,openai:gpt-4o-mini", "")
 	require.NoError(t, err)
 	require.Len(t, candidates, 2)
 	require.Equal(t, "opencode-zen", candidates[0].Name)
@@ -1220,6 +1383,233 @@ func TestBuildProviderCandidates_ModelRouteOverridesDefaultChain(t *testing.T) {
 	require.Equal(t, []string{"opencode-zen:kimi-k2.5", "openai:gpt-4o-mini"}, captured)
 }
 
+func TestBuildProviderCandidates_SkipsOpenBreakers(t *testing.T) {
+	originalProvider := newProvider
+	defer func() { newProvider = originalProvider }()
+
+	newProvider = func(cfg llm.Config) (llm.Provider, error) {
+		return stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+			return "ok", nil
+		}}, nil
+	}
+
+	tracker := llm.NewHealthTracker(llm.HealthConfig{})
+	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, "http://example.com", "", WithProviderHealth(tracker))
+	cfg := llm.Config{
+		Provider:         "openai",
+		Model:            "gpt-4.1",
+		FallbackProvider: "openrouter",
+		FallbackModel:    "meta-llama/3.1-8b",
+		OpenAIAPIKey:     "openai-key",
+		OpenRouterAPIKey: "openrouter-key",
+	}
+
+	tracker.RecordFailure("openai", "gpt-4.1", "LLM request failed: 429 Too Many Requests", time.Minute)
+	candidates, err := activities.buildProviderCandidates(context.Background(), cfg, "", "")
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	require.Equal(t, "openrouter", candidates[0].Name)
+	require.Equal(t, "meta-llama/3.1-8b", candidates[0].Model)
+
+	tracker.RecordFailure("openrouter", "meta-llama/3.1-8b", "LLM request failed: 429 Too Many Requests", time.Minute)
+	candidates, err = activities.buildProviderCandidates(context.Background(), cfg, "", "")
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	require.Equal(t, "openai", candidates[0].Name)
+}
+
+func TestBuildProviderCandidates_ResolvesProviderProfiles(t *testing.T) {
+	originalProvider := newProvider
+	defer func() { newProvider = originalProvider }()
+
+	captured := make([]llm.Config, 0, 4)
+	newProvider = func(cfg llm.Config) (llm.Provider, error) {
+		captured = append(captured, cfg)
+		return stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+			return "ok", nil
+		}}, nil
+	}
+
+	key := []byte("12345678901234567890123456789012")
+	workKey, err := secrets.Encrypt(key, "work-openai-key")
+	require.NoError(t, err)
+	routerKey, err := secrets.Encrypt(key, "profile-router-key")
+	require.NoError(t, err)
+	storeStub := &stubStore{
+		listLLMProfilesFunc: func(ctx context.Context) ([]store.LLMProviderProfile, error) {
+			return []store.LLMProviderProfile{
+				{Name: "router", Provider: "openrouter", Model: "meta-llama/3.1-8b", APIKeyEnc: routerKey},
+				{Name: "work", Provider: "openai", Model: "gpt-4.1", BaseURL: "https://work.example.com/v1", APIKeyEnc: workKey},
+			}, nil
+		},
+	}
+	activities := NewRunActivities(storeStub, llm.Config{}, key, "http://example.com", "")
+	candidates, err := activities.buildProviderCandidates(context.Background(), llm.Config{
+		Provider:     "openai",
+		Model:        "gpt-4o",
+		OpenAIAPIKey: "personal-openai-key",
+	}, "@work:gpt-4o-mini,openrouter,@missing", "")
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	require.Equal(t, "openai", candidates[0].Name)
+	require.Equal(t, "gpt-4o-mini", candidates[0].Model)
+	require.Equal(t, "openrouter", candidates[1].Name)
+	require.Equal(t, "meta-llama/3.1-8b", candidates[1].Model)
+
+	require.Len(t, captured, 2)
+	require.Equal(t, "work-openai-key", captured[0].OpenAIAPIKey)
+	require.Equal(t, "https://work.example.com/v1", captured[0].BaseURL)
+	require.Equal(t, "profile-router-key", captured[1].OpenRouterAPIKey)
+}
+
+func TestGenerateWithRetry_RetryAfterFailsOverAndOpensBreaker(t *testing.T) {
+	primaryCalls := 0
+	providers := []llmProviderCandidate{
+		{Name: "openai", Model: "gpt-4.1", Provider: stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+			primaryCalls++
+			return "", llm.ErrRequestFailed{Message: "LLM request failed: 429 Too Many Requests", StatusCode: http.StatusTooManyRequests, RetryAfter: 30 * time.Second}
+		}}},
+		{Name: "openrouter", Model: "mini", Provider: stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+			return "fallback", nil
+		}}},
+	}
+
+	failedPayloads := make(chan map[string]any, 4)
+	cpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["type"] == "model.request.failed" {
+			payload, _ := body["payload"].(map[string]any)
+			failedPayloads <- payload
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer cpServer.Close()
+
+	tracker := llm.NewHealthTracker(llm.HealthConfig{})
+	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, cpServer.URL, "", WithProviderHealth(tracker))
+	activities.httpClient = cpServer.Client()
+
+	response, err := activities.generateWithRetry(context.Background(), "run-1", providers, []llm.Message{{Role: "user", Content: "hi"}})
+	require.NoError(t, err)
+	require.Equal(t, "fallback", response)
+	require.Equal(t, 1, primaryCalls)
+	require.False(t, tracker.Allow("openai", "gpt-4.1"))
+
+	payload := <-failedPayloads
+	require.Equal(t, "gpt-4.1", payload["model"])
+	require.Equal(t, true, payload["retryable"])
+	require.Equal(t, float64(30000), payload["retry_after_ms"])
+	require.Equal(t, float64(429), payload["status_code"])
+}
+
+func TestGenerateWithRetry_ClaimsHalfOpenProbeOnlyWhenTried(t *testing.T) {
+	tracker := llm.NewHealthTracker(llm.HealthConfig{FailureThreshold: 1, Cooldown: time.Millisecond})
+	tracker.RecordFailure("openrouter", "mini", "502 Bad Gateway", 0)
+	time.Sleep(5 * time.Millisecond)
+	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, "http://example.com", "", WithProviderHealth(tracker))
+
+	probeCalls := 0
+	candidates := activities.filterHealthyCandidates([]llmProviderCandidate{
+		{Name: "openai", Model: "gpt-4.1", Provider: stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+			return "primary", nil
+		}}},
+		{Name: "openrouter", Model: "mini", Provider: stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+			probeCalls++
+			return "", errors.New("LLM request failed: 400 Bad Request")
+		}}},
+	})
+	require.Len(t, candidates, 2)
+	response, err := activities.generateWithRetry(context.Background(), "", candidates, []llm.Message{{Role: "user", Content: "hi"}})
+	require.NoError(t, err)
+	require.Equal(t, "primary", response)
+	require.True(t, tracker.Available("openrouter", "mini"))
+
+	// A rejected probe says nothing about health and frees the probe slot.
+	_, err = activities.generateWithRetry(context.Background(), "", candidates[1:], []llm.Message{{Role: "user", Content: "hi"}})
+	require.Error(t, err)
+	require.Equal(t, 1, probeCalls)
+	require.True(t, tracker.Available("openrouter", "mini"))
+}
+
+func TestMatchModelRouteRule(t *testing.T) {
+	rules := []store.LLMRouteRule{
+		{ID: "disabled", Name: "disabled", Enabled: false, Route: "openai:gpt-4o"},
+		{ID: "code", Name: "code", Enabled: true, TaskTypes: []string{"code"}, Route: "codex:gpt-5.2-codex"},
+		{ID: "research-news", Name: "research-news", Enabled: true, TaskTypes: []string{"research"}, Tags: []string{"News"}, Route: "openrouter:fast"},
+		{ID: "automation", Name: "automation", Enabled: true, AutomationIDs: []string{"auto-1"}, Route: "openai:gpt-4.1-mini"},
+		{ID: "title", Name: "title", Enabled: true, TaskTypes: []string{"title"}, Route: "openai:gpt-4.1-nano"},
+	}
+	activities := NewRunActivities(&stubStore{
+		listLLMRouteRulesFunc: func(ctx context.Context) ([]store.LLMRouteRule, error) { return rules, nil },
+	}, llm.Config{}, nil, "http://example.com", "")
+
+	rule := activities.matchModelRouteRule(context.Background(), routeMatchContext{TaskType: "research", Tags: []string{"news"}, PolicyProfile: "default"})
+	require.NotNil(t, rule)
+	require.Equal(t, "research-news", rule.Name)
+
+	rule = activities.matchModelRouteRule(context.Background(), routeMatchContext{TaskType: "research", PolicyProfile: "default"})
+	require.Nil(t, rule)
+
+	rule = activities.matchModelRouteRule(context.Background(), routeMatchContext{TaskType: "general", AutomationID: "auto-1"})
+	require.NotNil(t, rule)
+	require.Equal(t, "automation", rule.Name)
+
+	require.Equal(t, taskTypeResearch, taskTypeForPlan(buildExecutionPlan("research the latest news")))
+	require.Equal(t, taskTypeCode, taskTypeForPlan(buildExecutionPlan("build a nextjs website")))
+	require.Equal(t, taskTypeGeneral, taskTypeForPlan(buildExecutionPlan("hello")))
+}
+
+func TestGenerateAssistantReply_RouteRuleReportedOnModelRequest(t *testing.T) {
+	originalProvider := newProvider
+	defer func() { newProvider = originalProvider }()
+
+	usedModels := make(chan string, 8)
+	newProvider = func(cfg llm.Config) (llm.Provider, error) {
+		model := cfg.Provider + ":" + cfg.Model
+		return stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+			usedModels <- model
+			return "routed response", nil
+		}}, nil
+	}
+
+	startedPayloads := make(chan map[string]any, 8)
+	cpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["type"] == "model.request.started" {
+			payload, _ := body["payload"].(map[string]any)
+			startedPayloads <- payload
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer cpServer.Close()
+
+	storeStub := &stubStore{
+		listMessagesFunc: func(ctx context.Context, runID string) ([]store.Message, error) {
+			return []store.Message{{Role: "user", Content: "Summarize this paragraph"}}, nil
+		},
+		listEventsFunc: func(ctx context.Context, runID string, afterSeq int64) ([]store.RunEvent, error) {
+			return []store.RunEvent{
+				{Type: "run.started", Payload: map[string]any{"policy_profile": "default", "tags": []any{"briefing"}}},
+				{Type: "run.title.updated", Payload: map[string]any{"title": "existing"}},
+			}, nil
+		},
+		listLLMRouteRulesFunc: func(ctx context.Context) ([]store.LLMRouteRule, error) {
+			return []store.LLMRouteRule{{ID: "r1", Name: "briefings", Enabled: true, Tags: []string{"briefing"}, Route: "openrouter:fast-model"}}, nil
+		},
+	}
+
+	activities := NewRunActivities(storeStub, llm.Config{Provider: "openai", Model: "gpt-4.1", OpenAIAPIKey: "key", OpenRouterAPIKey: "key"}, nil, cpServer.URL, "")
+	activities.httpClient = cpServer.Client()
+
+	require.NoError(t, activities.GenerateAssistantReply(context.Background(), GenerateInput{RunID: "run-routed"}))
+	require.Equal(t, "openrouter:fast-model", <-usedModels)
+	payload := <-startedPayloads
+	require.Equal(t, "briefings", payload["route_rule"])
+	require.Equal(t, "fast-model", payload["model"])
+}
+
 func TestParseModelRoute(t *testing.T) {
 	entries := parseModelRoute("opencode-zen:kimi-k2.5; openai:gpt-4o-mini\nopenrouter")
 	require.Len(t, entries, 3)
@@ -1389,6 +1779,50 @@ func TestResolveConfig(t *testing.T) {
 		require.Equal(t, "router-key", cfg.OpenRouterAPIKey)
 	})
 
+	t.Run("utility_settings", func(t *testing.T) {
+		storeStub := &stubStore{
+			getLLMSettingsFunc: func(ctx context.Context) (*store.LLMSettings, error) {
+				return &store.LLMSettings{
+					Provider:        "openai",
+					Model:           "base",
+					UtilityProvider: "openrouter",
+					UtilityModel:    "small",
+					UtilityBaseURL:  "https://router.test/v1",
+				}, nil
+			},
+		}
+		utilityDefaults := defaultCfg
+		utilityDefaults.UtilityProvider = "openai"
+		utilityDefaults.UtilityBaseURL = "https://env-utility.test/v1"
+		activities := NewRunActivities(storeStub, utilityDefaults, key, "http://example.com", "")
+
+		cfg, err := activities.resolveConfig(context.Background(), nil)
+		require.NoError(t, err)
+		require.Equal(t, "openrouter", cfg.UtilityProvider)
+		require.Equal(t, "small", cfg.UtilityModel)
+		require.Equal(t, "https://router.test/v1", cfg.UtilityBaseURL)
+	})
+
+	t.Run("llm_profile_override", func(t *testing.T) {
+		storeStub := &stubStore{
+			listLLMProfilesFunc: func(ctx context.Context) ([]store.LLMProviderProfile, error) {
+				return []store.LLMProviderProfile{{Name: "router", Provider: "openrouter", Model: "router-profile-model", APIKeyEnc: encrypted}}, nil
+			},
+		}
+		activities := NewRunActivities(storeStub, defaultCfg, key, "http://example.com", "")
+		messages := []store.Message{{Role: "user", Metadata: map[string]any{"llm_profile": "router"}}}
+
+		cfg, err := activities.resolveConfig(context.Background(), messages)
+		require.NoError(t, err)
+		require.Equal(t, "openrouter", cfg.Provider)
+		require.Equal(t, "router-profile-model", cfg.Model)
+		require.Equal(t, "router-key", cfg.OpenRouterAPIKey)
+
+		messages = []store.Message{{Role: "user", Metadata: map[string]any{"llm_profile": "missing"}}}
+		_, err = activities.resolveConfig(context.Background(), messages)
+		require.EqualError(t, err, "unknown llm profile: missing")
+	})
+
 	t.Run("settings_error", func(t *testing.T) {
 		storeStub := &stubStore{
 			getLLMSettingsFunc: func(ctx context.Context) (*store.LLMSettings, error) {
@@ -3012,32 +3446,230 @@ func TestBuildToolExecutionFallback_UsesCompletionSummary(t *testing.T) {
 	require.NotContains(t, strings.ToLower(content), "could not produce a final assistant response")
 }
 
-func TestMaybeGenerateRunTitle_AppendsEvent(t *testing.T) {
-	storeStub := &stubStore{}
-	var capturedEvent store.RunEvent
-	storeStub.listEventsFunc = func(ctx context.Context, runID string, afterSeq int64) ([]store.RunEvent, error) {
-		return nil, nil
+func TestMaybeGenerateRunTitle_PostsEvent(t *testing.T) {
+	var posted map[string]any
+	cpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/runs/run-1/events", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer cpServer.Close()
+
+	storeStub := &stubStore{
+		appendEventFunc: func(ctx context.Context, event store.RunEvent) error {
+			t.Fatalf("unexpected local event %s", event.Type)
+			return nil
+		},
 	}
-	storeStub.nextSeqFunc = func(ctx context.Context, runID string) (int64, error) {
-		return 5, nil
+	activities := NewRunActivities(storeStub, llm.Config{}, nil, cpServer.URL, "")
+	activities.httpClient = cpServer.Client()
+	provider := stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+		require.Len(t, messages, 2)
+		return "Landing Page Fixes", nil
+	}}
+
+	activities.maybeGenerateRunTitle(context.Background(), "run-1", llmProviderCandidate{Name: "openai", Provider: provider}, []store.Message{{Role: "user", Content: "Fix my landing page"}}, "Done")
+
+	require.Equal(t, "run.title.updated", posted["type"])
+	require.Equal(t, "Landing Page Fixes", posted["payload"].(map[string]any)["title"])
+}
+
+func TestAppendLocalEvent_RedactsPayload(t *testing.T) {
+	key := []byte("12345678901234567890123456789012")
+	tokenEnc, err := secrets.Encrypt(key, "tok-live-123")
+	require.NoError(t, err)
+
+	cpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer cpServer.Close()
+
+	var captured store.RunEvent
+	storeStub := &stubStore{
+		listSecretsFunc: func(ctx context.Context) ([]store.Secret, error) {
+			return []store.Secret{{Name: "API_TOKEN", ValueEnc: tokenEnc, PolicyProfiles: []string{"*"}}}, nil
+		},
+		nextSeqFunc: func(ctx context.Context, runID string) (int64, error) {
+			return 5, nil
+		},
+		appendEventFunc: func(ctx context.Context, event store.RunEvent) error {
+			captured = event
+			return nil
+		},
 	}
-	storeStub.appendEventFunc = func(ctx context.Context, event store.RunEvent) error {
-		capturedEvent = event
-		return nil
+	activities := NewRunActivities(storeStub, llm.Config{}, key, cpServer.URL, "")
+	activities.httpClient = cpServer.Client()
+
+	require.NoError(t, activities.emitEvent(context.Background(), "run-1", "run.title.updated", map[string]any{"title": "Rotate tok-live-123 Today"}))
+	require.Equal(t, int64(5), captured.Seq)
+	require.Equal(t, "Rotate [secret:API_TOKEN] Today", captured.Payload["title"])
+	require.Len(t, captured.RedactionMarkers, 1)
+	require.Equal(t, "title", captured.RedactionMarkers[0].Path)
+}
+
+func TestGenerateAssistantReply_UtilityModelTitlesAndTagsRun(t *testing.T) {
+	originalProvider := newProvider
+	defer func() { newProvider = originalProvider }()
+
+	newProvider = func(cfg llm.Config) (llm.Provider, error) {
+		model := cfg.Model
+		return stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+			if model != "utility-mini" {
+				return "Here is the plan.", nil
+			}
+			if strings.Contains(messages[0].Content, "tags") {
+				return "Trip-Planning, #travel, travel", nil
+			}
+			return "Weekend Trip Plan", nil
+		}}, nil
 	}
 
-	activities := NewRunActivities(storeStub, llm.Config{}, nil, "http://example.com", "")
+	var titleEvent, tagEvent map[string]any
+	cpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/runs/run-utility/events" {
+			var body map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			switch body["type"] {
+			case "run.title.updated":
+				titleEvent = body
+			case store.RunTagsUpdatedEvent:
+				tagEvent = body
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer cpServer.Close()
+
+	appended := make([]store.RunEvent, 0)
+	storeStub := &stubStore{
+		listMessagesFunc: func(ctx context.Context, runID string) ([]store.Message, error) {
+			return []store.Message{{Role: "user", Content: "Plan a weekend trip"}}, nil
+		},
+		appendEventFunc: func(ctx context.Context, event store.RunEvent) error {
+			appended = append(appended, event)
+			return nil
+		},
+	}
+
+	activities := NewRunActivities(storeStub, llm.Config{Provider: "openai", Model: "gpt-4.1", OpenAIAPIKey: "key", UtilityProvider: "openai", UtilityModel: "utility-mini"}, nil, cpServer.URL, "")
+	activities.httpClient = cpServer.Client()
+
+	require.NoError(t, activities.GenerateAssistantReply(context.Background(), GenerateInput{RunID: "run-utility"}))
+	require.Empty(t, appended)
+	require.NotNil(t, titleEvent, "expected the title to be posted to the control plane")
+	require.Equal(t, "Weekend Trip Plan", titleEvent["payload"].(map[string]any)["title"])
+	require.NotNil(t, tagEvent, "expected tags to be posted to the control plane")
+	payload := tagEvent["payload"].(map[string]any)
+	require.Equal(t, []any{"trip-planning", "travel"}, payload["tags"])
+	require.Equal(t, "auto", payload["source"])
+}
+
+func TestMaybeGenerateRunTitle_UsesCandidateTimeout(t *testing.T) {
+	storeStub := &stubStore{
+		appendEventFunc: func(ctx context.Context, event store.RunEvent) error {
+			return nil
+		},
+	}
+	activities := NewRunActivities(storeStub, llm.Config{}, nil, "http://example.com", "", WithUtilityTimeout(30*time.Second))
+	var remaining time.Duration
 	provider := stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
-		require.Len(t, messages, 2)
-		return "Landing Page Fixes", nil
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		remaining = time.Until(deadline)
+		return "Slow Model Title", nil
 	}}
+	utility := &utilityModel{Name: "openai", Model: "utility-mini", Provider: provider, Timeout: activities.utilityTimeout}
 
-	activities.maybeGenerateRunTitle(context.Background(), "run-1", provider, []store.Message{{Role: "user", Content: "Fix my landing page"}}, "Done")
+	activities.maybeGenerateRunTitle(context.Background(), "run-1", utility.candidate(), []store.Message{{Role: "user", Content: "Fix my landing page"}}, "Done")
+	require.Greater(t, remaining, runTitleGenerateTimeout)
+
+	activities.maybeGenerateRunTitle(context.Background(), "run-1", llmProviderCandidate{Name: "openai", Provider: provider}, []store.Message{{Role: "user", Content: "Fix my landing page"}}, "Done")
+	require.LessOrEqual(t, remaining, runTitleGenerateTimeout)
+}
+
+func TestMaybeAutoTagRun_SkipsWhenAlreadyTagged(t *testing.T) {
+	storeStub := &stubStore{
+		listEventsFunc: func(ctx context.Context, runID string, afterSeq int64) ([]store.RunEvent, error) {
+			return []store.RunEvent{{Type: store.RunTagsUpdatedEvent, Payload: map[string]any{"tags": []any{"news"}, "source": "auto"}}}, nil
+		},
+		appendEventFunc: func(ctx context.Context, event store.RunEvent) error {
+			t.Fatalf("unexpected event %s", event.Type)
+			return nil
+		},
+	}
+	activities := NewRunActivities(storeStub, llm.Config{}, nil, "http://example.com", "")
+	utility := &utilityModel{Name: "openai", Model: "mini", Provider: stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+		t.Fatalf("utility model should not be called")
+		return "", nil
+	}}}
+
+	activities.maybeAutoTagRun(context.Background(), "run-1", utility, []store.Message{{Role: "user", Content: "news"}}, "done")
+}
 
-	require.Equal(t, "run-1", capturedEvent.RunID)
-	require.Equal(t, int64(5), capturedEvent.Seq)
-	require.Equal(t, "run.title.updated", capturedEvent.Type)
-	require.Equal(t, "Landing Page Fixes", capturedEvent.Payload["title"])
+func TestParseRunTags(t *testing.T) {
+	require.Equal(t, []string{"research", "ai-policy", "europe"}, parseRunTags("1. Research\n- AI Policy\n* europe, research"))
+	require.Equal(t, []string{"a", "b", "c", "d"}, parseRunTags("a, b, c, d, e"))
+	require.Empty(t, parseRunTags(" , ;"))
+}
+
+func TestUtilityConfig(t *testing.T) {
+	_, ok := utilityConfig(llm.Config{Provider: "openai", Model: "gpt-4.1"})
+	require.False(t, ok)
+
+	cfg, ok := utilityConfig(llm.Config{Provider: "openai", Model: "gpt-4.1", BaseURL: "https://primary.test", UtilityProvider: "openrouter", UtilityModel: "small"})
+	require.True(t, ok)
+	require.Equal(t, "openrouter", cfg.Provider)
+	require.Equal(t, "small", cfg.Model)
+	require.Equal(t, "", cfg.BaseURL)
+
+	cfg, ok = utilityConfig(llm.Config{Provider: "openai", Model: "gpt-4.1", BaseURL: "https://primary.test", UtilityProvider: "openai"})
+	require.True(t, ok)
+	require.Equal(t, "gpt-4.1", cfg.Model)
+	require.Equal(t, "https://primary.test", cfg.BaseURL)
+}
+
+func TestCompactConversationWindow_SummarizesDroppedMessages(t *testing.T) {
+	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, "http://example.com", "")
+	messages := []llm.Message{{Role: "system", Content: "system prompt"}}
+	for i := 0; i < maxConversationMessages+3; i++ {
+		messages = append(messages, llm.Message{Role: "user", Content: fmt.Sprintf("message %d", i)})
+	}
+
+	require.Len(t, activities.compactConversationWindow(context.Background(), nil, messages), maxConversationMessages+1)
+
+	var summarized string
+	utility := &utilityModel{Name: "openai", Model: "mini", Provider: stubProvider{generate: func(ctx context.Context, prompt []llm.Message) (string, error) {
+		summarized = prompt[1].Content
+		return "User sent numbered messages.", nil
+	}}}
+	compacted := activities.compactConversationWindow(context.Background(), utility, messages)
+	require.Len(t, compacted, maxConversationMessages+2)
+	require.Equal(t, "system prompt", compacted[0].Content)
+	require.Equal(t, "system", compacted[1].Role)
+	require.Contains(t, compacted[1].Content, "Summary of 3 earlier conversation message(s)")
+	require.Contains(t, compacted[1].Content, "User sent numbered messages.")
+	require.Contains(t, summarized, "user: message 0")
+	require.Contains(t, summarized, "user: message 2")
+	require.NotContains(t, summarized, "message 3")
+	require.Equal(t, "message 3", compacted[2].Content)
+}
+
+func TestPolishResearchResponse(t *testing.T) {
+	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, "http://example.com", "")
+	original := "Top stories:\n1. Alpha https://a.example.com\n2. Beta https://b.example.com\nCoverage limitation: retried twice"
+	requirements := webResearchRequirements{Enabled: true}
+
+	keepsLinks := &utilityModel{Name: "openai", Model: "mini", Provider: stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+		require.NotContains(t, messages[1].Content, "Coverage limitation")
+		return "Top stories:\n1. Alpha https://a.example.com\n2. Beta https://b.example.com", nil
+	}}}
+	require.Equal(t, "Top stories:\n1. Alpha https://a.example.com\n2. Beta https://b.example.com", activities.polishResearchResponse(context.Background(), keepsLinks, original, requirements))
+
+	dropsLinks := &utilityModel{Name: "openai", Model: "mini", Provider: stubProvider{generate: func(ctx context.Context, messages []llm.Message) (string, error) {
+		return "Top stories:\n1. Alpha https://a.example.com\n2. Beta", nil
+	}}}
+	require.Equal(t, original, activities.polishResearchResponse(context.Background(), dropsLinks, original, requirements))
+	require.Equal(t, original, activities.polishResearchResponse(context.Background(), keepsLinks, original, webResearchRequirements{}))
 }
 
 func TestParseToolCalls_InlineFenceAndBarePayload(t *testing.T) {
@@ -3426,6 +4058,78 @@ func TestExecuteToolCall_UserTabGuardrails(t *testing.T) {
 	require.Equal(t, "Mozilla/5.0 ... Brave/1.73.0", guardrails["browser_user_agent"])
 }
 
+func TestExecuteToolCall_ResolvesVaultSecrets(t *testing.T) {
+	key := []byte("12345678901234567890123456789012")
+	tokenEnc, err := secrets.Encrypt(key, "tok-live-123")
+	require.NoError(t, err)
+
+	var capturedBody map[string]any
+	toolServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&capturedBody))
+		_ = json.NewEncoder(w).Encode(toolRunnerResponse{
+			Status: "completed",
+			Output: map[string]any{"echo": "Authorization: Bearer tok-live-123", "items": []any{"tok-live-123"}},
+		})
+	}))
+	defer toolServer.Close()
+
+	policyProfile := "ops"
+	storeStub := &stubStore{
+		listSecretsFunc: func(ctx context.Context) ([]store.Secret, error) {
+			return []store.Secret{
+				{Name: "API_TOKEN", ValueEnc: tokenEnc, PolicyProfiles: []string{"ops"}},
+				{Name: "ADMIN_TOKEN", ValueEnc: tokenEnc, PolicyProfiles: []string{"admin"}},
+			}, nil
+		},
+		listEventsFunc: func(ctx context.Context, runID string, afterSeq int64) ([]store.RunEvent, error) {
+			return []store.RunEvent{{Type: "run.started", Payload: map[string]any{"policy_profile": policyProfile}}}, nil
+		},
+	}
+	activities := NewRunActivities(storeStub, llm.Config{}, key, "http://example.com", toolServer.URL)
+	activities.httpClient = toolServer.Client()
+
+	call := toolCall{
+		ToolName: "http.request",
+		Input: map[string]any{
+			"url":     "https://internal.example.com",
+			"headers": map[string]any{"Authorization": "Bearer {{secret:API_TOKEN}}"},
+		},
+	}
+	output, err := activities.executeToolCall(context.Background(), "run-1", call, browserUserTabConfig{})
+	require.NoError(t, err)
+	// The runner substitutes the values itself, so its events keep the
+	// placeholders.
+	headers := capturedBody["input"].(map[string]any)["headers"].(map[string]any)
+	require.Equal(t, "Bearer {{secret:API_TOKEN}}", headers["Authorization"])
+	require.Equal(t, map[string]any{"API_TOKEN": "tok-live-123"}, capturedBody["secrets"])
+	require.Equal(t, "Bearer {{secret:API_TOKEN}}", call.Input["headers"].(map[string]any)["Authorization"])
+	require.Equal(t, "Authorization: Bearer [secret:API_TOKEN]", output["echo"])
+	require.Equal(t, []any{"[secret:API_TOKEN]"}, output["items"])
+
+	capturedBody = nil
+	call.Input = map[string]any{"token": "{{secret:ADMIN_TOKEN}}"}
+	_, err = activities.executeToolCall(context.Background(), "run-1", call, browserUserTabConfig{})
+	require.EqualError(t, err, "secret ADMIN_TOKEN is not allowed for policy profile ops")
+	require.Nil(t, capturedBody)
+
+	call.Input = map[string]any{"token": "{{secret:MISSING}}"}
+	_, err = activities.executeToolCall(context.Background(), "run-1", call, browserUserTabConfig{})
+	require.EqualError(t, err, "unknown secret: MISSING")
+
+	policyProfile = "admin"
+	call.Input = map[string]any{"token": "{{ secret:ADMIN_TOKEN }}"}
+	_, err = activities.executeToolCall(context.Background(), "run-1", call, browserUserTabConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "{{ secret:ADMIN_TOKEN }}", capturedBody["input"].(map[string]any)["token"])
+	require.Equal(t, map[string]any{"ADMIN_TOKEN": "tok-live-123"}, capturedBody["secrets"])
+
+	capturedBody = nil
+	call.Input = map[string]any{"token": "plain"}
+	_, err = activities.executeToolCall(context.Background(), "run-1", call, browserUserTabConfig{})
+	require.NoError(t, err)
+	require.NotContains(t, capturedBody, "secrets")
+}
+
 func TestResolveBrowserUserTabConfig_IncludesPreferredBrowserFromUserAgent(t *testing.T) {
 	config := resolveBrowserUserTabConfig([]store.Message{
 		{
@@ -3444,6 +4148,19 @@ func TestResolveBrowserUserTabConfig_IncludesPreferredBrowserFromUserAgent(t *te
 	require.Contains(t, config.BrowserUserAgent, "Brave")
 }
 
+func TestBuildEnabledSkillsPrompt(t *testing.T) {
+	require.Empty(t, buildEnabledSkillsPrompt([]store.Message{{Role: "user", Content: "hi"}}))
+
+	prompt := buildEnabledSkillsPrompt([]store.Message{
+		{Role: "user", Metadata: map[string]any{"skills": "old"}},
+		{Role: "assistant", Metadata: map[string]any{"skills": "ignored"}},
+		{Role: "user", Metadata: map[string]any{"skills": "research, pdf-export"}},
+	})
+	require.Contains(t, prompt, "research, pdf-export")
+	require.Contains(t, prompt, "SKILL.md")
+	require.NotContains(t, prompt, "old")
+}
+
 func TestFallbackResearchSeedURLsFromRequest(t *testing.T) {
 	seeds := fallbackResearchSeedURLsFromRequest("Browse the web and give me the top 8 DeFi news items from February 2026 with sources")
 	require.NotEmpty(t, seeds)
@@ -3707,6 +4424,34 @@ func TestPostEvent(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("sends_api_key", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "Bearer worker-key", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		keyed := NewRunActivities(store, llm.Config{}, nil, server.URL, "", WithControlPlaneAPIKey(" worker-key "))
+		keyed.httpClient = server.Client()
+		require.NoError(t, keyed.postEvent(context.Background(), "run-1", "run.failed", map[string]any{"ok": true}))
+	})
+
+	t.Run("signs_request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			service, err := signing.Verify(r, body, map[string]string{"worker": "event-secret"}, nil, time.Now())
+			require.NoError(t, err)
+			require.Equal(t, "worker", service)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		signed := NewRunActivities(store, llm.Config{}, nil, server.URL, "", WithEventSigning("worker", "event-secret"))
+		signed.httpClient = server.Client()
+		require.NoError(t, signed.postEvent(context.Background(), "run-1", "run.failed", map[string]any{"ok": true}))
+	})
+
 	t.Run("status_error", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
@@ -3784,6 +4529,19 @@ func TestHandleRunFailure(t *testing.T) {
 		require.Equal(t, "upstream failed", appended.Payload["error"])
 	})
 
+	t.Run("appends locally in the run's workspace", func(t *testing.T) {
+		mem := memory.New()
+		teamCtx := store.WithWorkspace(context.Background(), "team")
+		require.NoError(t, mem.CreateRun(teamCtx, store.Run{ID: "run-3", Status: "running"}))
+		activities := NewRunActivities(mem, llm.Config{}, nil, "http://[::1]:namedport", "")
+		err := activities.HandleRunFailure(context.Background(), RunFailureInput{RunID: "run-3", Error: "boom"})
+		require.NoError(t, err)
+		events, err := mem.ListEvents(teamCtx, "run-3", 0)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "run.failed", events[0].Type)
+	})
+
 	t.Run("requires run id", func(t *testing.T) {
 		activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, "http://example.com", "")
 		err := activities.HandleRunFailure(context.Background(), RunFailureInput{})
@@ -0,0 +1,134 @@
+package workflows
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/signing"
+)
+
+const (
+	// AutomationJobTimeout bounds one attempt of an automation job. Prompt
+	// automations wait at most 30 minutes for their run.
+	AutomationJobTimeout = 35 * time.Minute
+	// AutomationWorkflowTimeout bounds the whole job, retries included.
+	AutomationWorkflowTimeout = 2 * time.Hour
+	automationJobAttempts     = 3
+)
+
+type AutomationInput struct {
+	WorkspaceID  string
+	AutomationID string
+	EntryID      string
+}
+
+type AutomationFailureInput struct {
+	WorkspaceID  string
+	AutomationID string
+	EntryID      string
+	Error        string
+}
+
+// AutomationWorkflow runs one queued automation inbox entry. The job itself
+// executes in the control plane; retries pick it up again after a control
+// plane restart, and a job that never finishes is failed explicitly so the
+// automation does not stay in progress.
+func AutomationWorkflow(ctx workflow.Context, input AutomationInput) error {
+	jobCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: AutomationJobTimeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    15 * time.Second,
+			BackoffCoefficient: 2,
+			MaximumAttempts:    automationJobAttempts,
+		},
+	})
+	err := workflow.ExecuteActivity(jobCtx, "ExecuteAutomationJob", input).Get(jobCtx, nil)
+	if err == nil {
+		return nil
+	}
+
+	logger := workflow.GetLogger(ctx)
+	logger.Error("automation job failed", "automation_id", input.AutomationID, "entry_id", input.EntryID, "error", err)
+	failCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: 5 * time.Second,
+			MaximumAttempts: 5,
+		},
+	})
+	failure := AutomationFailureInput{
+		WorkspaceID:  input.WorkspaceID,
+		AutomationID: input.AutomationID,
+		EntryID:      input.EntryID,
+		Error:        err.Error(),
+	}
+	if failureErr := workflow.ExecuteActivity(failCtx, "FailAutomationJob", failure).Get(failCtx, nil); failureErr != nil {
+		logger.Error("failed to record automation job failure", "error", failureErr)
+	}
+	return err
+}
+
+// ExecuteAutomationJob asks the control plane to run the inbox entry and
+// waits for it to finish. Entries that already finished are not run again.
+func (a *RunActivities) ExecuteAutomationJob(ctx context.Context, input AutomationInput) error {
+	return a.postAutomationJob(ctx, input.WorkspaceID, input.AutomationID, input.EntryID, "execute", nil)
+}
+
+// FailAutomationJob records a job that exhausted its attempts as failed.
+func (a *RunActivities) FailAutomationJob(ctx context.Context, input AutomationFailureInput) error {
+	detail := strings.TrimSpace(input.Error)
+	if detail == "" {
+		detail = "automation job failed"
+	}
+	return a.postAutomationJob(ctx, input.WorkspaceID, input.AutomationID, input.EntryID, "fail", map[string]any{"error": detail})
+}
+
+func (a *RunActivities) postAutomationJob(ctx context.Context, workspaceID string, automationID string, entryID string, action string, payload map[string]any) error {
+	if strings.TrimSpace(automationID) == "" || strings.TrimSpace(entryID) == "" {
+		return temporal.NewNonRetryableApplicationError("automation_id and entry_id required", "invalid_input", nil)
+	}
+	body := []byte("{}")
+	if payload != nil {
+		encoded, err := marshalJSON(payload)
+		if err != nil {
+			return err
+		}
+		body = encoded
+	}
+	endpoint := fmt.Sprintf("%s/automations/%s/inbox/%s/%s", a.controlPlane, url.PathEscape(automationID), url.PathEscape(entryID), action)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if workspaceID != "" {
+		req.Header.Set(signing.HeaderWorkspace, workspaceID)
+	}
+	a.setControlPlaneAuth(req, body)
+	// The job can outlast the shared client's timeout; the activity's
+	// StartToCloseTimeout bounds the request instead.
+	client := &http.Client{Transport: a.httpClient.Transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	err = fmt.Errorf("control plane automation %s failed: %s: %s", action, resp.Status, strings.TrimSpace(string(raw)))
+	if resp.StatusCode == http.StatusNotFound {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "not_found", nil)
+	}
+	return err
+}
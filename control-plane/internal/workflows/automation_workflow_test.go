@@ -0,0 +1,104 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	tests "go.temporal.io/sdk/testsuite"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+)
+
+func newAutomationWorkflowEnv() *tests.TestWorkflowEnvironment {
+	env := (&tests.WorkflowTestSuite{}).NewTestWorkflowEnvironment()
+	env.RegisterWorkflow(AutomationWorkflow)
+	env.RegisterActivityWithOptions(func(ctx context.Context, input AutomationInput) error {
+		return nil
+	}, activity.RegisterOptions{Name: "ExecuteAutomationJob"})
+	env.RegisterActivityWithOptions(func(ctx context.Context, input AutomationFailureInput) error {
+		return nil
+	}, activity.RegisterOptions{Name: "FailAutomationJob"})
+	return env
+}
+
+func TestAutomationWorkflow(t *testing.T) {
+	input := AutomationInput{WorkspaceID: "team", AutomationID: "auto-1", EntryID: "entry-1"}
+
+	t.Run("success", func(t *testing.T) {
+		env := newAutomationWorkflowEnv()
+		env.OnActivity("ExecuteAutomationJob", mock.Anything, input).Return(nil).Once()
+		env.ExecuteWorkflow(AutomationWorkflow, input)
+		require.True(t, env.IsWorkflowCompleted())
+		require.NoError(t, env.GetWorkflowError())
+		env.AssertExpectations(t)
+	})
+
+	t.Run("retries then fails the entry", func(t *testing.T) {
+		env := newAutomationWorkflowEnv()
+		env.OnActivity("ExecuteAutomationJob", mock.Anything, input).Return(errors.New("connection refused")).Times(automationJobAttempts)
+		env.OnActivity("FailAutomationJob", mock.Anything, mock.MatchedBy(func(failure AutomationFailureInput) bool {
+			return failure.EntryID == "entry-1" && failure.WorkspaceID == "team" && strings.Contains(failure.Error, "connection refused")
+		})).Return(nil).Once()
+		env.ExecuteWorkflow(AutomationWorkflow, input)
+		require.True(t, env.IsWorkflowCompleted())
+		require.Error(t, env.GetWorkflowError())
+		env.AssertExpectations(t)
+	})
+
+	t.Run("does not retry a missing entry", func(t *testing.T) {
+		env := newAutomationWorkflowEnv()
+		env.OnActivity("ExecuteAutomationJob", mock.Anything, input).Return(temporal.NewNonRetryableApplicationError("gone", "not_found", nil)).Once()
+		env.OnActivity("FailAutomationJob", mock.Anything, mock.Anything).Return(nil).Once()
+		env.ExecuteWorkflow(AutomationWorkflow, input)
+		require.Error(t, env.GetWorkflowError())
+		env.AssertExpectations(t)
+	})
+}
+
+func TestAutomationJobActivities(t *testing.T) {
+	var paths []string
+	var failure map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		require.Equal(t, "team", r.Header.Get("X-Gavryn-Workspace"))
+		require.Equal(t, "Bearer worker-key", r.Header.Get("Authorization"))
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/automations/missing/"):
+			http.Error(w, "automation not found", http.StatusNotFound)
+		case strings.HasSuffix(r.URL.Path, "/busy/execute"):
+			http.Error(w, "automation job is already running", http.StatusConflict)
+		case strings.HasSuffix(r.URL.Path, "/fail"):
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&failure))
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	activities := NewRunActivities(&stubStore{}, llm.Config{}, nil, server.URL, "", WithControlPlaneAPIKey("worker-key"))
+	ctx := context.Background()
+
+	require.NoError(t, activities.ExecuteAutomationJob(ctx, AutomationInput{WorkspaceID: "team", AutomationID: "auto-1", EntryID: "entry-1"}))
+	require.NoError(t, activities.FailAutomationJob(ctx, AutomationFailureInput{WorkspaceID: "team", AutomationID: "auto-1", EntryID: "entry-1", Error: "gave up"}))
+	require.Equal(t, []string{"/automations/auto-1/inbox/entry-1/execute", "/automations/auto-1/inbox/entry-1/fail"}, paths)
+	require.Equal(t, "gave up", failure["error"])
+
+	err := activities.ExecuteAutomationJob(ctx, AutomationInput{WorkspaceID: "team", AutomationID: "auto-1", EntryID: "busy"})
+	require.ErrorContains(t, err, "409")
+	var appErr *temporal.ApplicationError
+	require.False(t, errors.As(err, &appErr))
+
+	err = activities.ExecuteAutomationJob(ctx, AutomationInput{WorkspaceID: "team", AutomationID: "missing", EntryID: "entry-1"})
+	require.True(t, errors.As(err, &appErr))
+	require.True(t, appErr.NonRetryable())
+}
@@ -0,0 +1,151 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+// llmProfileRoutePrefix marks a model route entry that names a provider
+// profile instead of a provider, e.g. "@work-openrouter:openai/gpt-4o".
+const llmProfileRoutePrefix = "@"
+
+func (a *RunActivities) listProviderProfiles(ctx context.Context) []store.LLMProviderProfile {
+	profiles, err := a.store.ListLLMProviderProfiles(ctx)
+	if err != nil {
+		return nil
+	}
+	return profiles
+}
+
+func findProviderProfileByName(profiles []store.LLMProviderProfile, name string) *store.LLMProviderProfile {
+	name = strings.TrimSpace(name)
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// findProviderProfileByProvider returns the first profile (by name) with a
+// stored key for provider.
+func findProviderProfileByProvider(profiles []store.LLMProviderProfile, provider string) *store.LLMProviderProfile {
+	provider = strings.TrimSpace(provider)
+	for i := range profiles {
+		if profiles[i].Provider == provider && profiles[i].APIKeyEnc != "" {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+func setProviderAPIKey(cfg *llm.Config, provider string, apiKey string) {
+	switch provider {
+	case "openrouter":
+		cfg.OpenRouterAPIKey = apiKey
+	case "opencode-zen":
+		cfg.OpenCodeAPIKey = apiKey
+	default:
+		cfg.OpenAIAPIKey = apiKey
+	}
+}
+
+func providerHasAPIKey(cfg llm.Config, provider string) bool {
+	switch provider {
+	case "openrouter":
+		return cfg.OpenRouterAPIKey != ""
+	case "opencode-zen":
+		return cfg.OpenCodeAPIKey != ""
+	default:
+		return cfg.OpenAIAPIKey != ""
+	}
+}
+
+func (a *RunActivities) decryptProfileKey(profile store.LLMProviderProfile) (string, error) {
+	if profile.APIKeyEnc == "" {
+		return "", nil
+	}
+	if a.keyring == nil {
+		return "", errors.New("LLM_SECRETS_KEY is required to decrypt API keys")
+	}
+	return decryptSecret(a.keyring, profile.APIKeyEnc)
+}
+
+// applyProviderProfile makes profile the active provider for cfg.
+func (a *RunActivities) applyProviderProfile(cfg llm.Config, profile store.LLMProviderProfile) (llm.Config, error) {
+	apiKey, err := a.decryptProfileKey(profile)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Provider = profile.Provider
+	if profile.Model != "" {
+		cfg.Model = profile.Model
+	}
+	cfg.BaseURL = profile.BaseURL
+	if apiKey != "" {
+		setProviderAPIKey(&cfg, profile.Provider, apiKey)
+	}
+	return cfg, nil
+}
+
+// withProfileCredentials fills in the key and base URL for a provider other
+// than the active one from its provider profile. Env-var keys stay in place
+// when no profile exists for the provider.
+func (a *RunActivities) withProfileCredentials(cfg llm.Config, activeProvider string, profiles []store.LLMProviderProfile) llm.Config {
+	if strings.TrimSpace(cfg.Provider) == strings.TrimSpace(activeProvider) {
+		return cfg
+	}
+	profile := findProviderProfileByProvider(profiles, cfg.Provider)
+	if profile == nil {
+		return cfg
+	}
+	apiKey, err := a.decryptProfileKey(*profile)
+	if err != nil || apiKey == "" {
+		return cfg
+	}
+	setProviderAPIKey(&cfg, cfg.Provider, apiKey)
+	if profile.BaseURL != "" {
+		cfg.BaseURL = profile.BaseURL
+	}
+	return cfg
+}
+
+// routeEntryConfig builds the provider config for one model route entry.
+func (a *RunActivities) routeEntryConfig(cfg llm.Config, entry modelRouteEntry, profiles []store.LLMProviderProfile) (llm.Config, bool) {
+	if name, ok := strings.CutPrefix(entry.provider, llmProfileRoutePrefix); ok {
+		profile := findProviderProfileByName(profiles, name)
+		if profile == nil {
+			return cfg, false
+		}
+		routeCfg, err := a.applyProviderProfile(cfg, *profile)
+		if err != nil {
+			return cfg, false
+		}
+		if entry.model != "" {
+			routeCfg.Model = entry.model
+		}
+		return routeCfg, true
+	}
+	routeCfg := cfg
+	routeCfg.Provider = entry.provider
+	if entry.model != "" {
+		routeCfg.Model = entry.model
+	} else if profile := findProviderProfileByProvider(profiles, entry.provider); profile != nil && profile.Model != "" && entry.provider != cfg.Provider {
+		routeCfg.Model = profile.Model
+	}
+	return a.withProfileCredentials(routeCfg, cfg.Provider, profiles), true
+}
+
+func extractProfileOverride(messages []store.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		return readString(messages[i].Metadata, "llm_profile")
+	}
+	return ""
+}
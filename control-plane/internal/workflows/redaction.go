@@ -0,0 +1,55 @@
+package workflows
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/redaction"
+)
+
+// runRedactionPipeline builds the redaction pipeline the control plane
+// applies to the run's events, for events the worker has to store itself.
+// Lookup failures fall back to the built-in defaults, as ingest does.
+func (a *RunActivities) runRedactionPipeline(ctx context.Context, runID string) *redaction.Pipeline {
+	policyProfile := redaction.DefaultPolicyProfile
+	if run, err := a.store.GetRun(ctx, runID); err == nil && run != nil && strings.TrimSpace(run.PolicyProfile) != "" {
+		policyProfile = run.PolicyProfile
+	}
+	policy, err := redaction.EffectivePolicy(ctx, a.store, policyProfile)
+	if err != nil {
+		policy = redaction.DefaultPolicy(policyProfile)
+	}
+	var secretValues map[string]string
+	if policy.VaultSecrets {
+		secretValues = a.vaultSecretValues(ctx)
+	}
+	pipeline, err := redaction.FromPolicy(policy, secretValues)
+	if err != nil {
+		// Custom rules that no longer compile still leave the built-in
+		// detectors in place.
+		policy.CustomRules = nil
+		pipeline, _ = redaction.FromPolicy(policy, secretValues)
+	}
+	return pipeline
+}
+
+// vaultSecretValues decrypts every vault secret; secrets that cannot be
+// decrypted are skipped.
+func (a *RunActivities) vaultSecretValues(ctx context.Context) map[string]string {
+	if a.keyring == nil {
+		return nil
+	}
+	stored, err := a.store.ListSecrets(ctx)
+	if err != nil || len(stored) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(stored))
+	for _, secret := range stored {
+		value, err := decryptSecret(a.keyring, secret.ValueEnc)
+		if err != nil {
+			continue
+		}
+		values[secret.Name] = value
+	}
+	return values
+}
@@ -0,0 +1,190 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const allPolicyProfiles = "*"
+
+// secretReferenceRE matches {{secret:NAME}} placeholders in tool inputs.
+var secretReferenceRE = regexp.MustCompile(`\{\{\s*secret:([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// runPolicyProfile returns the policy profile recorded on the latest
+// run.started or run.resumed event.
+func (a *RunActivities) runPolicyProfile(ctx context.Context, runID string) string {
+	profile := "default"
+	eventsList, err := a.store.ListEvents(ctx, runID, 0)
+	if err != nil {
+		return profile
+	}
+	for i := len(eventsList) - 1; i >= 0; i-- {
+		if eventsList[i].Type != "run.started" && eventsList[i].Type != "run.resumed" {
+			continue
+		}
+		if value := readString(eventsList[i].Payload, "policy_profile"); value != "" {
+			profile = value
+		}
+		break
+	}
+	return profile
+}
+
+func secretAllowedForProfile(secret store.Secret, profile string) bool {
+	for _, allowed := range secret.PolicyProfiles {
+		if allowed == allPolicyProfiles || allowed == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveToolSecrets returns the vault values, keyed by name, that the
+// {{secret:NAME}} placeholders in a tool input refer to, checking that the
+// run's policy profile may use them. The tool runner receives the values
+// apart from the input and substitutes them itself, so the input it reports
+// in run events keeps its placeholders; the values are also used to scrub
+// the tool output before anything is persisted.
+func (a *RunActivities) resolveToolSecrets(ctx context.Context, runID string, input map[string]any) (map[string]string, error) {
+	if !containsSecretReference(input) {
+		return nil, nil
+	}
+	if a.keyring == nil {
+		return nil, errors.New("LLM_SECRETS_KEY is required to decrypt secrets")
+	}
+	vault, err := a.store.ListSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]store.Secret, len(vault))
+	for _, secret := range vault {
+		byName[secret.Name] = secret
+	}
+	profile := a.runPolicyProfile(ctx, runID)
+	used := map[string]string{}
+	resolve := func(name string) (string, error) {
+		if value, ok := used[name]; ok {
+			return value, nil
+		}
+		secret, ok := byName[name]
+		if !ok {
+			return "", fmt.Errorf("unknown secret: %s", name)
+		}
+		if !secretAllowedForProfile(secret, profile) {
+			return "", fmt.Errorf("secret %s is not allowed for policy profile %s", name, profile)
+		}
+		value, err := decryptSecret(a.keyring, secret.ValueEnc)
+		if err != nil {
+			return "", fmt.Errorf("secret %s could not be decrypted", name)
+		}
+		used[name] = value
+		return value, nil
+	}
+	if _, err := substituteSecretReferences(input, resolve); err != nil {
+		return nil, err
+	}
+	return used, nil
+}
+
+func containsSecretReference(value any) bool {
+	switch typed := value.(type) {
+	case string:
+		return secretReferenceRE.MatchString(typed)
+	case map[string]any:
+		for _, item := range typed {
+			if containsSecretReference(item) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range typed {
+			if containsSecretReference(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func substituteSecretReferences(value any, resolve func(name string) (string, error)) (any, error) {
+	switch typed := value.(type) {
+	case string:
+		var resolveErr error
+		replaced := secretReferenceRE.ReplaceAllStringFunc(typed, func(match string) string {
+			if resolveErr != nil {
+				return match
+			}
+			name := secretReferenceRE.FindStringSubmatch(match)[1]
+			secretValue, err := resolve(name)
+			if err != nil {
+				resolveErr = err
+				return match
+			}
+			return secretValue
+		})
+		return replaced, resolveErr
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for key, item := range typed {
+			replaced, err := substituteSecretReferences(item, resolve)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = replaced
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(typed))
+		for i, item := range typed {
+			replaced, err := substituteSecretReferences(item, resolve)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = replaced
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// scrubSecretValues replaces any resolved secret value echoed back by a tool
+// with its [secret:NAME] marker.
+func scrubSecretValues(value any, used map[string]string) any {
+	if len(used) == 0 {
+		return value
+	}
+	switch typed := value.(type) {
+	case string:
+		return scrubSecretString(typed, used)
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for key, item := range typed {
+			result[key] = scrubSecretValues(item, used)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, item := range typed {
+			result[i] = scrubSecretValues(item, used)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+func scrubSecretString(value string, used map[string]string) string {
+	for name, secretValue := range used {
+		if secretValue == "" {
+			continue
+		}
+		value = strings.ReplaceAll(value, secretValue, "[secret:"+name+"]")
+	}
+	return value
+}
@@ -2,9 +2,12 @@ package workflows
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 )
 
@@ -62,6 +65,40 @@ func (s *Service) CancelRun(ctx context.Context, runID string) error {
 	return s.client.CancelWorkflow(ctx, workflowID(runID), "")
 }
 
+// StartAutomation runs a queued automation inbox entry on AutomationWorkflow.
+// Starting the same entry twice is rejected by Temporal.
+func (s *Service) StartAutomation(ctx context.Context, workspaceID string, automationID string, entryID string) error {
+	options := client.StartWorkflowOptions{
+		ID:                       automationWorkflowID(entryID),
+		TaskQueue:                s.taskQueue,
+		WorkflowExecutionTimeout: AutomationWorkflowTimeout,
+	}
+	_, err := s.client.ExecuteWorkflow(ctx, options, AutomationWorkflow, AutomationInput{
+		WorkspaceID:  workspaceID,
+		AutomationID: automationID,
+		EntryID:      entryID,
+	})
+	return err
+}
+
+// AutomationActive reports whether the workflow for an inbox entry is still
+// running.
+func (s *Service) AutomationActive(ctx context.Context, entryID string) (bool, error) {
+	resp, err := s.client.DescribeWorkflowExecution(ctx, automationWorkflowID(entryID), "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return resp.GetWorkflowExecutionInfo().GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING, nil
+}
+
 func workflowID(runID string) string {
 	return fmt.Sprintf("run:%s", runID)
 }
+
+func automationWorkflowID(entryID string) string {
+	return fmt.Sprintf("automation:%s", entryID)
+}
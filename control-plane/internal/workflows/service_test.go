@@ -7,6 +7,10 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/mocks"
 )
@@ -159,3 +163,53 @@ func TestResumeRun_Error(t *testing.T) {
 	err := service.ResumeRun(context.Background(), runID, "")
 	require.ErrorIs(t, err, expectedErr)
 }
+
+func TestStartAutomation_Success(t *testing.T) {
+	mockClient := mocks.NewClient(t)
+	workflowRun := mocks.NewWorkflowRun(t)
+	taskQueue := "gavryn-runs-test"
+
+	mockClient.On(
+		"ExecuteWorkflow",
+		mock.Anything,
+		mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+			return opts.ID == "automation:entry-1" && opts.TaskQueue == taskQueue && opts.WorkflowExecutionTimeout == AutomationWorkflowTimeout
+		}),
+		mock.Anything,
+		AutomationInput{WorkspaceID: "team", AutomationID: "auto-1", EntryID: "entry-1"},
+	).Return(workflowRun, nil)
+
+	service := NewService(mockClient, taskQueue)
+	require.NoError(t, service.StartAutomation(context.Background(), "team", "auto-1", "entry-1"))
+}
+
+func TestAutomationActive(t *testing.T) {
+	mockClient := mocks.NewClient(t)
+	service := NewService(mockClient, "gavryn-runs")
+	describe := func(status enumspb.WorkflowExecutionStatus) *workflowservice.DescribeWorkflowExecutionResponse {
+		return &workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{Status: status},
+		}
+	}
+
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "automation:running", "").
+		Return(describe(enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING), nil)
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "automation:done", "").
+		Return(describe(enumspb.WORKFLOW_EXECUTION_STATUS_FAILED), nil)
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "automation:missing", "").
+		Return((*workflowservice.DescribeWorkflowExecutionResponse)(nil), serviceerror.NewNotFound("not found"))
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "automation:error", "").
+		Return((*workflowservice.DescribeWorkflowExecutionResponse)(nil), errors.New("unavailable"))
+
+	active, err := service.AutomationActive(context.Background(), "running")
+	require.NoError(t, err)
+	require.True(t, active)
+	active, err = service.AutomationActive(context.Background(), "done")
+	require.NoError(t, err)
+	require.False(t, active)
+	active, err = service.AutomationActive(context.Background(), "missing")
+	require.NoError(t, err)
+	require.False(t, active)
+	_, err = service.AutomationActive(context.Background(), "error")
+	require.Error(t, err)
+}
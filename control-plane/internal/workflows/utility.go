@@ -0,0 +1,225 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/llm"
+	"github.com/Keyring-Network/keyring-gavryn/control-plane/internal/store"
+)
+
+const (
+	maxAutoRunTags            = 4
+	maxAutoRunTagChars        = 32
+	maxCompactionInputChars   = 24000
+	maxCompactionMessageChars = 1200
+	maxCompactionSummaryChars = 4000
+)
+
+var (
+	runTagListPrefixRE   = regexp.MustCompile(`^(?:[-*#]+|\d+[.)])\s*`)
+	runTagInvalidCharsRE = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// utilityModel is the small, cheap model configured for housekeeping work:
+// titles, tags, research clean-up and conversation summaries.
+type utilityModel struct {
+	Name     string
+	Model    string
+	Provider llm.Provider
+	Timeout  time.Duration
+}
+
+func utilityConfig(cfg llm.Config) (llm.Config, bool) {
+	provider := strings.TrimSpace(cfg.UtilityProvider)
+	if provider == "" {
+		return cfg, false
+	}
+	utilityCfg := cfg
+	utilityCfg.Provider = provider
+	if model := strings.TrimSpace(cfg.UtilityModel); model != "" {
+		utilityCfg.Model = model
+	}
+	if baseURL := strings.TrimSpace(cfg.UtilityBaseURL); baseURL != "" {
+		utilityCfg.BaseURL = baseURL
+	} else if provider != strings.TrimSpace(cfg.Provider) {
+		utilityCfg.BaseURL = ""
+	}
+	return utilityCfg, true
+}
+
+// resolveUtilityModel returns nil when no utility model is configured, it
+// cannot be constructed, or its circuit breaker is open. Each request claims
+// the breaker again through generateUtility.
+func (a *RunActivities) resolveUtilityModel(ctx context.Context, cfg llm.Config) *utilityModel {
+	utilityCfg, ok := utilityConfig(cfg)
+	if !ok {
+		return nil
+	}
+	utilityCfg = a.withProfileCredentials(utilityCfg, cfg.Provider, a.listProviderProfiles(ctx))
+	if baseURL := strings.TrimSpace(cfg.UtilityBaseURL); baseURL != "" {
+		utilityCfg.BaseURL = baseURL
+	}
+	if !a.providerHealth.Available(utilityCfg.Provider, utilityCfg.Model) {
+		return nil
+	}
+	provider, err := newProvider(utilityCfg)
+	if err != nil {
+		return nil
+	}
+	return &utilityModel{
+		Name:     strings.TrimSpace(utilityCfg.Provider),
+		Model:    strings.TrimSpace(utilityCfg.Model),
+		Provider: provider,
+		Timeout:  a.utilityTimeout,
+	}
+}
+
+func (u *utilityModel) candidate() llmProviderCandidate {
+	return llmProviderCandidate{Name: u.Name, Model: u.Model, Provider: u.Provider, Timeout: u.Timeout}
+}
+
+func (a *RunActivities) generateUtility(ctx context.Context, utility *utilityModel, messages []llm.Message) (string, error) {
+	return a.generateOnce(ctx, utility.candidate(), messages, 0)
+}
+
+// compactConversationWindow clamps the conversation like clampConversationWindow
+// and, when a utility model is available, replaces the dropped messages with a
+// system summary so long runs keep their earlier context.
+func (a *RunActivities) compactConversationWindow(ctx context.Context, utility *utilityModel, messages []llm.Message) []llm.Message {
+	clamped := clampConversationWindow(messages, maxConversationMessages, maxConversationChars)
+	if utility == nil {
+		return clamped
+	}
+	prefixCount := 0
+	for prefixCount < len(messages) && messages[prefixCount].Role == "system" {
+		prefixCount++
+	}
+	kept := len(clamped) - prefixCount
+	droppedEnd := len(messages) - kept
+	if droppedEnd <= prefixCount {
+		return clamped
+	}
+	dropped := messages[prefixCount:droppedEnd]
+	summary, err := a.generateUtility(ctx, utility, buildCompactionPrompt(dropped))
+	if err != nil || summary == "" {
+		return clamped
+	}
+	result := make([]llm.Message, 0, len(clamped)+1)
+	result = append(result, clamped[:prefixCount]...)
+	result = append(result, llm.Message{
+		Role:    "system",
+		Content: fmt.Sprintf("Summary of %d earlier conversation message(s) omitted for length:\n%s", len(dropped), truncateRunes(summary, maxCompactionSummaryChars)),
+	})
+	result = append(result, clamped[prefixCount:]...)
+	return result
+}
+
+func buildCompactionPrompt(dropped []llm.Message) []llm.Message {
+	lines := make([]string, 0, len(dropped))
+	total := 0
+	for i := len(dropped) - 1; i >= 0; i-- {
+		line := fmt.Sprintf("%s: %s", dropped[i].Role, truncateRunes(strings.TrimSpace(dropped[i].Content), maxCompactionMessageChars))
+		if total+len(line) > maxCompactionInputChars && len(lines) > 0 {
+			break
+		}
+		lines = append(lines, line)
+		total += len(line)
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return []llm.Message{
+		{Role: "system", Content: "Summarize the following conversation excerpt for an assistant that will continue it. Keep user goals, decisions, constraints, file names, URLs and open questions. Be concise and factual. Return only the summary."},
+		{Role: "user", Content: strings.Join(lines, "\n\n")},
+	}
+}
+
+// polishResearchResponse asks the utility model to strip process chatter from a
+// research answer. The deterministic sanitizer still runs in postMessage; the
+// polished text is only used when it keeps every source link.
+func (a *RunActivities) polishResearchResponse(ctx context.Context, utility *utilityModel, content string, requirements webResearchRequirements) string {
+	if utility == nil || !requirements.Enabled {
+		return content
+	}
+	cleaned := strings.TrimSpace(sanitizeResearchUserResponse(content))
+	if cleaned == "" {
+		return content
+	}
+	polished, err := a.generateUtility(ctx, utility, []llm.Message{
+		{Role: "system", Content: "You clean up research reports before they are shown to a user. Remove internal process notes, tool diagnostics and retry chatter. Keep every fact, heading, list item and source URL exactly as written. Do not add information. Return only the cleaned report."},
+		{Role: "user", Content: cleaned},
+	})
+	if err != nil || polished == "" {
+		return content
+	}
+	if countSourceLinks(polished) < countSourceLinks(cleaned) {
+		return content
+	}
+	if len([]rune(polished))*2 < len([]rune(cleaned)) {
+		return content
+	}
+	return polished
+}
+
+func (a *RunActivities) finishRunMetadata(ctx context.Context, runID string, titleProvider llmProviderCandidate, utility *utilityModel, messages []store.Message, assistantReply string) {
+	a.maybeGenerateRunTitle(ctx, runID, titleProvider, messages, assistantReply)
+	a.maybeAutoTagRun(ctx, runID, utility, messages, assistantReply)
+}
+
+// maybeAutoTagRun classifies a run once with the utility model and posts a
+// run.tags.updated event; stores merge the tags into runs.tags.
+func (a *RunActivities) maybeAutoTagRun(ctx context.Context, runID string, utility *utilityModel, messages []store.Message, assistantReply string) {
+	if utility == nil || strings.TrimSpace(assistantReply) == "" {
+		return
+	}
+	events, err := a.store.ListEvents(ctx, runID, 0)
+	if err != nil {
+		return
+	}
+	for _, event := range events {
+		if event.Type == store.RunTagsUpdatedEvent && readString(event.Payload, "source") == "auto" {
+			return
+		}
+	}
+	latestUser := latestUserMessage(messages)
+	if latestUser == "" {
+		return
+	}
+	raw, err := a.generateUtility(ctx, utility, []llm.Message{
+		{Role: "system", Content: fmt.Sprintf("Classify this conversation with 1-%d short lowercase topic tags (single words or hyphenated). Return only a comma-separated list.", maxAutoRunTags)},
+		{Role: "user", Content: fmt.Sprintf("User request: %s\n\nAssistant response: %s", truncateRunes(latestUser, 600), truncateRunes(strings.TrimSpace(assistantReply), 1200))},
+	})
+	if err != nil {
+		return
+	}
+	tags := parseRunTags(raw)
+	if len(tags) == 0 {
+		return
+	}
+	_ = a.emitEvent(ctx, runID, store.RunTagsUpdatedEvent, map[string]any{"tags": tags, "source": "auto"})
+}
+
+func parseRunTags(raw string) []string {
+	parts := strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ',' || r == '\n' || r == ';'
+	})
+	tags := make([]string, 0, maxAutoRunTags)
+	for _, part := range parts {
+		tag := strings.ToLower(strings.TrimSpace(part))
+		tag = runTagListPrefixRE.ReplaceAllString(tag, "")
+		tag = strings.Join(strings.Fields(tag), "-")
+		tag = strings.Trim(runTagInvalidCharsRE.ReplaceAllString(tag, ""), "-")
+		if tag == "" || len(tag) > maxAutoRunTagChars {
+			continue
+		}
+		tags = store.MergeRunTags(tags, []string{tag})
+		if len(tags) >= maxAutoRunTags {
+			break
+		}
+	}
+	return tags
+}
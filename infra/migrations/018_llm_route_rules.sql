@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS llm_route_rules (
+  id UUID PRIMARY KEY,
+  name TEXT NOT NULL,
+  priority INTEGER NOT NULL DEFAULT 100,
+  task_types JSONB NOT NULL DEFAULT '[]'::jsonb,
+  tags JSONB NOT NULL DEFAULT '[]'::jsonb,
+  policy_profiles JSONB NOT NULL DEFAULT '[]'::jsonb,
+  automation_ids JSONB NOT NULL DEFAULT '[]'::jsonb,
+  route TEXT NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS llm_route_rules_priority_idx ON llm_route_rules(priority, name);
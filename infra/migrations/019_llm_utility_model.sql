@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS llm_settings ADD COLUMN IF NOT EXISTS utility_provider TEXT NOT NULL DEFAULT '';
+ALTER TABLE IF EXISTS llm_settings ADD COLUMN IF NOT EXISTS utility_model TEXT NOT NULL DEFAULT '';
+ALTER TABLE IF EXISTS llm_settings ADD COLUMN IF NOT EXISTS utility_base_url TEXT NOT NULL DEFAULT '';
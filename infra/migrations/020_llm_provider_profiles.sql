@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS llm_provider_profiles (
+  id UUID PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  provider TEXT NOT NULL,
+  model TEXT NOT NULL DEFAULT '',
+  base_url TEXT NOT NULL DEFAULT '',
+  api_key_enc TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+ALTER TABLE IF EXISTS automations ADD COLUMN IF NOT EXISTS llm_profile TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS secrets (
+  id UUID PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL DEFAULT '',
+  value_enc TEXT NOT NULL,
+  policy_profiles JSONB NOT NULL DEFAULT '[]'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS redaction_policies (
+  policy_profile TEXT PRIMARY KEY,
+  vault_secrets BOOLEAN NOT NULL DEFAULT TRUE,
+  key_formats BOOLEAN NOT NULL DEFAULT TRUE,
+  emails BOOLEAN NOT NULL DEFAULT FALSE,
+  custom_rules JSONB NOT NULL DEFAULT '[]'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,91 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO workspaces (id, name, created_at, updated_at)
+VALUES ('default', 'Default', NOW(), NOW())
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS users (
+  id UUID PRIMARY KEY,
+  name TEXT NOT NULL,
+  email TEXT UNIQUE,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+  workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role TEXT NOT NULL DEFAULT 'member',
+  created_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (workspace_id, user_id)
+);
+
+-- Existing rows land in the default workspace through the column default.
+ALTER TABLE IF EXISTS runs
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE,
+  ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS created_by TEXT;
+CREATE INDEX IF NOT EXISTS runs_workspace_idx ON runs(workspace_id);
+
+ALTER TABLE IF EXISTS skills
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE IF EXISTS skills DROP CONSTRAINT IF EXISTS skills_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS skills_workspace_name_idx ON skills(workspace_id, name);
+
+ALTER TABLE IF EXISTS context_nodes
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS context_nodes_workspace_idx ON context_nodes(workspace_id);
+
+ALTER TABLE IF EXISTS memory_entries
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS memory_entries_workspace_idx ON memory_entries(workspace_id);
+
+ALTER TABLE IF EXISTS automations
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS automations_workspace_idx ON automations(workspace_id);
+
+-- The settings tables were singletons keyed by id = 1; they are now keyed by
+-- workspace, and a workspace without a row inherits the default workspace's.
+ALTER TABLE IF EXISTS llm_settings
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE IF EXISTS llm_settings DROP CONSTRAINT IF EXISTS llm_settings_pkey;
+ALTER TABLE IF EXISTS llm_settings DROP CONSTRAINT IF EXISTS llm_settings_id_check;
+ALTER TABLE IF EXISTS llm_settings ALTER COLUMN id SET DEFAULT 1;
+CREATE UNIQUE INDEX IF NOT EXISTS llm_settings_workspace_idx ON llm_settings(workspace_id);
+
+ALTER TABLE IF EXISTS memory_settings
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE IF EXISTS memory_settings DROP CONSTRAINT IF EXISTS memory_settings_pkey;
+CREATE UNIQUE INDEX IF NOT EXISTS memory_settings_workspace_idx ON memory_settings(workspace_id);
+
+ALTER TABLE IF EXISTS personality_settings
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE IF EXISTS personality_settings DROP CONSTRAINT IF EXISTS personality_settings_pkey;
+CREATE UNIQUE INDEX IF NOT EXISTS personality_settings_workspace_idx ON personality_settings(workspace_id);
+
+ALTER TABLE IF EXISTS api_keys
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE,
+  ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE IF EXISTS llm_route_rules
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS llm_route_rules_workspace_idx ON llm_route_rules(workspace_id);
+
+ALTER TABLE IF EXISTS llm_provider_profiles
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE IF EXISTS llm_provider_profiles DROP CONSTRAINT IF EXISTS llm_provider_profiles_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS llm_provider_profiles_workspace_name_idx ON llm_provider_profiles(workspace_id, name);
+
+ALTER TABLE IF EXISTS secrets
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE IF EXISTS secrets DROP CONSTRAINT IF EXISTS secrets_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS secrets_workspace_name_idx ON secrets(workspace_id, name);
+
+ALTER TABLE IF EXISTS redaction_policies
+  ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE;
+ALTER TABLE IF EXISTS redaction_policies DROP CONSTRAINT IF EXISTS redaction_policies_pkey;
+CREATE UNIQUE INDEX IF NOT EXISTS redaction_policies_workspace_profile_idx ON redaction_policies(workspace_id, policy_profile);
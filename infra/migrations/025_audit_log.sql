@@ -0,0 +1,24 @@
+-- Audit entries outlive the workspaces, users and keys they mention, so
+-- they carry plain identifiers rather than foreign keys.
+CREATE TABLE IF NOT EXISTS audit_log (
+  seq BIGSERIAL PRIMARY KEY,
+  id UUID NOT NULL UNIQUE,
+  workspace_id TEXT NOT NULL DEFAULT 'default',
+  actor TEXT NOT NULL DEFAULT '',
+  user_id TEXT NOT NULL DEFAULT '',
+  action TEXT NOT NULL,
+  target_type TEXT NOT NULL DEFAULT '',
+  target_id TEXT NOT NULL DEFAULT '',
+  before JSONB,
+  after JSONB,
+  method TEXT NOT NULL,
+  path TEXT NOT NULL,
+  status_code INTEGER NOT NULL,
+  remote_addr TEXT NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  request_id TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_workspace_seq_idx ON audit_log (workspace_id, seq DESC);
+CREATE INDEX IF NOT EXISTS audit_log_target_idx ON audit_log (workspace_id, target_type, target_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS notification_channels (
+  id UUID PRIMARY KEY,
+  workspace_id TEXT NOT NULL DEFAULT 'default' REFERENCES workspaces(id) ON DELETE CASCADE,
+  name TEXT NOT NULL DEFAULT '',
+  type TEXT NOT NULL,
+  config JSONB NOT NULL DEFAULT '{}'::jsonb,
+  secrets_enc TEXT NOT NULL DEFAULT '',
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS notification_channels_workspace_idx ON notification_channels(workspace_id);
+
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS notification_channels JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS notify_on TEXT NOT NULL DEFAULT 'always';
@@ -0,0 +1,5 @@
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS change_summary TEXT NOT NULL DEFAULT '';
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS similarity DOUBLE PRECISION;
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS unchanged BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS change_threshold DOUBLE PRECISION NOT NULL DEFAULT 0.9;
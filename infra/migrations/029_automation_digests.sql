@@ -0,0 +1,3 @@
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'prompt';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS digest_automation_ids JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS digest_cadence TEXT NOT NULL DEFAULT '';
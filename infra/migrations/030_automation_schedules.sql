@@ -0,0 +1,5 @@
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS schedule_type TEXT NOT NULL DEFAULT 'weekly';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS cron_expression TEXT NOT NULL DEFAULT '';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS interval_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS run_at TIMESTAMPTZ;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS blackout_dates JSONB NOT NULL DEFAULT '[]'::jsonb;
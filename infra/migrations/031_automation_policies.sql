@@ -0,0 +1,9 @@
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS catch_up TEXT NOT NULL DEFAULT 'once';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS catch_up_max_age_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS retry_max_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS retry_backoff_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS retry_at TIMESTAMPTZ;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS retry_attempt INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS retry_scheduled_for TIMESTAMPTZ;
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS scheduled_for TIMESTAMPTZ;
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS attempt INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS policy_profile TEXT NOT NULL DEFAULT '';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS timeout_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS browser_mode TEXT NOT NULL DEFAULT '';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS browser_interaction TEXT NOT NULL DEFAULT '';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS browser_domain_allowlist JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS browser_preferred_browser TEXT NOT NULL DEFAULT '';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS browser_user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS skills JSONB NOT NULL DEFAULT '[]'::jsonb;
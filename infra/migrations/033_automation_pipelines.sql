@@ -0,0 +1 @@
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS stages JSONB NOT NULL DEFAULT '[]'::jsonb;
@@ -0,0 +1,8 @@
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS trigger_secret_enc TEXT NOT NULL DEFAULT '';
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS context_trigger_paths JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS run_trigger_tags JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS feed_urls JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS feed_poll_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS feed_seen_items JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS feed_checked_at TIMESTAMPTZ;
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS trigger_payload JSONB NOT NULL DEFAULT '{}'::jsonb;
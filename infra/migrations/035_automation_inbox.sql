@@ -0,0 +1,4 @@
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS inbox_retention_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automations ADD COLUMN IF NOT EXISTS inbox_retention_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS automation_inbox_started_idx ON automation_inbox(started_at DESC, id);
@@ -0,0 +1,2 @@
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS lease_owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE automation_inbox ADD COLUMN IF NOT EXISTS lease_until TIMESTAMPTZ;
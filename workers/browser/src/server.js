@@ -22,6 +22,9 @@ app.use(express.json({ limit: "2mb" }));
 
 const PORT = resolvePort(process.env.BROWSER_WORKER_PORT || process.env.PORT, 8082, "browser-worker");
 const CONTROL_PLANE_URL = process.env.CONTROL_PLANE_URL || "http://localhost:8080";
+const CONTROL_PLANE_API_KEY = (process.env.CONTROL_PLANE_API_KEY || "").trim();
+const EVENT_SIGNING_SECRET = (process.env.EVENT_SIGNING_SECRET || "").trim();
+const EVENT_SIGNING_SERVICE = "browser_worker";
 const BASE_URL = process.env.BROWSER_WORKER_URL || `http://localhost:${PORT}`;
 const HEADLESS = process.env.BROWSER_HEADLESS !== "false";
 const LIVE_FRAME_INTERVAL_MS = resolveInterval(
@@ -44,6 +47,7 @@ const USER_TAB_AUTOSTART = process.env.BROWSER_USER_TAB_AUTOSTART !== "false";
 const USER_TAB_PROMPT_HANDLING_ENABLED = process.env.BROWSER_USER_TAB_PROMPT_HANDLING !== "false";
 const USER_TAB_PROMPT_ATTEMPTS = Math.max(1, resolveInterval(process.env.BROWSER_USER_TAB_PROMPT_ATTEMPTS, 2, "user tab prompt attempts"));
 const USER_TAB_PROMPT_DELAY_MS = Math.max(100, resolveInterval(process.env.BROWSER_USER_TAB_PROMPT_DELAY_MS, 250, "user tab prompt delay"));
+const RUN_SECRETS_TTL_MS = Math.max(60 * 1000, resolveInterval(process.env.BROWSER_RUN_SECRETS_TTL_MS, 30 * 60 * 1000, "browser run secrets ttl"));
 
 const artifactsRoot = path.resolve(__dirname, "..", "artifacts");
 const sessions = new Map();
@@ -88,17 +92,109 @@ async function ensureDir(dir) {
   await fs.mkdir(dir, { recursive: true });
 }
 
+// Matches the control plane's internal/signing canonical request format.
+function signControlPlaneRequest(method, url, body) {
+  const timestamp = Math.floor(Date.now() / 1000);
+  const nonce = crypto.randomBytes(16).toString("hex");
+  const { pathname, search } = new URL(url);
+  const bodyHash = crypto.createHash("sha256").update(body).digest("hex");
+  const canonical = `${timestamp}\n${nonce}\n${method.toUpperCase()}\n${pathname}${search}\n${bodyHash}`;
+  const signature = crypto.createHmac("sha256", EVENT_SIGNING_SECRET).update(canonical).digest("hex");
+  return {
+    "X-Gavryn-Service": EVENT_SIGNING_SERVICE,
+    "X-Gavryn-Timestamp": String(timestamp),
+    "X-Gavryn-Nonce": nonce,
+    "X-Gavryn-Signature": `v1=${signature}`,
+  };
+}
+
+function controlPlaneHeaders(method, url, body) {
+  const headers = { "Content-Type": "application/json" };
+  if (CONTROL_PLANE_API_KEY) {
+    headers.Authorization = `Bearer ${CONTROL_PLANE_API_KEY}`;
+  }
+  if (EVENT_SIGNING_SECRET) {
+    Object.assign(headers, signControlPlaneRequest(method, url, body));
+  }
+  return headers;
+}
+
+// Vault secret values substituted into each run's tool inputs by the tool
+// runner, which sends them alongside. Every event about the run has them
+// replaced with [secret:NAME]. They are dropped when the run is cancelled or
+// RUN_SECRETS_TTL_MS after the run's last tool call, whichever comes first.
+const runSecrets = new Map();
+
+function rememberRunSecrets(runId, secrets) {
+  pruneRunSecrets();
+  if (!secrets || typeof secrets !== "object") {
+    return;
+  }
+  const values = {};
+  for (const [name, value] of Object.entries(secrets)) {
+    if (typeof value === "string" && value !== "") {
+      values[name] = value;
+    }
+  }
+  if (Object.keys(values).length > 0) {
+    runSecrets.set(runId, {
+      values: { ...(runSecrets.get(runId)?.values || {}), ...values },
+      expiresAt: Date.now() + RUN_SECRETS_TTL_MS,
+    });
+  }
+}
+
+function secretsForRun(runId) {
+  const entry = runSecrets.get(runId);
+  if (!entry) {
+    return undefined;
+  }
+  if (entry.expiresAt <= Date.now()) {
+    runSecrets.delete(runId);
+    return undefined;
+  }
+  return entry.values;
+}
+
+function pruneRunSecrets(now = Date.now()) {
+  for (const [runId, entry] of runSecrets.entries()) {
+    if (entry.expiresAt <= now) {
+      runSecrets.delete(runId);
+    }
+  }
+}
+
+function scrubSecretValues(value, secrets) {
+  if (typeof value === "string") {
+    let scrubbed = value;
+    for (const [name, secretValue] of Object.entries(secrets)) {
+      scrubbed = scrubbed.split(secretValue).join(`[secret:${name}]`);
+    }
+    return scrubbed;
+  }
+  if (Array.isArray(value)) {
+    return value.map((item) => scrubSecretValues(item, secrets));
+  }
+  if (value && typeof value === "object") {
+    return Object.fromEntries(Object.entries(value).map(([key, item]) => [key, scrubSecretValues(item, secrets)]));
+  }
+  return value;
+}
+
 async function emitEvent(runId, type, payload) {
   try {
-    await fetch(`${CONTROL_PLANE_URL}/runs/${runId}/events`, {
+    const url = `${CONTROL_PLANE_URL}/runs/${runId}/events`;
+    const secrets = secretsForRun(runId);
+    const body = JSON.stringify({
+      type,
+      source: "browser_worker",
+      timestamp: new Date().toISOString(),
+      payload: secrets ? scrubSecretValues(payload, secrets) : payload,
+    });
+    await fetch(url, {
       method: "POST",
-      headers: { "Content-Type": "application/json" },
-      body: JSON.stringify({
-        type,
-        source: "browser_worker",
-        timestamp: new Date().toISOString(),
-        payload,
-      }),
+      headers: controlPlaneHeaders("POST", url, body),
+      body,
     });
   } catch (err) {
     console.error("failed to emit event", err);
@@ -1597,7 +1693,7 @@ app.get("/ready", (_req, res) => {
 app.use("/artifacts", express.static(artifactsRoot));
 
 app.post("/tools/execute", async (req, res) => {
-  const { run_id: runId, invocation_id: invocationId, tool_name: toolName, input = {}, timeout_ms: timeoutMs } = req.body;
+  const { run_id: runId, invocation_id: invocationId, tool_name: toolName, input = {}, secrets, timeout_ms: timeoutMs } = req.body;
   const browserMode = normalizeBrowserMode(input?._browser_mode);
   const requestedGuardrails = buildGuardrails(input?._browser_guardrails, browserMode);
   const executionInput = sanitizeToolInput(input);
@@ -1606,6 +1702,7 @@ app.post("/tools/execute", async (req, res) => {
     res.status(400).json({ error: "run_id, invocation_id, and tool_name are required" });
     return;
   }
+  rememberRunSecrets(runId, secrets);
 
   const supportedTools = [
     "browser.navigate",
@@ -2037,6 +2134,7 @@ app.post("/cancel", async (req, res) => {
   }
   await closeSession(runId);
   await emitEvent(runId, "browser.navigation", { status: "cancelled" });
+  runSecrets.delete(runId);
   res.json({ status: "cancelled" });
 });
 
@@ -2046,6 +2144,7 @@ if (require.main === module) {
   server = app.listen(PORT, () => {
     console.log(`browser worker listening on ${PORT}`);
   });
+  setInterval(() => pruneRunSecrets(), 60 * 1000).unref();
 
   process.on("SIGINT", async () => {
     server.close();
@@ -2063,6 +2162,8 @@ module.exports = {
   resolveInterval,
   ensureDir,
   emitEvent,
+  runSecrets,
+  pruneRunSecrets,
   getSession,
   closeSession,
   captureScreenshot,
@@ -18,6 +18,8 @@ const {
   resolveInterval,
   ensureDir,
   emitEvent,
+  runSecrets,
+  pruneRunSecrets,
   getSession,
   closeSession,
   captureScreenshot,
@@ -1074,6 +1076,52 @@ describe("Browser Worker Server", () => {
       assert.strictEqual(sessions.has("test-run"), false);
     });
 
+    it("scrubs the run's vault secrets from events until the run is cancelled", async () => {
+      await request(app)
+        .post("/tools/execute")
+        .send({
+          run_id: "secret-run",
+          invocation_id: "secret-inv",
+          tool_name: "browser.navigate",
+          input: { url: "https://example.com/?token=tok-live-123" },
+          secrets: { API_TOKEN: "tok-live-123" },
+        });
+
+      const events = fetchCalls.filter((call) => call.url.includes("/runs/secret-run/events"));
+      assert.ok(events.length > 0);
+      for (const event of events) {
+        assert.ok(!event.options.body.includes("tok-live-123"));
+      }
+      assert.ok(events.some((event) => event.options.body.includes("[secret:API_TOKEN]")));
+
+      await request(app).post("/cancel").send({ run_id: "secret-run" });
+      assert.strictEqual(runSecrets.has("secret-run"), false);
+    });
+
+    it("drops a run's vault secrets once they expire", async () => {
+      await request(app)
+        .post("/tools/execute")
+        .send({
+          run_id: "expiring-secret-run",
+          invocation_id: "expiring-secret-inv",
+          tool_name: "browser.navigate",
+          input: { url: "https://example.com" },
+          secrets: { API_TOKEN: "tok-live-456" },
+        });
+
+      const entry = runSecrets.get("expiring-secret-run");
+      assert.ok(entry);
+      pruneRunSecrets(entry.expiresAt - 1);
+      assert.strictEqual(runSecrets.has("expiring-secret-run"), true);
+      pruneRunSecrets(entry.expiresAt);
+      assert.strictEqual(runSecrets.has("expiring-secret-run"), false);
+
+      runSecrets.set("expiring-secret-run", { values: { API_TOKEN: "tok-live-456" }, expiresAt: Date.now() - 1 });
+      await emitEvent("expiring-secret-run", "browser.navigation", { status: "completed" });
+      assert.strictEqual(runSecrets.has("expiring-secret-run"), false);
+      await request(app).post("/cancel").send({ run_id: "expiring-secret-run" });
+    });
+
     it("cancel without run_id returns 400", async () => {
       const response = await request(app).post("/cancel").send({});
       assert.strictEqual(response.status, 400);
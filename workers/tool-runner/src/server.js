@@ -13,6 +13,9 @@ app.use(express.json({ limit: "2mb" }));
 
 const PORT = resolvePort(process.env.TOOL_RUNNER_PORT || process.env.PORT, 8081, "tool-runner");
 const CONTROL_PLANE_URL = process.env.CONTROL_PLANE_URL || "http://localhost:8080";
+const CONTROL_PLANE_API_KEY = (process.env.CONTROL_PLANE_API_KEY || "").trim();
+const EVENT_SIGNING_SECRET = (process.env.EVENT_SIGNING_SECRET || "").trim();
+const EVENT_SIGNING_SERVICE = "tool_runner";
 const BROWSER_WORKER_URL = process.env.BROWSER_WORKER_URL || "http://localhost:8082";
 const BASE_URL = process.env.TOOL_RUNNER_URL || `http://localhost:${PORT}`;
 
@@ -93,6 +96,7 @@ const managedProcessRetentionMs = parseEnvInt("PROCESS_MANAGED_RETENTION_MS", 10
 const processStopGraceMs = parseEnvInt("PROCESS_STOP_GRACE_MS", 5000);
 const invocationCacheTTLms = parseEnvInt("TOOL_INVOCATION_CACHE_TTL_MS", 30 * 60 * 1000);
 const invocationCacheMaxEntries = parseEnvInt("TOOL_INVOCATION_CACHE_MAX_ENTRIES", 2000);
+const runSecretsTTLms = Math.max(60 * 1000, parseEnvInt("TOOL_RUN_SECRETS_TTL_MS", 30 * 60 * 1000));
 
 const managedProcesses = new Map();
 const invocationResultCache = new Map();
@@ -850,17 +854,126 @@ async function execWorkspaceProcess(runId, command, args, cwd, env, timeoutMs) {
   });
 }
 
+// Matches the control plane's internal/signing canonical request format.
+function signControlPlaneRequest(method, url, body) {
+  const timestamp = Math.floor(Date.now() / 1000);
+  const nonce = crypto.randomBytes(16).toString("hex");
+  const { pathname, search } = new URL(url);
+  const bodyHash = crypto.createHash("sha256").update(body).digest("hex");
+  const canonical = `${timestamp}\n${nonce}\n${method.toUpperCase()}\n${pathname}${search}\n${bodyHash}`;
+  const signature = crypto.createHmac("sha256", EVENT_SIGNING_SECRET).update(canonical).digest("hex");
+  return {
+    "X-Gavryn-Service": EVENT_SIGNING_SERVICE,
+    "X-Gavryn-Timestamp": String(timestamp),
+    "X-Gavryn-Nonce": nonce,
+    "X-Gavryn-Signature": `v1=${signature}`,
+  };
+}
+
+function controlPlaneHeaders(method, url, body) {
+  const headers = { "Content-Type": "application/json" };
+  if (CONTROL_PLANE_API_KEY) {
+    headers.Authorization = `Bearer ${CONTROL_PLANE_API_KEY}`;
+  }
+  if (EVENT_SIGNING_SECRET) {
+    Object.assign(headers, signControlPlaneRequest(method, url, body));
+  }
+  return headers;
+}
+
+// Matches the control plane's {{secret:NAME}} placeholders.
+const secretReferencePattern = /\{\{\s*secret:([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}/g;
+
+// Vault secret values each run's tools have used. The control plane sends
+// them apart from the tool input, and every event about the run has them
+// replaced with [secret:NAME]. They are dropped when the run's processes are
+// cleaned up, or TOOL_RUN_SECRETS_TTL_MS after the run's last tool call.
+const runSecrets = new Map();
+
+function rememberRunSecrets(runId, secrets) {
+  pruneRunSecrets();
+  const values = {};
+  if (secrets && typeof secrets === "object") {
+    for (const [name, value] of Object.entries(secrets)) {
+      if (typeof value === "string" && value !== "") {
+        values[name] = value;
+      }
+    }
+  }
+  if (Object.keys(values).length > 0) {
+    runSecrets.set(runId, {
+      values: { ...(runSecrets.get(runId)?.values || {}), ...values },
+      expiresAt: Date.now() + runSecretsTTLms,
+    });
+  }
+  return values;
+}
+
+function secretsForRun(runId) {
+  const entry = runSecrets.get(runId);
+  if (!entry) {
+    return undefined;
+  }
+  if (entry.expiresAt <= Date.now()) {
+    runSecrets.delete(runId);
+    return undefined;
+  }
+  return entry.values;
+}
+
+function pruneRunSecrets() {
+  const now = Date.now();
+  for (const [runId, entry] of runSecrets.entries()) {
+    if (entry.expiresAt <= now) {
+      runSecrets.delete(runId);
+    }
+  }
+}
+
+function resolveSecretReferences(value, secrets) {
+  if (typeof value === "string") {
+    return value.replace(secretReferencePattern, (match, name) => (Object.hasOwn(secrets, name) ? secrets[name] : match));
+  }
+  if (Array.isArray(value)) {
+    return value.map((item) => resolveSecretReferences(item, secrets));
+  }
+  if (value && typeof value === "object") {
+    return Object.fromEntries(Object.entries(value).map(([key, item]) => [key, resolveSecretReferences(item, secrets)]));
+  }
+  return value;
+}
+
+function scrubSecretValues(value, secrets) {
+  if (typeof value === "string") {
+    let scrubbed = value;
+    for (const [name, secretValue] of Object.entries(secrets)) {
+      scrubbed = scrubbed.split(secretValue).join(`[secret:${name}]`);
+    }
+    return scrubbed;
+  }
+  if (Array.isArray(value)) {
+    return value.map((item) => scrubSecretValues(item, secrets));
+  }
+  if (value && typeof value === "object") {
+    return Object.fromEntries(Object.entries(value).map(([key, item]) => [key, scrubSecretValues(item, secrets)]));
+  }
+  return value;
+}
+
 async function emitEvent(runId, type, payload) {
   try {
-    await fetch(`${CONTROL_PLANE_URL}/runs/${runId}/events`, {
+    const url = `${CONTROL_PLANE_URL}/runs/${runId}/events`;
+    const secrets = secretsForRun(runId);
+    const body = JSON.stringify({
+      type,
+      source: "tool_runner",
+      timestamp: new Date().toISOString(),
+      payload: secrets ? scrubSecretValues(payload, secrets) : payload,
+    });
+    await fetch(url, {
       method: "POST",
-      headers: { "Content-Type": "application/json" },
-      body: JSON.stringify({
-        type,
-        source: "tool_runner",
-        timestamp: new Date().toISOString(),
-        payload,
-      }),
+      headers: controlPlaneHeaders("POST", url, body),
+      body,
     });
   } catch (err) {
     console.error("failed to emit event", err);
@@ -951,6 +1064,7 @@ app.post("/runs/:runId/processes/cleanup", async (req, res) => {
   try {
     const processCleanup = await cleanupManagedProcessesForRun(runId, force);
     const browserCleanup = await cancelBrowserSessionForRun(runId);
+    runSecrets.delete(runId);
     res.json({
       status: "completed",
       output: {
@@ -974,7 +1088,8 @@ app.post("/tools/execute", async (req, res) => {
     invocation_id: rawInvocationID,
     idempotency_key: idempotencyKey,
     tool_name: requestedToolName,
-    input = {},
+    input: rawInput = {},
+    secrets,
     timeout_ms: timeoutMs,
     policy_context: policyContext = {},
   } = req.body || {};
@@ -1019,10 +1134,14 @@ app.post("/tools/execute", async (req, res) => {
     return originalJson(body);
   };
 
+  // The reported input keeps its {{secret:NAME}} placeholders; the tool runs
+  // with the values substituted.
+  const secretValues = rememberRunSecrets(runId, secrets);
+  const input = resolveSecretReferences(rawInput, secretValues);
   await emitEvent(runId, "tool.started", {
     tool_invocation_id: invocationId,
     tool_name: toolName,
-    input,
+    input: rawInput,
   });
 
   try {
@@ -1037,6 +1156,7 @@ app.post("/tools/execute", async (req, res) => {
           idempotency_key: invocationId,
           tool_name: toolName,
           input,
+          secrets: secretValues,
           timeout_ms: timeoutMs,
           policy_context: policyContext,
         }),
@@ -1564,6 +1684,7 @@ if (require.main === module) {
   app.listen(PORT, () => {
     console.log(`tool runner listening on ${PORT}`);
   });
+  setInterval(pruneRunSecrets, 60 * 1000).unref();
 }
 /* c8 ignore stop */
 
@@ -198,6 +198,48 @@ describe('Tool Runner Server', () => {
       assert.strictEqual(response.body.output.title, 'Test Page');
     });
 
+    it('substitutes vault secrets for the tool and keeps them out of events', async () => {
+      const events = [];
+      let browserBody;
+      global.fetch = async (url, options) => {
+        fetchCalls.push({ url, options });
+        if (url.includes('/runs/') && url.includes('/events')) {
+          events.push(JSON.parse(options.body));
+          return { ok: true };
+        }
+        if (url.includes('/tools/execute') && url.includes('3001')) {
+          browserBody = JSON.parse(options.body);
+          return {
+            ok: true,
+            json: async () => ({ output: { url: browserBody.input.url, links: [browserBody.input.url], status: 200 }, artifacts: [] })
+          };
+        }
+        return { ok: true };
+      };
+
+      await request(app)
+        .post('/tools/execute')
+        .send({
+          run_id: 'run-secret',
+          invocation_id: 'inv-secret',
+          tool_name: 'browser.navigate',
+          input: { url: 'https://example.com/?token={{secret:API_TOKEN}}&other={{secret:MISSING}}' },
+          secrets: { API_TOKEN: 'tok-live-123', EMPTY: '' }
+        })
+        .expect(200);
+
+      assert.strictEqual(browserBody.input.url, 'https://example.com/?token=tok-live-123&other={{secret:MISSING}}');
+      assert.deepStrictEqual(browserBody.secrets, { API_TOKEN: 'tok-live-123' });
+      const started = events.find((event) => event.type === 'tool.started');
+      assert.strictEqual(started.payload.input.url, 'https://example.com/?token={{secret:API_TOKEN}}&other={{secret:MISSING}}');
+      const completed = events.find((event) => event.type === 'tool.completed');
+      assert.strictEqual(completed.payload.output.url, 'https://example.com/?token=[secret:API_TOKEN]&other={{secret:MISSING}}');
+      assert.deepStrictEqual(completed.payload.output.links, [completed.payload.output.url]);
+      for (const event of events) {
+        assert.ok(!JSON.stringify(event).includes('tok-live-123'));
+      }
+    });
+
     it('browser.snapshot in allowlist proceeds', async () => {
       global.fetch = async (url, options) => {
         fetchCalls.push({ url, options });